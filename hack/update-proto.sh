@@ -0,0 +1,36 @@
+#!/usr/bin/env bash
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+# Generates the go bindings of the MetricSource plugin api, pkg/plugin/metricsource.pb.go, from
+# pkg/plugin/metricsource.proto.  protoc must be on the PATH.  protoc-gen-go is built at the
+# version of github.com/golang/protobuf locked in Gopkg.lock, so the bindings match the vendored
+# runtime they are compiled against.
+
+PROJECT_ROOT=$(cd "$(dirname "${BASH_SOURCE}")/.." && pwd)
+PROTO_DIR="${PROJECT_ROOT}/pkg/plugin"
+
+PROTOBUF_VERSION=$(grep -A10 'name = "github.com/golang/protobuf"' "${PROJECT_ROOT}/Gopkg.lock" | grep -m1 'version = ' | cut -d '"' -f2)
+if [[ -z "${PROTOBUF_VERSION}" ]]
+then
+  echo "unable to find the version of github.com/golang/protobuf in Gopkg.lock"
+  exit 1
+fi
+
+TOOLS_DIR=$(mktemp -d)
+trap 'rm -rf "${TOOLS_DIR}"' EXIT
+
+# protoc-gen-go is built in a module of its own, so it doesn't depend on the GOPATH
+cat > "${TOOLS_DIR}/go.mod" <<MOD
+module tools
+
+require github.com/golang/protobuf ${PROTOBUF_VERSION}
+MOD
+(cd "${TOOLS_DIR}" && GO111MODULE=on GOFLAGS=-mod=mod go build -o "${TOOLS_DIR}/protoc-gen-go" github.com/golang/protobuf/protoc-gen-go)
+
+protoc --plugin=protoc-gen-go="${TOOLS_DIR}/protoc-gen-go" \
+    --proto_path="${PROTO_DIR}" \
+    --go_out=plugins=grpc:"${OUTPUT_DIR:-${PROTO_DIR}}" \
+    "${PROTO_DIR}/metricsource.proto"
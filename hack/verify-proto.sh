@@ -0,0 +1,27 @@
+#!/usr/bin/env bash
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+PROJECT_ROOT=$(cd "$(dirname "${BASH_SOURCE}")/.." && pwd)
+
+GENERATED="${PROJECT_ROOT}/pkg/plugin/metricsource.pb.go"
+_tmp=$(mktemp -d)
+
+cleanup() {
+  rm -rf "${_tmp}"
+}
+trap "cleanup" EXIT SIGINT
+
+OUTPUT_DIR="${_tmp}" "${PROJECT_ROOT}/hack/update-proto.sh"
+echo "diffing ${GENERATED} against freshly generated bindings"
+ret=0
+diff -Naupr "${GENERATED}" "${_tmp}/metricsource.pb.go" || ret=$?
+if [[ $ret -eq 0 ]]
+then
+  echo "${GENERATED} up to date."
+else
+  echo "${GENERATED} is out of date. Please run hack/update-proto.sh"
+  exit 1
+fi
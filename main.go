@@ -7,23 +7,100 @@ package main
 
 import (
 	"flag"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"runtime"
+	"sync"
 	"time"
 
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/audit"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/custommetrics"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/instancemetadata"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/transport"
 	clientset "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned"
 	informers "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/informers/externalversions"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/controller"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/metriccache"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/plugin"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/policy"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/probe"
 	azureprovider "github.com/Azure/azure-k8s-metrics-adapter/pkg/provider"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/ratelimit"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/variables"
 	"github.com/golang/glog"
 	basecmd "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/cmd"
+	"github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"github.com/prometheus/client_golang/prometheus"
+	genericapiserver "k8s.io/apiserver/pkg/server"
 	"k8s.io/apiserver/pkg/util/logs"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
+var (
+	credentialsDir            string
+	authMode                  string
+	keyVaultSecretURL         string
+	keyVaultIdentityClientID  string
+	azureConfigFile           string
+	credentialsReloadInterval time.Duration
+	clientQPS                 float64
+	clientBurst               int
+	priorityClientQPS         float64
+	priorityClientBurst       int
+	priorityClients           []string
+	auditWebhookURL           string
+	auditWebhookFormat        string
+	auditWebhookAuthorization string
+	auditFlushInterval        time.Duration
+	exportMonitorResourceID   string
+	exportMonitorRegion       string
+	exportMonitorNamespace    string
+	exportMonitorInterval     time.Duration
+	pluginConfig              string
+	webhookAllowedHosts       []string
+	webhookTokenDir           string
+	monitorEndpoints          []string
+	monitorFailoverCooldown   time.Duration
+	monitorAPIVersion         string
+	endpointOverrides         credentials.Endpoints
+	applicationGatewayID      string
+	maintenanceWindows        []string
+	maxPinDuration            time.Duration
+	serviceHealthRegion       string
+	armQuotaThreshold         int
+	deletionGracePeriod       time.Duration
+	discoverExternalMetrics   bool
+	observeInterval           time.Duration
+	exporterAddress           string
+	ingestedMetricTTL         time.Duration
+	eventGridAddress          string
+	eventGridTLSCertFile      string
+	eventGridTLSKeyFile       string
+	detectInstanceMetadata    bool
+	clusterName               string
+	clusterResourceGroup      string
+	msiClientID               string
+	msiResourceID             string
+	imdsEndpoint              string
+	httpsProxy                string
+	noProxy                   string
+	caBundle                  string
+	ipFamily                  string
+
+	// metadata of the vm the adapter runs on, read once when first needed
+	instanceMetadataOnce sync.Once
+	instanceMetadata     instancemetadata.AzureConfig
+)
+
+// defaultExporterInterval is the interval the external metrics are evaluated at by the exporter
+// when --observe-interval isn't set
+const defaultExporterInterval = time.Minute
+
 func main() {
 	logs.InitLogs()
 	defer logs.FlushLogs()
@@ -33,27 +110,103 @@ func main() {
 	}
 
 	cmd := &basecmd.AdapterBase{}
+	cmd.Flags().StringVar(&credentialsDir, "credentials-dir", "", "directory of mounted credential files. When set secrets are never read from environment variables")
+	cmd.Flags().StringVar(&authMode, "auth-mode", "", "how the adapter authenticates to azure for local development: azcli uses the tokens of the azure cli, devicecode signs in with a device code. Credentials are read from the environment or --credentials-dir when empty")
+	cmd.Flags().StringVar(&keyVaultSecretURL, "keyvault-secret-url", "", "url of the key vault secret holding the client secret or certificate of the service principal, such as https://myvault.vault.azure.net/secrets/adapter. The vault is read with a managed identity and secrets are never read from environment variables")
+	cmd.Flags().StringVar(&keyVaultIdentityClientID, "keyvault-identity-client-id", "", "client id of the user assigned managed identity that reads --keyvault-secret-url. The system assigned identity is used when empty")
+	cmd.Flags().StringVar(&azureConfigFile, "azure-config-file", "", "cloud provider config, such as /etc/kubernetes/azure.json on AKS nodes, whose service principal or managed identity the adapter authenticates with. Its cloud and subscription default AZURE_ENVIRONMENT and SUBSCRIPTION_ID")
+	cmd.Flags().DurationVar(&credentialsReloadInterval, "credentials-reload-interval", 30*time.Second, "interval to check the credential files or key vault secret for changes")
+	cmd.Flags().Float64Var(&clientQPS, "client-qps", 0, "requests per second each client can make to the metrics apis. Zero disables the limit")
+	cmd.Flags().IntVar(&clientBurst, "client-burst", 20, "burst of requests each client can make to the metrics apis")
+	cmd.Flags().Float64Var(&priorityClientQPS, "priority-client-qps", 0, "requests per second each priority client can make to the metrics apis. Zero disables the limit")
+	cmd.Flags().IntVar(&priorityClientBurst, "priority-client-burst", 100, "burst of requests each priority client can make to the metrics apis")
+	cmd.Flags().StringSliceVar(&priorityClients, "priority-clients", ratelimit.DefaultPriorityUsers, "users, such as the horizontal pod autoscaler, that are limited by the priority client limits")
+	cmd.Flags().StringVar(&auditWebhookURL, "audit-webhook-url", "", "https endpoint that audit records of served metrics are posted to. Auditing is disabled when empty")
+	cmd.Flags().StringVar(&auditWebhookFormat, "audit-webhook-format", audit.FormatJSON, "format of the audit records posted to the webhook: json or eventhub")
+	cmd.Flags().StringVar(&auditWebhookAuthorization, "audit-webhook-authorization-file", "", "file containing the Authorization header sent to the audit webhook, such as a bearer or SAS token")
+	cmd.Flags().DurationVar(&auditFlushInterval, "audit-flush-interval", 5*time.Second, "interval that queued audit records are posted to the webhook")
+	cmd.Flags().StringVar(&exportMonitorResourceID, "export-monitor-resource-id", "", "id of the azure resource, such as the AKS cluster, that served metric values are exported to as azure monitor custom metrics. Exporting is disabled when empty")
+	cmd.Flags().StringVar(&exportMonitorRegion, "export-monitor-region", "", "azure region of the resource served metric values are exported to")
+	cmd.Flags().StringVar(&exportMonitorNamespace, "export-monitor-namespace", audit.DefaultMonitorNamespace, "azure monitor custom metric namespace served metric values are exported to")
+	cmd.Flags().DurationVar(&exportMonitorInterval, "export-monitor-interval", time.Minute, "interval that served metric values are aggregated and exported to azure monitor")
+	cmd.Flags().StringVar(&pluginConfig, "plugin-config", "", "yaml file listing the MetricSource plugins that serve external metrics of type plugin")
+	cmd.Flags().StringSliceVar(&webhookAllowedHosts, "webhook-allowed-hosts", []string{}, "hosts that external metrics of type webhook can call. Webhook metrics are disabled when empty")
+	cmd.Flags().StringVar(&webhookTokenDir, "webhook-token-dir", "", "directory of bearer token files that webhook metrics can reference by name")
+	cmd.Flags().StringSliceVar(&monitorEndpoints, "monitor-endpoints", []string{}, "regional azure resource manager endpoints azure monitor is queried through, primary first. The public endpoint is used when empty")
+	cmd.Flags().DurationVar(&monitorFailoverCooldown, "monitor-endpoint-failover-cooldown", time.Minute, "time an azure monitor endpoint is skipped after it fails")
+	cmd.Flags().StringVar(&monitorAPIVersion, "monitor-api-version", externalmetrics.DefaultMonitorAPIVersion, "azure monitor api version queried unless an external metric sets its own")
+	cmd.Flags().StringVar(&endpointOverrides.ResourceManager, "resource-manager-endpoint", "", "azure resource manager endpoint, such as a private endpoint. Defaults to the endpoint of the AZURE_ENVIRONMENT cloud")
+	cmd.Flags().StringVar(&endpointOverrides.AppInsights, "app-insights-endpoint", "", "application insights api endpoint. Defaults to the endpoint of the AZURE_ENVIRONMENT cloud")
+	cmd.Flags().StringVar(&endpointOverrides.LogAnalytics, "log-analytics-endpoint", "", "log analytics query api endpoint. Defaults to the endpoint of the AZURE_ENVIRONMENT cloud")
+	cmd.Flags().StringVar(&endpointOverrides.StorageSuffix, "storage-endpoint-suffix", "", "suffix of storage data plane endpoints. Defaults to the suffix of the AZURE_ENVIRONMENT cloud")
+	cmd.Flags().StringVar(&endpointOverrides.ServiceBusSuffix, "service-bus-endpoint-suffix", "", "suffix of service bus data plane endpoints. Defaults to the suffix of the AZURE_ENVIRONMENT cloud")
+	cmd.Flags().StringVar(&endpointOverrides.CosmosDBSuffix, "cosmosdb-endpoint-suffix", "", "suffix of cosmos db data plane endpoints. Defaults to the suffix of the AZURE_ENVIRONMENT cloud")
+	cmd.Flags().StringVar(&applicationGatewayID, "application-gateway-id", "", "resource id of the application gateway that serves the custom metrics of ingresses managed by the application gateway ingress controller")
+	cmd.Flags().StringSliceVar(&maintenanceWindows, "maintenance-windows", []string{}, "windows, written <from>/<until> in RFC3339, during which external metrics are frozen at their value before the window and azure is not queried")
+	cmd.Flags().StringVar(&serviceHealthRegion, "service-health-region", "", "azure region, such as westeurope, whose azure monitor incidents reported by azure service health make external metrics that fail serve their previous value. Disabled when empty")
+	cmd.Flags().IntVar(&armQuotaThreshold, "arm-quota-threshold", 1000, "azure resource manager reads remaining to a subscription below which its external metrics are queried less often, and their previous value served in between, so the adapter isn't throttled. Zero disables it")
+	cmd.Flags().DurationVar(&maxPinDuration, "max-pin-duration", 6*time.Hour, "longest time ahead an external metric can be pinned with the azure.com/pin annotation. Pins ending later are ignored")
+	cmd.Flags().DurationVar(&deletionGracePeriod, "deleted-metric-grace-period", 0, "time the last value of a deleted external metric is still served, with warnings, so an accidental deletion doesn't zero scaling. Disabled when zero")
+	cmd.Flags().BoolVar(&discoverExternalMetrics, "discover-external-metrics", true, "list the external metrics of every ExternalMetric in the discovery document of the external metrics api. Autoscalers query metrics by name, so clusters with many metrics can disable it to keep discovery small")
+	cmd.Flags().DurationVar(&observeInterval, "observe-interval", 0, "interval at which every external metric is evaluated, recording its value in the status of its ExternalMetric and the azure_metrics_adapter_external_metric_value gauge, to compare it with another metrics provider before registering the adapter as the external metrics api. Disabled when zero")
+	cmd.Flags().StringVar(&exporterAddress, "exporter-address", "", "address, such as :9100, the values of every external metric are served on over http as a prometheus scrape target at /metrics, instead of serving the metrics apis. They are evaluated every --observe-interval, 1m when zero. Disabled when empty")
+	cmd.Flags().DurationVar(&ingestedMetricTTL, "ingested-metric-ttl", 2*time.Minute, "time a custom metric value posted to the ingest path is served for")
+	cmd.Flags().StringVar(&eventGridAddress, "event-grid-address", "", "address, such as :8443, that azure event grid pushes the events of external metrics of type eventgrid to. Disabled when empty")
+	cmd.Flags().StringVar(&eventGridTLSCertFile, "event-grid-tls-cert-file", "", "file of the PEM encoded certificate the event grid endpoint is served with. Served over http when empty, such as behind an ingress terminating tls")
+	cmd.Flags().StringVar(&eventGridTLSKeyFile, "event-grid-tls-private-key-file", "", "file of the PEM encoded private key of the event grid certificate")
+	cmd.Flags().BoolVar(&detectInstanceMetadata, "detect-instance-metadata", false, "read the cloud, tenant and region of the node from azure instance metadata to default AZURE_ENVIRONMENT, AZURE_TENANT_ID, the regional azure monitor endpoint and the cluster variables of ExternalMetric specs")
+	cmd.Flags().StringVar(&clusterName, "cluster-name", "", "name of the AKS cluster, the {{ .ClusterName }} variable of ExternalMetric specs. Detected from the node resource group when empty")
+	cmd.Flags().StringVar(&clusterResourceGroup, "cluster-resource-group", "", "resource group of the AKS cluster, the {{ .ClusterResourceGroup }} variable of ExternalMetric specs. Detected from the node resource group when empty")
+	cmd.Flags().StringVar(&msiClientID, "msi-client-id", "", "client id of the user assigned managed identity to authenticate with when no service principal is configured, for nodes with many identities. Sets AZURE_CLIENT_ID")
+	cmd.Flags().StringVar(&msiResourceID, "msi-resource-id", "", "resource id of the user assigned managed identity to authenticate with when no service principal is configured, such as the identity bound to the adapter by aad-pod-identity. Sets AZURE_MSI_RESOURCE_ID")
+	cmd.Flags().StringVar(&imdsEndpoint, "imds-endpoint", "", "address of the azure instance metadata service managed identity tokens and instance metadata are read from. Defaults to http://169.254.169.254")
+	cmd.Flags().StringVar(&httpsProxy, "https-proxy", "", "proxy, such as http://proxy:3128, that azure is called through. Sets HTTPS_PROXY")
+	cmd.Flags().StringVar(&noProxy, "no-proxy", "", "comma separated hosts, domains and CIDRs called without the proxy, such as the kubernetes api server and 169.254.169.254. Sets NO_PROXY")
+	cmd.Flags().StringVar(&caBundle, "ca-bundle", "", "file of PEM encoded certificate authorities trusted in addition to the system's, such as the authority of a TLS intercepting proxy")
+	cmd.Flags().StringVar(&ipFamily, "ip-family", "", "ip family the secure port and event grid endpoint listen on: ipv4, ipv6, or both when empty. With ipv6 the secure port binds to :: unless --bind-address is set")
 	cmd.Flags().AddGoFlagSet(flag.CommandLine)
 	cmd.Flags().Parse(os.Args)
 
 	stopCh := make(chan struct{})
 	defer close(stopCh)
 
+	applyProxy()
+	applyIPFamily(cmd)
+	applyIMDSEndpoint()
+	applyMSIClientID()
+	applyMSIResourceID()
+	applyInstanceMetadata()
+	credentialSource := newCredentialSource(stopCh)
+	specVariables := newSpecVariables()
+
 	metriccache := metriccache.NewMetricCache()
 
 	// start and run contoller components
-	controller, adapterInformerFactory := newController(cmd, metriccache)
+	controller, adapterInformerFactory := newController(cmd, metriccache, specVariables)
+	policyEnforcer := newPolicyEnforcer(adapterInformerFactory)
+	credentialPool := newCredentialPool(cmd, adapterInformerFactory)
+	credentialPool.WatchSecrets(stopCh)
+	prober := newProber(cmd, adapterInformerFactory)
 	go adapterInformerFactory.Start(stopCh)
 	go controller.Run(2, time.Second, stopCh)
 
 	//setup and run metric server
-	setupAzureProvider(cmd, metriccache)
+	if exporterAddress == "" {
+		setupHandlerChain(cmd, credentialSource, stopCh)
+	}
+	azureProvider := setupAzureProvider(cmd, metriccache, policyEnforcer, credentialSource, credentialPool, specVariables, stopCh)
+	go prober.Run(azureProvider, stopCh)
+	serveEventGrid(metriccache, credentialPool, stopCh)
+	if exporterAddress != "" {
+		serveExporter()
+		return
+	}
 	if err := cmd.Run(stopCh); err != nil {
 		glog.Fatalf("Unable to run Azure metrics adapter: %v", err)
 	}
 }
 
-func setupAzureProvider(cmd *basecmd.AdapterBase, metricsCache *metriccache.MetricCache) {
+func setupAzureProvider(cmd *basecmd.AdapterBase, metricsCache *metriccache.MetricCache, policyEnforcer *policy.Enforcer, credentialSource credentials.Source, credentialPool *azureprovider.CredentialPool, specVariables variables.Variables, stopCh <-chan struct{}) provider.MetricsProvider {
 	mapper, err := cmd.RESTMapper()
 	if err != nil {
 		glog.Fatalf("unable to construct discovery REST mapper: %v", err)
@@ -64,19 +217,402 @@ func setupAzureProvider(cmd *basecmd.AdapterBase, metricsCache *metriccache.Metr
 		glog.Fatalf("unable to construct dynamic k8s client: %v", err)
 	}
 
+	if err := externalmetrics.ValidateMonitorAPIVersion(monitorAPIVersion); err != nil {
+		glog.Fatalf("unable to configure azure monitor: %v", err)
+	}
+
+	endpoints, err := endpointOverrides.Resolve()
+	if err != nil {
+		glog.Fatalf("unable to resolve azure endpoints: %v", err)
+	}
+
+	// monitor queries go through the resource manager endpoint, or the regional endpoint of the
+	// node when it is detected, unless regional endpoints are listed
+	if len(monitorEndpoints) == 0 {
+		location := ""
+		if detectInstanceMetadata {
+			location = getInstanceMetadata().Location
+		}
+		monitorEndpoints = externalmetrics.RegionalEndpoints(location, endpoints.ResourceManager)
+		glog.V(2).Infof("querying azure monitor through %v", monitorEndpoints)
+	}
+
 	defaultSubscriptionID := getDefaultSubscriptionID()
-	customMetricsClient := custommetrics.NewClient()
+	customMetricsClient := custommetrics.NewClient(credentialSource, endpoints.AppInsights, endpoints.AppInsightsResource)
 
+	armQuota := externalmetrics.NewARMQuota(armQuotaThreshold)
 	azureExternalClientFactory := externalmetrics.AzureExternalMetricClientFactory{
 		DefaultSubscriptionID: defaultSubscriptionID,
+		Credentials:           credentialSource,
+		Plugins:               newPluginRegistry(),
+		Webhooks: externalmetrics.WebhookOptions{
+			AllowedHosts: webhookAllowedHosts,
+			TokenDir:     webhookTokenDir,
+		},
+		MonitorEndpoints:  externalmetrics.NewMonitorEndpoints(monitorEndpoints, monitorFailoverCooldown).TrackQuota(armQuota),
+		MonitorAPIVersion: monitorAPIVersion,
+		Endpoints:         endpoints,
+		EndpointOverrides: endpointOverrides,
+		ARMQuota:          armQuota,
+		CostCache:         externalmetrics.NewCostCache(),
 	}
 
-	azureProvider := azureprovider.NewAzureProvider(defaultSubscriptionID, mapper, dynamicClient, customMetricsClient, azureExternalClientFactory, metricsCache)
+	rawResponses := azureprovider.NewRawResponses()
+	statuses := newMetricStatuses(cmd, stopCh)
+	apiCosts := azureprovider.NewAPICosts()
+	ingestedMetrics := azureprovider.NewIngestedMetrics(ingestedMetricTTL)
+	azureProvider := azureprovider.NewAzureProvider(azureprovider.AzureProviderOptions{
+		DefaultSubscriptionID: defaultSubscriptionID,
+		Mapper:                mapper,
+		KubeClient:            dynamicClient,
+		AppInsightsClient:     customMetricsClient,
+		AzureClientFactory:    azureExternalClientFactory,
+		MetricCache:           metricsCache,
+		PolicyEnforcer:        policyEnforcer,
+		SubscriptionLister:    externalmetrics.NewSubscriptionLister(credentialSource, endpoints.ResourceManager),
+		ResourceLister:        externalmetrics.NewResourceLister(credentialSource, endpoints.ResourceManager),
+		ApplicationGatewayID:  applicationGatewayID,
+		AlertChecker:          externalmetrics.NewAlertChecker(credentialSource, endpoints.ResourceManager),
+		ServiceHealth:         newServiceHealth(credentialSource, endpoints.ResourceManager),
+		ARMQuota:              armQuota,
+		MaintenanceWindows:    newMaintenanceWindows(),
+		MaxPinDuration:        maxPinDuration,
+		RawResponses:          rawResponses,
+		Statuses:              statuses,
+		APICosts:              apiCosts,
+		DeletionGracePeriod:   deletionGracePeriod,
+		Undiscovered:          !discoverExternalMetrics,
+		IngestedMetrics:       ingestedMetrics,
+		CredentialPool:        credentialPool,
+	})
 	cmd.WithCustomMetrics(azureProvider)
 	cmd.WithExternalMetrics(azureProvider)
+	if interval := evaluationInterval(); interval > 0 {
+		go azureprovider.NewObserver(azureProvider, metricsCache, statuses).Run(interval, stopCh)
+	}
+	if exporterAddress != "" {
+		// the exporter serves none of the apis of the adapter
+		return azureProvider
+	}
+
+	// the admission webhook is served behind the same authn/authz as the metrics apis
+	server, err := cmd.Server()
+	if err != nil {
+		glog.Fatalf("unable to construct metrics adapter server: %v", err)
+	}
+	server.GenericAPIServer.Handler.NonGoRestfulMux.Handle(policy.AdmissionPath, policy.NewAdmissionHandler(policyEnforcer, defaultSubscriptionID, specVariables, dynamicClient))
+	server.GenericAPIServer.Handler.NonGoRestfulMux.HandlePrefix(azureprovider.RawResponsePath, rawResponses)
+	server.GenericAPIServer.Handler.NonGoRestfulMux.Handle(azureprovider.APICostPath, apiCosts)
+	server.GenericAPIServer.Handler.NonGoRestfulMux.HandlePrefix(azureprovider.IngestPath, ingestedMetrics)
+	return azureProvider
+}
+
+func setupHandlerChain(cmd *basecmd.AdapterBase, credentialSource credentials.Source, stopCh <-chan struct{}) {
+	config, err := cmd.Config()
+	if err != nil {
+		glog.Fatalf("unable to construct metrics adapter config: %v", err)
+	}
+
+	limiter := ratelimit.NewLimiter(ratelimit.Limit{QPS: clientQPS, Burst: clientBurst},
+		ratelimit.Limit{QPS: priorityClientQPS, Burst: priorityClientBurst},
+		priorityClients)
+	auditSink := newAuditSink(credentialSource, stopCh)
+
+	// the filters are applied inside the default chain so the requesting user is known
+	buildHandlerChain := config.GenericConfig.BuildHandlerChainFunc
+	config.GenericConfig.BuildHandlerChainFunc = func(apiHandler http.Handler, c *genericapiserver.Config) http.Handler {
+		handler := ratelimit.WithRateLimit(apiHandler, limiter)
+		handler = audit.WithAudit(handler, auditSink)
+		return buildHandlerChain(handler, c)
+	}
+}
+
+func newAuditSink(credentialSource credentials.Source, stopCh <-chan struct{}) audit.Sink {
+	sinks := audit.Sinks{}
+	if auditWebhookURL != "" {
+		sink, err := audit.NewWebhookSink(auditWebhookURL, auditWebhookFormat, auditWebhookAuthorization)
+		if err != nil {
+			glog.Fatalf("unable to configure audit webhook: %v", err)
+		}
+
+		go sink.Run(auditFlushInterval, stopCh)
+		sinks = append(sinks, sink)
+	}
+
+	if exportMonitorResourceID != "" {
+		sink, err := audit.NewMonitorSink(exportMonitorRegion, exportMonitorResourceID, exportMonitorNamespace, credentialSource)
+		if err != nil {
+			glog.Fatalf("unable to configure azure monitor export: %v", err)
+		}
+
+		go sink.Run(exportMonitorInterval, stopCh)
+		sinks = append(sinks, sink)
+	}
+
+	if len(sinks) == 0 {
+		return nil
+	}
+	return sinks
+}
+
+// serveEventGrid serves the endpoint azure event grid pushes events to on its own listener, as event
+// grid can't authenticate to the metrics apis
+func serveEventGrid(metricsCache *metriccache.MetricCache, credentialPool *azureprovider.CredentialPool, stopCh <-chan struct{}) {
+	if eventGridAddress == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(azureprovider.EventGridPath, azureprovider.NewEventGrid(metricsCache, credentialPool))
+	server := &http.Server{
+		Addr:         eventGridAddress,
+		Handler:      mux,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+	go func() {
+		<-stopCh
+		server.Close()
+	}()
+
+	listener, err := net.Listen(listenNetwork(), eventGridAddress)
+	if err != nil {
+		glog.Fatalf("unable to serve the event grid endpoint: %v", err)
+	}
+	go func() {
+		var err error
+		if eventGridTLSCertFile != "" {
+			err = server.ServeTLS(listener, eventGridTLSCertFile, eventGridTLSKeyFile)
+		} else {
+			err = server.Serve(listener)
+		}
+		if err != http.ErrServerClosed {
+			glog.Fatalf("unable to serve the event grid endpoint: %v", err)
+		}
+	}()
+	glog.V(0).Infof("serving the event grid endpoint on %s", eventGridAddress)
+}
+
+// evaluationInterval returns the interval every external metric is evaluated at by the observer, or
+// zero when they are only evaluated when requested
+func evaluationInterval() time.Duration {
+	if exporterAddress != "" && observeInterval == 0 {
+		return defaultExporterInterval
+	}
+	return observeInterval
+}
+
+// serveExporter serves the values of the external metrics recorded by the observer, with the other
+// metrics of the adapter, as a prometheus scrape target
+func serveExporter() {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", prometheus.Handler())
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	server := &http.Server{
+		Handler:      mux,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+
+	listener, err := net.Listen(listenNetwork(), exporterAddress)
+	if err != nil {
+		glog.Fatalf("unable to serve the exporter: %v", err)
+	}
+	glog.V(0).Infof("serving external metric values to prometheus on %s", exporterAddress)
+	if err := server.Serve(listener); err != nil {
+		glog.Fatalf("unable to serve the exporter: %v", err)
+	}
+}
+
+func newServiceHealth(credentialSource credentials.Source, resourceManager string) externalmetrics.ServiceHealth {
+	if serviceHealthRegion == "" {
+		return nil
+	}
+	return externalmetrics.NewServiceHealth(credentialSource, resourceManager, serviceHealthRegion)
+}
+
+func newMaintenanceWindows() externalmetrics.MaintenanceDefinition {
+	maintenance, err := externalmetrics.ParseMaintenanceIntervals(maintenanceWindows)
+	if err != nil {
+		glog.Fatalf("unable to configure maintenance windows: %v", err)
+	}
+	return maintenance
+}
+
+func newPluginRegistry() *plugin.Registry {
+	if pluginConfig == "" {
+		return nil
+	}
+
+	registry, err := plugin.LoadRegistry(pluginConfig)
+	if err != nil {
+		glog.Fatalf("unable to configure metric source plugins: %v", err)
+	}
+	return registry
+}
+
+func newCredentialSource(stopCh <-chan struct{}) credentials.Source {
+	if authMode != "" {
+		return newDeveloperCredentialSource()
+	}
+	if keyVaultSecretURL != "" {
+		return newKeyVaultCredentialSource(stopCh)
+	}
+	if azureConfigFile != "" {
+		return newCloudConfigCredentialSource(stopCh)
+	}
+	if credentialsDir == "" {
+		return credentials.NewEnvironmentSource()
+	}
+
+	// hardened mode: refuse to start if secrets have been placed in the environment
+	if err := credentials.CheckNoSecretEnvironment(); err != nil {
+		glog.Fatalf("unable to use credentials from %s: %v", credentialsDir, err)
+	}
+
+	glog.V(2).Infof("reading azure credentials from files in %s", credentialsDir)
+	fileSource := credentials.NewFileSource(credentialsDir)
+	fileSource.Watch(credentialsReloadInterval, stopCh)
+	return fileSource
 }
 
-func newController(cmd *basecmd.AdapterBase, metricsCache *metriccache.MetricCache) (*controller.Controller, informers.SharedInformerFactory) {
+// newKeyVaultCredentialSource reads the secret of the service principal from key vault so it can be
+// rotated centrally without redeploying the adapter
+func newKeyVaultCredentialSource(stopCh <-chan struct{}) credentials.Source {
+	if credentialsDir != "" {
+		glog.Fatalf("--keyvault-secret-url can't be used with --credentials-dir")
+	}
+	if azureConfigFile != "" {
+		glog.Fatalf("--keyvault-secret-url can't be used with --azure-config-file")
+	}
+	if err := credentials.CheckNoSecretEnvironment(); err != nil {
+		glog.Fatalf("unable to use credentials from %s: %v", keyVaultSecretURL, err)
+	}
+
+	source, err := credentials.NewKeyVaultSource(keyVaultSecretURL, keyVaultIdentityClientID)
+	if err != nil {
+		glog.Fatalf("unable to use credentials from key vault: %v", err)
+	}
+	if err := source.Load(); err != nil {
+		glog.Fatalf("unable to read key vault secret %s: %v", keyVaultSecretURL, err)
+	}
+
+	glog.V(2).Infof("reading azure credentials from key vault secret %s", keyVaultSecretURL)
+	source.Watch(credentialsReloadInterval, stopCh)
+	return source
+}
+
+// newCloudConfigCredentialSource reads the credentials of the cluster from the cloud provider config
+// of the nodes, which also defaults the cloud and subscription of the adapter
+func newCloudConfigCredentialSource(stopCh <-chan struct{}) credentials.Source {
+	if credentialsDir != "" {
+		glog.Fatalf("--azure-config-file can't be used with --credentials-dir")
+	}
+
+	source := credentials.NewCloudConfigSource(azureConfigFile)
+	if err := source.Load(); err != nil {
+		glog.Fatalf("unable to read cloud provider config %s: %v", azureConfigFile, err)
+	}
+	if os.Getenv("AZURE_ENVIRONMENT") == "" && source.Cloud() != "" {
+		glog.V(2).Infof("using azure cloud %s from %s", source.Cloud(), azureConfigFile)
+		os.Setenv("AZURE_ENVIRONMENT", source.Cloud())
+	}
+	if os.Getenv("SUBSCRIPTION_ID") == "" && source.SubscriptionID() != "" {
+		os.Setenv("SUBSCRIPTION_ID", source.SubscriptionID())
+	}
+
+	glog.V(2).Infof("reading azure credentials from %s", azureConfigFile)
+	source.Watch(credentialsReloadInterval, stopCh)
+	return source
+}
+
+// newDeveloperCredentialSource authenticates as the developer running the adapter outside of a
+// cluster.  The azure cli's subscription is the default subscription unless SUBSCRIPTION_ID is set.
+func newDeveloperCredentialSource() credentials.Source {
+	if credentialsDir != "" {
+		glog.Fatalf("--auth-mode can't be used with --credentials-dir")
+	}
+
+	switch authMode {
+	case "azcli":
+		source := credentials.NewCLISource()
+		if os.Getenv("SUBSCRIPTION_ID") == "" {
+			subscriptionID, err := source.SubscriptionID()
+			if err != nil {
+				glog.Fatalf("unable to read the subscription of the azure cli: %v", err)
+			}
+			os.Setenv("SUBSCRIPTION_ID", subscriptionID)
+		}
+		glog.V(2).Infof("using the azure cli for azure authentication")
+		return source
+	case "devicecode":
+		glog.V(2).Infof("using device code login for azure authentication")
+		return credentials.NewDeviceCodeSource(os.Getenv(credentials.TenantID), os.Getenv(credentials.ClientID))
+	default:
+		glog.Fatalf("unknown --auth-mode %s, use azcli or devicecode", authMode)
+		return nil
+	}
+}
+
+func newPolicyEnforcer(adapterInformerFactory informers.SharedInformerFactory) *policy.Enforcer {
+	// request the informer before the factory is started so it is included in the start
+	policyInformer := adapterInformerFactory.Azure().V1alpha2().AdapterPolicies()
+	return policy.NewEnforcer(policyInformer.Lister(), policyInformer.Informer().HasSynced)
+}
+
+func newCredentialPool(cmd *basecmd.AdapterBase, adapterInformerFactory informers.SharedInformerFactory) *azureprovider.CredentialPool {
+	dynamicClient, err := cmd.DynamicClient()
+	if err != nil {
+		glog.Fatalf("unable to construct dynamic k8s client: %v", err)
+	}
+
+	// request the informer before the factory is started so it is included in the start
+	credentialInformer := adapterInformerFactory.Azure().V1alpha2().AzureCredentials()
+	credentialPool := azureprovider.NewCredentialPool(credentialInformer.Lister(), credentialInformer.Informer().HasSynced, dynamicClient)
+	credentialInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: credentialPool.CredentialDeleted,
+	})
+	return credentialPool
+}
+
+func newProber(cmd *basecmd.AdapterBase, adapterInformerFactory informers.SharedInformerFactory) *probe.Prober {
+	clientConfig, err := cmd.ClientConfig()
+	if err != nil {
+		glog.Fatalf("unable to construct client config: %s", err)
+	}
+	adapterClientSet, err := clientset.NewForConfig(clientConfig)
+	if err != nil {
+		glog.Fatalf("unable to construct client to update metric probes: %v", err)
+	}
+	coreClient, err := corev1client.NewForConfig(clientConfig)
+	if err != nil {
+		glog.Fatalf("unable to construct client to record metric probe events: %v", err)
+	}
+
+	// request the informer before the factory is started so it is included in the start
+	probeInformer := adapterInformerFactory.Azure().V1alpha2().MetricProbes()
+	return probe.NewProber(probeInformer.Lister(), probeInformer.Informer().HasSynced, adapterClientSet.AzureV1alpha2(), coreClient)
+}
+
+// newMetricStatuses writes the status of ExternalMetrics every few seconds
+func newMetricStatuses(cmd *basecmd.AdapterBase, stopCh <-chan struct{}) *azureprovider.MetricStatuses {
+	clientConfig, err := cmd.ClientConfig()
+	if err != nil {
+		glog.Fatalf("unable to construct client config: %s", err)
+	}
+	adapterClientSet, err := clientset.NewForConfig(clientConfig)
+	if err != nil {
+		glog.Fatalf("unable to construct client to update external metrics: %v", err)
+	}
+
+	statuses := azureprovider.NewMetricStatuses(adapterClientSet.AzureV1alpha2())
+	go statuses.Run(5*time.Second, stopCh)
+	return statuses
+}
+
+func newController(cmd *basecmd.AdapterBase, metricsCache *metriccache.MetricCache, specVariables variables.Variables) (*controller.Controller, informers.SharedInformerFactory) {
 	clientConfig, err := cmd.ClientConfig()
 	if err != nil {
 		glog.Fatalf("unable to construct client config: %s", err)
@@ -86,13 +622,22 @@ func newController(cmd *basecmd.AdapterBase, metricsCache *metriccache.MetricCac
 		glog.Fatalf("unable to construct lister client to initialize provider: %v", err)
 	}
 
+	dynamicClient, err := cmd.DynamicClient()
+	if err != nil {
+		glog.Fatalf("unable to construct client to read the variables of specs: %v", err)
+	}
+
 	adapterInformerFactory := informers.NewSharedInformerFactory(adapterClientSet, time.Second*30)
 	handler := controller.NewHandler(adapterInformerFactory.Azure().V1alpha2().ExternalMetrics().Lister(),
 		adapterInformerFactory.Azure().V1alpha2().CustomMetrics().Lister(),
-		metricsCache)
+		adapterInformerFactory.Azure().V1alpha2().ExternalMetricGroups().Lister(),
+		metricsCache,
+		specVariables,
+		dynamicClient)
 
 	controller := controller.NewController(adapterInformerFactory.Azure().V1alpha2().ExternalMetrics(),
-		adapterInformerFactory.Azure().V1alpha2().CustomMetrics(), &handler)
+		adapterInformerFactory.Azure().V1alpha2().CustomMetrics(),
+		adapterInformerFactory.Azure().V1alpha2().ExternalMetricGroups(), &handler)
 
 	return controller, adapterInformerFactory
 }
@@ -103,17 +648,188 @@ func getDefaultSubscriptionID() string {
 	if subscriptionID == "" {
 		glog.V(2).Info("Looking up subscription ID via instance metadata")
 		//fallback to trying azure instance meta data
+		subscriptionID = getInstanceMetadata().SubscriptionID
+	}
+
+	if subscriptionID == "" {
+		glog.V(0).Info("Default Azure Subscription is not set.  You must provide subscription id via HPA lables, set an environment variable, or enable MSI.  See docs for more details")
+	}
+
+	return subscriptionID
+}
+
+func getInstanceMetadata() instancemetadata.AzureConfig {
+	instanceMetadataOnce.Do(func() {
 		azureConfig, err := instancemetadata.GetAzureConfig()
 		if err != nil {
 			glog.Errorf("Unable to get azure config from MSI: %v", err)
 		}
+		instanceMetadata = azureConfig
+	})
+	return instanceMetadata
+}
 
-		subscriptionID = azureConfig.SubscriptionID
+// applyMSIClientID selects the user assigned managed identity the adapter authenticates with.  The
+// identity is named by AZURE_CLIENT_ID, which a service principal uses for its own client id.
+func applyMSIClientID() {
+	if msiClientID == "" {
+		return
+	}
+	if os.Getenv(credentials.ClientSecret) != "" || os.Getenv(credentials.CertificatePath) != "" {
+		glog.Fatalf("--msi-client-id can't be used with the client secret or certificate of a service principal")
+	}
+	if os.Getenv(credentials.FederatedTokenFile) != "" {
+		glog.Fatalf("--msi-client-id can't be used with workload identity")
+	}
+	if keyVaultSecretURL != "" {
+		// AZURE_CLIENT_ID is the service principal whose secret is in the vault
+		glog.Fatalf("--msi-client-id can't be used with --keyvault-secret-url, use --keyvault-identity-client-id")
+	}
+	if azureConfigFile != "" {
+		// the identity is the userAssignedIdentityID of the config
+		glog.Fatalf("--msi-client-id can't be used with --azure-config-file")
 	}
 
-	if subscriptionID == "" {
-		glog.V(0).Info("Default Azure Subscription is not set.  You must provide subscription id via HPA lables, set an environment variable, or enable MSI.  See docs for more details")
+	glog.V(2).Infof("using user assigned managed identity %s", msiClientID)
+	os.Setenv(credentials.ClientID, msiClientID)
+}
+
+// applyMSIResourceID selects the user assigned managed identity the adapter authenticates with by
+// its resource id
+func applyMSIResourceID() {
+	if msiResourceID == "" {
+		return
+	}
+	if msiClientID != "" {
+		glog.Fatalf("--msi-resource-id can't be used with --msi-client-id")
+	}
+	if os.Getenv(credentials.ClientSecret) != "" || os.Getenv(credentials.CertificatePath) != "" {
+		glog.Fatalf("--msi-resource-id can't be used with the client secret or certificate of a service principal")
+	}
+	if os.Getenv(credentials.FederatedTokenFile) != "" {
+		glog.Fatalf("--msi-resource-id can't be used with workload identity")
 	}
 
-	return subscriptionID
+	glog.V(2).Infof("using user assigned managed identity %s", msiResourceID)
+	os.Setenv(credentials.MSIResourceID, msiResourceID)
+}
+
+// applyProxy sends the requests of the adapter through a proxy and trusts the certificate
+// authorities of the bundle, before anything calls azure
+func applyProxy() {
+	if httpsProxy != "" {
+		if proxyURL, err := url.Parse(httpsProxy); err == nil {
+			// the proxy's credentials are not logged
+			proxyURL.User = nil
+			glog.V(2).Infof("calling azure through proxy %s", proxyURL)
+		}
+		os.Setenv("HTTPS_PROXY", httpsProxy)
+	}
+	if noProxy != "" {
+		os.Setenv("NO_PROXY", noProxy)
+	}
+	if caBundle != "" {
+		if err := transport.AddCABundle(caBundle); err != nil {
+			glog.Fatalf("unable to load --ca-bundle: %v", err)
+		}
+		glog.V(2).Infof("trusting the certificate authorities of %s", caBundle)
+	}
+}
+
+// applyIPFamily binds the secure port, which serves the metrics apis, admission webhooks and health
+// checks, to the ip family of the cluster.  Listening on the unspecified address of either family
+// accepts both in dual-stack clusters, so the family only needs to be set for IPv6-only clusters
+// or to keep the adapter off one family.
+func applyIPFamily(cmd *basecmd.AdapterBase) {
+	switch ipFamily {
+	case "":
+	case "ipv4":
+		cmd.SecureServing.BindNetwork = "tcp4"
+	case "ipv6":
+		cmd.SecureServing.BindNetwork = "tcp6"
+		if !cmd.Flags().Changed("bind-address") {
+			cmd.SecureServing.BindAddress = net.IPv6unspecified
+		}
+	default:
+		glog.Fatalf("unknown --ip-family %s, use ipv4 or ipv6", ipFamily)
+	}
+
+	// the generated certificate is valid for the loopback addresses of both families, as the
+	// adapter's clients connect to whichever localhost resolves to
+	loopback := []net.IP{net.ParseIP("127.0.0.1"), net.IPv6loopback}
+	if err := cmd.SecureServing.MaybeDefaultWithSelfSignedCerts("localhost", nil, loopback); err != nil {
+		glog.Fatalf("unable to create self-signed certificates: %v", err)
+	}
+}
+
+// listenNetwork returns the network the adapter's own listeners listen on for --ip-family
+func listenNetwork() string {
+	switch ipFamily {
+	case "ipv4":
+		return "tcp4"
+	case "ipv6":
+		return "tcp6"
+	default:
+		return "tcp"
+	}
+}
+
+// applyIMDSEndpoint points managed identity and instance metadata requests at another address
+// than the link local instance metadata service
+func applyIMDSEndpoint() {
+	if imdsEndpoint == "" {
+		return
+	}
+
+	glog.V(2).Infof("using instance metadata service %s", imdsEndpoint)
+	credentials.SetIMDSEndpoint(imdsEndpoint)
+	instancemetadata.SetEndpoint(imdsEndpoint)
+}
+
+// applyInstanceMetadata defaults the cloud and tenant to those of the node so they don't need to
+// be configured on AKS.  Values set in the environment are kept.
+func applyInstanceMetadata() {
+	if !detectInstanceMetadata {
+		return
+	}
+	if os.Getenv("AZURE_ENVIRONMENT") != "" && os.Getenv(credentials.TenantID) != "" {
+		return
+	}
+
+	azureConfig := getInstanceMetadata()
+	if os.Getenv("AZURE_ENVIRONMENT") == "" && azureConfig.Environment != "" {
+		glog.V(2).Infof("using azure cloud %s from instance metadata", azureConfig.Environment)
+		os.Setenv("AZURE_ENVIRONMENT", azureConfig.Environment)
+	}
+	if os.Getenv(credentials.TenantID) == "" && azureConfig.TenantID != "" {
+		glog.V(2).Infof("using azure tenant %s from instance metadata", azureConfig.TenantID)
+		os.Setenv(credentials.TenantID, azureConfig.TenantID)
+	}
+}
+
+// newSpecVariables returns the variables ExternalMetric specs can reference, from the flags and
+// instance metadata.  Variables that are not known are left out.
+func newSpecVariables() variables.Variables {
+	specVariables := variables.Variables{}
+	set := func(name string, value string) {
+		if value != "" {
+			specVariables[name] = value
+		}
+	}
+
+	set(variables.SubscriptionID, getDefaultSubscriptionID())
+	if detectInstanceMetadata {
+		azureConfig := getInstanceMetadata()
+		set(variables.Location, azureConfig.Location)
+		set(variables.NodeResourceGroup, azureConfig.ResourceGroup)
+		if resourceGroup, name, found := azureConfig.AKSCluster(); found {
+			set(variables.ClusterResourceGroup, resourceGroup)
+			set(variables.ClusterName, name)
+		}
+	}
+	set(variables.ClusterResourceGroup, clusterResourceGroup)
+	set(variables.ClusterName, clusterName)
+
+	glog.V(2).Infof("ExternalMetric spec variables: %v", specVariables)
+	return specVariables
 }
@@ -0,0 +1,47 @@
+package v1alpha2
+
+import (
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +genclient:noStatus
+// +genclient:skipVerbs=patch
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AdapterPolicy restricts the Azure scopes that metrics in a set of namespaces are allowed to query
+type AdapterPolicy struct {
+	// TypeMeta is the metadata for the resource, like kind and apiversion
+	meta_v1.TypeMeta `json:",inline"`
+
+	// ObjectMeta contains the metadata for the particular object (name, self link, labels, etc)
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec is the custom resource spec
+	Spec AdapterPolicySpec `json:"spec"`
+}
+
+// AdapterPolicySpec is the spec for a AdapterPolicy resource.
+// An empty list of scopes permits any value for that scope.
+type AdapterPolicySpec struct {
+	// Namespaces the policy applies to. Use "*" to match every namespace
+	Namespaces []string `json:"namespaces"`
+	// Subscriptions that metrics in the namespaces may query
+	Subscriptions []string `json:"subscriptions,omitempty"`
+	// ResourceGroups that metrics in the namespaces may query
+	ResourceGroups []string `json:"resourceGroups,omitempty"`
+	// ResourceTypes that metrics in the namespaces may query in the form
+	// of {resourceProviderNamespace}/{resourceType}, ex: Microsoft.ServiceBus/namespaces
+	ResourceTypes []string `json:"resourceTypes,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AdapterPolicyList is a list of AdapterPolicy resources
+type AdapterPolicyList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata"`
+
+	Items []AdapterPolicy `json:"items"`
+}
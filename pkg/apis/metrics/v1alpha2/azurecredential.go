@@ -0,0 +1,60 @@
+package v1alpha2
+
+import (
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:noStatus
+// +genclient:skipVerbs=patch
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AzureCredential is a named credential that ExternalMetrics in its namespace reference to query
+// Azure with an identity other than the adapter's
+type AzureCredential struct {
+	// TypeMeta is the metadata for the resource, like kind and apiversion
+	meta_v1.TypeMeta `json:",inline"`
+
+	// ObjectMeta contains the metadata for the particular object (name, self link, labels, etc)
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec is the custom resource spec
+	Spec AzureCredentialSpec `json:"spec"`
+}
+
+// AzureCredentialSpec is the spec for a AzureCredential resource.  Without a client secret or
+// certificate the credential is a user assigned managed identity of the adapter's node or pod identity.
+type AzureCredentialSpec struct {
+	// ClientID of the managed identity, or of the service principal when clientSecretRef or
+	// clientCertificateRef is set
+	ClientID string `json:"clientID"`
+	// TenantID of the service principal, required with clientSecretRef or clientCertificateRef
+	TenantID string `json:"tenantID,omitempty"`
+	// ClientSecretRef names the secret, in the namespace of the credential, holding the
+	// service principal's client secret
+	ClientSecretRef *SecretKeyRef `json:"clientSecretRef,omitempty"`
+	// ClientCertificateRef names the secret, in the namespace of the credential, holding the
+	// service principal's PKCS#12 or PEM certificate with its private key
+	ClientCertificateRef *SecretKeyRef `json:"clientCertificateRef,omitempty"`
+	// ClientCertificatePasswordRef names the secret holding the password of the certificate
+	ClientCertificatePasswordRef *SecretKeyRef `json:"clientCertificatePasswordRef,omitempty"`
+	// Cloud is the Azure cloud of the credential, such as AzureUSGovernmentCloud. Defaults to
+	// the cloud of the adapter
+	Cloud string `json:"cloud,omitempty"`
+}
+
+// SecretKeyRef names a key of a secret
+type SecretKeyRef struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AzureCredentialList is a list of AzureCredential resources
+type AzureCredentialList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata"`
+
+	Items []AzureCredential `json:"items"`
+}
@@ -24,6 +24,8 @@ type CustomMetric struct {
 // CustomMetricSpec is the spec for a CustomMetric resource
 type CustomMetricSpec struct {
 	MetricConfig CustomMetricConfig `json:"metric"`
+	// Transform converts the unit of the served value, multiplies it and adds an offset
+	Transform *TransformConfig `json:"transform,omitempty"`
 }
 
 // CustomMetricConfig holds app insights configuration
@@ -5,7 +5,6 @@ import (
 )
 
 // +genclient
-// +genclient:noStatus
 // +genclient:skipVerbs=patch
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -19,6 +18,46 @@ type ExternalMetric struct {
 
 	// Spec is the custom resource spec
 	Spec ExternalMetricSpec `json:"spec"`
+
+	// Status is written by the adapter while the metric is pinned or served during an incident
+	Status ExternalMetricStatus `json:"status,omitempty"`
+}
+
+// ExternalMetricStatus is how the adapter currently serves an external metric
+type ExternalMetricStatus struct {
+	// Pin is the active pin of the azure.com/pin annotation, unset once it expires or when the
+	// pin is ignored
+	Pin *PinStatus `json:"pin,omitempty"`
+	// Conditions are the current conditions of the metric, such as AzureServiceIncident while
+	// its previous value is served during an Azure Monitor incident
+	Conditions []ExternalMetricCondition `json:"conditions,omitempty"`
+	// Observed is the value the metric was last evaluated at by an adapter in observer mode
+	Observed *ObservedStatus `json:"observed,omitempty"`
+}
+
+// ObservedStatus is the value of an external metric evaluated in observer mode, or the error it
+// failed with
+type ObservedStatus struct {
+	Value string `json:"value,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ExternalMetricCondition is a condition of an external metric
+type ExternalMetricCondition struct {
+	Type string `json:"type"`
+	// Status is True, False or Unknown
+	Status string `json:"status"`
+	// Reason is a single word, such as the tracking id of an incident
+	Reason             string       `json:"reason,omitempty"`
+	Message            string       `json:"message,omitempty"`
+	LastTransitionTime meta_v1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// PinStatus is a pin the value of an external metric is served at
+type PinStatus struct {
+	// Value is the pinned value, empty when the metric is held at the value it last served
+	Value string       `json:"value,omitempty"`
+	Until meta_v1.Time `json:"until"`
 }
 
 // ExternalMetricSpec is the spec for a ExternalMetric resource
@@ -26,6 +65,123 @@ type ExternalMetricSpec struct {
 	MetricConfig ExternalMetricConfig `json:"metric"`
 	AzureConfig  AzureConfig          `json:"azure"`
 	Type         string               `json:"type,omitempty"`
+	// Credential names an AzureCredential in the namespace of the metric that Azure is queried
+	// with instead of the adapter's credentials
+	Credential string `json:"credential,omitempty"`
+	// CredentialSecret names a secret in the namespace of the metric holding the service principal
+	// that Azure is queried with, using the keys of the secret of the helm chart
+	CredentialSecret string `json:"credentialSecret,omitempty"`
+	// VariablesConfigMap names a ConfigMap in the namespace of the metric whose keys are variables
+	// the strings of the spec can reference, such as the queue of an environment
+	VariablesConfigMap string `json:"variablesConfigMap,omitempty"`
+	// TenantID is the AAD tenant of the metric's resource when it isn't the tenant of the
+	// credential.  The application of the credential must be consented in that tenant.
+	TenantID string `json:"tenantId,omitempty"`
+	// Schedule defines the value of a metric of type schedule
+	Schedule *ScheduleConfig `json:"schedule,omitempty"`
+	// Prediction configures the forecast served by a metric of type predictive
+	Prediction *PredictionConfig `json:"prediction,omitempty"`
+	// SLO configures the error budget burn rate served by a metric of type sloburnrate
+	SLO *SLOConfig `json:"slo,omitempty"`
+	// Plugin names the MetricSource plugin serving a metric of type plugin
+	Plugin *PluginConfig `json:"plugin,omitempty"`
+	// Webhook configures the endpoint called by a metric of type webhook
+	Webhook *WebhookConfig `json:"webhook,omitempty"`
+	// Activity also serves a 0/1 metric named <name>-activity for scale to zero controllers
+	Activity *ActivityConfig `json:"activity,omitempty"`
+	// Node resolves the metric for the scale set instance of the node named by the node label of the metric selector
+	Node *NodeConfig `json:"node,omitempty"`
+	// PerReplica divides the served value by the current replicas of a scale target
+	PerReplica *PerReplicaConfig `json:"perReplica,omitempty"`
+	// Expression computes the served value from the queried value, named sources and built-in variables
+	Expression *ExpressionConfig `json:"expression,omitempty"`
+	// ServiceBusQueue names the queue whose message count is read from the Service Bus data plane by a metric of type servicebus
+	ServiceBusQueue *ServiceBusQueueConfig `json:"serviceBusQueue,omitempty"`
+	// StorageQueue names the queue whose oldest message age is served by a metric of type
+	// storagequeuemessageage, or whose approximate message count is served by a metric of type storagequeue
+	StorageQueue *StorageQueueConfig `json:"storageQueue,omitempty"`
+	// EventHub names the event hub, consumer group and checkpoint container whose lag is served by a metric of type eventhub
+	EventHub *EventHubConfig `json:"eventHub,omitempty"`
+	// FileShare names the Azure Files share and metric served by a metric of type fileshare
+	FileShare *FileShareConfig `json:"fileShare,omitempty"`
+	// ActivityLog selects the events counted by a metric of type activitylog
+	ActivityLog *ActivityLogConfig `json:"activityLog,omitempty"`
+	// ContainerApp names the Container App or managed environment and metric served by a metric of type containerapp
+	ContainerApp *ContainerAppConfig `json:"containerApp,omitempty"`
+	// LogicApp names the Logic Apps workflow and run metric served by a metric of type logicapp
+	LogicApp *LogicAppConfig `json:"logicApp,omitempty"`
+	// LogAnalytics is the KQL query of a Log Analytics workspace served by a metric of type loganalytics
+	LogAnalytics *LogAnalyticsConfig `json:"logAnalytics,omitempty"`
+	// DataExplorer is the KQL query of an Azure Data Explorer database served by a metric of type dataexplorer
+	DataExplorer *DataExplorerConfig `json:"dataExplorer,omitempty"`
+	// CosmosDB names the Cosmos DB container and metric served by a metric of type cosmosdb
+	CosmosDB *CosmosDBConfig `json:"cosmosDB,omitempty"`
+	// Prometheus is the PromQL query of an Azure Monitor workspace served by a metric of type prometheus
+	Prometheus *PrometheusConfig `json:"prometheus,omitempty"`
+	// IoTHub names the consumer group of the built-in endpoint of an IoT hub served by a metric of type iothub
+	IoTHub *IoTHubConfig `json:"iotHub,omitempty"`
+	// Batch names the Azure Batch job or pool whose tasks are counted by a metric of type batch
+	Batch *BatchConfig `json:"batch,omitempty"`
+	// BlobCount names the blob container whose blobs are counted by a metric of type blobcount
+	BlobCount *BlobCountConfig `json:"blobCount,omitempty"`
+	// EventGrid accepts the events Azure Event Grid pushes to a metric of type eventgrid
+	EventGrid *EventGridConfig `json:"eventGrid,omitempty"`
+	// Redis names the key of an Azure Cache for Redis whose length is served by a metric of type redis
+	Redis *RedisConfig `json:"redis,omitempty"`
+	// AzureDevOps names the agent pool whose jobs are counted by a metric of type azuredevops
+	AzureDevOps *AzureDevOpsConfig `json:"azureDevOps,omitempty"`
+	// Cost selects the cost served by a metric of type cost
+	Cost *CostConfig `json:"cost,omitempty"`
+	// Quota names the regional quota served by a metric of type quota
+	Quota *QuotaConfig `json:"quota,omitempty"`
+	// Alert floors or caps the served value while an Azure Monitor alert rule is firing
+	Alert *AlertConfig `json:"alert,omitempty"`
+	// Maintenance windows freeze the served value at its level before the window
+	Maintenance *MaintenanceConfig `json:"maintenance,omitempty"`
+	// Timeout is the response budget of a query in the go duration format, such as 2s. A query
+	// taking longer serves the previous value and completes in the background
+	Timeout string `json:"timeout,omitempty"`
+	// NoDataPolicy is the value served when Azure Monitor has no values in the timespan of the
+	// metric: zero, lastValue for the last value that had data, error, or fixed for the noDataValue.
+	// Defaults to zero
+	NoDataPolicy string `json:"noDataPolicy,omitempty"`
+	// NoDataValue is served by the fixed noDataPolicy
+	NoDataValue int64 `json:"noDataValue,omitempty"`
+	// Sources are combined into the value of a metric of type combined by the sum of their weighted values
+	Sources []WeightedSource `json:"sources,omitempty"`
+	// Ratio divides the Azure Monitor metric of a metric of type ratio by the same metric on another resource
+	Ratio *RatioConfig `json:"ratio,omitempty"`
+	// Seasonal compares the Azure Monitor metric of a metric of type seasonal with its value an offset ago
+	Seasonal *SeasonalConfig `json:"seasonal,omitempty"`
+	// Heartbeat defines the value of a metric of type heartbeat
+	Heartbeat *HeartbeatConfig `json:"heartbeat,omitempty"`
+	// Transform converts the unit of the served value, multiplies it and adds an offset
+	Transform *TransformConfig `json:"transform,omitempty"`
+	// Variants are served under the name of the metric with their suffix, derived from its value
+	// without querying Azure again
+	Variants []VariantConfig `json:"variants,omitempty"`
+}
+
+// VariantConfig is a view of the value of an ExternalMetric served as another metric, such as the
+// rate of a queue's length alongside the length, so autoscalers and dashboards needing different
+// views of a signal share one query
+type VariantConfig struct {
+	// Suffix is added to the name of the ExternalMetric to request the variant, such as -rate
+	Suffix string `json:"suffix"`
+	// Rate serves the per second rate of change of the value between two queries
+	Rate bool `json:"rate,omitempty"`
+	// Transform converts the unit of the variant's value, multiplies it and adds an offset
+	Transform *TransformConfig `json:"transform,omitempty"`
+}
+
+// WeightedSource is the spec of a query whose value is multiplied by the weight and added to the
+// value of a combined metric
+type WeightedSource struct {
+	// Name is the variable holding the value of the source in the expression of the metric
+	Name string `json:"name,omitempty"`
+	// Weight is a decimal such as "0.7". Defaults to 1
+	Weight             string `json:"weight,omitempty"`
+	ExternalMetricSpec `json:",inline"`
 }
 
 // ExternalMetricConfig holds azure monitor metric configuration
@@ -35,6 +191,27 @@ type ExternalMetricConfig struct {
 	// Azure Monitor
 	Aggregation string `json:"aggregation,omitempty"`
 	Filter      string `json:"filter,omitempty"`
+	// MetricNamespace is the namespace of custom metrics, such as azure.vm.linux.guestmetrics for
+	// guest OS metrics. Defaults to the platform metrics of the resource type
+	MetricNamespace string `json:"metricNamespace,omitempty"`
+	// Timespan is the window of the query up to now, in whole minutes such as 15m. Defaults to 5m
+	Timespan string `json:"timespan,omitempty"`
+	// Interval is the time grain the values are aggregated over, 1m, 5m, 15m, 30m, 1h, 6h, 12h or
+	// 24h, and the value of the latest interval is served. Defaults to 1m
+	Interval string `json:"interval,omitempty"`
+	// Rate serves the per second rate of a cumulative counter, from the difference of its two latest
+	// values in the timespan, instead of its latest value
+	Rate bool `json:"rate,omitempty"`
+	// SplitDimension serves the series of each value of the dimension as a separate item
+	// labelled with the dimension name and value
+	SplitDimension string `json:"splitDimension,omitempty"`
+	// Top is the number of series with the highest values served when split. Defaults to 10
+	Top int32 `json:"top,omitempty"`
+	// UseUnits serves bytes with binary suffixes and percentages and milliseconds as fractions
+	UseUnits bool `json:"useUnits,omitempty"`
+	// MonitorAPIVersion is the Azure Monitor API version queried, for metric namespaces and
+	// dimensions that need a newer version than the adapter default
+	MonitorAPIVersion string `json:"monitorAPIVersion,omitempty"`
 }
 
 // AzureConfig holds Azure configuration for an External Metric
@@ -42,14 +219,516 @@ type AzureConfig struct {
 	// Shared
 	ResourceGroup  string `json:"resourceGroup"`
 	SubscriptionID string `json:"subscriptionID"`
+	// Subscriptions queries the same metric in each subscription, or every accessible
+	// subscription when it contains "*", and aggregates the values
+	Subscriptions []string `json:"subscriptions,omitempty"`
+	// SubscriptionAggregation is sum, average, minimum or maximum. Defaults to sum
+	SubscriptionAggregation string `json:"subscriptionAggregation,omitempty"`
 	// Azure Monitor
 	ResourceName              string `json:"resourceName,omitempty"`
 	ResourceProviderNamespace string `json:"resourceProviderNamespace,omitempty"`
 	ResourceType              string `json:"resourceType,omitempty"`
+	// Resources queries the same metric on each resource, of the resource type, and aggregates the values
+	Resources []AzureResource `json:"resources,omitempty"`
+	// ResourceTags queries the same metric on each resource of the resource type, in the
+	// subscription or resource group, with all of the tags and aggregates the values
+	ResourceTags map[string]string `json:"resourceTags,omitempty"`
+	// ResourceGraphQuery queries the same metric on each resource of the resource type whose id is
+	// returned by the Azure Resource Graph query in the subscription and aggregates the values
+	ResourceGraphQuery string `json:"resourceGraphQuery,omitempty"`
+	// ResourceAggregation is sum, average, minimum or maximum. Defaults to sum
+	ResourceAggregation string `json:"resourceAggregation,omitempty"`
 	// Azure Service Bus Topic Subscription
 	ServiceBusNamespace    string `json:"serviceBusNamespace,omitempty"`
 	ServiceBusTopic        string `json:"serviceBusTopic,omitempty"`
 	ServiceBusSubscription string `json:"serviceBusSubscription,omitempty"`
+	// ServiceBusMessageCounts are summed to give the value of the metric: active, scheduled and
+	// deadLetter. Defaults to active
+	ServiceBusMessageCounts []string `json:"serviceBusMessageCounts,omitempty"`
+}
+
+// AzureResource is a resource listed in the resources of an AzureConfig
+type AzureResource struct {
+	ResourceGroup string `json:"resourceGroup"`
+	ResourceName  string `json:"resourceName"`
+}
+
+// ActivityConfig defines when the metric is considered active
+type ActivityConfig struct {
+	// Threshold the value must be above for the metric to be active. Defaults to 0
+	Threshold int64 `json:"threshold,omitempty"`
+	// Window the metric stays active for after the value was last above the threshold,
+	// in the go duration format. Defaults to 5m
+	Window string `json:"window,omitempty"`
+}
+
+// AlertConfig guards the served value with an Azure Monitor alert rule, for example to never
+// report below target while a backlog alert is firing
+type AlertConfig struct {
+	// RuleID is the resource id of the alert rule
+	RuleID string `json:"ruleID"`
+	// Action is floor, to serve at least the value, or cap, to serve at most the value
+	Action string `json:"action"`
+	Value  int64  `json:"value"`
+}
+
+// MaintenanceConfig lists windows during which the value served before the window is served
+// and Azure is not queried
+type MaintenanceConfig struct {
+	// TimeZone is the IANA time zone recurring windows are evaluated in. Defaults to UTC
+	TimeZone string              `json:"timeZone,omitempty"`
+	Windows  []MaintenanceWindow `json:"windows"`
+}
+
+// MaintenanceWindow is either a single period between from and until, or a period recurring on
+// days between start and end like the windows of a schedule
+type MaintenanceWindow struct {
+	// From is the RFC3339 time a single window begins
+	From string `json:"from,omitempty"`
+	// Until is the RFC3339 time a single window ends
+	Until string `json:"until,omitempty"`
+	// Days uses the cron day of week format such as "1-5", "MON-FRI" or "0,6". Defaults to every day
+	Days string `json:"days,omitempty"`
+	// Start is the time of day, HH:MM, a recurring window begins
+	Start string `json:"start,omitempty"`
+	// End is the time of day, HH:MM, a recurring window ends
+	End string `json:"end,omitempty"`
+}
+
+// NodeConfig reads the metric of the virtual machine scale set instance backing a node.
+// The scale set and instance are found from the provider id of the node.
+type NodeConfig struct {
+	// Dimension of the scale set metric that identifies the instance. Defaults to VMName
+	Dimension string `json:"dimension,omitempty"`
+}
+
+// PerReplicaConfig references the scale target, such as the Deployment scaled on the metric, whose
+// current replicas divide the value so a Value target can be expressed per pod
+type PerReplicaConfig struct {
+	// APIVersion of the scale target. Defaults to apps/v1
+	APIVersion string `json:"apiVersion,omitempty"`
+	// Kind of the scale target. Defaults to Deployment
+	Kind string `json:"kind,omitempty"`
+	// Name of the scale target in the namespace of the ExternalMetric
+	Name string `json:"name"`
+}
+
+// ExpressionConfig computes the served value with an arithmetic expression, such as "hour >= 8 && hour < 18 ? max(orders, payments) : orders"
+type ExpressionConfig struct {
+	// Value is the expression. It reads value, the queried value, the named sources of a combined
+	// metric, hour, minute and weekday, and replicas when a scale target is set
+	Value string `json:"value"`
+	// TimeZone is the IANA time zone of hour, minute and weekday. Defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
+	// ScaleTarget is the target whose current replicas are read into replicas
+	ScaleTarget *PerReplicaConfig `json:"scaleTarget,omitempty"`
+}
+
+// ScheduleConfig defines a synthetic metric whose value depends on the time
+type ScheduleConfig struct {
+	// TimeZone is the IANA time zone the windows are evaluated in. Defaults to UTC
+	TimeZone string `json:"timeZone,omitempty"`
+	// DefaultValue is served when the current time is not in any window
+	DefaultValue int64 `json:"defaultValue"`
+	// Windows are evaluated in order and the value of the first matching window is served
+	Windows []ScheduleWindow `json:"windows,omitempty"`
+}
+
+// ScheduleWindow serves a value between the start and end times on the given days
+type ScheduleWindow struct {
+	// Days uses the cron day of week format such as "1-5", "MON-FRI" or "0,6". Defaults to every day
+	Days string `json:"days,omitempty"`
+	// Start is the time of day, HH:MM, the window begins
+	Start string `json:"start"`
+	// End is the time of day, HH:MM, the window ends. Windows that end before they start run past midnight
+	End   string `json:"end"`
+	Value int64  `json:"value"`
+}
+
+// PredictionConfig forecasts an Azure Monitor metric from its history.  Durations use
+// the go duration format such as "30m" or "6h".
+type PredictionConfig struct {
+	// History is how far back to read the metric. Defaults to 6h
+	History string `json:"history,omitempty"`
+	// Interval is the granularity of the history and must be supported by Azure Monitor. Defaults to 5m
+	Interval string `json:"interval,omitempty"`
+	// Horizon is how far ahead of now the value is projected
+	Horizon string `json:"horizon"`
+	// Method is linear or holtwinters. Defaults to linear
+	Method string `json:"method,omitempty"`
+	// SeasonLength is the length of a season, such as 24h, used by holtwinters.
+	// Without it holtwinters only models the level and trend of the metric.
+	SeasonLength string `json:"seasonLength,omitempty"`
+}
+
+// SLOConfig computes the error budget burn rate of an availability objective from
+// Application Insights request counts.  Windows use the go duration format.
+type SLOConfig struct {
+	// Objective is the percentage of requests that should succeed, such as "99.9"
+	Objective string `json:"objective"`
+	// LongWindow is the window the burn rate must be sustained over. Defaults to 1h
+	LongWindow string `json:"longWindow,omitempty"`
+	// ShortWindow confirms the budget is still burning so the metric recovers quickly. Defaults to 5m
+	ShortWindow string `json:"shortWindow,omitempty"`
+	// TotalMetric is the Application Insights metric counting all requests. Defaults to requests/count
+	TotalMetric string `json:"totalMetric,omitempty"`
+	// FailedMetric is the Application Insights metric counting failed requests. Defaults to requests/failed
+	FailedMetric string `json:"failedMetric,omitempty"`
+}
+
+// PluginConfig selects a MetricSource plugin from the adapter's plugin configuration
+type PluginConfig struct {
+	// Name of the plugin in the adapter's plugin configuration
+	Name string `json:"name"`
+	// Parameters are passed to the plugin unchanged
+	Parameters map[string]string `json:"parameters,omitempty"`
+}
+
+// WebhookConfig calls an https endpoint that returns the value of the metric as a json number.
+// The host must be one the adapter administrator allows.
+type WebhookConfig struct {
+	URL string `json:"url"`
+	// AADResource requests an Azure AD token for the resource with the adapter's identity
+	AADResource string `json:"aadResource,omitempty"`
+	// BearerToken is the name of a token file in the adapter's webhook token directory
+	BearerToken string `json:"bearerToken,omitempty"`
+}
+
+// FileShareConfig serves an Azure Files metric of a share from Azure Monitor
+type FileShareConfig struct {
+	// Account is the name of the storage account
+	Account string `json:"account"`
+	// Share filters the metric to a share. Without it the metric is served for each share of the account
+	Share string `json:"share,omitempty"`
+	// Metric is transactions, egress, snapshotcount, quota or capacity
+	Metric string `json:"metric"`
+}
+
+// ActivityLogConfig counts the Activity Log events of the subscription over a window.  Events are
+// listed for azure.resourceGroup, resourceId or resourceProvider, at most one of them.
+type ActivityLogConfig struct {
+	// Window is the period counted, in the go duration format. Defaults to 1h, at most 2160h (90 days)
+	Window string `json:"window,omitempty"`
+	// ResourceID lists the events of a resource
+	ResourceID string `json:"resourceId,omitempty"`
+	// ResourceProvider lists the events of a resource provider, such as Microsoft.Insights
+	ResourceProvider string `json:"resourceProvider,omitempty"`
+	// Category matches events such as Administrative, Autoscale, ResourceHealth or ServiceHealth
+	Category string `json:"category,omitempty"`
+	// OperationName matches events such as Microsoft.Insights/AutoscaleSettings/Scaleup/Action
+	OperationName string `json:"operationName,omitempty"`
+	// Status matches events such as Started, Succeeded or Failed
+	Status string `json:"status,omitempty"`
+	// Level matches events of Critical, Error, Warning or Informational
+	Level string `json:"level,omitempty"`
+}
+
+// ContainerAppConfig serves an Azure Container Apps metric of an app or of a managed environment
+// from Azure Monitor, in the resource group of azure.resourceGroup.  Set app or environment but not both.
+type ContainerAppConfig struct {
+	// App is the name of the Container App
+	App string `json:"app,omitempty"`
+	// Environment is the name of the Container Apps managed environment
+	Environment string `json:"environment,omitempty"`
+	// Revision filters the metric of an app to a revision
+	Revision string `json:"revision,omitempty"`
+	// Metric of an app is requests, replicas, cpu or memory. The metric of an environment is its
+	// Azure Monitor metric name
+	Metric string `json:"metric"`
+}
+
+// LogicAppConfig serves a run metric of a Logic Apps workflow from Azure Monitor, in the resource
+// group of azure.resourceGroup
+type LogicAppConfig struct {
+	// Workflow is the name of the Logic Apps workflow
+	Workflow string `json:"workflow"`
+	// Metric is started, completed, succeeded, failed or throttled
+	Metric string `json:"metric"`
+}
+
+// LogAnalyticsConfig serves the result of a KQL query of a Log Analytics workspace, which must
+// be a single number such as the result of summarize count()
+type LogAnalyticsConfig struct {
+	// Workspace is the workspace id of the Log Analytics workspace, a guid
+	Workspace string `json:"workspace"`
+	Query     string `json:"query"`
+	// Timespan limits the query to the records of the last timespan, in the go duration format
+	// such as 15m.  Without it the query filters the records itself, for example on TimeGenerated
+	Timespan string `json:"timespan,omitempty"`
+	// Column is the column of the result served, the first column unless set
+	Column string `json:"column,omitempty"`
+}
+
+// DataExplorerConfig serves the result of a KQL query of an Azure Data Explorer database, which
+// must be a single number such as the result of summarize count()
+type DataExplorerConfig struct {
+	// Cluster is the uri of the cluster, such as https://<cluster>.<region>.kusto.windows.net
+	Cluster  string `json:"cluster"`
+	Database string `json:"database"`
+	Query    string `json:"query"`
+	// Column is the column of the result served, the first column unless set
+	Column string `json:"column,omitempty"`
+}
+
+// CosmosDBConfig serves the normalized RU consumption of a Cosmos DB container from Azure Monitor,
+// or the number of changes of the container its change feed processor has not processed yet,
+// estimated from the leases of the processor
+type CosmosDBConfig struct {
+	// Account is the name of the Cosmos DB account, read from the connection string unless set
+	Account   string `json:"account,omitempty"`
+	Database  string `json:"database"`
+	Container string `json:"container,omitempty"`
+	// Metric is normalizedru or changefeedlag
+	Metric string `json:"metric"`
+	// LeaseDatabase is the database of the lease container, the database of the container unless set
+	LeaseDatabase string `json:"leaseDatabase,omitempty"`
+	// LeaseContainer is the lease container of the change feed processor, leases unless set
+	LeaseContainer string `json:"leaseContainer,omitempty"`
+	// LeasePrefix selects the leases of one processor when several share the lease container
+	LeasePrefix string `json:"leasePrefix,omitempty"`
+	// ConnectionStringRef names the secret key holding a connection string of the account, which
+	// reads the change feed with the account key in place of the adapter's credentials
+	ConnectionStringRef *SecretKeyRef `json:"connectionStringRef,omitempty"`
+}
+
+// PrometheusConfig serves the result of a PromQL instant query of an Azure Monitor workspace, which
+// must be a scalar or a single series such as the result of sum()
+type PrometheusConfig struct {
+	// Endpoint is the query endpoint of the workspace, such as
+	// https://<workspace>.<region>.prometheus.monitor.azure.com
+	Endpoint string `json:"endpoint"`
+	Query    string `json:"query"`
+}
+
+// BatchConfig serves the number of tasks of an Azure Batch job, or of the active jobs of a pool, in
+// a state
+type BatchConfig struct {
+	// Endpoint is the endpoint of the Batch account, such as https://<account>.<region>.batch.azure.com
+	Endpoint string `json:"endpoint"`
+	// Job is the id of the job, not set with a pool
+	Job string `json:"job,omitempty"`
+	// Pool is the id of the pool, not set with a job
+	Pool string `json:"pool,omitempty"`
+	// Tasks is active, the tasks waiting for a node, running, or pending, the tasks either active
+	// or running.  Defaults to active
+	Tasks string `json:"tasks,omitempty"`
+}
+
+// BlobCountConfig serves the number of blobs in a blob container whose names start with a prefix,
+// listed from the blob service.  The container is read with the connection string in the secret
+// when connectionStringRef is set, or with the adapter's credentials otherwise.
+type BlobCountConfig struct {
+	// Account is the name of the storage account, not needed with a connection string
+	Account   string `json:"account,omitempty"`
+	Container string `json:"container"`
+	// Prefix limits the count to the blobs whose names start with it, such as incoming/
+	Prefix string `json:"prefix,omitempty"`
+	// ConnectionStringRef names the secret, in the namespace of the metric, holding a connection
+	// string with the account key or a shared access signature allowing the container to be listed
+	ConnectionStringRef *SecretKeyRef `json:"connectionStringRef,omitempty"`
+}
+
+// CostConfig serves the cost of the subscription of azure.subscriptionID, or of azure.resourceGroup
+// when it is set, from Cost Management, such as to scale workloads down as a budget is approached.
+// Costs are refreshed by Azure a few times a day, and queried by the adapter at most every 30 minutes.
+type CostConfig struct {
+	// Metric is accrued, the cost over the timeframe, spendrate, the average daily cost of the last
+	// days, or budget, the current spend of the budget as a percentage of its amount
+	Metric string `json:"metric"`
+	// Timeframe of accrued costs, MonthToDate, BillingMonthToDate or WeekToDate. Defaults to MonthToDate
+	Timeframe string `json:"timeframe,omitempty"`
+	// Days the spend rate is averaged over, up to yesterday. Defaults to 7, at most 31
+	Days int `json:"days,omitempty"`
+	// CostType is ActualCost or AmortizedCost. Defaults to ActualCost
+	CostType string `json:"costType,omitempty"`
+	// Budget is the name of the budget of a budget metric
+	Budget string `json:"budget,omitempty"`
+}
+
+// QuotaConfig serves a regional quota of the subscription of azure.subscriptionID, such as the vCPUs
+// left to a VM family, from the usages of its resource provider
+type QuotaConfig struct {
+	// Provider is Microsoft.Compute, Microsoft.Network or Microsoft.Storage. Defaults to Microsoft.Compute
+	Provider string `json:"provider,omitempty"`
+	// Location is the region of the quota, such as westeurope
+	Location string `json:"location"`
+	// Name of the quota, such as cores, standardDSv3Family or PublicIPAddresses
+	Name string `json:"name"`
+	// Metric is remaining, the limit less the usage, used, limit or percent, the usage as a
+	// percentage of the limit. Defaults to remaining
+	Metric string `json:"metric,omitempty"`
+}
+
+// TransformConfig scales the served value into the units the targets of autoscalers are written in
+type TransformConfig struct {
+	// Multiplier is a decimal such as "0.001" the value is multiplied by. Defaults to 1
+	Multiplier string `json:"multiplier,omitempty"`
+	// Offset is a decimal added to the multiplied value
+	Offset string `json:"offset,omitempty"`
+	// Unit converts a value in bytes to KiB, MiB or GiB, or a value in milliseconds to s, before it
+	// is multiplied
+	Unit string `json:"unit,omitempty"`
+}
+
+// AzureDevOpsConfig serves the number of jobs of an Azure DevOps agent pool, such as the pool of
+// self-hosted agents running in the cluster, in the latest 250 job requests of the pool.  The
+// organization is authenticated with the personal access token in the secret when
+// personalAccessTokenRef is set, or with the adapter's credentials otherwise.
+type AzureDevOpsConfig struct {
+	// OrganizationURL is the url of the organization, such as https://dev.azure.com/<organization>
+	OrganizationURL string `json:"organizationURL"`
+	// PoolID is the id of the agent pool, or PoolName its name
+	PoolID   int    `json:"poolID,omitempty"`
+	PoolName string `json:"poolName,omitempty"`
+	// Jobs is queued, the jobs waiting for an agent, or pending, the jobs not finished whether
+	// waiting or running. Defaults to queued
+	Jobs string `json:"jobs,omitempty"`
+	// PersonalAccessTokenRef names the secret, in the namespace of the metric, holding a personal
+	// access token with the Agent Pools (read) scope
+	PersonalAccessTokenRef *SecretKeyRef `json:"personalAccessTokenRef,omitempty"`
+}
+
+// RedisConfig serves the length of a list or stream of an Azure Cache for Redis instance, such as a
+// work queue, or 0 when the key doesn't exist.  The cache is authenticated with the access key in
+// the secret when accessKeyRef is set, or with the adapter's credentials otherwise.
+type RedisConfig struct {
+	// Host is the host name of the cache, such as orders.redis.cache.windows.net, with the port when
+	// it isn't the tls port 6380
+	Host string `json:"host"`
+	Key  string `json:"key"`
+	// Database is the number of the database of the key. Defaults to 0
+	Database int `json:"database,omitempty"`
+	// AccessKeyRef names the secret, in the namespace of the metric, holding an access key of the cache
+	AccessKeyRef *SecretKeyRef `json:"accessKeyRef,omitempty"`
+}
+
+// EventGridConfig accepts the events an Azure Event Grid subscription pushes to the adapter's event
+// grid endpoint, at /eventgrid/<namespace>/<name>?key=<key>, and serves the value of the latest.
+// Values are kept in memory, so they are served again once the next event is pushed after the
+// adapter restarts.
+type EventGridConfig struct {
+	// KeyRef names the secret, in the namespace of the metric, holding the key the subscription
+	// sends in the key query parameter of its endpoint
+	KeyRef SecretKeyRef `json:"keyRef"`
+	// Value is the field of the data of an event holding the value, with nested fields separated
+	// by dots such as metrics.backlog. Defaults to value
+	Value string `json:"value,omitempty"`
+	// MaxAge is how long a pushed value is served, in the go duration format such as 10m. Values
+	// are served until the next is pushed when empty
+	MaxAge string `json:"maxAge,omitempty"`
+}
+
+// HeartbeatConfig defines a synthetic metric that serves a constant value, or a value ramping
+// between value and rampTo and back, to validate the metrics pipeline without any Azure resource
+type HeartbeatConfig struct {
+	Value int64 `json:"value"`
+	// RampTo is the value the metric ramps to when rampPeriod is set
+	RampTo int64 `json:"rampTo,omitempty"`
+	// RampPeriod is how long the ramp from value to rampTo takes, in the go duration format such
+	// as 30m. The value then ramps back over the same period, repeatedly
+	RampPeriod string `json:"rampPeriod,omitempty"`
+}
+
+// RatioConfig is the resource the metric is divided by, such as the other slot of a deployment or
+// the resource serving all regions.  The fields of the denominator left empty are those of the azure
+// section.
+type RatioConfig struct {
+	Denominator RatioDenominator `json:"denominator"`
+}
+
+// RatioDenominator is the resource and filter of the denominator of a ratio metric
+type RatioDenominator struct {
+	SubscriptionID            string `json:"subscriptionID,omitempty"`
+	ResourceGroup             string `json:"resourceGroup,omitempty"`
+	ResourceProviderNamespace string `json:"resourceProviderNamespace,omitempty"`
+	ResourceType              string `json:"resourceType,omitempty"`
+	ResourceName              string `json:"resourceName,omitempty"`
+	// Filter replaces the filter of the metric section on the denominator when set. An empty
+	// filter divides by the metric of every dimension value, such as the total of all regions
+	Filter *string `json:"filter,omitempty"`
+}
+
+// SeasonalConfig compares the metric over its timespan now with the same timespan an offset ago, such
+// as the same hour of the previous week, so workloads can scale when traffic exceeds its usual level
+type SeasonalConfig struct {
+	// Offset is how long ago the past timespan ends, in the go duration format such as 24h.
+	// Defaults to 168h, a week, and can be at most 2232h, the 93 days Azure Monitor keeps metrics
+	Offset string `json:"offset,omitempty"`
+	// Comparison is ratio to serve the current value divided by the past value, or difference to
+	// serve the current value minus the past value. Defaults to ratio
+	Comparison string `json:"comparison,omitempty"`
+}
+
+// ServiceBusQueueConfig serves the message count of a Service Bus queue read from the data plane
+// of its namespace, which is current within seconds.  The queue is read with the connection string
+// in the secret when connectionStringRef is set, or with the adapter's credentials otherwise.
+type ServiceBusQueueConfig struct {
+	// Namespace is the name of the Service Bus namespace, not needed with a connection string
+	Namespace string `json:"namespace,omitempty"`
+	// Queue defaults to the EntityPath of the connection string
+	Queue string `json:"queue,omitempty"`
+	// ConnectionStringRef names the secret, in the namespace of the metric, holding a connection
+	// string with a shared access key allowing Manage on the queue or its namespace
+	ConnectionStringRef *SecretKeyRef `json:"connectionStringRef,omitempty"`
+}
+
+// StorageQueueConfig serves the age in seconds of the oldest message in a Storage queue, or its
+// approximate message count.  The message is peeked so it stays visible and its dequeue count is
+// unchanged.  The queue is read with the connection string in the secret when connectionStringRef
+// is set, or with the adapter's credentials otherwise.
+type StorageQueueConfig struct {
+	// Account is the name of the storage account, not needed with a connection string
+	Account string `json:"account,omitempty"`
+	Queue   string `json:"queue"`
+	// ConnectionStringRef names the secret, in the namespace of the metric, holding a connection
+	// string with the account key or a shared access signature allowing reads of the queue
+	ConnectionStringRef *SecretKeyRef `json:"connectionStringRef,omitempty"`
+}
+
+// EventHubConfig serves the number of events of an event hub not yet processed by a consumer
+// group: the events after the checkpoint of each partition, or every event of a partition without
+// a checkpoint.  The checkpoints are read from the blobs of an Event Processor checkpoint store.
+type EventHubConfig struct {
+	// Namespace is the name of the Event Hubs namespace, not needed with a connection string
+	Namespace string `json:"namespace,omitempty"`
+	// EventHub defaults to the EntityPath of the connection string
+	EventHub string `json:"eventHub,omitempty"`
+	// ConsumerGroup defaults to $Default
+	ConsumerGroup string `json:"consumerGroup,omitempty"`
+	// LagPerReplica is the lag one consumer keeps up with.  When set the metric serves the
+	// recommended number of consumers, the lag divided by lagPerReplica rounded up and capped at
+	// the number of partitions, instead of the lag
+	LagPerReplica int64 `json:"lagPerReplica,omitempty"`
+	// ConnectionStringRef names the secret, in the namespace of the metric, holding a connection
+	// string with a shared access key allowing Manage on the event hub or its namespace
+	ConnectionStringRef *SecretKeyRef `json:"connectionStringRef,omitempty"`
+	// CheckpointStore names the blob container the consumers store their checkpoints in
+	CheckpointStore CheckpointStoreConfig `json:"checkpointStore"`
+}
+
+// IoTHubConfig serves the number of device-to-cloud messages of an IoT hub not yet processed by a
+// consumer group of its built-in endpoint, the events endpoint, read as the lag of an eventhub
+// metric is from the checkpoints of the consumers
+type IoTHubConfig struct {
+	// ConsumerGroup is a consumer group of the built-in endpoint, $Default unless set
+	ConsumerGroup string `json:"consumerGroup,omitempty"`
+	// ConnectionStringRef names the secret, in the namespace of the metric, holding the Event
+	// Hub-compatible connection string of the built-in endpoint, including its EntityPath
+	ConnectionStringRef *SecretKeyRef `json:"connectionStringRef"`
+	// CheckpointStore names the blob container the consumers store their checkpoints in
+	CheckpointStore CheckpointStoreConfig `json:"checkpointStore"`
+}
+
+// CheckpointStoreConfig names the blob container of the checkpoints of Event Processors.  The
+// container is read with the connection string in the secret when connectionStringRef is set, or
+// with the adapter's credentials otherwise.
+type CheckpointStoreConfig struct {
+	// Account is the name of the storage account, not needed with a connection string
+	Account   string `json:"account,omitempty"`
+	Container string `json:"container"`
+	// ConnectionStringRef names the secret, in the namespace of the metric, holding a connection
+	// string with the account key or a shared access signature allowing the container to be listed
+	ConnectionStringRef *SecretKeyRef `json:"connectionStringRef,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
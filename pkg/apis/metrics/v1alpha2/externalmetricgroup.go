@@ -0,0 +1,48 @@
+package v1alpha2
+
+import (
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:noStatus
+// +genclient:skipVerbs=patch
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ExternalMetricGroup defines many external metrics of a service that share their Azure resource
+// and credentials.  Each metric of the group is served as if it were an ExternalMetric of its name.
+type ExternalMetricGroup struct {
+	// TypeMeta is the metadata for the resource, like kind and apiversion
+	meta_v1.TypeMeta `json:",inline"`
+
+	// ObjectMeta contains the metadata for the particular object (name, self link, labels, etc)
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec is the custom resource spec
+	Spec ExternalMetricGroupSpec `json:"spec"`
+}
+
+// ExternalMetricGroupSpec is the spec for a ExternalMetricGroup resource
+type ExternalMetricGroupSpec struct {
+	// Template is the spec every metric of the group starts from, such as its type, azure
+	// section and credential
+	Template ExternalMetricSpec `json:"template"`
+	// Metrics of the group. The fields a metric sets replace those of the template
+	Metrics []ExternalMetricGroupMember `json:"metrics"`
+}
+
+// ExternalMetricGroupMember is a metric of a group, served under its name
+type ExternalMetricGroupMember struct {
+	Name               string `json:"name"`
+	ExternalMetricSpec `json:",inline"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ExternalMetricGroupList is a list of ExternalMetricGroup resources
+type ExternalMetricGroupList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata"`
+
+	Items []ExternalMetricGroup `json:"items"`
+}
@@ -0,0 +1,64 @@
+package v1alpha2
+
+import (
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:skipVerbs=patch
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MetricProbe checks that an external metric keeps serving values in an expected range, so a
+// metric silently broken by a wrong filter or a revoked permission is found before the
+// HorizontalPodAutoscalers using it misbehave.  The adapter probes the metric at the interval and
+// reports the result in the status and in events.
+type MetricProbe struct {
+	// TypeMeta is the metadata for the resource, like kind and apiversion
+	meta_v1.TypeMeta `json:",inline"`
+
+	// ObjectMeta contains the metadata for the particular object (name, self link, labels, etc)
+	meta_v1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Spec is the custom resource spec
+	Spec MetricProbeSpec `json:"spec"`
+
+	// Status is the result of the last probe
+	Status MetricProbeStatus `json:"status,omitempty"`
+}
+
+// MetricProbeSpec is the spec for a MetricProbe resource
+type MetricProbeSpec struct {
+	// ExternalMetric is the name of the external metric probed, in the namespace of the probe
+	ExternalMetric string `json:"externalMetric"`
+	// MetricSelector is the label selector the metric is requested with, as an HPA would
+	MetricSelector map[string]string `json:"metricSelector,omitempty"`
+	// Min is the lowest value expected, such as 0. No lower bound when empty
+	Min string `json:"min,omitempty"`
+	// Max is the highest value expected. No upper bound when empty
+	Max string `json:"max,omitempty"`
+	// Interval between probes in the go duration format, such as 5m. Defaults to 1m
+	Interval string `json:"interval,omitempty"`
+}
+
+// MetricProbeStatus is the result of the last probe of a metric
+type MetricProbeStatus struct {
+	// Result is Passed when the metric was served with a value in range, or Failed
+	Result string `json:"result,omitempty"`
+	// Value is the value served, the sum of the values of a metric split by dimension
+	Value string `json:"value,omitempty"`
+	// Message explains why the probe failed
+	Message       string        `json:"message,omitempty"`
+	LastProbeTime *meta_v1.Time `json:"lastProbeTime,omitempty"`
+	// LastTransitionTime is when the result last changed
+	LastTransitionTime *meta_v1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// MetricProbeList is a list of MetricProbe resources
+type MetricProbeList struct {
+	meta_v1.TypeMeta `json:",inline"`
+	meta_v1.ListMeta `json:"metadata"`
+
+	Items []MetricProbe `json:"items"`
+}
@@ -29,8 +29,16 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		SchemeGroupVersion,
 		&ExternalMetric{},
 		&ExternalMetricList{},
+		&ExternalMetricGroup{},
+		&ExternalMetricGroupList{},
 		&CustomMetric{},
 		&CustomMetricList{},
+		&AdapterPolicy{},
+		&AdapterPolicyList{},
+		&AzureCredential{},
+		&AzureCredentialList{},
+		&MetricProbe{},
+		&MetricProbeList{},
 	)
 
 	// register the type in the scheme
@@ -25,42 +25,58 @@ import (
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *AzureConfig) DeepCopyInto(out *AzureConfig) {
+func (in *ActivityConfig) DeepCopyInto(out *ActivityConfig) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureConfig.
-func (in *AzureConfig) DeepCopy() *AzureConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActivityConfig.
+func (in *ActivityConfig) DeepCopy() *ActivityConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(AzureConfig)
+	out := new(ActivityConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CustomMetric) DeepCopyInto(out *CustomMetric) {
+func (in *ActivityLogConfig) DeepCopyInto(out *ActivityLogConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ActivityLogConfig.
+func (in *ActivityLogConfig) DeepCopy() *ActivityLogConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ActivityLogConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdapterPolicy) DeepCopyInto(out *AdapterPolicy) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomMetric.
-func (in *CustomMetric) DeepCopy() *CustomMetric {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdapterPolicy.
+func (in *AdapterPolicy) DeepCopy() *AdapterPolicy {
 	if in == nil {
 		return nil
 	}
-	out := new(CustomMetric)
+	out := new(AdapterPolicy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CustomMetric) DeepCopyObject() runtime.Object {
+func (in *AdapterPolicy) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -68,29 +84,163 @@ func (in *CustomMetric) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CustomMetricConfig) DeepCopyInto(out *CustomMetricConfig) {
+func (in *AdapterPolicyList) DeepCopyInto(out *AdapterPolicyList) {
 	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AdapterPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomMetricConfig.
-func (in *CustomMetricConfig) DeepCopy() *CustomMetricConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdapterPolicyList.
+func (in *AdapterPolicyList) DeepCopy() *AdapterPolicyList {
 	if in == nil {
 		return nil
 	}
-	out := new(CustomMetricConfig)
+	out := new(AdapterPolicyList)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AdapterPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CustomMetricList) DeepCopyInto(out *CustomMetricList) {
+func (in *AdapterPolicySpec) DeepCopyInto(out *AdapterPolicySpec) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Subscriptions != nil {
+		in, out := &in.Subscriptions, &out.Subscriptions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResourceGroups != nil {
+		in, out := &in.ResourceGroups, &out.ResourceGroups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResourceTypes != nil {
+		in, out := &in.ResourceTypes, &out.ResourceTypes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdapterPolicySpec.
+func (in *AdapterPolicySpec) DeepCopy() *AdapterPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AdapterPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlertConfig) DeepCopyInto(out *AlertConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlertConfig.
+func (in *AlertConfig) DeepCopy() *AlertConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AlertConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureConfig) DeepCopyInto(out *AzureConfig) {
+	*out = *in
+	if in.Subscriptions != nil {
+		in, out := &in.Subscriptions, &out.Subscriptions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]AzureResource, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResourceTags != nil {
+		in, out := &in.ResourceTags, &out.ResourceTags
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ServiceBusMessageCounts != nil {
+		in, out := &in.ServiceBusMessageCounts, &out.ServiceBusMessageCounts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureConfig.
+func (in *AzureConfig) DeepCopy() *AzureConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureCredential) DeepCopyInto(out *AzureCredential) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureCredential.
+func (in *AzureCredential) DeepCopy() *AzureCredential {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureCredential)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AzureCredential) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureCredentialList) DeepCopyInto(out *AzureCredentialList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	out.ListMeta = in.ListMeta
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]CustomMetric, len(*in))
+		*out = make([]AzureCredential, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -98,18 +248,18 @@ func (in *CustomMetricList) DeepCopyInto(out *CustomMetricList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomMetricList.
-func (in *CustomMetricList) DeepCopy() *CustomMetricList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureCredentialList.
+func (in *AzureCredentialList) DeepCopy() *AzureCredentialList {
 	if in == nil {
 		return nil
 	}
-	out := new(CustomMetricList)
+	out := new(AzureCredentialList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *CustomMetricList) DeepCopyObject() runtime.Object {
+func (in *AzureCredentialList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -117,43 +267,205 @@ func (in *CustomMetricList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *CustomMetricSpec) DeepCopyInto(out *CustomMetricSpec) {
+func (in *AzureCredentialSpec) DeepCopyInto(out *AzureCredentialSpec) {
 	*out = *in
-	out.MetricConfig = in.MetricConfig
+	if in.ClientSecretRef != nil {
+		in, out := &in.ClientSecretRef, &out.ClientSecretRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	if in.ClientCertificateRef != nil {
+		in, out := &in.ClientCertificateRef, &out.ClientCertificateRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	if in.ClientCertificatePasswordRef != nil {
+		in, out := &in.ClientCertificatePasswordRef, &out.ClientCertificatePasswordRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomMetricSpec.
-func (in *CustomMetricSpec) DeepCopy() *CustomMetricSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureCredentialSpec.
+func (in *AzureCredentialSpec) DeepCopy() *AzureCredentialSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(CustomMetricSpec)
+	out := new(AzureCredentialSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExternalMetric) DeepCopyInto(out *ExternalMetric) {
+func (in *AzureDevOpsConfig) DeepCopyInto(out *AzureDevOpsConfig) {
+	*out = *in
+	if in.PersonalAccessTokenRef != nil {
+		in, out := &in.PersonalAccessTokenRef, &out.PersonalAccessTokenRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureDevOpsConfig.
+func (in *AzureDevOpsConfig) DeepCopy() *AzureDevOpsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureDevOpsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureResource) DeepCopyInto(out *AzureResource) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureResource.
+func (in *AzureResource) DeepCopy() *AzureResource {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureResource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BatchConfig) DeepCopyInto(out *BatchConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BatchConfig.
+func (in *BatchConfig) DeepCopy() *BatchConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BatchConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BlobCountConfig) DeepCopyInto(out *BlobCountConfig) {
+	*out = *in
+	if in.ConnectionStringRef != nil {
+		in, out := &in.ConnectionStringRef, &out.ConnectionStringRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BlobCountConfig.
+func (in *BlobCountConfig) DeepCopy() *BlobCountConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(BlobCountConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheckpointStoreConfig) DeepCopyInto(out *CheckpointStoreConfig) {
+	*out = *in
+	if in.ConnectionStringRef != nil {
+		in, out := &in.ConnectionStringRef, &out.ConnectionStringRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheckpointStoreConfig.
+func (in *CheckpointStoreConfig) DeepCopy() *CheckpointStoreConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CheckpointStoreConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerAppConfig) DeepCopyInto(out *ContainerAppConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerAppConfig.
+func (in *ContainerAppConfig) DeepCopy() *ContainerAppConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ContainerAppConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CosmosDBConfig) DeepCopyInto(out *CosmosDBConfig) {
+	*out = *in
+	if in.ConnectionStringRef != nil {
+		in, out := &in.ConnectionStringRef, &out.ConnectionStringRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CosmosDBConfig.
+func (in *CosmosDBConfig) DeepCopy() *CosmosDBConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CosmosDBConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CostConfig) DeepCopyInto(out *CostConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CostConfig.
+func (in *CostConfig) DeepCopy() *CostConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CostConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomMetric) DeepCopyInto(out *CustomMetric) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetric.
-func (in *ExternalMetric) DeepCopy() *ExternalMetric {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomMetric.
+func (in *CustomMetric) DeepCopy() *CustomMetric {
 	if in == nil {
 		return nil
 	}
-	out := new(ExternalMetric)
+	out := new(CustomMetric)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ExternalMetric) DeepCopyObject() runtime.Object {
+func (in *CustomMetric) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -161,29 +473,29 @@ func (in *ExternalMetric) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExternalMetricConfig) DeepCopyInto(out *ExternalMetricConfig) {
+func (in *CustomMetricConfig) DeepCopyInto(out *CustomMetricConfig) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetricConfig.
-func (in *ExternalMetricConfig) DeepCopy() *ExternalMetricConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomMetricConfig.
+func (in *CustomMetricConfig) DeepCopy() *CustomMetricConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(ExternalMetricConfig)
+	out := new(CustomMetricConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExternalMetricList) DeepCopyInto(out *ExternalMetricList) {
+func (in *CustomMetricList) DeepCopyInto(out *CustomMetricList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	out.ListMeta = in.ListMeta
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]ExternalMetric, len(*in))
+		*out = make([]CustomMetric, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
@@ -191,18 +503,18 @@ func (in *ExternalMetricList) DeepCopyInto(out *ExternalMetricList) {
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetricList.
-func (in *ExternalMetricList) DeepCopy() *ExternalMetricList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomMetricList.
+func (in *CustomMetricList) DeepCopy() *CustomMetricList {
 	if in == nil {
 		return nil
 	}
-	out := new(ExternalMetricList)
+	out := new(CustomMetricList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *ExternalMetricList) DeepCopyObject() runtime.Object {
+func (in *CustomMetricList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -210,19 +522,1152 @@ func (in *ExternalMetricList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ExternalMetricSpec) DeepCopyInto(out *ExternalMetricSpec) {
+func (in *CustomMetricSpec) DeepCopyInto(out *CustomMetricSpec) {
 	*out = *in
 	out.MetricConfig = in.MetricConfig
-	out.AzureConfig = in.AzureConfig
+	if in.Transform != nil {
+		in, out := &in.Transform, &out.Transform
+		*out = new(TransformConfig)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomMetricSpec.
+func (in *CustomMetricSpec) DeepCopy() *CustomMetricSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomMetricSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DataExplorerConfig) DeepCopyInto(out *DataExplorerConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataExplorerConfig.
+func (in *DataExplorerConfig) DeepCopy() *DataExplorerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(DataExplorerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventGridConfig) DeepCopyInto(out *EventGridConfig) {
+	*out = *in
+	out.KeyRef = in.KeyRef
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventGridConfig.
+func (in *EventGridConfig) DeepCopy() *EventGridConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EventGridConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventHubConfig) DeepCopyInto(out *EventHubConfig) {
+	*out = *in
+	if in.ConnectionStringRef != nil {
+		in, out := &in.ConnectionStringRef, &out.ConnectionStringRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	in.CheckpointStore.DeepCopyInto(&out.CheckpointStore)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventHubConfig.
+func (in *EventHubConfig) DeepCopy() *EventHubConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EventHubConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExpressionConfig) DeepCopyInto(out *ExpressionConfig) {
+	*out = *in
+	if in.ScaleTarget != nil {
+		in, out := &in.ScaleTarget, &out.ScaleTarget
+		*out = new(PerReplicaConfig)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExpressionConfig.
+func (in *ExpressionConfig) DeepCopy() *ExpressionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ExpressionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalMetric) DeepCopyInto(out *ExternalMetric) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetric.
+func (in *ExternalMetric) DeepCopy() *ExternalMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExternalMetric) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalMetricCondition) DeepCopyInto(out *ExternalMetricCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetricCondition.
+func (in *ExternalMetricCondition) DeepCopy() *ExternalMetricCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalMetricCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalMetricConfig) DeepCopyInto(out *ExternalMetricConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetricConfig.
+func (in *ExternalMetricConfig) DeepCopy() *ExternalMetricConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalMetricConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalMetricGroup) DeepCopyInto(out *ExternalMetricGroup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetricGroup.
+func (in *ExternalMetricGroup) DeepCopy() *ExternalMetricGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalMetricGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExternalMetricGroup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalMetricGroupList) DeepCopyInto(out *ExternalMetricGroupList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ExternalMetricGroup, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetricGroupList.
+func (in *ExternalMetricGroupList) DeepCopy() *ExternalMetricGroupList {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalMetricGroupList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExternalMetricGroupList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalMetricGroupMember) DeepCopyInto(out *ExternalMetricGroupMember) {
+	*out = *in
+	in.ExternalMetricSpec.DeepCopyInto(&out.ExternalMetricSpec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetricGroupMember.
+func (in *ExternalMetricGroupMember) DeepCopy() *ExternalMetricGroupMember {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalMetricGroupMember)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalMetricGroupSpec) DeepCopyInto(out *ExternalMetricGroupSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make([]ExternalMetricGroupMember, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetricGroupSpec.
+func (in *ExternalMetricGroupSpec) DeepCopy() *ExternalMetricGroupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalMetricGroupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalMetricList) DeepCopyInto(out *ExternalMetricList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ExternalMetric, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetricList.
+func (in *ExternalMetricList) DeepCopy() *ExternalMetricList {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalMetricList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExternalMetricList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalMetricSpec) DeepCopyInto(out *ExternalMetricSpec) {
+	*out = *in
+	out.MetricConfig = in.MetricConfig
+	in.AzureConfig.DeepCopyInto(&out.AzureConfig)
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(ScheduleConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Prediction != nil {
+		in, out := &in.Prediction, &out.Prediction
+		*out = new(PredictionConfig)
+		**out = **in
+	}
+	if in.SLO != nil {
+		in, out := &in.SLO, &out.SLO
+		*out = new(SLOConfig)
+		**out = **in
+	}
+	if in.Plugin != nil {
+		in, out := &in.Plugin, &out.Plugin
+		*out = new(PluginConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(WebhookConfig)
+		**out = **in
+	}
+	if in.Activity != nil {
+		in, out := &in.Activity, &out.Activity
+		*out = new(ActivityConfig)
+		**out = **in
+	}
+	if in.Node != nil {
+		in, out := &in.Node, &out.Node
+		*out = new(NodeConfig)
+		**out = **in
+	}
+	if in.PerReplica != nil {
+		in, out := &in.PerReplica, &out.PerReplica
+		*out = new(PerReplicaConfig)
+		**out = **in
+	}
+	if in.Expression != nil {
+		in, out := &in.Expression, &out.Expression
+		*out = new(ExpressionConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceBusQueue != nil {
+		in, out := &in.ServiceBusQueue, &out.ServiceBusQueue
+		*out = new(ServiceBusQueueConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StorageQueue != nil {
+		in, out := &in.StorageQueue, &out.StorageQueue
+		*out = new(StorageQueueConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EventHub != nil {
+		in, out := &in.EventHub, &out.EventHub
+		*out = new(EventHubConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FileShare != nil {
+		in, out := &in.FileShare, &out.FileShare
+		*out = new(FileShareConfig)
+		**out = **in
+	}
+	if in.ActivityLog != nil {
+		in, out := &in.ActivityLog, &out.ActivityLog
+		*out = new(ActivityLogConfig)
+		**out = **in
+	}
+	if in.ContainerApp != nil {
+		in, out := &in.ContainerApp, &out.ContainerApp
+		*out = new(ContainerAppConfig)
+		**out = **in
+	}
+	if in.LogicApp != nil {
+		in, out := &in.LogicApp, &out.LogicApp
+		*out = new(LogicAppConfig)
+		**out = **in
+	}
+	if in.LogAnalytics != nil {
+		in, out := &in.LogAnalytics, &out.LogAnalytics
+		*out = new(LogAnalyticsConfig)
+		**out = **in
+	}
+	if in.DataExplorer != nil {
+		in, out := &in.DataExplorer, &out.DataExplorer
+		*out = new(DataExplorerConfig)
+		**out = **in
+	}
+	if in.CosmosDB != nil {
+		in, out := &in.CosmosDB, &out.CosmosDB
+		*out = new(CosmosDBConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Prometheus != nil {
+		in, out := &in.Prometheus, &out.Prometheus
+		*out = new(PrometheusConfig)
+		**out = **in
+	}
+	if in.IoTHub != nil {
+		in, out := &in.IoTHub, &out.IoTHub
+		*out = new(IoTHubConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Batch != nil {
+		in, out := &in.Batch, &out.Batch
+		*out = new(BatchConfig)
+		**out = **in
+	}
+	if in.BlobCount != nil {
+		in, out := &in.BlobCount, &out.BlobCount
+		*out = new(BlobCountConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EventGrid != nil {
+		in, out := &in.EventGrid, &out.EventGrid
+		*out = new(EventGridConfig)
+		**out = **in
+	}
+	if in.Redis != nil {
+		in, out := &in.Redis, &out.Redis
+		*out = new(RedisConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AzureDevOps != nil {
+		in, out := &in.AzureDevOps, &out.AzureDevOps
+		*out = new(AzureDevOpsConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Cost != nil {
+		in, out := &in.Cost, &out.Cost
+		*out = new(CostConfig)
+		**out = **in
+	}
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(QuotaConfig)
+		**out = **in
+	}
+	if in.Alert != nil {
+		in, out := &in.Alert, &out.Alert
+		*out = new(AlertConfig)
+		**out = **in
+	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(MaintenanceConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]WeightedSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Ratio != nil {
+		in, out := &in.Ratio, &out.Ratio
+		*out = new(RatioConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Seasonal != nil {
+		in, out := &in.Seasonal, &out.Seasonal
+		*out = new(SeasonalConfig)
+		**out = **in
+	}
+	if in.Heartbeat != nil {
+		in, out := &in.Heartbeat, &out.Heartbeat
+		*out = new(HeartbeatConfig)
+		**out = **in
+	}
+	if in.Transform != nil {
+		in, out := &in.Transform, &out.Transform
+		*out = new(TransformConfig)
+		**out = **in
+	}
+	if in.Variants != nil {
+		in, out := &in.Variants, &out.Variants
+		*out = make([]VariantConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetricSpec.
+func (in *ExternalMetricSpec) DeepCopy() *ExternalMetricSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalMetricSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalMetricStatus) DeepCopyInto(out *ExternalMetricStatus) {
+	*out = *in
+	if in.Pin != nil {
+		in, out := &in.Pin, &out.Pin
+		*out = new(PinStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]ExternalMetricCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Observed != nil {
+		in, out := &in.Observed, &out.Observed
+		*out = new(ObservedStatus)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetricStatus.
+func (in *ExternalMetricStatus) DeepCopy() *ExternalMetricStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalMetricStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileShareConfig) DeepCopyInto(out *FileShareConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileShareConfig.
+func (in *FileShareConfig) DeepCopy() *FileShareConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(FileShareConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeartbeatConfig) DeepCopyInto(out *HeartbeatConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeartbeatConfig.
+func (in *HeartbeatConfig) DeepCopy() *HeartbeatConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(HeartbeatConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IoTHubConfig) DeepCopyInto(out *IoTHubConfig) {
+	*out = *in
+	if in.ConnectionStringRef != nil {
+		in, out := &in.ConnectionStringRef, &out.ConnectionStringRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	in.CheckpointStore.DeepCopyInto(&out.CheckpointStore)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IoTHubConfig.
+func (in *IoTHubConfig) DeepCopy() *IoTHubConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(IoTHubConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogAnalyticsConfig) DeepCopyInto(out *LogAnalyticsConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogAnalyticsConfig.
+func (in *LogAnalyticsConfig) DeepCopy() *LogAnalyticsConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(LogAnalyticsConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogicAppConfig) DeepCopyInto(out *LogicAppConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogicAppConfig.
+func (in *LogicAppConfig) DeepCopy() *LogicAppConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(LogicAppConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceConfig) DeepCopyInto(out *MaintenanceConfig) {
+	*out = *in
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = make([]MaintenanceWindow, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceConfig.
+func (in *MaintenanceConfig) DeepCopy() *MaintenanceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceWindow) DeepCopyInto(out *MaintenanceWindow) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceWindow.
+func (in *MaintenanceWindow) DeepCopy() *MaintenanceWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricProbe) DeepCopyInto(out *MetricProbe) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricProbe.
+func (in *MetricProbe) DeepCopy() *MetricProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MetricProbe) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricProbeList) DeepCopyInto(out *MetricProbeList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	out.ListMeta = in.ListMeta
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MetricProbe, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricProbeList.
+func (in *MetricProbeList) DeepCopy() *MetricProbeList {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricProbeList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MetricProbeList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricProbeSpec) DeepCopyInto(out *MetricProbeSpec) {
+	*out = *in
+	if in.MetricSelector != nil {
+		in, out := &in.MetricSelector, &out.MetricSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricProbeSpec.
+func (in *MetricProbeSpec) DeepCopy() *MetricProbeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricProbeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricProbeStatus) DeepCopyInto(out *MetricProbeStatus) {
+	*out = *in
+	if in.LastProbeTime != nil {
+		in, out := &in.LastProbeTime, &out.LastProbeTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricProbeStatus.
+func (in *MetricProbeStatus) DeepCopy() *MetricProbeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricProbeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeConfig) DeepCopyInto(out *NodeConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeConfig.
+func (in *NodeConfig) DeepCopy() *NodeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObservedStatus) DeepCopyInto(out *ObservedStatus) {
+	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalMetricSpec.
-func (in *ExternalMetricSpec) DeepCopy() *ExternalMetricSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObservedStatus.
+func (in *ObservedStatus) DeepCopy() *ObservedStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ExternalMetricSpec)
+	out := new(ObservedStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PerReplicaConfig) DeepCopyInto(out *PerReplicaConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PerReplicaConfig.
+func (in *PerReplicaConfig) DeepCopy() *PerReplicaConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PerReplicaConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PinStatus) DeepCopyInto(out *PinStatus) {
+	*out = *in
+	in.Until.DeepCopyInto(&out.Until)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PinStatus.
+func (in *PinStatus) DeepCopy() *PinStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PinStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginConfig) DeepCopyInto(out *PluginConfig) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginConfig.
+func (in *PluginConfig) DeepCopy() *PluginConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PredictionConfig) DeepCopyInto(out *PredictionConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PredictionConfig.
+func (in *PredictionConfig) DeepCopy() *PredictionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PredictionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusConfig) DeepCopyInto(out *PrometheusConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusConfig.
+func (in *PrometheusConfig) DeepCopy() *PrometheusConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaConfig) DeepCopyInto(out *QuotaConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaConfig.
+func (in *QuotaConfig) DeepCopy() *QuotaConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RatioConfig) DeepCopyInto(out *RatioConfig) {
+	*out = *in
+	in.Denominator.DeepCopyInto(&out.Denominator)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RatioConfig.
+func (in *RatioConfig) DeepCopy() *RatioConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RatioConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RatioDenominator) DeepCopyInto(out *RatioDenominator) {
+	*out = *in
+	if in.Filter != nil {
+		in, out := &in.Filter, &out.Filter
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RatioDenominator.
+func (in *RatioDenominator) DeepCopy() *RatioDenominator {
+	if in == nil {
+		return nil
+	}
+	out := new(RatioDenominator)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RedisConfig) DeepCopyInto(out *RedisConfig) {
+	*out = *in
+	if in.AccessKeyRef != nil {
+		in, out := &in.AccessKeyRef, &out.AccessKeyRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisConfig.
+func (in *RedisConfig) DeepCopy() *RedisConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RedisConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SLOConfig) DeepCopyInto(out *SLOConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SLOConfig.
+func (in *SLOConfig) DeepCopy() *SLOConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SLOConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleConfig) DeepCopyInto(out *ScheduleConfig) {
+	*out = *in
+	if in.Windows != nil {
+		in, out := &in.Windows, &out.Windows
+		*out = make([]ScheduleWindow, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleConfig.
+func (in *ScheduleConfig) DeepCopy() *ScheduleConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScheduleWindow) DeepCopyInto(out *ScheduleWindow) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduleWindow.
+func (in *ScheduleWindow) DeepCopy() *ScheduleWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(ScheduleWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeasonalConfig) DeepCopyInto(out *SeasonalConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeasonalConfig.
+func (in *SeasonalConfig) DeepCopy() *SeasonalConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(SeasonalConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyRef) DeepCopyInto(out *SecretKeyRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyRef.
+func (in *SecretKeyRef) DeepCopy() *SecretKeyRef {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceBusQueueConfig) DeepCopyInto(out *ServiceBusQueueConfig) {
+	*out = *in
+	if in.ConnectionStringRef != nil {
+		in, out := &in.ConnectionStringRef, &out.ConnectionStringRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceBusQueueConfig.
+func (in *ServiceBusQueueConfig) DeepCopy() *ServiceBusQueueConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceBusQueueConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageQueueConfig) DeepCopyInto(out *StorageQueueConfig) {
+	*out = *in
+	if in.ConnectionStringRef != nil {
+		in, out := &in.ConnectionStringRef, &out.ConnectionStringRef
+		*out = new(SecretKeyRef)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageQueueConfig.
+func (in *StorageQueueConfig) DeepCopy() *StorageQueueConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageQueueConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransformConfig) DeepCopyInto(out *TransformConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransformConfig.
+func (in *TransformConfig) DeepCopy() *TransformConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TransformConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VariantConfig) DeepCopyInto(out *VariantConfig) {
+	*out = *in
+	if in.Transform != nil {
+		in, out := &in.Transform, &out.Transform
+		*out = new(TransformConfig)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VariantConfig.
+func (in *VariantConfig) DeepCopy() *VariantConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(VariantConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookConfig) DeepCopyInto(out *WebhookConfig) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookConfig.
+func (in *WebhookConfig) DeepCopy() *WebhookConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WeightedSource) DeepCopyInto(out *WeightedSource) {
+	*out = *in
+	in.ExternalMetricSpec.DeepCopyInto(&out.ExternalMetricSpec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WeightedSource.
+func (in *WeightedSource) DeepCopy() *WeightedSource {
+	if in == nil {
+		return nil
+	}
+	out := new(WeightedSource)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -0,0 +1,149 @@
+// Package audit records who requested which metric from the adapter and the values that
+// were served so they can be forwarded to an external system for retention
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+const (
+	customMetricsGroup   = "custom.metrics.k8s.io"
+	externalMetricsGroup = "external.metrics.k8s.io"
+
+	// maxCapturedBody limits how much of a response is kept to read the metric values
+	maxCapturedBody = 64 * 1024
+)
+
+// Record describes a single request to the custom or external metrics apis
+type Record struct {
+	Time      time.Time `json:"time"`
+	User      string    `json:"user"`
+	Groups    []string  `json:"groups,omitempty"`
+	Verb      string    `json:"verb"`
+	APIGroup  string    `json:"apiGroup"`
+	Namespace string    `json:"namespace,omitempty"`
+	Metric    string    `json:"metric"`
+	Path      string    `json:"path"`
+	Code      int       `json:"code"`
+	Values    []string  `json:"values,omitempty"`
+	// LatencyMilliseconds is the time taken to serve the request including the call to Azure
+	LatencyMilliseconds int64 `json:"latencyMilliseconds"`
+}
+
+// Sink receives audit records.  Send must not block serving the request.
+type Sink interface {
+	Send(record Record)
+}
+
+// Sinks sends each record to every sink
+type Sinks []Sink
+
+// Send sends the record to each sink
+func (s Sinks) Send(record Record) {
+	for _, sink := range s {
+		sink.Send(record)
+	}
+}
+
+// WithAudit sends a Record to the sink for every request to the metrics apis.
+// It must run after authentication and request info have been added to the context.
+func WithAudit(handler http.Handler, sink Sink) http.Handler {
+	if sink == nil {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		info, ok := request.RequestInfoFrom(ctx)
+		if !ok || !isMetricsRequest(info) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		start := time.Now()
+		recorder := &responseRecorder{ResponseWriter: w, code: http.StatusOK}
+		handler.ServeHTTP(recorder, req)
+
+		record := Record{
+			Time:                start.UTC(),
+			Verb:                info.Verb,
+			APIGroup:            info.APIGroup,
+			Namespace:           info.Namespace,
+			Metric:              metricName(info),
+			Path:                req.URL.Path,
+			Code:                recorder.code,
+			LatencyMilliseconds: int64(time.Since(start) / time.Millisecond),
+		}
+		if user, ok := request.UserFrom(ctx); ok {
+			record.User = user.GetName()
+			record.Groups = user.GetGroups()
+		}
+		if recorder.code == http.StatusOK {
+			record.Values = metricValues(recorder.body.Bytes())
+		}
+
+		sink.Send(record)
+	})
+}
+
+func isMetricsRequest(info *request.RequestInfo) bool {
+	return info.IsResourceRequest && (info.APIGroup == customMetricsGroup || info.APIGroup == externalMetricsGroup)
+}
+
+// metricName returns the metric being requested. External metrics are the resource of the
+// request while custom metrics are the subresource or name of a wildcard request.
+func metricName(info *request.RequestInfo) string {
+	if info.APIGroup == externalMetricsGroup {
+		return info.Resource
+	}
+	if info.Subresource != "" {
+		return info.Subresource
+	}
+	return info.Name
+}
+
+// metricValues reads the values from a MetricValueList or ExternalMetricValueList
+func metricValues(body []byte) []string {
+	list := struct {
+		Items []struct {
+			Value string `json:"value"`
+		} `json:"items"`
+	}{}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil
+	}
+
+	values := []string{}
+	for _, item := range list.Items {
+		values = append(values, item.Value)
+	}
+	return values
+}
+
+// responseRecorder keeps the status code and the start of the body written to the client
+type responseRecorder struct {
+	http.ResponseWriter
+	code int
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	r.code = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if remaining := maxCapturedBody - r.body.Len(); remaining > 0 {
+		if len(b) < remaining {
+			remaining = len(b)
+		}
+		r.body.Write(b[:remaining])
+	}
+	return r.ResponseWriter.Write(b)
+}
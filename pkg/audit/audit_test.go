@@ -0,0 +1,110 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+type fakeSink struct {
+	records []Record
+}
+
+func (f *fakeSink) Send(record Record) {
+	f.records = append(f.records, record)
+}
+
+func TestWithAuditRecordsMetricRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		info       *request.RequestInfo
+		body       string
+		wantMetric string
+		wantValues []string
+	}{
+		{
+			name:       "external metric",
+			info:       &request.RequestInfo{IsResourceRequest: true, Verb: "list", APIGroup: externalMetricsGroup, Namespace: "default", Resource: "queuemessages"},
+			body:       `{"kind":"ExternalMetricValueList","items":[{"metricName":"queuemessages","value":"42"}]}`,
+			wantMetric: "queuemessages",
+			wantValues: []string{"42"},
+		},
+		{
+			name:       "custom metric",
+			info:       &request.RequestInfo{IsResourceRequest: true, Verb: "get", APIGroup: customMetricsGroup, Namespace: "default", Resource: "pods", Name: "*", Subresource: "rps"},
+			body:       `{"kind":"MetricValueList","items":[{"metricName":"rps","value":"10500m"},{"metricName":"rps","value":"3"}]}`,
+			wantMetric: "rps",
+			wantValues: []string{"10500m", "3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &fakeSink{}
+			handler := WithAudit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, tt.body)
+			}), sink)
+
+			req := httptest.NewRequest("GET", "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/queuemessages", nil)
+			ctx := request.WithRequestInfo(req.Context(), tt.info)
+			ctx = request.WithUser(ctx, &user.DefaultInfo{Name: "alice", Groups: []string{"devs"}})
+			handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+			if len(sink.records) != 1 {
+				t.Fatalf("records = %v, want 1", len(sink.records))
+			}
+			record := sink.records[0]
+			if record.User != "alice" {
+				t.Errorf("User = %v, want alice", record.User)
+			}
+			if record.Metric != tt.wantMetric {
+				t.Errorf("Metric = %v, want %v", record.Metric, tt.wantMetric)
+			}
+			if record.Code != http.StatusOK {
+				t.Errorf("Code = %v, want %v", record.Code, http.StatusOK)
+			}
+			if !reflect.DeepEqual(record.Values, tt.wantValues) {
+				t.Errorf("Values = %v, want %v", record.Values, tt.wantValues)
+			}
+		})
+	}
+}
+
+func TestWithAuditRecordsFailedRequestsWithoutValues(t *testing.T) {
+	sink := &fakeSink{}
+	handler := WithAudit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"kind":"Status","code":403}`)
+	}), sink)
+
+	req := httptest.NewRequest("GET", "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/queuemessages", nil)
+	ctx := request.WithRequestInfo(req.Context(), &request.RequestInfo{IsResourceRequest: true, APIGroup: externalMetricsGroup, Resource: "queuemessages"})
+	handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+	if len(sink.records) != 1 {
+		t.Fatalf("records = %v, want 1", len(sink.records))
+	}
+	if sink.records[0].Code != http.StatusForbidden {
+		t.Errorf("Code = %v, want %v", sink.records[0].Code, http.StatusForbidden)
+	}
+	if len(sink.records[0].Values) != 0 {
+		t.Errorf("Values = %v, want none", sink.records[0].Values)
+	}
+}
+
+func TestWithAuditIgnoresOtherRequests(t *testing.T) {
+	sink := &fakeSink{}
+	handler := WithAudit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), sink)
+
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	ctx := request.WithRequestInfo(req.Context(), &request.RequestInfo{IsResourceRequest: false, Path: "/healthz"})
+	handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+
+	if len(sink.records) != 0 {
+		t.Errorf("records = %v, want none", sink.records)
+	}
+}
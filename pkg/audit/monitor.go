@@ -0,0 +1,229 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// monitorResource is the AAD resource of the Azure Monitor custom metrics ingestion api
+	monitorResource = "https://monitoring.azure.com/"
+
+	// DefaultMonitorNamespace is the custom metric namespace served values are exported to
+	DefaultMonitorNamespace = "azure-k8s-metrics-adapter"
+)
+
+var azureRegion = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// monitorDimensions are the dimensions of each exported value, in the order of their values
+var monitorDimensions = []string{"Namespace", "API", "User"}
+
+// MonitorSink exports the values served by the metrics apis to Azure Monitor custom metrics of a
+// resource, so scaling inputs can be charted and alerted on next to the platform metrics they are
+// read from.  Values are aggregated by minute, metric, namespace, api and requesting user, such as
+// the horizontal pod autoscaler, and are dropped when the ingestion api can not keep up.
+type MonitorSink struct {
+	url         string
+	namespace   string
+	credentials credentials.Source
+	client      *http.Client
+	queue       chan Record
+}
+
+// monitorMetric is a custom metric posted to the ingestion api
+type monitorMetric struct {
+	Time time.Time `json:"time"`
+	Data struct {
+		BaseData struct {
+			Metric    string          `json:"metric"`
+			Namespace string          `json:"namespace"`
+			DimNames  []string        `json:"dimNames"`
+			Series    []monitorSeries `json:"series"`
+		} `json:"baseData"`
+	} `json:"data"`
+}
+
+type monitorSeries struct {
+	DimValues []string `json:"dimValues"`
+	Min       float64  `json:"min"`
+	Max       float64  `json:"max"`
+	Sum       float64  `json:"sum"`
+	Count     int      `json:"count"`
+}
+
+// NewMonitorSink creates a sink exporting to the custom metrics of the resource with the given id
+// in the Azure region, authenticating with the source.  The identity needs the Monitoring Metrics
+// Publisher role on the resource.
+func NewMonitorSink(region string, resourceID string, metricNamespace string, source credentials.Source) (*MonitorSink, error) {
+	if !azureRegion.MatchString(region) {
+		return nil, fmt.Errorf("invalid azure region '%s', must be a region name such as westus2", region)
+	}
+	if !strings.HasPrefix(resourceID, "/subscriptions/") {
+		return nil, fmt.Errorf("invalid resource id '%s', must start with /subscriptions/", resourceID)
+	}
+	if metricNamespace == "" {
+		metricNamespace = DefaultMonitorNamespace
+	}
+
+	return &MonitorSink{
+		url:         fmt.Sprintf("https://%s.monitoring.azure.com%s/metrics", region, strings.TrimSuffix(resourceID, "/")),
+		namespace:   metricNamespace,
+		credentials: source,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		queue:       make(chan Record, queueSize),
+	}, nil
+}
+
+// Send queues the values of a served request to be exported
+func (s *MonitorSink) Send(record Record) {
+	if record.Code != http.StatusOK || len(record.Values) == 0 {
+		return
+	}
+
+	select {
+	case s.queue <- record:
+	default:
+		glog.Warningf("azure monitor export queue is full, dropping values for %s", record.Path)
+	}
+}
+
+// Run exports the queued values every flushInterval until stopCh is closed
+func (s *MonitorSink) Run(flushInterval time.Duration, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := []Record{}
+	flush := func() {
+		for _, metric := range s.aggregate(batch) {
+			if err := s.post(metric); err != nil {
+				glog.Errorf("unable to export metric %s to azure monitor: %v", metric.Data.BaseData.Metric, err)
+			}
+		}
+		batch = []Record{}
+	}
+
+	for {
+		select {
+		case record := <-s.queue:
+			batch = append(batch, record)
+		case <-ticker.C:
+			flush()
+		case <-stopCh:
+			flush()
+			return
+		}
+	}
+}
+
+// aggregate summarizes the values of the records for each minute and metric, with a series for
+// each namespace, api and user
+func (s *MonitorSink) aggregate(batch []Record) []*monitorMetric {
+	keys := []string{}
+	metrics := map[string]*monitorMetric{}
+	series := map[string]map[string]*monitorSeries{}
+	for _, record := range batch {
+		minute := record.Time.Truncate(time.Minute)
+		key := fmt.Sprintf("%d/%s", minute.Unix(), record.Metric)
+		if _, found := metrics[key]; !found {
+			metric := &monitorMetric{Time: minute}
+			metric.Data.BaseData.Metric = record.Metric
+			metric.Data.BaseData.Namespace = s.namespace
+			metric.Data.BaseData.DimNames = monitorDimensions
+			metrics[key] = metric
+			series[key] = map[string]*monitorSeries{}
+			keys = append(keys, key)
+		}
+
+		api := "custom"
+		if record.APIGroup == externalMetricsGroup {
+			api = "external"
+		}
+		dimValues := []string{record.Namespace, api, record.User}
+		dimKey := strings.Join(dimValues, "/")
+		for _, served := range record.Values {
+			quantity, err := resource.ParseQuantity(served)
+			if err != nil {
+				continue
+			}
+			value := float64(quantity.MilliValue()) / 1000
+
+			values, found := series[key][dimKey]
+			if !found {
+				values = &monitorSeries{DimValues: dimValues, Min: value, Max: value}
+				series[key][dimKey] = values
+			}
+			if value < values.Min {
+				values.Min = value
+			}
+			if value > values.Max {
+				values.Max = value
+			}
+			values.Sum += value
+			values.Count++
+		}
+	}
+
+	aggregated := []*monitorMetric{}
+	for _, key := range keys {
+		dimKeys := []string{}
+		for dimKey := range series[key] {
+			dimKeys = append(dimKeys, dimKey)
+		}
+		if len(dimKeys) == 0 {
+			continue
+		}
+		sort.Strings(dimKeys)
+
+		metric := metrics[key]
+		for _, dimKey := range dimKeys {
+			metric.Data.BaseData.Series = append(metric.Data.BaseData.Series, *series[key][dimKey])
+		}
+		aggregated = append(aggregated, metric)
+	}
+	return aggregated
+}
+
+func (s *MonitorSink) post(metric *monitorMetric) error {
+	body, err := json.Marshal(metric)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return redact.Error(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	authorizer, err := s.credentials.Authorizer(monitorResource)
+	if err != nil {
+		return redact.Error(err)
+	}
+	if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+		return redact.Error(err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("azure monitor returned %s", resp.Status)
+	}
+
+	return nil
+}
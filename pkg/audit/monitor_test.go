@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+func TestNewMonitorSinkValidatesTarget(t *testing.T) {
+	if _, err := NewMonitorSink("West US", "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/aks", "", nullCredentialSource{}); err == nil {
+		t.Errorf("NewMonitorSink() error = nil, want error for invalid region")
+	}
+	if _, err := NewMonitorSink("westus2", "aks", "", nullCredentialSource{}); err == nil {
+		t.Errorf("NewMonitorSink() error = nil, want error for invalid resource id")
+	}
+
+	sink, err := NewMonitorSink("westus2", "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/aks", "", nullCredentialSource{})
+	if err != nil {
+		t.Fatalf("NewMonitorSink() error = %v", err)
+	}
+	want := "https://westus2.monitoring.azure.com/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/aks/metrics"
+	if sink.url != want || sink.namespace != DefaultMonitorNamespace {
+		t.Errorf("url = %v and namespace = %v, want %v and %v", sink.url, sink.namespace, want, DefaultMonitorNamespace)
+	}
+}
+
+func TestMonitorSinkAggregatesByMinute(t *testing.T) {
+	sink, _ := NewMonitorSink("westus2", "/subscriptions/sub", "", nullCredentialSource{})
+	minute := time.Date(2019, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	metrics := sink.aggregate([]Record{
+		{Time: minute.Add(5 * time.Second), APIGroup: externalMetricsGroup, Namespace: "default", Metric: "queue", User: "hpa", Values: []string{"10"}},
+		{Time: minute.Add(20 * time.Second), APIGroup: externalMetricsGroup, Namespace: "default", Metric: "queue", User: "hpa", Values: []string{"1500m"}},
+		{Time: minute.Add(30 * time.Second), APIGroup: customMetricsGroup, Namespace: "default", Metric: "queue", User: "alice", Values: []string{"2", "4"}},
+		{Time: minute.Add(70 * time.Second), APIGroup: externalMetricsGroup, Namespace: "default", Metric: "queue", User: "hpa", Values: []string{"7"}},
+	})
+
+	if len(metrics) != 2 {
+		t.Fatalf("metrics = %v, want one for each minute", len(metrics))
+	}
+	first := metrics[0].Data.BaseData
+	if !metrics[0].Time.Equal(minute) || first.Metric != "queue" || first.Namespace != DefaultMonitorNamespace {
+		t.Errorf("metric = %v %s/%s, want %v %s/queue", metrics[0].Time, first.Namespace, first.Metric, minute, DefaultMonitorNamespace)
+	}
+	if len(first.Series) != 2 {
+		t.Fatalf("series = %+v, want one for each api and user", first.Series)
+	}
+	custom := monitorSeries{DimValues: []string{"default", "custom", "alice"}, Min: 2, Max: 4, Sum: 6, Count: 2}
+	external := monitorSeries{DimValues: []string{"default", "external", "hpa"}, Min: 1.5, Max: 10, Sum: 11.5, Count: 2}
+	for i, want := range []monitorSeries{custom, external} {
+		if !reflect.DeepEqual(first.Series[i], want) {
+			t.Errorf("series[%d] = %+v, want %+v", i, first.Series[i], want)
+		}
+	}
+}
+
+func TestMonitorSinkPostsMetric(t *testing.T) {
+	var gotBody map[string]interface{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&gotBody)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sink, _ := NewMonitorSink("westus2", "/subscriptions/sub", "", nullCredentialSource{})
+	sink.url = server.URL
+	sink.client = server.Client()
+
+	metrics := sink.aggregate([]Record{{Time: time.Now(), Metric: "queue", Values: []string{"3"}}})
+	if err := sink.post(metrics[0]); err != nil {
+		t.Fatalf("post() error = %v", err)
+	}
+	if _, ok := gotBody["data"]; !ok {
+		t.Errorf("body = %v, want the custom metric data", gotBody)
+	}
+}
+
+func TestMonitorSinkSkipsFailedRequests(t *testing.T) {
+	sink, _ := NewMonitorSink("westus2", "/subscriptions/sub", "", nullCredentialSource{})
+
+	sink.Send(Record{Code: http.StatusNotFound, Values: []string{"1"}})
+	sink.Send(Record{Code: http.StatusOK})
+	sink.Send(Record{Code: http.StatusOK, Values: []string{"1"}})
+
+	if len(sink.queue) != 1 {
+		t.Errorf("queue length = %v, want %v", len(sink.queue), 1)
+	}
+}
+
+type nullCredentialSource struct{}
+
+func (nullCredentialSource) Authorizer(resource string) (autorest.Authorizer, error) {
+	return autorest.NullAuthorizer{}, nil
+}
+
+func (nullCredentialSource) Value(name string) string {
+	return ""
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/golang/glog"
+)
+
+const (
+	// FormatJSON posts each batch as a json array of records
+	FormatJSON = "json"
+	// FormatEventHub posts each batch using the Event Hubs REST batch format so
+	// records can be sent directly to an Event Hub
+	FormatEventHub = "eventhub"
+
+	queueSize    = 1000
+	maxBatchSize = 100
+)
+
+// WebhookSink posts batches of audit records to an https endpoint.  Records are
+// queued and dropped when the endpoint can not keep up so serving metrics is never slowed down.
+type WebhookSink struct {
+	url               string
+	format            string
+	authorizationFile string
+	client            *http.Client
+	queue             chan Record
+}
+
+// NewWebhookSink creates a sink that posts to the given https url.  The contents of authorizationFile,
+// if set, are sent as the Authorization header; for example a bearer token or Event Hub SAS token.
+func NewWebhookSink(webhookURL string, format string, authorizationFile string) (*WebhookSink, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid audit webhook url: %v", redact.Error(err))
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("audit webhook url must use https")
+	}
+
+	if format != FormatJSON && format != FormatEventHub {
+		return nil, fmt.Errorf("unknown audit webhook format %q, must be %s or %s", format, FormatJSON, FormatEventHub)
+	}
+
+	return &WebhookSink{
+		url:               webhookURL,
+		format:            format,
+		authorizationFile: authorizationFile,
+		client:            &http.Client{Timeout: 10 * time.Second},
+		queue:             make(chan Record, queueSize),
+	}, nil
+}
+
+// Send queues the record to be posted
+func (s *WebhookSink) Send(record Record) {
+	select {
+	case s.queue <- record:
+	default:
+		glog.Warningf("audit queue is full, dropping record for %s", record.Path)
+	}
+}
+
+// Run posts queued records every flushInterval, or sooner when a full batch is waiting, until stopCh is closed
+func (s *WebhookSink) Run(flushInterval time.Duration, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := []Record{}
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.post(batch); err != nil {
+			glog.Errorf("unable to send %d audit records: %v", len(batch), err)
+		}
+		batch = []Record{}
+	}
+
+	for {
+		select {
+		case record := <-s.queue:
+			batch = append(batch, record)
+			if len(batch) >= maxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-stopCh:
+			flush()
+			return
+		}
+	}
+}
+
+func (s *WebhookSink) post(batch []Record) error {
+	body, contentType, err := s.encode(batch)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return redact.Error(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	if s.authorizationFile != "" {
+		// read for every batch so rotated tokens are picked up
+		authorization, err := ioutil.ReadFile(s.authorizationFile)
+		if err != nil {
+			return fmt.Errorf("unable to read audit webhook authorization: %v", err)
+		}
+		req.Header.Set("Authorization", strings.TrimSpace(string(authorization)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("audit webhook returned %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (s *WebhookSink) encode(batch []Record) ([]byte, string, error) {
+	if s.format == FormatJSON {
+		body, err := json.Marshal(batch)
+		return body, "application/json", err
+	}
+
+	type eventHubMessage struct {
+		Body string `json:"Body"`
+	}
+
+	messages := []eventHubMessage{}
+	for _, record := range batch {
+		event, err := json.Marshal(record)
+		if err != nil {
+			return nil, "", err
+		}
+		messages = append(messages, eventHubMessage{Body: string(event)})
+	}
+
+	body, err := json.Marshal(messages)
+	return body, "application/vnd.microsoft.servicebus.json", err
+}
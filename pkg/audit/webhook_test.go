@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestNewWebhookSinkRequiresHTTPS(t *testing.T) {
+	if _, err := NewWebhookSink("http://audit.example.com", FormatJSON, ""); err == nil {
+		t.Errorf("NewWebhookSink() error = nil, want error for http url")
+	}
+	if _, err := NewWebhookSink("https://audit.example.com", "xml", ""); err == nil {
+		t.Errorf("NewWebhookSink() error = nil, want error for unknown format")
+	}
+}
+
+func TestWebhookSinkPostsBatch(t *testing.T) {
+	tests := []struct {
+		name            string
+		format          string
+		wantContentType string
+	}{
+		{
+			name:            "json",
+			format:          FormatJSON,
+			wantContentType: "application/json",
+		},
+		{
+			name:            "event hub",
+			format:          FormatEventHub,
+			wantContentType: "application/vnd.microsoft.servicebus.json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotContentType, gotAuthorization string
+			var gotBody []map[string]interface{}
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotContentType = r.Header.Get("Content-Type")
+				gotAuthorization = r.Header.Get("Authorization")
+				json.NewDecoder(r.Body).Decode(&gotBody)
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			tokenFile, _ := ioutil.TempFile("", "token")
+			defer os.Remove(tokenFile.Name())
+			tokenFile.WriteString("Bearer token\n")
+			tokenFile.Close()
+
+			sink, err := NewWebhookSink(server.URL, tt.format, tokenFile.Name())
+			if err != nil {
+				t.Fatalf("NewWebhookSink() error = %v", err)
+			}
+			sink.client = server.Client()
+
+			err = sink.post([]Record{{User: "alice", Metric: "queuemessages"}, {User: "bob", Metric: "rps"}})
+			if err != nil {
+				t.Fatalf("post() error = %v", err)
+			}
+
+			if gotContentType != tt.wantContentType {
+				t.Errorf("Content-Type = %v, want %v", gotContentType, tt.wantContentType)
+			}
+			if gotAuthorization != "Bearer token" {
+				t.Errorf("Authorization = %v, want %v", gotAuthorization, "Bearer token")
+			}
+			if len(gotBody) != 2 {
+				t.Errorf("posted %v records, want 2", len(gotBody))
+			}
+		})
+	}
+}
+
+func TestWebhookSinkReturnsErrorStatus(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sink, _ := NewWebhookSink(server.URL, FormatJSON, "")
+	sink.client = server.Client()
+
+	if err := sink.post([]Record{{User: "alice"}}); err == nil {
+		t.Errorf("post() error = nil, want error")
+	}
+}
+
+func TestWebhookSinkDropsWhenQueueIsFull(t *testing.T) {
+	sink, _ := NewWebhookSink("https://audit.example.com", FormatJSON, "")
+
+	for i := 0; i < queueSize+10; i++ {
+		sink.Send(Record{})
+	}
+
+	if len(sink.queue) != queueSize {
+		t.Errorf("queue length = %v, want %v", len(sink.queue), queueSize)
+	}
+}
@@ -0,0 +1,121 @@
+package credentials
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"golang.org/x/crypto/pkcs12"
+)
+
+// CertificateFileAuthorizer returns an authorizer of the service principal authenticating with the
+// certificate in the file.  The file is either PKCS#12 (pfx) or PEM holding the certificate and its
+// RSA private key.
+func CertificateFileAuthorizer(activeDirectoryEndpoint string, tenantID string, clientID string, path string, password string, resource string) (autorest.Authorizer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the certificate file (%s): %v", path, err)
+	}
+	return CertificateAuthorizer(activeDirectoryEndpoint, tenantID, clientID, data, password, resource)
+}
+
+// CertificateAuthorizer returns an authorizer of the service principal authenticating with the
+// PKCS#12 or PEM certificate
+func CertificateAuthorizer(activeDirectoryEndpoint string, tenantID string, clientID string, data []byte, password string, resource string) (autorest.Authorizer, error) {
+	certificate, privateKey, err := decodeCertificate(data, password)
+	if err != nil {
+		return nil, err
+	}
+
+	oauthConfig, err := adal.NewOAuthConfig(activeDirectoryEndpoint, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := adal.NewServicePrincipalTokenFromCertificate(*oauthConfig, clientID, certificate, privateKey, resource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oauth token from certificate auth: %v", err)
+	}
+	return autorest.NewBearerAuthorizer(token), nil
+}
+
+// decodeCertificate reads the certificate and RSA private key of a PEM file, or a PKCS#12 file
+// when it isn't PEM
+func decodeCertificate(data []byte, password string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	if !bytes.Contains(data, []byte("-----BEGIN")) {
+		key, certificate, err := pkcs12.Decode(data, password)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to decode pkcs12 certificate: %v", err)
+		}
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, nil, fmt.Errorf("PKCS#12 certificate must contain an RSA private key")
+		}
+		return certificate, rsaKey, nil
+	}
+
+	var certificate *x509.Certificate
+	var privateKey *rsa.PrivateKey
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "CERTIFICATE":
+			// the first certificate is the service principal's, any others are its chain
+			if certificate != nil {
+				continue
+			}
+			parsed, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to parse PEM certificate: %v", err)
+			}
+			certificate = parsed
+		case "RSA PRIVATE KEY", "PRIVATE KEY":
+			key, err := parsePrivateKey(block, password)
+			if err != nil {
+				return nil, nil, err
+			}
+			privateKey = key
+		}
+	}
+
+	if certificate == nil {
+		return nil, nil, fmt.Errorf("PEM certificate file has no CERTIFICATE block")
+	}
+	if privateKey == nil {
+		return nil, nil, fmt.Errorf("PEM certificate file has no RSA private key")
+	}
+	return certificate, privateKey, nil
+}
+
+func parsePrivateKey(block *pem.Block, password string) (*rsa.PrivateKey, error) {
+	der := block.Bytes
+	if x509.IsEncryptedPEMBlock(block) {
+		var err error
+		der, err = x509.DecryptPEMBlock(block, []byte(password))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt PEM private key: %v", err)
+		}
+	}
+
+	if block.Type == "RSA PRIVATE KEY" {
+		key, err := x509.ParsePKCS1PrivateKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse PEM private key: %v", err)
+		}
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PEM private key: %v", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("PEM certificate must contain an RSA private key")
+	}
+	return rsaKey, nil
+}
@@ -0,0 +1,111 @@
+package credentials
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestDecodePEMCertificate(t *testing.T) {
+	key, certificate := newTestCertificate(t)
+	certificateBlock := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(key)
+	encrypted, _ := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key), []byte("s3cret"), x509.PEMCipherAES256)
+
+	var tests = []struct {
+		name     string
+		data     []byte
+		password string
+	}{
+		{"pkcs1 key", append(certificateBlock, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})...), ""},
+		{"pkcs8 key before certificate", append(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}), certificateBlock...), ""},
+		{"encrypted key", append(certificateBlock, pem.EncodeToMemory(encrypted)...), "s3cret"},
+	}
+
+	for _, tt := range tests {
+		decodedCertificate, decodedKey, err := decodeCertificate(tt.data, tt.password)
+		if err != nil {
+			t.Errorf("%s: error = %v, want nil", tt.name, err)
+			continue
+		}
+		if decodedCertificate.Subject.CommonName != "adapter" {
+			t.Errorf("%s: certificate = %v, want %v", tt.name, decodedCertificate.Subject.CommonName, "adapter")
+		}
+		if decodedKey.N.Cmp(key.N) != 0 {
+			t.Errorf("%s: private key is not the key of the certificate", tt.name)
+		}
+	}
+}
+
+func TestDecodeInvalidCertificateGetsError(t *testing.T) {
+	key, certificate := newTestCertificate(t)
+	certificateBlock := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})
+	keyBlock := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	encrypted, _ := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key), []byte("s3cret"), x509.PEMCipherAES256)
+
+	var tests = []struct {
+		name     string
+		data     []byte
+		password string
+	}{
+		{"no key", certificateBlock, ""},
+		{"no certificate", keyBlock, ""},
+		{"wrong password", append(certificateBlock, pem.EncodeToMemory(encrypted)...), "other"},
+		{"not pkcs12", []byte("not a certificate"), ""},
+	}
+
+	for _, tt := range tests {
+		if _, _, err := decodeCertificate(tt.data, tt.password); err == nil {
+			t.Errorf("%s: error = nil, want error", tt.name)
+		}
+	}
+}
+
+func TestNamedSourceWithCertificate(t *testing.T) {
+	key, certificate := newTestCertificate(t)
+	data := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+
+	source, err := NewNamedSource(Config{TenantID: "tenant", ClientID: "client", ClientCertificate: data})
+	if err != nil {
+		t.Fatalf("NewNamedSource() error = %v, want nil", err)
+	}
+	if _, err := source.Authorizer(""); err != nil {
+		t.Errorf("Authorizer() error = %v, want nil", err)
+	}
+
+	var invalid = []Config{
+		{ClientID: "client", ClientCertificate: data},
+		{TenantID: "tenant", ClientID: "client", ClientCertificate: data, ClientSecret: "secret"},
+		{TenantID: "tenant", ClientID: "client", ClientCertificate: "not a certificate"},
+	}
+	for _, config := range invalid {
+		if _, err := NewNamedSource(config); err == nil {
+			t.Errorf("NewNamedSource() error = nil, want error")
+		}
+	}
+}
+
+func newTestCertificate(t *testing.T) (*rsa.PrivateKey, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "adapter"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unable to create certificate: %v", err)
+	}
+	return key, certificate
+}
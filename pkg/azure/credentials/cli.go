@@ -0,0 +1,166 @@
+package credentials
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+// cliTokenRefreshMargin is how long before it expires a token of the Azure CLI is requested again
+const cliTokenRefreshMargin = 5 * time.Minute
+
+// cliExpiresOnLayout is the local time the Azure CLI writes as the expiry of a token
+const cliExpiresOnLayout = "2006-01-02 15:04:05.999999"
+
+// CLISource authenticates as the user, or service principal, logged in to the Azure CLI, so the
+// adapter can be run outside of a cluster during development.  Tokens are requested with
+// `az account get-access-token` and other values are read from the environment.
+type CLISource struct {
+	// az runs the Azure CLI with the arguments and returns its output
+	az  func(args ...string) ([]byte, error)
+	now func() time.Time
+
+	mu          sync.Mutex
+	authorizers map[string]*cliAuthorizer
+}
+
+// NewCLISource creates a source that requests tokens from the az command
+func NewCLISource() *CLISource {
+	return &CLISource{
+		az:          runAzureCLI,
+		now:         time.Now,
+		authorizers: map[string]*cliAuthorizer{},
+	}
+}
+
+func runAzureCLI(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("az", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("az %s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Authorizer returns an authorizer with the Azure CLI's tokens for the resource.  The authorizers
+// of a resource share their token.
+func (s *CLISource) Authorizer(resource string) (autorest.Authorizer, error) {
+	if resource == "" {
+		environment, err := Environment()
+		if err != nil {
+			return nil, err
+		}
+		resource = environment.ResourceManagerEndpoint
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	authorizer, found := s.authorizers[resource]
+	if !found {
+		authorizer = &cliAuthorizer{source: s, resource: resource}
+		s.authorizers[resource] = authorizer
+	}
+	return authorizer, nil
+}
+
+// Value returns the value of the environment variable
+func (s *CLISource) Value(name string) string {
+	return os.Getenv(name)
+}
+
+// SubscriptionID returns the subscription selected in the Azure CLI
+func (s *CLISource) SubscriptionID() (string, error) {
+	out, err := s.az("account", "show", "--output", "json")
+	if err != nil {
+		return "", err
+	}
+
+	var account struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(out, &account); err != nil {
+		return "", fmt.Errorf("unable to parse the azure cli account: %v", err)
+	}
+	return account.ID, nil
+}
+
+// cliAuthorizer adds the Azure CLI's token for the resource to requests, requesting a new one
+// shortly before it expires
+type cliAuthorizer struct {
+	source   *CLISource
+	resource string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+type cliAccessToken struct {
+	AccessToken string `json:"accessToken"`
+	// ExpiresOn is the local time the token expires
+	ExpiresOn string `json:"expiresOn"`
+	// UnixExpiresOn is the time the token expires in seconds since the epoch, written by newer versions
+	UnixExpiresOn int64 `json:"expires_on"`
+}
+
+// WithAuthorization returns a decorator that sets the bearer token of the request
+func (a *cliAuthorizer) WithAuthorization() autorest.PrepareDecorator {
+	return func(p autorest.Preparer) autorest.Preparer {
+		return autorest.PreparerFunc(func(r *http.Request) (*http.Request, error) {
+			r, err := p.Prepare(r)
+			if err != nil {
+				return r, err
+			}
+			token, err := a.accessToken()
+			if err != nil {
+				return r, err
+			}
+			return autorest.Prepare(r, autorest.WithHeader("Authorization", "Bearer "+token))
+		})
+	}
+}
+
+func (a *cliAuthorizer) accessToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.source.now()
+	if a.token != "" && now.Add(cliTokenRefreshMargin).Before(a.expires) {
+		return a.token, nil
+	}
+
+	out, err := a.source.az("account", "get-access-token", "--resource", a.resource, "--output", "json")
+	if err != nil {
+		return "", err
+	}
+	var token cliAccessToken
+	if err := json.Unmarshal(out, &token); err != nil {
+		return "", fmt.Errorf("unable to parse the azure cli token: %v", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("the azure cli returned no token for %s", a.resource)
+	}
+
+	expires := time.Unix(token.UnixExpiresOn, 0)
+	if token.UnixExpiresOn == 0 {
+		expires, err = time.ParseInLocation(cliExpiresOnLayout, token.ExpiresOn, time.Local)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse the expiry of the azure cli token: %v", err)
+		}
+	}
+
+	glog.V(2).Infof("using azure cli token for %s until %s", a.resource, expires.Format(time.RFC3339))
+	a.token, a.expires = token.AccessToken, expires
+	return a.token, nil
+}
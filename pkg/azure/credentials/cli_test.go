@@ -0,0 +1,91 @@
+package credentials
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+func TestCLITokenReusedUntilNearExpiry(t *testing.T) {
+	now := time.Now()
+	calls := 0
+	source := NewCLISource()
+	source.now = func() time.Time { return now }
+	source.az = func(args ...string) ([]byte, error) {
+		calls++
+		if got := strings.Join(args, " "); got != "account get-access-token --resource https://api.applicationinsights.io --output json" {
+			t.Errorf("az args = %v, want a token of the resource", got)
+		}
+		return []byte(fmt.Sprintf(`{"accessToken": "token-%d", "expires_on": %d, "tokenType": "Bearer"}`, calls, now.Add(time.Hour).Unix())), nil
+	}
+
+	var tests = []struct {
+		after     time.Duration
+		wantToken string
+	}{
+		{0, "token-1"},
+		{50 * time.Minute, "token-1"},
+		{56 * time.Minute, "token-2"},
+	}
+
+	for _, tt := range tests {
+		now = now.Add(tt.after)
+		authorizer, err := source.Authorizer("https://api.applicationinsights.io")
+		if err != nil {
+			t.Fatalf("error = %v, want nil", err)
+		}
+		req, _ := http.NewRequest("GET", "https://api.applicationinsights.io/v1/apps", nil)
+		req, err = autorest.Prepare(req, authorizer.WithAuthorization())
+		if err != nil {
+			t.Fatalf("error = %v, want nil", err)
+		}
+		if got := req.Header.Get("Authorization"); got != "Bearer "+tt.wantToken {
+			t.Errorf("after %s: authorization = %v, want %v", tt.after, got, "Bearer "+tt.wantToken)
+		}
+	}
+}
+
+func TestCLITokenExpiresOnLocalTime(t *testing.T) {
+	source := NewCLISource()
+	source.az = func(args ...string) ([]byte, error) {
+		return []byte(`{"accessToken": "token", "expiresOn": "2019-03-10 01:30:00.123456", "tokenType": "Bearer"}`), nil
+	}
+
+	authorizer, _ := source.Authorizer("https://management.azure.com/")
+	if _, err := authorizer.(*cliAuthorizer).accessToken(); err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+	want := time.Date(2019, 3, 10, 1, 30, 0, 123456000, time.Local)
+	if got := authorizer.(*cliAuthorizer).expires; !got.Equal(want) {
+		t.Errorf("expires = %v, want %v", got, want)
+	}
+}
+
+func TestCLIErrorFailsRequest(t *testing.T) {
+	source := NewCLISource()
+	source.az = func(args ...string) ([]byte, error) {
+		return nil, fmt.Errorf("az account get-access-token failed: Please run 'az login' to setup account")
+	}
+
+	authorizer, _ := source.Authorizer("https://management.azure.com/")
+	req, _ := http.NewRequest("GET", "https://management.azure.com/subscriptions", nil)
+	if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err == nil || !strings.Contains(err.Error(), "az login") {
+		t.Errorf("error = %v, want the azure cli error", err)
+	}
+}
+
+func TestCLISubscriptionID(t *testing.T) {
+	source := NewCLISource()
+	source.az = func(args ...string) ([]byte, error) {
+		return []byte(`{"id": "subscription", "name": "dev", "tenantId": "tenant"}`), nil
+	}
+
+	subscriptionID, err := source.SubscriptionID()
+	if err != nil || subscriptionID != "subscription" {
+		t.Errorf("subscription = %v, %v, want %v", subscriptionID, err, "subscription")
+	}
+}
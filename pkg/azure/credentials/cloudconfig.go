@@ -0,0 +1,178 @@
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// CloudConfigSource reads credentials from the config file of the kubernetes azure cloud provider,
+// /etc/kubernetes/azure.json on AKS and aks-engine nodes, so the service principal or managed
+// identity of the cluster doesn't have to be copied into the adapter's environment.  The file is
+// read again at an interval so credentials rotated on the nodes are picked up.
+type CloudConfigSource struct {
+	path string
+
+	mutex       sync.Mutex
+	config      cloudConfig
+	authorizers map[string]autorest.Authorizer
+}
+
+// cloudConfig holds the fields of the cloud provider config the adapter authenticates with
+type cloudConfig struct {
+	Cloud                       string `json:"cloud"`
+	TenantID                    string `json:"tenantId"`
+	SubscriptionID              string `json:"subscriptionId"`
+	AADClientID                 string `json:"aadClientId"`
+	AADClientSecret             string `json:"aadClientSecret"`
+	AADClientCertPath           string `json:"aadClientCertPath"`
+	AADClientCertPassword       string `json:"aadClientCertPassword"`
+	UseManagedIdentityExtension bool   `json:"useManagedIdentityExtension"`
+	UserAssignedIdentityID      string `json:"userAssignedIdentityID"`
+}
+
+// NewCloudConfigSource creates a Source that reads credentials from the cloud provider config at path
+func NewCloudConfigSource(path string) *CloudConfigSource {
+	return &CloudConfigSource{
+		path:        path,
+		authorizers: make(map[string]autorest.Authorizer),
+	}
+}
+
+// Load reads the config file, rebuilding the authorizers when it changed
+func (c *CloudConfigSource) Load() error {
+	content, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return err
+	}
+	var config cloudConfig
+	if err := json.Unmarshal(content, &config); err != nil {
+		return fmt.Errorf("unable to parse cloud provider config %s: %v", c.path, err)
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if config == c.config {
+		return nil
+	}
+	if c.config != (cloudConfig{}) {
+		glog.V(2).Infof("cloud provider config %s changed, reloading azure authorizers", c.path)
+	}
+	c.config = config
+	c.authorizers = make(map[string]autorest.Authorizer)
+	return nil
+}
+
+// Watch reads the config file at the given interval until stopCh is closed.  The last config read
+// is kept while the file can't be read.
+func (c *CloudConfigSource) Watch(interval time.Duration, stopCh <-chan struct{}) {
+	go wait.Until(func() {
+		if err := c.Load(); err != nil {
+			glog.Errorf("unable to read cloud provider config %s: %v", c.path, err)
+		}
+	}, interval, stopCh)
+}
+
+// Cloud returns the name of the azure cloud of the config, such as AzurePublicCloud
+func (c *CloudConfigSource) Cloud() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.config.Cloud
+}
+
+// SubscriptionID returns the subscription of the cluster
+func (c *CloudConfigSource) SubscriptionID() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.config.SubscriptionID
+}
+
+// Value returns the named credential from the config file.  Values the config doesn't hold, such
+// as the App Insights api key, are read from the environment.
+func (c *CloudConfigSource) Value(name string) string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	switch name {
+	case TenantID:
+		return c.config.TenantID
+	case ClientID:
+		if c.config.UseManagedIdentityExtension {
+			// aadClientId is set to msi when the managed identity is used
+			return c.config.UserAssignedIdentityID
+		}
+		return c.config.AADClientID
+	case ClientSecret:
+		if c.config.UseManagedIdentityExtension {
+			return ""
+		}
+		return c.config.AADClientSecret
+	case CertificatePath:
+		return c.config.AADClientCertPath
+	case CertificatePassword:
+		return c.config.AADClientCertPassword
+	}
+	return os.Getenv(name)
+}
+
+// Authorizer returns an authorizer that always uses the latest config read
+func (c *CloudConfigSource) Authorizer(resource string) (autorest.Authorizer, error) {
+	return reloadingAuthorizer{source: c, resource: resource}, nil
+}
+
+func (c *CloudConfigSource) current(resource string) (autorest.Authorizer, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if authorizer, ok := c.authorizers[resource]; ok {
+		return authorizer, nil
+	}
+	if c.config == (cloudConfig{}) {
+		return nil, fmt.Errorf("cloud provider config %s has not been read", c.path)
+	}
+
+	authorizer, err := c.newAuthorizer(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	c.authorizers[resource] = authorizer
+	return authorizer, nil
+}
+
+func (c *CloudConfigSource) newAuthorizer(resource string) (autorest.Authorizer, error) {
+	environment, err := Environment()
+	if err != nil {
+		return nil, err
+	}
+
+	if resource == "" {
+		resource = environment.ResourceManagerEndpoint
+	}
+
+	config := c.config
+	switch {
+	case config.UseManagedIdentityExtension:
+		return MSIAuthorizer(config.UserAssignedIdentityID, "", resource)
+	case config.AADClientSecret != "":
+		glog.V(2).Info("using client secret from cloud provider config for azure authentication")
+		credentialsConfig := auth.NewClientCredentialsConfig(config.AADClientID, config.AADClientSecret, config.TenantID)
+		credentialsConfig.AADEndpoint = environment.ActiveDirectoryEndpoint
+		credentialsConfig.Resource = resource
+		return credentialsConfig.Authorizer()
+	case config.AADClientCertPath != "":
+		glog.V(2).Info("using client certificate from cloud provider config for azure authentication")
+		return CertificateFileAuthorizer(environment.ActiveDirectoryEndpoint, config.TenantID, config.AADClientID, config.AADClientCertPath, config.AADClientCertPassword, resource)
+	default:
+		return nil, fmt.Errorf("cloud provider config %s has neither a managed identity nor a service principal secret or certificate", c.path)
+	}
+}
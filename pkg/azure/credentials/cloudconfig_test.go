@@ -0,0 +1,91 @@
+package credentials
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCloudConfigSourceReadsServicePrincipal(t *testing.T) {
+	path := newCloudConfig(t, `{"cloud":"AzurePublicCloud","tenantId":"tenant","subscriptionId":"1234","aadClientId":"client","aadClientSecret":"secret"}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	source := NewCloudConfigSource(path)
+	if _, err := source.current(""); err == nil {
+		t.Errorf("current() error = nil before the config was read, want error")
+	}
+	if err := source.Load(); err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+
+	if got := source.SubscriptionID(); got != "1234" {
+		t.Errorf("SubscriptionID() = %v, want 1234", got)
+	}
+	if got := source.Value(ClientID); got != "client" {
+		t.Errorf("Value(ClientID) = %v, want client", got)
+	}
+	if got := source.Value(ClientSecret); got != "secret" {
+		t.Errorf("Value(ClientSecret) = %v, want secret", got)
+	}
+	first, err := source.current("")
+	if err != nil {
+		t.Fatalf("current() error = %v, want nil", err)
+	}
+
+	// the secret is rotated on the node
+	if err := ioutil.WriteFile(path, []byte(`{"cloud":"AzurePublicCloud","tenantId":"tenant","subscriptionId":"1234","aadClientId":"client","aadClientSecret":"rotated"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	source.Load()
+	if rotated, _ := source.current(""); rotated == first {
+		t.Errorf("authorizer was not rebuilt when the secret was rotated")
+	}
+}
+
+func TestCloudConfigSourceReadsManagedIdentity(t *testing.T) {
+	path := newCloudConfig(t, `{"tenantId":"tenant","aadClientId":"msi","aadClientSecret":"msi","useManagedIdentityExtension":true,"userAssignedIdentityID":"kubelet"}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	source := NewCloudConfigSource(path)
+	if err := source.Load(); err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+
+	if got := source.Value(ClientID); got != "kubelet" {
+		t.Errorf("Value(ClientID) = %v, want the user assigned identity", got)
+	}
+	if got := source.Value(ClientSecret); got != "" {
+		t.Errorf("Value(ClientSecret) = %v, want empty for a managed identity", got)
+	}
+}
+
+func TestCloudConfigSourceKeepsConfigWhenFileIsInvalid(t *testing.T) {
+	path := newCloudConfig(t, `{"tenantId":"tenant","aadClientId":"client","aadClientSecret":"secret"}`)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	source := NewCloudConfigSource(path)
+	source.Load()
+	if err := ioutil.WriteFile(path, []byte(`{`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.Load(); err == nil {
+		t.Errorf("Load() error = nil for an invalid config, want error")
+	}
+	if got := source.Value(ClientID); got != "client" {
+		t.Errorf("Value(ClientID) = %v, want the last config read", got)
+	}
+}
+
+// newCloudConfig writes the cloud provider config to a temporary directory, returning its path
+func newCloudConfig(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "cloudconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "azure.json")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
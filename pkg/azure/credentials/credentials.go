@@ -0,0 +1,180 @@
+// Package credentials provides the authorizers and secrets used by the Azure clients
+package credentials
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/golang/glog"
+)
+
+// Names of the credential values that can be requested from a Source.
+// They match the environment variables historically used to configure the adapter.
+const (
+	TenantID            = "AZURE_TENANT_ID"
+	ClientID            = "AZURE_CLIENT_ID"
+	ClientSecret        = "AZURE_CLIENT_SECRET"
+	CertificatePath     = "AZURE_CERTIFICATE_PATH"
+	CertificatePassword = "AZURE_CERTIFICATE_PASSWORD"
+	Username            = "AZURE_USERNAME"
+	Password            = "AZURE_PASSWORD"
+	AppInsightsAppID    = "APP_INSIGHTS_APP_ID"
+	AppInsightsKey      = "APP_INSIGHTS_KEY"
+	// MSIResourceID names the managed identity by its resource id rather than its client id
+	MSIResourceID = "AZURE_MSI_RESOURCE_ID"
+)
+
+// msiEndpoint returns the endpoint managed identity tokens are requested from
+var msiEndpoint = adal.GetMSIVMEndpoint
+
+// SetIMDSEndpoint sets the address of the instance metadata service managed identity tokens are
+// requested from, such as the node managed identity endpoint of aad-pod-identity
+func SetIMDSEndpoint(endpoint string) {
+	tokenEndpoint := strings.TrimSuffix(endpoint, "/") + "/metadata/identity/oauth2/token"
+	msiEndpoint = func() (string, error) {
+		return tokenEndpoint, nil
+	}
+}
+
+// secretNames are the values that must never be read from the environment in file only mode
+var secretNames = []string{ClientSecret, CertificatePassword, Password, AppInsightsKey}
+
+// Source provides the credentials used to call Azure
+type Source interface {
+	// Authorizer returns an authorizer for the given AAD resource.
+	// An empty resource uses the Azure Resource Manager endpoint.
+	Authorizer(resource string) (autorest.Authorizer, error)
+	// Value returns a named credential value such as the App Insights api key
+	Value(name string) string
+}
+
+// EnvironmentSource reads credentials from environment variables using the
+// same conventions as the Azure SDK for Go
+type EnvironmentSource struct{}
+
+// NewEnvironmentSource creates a Source that reads from environment variables
+func NewEnvironmentSource() Source {
+	return EnvironmentSource{}
+}
+
+// Authorizer returns an authorizer configured from the environment.  A certificate is either
+// PKCS#12 or PEM.  Without a client secret, certificate or username the workload identity of
+// AZURE_FEDERATED_TOKEN_FILE is used, then the managed identity named by AZURE_CLIENT_ID or
+// AZURE_MSI_RESOURCE_ID, or the system assigned identity when neither is set.
+func (EnvironmentSource) Authorizer(resource string) (autorest.Authorizer, error) {
+	if os.Getenv(ClientSecret) == "" && (os.Getenv(CertificatePath) != "" || os.Getenv(Username) == "" || os.Getenv(Password) == "") {
+		environment, err := Environment()
+		if err != nil {
+			return nil, err
+		}
+		if resource == "" {
+			resource = environment.ResourceManagerEndpoint
+		}
+
+		if path := os.Getenv(CertificatePath); path != "" {
+			return CertificateFileAuthorizer(environment.ActiveDirectoryEndpoint, os.Getenv(TenantID), os.Getenv(ClientID), path, os.Getenv(CertificatePassword), resource)
+		}
+		if tokenFile := os.Getenv(FederatedTokenFile); tokenFile != "" {
+			return WorkloadIdentityAuthorizer(os.Getenv(TenantID), os.Getenv(ClientID), tokenFile, resource)
+		}
+		return MSIAuthorizer(os.Getenv(ClientID), os.Getenv(MSIResourceID), resource)
+	}
+
+	if resource == "" {
+		return auth.NewAuthorizerFromEnvironment()
+	}
+	return auth.NewAuthorizerFromEnvironmentWithResource(resource)
+}
+
+// MSIAuthorizer returns an authorizer for the managed identity with the client id or resource id,
+// or the system assigned identity when both are empty.  Nodes, and pods with aad-pod-identity, can
+// carry many user assigned identities, so the identity must be named to use one of them.
+func MSIAuthorizer(clientID string, resourceID string, resource string) (autorest.Authorizer, error) {
+	token, err := msiToken(clientID, resourceID, resource)
+	if err != nil {
+		return nil, err
+	}
+	return autorest.NewBearerAuthorizer(token), nil
+}
+
+func msiToken(clientID string, resourceID string, resource string) (*adal.ServicePrincipalToken, error) {
+	if clientID != "" && resourceID != "" {
+		return nil, fmt.Errorf("a managed identity is named by either its client id or its resource id")
+	}
+
+	endpoint, err := msiEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	var token *adal.ServicePrincipalToken
+	switch {
+	case clientID != "":
+		glog.V(2).Infof("using user assigned managed identity %s for azure authentication", clientID)
+		token, err = adal.NewServicePrincipalTokenFromMSIWithUserAssignedID(endpoint, resource, clientID)
+	case resourceID != "":
+		glog.V(2).Infof("using managed identity %s for azure authentication", resourceID)
+		token, err = adal.NewServicePrincipalTokenFromMSI(endpoint, resource)
+		if err == nil {
+			// adal can't name an identity by resource id, so it is added to each token request
+			token.SetSender(identityResourceSender{resourceID: resourceID, sender: &http.Client{}})
+		}
+	default:
+		glog.V(2).Info("using system assigned managed identity for azure authentication")
+		token, err = adal.NewServicePrincipalTokenFromMSI(endpoint, resource)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get oauth token from MSI: %v", err)
+	}
+	return token, nil
+}
+
+// identityResourceSender requests the tokens of the managed identity with the resource id
+type identityResourceSender struct {
+	resourceID string
+	sender     adal.Sender
+}
+
+func (s identityResourceSender) Do(req *http.Request) (*http.Response, error) {
+	query := req.URL.Query()
+	query.Set("mi_res_id", s.resourceID)
+	req.URL.RawQuery = query.Encode()
+	return s.sender.Do(req)
+}
+
+// Value returns the environment variable with the given name
+func (EnvironmentSource) Value(name string) string {
+	return os.Getenv(name)
+}
+
+// CheckNoSecretEnvironment returns an error if any secret is provided through an environment variable
+func CheckNoSecretEnvironment() error {
+	found := []string{}
+	for _, name := range secretNames {
+		if os.Getenv(name) != "" {
+			found = append(found, name)
+		}
+	}
+
+	if len(found) > 0 {
+		return fmt.Errorf("secrets must be provided as files but found environment variables: %s", strings.Join(found, ", "))
+	}
+
+	return nil
+}
+
+// Environment returns the Azure cloud the adapter is configured to use
+func Environment() (azure.Environment, error) {
+	envName := os.Getenv("AZURE_ENVIRONMENT")
+	if envName == "" {
+		return azure.PublicCloud, nil
+	}
+
+	return azure.EnvironmentFromName(envName)
+}
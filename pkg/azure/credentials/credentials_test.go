@@ -0,0 +1,63 @@
+package credentials
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMSITokenUsesUserAssignedIdentity(t *testing.T) {
+	var tests = []struct {
+		name       string
+		clientID   string
+		resourceID string
+	}{
+		{"user assigned", "identity-client-id", ""},
+		{"resource id", "", "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.ManagedIdentity/userAssignedIdentities/adapter"},
+		{"system assigned", "", ""},
+	}
+
+	requestedClientID, requestedResourceID := "", ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestedClientID = r.URL.Query().Get("client_id")
+		requestedResourceID = r.URL.Query().Get("mi_res_id")
+		expires := time.Now().Add(time.Hour).Unix()
+		fmt.Fprintf(w, `{"access_token":"token","expires_in":"3600","expires_on":"%d","not_before":"%d","resource":"%s","token_type":"Bearer"}`, expires, expires-3600, r.URL.Query().Get("resource"))
+	}))
+	defer server.Close()
+	defer func(endpoint func() (string, error)) { msiEndpoint = endpoint }(msiEndpoint)
+	msiEndpoint = func() (string, error) { return server.URL, nil }
+
+	for _, tt := range tests {
+		token, err := msiToken(tt.clientID, tt.resourceID, "https://management.azure.com/")
+		if err != nil {
+			t.Fatalf("%s: error = %v, want nil", tt.name, err)
+		}
+		if err := token.Refresh(); err != nil {
+			t.Fatalf("%s: refresh error = %v, want nil", tt.name, err)
+		}
+
+		if requestedClientID != tt.clientID {
+			t.Errorf("%s: requested client id = %v, want %v", tt.name, requestedClientID, tt.clientID)
+		}
+		if requestedResourceID != tt.resourceID {
+			t.Errorf("%s: requested resource id = %v, want %v", tt.name, requestedResourceID, tt.resourceID)
+		}
+	}
+
+	if _, err := msiToken("identity-client-id", "/subscriptions/1234", "https://management.azure.com/"); err == nil {
+		t.Errorf("both client and resource id: error = nil, want an error")
+	}
+}
+
+func TestSetIMDSEndpoint(t *testing.T) {
+	defer func(endpoint func() (string, error)) { msiEndpoint = endpoint }(msiEndpoint)
+
+	SetIMDSEndpoint("http://127.0.0.1:2579/")
+
+	if endpoint, _ := msiEndpoint(); endpoint != "http://127.0.0.1:2579/metadata/identity/oauth2/token" {
+		t.Errorf("msi endpoint = %v, want the token endpoint of the address", endpoint)
+	}
+}
@@ -0,0 +1,112 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/golang/glog"
+)
+
+// AzureCLIClientID is the public client id of the Azure CLI, which device code logins use unless
+// another application is given
+const AzureCLIClientID = "04b07795-8ddb-461a-bbee-02f9e1bf7b46"
+
+// DeviceCodeSource authenticates as a user who signs in with a device code, so the adapter can be
+// run outside of a cluster during development without the Azure CLI.  The user signs in once, when
+// the first token is needed, and tokens of other resources are exchanged for its refresh token.
+// Other values are read from the environment.
+type DeviceCodeSource struct {
+	tenantID string
+	clientID string
+
+	mu     sync.Mutex
+	tokens map[string]*adal.ServicePrincipalToken
+	// signedIn is the token of the device code login
+	signedIn *adal.ServicePrincipalToken
+}
+
+// NewDeviceCodeSource creates a source that signs the user in to the tenant with a device code of
+// the public client application, or the Azure CLI's when empty
+func NewDeviceCodeSource(tenantID string, clientID string) *DeviceCodeSource {
+	if clientID == "" {
+		clientID = AzureCLIClientID
+	}
+	if tenantID == "" {
+		tenantID = "common"
+	}
+	return &DeviceCodeSource{
+		tenantID: tenantID,
+		clientID: clientID,
+		tokens:   map[string]*adal.ServicePrincipalToken{},
+	}
+}
+
+// Authorizer returns an authorizer for the resource, prompting the user to sign in the first time
+func (s *DeviceCodeSource) Authorizer(resource string) (autorest.Authorizer, error) {
+	environment, err := Environment()
+	if err != nil {
+		return nil, err
+	}
+	if resource == "" {
+		resource = environment.ResourceManagerEndpoint
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if token, found := s.tokens[resource]; found {
+		return autorest.NewBearerAuthorizer(token), nil
+	}
+
+	oauthConfig, err := adal.NewOAuthConfig(environment.ActiveDirectoryEndpoint, s.tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	var token *adal.ServicePrincipalToken
+	if s.signedIn == nil {
+		token, err = s.signIn(*oauthConfig, resource)
+		if err != nil {
+			return nil, err
+		}
+		s.signedIn = token
+	} else {
+		token, err = adal.NewServicePrincipalTokenFromManualToken(*oauthConfig, s.clientID, resource, s.signedIn.Token())
+		if err != nil {
+			return nil, err
+		}
+		if err := token.RefreshExchange(resource); err != nil {
+			return nil, fmt.Errorf("unable to get a token for %s: %v", resource, err)
+		}
+	}
+
+	s.tokens[resource] = token
+	return autorest.NewBearerAuthorizer(token), nil
+}
+
+// signIn prompts the user to sign in with a device code and waits until they have
+func (s *DeviceCodeSource) signIn(oauthConfig adal.OAuthConfig, resource string) (*adal.ServicePrincipalToken, error) {
+	client := &autorest.Client{}
+	deviceCode, err := adal.InitiateDeviceAuth(client, oauthConfig, s.clientID, resource)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start device code login: %v", err)
+	}
+
+	// the prompt is written to the terminal as well as the log, which may be filtered
+	fmt.Fprintln(os.Stderr, *deviceCode.Message)
+	glog.V(0).Info(*deviceCode.Message)
+
+	token, err := adal.WaitForUserCompletion(client, deviceCode)
+	if err != nil {
+		return nil, fmt.Errorf("unable to finish device code login: %v", err)
+	}
+	glog.V(2).Infof("signed in to tenant %s with a device code", s.tenantID)
+	return adal.NewServicePrincipalTokenFromManualToken(oauthConfig, s.clientID, resource, *token)
+}
+
+// Value returns the value of the environment variable
+func (s *DeviceCodeSource) Value(name string) string {
+	return os.Getenv(name)
+}
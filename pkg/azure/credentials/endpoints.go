@@ -0,0 +1,144 @@
+package credentials
+
+import (
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest/azure"
+)
+
+const (
+	defaultAppInsightsEndpoint  = "https://api.applicationinsights.io"
+	defaultLogAnalyticsEndpoint = "https://api.loganalytics.io"
+	defaultCosmosDBSuffix       = "documents.azure.com"
+	defaultPrometheusResource   = "https://prometheus.monitor.azure.com"
+)
+
+// appInsightsEndpoints and logAnalyticsEndpoints are the api endpoints of the clouds other than the
+// public cloud, which are also the resources their tokens are requested for
+var (
+	appInsightsEndpoints = map[string]string{
+		"AzureChinaCloud":        "https://api.applicationinsights.azure.cn",
+		"AzureUSGovernmentCloud": "https://api.applicationinsights.us",
+	}
+	logAnalyticsEndpoints = map[string]string{
+		"AzureChinaCloud":        "https://api.loganalytics.azure.cn",
+		"AzureUSGovernmentCloud": "https://api.loganalytics.us",
+	}
+)
+
+// prometheusResources are the resources of the tokens of the query endpoints of Azure Monitor
+// workspaces in the clouds other than the public cloud
+var prometheusResources = map[string]string{
+	"AzureChinaCloud":        "https://prometheus.monitor.azure.cn",
+	"AzureUSGovernmentCloud": "https://prometheus.monitor.azure.us",
+}
+
+// cosmosDBSuffixes are the suffixes of the Cosmos DB data plane endpoints of the clouds other
+// than the public cloud, which the environments of go-autorest don't include
+var cosmosDBSuffixes = map[string]string{
+	"AzureChinaCloud":        "documents.azure.cn",
+	"AzureUSGovernmentCloud": "documents.azure.us",
+	"AzureGermanCloud":       "documents.microsoftazure.de",
+}
+
+// Endpoints are the endpoints of the Azure services the adapter calls.  Each can be overridden
+// independently, for example to reach a service through a private endpoint, on Azure Stack or
+// in a test double.
+type Endpoints struct {
+	// ResourceManager is the Azure Resource Manager endpoint, such as https://management.azure.com
+	ResourceManager string
+	// AppInsights is the Application Insights api endpoint, such as https://api.applicationinsights.io
+	AppInsights string
+	// LogAnalytics is the Log Analytics query api endpoint, such as https://api.loganalytics.io
+	LogAnalytics string
+	// StorageSuffix is the suffix of the Storage data plane endpoints, such as core.windows.net
+	StorageSuffix string
+	// ServiceBusSuffix is the suffix of the Service Bus data plane endpoints, such as servicebus.windows.net
+	ServiceBusSuffix string
+	// CosmosDBSuffix is the suffix of the Cosmos DB data plane endpoints, such as documents.azure.com
+	CosmosDBSuffix string
+
+	// AppInsightsResource is the resource of the tokens of the Application Insights api, which is
+	// the api of the cloud whatever endpoint is called
+	AppInsightsResource string
+	// LogAnalyticsResource is the resource of the tokens of the Log Analytics api
+	LogAnalyticsResource string
+	// PrometheusResource is the resource of the tokens of the query endpoints of Azure Monitor
+	// workspaces, such as https://prometheus.monitor.azure.com
+	PrometheusResource string
+	// BatchResource is the resource of the tokens of the Batch account endpoints, such as
+	// https://batch.core.windows.net/
+	BatchResource string
+}
+
+// Resolve returns the endpoints with the endpoints of the Azure cloud the adapter is configured
+// to use in place of the ones not overridden
+func (e Endpoints) Resolve() (Endpoints, error) {
+	env, err := Environment()
+	if err != nil {
+		return Endpoints{}, err
+	}
+	return e.resolve(env), nil
+}
+
+// ResolveCloud returns the endpoints with the endpoints of the named Azure cloud, such as
+// AzureChinaCloud, in place of the ones not overridden
+func (e Endpoints) ResolveCloud(cloud string) (Endpoints, error) {
+	env, err := CloudEnvironment(cloud)
+	if err != nil {
+		return Endpoints{}, err
+	}
+	return e.resolve(env), nil
+}
+
+func (e Endpoints) resolve(env azure.Environment) Endpoints {
+	if e.ResourceManager == "" {
+		e.ResourceManager = env.ResourceManagerEndpoint
+	}
+	if e.AppInsights == "" {
+		e.AppInsights = cloudEndpoint(appInsightsEndpoints, env.Name, defaultAppInsightsEndpoint)
+	}
+	if e.LogAnalytics == "" {
+		e.LogAnalytics = cloudEndpoint(logAnalyticsEndpoints, env.Name, defaultLogAnalyticsEndpoint)
+	}
+	if e.StorageSuffix == "" {
+		e.StorageSuffix = env.StorageEndpointSuffix
+	}
+	if e.ServiceBusSuffix == "" {
+		e.ServiceBusSuffix = env.ServiceBusEndpointSuffix
+	}
+	if e.CosmosDBSuffix == "" {
+		e.CosmosDBSuffix = defaultCosmosDBSuffix
+		if suffix, ok := cosmosDBSuffixes[env.Name]; ok {
+			e.CosmosDBSuffix = suffix
+		}
+	}
+	if e.AppInsightsResource == "" {
+		e.AppInsightsResource = cloudEndpoint(appInsightsEndpoints, env.Name, defaultAppInsightsEndpoint)
+	}
+	if e.LogAnalyticsResource == "" {
+		e.LogAnalyticsResource = cloudEndpoint(logAnalyticsEndpoints, env.Name, defaultLogAnalyticsEndpoint)
+	}
+	if e.PrometheusResource == "" {
+		e.PrometheusResource = cloudEndpoint(prometheusResources, env.Name, defaultPrometheusResource)
+	}
+	if e.BatchResource == "" {
+		e.BatchResource = env.BatchManagementEndpoint
+	}
+
+	e.ResourceManager = strings.TrimSuffix(e.ResourceManager, "/")
+	e.AppInsights = strings.TrimSuffix(e.AppInsights, "/")
+	e.LogAnalytics = strings.TrimSuffix(e.LogAnalytics, "/")
+	e.StorageSuffix = strings.Trim(e.StorageSuffix, ".")
+	e.ServiceBusSuffix = strings.Trim(e.ServiceBusSuffix, ".")
+	e.CosmosDBSuffix = strings.Trim(e.CosmosDBSuffix, ".")
+	return e
+}
+
+// cloudEndpoint returns the endpoint of the cloud, or the endpoint of the public cloud
+func cloudEndpoint(endpoints map[string]string, cloud string, public string) string {
+	if endpoint, ok := endpoints[cloud]; ok {
+		return endpoint
+	}
+	return public
+}
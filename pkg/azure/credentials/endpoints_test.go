@@ -0,0 +1,97 @@
+package credentials
+
+import (
+	"os"
+	"testing"
+)
+
+func TestEndpointsResolveOverridesEachService(t *testing.T) {
+	var tests = []struct {
+		name      string
+		overrides Endpoints
+		want      Endpoints
+	}{
+		{
+			name:      "public cloud",
+			overrides: Endpoints{},
+			want:      Endpoints{ResourceManager: "https://management.azure.com", AppInsights: "https://api.applicationinsights.io", LogAnalytics: "https://api.loganalytics.io", StorageSuffix: "core.windows.net", ServiceBusSuffix: "servicebus.windows.net", CosmosDBSuffix: "documents.azure.com", AppInsightsResource: "https://api.applicationinsights.io", LogAnalyticsResource: "https://api.loganalytics.io", PrometheusResource: "https://prometheus.monitor.azure.com", BatchResource: "https://batch.core.windows.net/"},
+		},
+		{
+			name:      "resource manager only",
+			overrides: Endpoints{ResourceManager: "https://management.local.azurestack.external/"},
+			want:      Endpoints{ResourceManager: "https://management.local.azurestack.external", AppInsights: "https://api.applicationinsights.io", LogAnalytics: "https://api.loganalytics.io", StorageSuffix: "core.windows.net", ServiceBusSuffix: "servicebus.windows.net", CosmosDBSuffix: "documents.azure.com", AppInsightsResource: "https://api.applicationinsights.io", LogAnalyticsResource: "https://api.loganalytics.io", PrometheusResource: "https://prometheus.monitor.azure.com", BatchResource: "https://batch.core.windows.net/"},
+		},
+		{
+			name:      "every service",
+			overrides: Endpoints{ResourceManager: "https://arm.test", AppInsights: "https://appinsights.test/", LogAnalytics: "https://loganalytics.test/", StorageSuffix: ".storage.test", ServiceBusSuffix: "servicebus.test.", CosmosDBSuffix: "cosmos.test."},
+			want:      Endpoints{ResourceManager: "https://arm.test", AppInsights: "https://appinsights.test", LogAnalytics: "https://loganalytics.test", StorageSuffix: "storage.test", ServiceBusSuffix: "servicebus.test", CosmosDBSuffix: "cosmos.test", AppInsightsResource: "https://api.applicationinsights.io", LogAnalyticsResource: "https://api.loganalytics.io", PrometheusResource: "https://prometheus.monitor.azure.com", BatchResource: "https://batch.core.windows.net/"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.overrides.Resolve()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndpointsResolveToServicesOfSovereignCloud(t *testing.T) {
+	var tests = []struct {
+		cloud string
+		want  Endpoints
+	}{
+		{
+			cloud: "AzureUSGovernmentCloud",
+			want:  Endpoints{ResourceManager: "https://management.usgovcloudapi.net", AppInsights: "https://api.applicationinsights.us", LogAnalytics: "https://api.loganalytics.us", StorageSuffix: "core.usgovcloudapi.net", ServiceBusSuffix: "servicebus.usgovcloudapi.net", CosmosDBSuffix: "documents.azure.us", AppInsightsResource: "https://api.applicationinsights.us", LogAnalyticsResource: "https://api.loganalytics.us", PrometheusResource: "https://prometheus.monitor.azure.us", BatchResource: "https://batch.core.usgovcloudapi.net/"},
+		},
+		{
+			cloud: "AzureChinaCloud",
+			want:  Endpoints{ResourceManager: "https://management.chinacloudapi.cn", AppInsights: "https://api.applicationinsights.azure.cn", LogAnalytics: "https://api.loganalytics.azure.cn", StorageSuffix: "core.chinacloudapi.cn", ServiceBusSuffix: "servicebus.chinacloudapi.cn", CosmosDBSuffix: "documents.azure.cn", AppInsightsResource: "https://api.applicationinsights.azure.cn", LogAnalyticsResource: "https://api.loganalytics.azure.cn", PrometheusResource: "https://prometheus.monitor.azure.cn", BatchResource: "https://batch.chinacloudapi.cn/"},
+		},
+	}
+
+	defer os.Unsetenv("AZURE_ENVIRONMENT")
+	for _, tt := range tests {
+		t.Run(tt.cloud, func(t *testing.T) {
+			os.Setenv("AZURE_ENVIRONMENT", tt.cloud)
+			got, err := Endpoints{}.Resolve()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+			os.Unsetenv("AZURE_ENVIRONMENT")
+
+			// the endpoints of the cloud are resolved whatever cloud the adapter uses
+			got, err = Endpoints{}.ResolveCloud(tt.cloud)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveCloud(%s) = %+v, want %+v", tt.cloud, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndpointsResolveKeepsTokenResourceOfOverriddenEndpoint(t *testing.T) {
+	got, err := Endpoints{AppInsights: "https://appinsights.test", LogAnalytics: "https://loganalytics.test"}.ResolveCloud("AzureChinaCloud")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// tokens are for the api of the cloud even when the endpoint is overridden
+	if got.AppInsightsResource != "https://api.applicationinsights.azure.cn" {
+		t.Errorf("AppInsightsResource = %v, want the api of the cloud", got.AppInsightsResource)
+	}
+	if got.LogAnalyticsResource != "https://api.loganalytics.azure.cn" {
+		t.Errorf("LogAnalyticsResource = %v, want the api of the cloud", got.LogAnalyticsResource)
+	}
+}
@@ -0,0 +1,219 @@
+package credentials
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// fileNames maps credential names to the files they are read from. They match the
+// keys of the secret created by the helm chart so the secret can be mounted as a volume.
+var fileNames = map[string]string{
+	TenantID:            "azure-tenant-id",
+	ClientID:            "azure-client-id",
+	ClientSecret:        "azure-client-secret",
+	CertificatePath:     "azure-client-certificate",
+	CertificatePassword: "azure-client-certificate-password",
+	AppInsightsAppID:    "appinsights-appid",
+	AppInsightsKey:      "appinsights-key",
+}
+
+// SecretConfig returns the credential held by the keys of a secret, which are named as the
+// files of a mounted secret
+func SecretConfig(data map[string]string) Config {
+	return Config{
+		TenantID:                  data[fileNames[TenantID]],
+		ClientID:                  data[fileNames[ClientID]],
+		ClientSecret:              data[fileNames[ClientSecret]],
+		ClientCertificate:         data[fileNames[CertificatePath]],
+		ClientCertificatePassword: data[fileNames[CertificatePassword]],
+	}
+}
+
+// FileSource reads credentials from files in a directory such as a mounted
+// secret, projected volume or CSI secrets store volume. Secrets are never read from
+// the environment. Authorizers are rebuilt when the files change.
+type FileSource struct {
+	dir string
+
+	mutex       sync.Mutex
+	fingerprint string
+	authorizers map[string]autorest.Authorizer
+}
+
+// NewFileSource creates a Source that reads credentials from files in dir
+func NewFileSource(dir string) *FileSource {
+	source := &FileSource{
+		dir:         dir,
+		authorizers: make(map[string]autorest.Authorizer),
+	}
+	source.fingerprint = source.currentFingerprint()
+	return source
+}
+
+// Value returns the contents of the file for the named credential.  Values
+// that are not secret fall back to the environment when no file is present.
+func (f *FileSource) Value(name string) string {
+	fileName, ok := fileNames[name]
+	if ok {
+		content, err := ioutil.ReadFile(filepath.Join(f.dir, fileName))
+		if err == nil {
+			return strings.TrimSpace(string(content))
+		}
+	}
+
+	if isSecret(name) {
+		return ""
+	}
+	return os.Getenv(name)
+}
+
+// Authorizer returns an authorizer that always uses the latest credentials on disk
+func (f *FileSource) Authorizer(resource string) (autorest.Authorizer, error) {
+	return reloadingAuthorizer{source: f, resource: resource}, nil
+}
+
+// Watch checks the credential files for changes at the given interval until stopCh is closed
+func (f *FileSource) Watch(interval time.Duration, stopCh <-chan struct{}) {
+	go wait.Until(f.checkForChanges, interval, stopCh)
+}
+
+func (f *FileSource) checkForChanges() {
+	fingerprint := f.currentFingerprint()
+
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if fingerprint == f.fingerprint {
+		return
+	}
+
+	glog.V(2).Infof("credential files in %s changed, reloading azure authorizers", f.dir)
+	f.fingerprint = fingerprint
+	f.authorizers = make(map[string]autorest.Authorizer)
+}
+
+// currentFingerprint hashes the content of the credential files. Content is used rather
+// than modification times because kubelet updates volumes by swapping symlinks.
+func (f *FileSource) currentFingerprint() string {
+	names := []string{}
+	for _, fileName := range fileNames {
+		names = append(names, fileName)
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	for _, name := range names {
+		content, err := ioutil.ReadFile(filepath.Join(f.dir, name))
+		if err != nil {
+			continue
+		}
+		hash.Write([]byte(name))
+		hash.Write(content)
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil))
+}
+
+func (f *FileSource) current(resource string) (autorest.Authorizer, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if authorizer, ok := f.authorizers[resource]; ok {
+		return authorizer, nil
+	}
+
+	authorizer, err := f.newAuthorizer(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	f.authorizers[resource] = authorizer
+	return authorizer, nil
+}
+
+func (f *FileSource) newAuthorizer(resource string) (autorest.Authorizer, error) {
+	environment, err := Environment()
+	if err != nil {
+		return nil, err
+	}
+
+	if resource == "" {
+		resource = environment.ResourceManagerEndpoint
+	}
+
+	tenantID := f.Value(TenantID)
+	clientID := f.Value(ClientID)
+
+	if secret := f.Value(ClientSecret); secret != "" {
+		glog.V(2).Info("using client secret from file for azure authentication")
+		config := auth.NewClientCredentialsConfig(clientID, secret, tenantID)
+		config.AADEndpoint = environment.ActiveDirectoryEndpoint
+		config.Resource = resource
+		return config.Authorizer()
+	}
+
+	if certificatePath := f.certificatePath(); certificatePath != "" {
+		glog.V(2).Info("using client certificate from file for azure authentication")
+		return CertificateFileAuthorizer(environment.ActiveDirectoryEndpoint, tenantID, clientID, certificatePath, f.Value(CertificatePassword), resource)
+	}
+
+	if tokenFile := f.Value(FederatedTokenFile); tokenFile != "" {
+		return WorkloadIdentityAuthorizer(tenantID, clientID, tokenFile, resource)
+	}
+
+	glog.V(2).Info("no credential files found, using MSI for azure authentication")
+	return MSIAuthorizer(clientID, f.Value(MSIResourceID), resource)
+}
+
+func (f *FileSource) certificatePath() string {
+	path := filepath.Join(f.dir, fileNames[CertificatePath])
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+
+	// the path to a mounted certificate is not a secret
+	return os.Getenv(CertificatePath)
+}
+
+func isSecret(name string) bool {
+	for _, secret := range secretNames {
+		if secret == name {
+			return true
+		}
+	}
+	return false
+}
+
+// reloadingAuthorizer resolves the current authorizer for every request so
+// rotated credentials are picked up without recreating clients
+type reloadingAuthorizer struct {
+	source interface {
+		current(resource string) (autorest.Authorizer, error)
+	}
+	resource string
+}
+
+func (a reloadingAuthorizer) WithAuthorization() autorest.PrepareDecorator {
+	return func(p autorest.Preparer) autorest.Preparer {
+		return autorest.PreparerFunc(func(r *http.Request) (*http.Request, error) {
+			authorizer, err := a.source.current(a.resource)
+			if err != nil {
+				return r, err
+			}
+			return authorizer.WithAuthorization()(p).Prepare(r)
+		})
+	}
+}
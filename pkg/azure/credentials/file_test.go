@@ -0,0 +1,102 @@
+package credentials
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSourceReadsValueFromFile(t *testing.T) {
+	dir := newCredentialsDir(t, map[string]string{"appinsights-key": "key\n"})
+	defer os.RemoveAll(dir)
+
+	source := NewFileSource(dir)
+
+	if got := source.Value(AppInsightsKey); got != "key" {
+		t.Errorf("Value() = %v, want %v", got, "key")
+	}
+}
+
+func TestFileSourceDoesNotReadSecretsFromEnvironment(t *testing.T) {
+	dir := newCredentialsDir(t, map[string]string{})
+	defer os.RemoveAll(dir)
+
+	os.Setenv(ClientSecret, "from-env")
+	defer os.Unsetenv(ClientSecret)
+
+	source := NewFileSource(dir)
+
+	if got := source.Value(ClientSecret); got != "" {
+		t.Errorf("Value() = %v, want empty", got)
+	}
+}
+
+func TestFileSourceReadsNonSecretsFromEnvironment(t *testing.T) {
+	dir := newCredentialsDir(t, map[string]string{})
+	defer os.RemoveAll(dir)
+
+	os.Setenv(TenantID, "tenant")
+	defer os.Unsetenv(TenantID)
+
+	source := NewFileSource(dir)
+
+	if got := source.Value(TenantID); got != "tenant" {
+		t.Errorf("Value() = %v, want %v", got, "tenant")
+	}
+}
+
+func TestFileSourceReloadsAuthorizersWhenFilesChange(t *testing.T) {
+	dir := newCredentialsDir(t, map[string]string{
+		"azure-tenant-id":     "tenant",
+		"azure-client-id":     "client",
+		"azure-client-secret": "secret",
+	})
+	defer os.RemoveAll(dir)
+
+	source := NewFileSource(dir)
+	first, err := source.current("")
+	if err != nil {
+		t.Fatalf("current() error = %v, want nil", err)
+	}
+
+	source.checkForChanges()
+	unchanged, _ := source.current("")
+	if unchanged != first {
+		t.Errorf("authorizer was rebuilt when files did not change")
+	}
+
+	ioutil.WriteFile(filepath.Join(dir, "azure-client-secret"), []byte("rotated"), 0600)
+	source.checkForChanges()
+
+	rotated, _ := source.current("")
+	if rotated == first {
+		t.Errorf("authorizer was not rebuilt when files changed")
+	}
+}
+
+func TestCheckNoSecretEnvironment(t *testing.T) {
+	if err := CheckNoSecretEnvironment(); err != nil {
+		t.Errorf("CheckNoSecretEnvironment() = %v, want nil", err)
+	}
+
+	os.Setenv(AppInsightsKey, "key")
+	defer os.Unsetenv(AppInsightsKey)
+
+	if err := CheckNoSecretEnvironment(); err == nil {
+		t.Errorf("CheckNoSecretEnvironment() = nil, want error")
+	}
+}
+
+func newCredentialsDir(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+
+	for name, content := range files {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600)
+	}
+
+	return dir
+}
@@ -0,0 +1,213 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	keyVaultAPIVersion = "7.0"
+	// maxKeyVaultSecretSize bounds the response read for a secret, which Key Vault limits to 25k
+	maxKeyVaultSecretSize = 64 * 1024
+
+	// the content types Key Vault gives the secrets backing its certificates
+	pkcs12ContentType = "application/x-pkcs12"
+	pemContentType    = "application/x-pem-file"
+)
+
+// KeyVaultSource reads the client secret or certificate of the adapter's service principal from
+// a Key Vault secret, authenticating to the vault with a managed identity.  The tenant and client
+// id are not secret and are read from the environment.  The secret is fetched again at an interval
+// so it can be rotated in the vault without redeploying the adapter.  A certificate created in Key
+// Vault can be used through the secret of the same name.
+type KeyVaultSource struct {
+	secretURL string
+	client    *http.Client
+	// vault returns the authorizer of the managed identity the secret is read with
+	vault func() (autorest.Authorizer, error)
+
+	mutex       sync.Mutex
+	secret      keyVaultSecret
+	authorizers map[string]autorest.Authorizer
+}
+
+type keyVaultSecret struct {
+	// ID is the url of the secret's version, which changes when the secret is rotated
+	ID          string `json:"id"`
+	Value       string `json:"value"`
+	ContentType string `json:"contentType"`
+}
+
+// NewKeyVaultSource creates a Source that reads the service principal's credential from the Key
+// Vault secret at secretURL, such as https://myvault.vault.azure.net/secrets/adapter.  The latest
+// version is read unless the url names one.  The vault is read with the managed identity with the
+// client id, or the system assigned identity when it is empty.
+func NewKeyVaultSource(secretURL string, identityClientID string) (*KeyVaultSource, error) {
+	parsed, err := url.Parse(secretURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" || !strings.HasPrefix(parsed.Path, "/secrets/") {
+		return nil, fmt.Errorf("key vault secret url %q must be https://<vault>/secrets/<name>", secretURL)
+	}
+
+	return &KeyVaultSource{
+		secretURL: strings.TrimSuffix(secretURL, "/"),
+		client:    &http.Client{Timeout: 10 * time.Second},
+		vault: func() (autorest.Authorizer, error) {
+			environment, err := Environment()
+			if err != nil {
+				return nil, err
+			}
+			return MSIAuthorizer(identityClientID, "", strings.TrimSuffix(environment.KeyVaultEndpoint, "/"))
+		},
+		authorizers: make(map[string]autorest.Authorizer),
+	}, nil
+}
+
+// Load reads the secret from the vault, rebuilding the authorizers when it changed
+func (k *KeyVaultSource) Load() error {
+	secret, err := k.fetch()
+	if err != nil {
+		return err
+	}
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if secret == k.secret {
+		return nil
+	}
+	if k.secret.ID != "" {
+		glog.V(2).Infof("key vault secret %s changed, reloading azure authorizers", k.secretURL)
+	}
+	k.secret = secret
+	k.authorizers = make(map[string]autorest.Authorizer)
+	return nil
+}
+
+// Watch reads the secret from the vault at the given interval until stopCh is closed.  The last
+// secret read is kept while the vault can't be reached.
+func (k *KeyVaultSource) Watch(interval time.Duration, stopCh <-chan struct{}) {
+	go wait.Until(func() {
+		if err := k.Load(); err != nil {
+			glog.Errorf("unable to read key vault secret %s: %v", k.secretURL, err)
+		}
+	}, interval, stopCh)
+}
+
+func (k *KeyVaultSource) fetch() (keyVaultSecret, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s?api-version=%s", k.secretURL, keyVaultAPIVersion), nil)
+	if err != nil {
+		return keyVaultSecret{}, err
+	}
+	authorizer, err := k.vault()
+	if err != nil {
+		return keyVaultSecret{}, err
+	}
+	if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+		return keyVaultSecret{}, err
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return keyVaultSecret{}, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxKeyVaultSecretSize))
+	if err != nil {
+		return keyVaultSecret{}, fmt.Errorf("unable to read key vault secret: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// the body is an error message, never the secret
+		return keyVaultSecret{}, fmt.Errorf("unable to get key vault secret, status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var secret keyVaultSecret
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return keyVaultSecret{}, fmt.Errorf("unable to parse key vault secret: %v", err)
+	}
+	if secret.Value == "" {
+		return keyVaultSecret{}, fmt.Errorf("key vault secret %s is empty", k.secretURL)
+	}
+	return secret, nil
+}
+
+// Value returns the named credential from the environment.  Secrets are only kept in the vault,
+// so they are never read from the environment.
+func (k *KeyVaultSource) Value(name string) string {
+	if isSecret(name) {
+		return ""
+	}
+	return os.Getenv(name)
+}
+
+// Authorizer returns an authorizer that always uses the latest secret read from the vault
+func (k *KeyVaultSource) Authorizer(resource string) (autorest.Authorizer, error) {
+	return reloadingAuthorizer{source: k, resource: resource}, nil
+}
+
+func (k *KeyVaultSource) current(resource string) (autorest.Authorizer, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if authorizer, ok := k.authorizers[resource]; ok {
+		return authorizer, nil
+	}
+	if k.secret.ID == "" {
+		return nil, fmt.Errorf("key vault secret %s has not been read", k.secretURL)
+	}
+
+	authorizer, err := k.newAuthorizer(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	k.authorizers[resource] = authorizer
+	return authorizer, nil
+}
+
+func (k *KeyVaultSource) newAuthorizer(resource string) (autorest.Authorizer, error) {
+	environment, err := Environment()
+	if err != nil {
+		return nil, err
+	}
+
+	if resource == "" {
+		resource = environment.ResourceManagerEndpoint
+	}
+
+	tenantID := k.Value(TenantID)
+	clientID := k.Value(ClientID)
+
+	switch k.secret.ContentType {
+	case pkcs12ContentType:
+		data, err := base64.StdEncoding.DecodeString(k.secret.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode the pkcs12 certificate of key vault secret %s: %v", k.secretURL, err)
+		}
+		glog.V(2).Info("using client certificate from key vault for azure authentication")
+		return CertificateAuthorizer(environment.ActiveDirectoryEndpoint, tenantID, clientID, data, "", resource)
+	case pemContentType:
+		glog.V(2).Info("using client certificate from key vault for azure authentication")
+		return CertificateAuthorizer(environment.ActiveDirectoryEndpoint, tenantID, clientID, []byte(k.secret.Value), "", resource)
+	default:
+		glog.V(2).Info("using client secret from key vault for azure authentication")
+		config := auth.NewClientCredentialsConfig(clientID, k.secret.Value, tenantID)
+		config.AADEndpoint = environment.ActiveDirectoryEndpoint
+		config.Resource = resource
+		return config.Authorizer()
+	}
+}
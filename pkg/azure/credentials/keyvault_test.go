@@ -0,0 +1,120 @@
+package credentials
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+func TestKeyVaultSourceReloadsAuthorizersWhenSecretRotates(t *testing.T) {
+	defer setServicePrincipalEnvironment()()
+	version, value := "1", "secret"
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/secrets/adapter" || r.URL.Query().Get("api-version") != keyVaultAPIVersion {
+			t.Errorf("url = %v, want the latest version of secret adapter", r.URL)
+		}
+		fmt.Fprintf(w, `{"id":"https://vault/secrets/adapter/%s","value":"%s"}`, version, value)
+	}))
+	defer server.Close()
+
+	source := newTestKeyVaultSource(t, server)
+	if _, err := source.current(""); err == nil {
+		t.Errorf("current() error = nil before the secret was read, want error")
+	}
+
+	if err := source.Load(); err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+	first, err := source.current("")
+	if err != nil {
+		t.Fatalf("current() error = %v, want nil", err)
+	}
+
+	source.Load()
+	if unchanged, _ := source.current(""); unchanged != first {
+		t.Errorf("authorizer was rebuilt when the secret did not change")
+	}
+
+	version, value = "2", "rotated"
+	source.Load()
+	if rotated, _ := source.current(""); rotated == first {
+		t.Errorf("authorizer was not rebuilt when the secret was rotated")
+	}
+}
+
+func TestKeyVaultSourceWithCertificate(t *testing.T) {
+	defer setServicePrincipalEnvironment()()
+	key, certificate := newTestCertificate(t)
+	data := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})) +
+		string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"id":"https://vault/secrets/adapter/1","value":%q,"contentType":"application/x-pem-file"}`, data)
+	}))
+	defer server.Close()
+
+	source := newTestKeyVaultSource(t, server)
+	if err := source.Load(); err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+	if _, err := source.current(""); err != nil {
+		t.Errorf("current() error = %v, want nil", err)
+	}
+}
+
+func TestKeyVaultSourceKeepsSecretWhenVaultFails(t *testing.T) {
+	defer setServicePrincipalEnvironment()()
+	status := http.StatusOK
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, `{"id":"https://vault/secrets/adapter/1","value":"secret"}`)
+	}))
+	defer server.Close()
+
+	source := newTestKeyVaultSource(t, server)
+	source.Load()
+	status = http.StatusForbidden
+	if err := source.Load(); err == nil {
+		t.Errorf("Load() error = nil when the vault is forbidden, want error")
+	}
+	if _, err := source.current(""); err != nil {
+		t.Errorf("current() error = %v, want the last secret read to be used", err)
+	}
+}
+
+func TestKeyVaultSourceNeedsSecretURL(t *testing.T) {
+	for _, secretURL := range []string{"", "http://vault.vault.azure.net/secrets/adapter", "https://vault.vault.azure.net/keys/adapter"} {
+		if _, err := NewKeyVaultSource(secretURL, ""); err == nil {
+			t.Errorf("NewKeyVaultSource(%q) error = nil, want error", secretURL)
+		}
+	}
+}
+
+// setServicePrincipalEnvironment sets the ids of the service principal whose secret is in the
+// vault, returning a function that unsets them
+func setServicePrincipalEnvironment() func() {
+	os.Setenv(TenantID, "tenant")
+	os.Setenv(ClientID, "client")
+	return func() {
+		os.Unsetenv(TenantID)
+		os.Unsetenv(ClientID)
+	}
+}
+
+// newTestKeyVaultSource creates a source reading the secret adapter from the vault served by server
+func newTestKeyVaultSource(t *testing.T, server *httptest.Server) *KeyVaultSource {
+	source, err := NewKeyVaultSource(server.URL+"/secrets/adapter", "")
+	if err != nil {
+		t.Fatalf("NewKeyVaultSource() error = %v, want nil", err)
+	}
+	source.client = server.Client()
+	source.vault = func() (autorest.Authorizer, error) {
+		return autorest.NullAuthorizer{}, nil
+	}
+	return source
+}
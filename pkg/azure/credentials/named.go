@@ -0,0 +1,133 @@
+package credentials
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/golang/glog"
+)
+
+// Config is a named credential: a service principal when it has a client secret or certificate,
+// an application federated with the pod's workload identity when it has a token file, otherwise a
+// user assigned managed identity
+type Config struct {
+	TenantID     string
+	ClientID     string
+	ClientSecret string
+	// ClientCertificate is the PKCS#12 or PEM certificate of the service principal
+	ClientCertificate         string
+	ClientCertificatePassword string
+	// FederatedTokenFile is the projected service account token of a workload identity
+	FederatedTokenFile string
+	// Cloud is the name of the Azure cloud of the credential. The adapter's cloud when empty
+	Cloud string
+}
+
+// NamedSource authenticates with a named credential rather than the adapter's own.  An authorizer
+// is created once for each resource so its token is cached and refreshed.
+type NamedSource struct {
+	config Config
+
+	mutex       sync.Mutex
+	authorizers map[string]autorest.Authorizer
+}
+
+// NewNamedSource creates a Source for the credential
+func NewNamedSource(config Config) (*NamedSource, error) {
+	if config.ClientID == "" {
+		return nil, fmt.Errorf("a credential needs a client id")
+	}
+	if (config.ClientSecret != "" || config.FederatedTokenFile != "") && config.TenantID == "" {
+		return nil, fmt.Errorf("a credential with a client secret or workload identity needs a tenant id")
+	}
+	if config.ClientCertificate != "" {
+		if config.ClientSecret != "" {
+			return nil, fmt.Errorf("a credential can't have both a client secret and a certificate")
+		}
+		if config.TenantID == "" {
+			return nil, fmt.Errorf("a credential with a certificate needs a tenant id")
+		}
+		if _, _, err := decodeCertificate([]byte(config.ClientCertificate), config.ClientCertificatePassword); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := CloudEnvironment(config.Cloud); err != nil {
+		return nil, err
+	}
+
+	return &NamedSource{
+		config:      config,
+		authorizers: make(map[string]autorest.Authorizer),
+	}, nil
+}
+
+// Authorizer returns the authorizer of the credential for the given AAD resource
+func (s *NamedSource) Authorizer(resource string) (autorest.Authorizer, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if authorizer, ok := s.authorizers[resource]; ok {
+		return authorizer, nil
+	}
+
+	environment, err := CloudEnvironment(s.config.Cloud)
+	if err != nil {
+		return nil, err
+	}
+	tokenResource := resource
+	if tokenResource == "" {
+		tokenResource = environment.ResourceManagerEndpoint
+	}
+
+	var authorizer autorest.Authorizer
+	if s.config.ClientCertificate != "" {
+		glog.V(2).Infof("using client certificate of service principal %s for azure authentication", s.config.ClientID)
+		authorizer, err = CertificateAuthorizer(environment.ActiveDirectoryEndpoint, s.config.TenantID, s.config.ClientID, []byte(s.config.ClientCertificate), s.config.ClientCertificatePassword, tokenResource)
+	} else if s.config.ClientSecret != "" {
+		glog.V(2).Infof("using client secret of service principal %s for azure authentication", s.config.ClientID)
+		config := auth.NewClientCredentialsConfig(s.config.ClientID, s.config.ClientSecret, s.config.TenantID)
+		config.AADEndpoint = environment.ActiveDirectoryEndpoint
+		config.Resource = tokenResource
+		authorizer, err = config.Authorizer()
+	} else if s.config.FederatedTokenFile != "" {
+		authorizer, err = WorkloadIdentityAuthorizer(s.config.TenantID, s.config.ClientID, s.config.FederatedTokenFile, tokenResource)
+	} else {
+		authorizer, err = MSIAuthorizer(s.config.ClientID, "", tokenResource)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.authorizers[resource] = authorizer
+	return authorizer, nil
+}
+
+// Config returns the credential of the source
+func (s *NamedSource) Config() Config {
+	return s.config
+}
+
+// Value returns the tenant and client id of the credential.  Other values, such as the
+// Application Insights key, are not part of a named credential.
+func (s *NamedSource) Value(name string) string {
+	switch name {
+	case TenantID:
+		return s.config.TenantID
+	case ClientID:
+		return s.config.ClientID
+	}
+	return ""
+}
+
+// CloudEnvironment returns the Azure cloud of the given name, or the cloud the adapter is
+// configured to use when the name is empty
+func CloudEnvironment(name string) (azure.Environment, error) {
+	if name == "" {
+		return Environment()
+	}
+
+	return azure.EnvironmentFromName(name)
+}
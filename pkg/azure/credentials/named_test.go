@@ -0,0 +1,42 @@
+package credentials
+
+import (
+	"testing"
+)
+
+func TestNamedSourceCachesAuthorizerOfEachResource(t *testing.T) {
+	source, err := NewNamedSource(Config{TenantID: "tenant", ClientID: "client", ClientSecret: "secret"})
+	if err != nil {
+		t.Fatalf("NewNamedSource() error = %v, want nil", err)
+	}
+
+	first, err := source.Authorizer("")
+	if err != nil {
+		t.Fatalf("Authorizer() error = %v, want nil", err)
+	}
+	second, _ := source.Authorizer("")
+	if first != second {
+		t.Errorf("Authorizer() was recreated for the same resource")
+	}
+
+	if got := source.Value(ClientID); got != "client" {
+		t.Errorf("Value(ClientID) = %v, want %v", got, "client")
+	}
+	if got := source.Value(AppInsightsKey); got != "" {
+		t.Errorf("Value(AppInsightsKey) = %v, want empty", got)
+	}
+}
+
+func TestNamedSourceInvalidConfigGetsError(t *testing.T) {
+	var tests = []Config{
+		{TenantID: "tenant"},
+		{ClientID: "client", ClientSecret: "secret"},
+		{ClientID: "client", Cloud: "MarsCloud"},
+	}
+
+	for _, config := range tests {
+		if _, err := NewNamedSource(config); err == nil {
+			t.Errorf("NewNamedSource(%+v) error = nil, want error", config)
+		}
+	}
+}
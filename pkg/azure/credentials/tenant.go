@@ -0,0 +1,48 @@
+package credentials
+
+import (
+	"fmt"
+	"io/ioutil"
+)
+
+// TenantConfig returns the credential of the application the source authenticates as, in another
+// tenant.  A multi-tenant application consented in that tenant can then query its resources.  A
+// managed identity only authenticates in its own tenant.
+func TenantConfig(source Source, tenantID string) (Config, error) {
+	if named, ok := source.(*NamedSource); ok {
+		config := named.Config()
+		if config.ClientSecret == "" && config.ClientCertificate == "" && config.FederatedTokenFile == "" {
+			return Config{}, fmt.Errorf("managed identity %s can't authenticate in tenant %s", config.ClientID, tenantID)
+		}
+		config.TenantID = tenantID
+		return config, nil
+	}
+
+	config := Config{
+		TenantID:     tenantID,
+		ClientID:     source.Value(ClientID),
+		ClientSecret: source.Value(ClientSecret),
+	}
+	if config.ClientSecret != "" {
+		return config, nil
+	}
+
+	path := source.Value(CertificatePath)
+	if file, ok := source.(*FileSource); ok {
+		path = file.certificatePath()
+	}
+	if path != "" {
+		certificate, err := ioutil.ReadFile(path)
+		if err != nil {
+			return Config{}, fmt.Errorf("unable to read certificate %s: %v", path, err)
+		}
+		config.ClientCertificate = string(certificate)
+		config.ClientCertificatePassword = source.Value(CertificatePassword)
+		return config, nil
+	}
+
+	if config.FederatedTokenFile = source.Value(FederatedTokenFile); config.FederatedTokenFile != "" {
+		return config, nil
+	}
+	return Config{}, fmt.Errorf("the adapter's managed identity can't authenticate in tenant %s, a client secret, certificate or workload identity is needed", tenantID)
+}
@@ -0,0 +1,39 @@
+package credentials
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTenantConfigUsesApplicationOfSource(t *testing.T) {
+	dir := newCredentialsDir(t, map[string]string{
+		"azure-tenant-id":          "tenant",
+		"azure-client-id":          "client",
+		"azure-client-certificate": "certificate",
+	})
+	defer os.RemoveAll(dir)
+
+	config, err := TenantConfig(NewFileSource(dir), "other-tenant")
+
+	if err != nil {
+		t.Fatalf("error after processing = %v, want nil", err)
+	}
+	want := Config{TenantID: "other-tenant", ClientID: "client", ClientCertificate: "certificate"}
+	if config != want {
+		t.Errorf("config = %+v, want %+v", config, want)
+	}
+}
+
+func TestTenantConfigOfManagedIdentityGetsError(t *testing.T) {
+	dir := newCredentialsDir(t, map[string]string{"azure-client-id": "identity"})
+	defer os.RemoveAll(dir)
+
+	if _, err := TenantConfig(NewFileSource(dir), "other-tenant"); err == nil {
+		t.Errorf("error after processing = nil, want an error for the adapter's managed identity")
+	}
+
+	named, _ := NewNamedSource(Config{ClientID: "identity"})
+	if _, err := TenantConfig(named, "other-tenant"); err == nil {
+		t.Errorf("error after processing = nil, want an error for a named managed identity")
+	}
+}
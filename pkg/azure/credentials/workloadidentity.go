@@ -0,0 +1,73 @@
+package credentials
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/golang/glog"
+)
+
+// Names of the values the Azure AD workload identity webhook sets on pods using workload identity
+const (
+	FederatedTokenFile = "AZURE_FEDERATED_TOKEN_FILE"
+	AuthorityHost      = "AZURE_AUTHORITY_HOST"
+)
+
+// WorkloadIdentityAuthorizer returns an authorizer that exchanges the projected service account
+// token in the file for an Azure AD token of the application with the client id.  The file is
+// read on every refresh as kubelet rotates the token.
+func WorkloadIdentityAuthorizer(tenantID string, clientID string, tokenFile string, resource string) (autorest.Authorizer, error) {
+	token, err := workloadIdentityToken(tenantID, clientID, tokenFile, resource)
+	if err != nil {
+		return nil, err
+	}
+	return autorest.NewBearerAuthorizer(token), nil
+}
+
+func workloadIdentityToken(tenantID string, clientID string, tokenFile string, resource string) (*adal.ServicePrincipalToken, error) {
+	if tenantID == "" || clientID == "" {
+		return nil, fmt.Errorf("workload identity needs %s and %s", TenantID, ClientID)
+	}
+
+	environment, err := Environment()
+	if err != nil {
+		return nil, err
+	}
+	if resource == "" {
+		resource = environment.ResourceManagerEndpoint
+	}
+	authority := environment.ActiveDirectoryEndpoint
+	if host := os.Getenv(AuthorityHost); host != "" {
+		authority = host
+	}
+
+	oauthConfig, err := adal.NewOAuthConfig(authority, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	glog.V(2).Infof("using workload identity of application %s for azure authentication", clientID)
+	return adal.NewServicePrincipalTokenWithSecret(*oauthConfig, clientID, resource, &federatedTokenSecret{file: tokenFile})
+}
+
+// federatedTokenSecret authenticates with the service account token as a client assertion
+type federatedTokenSecret struct {
+	file string
+}
+
+// SetAuthenticationValues reads the current service account token into the token request
+func (s *federatedTokenSecret) SetAuthenticationValues(spt *adal.ServicePrincipalToken, values *url.Values) error {
+	content, err := ioutil.ReadFile(s.file)
+	if err != nil {
+		return fmt.Errorf("unable to read federated token: %v", err)
+	}
+
+	values.Set("client_assertion", strings.TrimSpace(string(content)))
+	values.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	return nil
+}
@@ -0,0 +1,68 @@
+package credentials
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWorkloadIdentityExchangesFederatedToken(t *testing.T) {
+	dir := newCredentialsDir(t, map[string]string{"token": "service-account-token\n"})
+	defer os.RemoveAll(dir)
+
+	var form map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/tenant/oauth2/token" {
+			t.Errorf("path = %v, want %v", r.URL.Path, "/tenant/oauth2/token")
+		}
+		r.ParseForm()
+		form = r.PostForm
+		expires := time.Now().Add(time.Hour).Unix()
+		fmt.Fprintf(w, `{"access_token":"token","expires_in":"3600","expires_on":"%d","not_before":"%d","resource":"resource","token_type":"Bearer"}`, expires, expires-3600)
+	}))
+	defer server.Close()
+	os.Setenv(AuthorityHost, server.URL)
+	defer os.Unsetenv(AuthorityHost)
+
+	token, err := workloadIdentityToken("tenant", "client", filepath.Join(dir, "token"), "https://management.azure.com/")
+	if err != nil {
+		t.Fatalf("error = %v, want nil", err)
+	}
+	if err := token.Refresh(); err != nil {
+		t.Fatalf("refresh error = %v, want nil", err)
+	}
+
+	want := map[string]string{
+		"client_id":             "client",
+		"client_assertion":      "service-account-token",
+		"client_assertion_type": "urn:ietf:params:oauth:client-assertion-type:jwt-bearer",
+		"grant_type":            "client_credentials",
+		"resource":              "https://management.azure.com/",
+	}
+	for key, value := range want {
+		if got := form[key]; len(got) != 1 || got[0] != value {
+			t.Errorf("%s = %v, want %v", key, got, value)
+		}
+	}
+
+	// the rotated token is read on the next refresh
+	ioutil.WriteFile(filepath.Join(dir, "token"), []byte("rotated-token"), 0600)
+	token.Refresh()
+	if got := form["client_assertion"]; len(got) != 1 || got[0] != "rotated-token" {
+		t.Errorf("client_assertion = %v after rotation, want %v", got, "rotated-token")
+	}
+}
+
+func TestWorkloadIdentityNeedsTenantAndClient(t *testing.T) {
+	if _, err := workloadIdentityToken("", "client", "token", ""); err == nil {
+		t.Errorf("error = nil without a tenant, want error")
+	}
+	if _, err := workloadIdentityToken("tenant", "", "token", ""); err == nil {
+		t.Errorf("error = nil without a client, want error")
+	}
+}
@@ -10,42 +10,42 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
-	"os"
+	"strings"
 
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/transform"
 	"github.com/Azure/azure-sdk-for-go/services/appinsights/v1/insights"
-	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/golang/glog"
 )
 
-const (
-	defaultAPIUrl   = "api.applicationinsights.io"
-	apiVersion      = "v1"
-	azureAdResource = "https://api.applicationinsights.io"
-)
+const apiVersion = "v1"
 
 // AzureAppInsightsClient provides methods for accessing App Insights via AD auth or App API Key
 type AzureAppInsightsClient interface {
 	GetCustomMetric(request MetricRequest) (float64, error)
+	GetMetricTotal(request MetricRequest) (float64, error)
 }
 
 // appinsightsClient is used to call Application Insights Api
 type appinsightsClient struct {
-	appID           string
-	appKey          string
-	useADAuthorizer bool
+	appID       string
+	credentials credentials.Source
+	endpoint    string
+	resource    string
 }
 
 // NewClient creates a client for calling Application
-// insights api
-func NewClient() AzureAppInsightsClient {
-	defaultAppInsightsAppID := os.Getenv("APP_INSIGHTS_APP_ID")
-	appInsightsKey := os.Getenv("APP_INSIGHTS_KEY")
+// insights api at the endpoint, such as https://api.applicationinsights.io,
+// with tokens for the resource of the api
+func NewClient(credentialSource credentials.Source, endpoint string, resource string) AzureAppInsightsClient {
+	defaultAppInsightsAppID := credentialSource.Value(credentials.AppInsightsAppID)
 
-	// if no application insights key has been specified, then we will use AD authentication
 	return appinsightsClient{
-		appID:           defaultAppInsightsAppID,
-		appKey:          appInsightsKey,
-		useADAuthorizer: appInsightsKey == "",
+		appID:       defaultAppInsightsAppID,
+		credentials: credentialSource,
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		resource:    resource,
 	}
 }
 
@@ -84,6 +84,32 @@ func (c appinsightsClient) GetCustomMetric(request MetricRequest) (float64, erro
 	return normalizedValue, nil
 }
 
+// GetMetricTotal calls to Application Insights to retrieve the sum of the metric over the requested timespan
+func (c appinsightsClient) GetMetricTotal(request MetricRequest) (float64, error) {
+	request.Aggregation = "sum"
+	request.Interval = ""
+
+	metricsResult, err := c.getMetric(request)
+	if err != nil {
+		return 0, err
+	}
+
+	if metricsResult.Value == nil {
+		return 0, errors.New("metrics result is nil")
+	}
+
+	// the metric is left out of the result when there was no data in the timespan
+	metric, ok := metricsResult.Value.AdditionalProperties[request.MetricName].(map[string]interface{})
+	if !ok {
+		glog.V(2).Infof("no value for metric %s", request.MetricName)
+		return 0, nil
+	}
+
+	total := normalizeValue(metric["sum"])
+	glog.V(2).Infof("found metric total: %f", total)
+	return total, nil
+}
+
 func normalizeValue(value interface{}) float64 {
 	switch t := value.(type) {
 	case int32:
@@ -102,31 +128,39 @@ func normalizeValue(value interface{}) float64 {
 
 // GetMetric calls to API to retrieve a specific metric
 func (ai appinsightsClient) getMetric(metricInfo MetricRequest) (*insights.MetricsResult, error) {
-	if ai.useADAuthorizer {
+	// the key is looked up on every request so rotated keys are used without a restart
+	// if no application insights key has been specified, then we will use AD authentication
+	appKey := ai.credentials.Value(credentials.AppInsightsKey)
+	if appKey == "" {
 		glog.V(2).Infoln("No application insights key provided - using Azure GO SDK auth.")
 		return getMetricUsingADAuthorizer(ai, metricInfo)
 	}
 
 	glog.V(2).Infoln("Application insights key has been provided - using Application Insights REST API.")
-	return getMetricUsingAPIKey(ai, metricInfo)
+	return getMetricUsingAPIKey(ai, appKey, metricInfo)
 }
 
 func getMetricUsingADAuthorizer(ai appinsightsClient, metricInfo MetricRequest) (*insights.MetricsResult, error) {
 
-	authorizer, err := auth.NewAuthorizerFromEnvironmentWithResource(azureAdResource)
+	authorizer, err := ai.credentials.Authorizer(ai.resource)
 	if err != nil {
 		glog.Errorf("unable to retrieve an authorizer from environment: %v", err)
 		return nil, err
 	}
 
-	metricsClient := insights.NewMetricsClient()
+	metricsClient := insights.NewMetricsClientWithBaseURI(fmt.Sprintf("%s/%s", ai.endpoint, apiVersion))
 	metricsClient.Authorizer = authorizer
 
 	metricsBodyParameter := insights.MetricsPostBodySchemaParameters{
-		Interval: &metricInfo.Interval,
 		Timespan: &metricInfo.Timespan,
 		MetricID: insights.MetricID(metricInfo.MetricName),
 	}
+	if metricInfo.Interval != "" {
+		metricsBodyParameter.Interval = &metricInfo.Interval
+	}
+	if metricInfo.Aggregation != "" {
+		metricsBodyParameter.Aggregation = &[]insights.MetricsAggregation{insights.MetricsAggregation(metricInfo.Aggregation)}
+	}
 
 	requestSchemaIdentifier := generateRequestSchemaUniqueIdentifier()
 	metricsBody := []insights.MetricsPostBodySchema{
@@ -138,6 +172,7 @@ func getMetricUsingADAuthorizer(ai appinsightsClient, metricInfo MetricRequest)
 
 	metricsResultsItem, err := metricsClient.GetMultiple(context.Background(), ai.appID, metricsBody)
 	if err != nil {
+		err = redact.Error(err)
 		glog.Errorf("unable to get retrive metric: %v", err)
 		return nil, err
 	}
@@ -170,22 +205,28 @@ func generateRequestSchemaUniqueIdentifier() string {
 	return uuid
 }
 
-func getMetricUsingAPIKey(ai appinsightsClient, metricInfo MetricRequest) (*insights.MetricsResult, error) {
+func getMetricUsingAPIKey(ai appinsightsClient, appKey string, metricInfo MetricRequest) (*insights.MetricsResult, error) {
 	client := &http.Client{}
 
 	request := fmt.Sprintf("/%s/apps/%s/metrics/%s", apiVersion, ai.appID, metricInfo.MetricName)
 
-	req, _ := http.NewRequest("GET", fmt.Sprintf("https://%s%s", defaultAPIUrl, request), nil)
-	req.Header.Add("x-api-key", ai.appKey)
+	req, _ := http.NewRequest("GET", fmt.Sprintf("%s%s", ai.endpoint, request), nil)
+	req.Header.Add("x-api-key", appKey)
 
 	q := req.URL.Query()
 	q.Add("timespan", metricInfo.Timespan)
-	q.Add("interval", metricInfo.Interval)
+	if metricInfo.Interval != "" {
+		q.Add("interval", metricInfo.Interval)
+	}
+	if metricInfo.Aggregation != "" {
+		q.Add("aggregation", metricInfo.Aggregation)
+	}
 	req.URL.RawQuery = q.Encode()
 
-	glog.V(2).Infoln("request to: ", req.URL)
+	glog.V(2).Infoln("request to: ", redact.URL(req.URL))
 	resp, err := client.Do(req)
 	if err != nil {
+		err = redact.Error(err)
 		glog.Errorf("unable to retrive metric: %v", err)
 		return nil, err
 	}
@@ -199,9 +240,9 @@ func getMetricUsingAPIKey(ai appinsightsClient, metricInfo MetricRequest) (*insi
 			return nil, err
 		}
 
-		respMessage := string(respBody)
-		err = fmt.Errorf(respMessage)
-		return nil, err
+		// the response body can echo back the request so make sure the key is not exposed
+		respMessage := redact.String(string(respBody))
+		return nil, errors.New(respMessage)
 	}
 	// return the response unmarshaled
 	metricsResult := insights.MetricsResult{}
@@ -234,6 +275,7 @@ type MetricRequest struct {
 	Segment     string
 	OrderBy     string
 	Filter      string
+	Transform   transform.Transform
 }
 
 // NewMetricRequest creates a new metric request with defaults for optional parameters
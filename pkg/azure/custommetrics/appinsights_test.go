@@ -1,7 +1,13 @@
 package custommetrics
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/go-autorest/autorest"
 )
 
 func TestNormalizeValue(t *testing.T) {
@@ -57,3 +63,41 @@ func TestNormalizeValue(t *testing.T) {
 		})
 	}
 }
+
+func TestGetMetricTotalCallsEndpoint(t *testing.T) {
+	path := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		fmt.Fprint(w, `{"value":{"requests/count":{"sum":42}}}`)
+	}))
+	defer server.Close()
+
+	client := NewClient(apiKeySource{}, server.URL+"/", "")
+	total, err := client.GetMetricTotal(NewMetricRequest("requests/count"))
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 42 {
+		t.Errorf("GetMetricTotal() = %v, want %v", total, 42)
+	}
+	if path != "/v1/apps/app/metrics/requests/count" {
+		t.Errorf("path = %v, want %v", path, "/v1/apps/app/metrics/requests/count")
+	}
+}
+
+type apiKeySource struct{}
+
+func (apiKeySource) Authorizer(resource string) (autorest.Authorizer, error) {
+	return autorest.NullAuthorizer{}, nil
+}
+
+func (apiKeySource) Value(name string) string {
+	switch name {
+	case credentials.AppInsightsAppID:
+		return "app"
+	case credentials.AppInsightsKey:
+		return "key"
+	}
+	return ""
+}
@@ -0,0 +1,162 @@
+package externalmetrics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const (
+	defaultActivityLogWindow = time.Hour
+	// maxActivityLogWindow is the retention of the Activity Log
+	maxActivityLogWindow = 90 * 24 * time.Hour
+	// maxActivityLogPages limits the events counted for a request, at up to 200 events a page
+	maxActivityLogPages = 50
+	// activityLogFields are the only fields of an event read
+	activityLogFields = "eventTimestamp,category,operationName,status,level"
+)
+
+// ActivityLogDefinition selects the Activity Log events counted over the window.  The events are
+// listed for the resource group of the request, or a resource id or resource provider, and matched
+// on category, operation name, status and level, which are not supported by the Activity Log filter.
+type ActivityLogDefinition struct {
+	Window           string
+	ResourceID       string
+	ResourceProvider string
+	Category         string
+	OperationName    string
+	Status           string
+	Level            string
+}
+
+// matches returns true if the event has the category, operation name, status and level of the
+// definition, where they are set
+func (d ActivityLogDefinition) matches(event insights.EventData) bool {
+	return matchesLocalizable(d.Category, event.Category) &&
+		matchesLocalizable(d.OperationName, event.OperationName) &&
+		matchesLocalizable(d.Status, event.Status) &&
+		(d.Level == "" || strings.EqualFold(d.Level, string(event.Level)))
+}
+
+func matchesLocalizable(want string, value *insights.LocalizableString) bool {
+	if want == "" {
+		return true
+	}
+	return value != nil && value.Value != nil && strings.EqualFold(want, *value.Value)
+}
+
+type activityLogClient struct {
+	authorizer            autorest.Authorizer
+	resourceManager       string
+	DefaultSubscriptionID string
+	now                   func() time.Time
+}
+
+// NewActivityLogClient creates a client that serves the number of Activity Log events matching the
+// definition of a metric
+func NewActivityLogClient(defaultSubscriptionID string, credentialSource credentials.Source, resourceManager string) AzureExternalMetricClient {
+	glog.V(2).Info("Creating a new Azure Activity Log client")
+	client := &activityLogClient{
+		resourceManager:       resourceManager,
+		DefaultSubscriptionID: defaultSubscriptionID,
+		now:                   time.Now,
+	}
+	authorizer, err := credentialSource.Authorizer("")
+	if err == nil {
+		client.authorizer = authorizer
+	}
+
+	return client
+}
+
+func (c *activityLogClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	glog.V(6).Infof("Received metric request:\n%v", azMetricRequest)
+	subscriptionID := azMetricRequest.SubscriptionID
+	if subscriptionID == "" {
+		subscriptionID = c.DefaultSubscriptionID
+	}
+	if subscriptionID == "" {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "subscriptionID is required. set a default or pass via label selectors"}
+	}
+
+	filter, err := activityLogFilter(azMetricRequest.ResourceGroup, azMetricRequest.ActivityLog, c.now())
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	client := insights.NewActivityLogsClientWithBaseURI(c.resourceManager, subscriptionID)
+	if c.authorizer != nil {
+		client.Authorizer = c.authorizer
+	}
+
+	glog.V(2).Infof("counting activity log events of subscription %s matching %s", subscriptionID, filter)
+	page, err := client.List(context.Background(), filter, activityLogFields)
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+
+	count := 0
+	for pages := 1; page.NotDone(); pages++ {
+		if pages > maxActivityLogPages {
+			return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: fmt.Sprintf("more than %d pages of activity log events, narrow the window or filter", maxActivityLogPages)}
+		}
+		for _, event := range page.Values() {
+			if azMetricRequest.ActivityLog.matches(event) {
+				count++
+			}
+		}
+		if err := page.Next(); err != nil {
+			return AzureExternalMetricResponse{}, redact.Error(err)
+		}
+	}
+
+	glog.V(2).Infof("%d activity log events of subscription %s matched", count, subscriptionID)
+	return AzureExternalMetricResponse{
+		Total: float64(count),
+	}, nil
+}
+
+// activityLogFilter returns the Activity Log filter of the events of the window ending at now, for
+// the resource group, resource id or resource provider
+func activityLogFilter(resourceGroup string, definition ActivityLogDefinition, now time.Time) (string, error) {
+	window := defaultActivityLogWindow
+	if definition.Window != "" {
+		var err error
+		window, err = time.ParseDuration(definition.Window)
+		if err != nil || window <= 0 || window > maxActivityLogWindow {
+			return "", InvalidMetricRequestError{err: fmt.Sprintf("invalid activity log window '%s', must be a go duration of at most %s", definition.Window, maxActivityLogWindow)}
+		}
+	}
+
+	scopes := []string{}
+	if resourceGroup != "" {
+		scopes = append(scopes, fmt.Sprintf("resourceGroupName eq '%s'", resourceGroup))
+	}
+	if definition.ResourceID != "" {
+		scopes = append(scopes, fmt.Sprintf("resourceId eq '%s'", definition.ResourceID))
+	}
+	if definition.ResourceProvider != "" {
+		scopes = append(scopes, fmt.Sprintf("resourceProvider eq '%s'", definition.ResourceProvider))
+	}
+	if len(scopes) > 1 {
+		return "", InvalidMetricRequestError{err: "activity log events can be listed for a resource group, resource id or resource provider but only one of them"}
+	}
+	for _, value := range []string{resourceGroup, definition.ResourceID, definition.ResourceProvider} {
+		if strings.Contains(value, "'") {
+			return "", InvalidMetricRequestError{err: fmt.Sprintf("invalid activity log scope '%s'", value)}
+		}
+	}
+
+	filter := fmt.Sprintf("eventTimestamp ge '%s' and eventTimestamp le '%s'", now.Add(-window).UTC().Format(time.RFC3339), now.UTC().Format(time.RFC3339))
+	if len(scopes) == 1 {
+		filter = fmt.Sprintf("%s and %s", filter, scopes[0])
+	}
+	return filter, nil
+}
@@ -0,0 +1,116 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testActivityLogNow = time.Date(2019, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func TestActivityLogCountsMatchingEventsOfEachPage(t *testing.T) {
+	filter := ""
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprint(w, `{"value":[
+				{"category":{"value":"Autoscale"},"status":{"value":"Succeeded"},"level":"Informational"},
+				{"category":{"value":"Administrative"},"status":{"value":"Succeeded"},"level":"Informational"}
+			]}`)
+			return
+		}
+		filter = r.URL.Query().Get("$filter")
+		fmt.Fprintf(w, `{"value":[
+			{"category":{"value":"Autoscale"},"status":{"value":"Succeeded"},"level":"Informational"},
+			{"category":{"value":"Autoscale"},"status":{"value":"Failed"},"level":"Error"},
+			{"category":{"value":"autoscale"},"status":{"value":"succeeded"},"level":"Informational"}
+		],"nextLink":"%s/next?page=2"}`, server.URL)
+	}))
+	defer server.Close()
+
+	client := newTestActivityLogClient(server)
+	request := AzureExternalMetricRequest{
+		SubscriptionID: "1234",
+		ResourceGroup:  "rg",
+		ActivityLog:    ActivityLogDefinition{Window: "30m", Category: "Autoscale", Status: "Succeeded"},
+	}
+
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 3 {
+		t.Errorf("metricResponse.Total = %v, want %v", metricResponse.Total, 3)
+	}
+	want := "eventTimestamp ge '2019-03-10T11:30:00Z' and eventTimestamp le '2019-03-10T12:00:00Z' and resourceGroupName eq 'rg'"
+	if filter != want {
+		t.Errorf("$filter = %v, want %v", filter, want)
+	}
+}
+
+func TestActivityLogFilter(t *testing.T) {
+	var tests = []struct {
+		name          string
+		resourceGroup string
+		definition    ActivityLogDefinition
+		want          string
+	}{
+		{"default window", "", ActivityLogDefinition{}, "eventTimestamp ge '2019-03-10T11:00:00Z' and eventTimestamp le '2019-03-10T12:00:00Z'"},
+		{"resource id", "", ActivityLogDefinition{Window: "24h", ResourceID: "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Web/sites/api"},
+			"eventTimestamp ge '2019-03-09T12:00:00Z' and eventTimestamp le '2019-03-10T12:00:00Z' and resourceId eq '/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Web/sites/api'"},
+		{"resource provider", "", ActivityLogDefinition{ResourceProvider: "Microsoft.Insights"},
+			"eventTimestamp ge '2019-03-10T11:00:00Z' and eventTimestamp le '2019-03-10T12:00:00Z' and resourceProvider eq 'Microsoft.Insights'"},
+	}
+
+	for _, tt := range tests {
+		got, err := activityLogFilter(tt.resourceGroup, tt.definition, testActivityLogNow)
+		if err != nil {
+			t.Errorf("%s: error after processing got: %v, want nil", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: filter = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestActivityLogInvalidRequestGetError(t *testing.T) {
+	var tests = []struct {
+		name          string
+		resourceGroup string
+		definition    ActivityLogDefinition
+	}{
+		{"invalid window", "rg", ActivityLogDefinition{Window: "recent"}},
+		{"window beyond retention", "rg", ActivityLogDefinition{Window: "2200h"}},
+		{"several scopes", "rg", ActivityLogDefinition{ResourceProvider: "Microsoft.Insights"}},
+		{"quoted scope", "rg' or resourceGroupName eq 'other", ActivityLogDefinition{}},
+	}
+
+	for _, tt := range tests {
+		if _, err := activityLogFilter(tt.resourceGroup, tt.definition, testActivityLogNow); !IsInvalidMetricRequestError(err) {
+			t.Errorf("%s: error after processing got: %v, want InvalidMetricRequestError", tt.name, err)
+		}
+	}
+}
+
+func TestActivityLogFailedResponseGetError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"code":"AuthorizationFailed"}}`, http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := newTestActivityLogClient(server).GetAzureMetric(AzureExternalMetricRequest{SubscriptionID: "1234", ResourceGroup: "rg"})
+
+	if err == nil {
+		t.Errorf("no error after processing got: %v, want error", nil)
+	}
+}
+
+func newTestActivityLogClient(server *httptest.Server) *activityLogClient {
+	return &activityLogClient{
+		resourceManager: server.URL,
+		now:             func() time.Time { return testActivityLogNow },
+	}
+}
@@ -0,0 +1,186 @@
+package externalmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+// Actions applied to the value of a metric while its alert is firing
+const (
+	// AlertActionFloor serves at least the alert value, to hold scale during an incident
+	AlertActionFloor string = "floor"
+	// AlertActionCap serves at most the alert value, to stop scaling out into a failing dependency
+	AlertActionCap string = "cap"
+)
+
+const (
+	alertsAPIVersion = "2018-05-05"
+	// maxAlertsResponseSize limits how much of the alerts of a rule is read
+	maxAlertsResponseSize = 1024 * 1024
+)
+
+// AlertDefinition guards the value of a metric with an Azure Monitor alert rule
+type AlertDefinition struct {
+	RuleID string
+	Action string
+	Value  float64
+}
+
+// Apply returns the value the metric is served with while the alert is firing
+func (a AlertDefinition) Apply(value float64) float64 {
+	switch a.Action {
+	case AlertActionFloor:
+		if value < a.Value {
+			return a.Value
+		}
+	case AlertActionCap:
+		if value > a.Value {
+			return a.Value
+		}
+	}
+	return value
+}
+
+// Validate checks the action and rule id of the alert
+func (a AlertDefinition) Validate() error {
+	if a.Action != AlertActionFloor && a.Action != AlertActionCap {
+		return InvalidMetricRequestError{err: fmt.Sprintf("alert action must be %s or %s", AlertActionFloor, AlertActionCap)}
+	}
+	if _, err := ParseAlertRuleID(a.RuleID); err != nil {
+		return err
+	}
+	return nil
+}
+
+// AlertRule identifies an alert rule from its resource id
+type AlertRule struct {
+	SubscriptionID string
+	ResourceGroup  string
+	ResourceType   string
+	Name           string
+}
+
+// ParseAlertRuleID parses the resource id of an alert rule:
+// /subscriptions/<id>/resourceGroups/<group>/providers/Microsoft.Insights/metricAlerts/<name>
+func ParseAlertRuleID(ruleID string) (AlertRule, error) {
+	parts := strings.Split(strings.Trim(ruleID, "/"), "/")
+	if len(parts) != 8 || !strings.EqualFold(parts[0], "subscriptions") || !strings.EqualFold(parts[2], "resourceGroups") ||
+		!strings.EqualFold(parts[4], "providers") || !strings.EqualFold(parts[5], "Microsoft.Insights") {
+		return AlertRule{}, InvalidMetricRequestError{err: fmt.Sprintf("invalid alert rule id '%s'", ruleID)}
+	}
+
+	return AlertRule{
+		SubscriptionID: parts[1],
+		ResourceGroup:  parts[3],
+		ResourceType:   fmt.Sprintf("%s/%s", parts[5], parts[6]),
+		Name:           parts[7],
+	}, nil
+}
+
+// AlertChecker reports whether an alert rule has fired an alert that has not resolved
+type AlertChecker interface {
+	IsFiring(ruleID string) (bool, error)
+}
+
+type armAlertChecker struct {
+	credentials credentials.Source
+	client      *http.Client
+	// baseURL returns the Azure Resource Manager endpoint
+	baseURL func() (string, error)
+}
+
+// NewAlertChecker creates a checker that asks the Azure Monitor alerts management api of the
+// Azure Resource Manager endpoint for the alerts of a rule
+func NewAlertChecker(credentialSource credentials.Source, resourceManager string) AlertChecker {
+	return &armAlertChecker{
+		credentials: credentialSource,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		baseURL: func() (string, error) {
+			return strings.TrimSuffix(resourceManager, "/"), nil
+		},
+	}
+}
+
+type alertListResult struct {
+	Value []struct {
+		Properties struct {
+			Essentials struct {
+				MonitorCondition string `json:"monitorCondition"`
+				AlertState       string `json:"alertState"`
+			} `json:"essentials"`
+		} `json:"properties"`
+	} `json:"value"`
+}
+
+// IsFiring returns true if the rule has a fired alert that has not been closed.  Closing the alert
+// releases the guard before the condition resolves.
+func (c *armAlertChecker) IsFiring(ruleID string) (bool, error) {
+	rule, err := ParseAlertRuleID(ruleID)
+	if err != nil {
+		return false, err
+	}
+
+	baseURL, err := c.baseURL()
+	if err != nil {
+		return false, err
+	}
+
+	query := url.Values{}
+	query.Set("api-version", alertsAPIVersion)
+	query.Set("alertRule", ruleID)
+	query.Set("monitorCondition", "Fired")
+	// alerts that fired long ago can still be firing
+	query.Set("timeRange", "30d")
+	endpoint := fmt.Sprintf("%s/subscriptions/%s/providers/Microsoft.AlertsManagement/alerts?%s", baseURL, rule.SubscriptionID, query.Encode())
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return false, redact.Error(err)
+	}
+	authorizer, err := c.credentials.Authorizer("")
+	if err != nil {
+		return false, redact.Error(err)
+	}
+	if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+		return false, redact.Error(err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return false, redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxAlertsResponseSize))
+	if err != nil {
+		return false, fmt.Errorf("unable to read alerts: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unable to list alerts of rule %s, status %d: %s", rule.Name, resp.StatusCode, redact.String(string(body)))
+	}
+
+	var result alertListResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return false, fmt.Errorf("unable to parse alerts: %v", err)
+	}
+
+	for _, alert := range result.Value {
+		essentials := alert.Properties.Essentials
+		if essentials.MonitorCondition == "Fired" && essentials.AlertState != "Closed" {
+			glog.V(2).Infof("alert rule %s is firing", rule.Name)
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,104 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testAlertRuleID = "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Insights/metricAlerts/backlog"
+
+func TestAlertCheckerFiring(t *testing.T) {
+	var tests = []struct {
+		name   string
+		alerts string
+		want   bool
+	}{
+		{"fired", `{"value":[{"properties":{"essentials":{"monitorCondition":"Fired","alertState":"New"}}}]}`, true},
+		{"acknowledged", `{"value":[{"properties":{"essentials":{"monitorCondition":"Fired","alertState":"Acknowledged"}}}]}`, true},
+		{"closed", `{"value":[{"properties":{"essentials":{"monitorCondition":"Fired","alertState":"Closed"}}}]}`, false},
+		{"resolved", `{"value":[{"properties":{"essentials":{"monitorCondition":"Resolved","alertState":"New"}}}]}`, false},
+		{"none", `{"value":[]}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alertRule := ""
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				alertRule = r.URL.Query().Get("alertRule")
+				fmt.Fprint(w, tt.alerts)
+			}))
+			defer server.Close()
+
+			firing, err := newTestAlertChecker(server).IsFiring(testAlertRuleID)
+
+			if err != nil {
+				t.Fatalf("error after processing got: %v, want nil", err)
+			}
+			if firing != tt.want {
+				t.Errorf("IsFiring() = %v, want %v", firing, tt.want)
+			}
+			if alertRule != testAlertRuleID {
+				t.Errorf("alertRule = %v, want %v", alertRule, testAlertRuleID)
+			}
+		})
+	}
+}
+
+func TestAlertCheckerErrorStatusGetError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "throttled", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := newTestAlertChecker(server).IsFiring(testAlertRuleID)
+
+	if err == nil {
+		t.Errorf("no error after processing got: %v, want error", nil)
+	}
+}
+
+func TestAlertDefinitionApply(t *testing.T) {
+	var tests = []struct {
+		action string
+		value  float64
+		want   float64
+	}{
+		{AlertActionFloor, 3, 10},
+		{AlertActionFloor, 12, 12},
+		{AlertActionCap, 3, 3},
+		{AlertActionCap, 12, 10},
+	}
+
+	for _, tt := range tests {
+		alert := AlertDefinition{RuleID: testAlertRuleID, Action: tt.action, Value: 10}
+		if got := alert.Apply(tt.value); got != tt.want {
+			t.Errorf("%s Apply(%v) = %v, want %v", tt.action, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAlertDefinitionInvalidGetError(t *testing.T) {
+	var tests = []AlertDefinition{
+		{RuleID: testAlertRuleID, Action: "hold"},
+		{RuleID: "", Action: AlertActionFloor},
+		{RuleID: "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/account", Action: AlertActionFloor},
+	}
+
+	for _, alert := range tests {
+		if err := alert.Validate(); !IsInvalidMetricRequestError(err) {
+			t.Errorf("Validate(%+v) got %v, want InvalidMetricRequestError", alert, err)
+		}
+	}
+}
+
+func newTestAlertChecker(server *httptest.Server) *armAlertChecker {
+	return &armAlertChecker{
+		credentials: nullCredentialSource{},
+		client:      server.Client(),
+		baseURL: func() (string, error) {
+			return server.URL, nil
+		},
+	}
+}
@@ -0,0 +1,140 @@
+package externalmetrics
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// armReadsRemainingHeader is the number of reads Azure Resource Manager still allows the
+	// subscription before it throttles the caller
+	armReadsRemainingHeader = "x-ms-ratelimit-remaining-subscription-reads"
+	// MaxARMQuotaDegradation limits how far the quota stretches the interval metrics are queried at
+	MaxARMQuotaDegradation = 10
+	// armQuotaObservationTTL is how long the remaining quota of a subscription is trusted without
+	// a new response, as the quota refills over time
+	armQuotaObservationTTL = 5 * time.Minute
+)
+
+var subscriptionPath = regexp.MustCompile(`(?i)/subscriptions/([^/]+)`)
+
+var armQuotaDegradation = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "azure_metrics_adapter_arm_quota_degradation",
+	Help: "Factor the interval external metrics of the subscription are queried at is stretched by as its Azure Resource Manager read quota runs out. 1 when not degraded.",
+}, []string{"subscription"})
+
+func init() {
+	prometheus.MustRegister(armQuotaDegradation)
+}
+
+// ARMQuota tracks the Azure Resource Manager reads remaining to each subscription, so the adapter
+// can query Azure less often before it is throttled.  Below the threshold the degradation is the
+// threshold divided by the remaining reads, so it grows as the quota runs out.
+type ARMQuota struct {
+	threshold int
+	now       func() time.Time
+
+	mu        sync.Mutex
+	remaining map[string]observedQuota
+}
+
+type observedQuota struct {
+	reads    int
+	observed time.Time
+}
+
+// NewARMQuota creates a tracker that degrades subscriptions with fewer remaining reads than the
+// threshold.  A threshold of zero disables it.
+func NewARMQuota(threshold int) *ARMQuota {
+	if threshold <= 0 {
+		return nil
+	}
+	return &ARMQuota{
+		threshold: threshold,
+		now:       time.Now,
+		remaining: map[string]observedQuota{},
+	}
+}
+
+// Observe records the remaining reads of the subscription of the request of the response
+func (q *ARMQuota) Observe(resp *http.Response) {
+	if q == nil || resp == nil || resp.Request == nil || resp.Request.URL == nil {
+		return
+	}
+	header := resp.Header.Get(armReadsRemainingHeader)
+	if header == "" {
+		return
+	}
+	reads, err := strconv.Atoi(header)
+	if err != nil {
+		glog.V(2).Infof("ignoring invalid %s header %q", armReadsRemainingHeader, header)
+		return
+	}
+	match := subscriptionPath.FindStringSubmatch(resp.Request.URL.Path)
+	if match == nil {
+		return
+	}
+	subscriptionID := strings.ToLower(match[1])
+
+	q.mu.Lock()
+	q.remaining[subscriptionID] = observedQuota{reads: reads, observed: q.now()}
+	q.mu.Unlock()
+
+	degradation := q.Degradation(subscriptionID)
+	if degradation > 1 {
+		glog.V(2).Infof("subscription %s has %d azure resource manager reads left, querying it %.1f times less often", subscriptionID, reads, degradation)
+	}
+}
+
+// Degradation returns the factor the interval metrics of the subscription are queried at is
+// stretched by, 1 when its quota is above the threshold or unknown.  The degradation is exported
+// as a gauge each time it is applied.
+func (q *ARMQuota) Degradation(subscriptionID string) float64 {
+	if q == nil {
+		return 1
+	}
+
+	subscriptionID = strings.ToLower(subscriptionID)
+	q.mu.Lock()
+	quota, found := q.remaining[subscriptionID]
+	q.mu.Unlock()
+	if !found {
+		return 1
+	}
+
+	degradation := q.degradation(quota)
+	armQuotaDegradation.WithLabelValues(subscriptionID).Set(degradation)
+	return degradation
+}
+
+func (q *ARMQuota) degradation(quota observedQuota) float64 {
+	if q.now().Sub(quota.observed) > armQuotaObservationTTL || quota.reads >= q.threshold {
+		return 1
+	}
+	if quota.reads <= 0 {
+		return MaxARMQuotaDegradation
+	}
+	degradation := float64(q.threshold) / float64(quota.reads)
+	if degradation > MaxARMQuotaDegradation {
+		return MaxARMQuotaDegradation
+	}
+	return degradation
+}
+
+// observeResponses records the remaining quota of every response an autorest client receives
+func (q *ARMQuota) observeResponses() autorest.RespondDecorator {
+	return func(r autorest.Responder) autorest.Responder {
+		return autorest.ResponderFunc(func(resp *http.Response) error {
+			q.Observe(resp)
+			return r.Respond(resp)
+		})
+	}
+}
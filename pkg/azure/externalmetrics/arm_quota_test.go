@@ -0,0 +1,66 @@
+package externalmetrics
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestARMQuotaDegradation(t *testing.T) {
+	var tests = []struct {
+		name      string
+		remaining string
+		age       time.Duration
+		want      float64
+	}{
+		{"above threshold", "12000", 0, 1},
+		{"at threshold", "1000", 0, 1},
+		{"below threshold", "500", 0, 2},
+		{"nearly exhausted", "10", 0, MaxARMQuotaDegradation},
+		{"exhausted", "0", 0, MaxARMQuotaDegradation},
+		{"observed too long ago", "500", armQuotaObservationTTL + time.Second, 1},
+		{"invalid header", "many", 0, 1},
+	}
+
+	for _, tt := range tests {
+		now := time.Now()
+		quota := NewARMQuota(1000)
+		quota.now = func() time.Time { return now }
+
+		quota.Observe(newQuotaResponse("https://management.azure.com/subscriptions/SUB-ID/resourceGroups/rg/providers/microsoft.insights/metrics", tt.remaining))
+		now = now.Add(tt.age)
+
+		if got := quota.Degradation("sub-id"); got != tt.want {
+			t.Errorf("%s: degradation = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestARMQuotaOfOtherSubscriptionNotDegraded(t *testing.T) {
+	quota := NewARMQuota(1000)
+	quota.Observe(newQuotaResponse("https://management.azure.com/subscriptions/sub-a/providers/microsoft.insights/metrics", "100"))
+
+	if got := quota.Degradation("sub-a"); got != 10 {
+		t.Errorf("sub-a: degradation = %v, want %v", got, 10)
+	}
+	if got := quota.Degradation("sub-b"); got != 1 {
+		t.Errorf("sub-b: degradation = %v, want %v", got, 1)
+	}
+}
+
+func TestDisabledARMQuotaNotDegraded(t *testing.T) {
+	quota := NewARMQuota(0)
+	quota.Observe(newQuotaResponse("https://management.azure.com/subscriptions/sub-a", "0"))
+
+	if got := quota.Degradation("sub-a"); got != 1 {
+		t.Errorf("degradation = %v, want %v", got, 1)
+	}
+}
+
+func newQuotaResponse(rawURL string, remaining string) *http.Response {
+	u, _ := url.Parse(rawURL)
+	header := http.Header{}
+	header.Set(armReadsRemainingHeader, remaining)
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Request: &http.Request{URL: u}}
+}
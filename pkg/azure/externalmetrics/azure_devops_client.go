@@ -0,0 +1,188 @@
+package externalmetrics
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const (
+	// AzureDevOpsQueued serves the jobs waiting for an agent
+	AzureDevOpsQueued = "queued"
+	// AzureDevOpsPending serves the jobs not finished yet, whether waiting for or running on an agent
+	AzureDevOpsPending = "pending"
+
+	// azureDevOpsResource is the application id of Azure DevOps that tokens are requested for
+	azureDevOpsResource   = "499b84ac-1321-427f-aa17-267ca6975798"
+	azureDevOpsAPIVersion = "7.0"
+	// maxAzureDevOpsJobRequests limits the latest job requests of a pool read, which include
+	// finished jobs
+	maxAzureDevOpsJobRequests = 250
+	// maxAzureDevOpsResponseSize limits how much of a response is read
+	maxAzureDevOpsResponseSize = 8 * 1024 * 1024
+)
+
+// AzureDevOpsDefinition names the agent pool of an Azure DevOps organization whose jobs are counted,
+// and which jobs are counted.  The organization is authenticated with the personal access token
+// when it is set, which the provider resolves from the secret, or with a token of the adapter's
+// credentials otherwise.
+type AzureDevOpsDefinition struct {
+	// OrganizationURL is the url of the organization, such as https://dev.azure.com/<organization>
+	OrganizationURL string
+	PoolID          int
+	PoolName        string
+	// Jobs is queued or pending, queued unless set
+	Jobs                      string
+	PersonalAccessTokenSecret string
+	PersonalAccessTokenKey    string
+	PersonalAccessToken       string
+}
+
+type azureDevOpsClient struct {
+	credentials credentials.Source
+	client      *http.Client
+	// organizationURL returns the base url of the rest api of an organization
+	organizationURL func(organization *url.URL) string
+}
+
+// NewAzureDevOpsClient creates a client that serves the jobs of Azure DevOps agent pools, requested
+// from the rest api of their organization
+func NewAzureDevOpsClient(credentialSource credentials.Source) AzureExternalMetricClient {
+	return &azureDevOpsClient{
+		credentials: credentialSource,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		organizationURL: func(organization *url.URL) string {
+			return fmt.Sprintf("https://%s%s", organization.Host, strings.TrimSuffix(organization.Path, "/"))
+		},
+	}
+}
+
+// azureDevOpsJobRequests are the latest job requests of an agent pool
+type azureDevOpsJobRequests struct {
+	Value []struct {
+		RequestID  int64  `json:"requestId"`
+		AssignTime string `json:"assignTime"`
+		Result     string `json:"result"`
+	} `json:"value"`
+}
+
+// azureDevOpsPools are the agent pools of an organization with a name
+type azureDevOpsPools struct {
+	Value []struct {
+		ID int `json:"id"`
+	} `json:"value"`
+}
+
+func (c *azureDevOpsClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	devops := azMetricRequest.AzureDevOps
+	organization, err := url.Parse(devops.OrganizationURL)
+	if err != nil || organization.Scheme != "https" || organization.RawQuery != "" || !isAzureDevOpsHost(organization.Host) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "azure devops organization url must be https://dev.azure.com/<organization> or https://<organization>.visualstudio.com"}
+	}
+	if (devops.PoolID == 0) == (devops.PoolName == "") {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "azure devops metrics count the jobs of either a pool id or a pool name"}
+	}
+	if devops.PoolID < 0 {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "azure devops pool id is invalid"}
+	}
+	jobs := strings.ToLower(devops.Jobs)
+	if jobs == "" {
+		jobs = AzureDevOpsQueued
+	}
+	if jobs != AzureDevOpsQueued && jobs != AzureDevOpsPending {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: fmt.Sprintf("azure devops jobs must be one of %s, %s", AzureDevOpsPending, AzureDevOpsQueued)}
+	}
+
+	baseURL := c.organizationURL(organization)
+	// personal access tokens are sent as the password of basic authentication with no user name
+	authorizer := autorest.Authorizer(autorest.NewAPIKeyAuthorizerWithHeaders(map[string]interface{}{
+		"Authorization": "Basic " + base64.StdEncoding.EncodeToString([]byte(":"+devops.PersonalAccessToken)),
+	}))
+	if devops.PersonalAccessToken == "" {
+		if authorizer, err = c.credentials.Authorizer(azureDevOpsResource); err != nil {
+			return AzureExternalMetricResponse{}, redact.Error(err)
+		}
+	}
+
+	poolID := devops.PoolID
+	if devops.PoolName != "" {
+		var pools azureDevOpsPools
+		if err := c.get(fmt.Sprintf("%s/_apis/distributedtask/pools?poolName=%s&api-version=%s", baseURL, url.QueryEscape(devops.PoolName), azureDevOpsAPIVersion), authorizer, &pools); err != nil {
+			return AzureExternalMetricResponse{}, err
+		}
+		if len(pools.Value) == 0 {
+			return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: fmt.Sprintf("azure devops pool %s not found", devops.PoolName)}
+		}
+		poolID = pools.Value[0].ID
+	}
+
+	var requests azureDevOpsJobRequests
+	if err := c.get(fmt.Sprintf("%s/_apis/distributedtask/pools/%d/jobrequests?$top=%d&api-version=%s", baseURL, poolID, maxAzureDevOpsJobRequests, azureDevOpsAPIVersion), authorizer, &requests); err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	count := 0
+	for _, request := range requests.Value {
+		if request.Result != "" {
+			continue
+		}
+		if jobs == AzureDevOpsQueued && request.AssignTime != "" {
+			continue
+		}
+		count++
+	}
+
+	glog.V(4).Infof("azure devops pool %d has %d %s jobs", poolID, count, jobs)
+	return AzureExternalMetricResponse{
+		Total: float64(count),
+		Raw:   []string{fmt.Sprintf("%d %s jobs in the latest %d job requests of pool %d", count, jobs, len(requests.Value), poolID)},
+	}, nil
+}
+
+// get requests the url with the authorizer and parses the response into result
+func (c *azureDevOpsClient) get(requestURL string, authorizer autorest.Authorizer, result interface{}) error {
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return redact.Error(err)
+	}
+	if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+		return redact.Error(err)
+	}
+
+	glog.V(2).Infof("requesting %s", req.URL.Path)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxAzureDevOpsResponseSize))
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %v", req.URL.Path, err)
+	}
+	// azure devops redirects requests it can't authenticate to its sign in page
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		return fmt.Errorf("azure devops request %s returned status %d: %s", req.URL.Path, resp.StatusCode, redact.String(string(body)))
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("unable to parse %s: %v", req.URL.Path, err)
+	}
+	return nil
+}
+
+// isAzureDevOpsHost returns whether the host is that of Azure DevOps Services, the only hosts the
+// token of the adapter is sent to
+func isAzureDevOpsHost(host string) bool {
+	return host == "dev.azure.com" || (strings.HasSuffix(host, ".visualstudio.com") && len(host) > len(".visualstudio.com"))
+}
@@ -0,0 +1,131 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+)
+
+const testAzureDevOpsJobRequests = `{"count":4,"value":[` +
+	`{"requestId":4,"queueTime":"2023-06-01T10:03:00Z"},` +
+	`{"requestId":3,"queueTime":"2023-06-01T10:02:00Z"},` +
+	`{"requestId":2,"queueTime":"2023-06-01T10:01:00Z","assignTime":"2023-06-01T10:01:05Z","receiveTime":"2023-06-01T10:01:06Z"},` +
+	`{"requestId":1,"queueTime":"2023-06-01T10:00:00Z","assignTime":"2023-06-01T10:00:05Z","finishTime":"2023-06-01T10:00:50Z","result":"succeeded"}]}`
+
+func TestAzureDevOpsCountsQueuedJobsOfPool(t *testing.T) {
+	path, authorization := "", ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, authorization = r.URL.Path, r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprint(w, testAzureDevOpsJobRequests)
+	}))
+	defer server.Close()
+
+	client := newTestAzureDevOpsClient(server, nullCredentialSource{})
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type:        AzureDevOps,
+		AzureDevOps: AzureDevOpsDefinition{OrganizationURL: "https://dev.azure.com/contoso", PoolID: 12, PersonalAccessToken: "c2VjcmV0"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 2 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 2)
+	}
+	if path != "/contoso/_apis/distributedtask/pools/12/jobrequests" {
+		t.Errorf("path = %v, want job requests of the pool", path)
+	}
+	if authorization != "Basic OmMyVmpjbVYw" {
+		t.Errorf("authorization = %v, want the personal access token", authorization)
+	}
+}
+
+func TestAzureDevOpsCountsPendingJobsOfPoolName(t *testing.T) {
+	poolName := ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/contoso/_apis/distributedtask/pools":
+			poolName = r.URL.Query().Get("poolName")
+			fmt.Fprint(w, `{"count":1,"value":[{"id":7,"name":"aks agents"}]}`)
+		case "/contoso/_apis/distributedtask/pools/7/jobrequests":
+			fmt.Fprint(w, testAzureDevOpsJobRequests)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tokens := &resourceCredentialSource{}
+	client := newTestAzureDevOpsClient(server, tokens)
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		AzureDevOps: AzureDevOpsDefinition{OrganizationURL: "https://dev.azure.com/contoso/", PoolName: "aks agents", Jobs: "Pending"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 3 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 3)
+	}
+	if poolName != "aks agents" {
+		t.Errorf("pool name = %v, want aks agents", poolName)
+	}
+	if tokens.resource != azureDevOpsResource {
+		t.Errorf("token resource = %v, want %v", tokens.resource, azureDevOpsResource)
+	}
+}
+
+func TestAzureDevOpsSignInPageGetError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<html>Sign in</html>`)
+	}))
+	defer server.Close()
+
+	client := newTestAzureDevOpsClient(server, nullCredentialSource{})
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		AzureDevOps: AzureDevOpsDefinition{OrganizationURL: "https://contoso.visualstudio.com", PoolID: 12},
+	})
+
+	if err == nil {
+		t.Errorf("error after processing got: nil, want error for the sign in page")
+	}
+}
+
+func TestAzureDevOpsInvalidRequestsGetError(t *testing.T) {
+	var tests = []AzureDevOpsDefinition{
+		{OrganizationURL: "", PoolID: 12},
+		{OrganizationURL: "http://dev.azure.com/contoso", PoolID: 12},
+		{OrganizationURL: "https://attacker.example.com/contoso", PoolID: 12},
+		{OrganizationURL: "https://.visualstudio.com", PoolID: 12},
+		{OrganizationURL: "https://dev.azure.com/contoso"},
+		{OrganizationURL: "https://dev.azure.com/contoso", PoolID: 12, PoolName: "aks agents"},
+		{OrganizationURL: "https://dev.azure.com/contoso", PoolID: -1},
+		{OrganizationURL: "https://dev.azure.com/contoso", PoolID: 12, Jobs: "failed"},
+	}
+
+	client := NewAzureDevOpsClient(fakeCredentialSource{})
+	for _, devops := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{AzureDevOps: devops})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%+v) got %v, want InvalidMetricRequestError", devops, err)
+		}
+	}
+}
+
+func newTestAzureDevOpsClient(server *httptest.Server, credentialSource credentials.Source) *azureDevOpsClient {
+	return &azureDevOpsClient{
+		credentials: credentialSource,
+		client:      server.Client(),
+		organizationURL: func(organization *url.URL) string {
+			return server.URL + strings.TrimSuffix(organization.Path, "/")
+		},
+	}
+}
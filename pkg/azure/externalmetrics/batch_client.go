@@ -0,0 +1,198 @@
+package externalmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const (
+	// BatchActive serves the tasks waiting to be scheduled on a node
+	BatchActive = "active"
+	// BatchRunning serves the tasks running on a node
+	BatchRunning = "running"
+	// BatchPending serves the tasks not completed yet, whether active or running
+	BatchPending = "pending"
+
+	batchAPIVersion = "2023-05-01.17.0"
+	// maxBatchJobs limits the active jobs of a pool whose tasks are counted
+	maxBatchJobs = 100
+	// maxBatchResponseSize limits how much of a response is read
+	maxBatchResponseSize = 1024 * 1024
+)
+
+var batchID = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// BatchDefinition names the Azure Batch job, or the pool whose active jobs, have their tasks
+// counted, and which tasks are counted
+type BatchDefinition struct {
+	// Endpoint is the endpoint of the Batch account, such as https://<account>.<region>.batch.azure.com
+	Endpoint string
+	Job      string
+	Pool     string
+	// Tasks is active, running or pending, active unless set
+	Tasks string
+}
+
+type batchClient struct {
+	credentials credentials.Source
+	client      *http.Client
+	resource    string
+}
+
+// NewBatchClient creates a client that serves the task counts of Azure Batch jobs, requested from
+// the endpoint of their Batch account with tokens for the Batch resource of the cloud
+func NewBatchClient(credentialSource credentials.Source, batchResource string) AzureExternalMetricClient {
+	return &batchClient{
+		credentials: credentialSource,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		resource:    batchResource,
+	}
+}
+
+// batchTaskCounts are the task counts of a job by state
+type batchTaskCounts struct {
+	TaskCounts struct {
+		Active    int64 `json:"active"`
+		Running   int64 `json:"running"`
+		Completed int64 `json:"completed"`
+	} `json:"taskCounts"`
+}
+
+// batchJobs is a page of the jobs of a Batch account
+type batchJobs struct {
+	Value []struct {
+		ID string `json:"id"`
+	} `json:"value"`
+	NextLink string `json:"odata.nextLink"`
+}
+
+func (c *batchClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	batch := azMetricRequest.Batch
+	endpoint, err := url.Parse(batch.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" || strings.Trim(endpoint.Path, "/") != "" || endpoint.RawQuery != "" {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "batch endpoint must be the https endpoint of the batch account"}
+	}
+	if (batch.Job == "") == (batch.Pool == "") {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "batch metrics count the tasks of either a job or a pool"}
+	}
+	if batch.Job != "" && !batchID.MatchString(batch.Job) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "batch job id is invalid"}
+	}
+	if batch.Pool != "" && !batchID.MatchString(batch.Pool) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "batch pool id is invalid"}
+	}
+	tasks := strings.ToLower(batch.Tasks)
+	if tasks == "" {
+		tasks = BatchActive
+	}
+	if tasks != BatchActive && tasks != BatchRunning && tasks != BatchPending {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: fmt.Sprintf("batch tasks must be one of %s, %s, %s", BatchActive, BatchPending, BatchRunning)}
+	}
+
+	baseURL := fmt.Sprintf("https://%s", endpoint.Host)
+	authorizer, err := c.credentials.Authorizer(c.resource)
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+
+	jobs := []string{batch.Job}
+	if batch.Pool != "" {
+		if jobs, err = c.poolJobs(baseURL, batch.Pool, authorizer); err != nil {
+			return AzureExternalMetricResponse{}, err
+		}
+	}
+
+	total := int64(0)
+	raw := []string{}
+	for _, job := range jobs {
+		var counts batchTaskCounts
+		body, err := c.get(fmt.Sprintf("%s/jobs/%s/taskcounts?api-version=%s", baseURL, job, batchAPIVersion), authorizer, &counts)
+		if err != nil {
+			return AzureExternalMetricResponse{}, err
+		}
+		switch tasks {
+		case BatchActive:
+			total += counts.TaskCounts.Active
+		case BatchRunning:
+			total += counts.TaskCounts.Running
+		case BatchPending:
+			total += counts.TaskCounts.Active + counts.TaskCounts.Running
+		}
+		raw = append(raw, body)
+	}
+
+	glog.V(4).Infof("batch account %s has %d %s tasks in %d jobs", endpoint.Host, total, tasks, len(jobs))
+	return AzureExternalMetricResponse{
+		Total: float64(total),
+		Raw:   raw,
+	}, nil
+}
+
+// poolJobs returns the active jobs of the pool
+func (c *batchClient) poolJobs(baseURL string, pool string, authorizer autorest.Authorizer) ([]string, error) {
+	filter := url.QueryEscape(fmt.Sprintf("executionInfo/poolId eq '%s' and state eq 'active'", pool))
+	next := fmt.Sprintf("%s/jobs?api-version=%s&$select=id&$filter=%s", baseURL, batchAPIVersion, filter)
+
+	jobs := []string{}
+	for next != "" {
+		// the token is only sent to the account, whatever the next link of a page
+		if !strings.HasPrefix(next, baseURL+"/") {
+			return nil, fmt.Errorf("jobs of batch pool %s returned a next link outside the account", pool)
+		}
+		var page batchJobs
+		if _, err := c.get(next, authorizer, &page); err != nil {
+			return nil, err
+		}
+		for _, job := range page.Value {
+			jobs = append(jobs, job.ID)
+		}
+		if len(jobs) > maxBatchJobs {
+			return nil, InvalidMetricRequestError{err: fmt.Sprintf("batch pool %s has more than %d active jobs, count the tasks of a job", pool, maxBatchJobs)}
+		}
+		next = page.NextLink
+	}
+	return jobs, nil
+}
+
+// get requests the url with a token of the adapter's credentials and parses the response into
+// result, returning the body of the response
+func (c *batchClient) get(requestURL string, authorizer autorest.Authorizer, result interface{}) (string, error) {
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return "", redact.Error(err)
+	}
+	if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+		return "", redact.Error(err)
+	}
+
+	glog.V(2).Infof("requesting %s", req.URL.Path)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBatchResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("unable to read %s: %v", req.URL.Path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("batch request %s returned status %d: %s", req.URL.Path, resp.StatusCode, redact.String(string(body)))
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return "", fmt.Errorf("unable to parse %s: %v", req.URL.Path, err)
+	}
+	return string(body), nil
+}
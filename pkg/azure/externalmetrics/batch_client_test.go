@@ -0,0 +1,113 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBatchCountsTasksOfJob(t *testing.T) {
+	path := ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		fmt.Fprint(w, `{"taskCounts":{"active":12,"running":4,"completed":30,"succeeded":29,"failed":1}}`)
+	}))
+	defer server.Close()
+
+	credentials := &resourceCredentialSource{}
+	client := &batchClient{credentials: credentials, client: server.Client(), resource: "https://batch.core.windows.net/"}
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type:  Batch,
+		Batch: BatchDefinition{Endpoint: server.URL, Job: "render-frames", Tasks: "Pending"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 16 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 16)
+	}
+	if path != "/jobs/render-frames/taskcounts" {
+		t.Errorf("path = %v, want task counts of the job", path)
+	}
+	if credentials.resource != "https://batch.core.windows.net/" {
+		t.Errorf("token resource = %v, want %v", credentials.resource, "https://batch.core.windows.net/")
+	}
+}
+
+func TestBatchCountsTasksOfActiveJobsOfPool(t *testing.T) {
+	filter := ""
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/jobs":
+			if r.URL.Query().Get("page") == "" {
+				filter = r.URL.Query().Get("$filter")
+				fmt.Fprintf(w, `{"value":[{"id":"render-frames"}],"odata.nextLink":"%s/jobs?api-version=%s&page=2"}`, server.URL, batchAPIVersion)
+				return
+			}
+			fmt.Fprint(w, `{"value":[{"id":"encode-video"}]}`)
+		case "/jobs/render-frames/taskcounts":
+			fmt.Fprint(w, `{"taskCounts":{"active":12,"running":4,"completed":30}}`)
+		case "/jobs/encode-video/taskcounts":
+			fmt.Fprint(w, `{"taskCounts":{"active":3,"running":2,"completed":0}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := &batchClient{credentials: nullCredentialSource{}, client: server.Client()}
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type:  Batch,
+		Batch: BatchDefinition{Endpoint: server.URL, Pool: "gpu"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 15 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 15)
+	}
+	if filter != "executionInfo/poolId eq 'gpu' and state eq 'active'" {
+		t.Errorf("filter = %v, want active jobs of the pool", filter)
+	}
+}
+
+func TestBatchNextLinkOutsideAccountGetError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value":[{"id":"render-frames"}],"odata.nextLink":"https://attacker.example.com/jobs"}`)
+	}))
+	defer server.Close()
+
+	client := &batchClient{credentials: nullCredentialSource{}, client: server.Client()}
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Batch: BatchDefinition{Endpoint: server.URL, Pool: "gpu"},
+	})
+
+	if err == nil {
+		t.Errorf("error after processing got: nil, want error for the next link")
+	}
+}
+
+func TestBatchInvalidRequestsGetError(t *testing.T) {
+	var tests = []BatchDefinition{
+		{Endpoint: "", Job: "render-frames"},
+		{Endpoint: "http://render.westeurope.batch.azure.com", Job: "render-frames"},
+		{Endpoint: "https://render.westeurope.batch.azure.com/jobs", Job: "render-frames"},
+		{Endpoint: "https://render.westeurope.batch.azure.com"},
+		{Endpoint: "https://render.westeurope.batch.azure.com", Job: "render-frames", Pool: "gpu"},
+		{Endpoint: "https://render.westeurope.batch.azure.com", Job: "render/../frames"},
+		{Endpoint: "https://render.westeurope.batch.azure.com", Pool: "gpu'"},
+		{Endpoint: "https://render.westeurope.batch.azure.com", Job: "render-frames", Tasks: "failed"},
+	}
+
+	client := NewBatchClient(fakeCredentialSource{}, "https://batch.core.windows.net/")
+	for _, batch := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{Batch: batch})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%+v) got %v, want InvalidMetricRequestError", batch, err)
+		}
+	}
+}
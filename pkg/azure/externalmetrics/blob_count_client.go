@@ -0,0 +1,133 @@
+package externalmetrics
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/golang/glog"
+)
+
+const (
+	// blobsPerPage is the most blobs a page of a container listing returns
+	blobsPerPage = 5000
+	// maxBlobPages limits the pages of a listing read, so the count is capped at 100000 blobs
+	maxBlobPages = 20
+	// maxBlobListResponseSize limits how much of a page of a listing is read
+	maxBlobListResponseSize = 16 * 1024 * 1024
+)
+
+// BlobCountDefinition names the blob container, and the prefix of the names of its blobs, whose
+// blobs are counted.  The container is read with the connection string when it is set, which the
+// provider resolves from the secret, or with the adapter's credentials otherwise.
+type BlobCountDefinition struct {
+	Account                string
+	Container              string
+	Prefix                 string
+	ConnectionStringSecret string
+	ConnectionStringKey    string
+	ConnectionString       string
+}
+
+type blobCountClient struct {
+	credentials credentials.Source
+	client      *http.Client
+	now         func() time.Time
+	// blobURL returns the base url of the blob service of an account
+	blobURL func(account string) string
+	// storageSuffix is the suffix of the blob services of connection strings without endpoints
+	storageSuffix string
+}
+
+// NewBlobCountClient creates a client that serves the number of blobs in a container of a Storage
+// account under the storage endpoint suffix, listed from the blob service
+func NewBlobCountClient(credentialSource credentials.Source, storageSuffix string) AzureExternalMetricClient {
+	return &blobCountClient{
+		credentials: credentialSource,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		now:         time.Now,
+		blobURL: func(account string) string {
+			return fmt.Sprintf("https://%s.blob.%s", account, storageSuffix)
+		},
+		storageSuffix: storageSuffix,
+	}
+}
+
+// blobList is a page of the listing of the blobs of a container
+type blobList struct {
+	Blobs []struct {
+		Name string `xml:"Name"`
+	} `xml:"Blobs>Blob"`
+	NextMarker string `xml:"NextMarker"`
+}
+
+func (c *blobCountClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	blobs := azMetricRequest.BlobCount
+	var connection storageConnectionString
+	if blobs.ConnectionString != "" {
+		var err error
+		connection, err = parseStorageConnectionString(blobs.ConnectionString, c.storageSuffix, "blob")
+		if err != nil {
+			return AzureExternalMetricResponse{}, err
+		}
+		blobs.Account = connection.account
+	}
+	if !storageAccountName.MatchString(blobs.Account) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "storage account name is invalid"}
+	}
+	// container names follow the same rules as queue names
+	if len(blobs.Container) < 3 || len(blobs.Container) > 63 || !storageQueueName.MatchString(blobs.Container) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "blob container name is invalid"}
+	}
+	if len(blobs.Prefix) > 1024 {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "blob prefix is longer than a blob name"}
+	}
+
+	baseURL := connection.endpoint
+	if baseURL == "" {
+		baseURL = c.blobURL(blobs.Account)
+	}
+
+	count, marker := 0, ""
+	for page := 0; page < maxBlobPages; page++ {
+		listURL := fmt.Sprintf("%s/%s?restype=container&comp=list&maxresults=%d", baseURL, blobs.Container, blobsPerPage)
+		if blobs.Prefix != "" {
+			listURL = fmt.Sprintf("%s&prefix=%s", listURL, url.QueryEscape(blobs.Prefix))
+		}
+		if marker != "" {
+			listURL = fmt.Sprintf("%s&marker=%s", listURL, url.QueryEscape(marker))
+		}
+
+		glog.V(2).Infof("listing blobs of container %s of storage account %s", blobs.Container, blobs.Account)
+		resp, body, err := storageGet(c.client, c.credentials, c.now(), connection, listURL, maxBlobListResponseSize)
+		if err != nil {
+			return AzureExternalMetricResponse{}, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return AzureExternalMetricResponse{}, fmt.Errorf("blobs of container %s returned status %d: %s", blobs.Container, resp.StatusCode, redact.String(string(body)))
+		}
+
+		var list blobList
+		if err := xml.Unmarshal(body, &list); err != nil {
+			return AzureExternalMetricResponse{}, fmt.Errorf("unable to parse blobs of container %s: %v", blobs.Container, err)
+		}
+		count += len(list.Blobs)
+		marker = list.NextMarker
+		if marker == "" {
+			break
+		}
+	}
+	if marker != "" {
+		glog.Warningf("container %s has more than %d blobs with prefix '%s', serving %d", blobs.Container, count, blobs.Prefix, count)
+	}
+
+	glog.V(4).Infof("container %s has %d blobs with prefix '%s'", blobs.Container, count, blobs.Prefix)
+	return AzureExternalMetricResponse{
+		Total: float64(count),
+		Raw:   []string{fmt.Sprintf("%d blobs", count)},
+	}, nil
+}
@@ -0,0 +1,102 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBlobCountCountsEveryPage(t *testing.T) {
+	prefixes, markers := []string{}, []string{}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		prefixes = append(prefixes, r.URL.Query().Get("prefix"))
+		markers = append(markers, r.URL.Query().Get("marker"))
+		if r.URL.Query().Get("marker") == "" {
+			fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><EnumerationResults ContainerName="uploads"><Prefix>incoming/</Prefix><Blobs>`+
+				`<Blob><Name>incoming/a.csv</Name><Properties /></Blob><Blob><Name>incoming/b.csv</Name><Properties /></Blob>`+
+				`</Blobs><NextMarker>2!72!incoming/c.csv</NextMarker></EnumerationResults>`)
+			return
+		}
+		fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><EnumerationResults ContainerName="uploads"><Blobs>`+
+			`<Blob><Name>incoming/c.csv</Name><Properties /></Blob>`+
+			`</Blobs><NextMarker /></EnumerationResults>`)
+	}))
+	defer server.Close()
+
+	client := newTestBlobCountClient(server)
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type:      BlobCount,
+		BlobCount: BlobCountDefinition{Account: "account", Container: "uploads", Prefix: "incoming/"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 3 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 3)
+	}
+	if len(prefixes) != 2 || prefixes[1] != "incoming/" || markers[1] != "2!72!incoming/c.csv" {
+		t.Errorf("listings = %v %v, want the second page of the prefix", prefixes, markers)
+	}
+}
+
+func TestBlobCountSignedWithConnectionString(t *testing.T) {
+	authorization := ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><EnumerationResults><Blobs /><NextMarker /></EnumerationResults>`)
+	}))
+	defer server.Close()
+
+	client := newTestBlobCountClient(server)
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		BlobCount: BlobCountDefinition{
+			Container:        "uploads",
+			ConnectionString: fmt.Sprintf("AccountName=account;AccountKey=c2VjcmV0;BlobEndpoint=%s", server.URL),
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 0 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 0)
+	}
+	if !strings.HasPrefix(authorization, "SharedKey account:") {
+		t.Errorf("authorization = %v, want shared key of the account", authorization)
+	}
+}
+
+func TestBlobCountInvalidRequestsGetError(t *testing.T) {
+	var tests = []BlobCountDefinition{
+		{Account: "", Container: "uploads"},
+		{Account: "Account", Container: "uploads"},
+		{Account: "account", Container: "up"},
+		{Account: "account", Container: "uploads/../other"},
+		{Account: "account", Container: "uploads", Prefix: strings.Repeat("a", 1025)},
+		{Container: "uploads", ConnectionString: "AccountName=account"},
+	}
+
+	client := NewBlobCountClient(fakeCredentialSource{}, "core.windows.net")
+	for _, blobs := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{BlobCount: blobs})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%+v) got %v, want InvalidMetricRequestError", blobs, err)
+		}
+	}
+}
+
+func newTestBlobCountClient(server *httptest.Server) *blobCountClient {
+	return &blobCountClient{
+		credentials: nullCredentialSource{},
+		client:      server.Client(),
+		now:         func() time.Time { return testQueueNow },
+		blobURL: func(account string) string {
+			return server.URL
+		},
+		storageSuffix: "core.windows.net",
+	}
+}
@@ -1,7 +1,37 @@
 package externalmetrics
 
+import (
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
+)
+
 type AzureExternalMetricResponse struct {
 	Total float64
+	// Series holds the value for each dimension value when the metric has several series
+	Series []MetricSeries
+	// Unit is the Azure Monitor unit of the values when known
+	Unit string
+	// Raw holds the bodies of the responses the value was derived from, when the client keeps them
+	Raw []string
+	// Sources holds the value of each named source of a combined metric
+	Sources map[string]float64
+	// NoData is set when Azure Monitor had no values for the metric in the timespan
+	NoData bool
+}
+
+// Azure Monitor units that are scaled when an ExternalMetric uses units
+const (
+	UnitBytes          = string(insights.UnitBytes)
+	UnitBytesPerSecond = string(insights.UnitBytesPerSecond)
+	UnitByteSeconds    = string(insights.UnitByteSeconds)
+	UnitMilliSeconds   = string(insights.UnitMilliSeconds)
+	UnitPercent        = string(insights.UnitPercent)
+)
+
+// MetricSeries is the value of a metric for one set of dimension values
+type MetricSeries struct {
+	// Labels are the dimension values keyed by the lower case dimension name
+	Labels map[string]string
+	Value  float64
 }
 
 type AzureExternalMetricClient interface {
@@ -0,0 +1,28 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// WeightedSource is a query whose value is multiplied by its weight and added to the value of a
+// combined metric.  The weight is a decimal such as "0.7" and is parsed when the metric is requested.
+// A named source is also read by name in the expression of the metric.
+type WeightedSource struct {
+	Name    string
+	Weight  string
+	Request AzureExternalMetricRequest
+}
+
+// ParseWeight returns the weight of the source, 1 when it has none
+func (s WeightedSource) ParseWeight() (float64, error) {
+	if s.Weight == "" {
+		return 1, nil
+	}
+	weight, err := strconv.ParseFloat(s.Weight, 64)
+	if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return 0, InvalidMetricRequestError{err: fmt.Sprintf("invalid source weight '%s'", s.Weight)}
+	}
+	return weight, nil
+}
@@ -0,0 +1,123 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/golang/glog"
+)
+
+// containerAppRevisionDimension splits Container Apps metrics by revision
+const containerAppRevisionDimension = "revisionName"
+
+var containerAppName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]{0,58}[a-zA-Z0-9]$`)
+
+// ContainerAppDefinition names a Container App, or a Container Apps managed environment, and the
+// metric of it to serve.  The metric of an app is requests, replicas, cpu or memory and can be
+// filtered to a revision.  The metric of an environment is its Azure Monitor metric name.
+type ContainerAppDefinition struct {
+	App         string
+	Environment string
+	Revision    string
+	Metric      string
+}
+
+// containerAppMetrics are the Azure Monitor metrics of a Container App served for each metric
+var containerAppMetrics = map[string]fileShareMetric{
+	"requests": {metricName: "Requests", aggregation: "Total"},
+	"replicas": {metricName: "Replicas", aggregation: "Maximum"},
+	"cpu":      {metricName: "UsageNanoCores", aggregation: "Average"},
+	"memory":   {metricName: "WorkingSetBytes", aggregation: "Average"},
+}
+
+type containerAppClient struct {
+	monitor AzureExternalMetricClient
+}
+
+// NewContainerAppClient creates a client that serves Azure Container Apps metrics from Azure Monitor
+func NewContainerAppClient(defaultsubscriptionID string, credentialSource credentials.Source, endpoints *MonitorEndpoints, apiVersion string) AzureExternalMetricClient {
+	return &containerAppClient{
+		monitor: NewMonitorClient(defaultsubscriptionID, credentialSource, endpoints, apiVersion),
+	}
+}
+
+func (c *containerAppClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	monitorRequest, err := containerAppRequest(azMetricRequest)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	glog.V(2).Infof("requesting %s of container apps %s/%s", monitorRequest.MetricName, monitorRequest.ResourceType, monitorRequest.ResourceName)
+	return c.monitor.GetAzureMetric(monitorRequest)
+}
+
+// containerAppRequest converts a Container Apps metric to the Azure Monitor query of the app or
+// managed environment
+func containerAppRequest(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricRequest, error) {
+	containerApp := azMetricRequest.ContainerApp
+	if (containerApp.App == "") == (containerApp.Environment == "") {
+		return AzureExternalMetricRequest{}, InvalidMetricRequestError{err: "a container app metric names an app or an environment but not both"}
+	}
+
+	monitorRequest := azMetricRequest
+	monitorRequest.Type = Monitor
+	monitorRequest.ResourceProviderNamespace = "Microsoft.App"
+
+	if containerApp.Environment != "" {
+		if !containerAppName.MatchString(containerApp.Environment) {
+			return AzureExternalMetricRequest{}, InvalidMetricRequestError{err: "container apps environment name is invalid"}
+		}
+		if containerApp.Metric == "" {
+			return AzureExternalMetricRequest{}, InvalidMetricRequestError{err: "the metric of a container apps environment is required"}
+		}
+		if containerApp.Revision != "" {
+			return AzureExternalMetricRequest{}, InvalidMetricRequestError{err: "a revision can only filter the metric of an app"}
+		}
+		monitorRequest.ResourceType = "managedEnvironments"
+		monitorRequest.ResourceName = containerApp.Environment
+		monitorRequest.MetricName = containerApp.Metric
+		if monitorRequest.Aggregation == "" {
+			monitorRequest.Aggregation = "Average"
+		}
+		return monitorRequest, nil
+	}
+
+	metric, ok := containerAppMetrics[strings.ToLower(containerApp.Metric)]
+	if !ok {
+		return AzureExternalMetricRequest{}, InvalidMetricRequestError{err: fmt.Sprintf("container app metric must be one of %s", strings.Join(containerAppMetricNames(), ", "))}
+	}
+	if !containerAppName.MatchString(containerApp.App) {
+		return AzureExternalMetricRequest{}, InvalidMetricRequestError{err: "container app name is invalid"}
+	}
+	if strings.ContainsAny(containerApp.Revision, "'/") {
+		return AzureExternalMetricRequest{}, InvalidMetricRequestError{err: "container app revision name is invalid"}
+	}
+
+	monitorRequest.ResourceType = "containerApps"
+	monitorRequest.ResourceName = containerApp.App
+	monitorRequest.MetricName = metric.metricName
+	if monitorRequest.Aggregation == "" {
+		monitorRequest.Aggregation = metric.aggregation
+	}
+
+	if containerApp.Revision != "" {
+		filter := fmt.Sprintf("%s eq '%s'", containerAppRevisionDimension, containerApp.Revision)
+		if monitorRequest.Filter != "" {
+			filter = fmt.Sprintf("%s and %s", monitorRequest.Filter, filter)
+		}
+		monitorRequest.Filter = filter
+	}
+	return monitorRequest, nil
+}
+
+func containerAppMetricNames() []string {
+	names := []string{}
+	for name := range containerAppMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,85 @@
+package externalmetrics
+
+import (
+	"testing"
+)
+
+func TestContainerAppQueriesRevisionOfApp(t *testing.T) {
+	monitorClient := &recordingMonitorClient{result: makeAzureMonitorResponse(300)}
+	monitor := newMonitorClient("", monitorClient)
+	client := containerAppClient{monitor: &monitor}
+
+	request := newContainerAppMetricRequest()
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if metricResponse.Total != 300 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 300)
+	}
+
+	wantURI := "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.App/containerApps/api"
+	if monitorClient.resourceURI != wantURI {
+		t.Errorf("resourceURI = %v, want = %v", monitorClient.resourceURI, wantURI)
+	}
+	if monitorClient.metricnames != "Requests" || monitorClient.aggregation != "Total" {
+		t.Errorf("metric = %v %v, want = Requests Total", monitorClient.metricnames, monitorClient.aggregation)
+	}
+	if monitorClient.filter != "revisionName eq 'api--v2'" {
+		t.Errorf("filter = %v, want = %v", monitorClient.filter, "revisionName eq 'api--v2'")
+	}
+}
+
+func TestContainerAppEnvironmentQueriesMetricByName(t *testing.T) {
+	request := newContainerAppMetricRequest()
+	request.ContainerApp = ContainerAppDefinition{Environment: "prod", Metric: "EnvCoresQuotaUtilization"}
+
+	monitorRequest, err := containerAppRequest(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if monitorRequest.ResourceType != "managedEnvironments" || monitorRequest.ResourceName != "prod" {
+		t.Errorf("resource = %v/%v, want = managedEnvironments/prod", monitorRequest.ResourceType, monitorRequest.ResourceName)
+	}
+	if monitorRequest.MetricName != "EnvCoresQuotaUtilization" || monitorRequest.Aggregation != "Average" {
+		t.Errorf("metric = %v %v, want = EnvCoresQuotaUtilization Average", monitorRequest.MetricName, monitorRequest.Aggregation)
+	}
+}
+
+func TestContainerAppInvalidRequestGetError(t *testing.T) {
+	var tests = []ContainerAppDefinition{
+		{App: "api", Metric: "latency"},
+		{App: "api", Environment: "prod", Metric: "requests"},
+		{Metric: "requests"},
+		{App: "api/revisions", Metric: "requests"},
+		{App: "api", Revision: "v2' or revisionName eq 'v1", Metric: "requests"},
+		{Environment: "prod"},
+		{Environment: "prod", Revision: "api--v2", Metric: "Requests"},
+	}
+
+	for _, containerApp := range tests {
+		request := newContainerAppMetricRequest()
+		request.ContainerApp = containerApp
+		if _, err := containerAppRequest(request); !IsInvalidMetricRequestError(err) {
+			t.Errorf("containerAppRequest(%+v) got %v, want InvalidMetricRequestError", containerApp, err)
+		}
+	}
+}
+
+func newContainerAppMetricRequest() AzureExternalMetricRequest {
+	return AzureExternalMetricRequest{
+		Type:           ContainerApp,
+		SubscriptionID: "1234",
+		ResourceGroup:  "rg",
+		Timespan:       "PT10",
+		ContainerApp: ContainerAppDefinition{
+			App:      "api",
+			Revision: "api--v2",
+			Metric:   "requests",
+		},
+	}
+}
@@ -0,0 +1,400 @@
+package externalmetrics
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const (
+	// CosmosDBNormalizedRU serves the normalized request unit consumption of a container, in percent
+	CosmosDBNormalizedRU = "normalizedru"
+	// CosmosDBChangeFeedLag serves the estimated number of changes of a container not yet
+	// processed by a change feed processor
+	CosmosDBChangeFeedLag = "changefeedlag"
+
+	cosmosDBAPIVersion = "2018-12-31"
+	// defaultLeaseContainer is the container of the leases of change feed processors unless a
+	// metric names another
+	defaultLeaseContainer = "leases"
+	// maxLeasePages limits how many pages of leases are read, of up to 1000 leases each
+	maxLeasePages = 10
+	// maxCosmosDBResponseSize limits how much of a response is read
+	maxCosmosDBResponseSize = 4 * 1024 * 1024
+)
+
+var (
+	cosmosDBAccountName  = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,42}[a-z0-9]$`)
+	cosmosDBResourceName = regexp.MustCompile(`^[^/\\#?]{0,254}[^/\\#? ]$`)
+)
+
+// CosmosDBDefinition names the Cosmos DB container and the metric of it to serve.  The change feed
+// lag is estimated from the leases of the change feed processor, which are read with the
+// connection string when it is set, which the provider resolves from the secret, or with the
+// adapter's credentials otherwise.
+type CosmosDBDefinition struct {
+	Account   string
+	Database  string
+	Container string
+	Metric    string
+	// LeaseDatabase is the database of the lease container, the database of the container unless set
+	LeaseDatabase  string
+	LeaseContainer string
+	// LeasePrefix selects the leases of one processor when several share the lease container
+	LeasePrefix            string
+	ConnectionStringSecret string
+	ConnectionStringKey    string
+	ConnectionString       string
+}
+
+type cosmosDBClient struct {
+	monitor     AzureExternalMetricClient
+	credentials credentials.Source
+	client      *http.Client
+	now         func() time.Time
+	// accountURL returns the base url of the data plane of an account
+	accountURL func(account string) string
+}
+
+// NewCosmosDBClient creates a client that serves the normalized RU consumption of a Cosmos DB
+// container from Azure Monitor, or the lag of its change feed processor from the data plane of
+// the account under the Cosmos DB endpoint suffix
+func NewCosmosDBClient(defaultsubscriptionID string, credentialSource credentials.Source, endpoints *MonitorEndpoints, apiVersion string, cosmosDBSuffix string) AzureExternalMetricClient {
+	return &cosmosDBClient{
+		monitor:     NewMonitorClient(defaultsubscriptionID, credentialSource, endpoints, apiVersion),
+		credentials: credentialSource,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		now:         time.Now,
+		accountURL: func(account string) string {
+			return fmt.Sprintf("https://%s.%s", account, cosmosDBSuffix)
+		},
+	}
+}
+
+// cosmosDBConnectionString is the endpoint and key of a connection string
+type cosmosDBConnectionString struct {
+	endpoint string
+	key      string
+}
+
+// changeFeedLease is a lease of a change feed processor on a partition key range, the lease token,
+// with the continuation of the change feed the processor has reached
+type changeFeedLease struct {
+	ID                string `json:"id"`
+	LeaseToken        string `json:"LeaseToken"`
+	PartitionID       string `json:"PartitionId"`
+	ContinuationToken string `json:"ContinuationToken"`
+}
+
+func (c *cosmosDBClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	cosmos := azMetricRequest.CosmosDB
+	var connection cosmosDBConnectionString
+	if cosmos.ConnectionString != "" {
+		var err error
+		connection, err = parseCosmosDBConnectionString(cosmos.ConnectionString)
+		if err != nil {
+			return AzureExternalMetricResponse{}, err
+		}
+		if cosmos.Account == "" {
+			cosmos.Account = strings.SplitN(strings.TrimPrefix(connection.endpoint, "https://"), ".", 2)[0]
+		}
+	}
+	if !cosmosDBAccountName.MatchString(cosmos.Account) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "cosmos db account name is invalid"}
+	}
+	if !cosmosDBResourceName.MatchString(cosmos.Database) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "cosmos db database name is invalid"}
+	}
+	if cosmos.Container != "" && !cosmosDBResourceName.MatchString(cosmos.Container) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "cosmos db container name is invalid"}
+	}
+
+	switch strings.ToLower(cosmos.Metric) {
+	case CosmosDBNormalizedRU:
+		monitorRequest := cosmosDBRequest(azMetricRequest, cosmos)
+		glog.V(2).Infof("requesting %s of cosmos db account %s", monitorRequest.MetricName, cosmos.Account)
+		return c.monitor.GetAzureMetric(monitorRequest)
+	case CosmosDBChangeFeedLag:
+		if cosmos.Container == "" {
+			return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "the change feed lag requires the cosmos db container"}
+		}
+		if cosmos.LeaseDatabase == "" {
+			cosmos.LeaseDatabase = cosmos.Database
+		}
+		if cosmos.LeaseContainer == "" {
+			cosmos.LeaseContainer = defaultLeaseContainer
+		}
+		if !cosmosDBResourceName.MatchString(cosmos.LeaseDatabase) || !cosmosDBResourceName.MatchString(cosmos.LeaseContainer) {
+			return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "cosmos db lease container name is invalid"}
+		}
+		baseURL := connection.endpoint
+		if baseURL == "" {
+			baseURL = c.accountURL(cosmos.Account)
+		}
+		return c.changeFeedLag(cosmos, connection, baseURL)
+	default:
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: fmt.Sprintf("cosmos db metric must be one of %s, %s", CosmosDBChangeFeedLag, CosmosDBNormalizedRU)}
+	}
+}
+
+// cosmosDBRequest converts the normalized RU consumption to the Azure Monitor query of the
+// account, filtered to the database and container
+func cosmosDBRequest(azMetricRequest AzureExternalMetricRequest, cosmos CosmosDBDefinition) AzureExternalMetricRequest {
+	monitorRequest := azMetricRequest
+	monitorRequest.Type = Monitor
+	monitorRequest.ResourceProviderNamespace = "Microsoft.DocumentDB"
+	monitorRequest.ResourceType = "databaseAccounts"
+	monitorRequest.ResourceName = cosmos.Account
+	monitorRequest.MetricName = "NormalizedRUConsumption"
+	if monitorRequest.Aggregation == "" {
+		monitorRequest.Aggregation = "Maximum"
+	}
+
+	filter := fmt.Sprintf("DatabaseName eq '%s'", strings.Replace(cosmos.Database, "'", "''", -1))
+	if cosmos.Container != "" {
+		filter = fmt.Sprintf("%s and CollectionName eq '%s'", filter, strings.Replace(cosmos.Container, "'", "''", -1))
+	}
+	if monitorRequest.Filter != "" {
+		filter = fmt.Sprintf("%s and %s", monitorRequest.Filter, filter)
+	}
+	monitorRequest.Filter = filter
+	return monitorRequest
+}
+
+// changeFeedLag estimates the changes not yet processed as the change feed estimator of the Cosmos
+// DB SDKs does: for each lease, the changes from the first change after its continuation up to the
+// latest LSN of its partition key range
+func (c *cosmosDBClient) changeFeedLag(cosmos CosmosDBDefinition, connection cosmosDBConnectionString, baseURL string) (AzureExternalMetricResponse, error) {
+	leases, err := c.leases(cosmos, connection, baseURL)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+	if len(leases) == 0 {
+		return AzureExternalMetricResponse{}, fmt.Errorf("no change feed leases found in container %s/%s, the processor may not have started", cosmos.LeaseDatabase, cosmos.LeaseContainer)
+	}
+
+	lag := int64(0)
+	raw := []string{}
+	for _, lease := range leases {
+		leaseLag, err := c.leaseLag(cosmos, connection, baseURL, lease)
+		if err != nil {
+			return AzureExternalMetricResponse{}, err
+		}
+		lag += leaseLag
+		raw = append(raw, fmt.Sprintf("lease %s: continuation %s, lag %d", lease.ID, lease.ContinuationToken, leaseLag))
+	}
+
+	glog.V(4).Infof("change feed of cosmos db container %s/%s lags by %d changes", cosmos.Database, cosmos.Container, lag)
+	return AzureExternalMetricResponse{
+		Total: float64(lag),
+		Raw:   raw,
+	}, nil
+}
+
+// leases reads the leases of the change feed processor from the lease container, skipping the
+// documents the processor keeps alongside them
+func (c *cosmosDBClient) leases(cosmos CosmosDBDefinition, connection cosmosDBConnectionString, baseURL string) ([]changeFeedLease, error) {
+	link := fmt.Sprintf("dbs/%s/colls/%s", cosmos.LeaseDatabase, cosmos.LeaseContainer)
+	leases := []changeFeedLease{}
+	continuation := ""
+	for page := 0; page < maxLeasePages; page++ {
+		headers := map[string]string{"x-ms-max-item-count": "1000"}
+		if continuation != "" {
+			headers["x-ms-continuation"] = continuation
+		}
+		resp, body, err := c.do(connection, baseURL, link, headers)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("leases in container %s/%s returned status %d: %s", cosmos.LeaseDatabase, cosmos.LeaseContainer, resp.StatusCode, redact.String(string(body)))
+		}
+
+		var feed struct {
+			Documents []changeFeedLease `json:"Documents"`
+		}
+		if err := json.Unmarshal(body, &feed); err != nil {
+			return nil, fmt.Errorf("unable to parse leases in container %s/%s: %v", cosmos.LeaseDatabase, cosmos.LeaseContainer, err)
+		}
+		for _, lease := range feed.Documents {
+			if lease.LeaseToken == "" {
+				// leases of version 2 of the SDKs name the partition key range its partition
+				lease.LeaseToken = lease.PartitionID
+			}
+			if lease.LeaseToken != "" && strings.HasPrefix(lease.ID, cosmos.LeasePrefix) {
+				leases = append(leases, lease)
+			}
+		}
+
+		continuation = resp.Header.Get("x-ms-continuation")
+		if continuation == "" {
+			return leases, nil
+		}
+	}
+	return nil, fmt.Errorf("more than %d pages of documents in lease container %s/%s", maxLeasePages, cosmos.LeaseDatabase, cosmos.LeaseContainer)
+}
+
+// leaseLag reads the first change after the continuation of the lease, and returns the changes
+// from it up to the latest LSN of the partition key range, reported in the session token
+func (c *cosmosDBClient) leaseLag(cosmos CosmosDBDefinition, connection cosmosDBConnectionString, baseURL string, lease changeFeedLease) (int64, error) {
+	headers := map[string]string{
+		"A-IM":                                "Incremental feed",
+		"x-ms-documentdb-partitionkeyrangeid": lease.LeaseToken,
+		"x-ms-max-item-count":                 "1",
+	}
+	if lease.ContinuationToken != "" {
+		headers["If-None-Match"] = lease.ContinuationToken
+	}
+	resp, body, err := c.do(connection, baseURL, fmt.Sprintf("dbs/%s/colls/%s", cosmos.Database, cosmos.Container), headers)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		return 0, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("change feed of cosmos db container %s/%s returned status %d: %s", cosmos.Database, cosmos.Container, resp.StatusCode, redact.String(string(body)))
+	}
+
+	var feed struct {
+		Documents []struct {
+			LSN int64 `json:"_lsn"`
+		} `json:"Documents"`
+	}
+	if err := json.Unmarshal(body, &feed); err != nil {
+		return 0, fmt.Errorf("unable to parse change feed of cosmos db container %s/%s: %v", cosmos.Database, cosmos.Container, err)
+	}
+	if len(feed.Documents) == 0 {
+		return 0, nil
+	}
+
+	sessionToken := resp.Header.Get("x-ms-session-token")
+	latest, err := sessionTokenLSN(sessionToken)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse session token '%s' of cosmos db container %s/%s: %v", sessionToken, cosmos.Database, cosmos.Container, err)
+	}
+	if lag := latest - feed.Documents[0].LSN + 1; lag > 0 {
+		return lag, nil
+	}
+	return 0, nil
+}
+
+// sessionTokenLSN returns the LSN of a session token such as 0:-1#1234, of the partition key range
+// 0, or 0:1234 in the older format
+func sessionTokenLSN(sessionToken string) (int64, error) {
+	token := sessionToken[strings.Index(sessionToken, ":")+1:]
+	segments := strings.Split(token, "#")
+	lsn := segments[0]
+	if len(segments) > 1 {
+		lsn = segments[1]
+	}
+	return strconv.ParseInt(lsn, 10, 64)
+}
+
+// do sends a GET of the documents of the resource link, authorized with the key of the connection
+// string when it is set or a token of the adapter's credentials, and returns the response with its
+// body
+func (c *cosmosDBClient) do(connection cosmosDBConnectionString, baseURL string, link string, headers map[string]string) (*http.Response, []byte, error) {
+	segments := strings.Split(link, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/%s/docs", baseURL, strings.Join(segments, "/")), nil)
+	if err != nil {
+		return nil, nil, redact.Error(err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	date := c.now().UTC().Format(http.TimeFormat)
+	req.Header.Set("x-ms-date", date)
+	req.Header.Set("x-ms-version", cosmosDBAPIVersion)
+
+	if connection.key != "" {
+		authorization, err := connection.masterKeyAuthorization("get", "docs", link, date)
+		if err != nil {
+			return nil, nil, err
+		}
+		req.Header.Set("Authorization", authorization)
+	} else {
+		authorizer, err := c.credentials.Authorizer(baseURL)
+		if err != nil {
+			return nil, nil, redact.Error(err)
+		}
+		if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+			return nil, nil, redact.Error(err)
+		}
+		// cosmos db expects the token in its own format rather than as a bearer token
+		token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+		req.Header.Set("Authorization", url.QueryEscape("type=aad&ver=1.0&sig="+token))
+	}
+
+	glog.V(2).Infof("requesting documents of %s from %s", link, baseURL)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxCosmosDBResponseSize))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read documents of %s: %v", link, err)
+	}
+	return resp, body, nil
+}
+
+// parseCosmosDBConnectionString parses a connection string with an account key, such as
+// AccountEndpoint=https://<account>.documents.azure.com:443/;AccountKey=<key>
+func parseCosmosDBConnectionString(connectionString string) (cosmosDBConnectionString, error) {
+	var connection cosmosDBConnectionString
+	for _, part := range strings.Split(connectionString, ";") {
+		pair := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(pair) != 2 {
+			continue
+		}
+		switch strings.ToLower(pair[0]) {
+		case "accountendpoint":
+			endpoint, err := url.Parse(pair[1])
+			if err != nil || endpoint.Host == "" {
+				return cosmosDBConnectionString{}, InvalidMetricRequestError{err: "cosmos db connection string has an invalid endpoint"}
+			}
+			connection.endpoint = fmt.Sprintf("%s://%s", endpoint.Scheme, endpoint.Host)
+		case "accountkey":
+			connection.key = pair[1]
+		}
+	}
+
+	if connection.endpoint == "" || connection.key == "" {
+		return cosmosDBConnectionString{}, InvalidMetricRequestError{err: "cosmos db connection string requires AccountEndpoint and AccountKey"}
+	}
+	return connection, nil
+}
+
+// masterKeyAuthorization returns the authorization header of a request of the resource, signed
+// with the key of the connection string
+func (c cosmosDBConnectionString) masterKeyAuthorization(verb string, resourceType string, link string, date string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(c.key)
+	if err != nil {
+		return "", InvalidMetricRequestError{err: "cosmos db account key is not base64 encoded"}
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(fmt.Sprintf("%s\n%s\n%s\n%s\n\n", strings.ToLower(verb), strings.ToLower(resourceType), link, strings.ToLower(date))))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return url.QueryEscape(fmt.Sprintf("type=master&ver=1.0&sig=%s", signature)), nil
+}
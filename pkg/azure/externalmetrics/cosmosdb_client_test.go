@@ -0,0 +1,143 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCosmosDBQueriesNormalizedRUOfContainer(t *testing.T) {
+	monitorClient := &recordingMonitorClient{result: makeAzureMonitorResponse(42)}
+	monitor := newMonitorClient("", monitorClient)
+	client := cosmosDBClient{monitor: &monitor}
+
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type:           CosmosDB,
+		SubscriptionID: "1234",
+		ResourceGroup:  "rg",
+		CosmosDB:       CosmosDBDefinition{Account: "orders-db", Database: "orders", Container: "events", Metric: "NormalizedRU"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	wantURI := "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.DocumentDB/databaseAccounts/orders-db"
+	if monitorClient.resourceURI != wantURI {
+		t.Errorf("resourceURI = %v, want = %v", monitorClient.resourceURI, wantURI)
+	}
+	if monitorClient.metricnames != "NormalizedRUConsumption" || monitorClient.aggregation != "Maximum" {
+		t.Errorf("metric = %v %v, want = NormalizedRUConsumption Maximum", monitorClient.metricnames, monitorClient.aggregation)
+	}
+	if monitorClient.filter != "DatabaseName eq 'orders' and CollectionName eq 'events'" {
+		t.Errorf("filter = %v, want the database and container", monitorClient.filter)
+	}
+}
+
+func TestCosmosDBChangeFeedLagSumsLagOfLeases(t *testing.T) {
+	authorization := ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/dbs/orders/colls/leases/docs":
+			authorization = r.Header.Get("Authorization")
+			fmt.Fprint(w, `{"Documents":[
+				{"id":"orders-db.documents.azure.com_abc==_def=.info"},
+				{"id":"orders-db.documents.azure.com_abc==_def=..0","LeaseToken":"0","ContinuationToken":"\"100\""},
+				{"id":"orders-db.documents.azure.com_abc==_def=..1","LeaseToken":"1","ContinuationToken":"\"50\""},
+				{"id":"other.documents.azure.com_abc==_def=..0","LeaseToken":"0","ContinuationToken":"\"10\""}]}`)
+		case "/dbs/orders/colls/events/docs":
+			if r.Header.Get("A-IM") != "Incremental feed" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if r.Header.Get("x-ms-documentdb-partitionkeyrangeid") == "1" {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("x-ms-session-token", "0:-1#130")
+			fmt.Fprint(w, `{"Documents":[{"id":"order-17","_lsn":101}],"_count":1}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := &cosmosDBClient{credentials: nullCredentialSource{}, client: server.Client(), now: time.Now}
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type: CosmosDB,
+		CosmosDB: CosmosDBDefinition{
+			Account:          "orders-db",
+			Database:         "orders",
+			Container:        "events",
+			Metric:           CosmosDBChangeFeedLag,
+			LeasePrefix:      "orders-db.",
+			ConnectionString: fmt.Sprintf("AccountEndpoint=%s/;AccountKey=a2V5;", server.URL),
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 30 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 30)
+	}
+	if !strings.HasPrefix(authorization, "type%3Dmaster") {
+		t.Errorf("authorization = %v, want signed with the account key", authorization)
+	}
+}
+
+func TestCosmosDBMasterKeyAuthorization(t *testing.T) {
+	// the example of the Cosmos DB REST api documentation
+	connection := cosmosDBConnectionString{key: "dsZQi3KtZmCv1ljt3VNWNm7sQUF1y5rJfC6kv5JiwvW0EndXdDku/dkKBp8/ufDToSxLzR4y+O/0H/t4bQtVNw=="}
+	authorization, err := connection.masterKeyAuthorization("GET", "dbs", "dbs/ToDoList", "Thu, 27 Apr 2017 00:51:12 GMT")
+
+	if err != nil {
+		t.Fatalf("masterKeyAuthorization() error = %v, want nil", err)
+	}
+	want := "type%3dmaster%26ver%3d1.0%26sig%3dc09PEVJrgp2uQRkr934kFbTqhByc7TVr3OHyqlu%2bc%2bc%3d"
+	if !strings.EqualFold(authorization, want) {
+		t.Errorf("masterKeyAuthorization() = %v, want %v", authorization, want)
+	}
+}
+
+func TestSessionTokenLSN(t *testing.T) {
+	var tests = []struct {
+		token string
+		want  int64
+	}{
+		{token: "0:-1#130", want: 130},
+		{token: "2:1#4521#3=4520", want: 4521},
+		{token: "0:97", want: 97},
+	}
+
+	for _, tt := range tests {
+		if lsn, err := sessionTokenLSN(tt.token); err != nil || lsn != tt.want {
+			t.Errorf("sessionTokenLSN(%s) = %v %v, want %v", tt.token, lsn, err, tt.want)
+		}
+	}
+}
+
+func TestCosmosDBInvalidRequestsGetError(t *testing.T) {
+	var tests = []CosmosDBDefinition{
+		{Account: "", Database: "orders", Container: "events", Metric: CosmosDBNormalizedRU},
+		{Account: "Orders_DB", Database: "orders", Container: "events", Metric: CosmosDBNormalizedRU},
+		{Account: "orders-db", Database: "", Container: "events", Metric: CosmosDBNormalizedRU},
+		{Account: "orders-db", Database: "orders/colls", Container: "events", Metric: CosmosDBNormalizedRU},
+		{Account: "orders-db", Database: "orders", Container: "events", Metric: "latency"},
+		{Account: "orders-db", Database: "orders", Metric: CosmosDBChangeFeedLag},
+		{Account: "orders-db", Database: "orders", Container: "events", Metric: CosmosDBChangeFeedLag, LeaseContainer: "leases#1"},
+		{Database: "orders", Container: "events", Metric: CosmosDBChangeFeedLag, ConnectionString: "AccountEndpoint=https://orders-db.documents.azure.com:443/"},
+		{Database: "orders", Container: "events", Metric: CosmosDBChangeFeedLag, ConnectionString: "AccountEndpoint=https://orders-db.documents.azure.com:443/;AccountKey=not base64"},
+	}
+
+	client := NewCosmosDBClient("", fakeCredentialSource{}, nil, "", "documents.azure.com")
+	for _, cosmos := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{CosmosDB: cosmos})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%+v) got %v, want InvalidMetricRequestError", cosmos, err)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package externalmetrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const (
+	// CostAccrued serves the cost accrued over the timeframe
+	CostAccrued = "accrued"
+	// CostSpendRate serves the average daily cost of the last days
+	CostSpendRate = "spendrate"
+	// CostBudget serves the current spend of a budget as a percentage of its amount
+	CostBudget = "budget"
+
+	costManagementAPIVersion = "2023-03-01"
+	consumptionAPIVersion    = "2023-05-01"
+	// costCacheTTL is how long a cost is served before it is queried again, as costs are refreshed
+	// a few times a day and Cost Management allows few queries a minute
+	costCacheTTL = 30 * time.Minute
+	// defaultSpendRateDays is how many days the spend rate is averaged over unless set
+	defaultSpendRateDays = 7
+	// maxCostResponseSize limits how much of a response is read
+	maxCostResponseSize = 1024 * 1024
+)
+
+var (
+	costTimeframes = map[string]bool{"MonthToDate": true, "BillingMonthToDate": true, "WeekToDate": true}
+	costTypes      = map[string]bool{"ActualCost": true, "AmortizedCost": true}
+)
+
+// CostDefinition is the cost of the subscription, or of the resource group when the request has one,
+// that is served
+type CostDefinition struct {
+	// Metric is accrued, spendrate or budget
+	Metric string
+	// Timeframe of accrued costs, MonthToDate unless set
+	Timeframe string
+	// Days the spend rate is averaged over, 7 unless set
+	Days int
+	// CostType is ActualCost unless set, or AmortizedCost
+	CostType string
+	// Budget is the name of the budget of a budget metric
+	Budget string
+}
+
+// CostCache keeps the costs queried from Cost Management until they are older than the ttl, so
+// every replica of a workload querying the same cost doesn't get the adapter throttled
+type CostCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	results map[string]cachedCost
+}
+
+type cachedCost struct {
+	response AzureExternalMetricResponse
+	queried  time.Time
+}
+
+// NewCostCache creates the cache of the costs queried from Cost Management
+func NewCostCache() *CostCache {
+	return &CostCache{
+		ttl:     costCacheTTL,
+		now:     time.Now,
+		results: map[string]cachedCost{},
+	}
+}
+
+func (c *CostCache) get(key string) (AzureExternalMetricResponse, bool) {
+	if c == nil {
+		return AzureExternalMetricResponse{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, found := c.results[key]
+	if !found || c.now().Sub(cached.queried) > c.ttl {
+		delete(c.results, key)
+		return AzureExternalMetricResponse{}, false
+	}
+	return cached.response, true
+}
+
+func (c *CostCache) set(key string, response AzureExternalMetricResponse) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results[key] = cachedCost{response: response, queried: c.now()}
+}
+
+type costClient struct {
+	defaultSubscriptionID string
+	credentials           credentials.Source
+	client                *http.Client
+	resourceManager       string
+	cache                 *CostCache
+	now                   func() time.Time
+}
+
+// NewCostClient creates a client that serves the costs of subscriptions and resource groups from Cost
+// Management, through the resource manager endpoint, caching them in the cache
+func NewCostClient(defaultsubscriptionID string, credentialSource credentials.Source, resourceManager string, cache *CostCache) AzureExternalMetricClient {
+	return &costClient{
+		defaultSubscriptionID: defaultsubscriptionID,
+		credentials:           credentialSource,
+		client:                &http.Client{Timeout: 30 * time.Second},
+		resourceManager:       resourceManager,
+		cache:                 cache,
+		now:                   time.Now,
+	}
+}
+
+// costQuery is the body of a Cost Management query
+type costQuery struct {
+	Type       string          `json:"type"`
+	Timeframe  string          `json:"timeframe"`
+	TimePeriod *costTimePeriod `json:"timePeriod,omitempty"`
+	Dataset    struct {
+		Granularity string `json:"granularity"`
+		Aggregation map[string]struct {
+			Name     string `json:"name"`
+			Function string `json:"function"`
+		} `json:"aggregation"`
+	} `json:"dataset"`
+}
+
+type costTimePeriod struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// costQueryResult is the result of a Cost Management query, a single row of the total cost and its
+// currency
+type costQueryResult struct {
+	Properties struct {
+		Columns []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"columns"`
+		Rows [][]interface{} `json:"rows"`
+	} `json:"properties"`
+}
+
+// consumptionBudget is a budget of a subscription or resource group
+type consumptionBudget struct {
+	Properties struct {
+		Amount       float64 `json:"amount"`
+		CurrentSpend struct {
+			Amount float64 `json:"amount"`
+			Unit   string  `json:"unit"`
+		} `json:"currentSpend"`
+	} `json:"properties"`
+}
+
+func (c *costClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	cost := azMetricRequest.Cost
+	subscriptionID := azMetricRequest.SubscriptionID
+	if subscriptionID == "" {
+		subscriptionID = c.defaultSubscriptionID
+	}
+	if subscriptionID == "" {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "subscriptionID is required for cost metrics"}
+	}
+	scope := fmt.Sprintf("/subscriptions/%s", subscriptionID)
+	if azMetricRequest.ResourceGroup != "" {
+		scope = fmt.Sprintf("%s/resourceGroups/%s", scope, azMetricRequest.ResourceGroup)
+	}
+
+	costType := cost.CostType
+	if costType == "" {
+		costType = "ActualCost"
+	}
+	if !costTypes[costType] {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "cost type must be ActualCost or AmortizedCost"}
+	}
+
+	switch strings.ToLower(cost.Metric) {
+	case CostAccrued:
+		timeframe := cost.Timeframe
+		if timeframe == "" {
+			timeframe = "MonthToDate"
+		}
+		if !costTimeframes[timeframe] {
+			return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "cost timeframe must be one of BillingMonthToDate, MonthToDate, WeekToDate"}
+		}
+		return c.query(scope, newCostQuery(costType, timeframe, nil), 1)
+	case CostSpendRate:
+		days := cost.Days
+		if days == 0 {
+			days = defaultSpendRateDays
+		}
+		if days < 1 || days > 31 {
+			return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "spend rate days must be between 1 and 31"}
+		}
+		// the days before today, as the costs of today are incomplete
+		today := c.now().UTC().Truncate(24 * time.Hour)
+		period := &costTimePeriod{
+			From: today.AddDate(0, 0, -days).Format(time.RFC3339),
+			To:   today.Add(-time.Second).Format(time.RFC3339),
+		}
+		return c.query(scope, newCostQuery(costType, "Custom", period), float64(days))
+	case CostBudget:
+		if cost.Budget == "" {
+			return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "budget is required for budget cost metrics"}
+		}
+		return c.budget(scope, cost.Budget)
+	}
+	return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: fmt.Sprintf("cost metric must be one of %s, %s, %s", CostAccrued, CostBudget, CostSpendRate)}
+}
+
+func newCostQuery(costType string, timeframe string, period *costTimePeriod) costQuery {
+	query := costQuery{Type: costType, Timeframe: timeframe, TimePeriod: period}
+	query.Dataset.Granularity = "None"
+	query.Dataset.Aggregation = map[string]struct {
+		Name     string `json:"name"`
+		Function string `json:"function"`
+	}{"totalCost": {Name: "Cost", Function: "Sum"}}
+	return query
+}
+
+// query serves the total cost of the query in the scope divided by the divisor
+func (c *costClient) query(scope string, query costQuery, divisor float64) (AzureExternalMetricResponse, error) {
+	body, err := json.Marshal(query)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+	queryURL := fmt.Sprintf("%s%s/providers/Microsoft.CostManagement/query?api-version=%s", c.resourceManager, scope, costManagementAPIVersion)
+	key := queryURL + string(body)
+	if cached, found := c.cache.get(key); found {
+		glog.V(4).Infof("serving cached cost of %s", scope)
+		return cached, nil
+	}
+
+	var result costQueryResult
+	raw, err := c.do("POST", queryURL, body, &result)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	total := 0.0
+	columns := result.Properties.Columns
+	if len(result.Properties.Rows) > 0 {
+		row := result.Properties.Rows[0]
+		for i, column := range columns {
+			if column.Type == "Number" && i < len(row) {
+				value, ok := row[i].(float64)
+				if !ok {
+					return AzureExternalMetricResponse{}, fmt.Errorf("cost of %s is not a number: %v", scope, row[i])
+				}
+				total = value
+				break
+			}
+		}
+	}
+
+	glog.V(4).Infof("cost of %s is %v", scope, total/divisor)
+	response := AzureExternalMetricResponse{
+		Total: total / divisor,
+		Raw:   []string{raw},
+	}
+	c.cache.set(key, response)
+	return response, nil
+}
+
+// budget serves the current spend of the budget in the scope as a percentage of its amount
+func (c *costClient) budget(scope string, name string) (AzureExternalMetricResponse, error) {
+	budgetURL := fmt.Sprintf("%s%s/providers/Microsoft.Consumption/budgets/%s?api-version=%s", c.resourceManager, scope, name, consumptionAPIVersion)
+	if cached, found := c.cache.get(budgetURL); found {
+		glog.V(4).Infof("serving cached budget %s of %s", name, scope)
+		return cached, nil
+	}
+
+	var budget consumptionBudget
+	raw, err := c.do("GET", budgetURL, nil, &budget)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+	if budget.Properties.Amount <= 0 {
+		return AzureExternalMetricResponse{}, fmt.Errorf("budget %s of %s has no amount", name, scope)
+	}
+
+	percent := budget.Properties.CurrentSpend.Amount / budget.Properties.Amount * 100
+	glog.V(4).Infof("budget %s of %s is %v%% spent", name, scope, percent)
+	response := AzureExternalMetricResponse{
+		Total: percent,
+		Unit:  UnitPercent,
+		Raw:   []string{raw},
+	}
+	c.cache.set(budgetURL, response)
+	return response, nil
+}
+
+// do sends the request with a token of the adapter's credentials and parses the response into
+// result, returning the body of the response
+func (c *costClient) do(method string, requestURL string, body []byte, result interface{}) (string, error) {
+	req, err := http.NewRequest(method, requestURL, bytes.NewReader(body))
+	if err != nil {
+		return "", redact.Error(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	authorizer, err := c.credentials.Authorizer("")
+	if err != nil {
+		return "", redact.Error(err)
+	}
+	if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+		return "", redact.Error(err)
+	}
+
+	glog.V(2).Infof("requesting %s", req.URL.Path)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxCostResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("unable to read %s: %v", req.URL.Path, err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return "", fmt.Errorf("cost request %s was throttled, retry after %s seconds", req.URL.Path, resp.Header.Get("Retry-After"))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("cost request %s returned status %d: %s", req.URL.Path, resp.StatusCode, redact.String(string(respBody)))
+	}
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return "", fmt.Errorf("unable to parse %s: %v", req.URL.Path, err)
+	}
+	return string(respBody), nil
+}
@@ -0,0 +1,161 @@
+package externalmetrics
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCostAccruedOfResourceGroup(t *testing.T) {
+	var query costQuery
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &query)
+		w.Write([]byte(`{"properties":{"columns":[{"name":"totalCost","type":"Number"},{"name":"Currency","type":"String"}],"rows":[[1234.5,"USD"]]}}`))
+	}))
+	defer server.Close()
+
+	client := NewCostClient("default-subscription", nullCredentialSource{}, server.URL, nil)
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type:          Cost,
+		ResourceGroup: "shop",
+		Cost:          CostDefinition{Metric: "accrued", CostType: "AmortizedCost"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 1234.5 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 1234.5)
+	}
+	if path != "/subscriptions/default-subscription/resourceGroups/shop/providers/Microsoft.CostManagement/query" {
+		t.Errorf("path = %v, want the query of the resource group", path)
+	}
+	if query.Type != "AmortizedCost" || query.Timeframe != "MonthToDate" {
+		t.Errorf("query = %+v, want AmortizedCost MonthToDate", query)
+	}
+}
+
+func TestCostSpendRateAveragesCompletedDays(t *testing.T) {
+	var query costQuery
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &query)
+		w.Write([]byte(`{"properties":{"columns":[{"name":"totalCost","type":"Number"},{"name":"Currency","type":"String"}],"rows":[[700,"USD"]]}}`))
+	}))
+	defer server.Close()
+
+	client := &costClient{
+		credentials:     nullCredentialSource{},
+		client:          http.DefaultClient,
+		resourceManager: server.URL,
+		now:             func() time.Time { return time.Date(2019, 3, 15, 13, 30, 0, 0, time.UTC) },
+	}
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		SubscriptionID: "sub",
+		Cost:           CostDefinition{Metric: "spendrate"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 100 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 100)
+	}
+	if query.Timeframe != "Custom" || query.TimePeriod == nil || query.TimePeriod.From != "2019-03-08T00:00:00Z" || query.TimePeriod.To != "2019-03-14T23:59:59Z" {
+		t.Errorf("query = %+v, want the 7 days before today", query)
+	}
+}
+
+func TestCostBudgetServesPercentSpent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/subscriptions/sub/providers/Microsoft.Consumption/budgets/monthly" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"properties":{"amount":2000,"currentSpend":{"amount":1500,"unit":"USD"}}}`))
+	}))
+	defer server.Close()
+
+	client := NewCostClient("sub", nullCredentialSource{}, server.URL, nil)
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Cost: CostDefinition{Metric: "budget", Budget: "monthly"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 75 || metricResponse.Unit != UnitPercent {
+		t.Errorf("metricResponse = %v %v, want = 75 %v", metricResponse.Total, metricResponse.Unit, UnitPercent)
+	}
+}
+
+func TestCostIsCachedUntilTTL(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"properties":{"columns":[{"name":"totalCost","type":"Number"}],"rows":[[10]]}}`))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	cache := NewCostCache()
+	cache.now = func() time.Time { return now }
+	client := NewCostClient("sub", nullCredentialSource{}, server.URL, cache)
+	request := AzureExternalMetricRequest{Cost: CostDefinition{Metric: "accrued"}}
+
+	client.GetAzureMetric(request)
+	client.GetAzureMetric(request)
+	if requests != 1 {
+		t.Errorf("requests = %v, want the cost to be cached", requests)
+	}
+
+	now = now.Add(costCacheTTL + time.Second)
+	client.GetAzureMetric(request)
+	if requests != 2 {
+		t.Errorf("requests = %v, want the cost to be queried after the ttl", requests)
+	}
+}
+
+func TestCostThrottledGetsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	cache := NewCostCache()
+	client := NewCostClient("sub", nullCredentialSource{}, server.URL, cache)
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{Cost: CostDefinition{Metric: "accrued"}})
+
+	if err == nil {
+		t.Errorf("error after processing got nil, want error")
+	}
+	if len(cache.results) != 0 {
+		t.Errorf("cache = %v, want errors not cached", cache.results)
+	}
+}
+
+func TestCostInvalidRequestsGetError(t *testing.T) {
+	var tests = []CostDefinition{
+		{},
+		{Metric: "forecast"},
+		{Metric: "accrued", Timeframe: "LastYear"},
+		{Metric: "accrued", CostType: "Usage"},
+		{Metric: "spendrate", Days: 90},
+		{Metric: "budget"},
+	}
+
+	client := NewCostClient("sub", nullCredentialSource{}, "https://management.azure.com", nil)
+	for _, cost := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{Cost: cost})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%+v) got %v, want InvalidMetricRequestError", cost, err)
+		}
+	}
+}
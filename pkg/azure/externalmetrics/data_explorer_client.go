@@ -0,0 +1,162 @@
+package externalmetrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+var dataExplorerDatabaseName = regexp.MustCompile(`^[a-zA-Z0-9 ._-]{1,260}$`)
+
+// DataExplorerDefinition is a KQL query of an Azure Data Explorer database whose result is a
+// single number
+type DataExplorerDefinition struct {
+	// Cluster is the uri of the cluster, such as https://<cluster>.<region>.kusto.windows.net
+	Cluster  string
+	Database string
+	Query    string
+	// Column is the column of the result served, the first column unless set
+	Column string
+}
+
+type dataExplorerClient struct {
+	credentials credentials.Source
+	client      *http.Client
+}
+
+// NewDataExplorerClient creates a client that serves the result of KQL queries of Azure Data
+// Explorer databases, requested from the query endpoint of their cluster
+func NewDataExplorerClient(credentialSource credentials.Source) AzureExternalMetricClient {
+	return &dataExplorerClient{
+		credentials: credentialSource,
+		client:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// dataExplorerQuery is the body of a query request
+type dataExplorerQuery struct {
+	Database string `json:"db"`
+	Query    string `json:"csl"`
+}
+
+// dataExplorerResult is the result of a query in the v1 format, whose first table is the result
+// of its last statement
+type dataExplorerResult struct {
+	Tables []struct {
+		Columns []struct {
+			ColumnName string `json:"ColumnName"`
+		} `json:"Columns"`
+		Rows [][]interface{} `json:"Rows"`
+	} `json:"Tables"`
+}
+
+// dataExplorerError is the error returned by a cluster for a query that fails
+type dataExplorerError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		// Detail is the reason of the error, such as the syntax error of the query
+		Detail string `json:"@message"`
+	} `json:"error"`
+}
+
+func (c *dataExplorerClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	query := azMetricRequest.DataExplorer
+	cluster, err := url.Parse(query.Cluster)
+	if err != nil || cluster.Scheme != "https" || cluster.Host == "" || strings.Trim(cluster.Path, "/") != "" || cluster.RawQuery != "" {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "data explorer cluster must be the https uri of the cluster"}
+	}
+	if !dataExplorerDatabaseName.MatchString(query.Database) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "data explorer database name is invalid"}
+	}
+	if strings.TrimSpace(query.Query) == "" {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "data explorer query is required"}
+	}
+
+	clusterURI := fmt.Sprintf("https://%s", cluster.Host)
+	requestBody, err := json.Marshal(dataExplorerQuery{Database: query.Database, Query: query.Query})
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/v1/rest/query", clusterURI), bytes.NewReader(requestBody))
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// tokens of a cluster are requested for the uri of the cluster
+	authorizer, err := c.credentials.Authorizer(clusterURI)
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+	if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+
+	glog.V(2).Infof("querying data explorer database %s of cluster %s", query.Database, clusterURI)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxQueryResponseSize))
+	if err != nil {
+		return AzureExternalMetricResponse{}, fmt.Errorf("unable to read result of data explorer query: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return AzureExternalMetricResponse{}, dataExplorerQueryError(resp.StatusCode, responseBody)
+	}
+
+	var result dataExplorerResult
+	if err := json.Unmarshal(responseBody, &result); err != nil {
+		return AzureExternalMetricResponse{}, fmt.Errorf("unable to parse result of data explorer query: %v", err)
+	}
+	if len(result.Tables) == 0 {
+		return AzureExternalMetricResponse{}, fmt.Errorf("data explorer query returned no table")
+	}
+	table := result.Tables[0]
+	columns := []string{}
+	for _, c := range table.Columns {
+		columns = append(columns, c.ColumnName)
+	}
+	value, err := queryScalar("data explorer", columns, table.Rows, query.Column)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	glog.V(4).Infof("data explorer query of database %s returned %f", query.Database, value)
+	return AzureExternalMetricResponse{
+		Total: value,
+		Raw:   []string{string(responseBody)},
+	}, nil
+}
+
+// dataExplorerQueryError returns the reason of the error of a failed query
+func dataExplorerQueryError(statusCode int, body []byte) error {
+	var queryError dataExplorerError
+	if err := json.Unmarshal(body, &queryError); err != nil || queryError.Error.Message == "" {
+		return fmt.Errorf("data explorer query returned status %d: %s", statusCode, redact.String(string(body)))
+	}
+
+	message := queryError.Error.Message
+	if queryError.Error.Detail != "" {
+		message = queryError.Error.Detail
+	}
+	if statusCode == http.StatusBadRequest {
+		return InvalidMetricRequestError{err: fmt.Sprintf("data explorer query is invalid: %s", message)}
+	}
+	return fmt.Errorf("data explorer query returned status %d: %s: %s", statusCode, queryError.Error.Code, message)
+}
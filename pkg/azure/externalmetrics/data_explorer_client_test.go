@@ -0,0 +1,98 @@
+package externalmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+func TestDataExplorerReturnsScalarResult(t *testing.T) {
+	var query dataExplorerQuery
+	path := ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&query)
+		fmt.Fprint(w, `{"Tables":[{"TableName":"Table_0","Columns":[{"ColumnName":"pending","DataType":"Int64","ColumnType":"long"},{"ColumnName":"oldest","DataType":"DateTime","ColumnType":"datetime"}],"Rows":[[1250,"2019-03-04T11:00:00Z"]]},{"TableName":"Table_1","Columns":[],"Rows":[]}]}`)
+	}))
+	defer server.Close()
+
+	credentials := &resourceCredentialSource{}
+	client := &dataExplorerClient{credentials: credentials, client: server.Client()}
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type: DataExplorer,
+		DataExplorer: DataExplorerDefinition{
+			Cluster:  server.URL + "/",
+			Database: "Ingestion",
+			Query:    "Backlog | summarize pending = count(), oldest = min(EnqueuedAt)",
+			Column:   "pending",
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 1250 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 1250)
+	}
+	if path != "/v1/rest/query" || query.Database != "Ingestion" || !strings.HasPrefix(query.Query, "Backlog") {
+		t.Errorf("request = %v %+v, want query of the database", path, query)
+	}
+	if credentials.resource != server.URL {
+		t.Errorf("token resource = %v, want the cluster %v", credentials.resource, server.URL)
+	}
+}
+
+func TestDataExplorerSyntaxErrorIsInvalidRequest(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"code":"General_BadRequest","message":"Request is invalid and cannot be executed.","@type":"Kusto.Data.Exceptions.SyntaxException","@message":"Syntax error: Query could not be parsed at '|'"}}`)
+	}))
+	defer server.Close()
+
+	client := &dataExplorerClient{credentials: nullCredentialSource{}, client: server.Client()}
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		DataExplorer: DataExplorerDefinition{Cluster: server.URL, Database: "Ingestion", Query: "Backlog |"},
+	})
+
+	if !IsInvalidMetricRequestError(err) || !strings.Contains(err.Error(), "Syntax error") {
+		t.Errorf("error after processing got: %v, want InvalidMetricRequestError with the syntax error", err)
+	}
+}
+
+func TestDataExplorerInvalidRequestsGetError(t *testing.T) {
+	var tests = []DataExplorerDefinition{
+		{Cluster: "", Database: "Ingestion", Query: "Backlog | count"},
+		{Cluster: "http://ingest.westeurope.kusto.windows.net", Database: "Ingestion", Query: "Backlog | count"},
+		{Cluster: "https://ingest.westeurope.kusto.windows.net/Ingestion", Database: "Ingestion", Query: "Backlog | count"},
+		{Cluster: "https://ingest.westeurope.kusto.windows.net", Database: "", Query: "Backlog | count"},
+		{Cluster: "https://ingest.westeurope.kusto.windows.net", Database: "Ingestion/../other", Query: "Backlog | count"},
+		{Cluster: "https://ingest.westeurope.kusto.windows.net", Database: "Ingestion", Query: ""},
+	}
+
+	client := NewDataExplorerClient(fakeCredentialSource{})
+	for _, query := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{DataExplorer: query})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%+v) got %v, want InvalidMetricRequestError", query, err)
+		}
+	}
+}
+
+// resourceCredentialSource records the resource of the last token requested
+type resourceCredentialSource struct {
+	resource string
+}
+
+func (s *resourceCredentialSource) Authorizer(resource string) (autorest.Authorizer, error) {
+	s.resource = resource
+	return autorest.NullAuthorizer{}, nil
+}
+
+func (s *resourceCredentialSource) Value(name string) string {
+	return ""
+}
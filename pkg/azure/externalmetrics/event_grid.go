@@ -0,0 +1,41 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EventGridDefinition is how events pushed by Azure Event Grid to a metric of type eventgrid are
+// accepted and served.  Events are authenticated by the key in the secret, and the value of the
+// metric is read from the data of each event at the path of Value.
+type EventGridDefinition struct {
+	KeySecret string
+	KeyKey    string
+	// Value is the field of the data of an event holding the value, with nested fields separated
+	// by dots such as metrics.backlog. Defaults to value
+	Value string
+	// MaxAge is how long a pushed value is served, in the go duration format. Values are served
+	// until the next is pushed when empty
+	MaxAge string
+}
+
+// ValuePath returns the field names leading to the value in the data of an event
+func (d EventGridDefinition) ValuePath() []string {
+	if d.Value == "" {
+		return []string{"value"}
+	}
+	return strings.Split(d.Value, ".")
+}
+
+// ParseMaxAge returns how long a pushed value is served, 0 when it is served until the next is pushed
+func (d EventGridDefinition) ParseMaxAge() (time.Duration, error) {
+	if d.MaxAge == "" {
+		return 0, nil
+	}
+	maxAge, err := time.ParseDuration(d.MaxAge)
+	if err != nil || maxAge <= 0 {
+		return 0, InvalidMetricRequestError{err: fmt.Sprintf("invalid event grid max age '%s'", d.MaxAge)}
+	}
+	return maxAge, nil
+}
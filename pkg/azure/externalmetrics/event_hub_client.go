@@ -0,0 +1,296 @@
+package externalmetrics
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const (
+	eventHubsResource   = "https://eventhubs.azure.net/"
+	eventHubsAPIVersion = "2014-01"
+	// defaultConsumerGroup is the consumer group of event hubs unless a metric names another
+	defaultConsumerGroup = "$Default"
+	// maxPartitionsResponseSize limits how much of the partitions of an event hub, or of the list
+	// of their checkpoints, is read
+	maxPartitionsResponseSize = 1024 * 1024
+)
+
+var (
+	eventHubName      = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._-]*[a-zA-Z0-9])?$`)
+	consumerGroupName = regexp.MustCompile(`^\$?[a-zA-Z0-9]([a-zA-Z0-9._-]*[a-zA-Z0-9])?$`)
+)
+
+// EventHubDefinition names the event hub and consumer group whose lag is served, and the blob
+// container the consumers store their checkpoints in.  The event hub and the container are read
+// with the connection strings when they are set, which the provider resolves from the secrets, or
+// with the adapter's credentials otherwise.  When LagPerReplica is set the recommended number of
+// consumers is served instead of the lag.
+type EventHubDefinition struct {
+	Namespace              string
+	EventHub               string
+	ConsumerGroup          string
+	LagPerReplica          int64
+	ConnectionStringSecret string
+	ConnectionStringKey    string
+	ConnectionString       string
+
+	StorageAccount                string
+	Container                     string
+	StorageConnectionStringSecret string
+	StorageConnectionStringKey    string
+	StorageConnectionString       string
+}
+
+type eventHubClient struct {
+	credentials credentials.Source
+	client      *http.Client
+	now         func() time.Time
+	// namespaceURL returns the base url of the data plane of a namespace
+	namespaceURL func(namespace string) string
+	// blobURL returns the base url of the blob service of an account
+	blobURL          func(account string) string
+	serviceBusSuffix string
+	storageSuffix    string
+}
+
+// NewEventHubClient creates a client that serves the number of events of an event hub not yet
+// processed by a consumer group, from the end of each partition and the checkpoints of the
+// consumers in Blob Storage.  Namespaces are under the service bus endpoint suffix and storage
+// accounts under the storage endpoint suffix.
+func NewEventHubClient(credentialSource credentials.Source, serviceBusSuffix string, storageSuffix string) AzureExternalMetricClient {
+	return &eventHubClient{
+		credentials: credentialSource,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		now:         time.Now,
+		namespaceURL: func(namespace string) string {
+			return fmt.Sprintf("https://%s.%s", namespace, serviceBusSuffix)
+		},
+		blobURL: func(account string) string {
+			return fmt.Sprintf("https://%s.blob.%s", account, storageSuffix)
+		},
+		serviceBusSuffix: serviceBusSuffix,
+		storageSuffix:    storageSuffix,
+	}
+}
+
+// eventHubPartitions is the feed of the partitions of an event hub
+type eventHubPartitions struct {
+	XMLName    xml.Name
+	Partitions []struct {
+		ID          string `xml:"title"`
+		Description struct {
+			BeginSequenceNumber int64 `xml:"BeginSequenceNumber"`
+			EndSequenceNumber   int64 `xml:"EndSequenceNumber"`
+		} `xml:"content>PartitionDescription"`
+	} `xml:"entry"`
+}
+
+// checkpointBlobs is the list of the checkpoint blobs of a consumer group, one per partition
+type checkpointBlobs struct {
+	Blobs []struct {
+		Name     string `xml:"Name"`
+		Metadata struct {
+			SequenceNumber string `xml:"sequencenumber"`
+		} `xml:"Metadata"`
+	} `xml:"Blobs>Blob"`
+}
+
+func (c *eventHubClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	hub := azMetricRequest.EventHub
+	if hub.ConsumerGroup == "" {
+		hub.ConsumerGroup = defaultConsumerGroup
+	}
+
+	baseURL, fullyQualifiedNamespace := "", ""
+	var connection serviceBusConnectionString
+	if hub.ConnectionString != "" {
+		var err error
+		connection, err = parseServiceBusConnectionString(hub.ConnectionString)
+		if err != nil {
+			return AzureExternalMetricResponse{}, err
+		}
+		baseURL = connection.endpoint
+		fullyQualifiedNamespace = strings.TrimPrefix(connection.endpoint, "https://")
+		if hub.EventHub == "" {
+			hub.EventHub = connection.entityPath
+		}
+	} else {
+		if !serviceBusNamespaceName.MatchString(hub.Namespace) {
+			return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "event hubs namespace name is invalid"}
+		}
+		baseURL = c.namespaceURL(hub.Namespace)
+		fullyQualifiedNamespace = fmt.Sprintf("%s.%s", hub.Namespace, c.serviceBusSuffix)
+	}
+	if len(hub.EventHub) > 256 || !eventHubName.MatchString(hub.EventHub) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "event hub name is invalid"}
+	}
+	if len(hub.ConsumerGroup) > 50 || !consumerGroupName.MatchString(hub.ConsumerGroup) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "event hub consumer group name is invalid"}
+	}
+	if hub.LagPerReplica < 0 {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "event hub lag per replica must not be negative"}
+	}
+
+	var storage storageConnectionString
+	if hub.StorageConnectionString != "" {
+		var err error
+		storage, err = parseStorageConnectionString(hub.StorageConnectionString, c.storageSuffix, "blob")
+		if err != nil {
+			return AzureExternalMetricResponse{}, err
+		}
+		hub.StorageAccount = storage.account
+	}
+	if !storageAccountName.MatchString(hub.StorageAccount) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "checkpoint storage account name is invalid"}
+	}
+	// container names follow the same rules as queue names
+	if len(hub.Container) < 3 || len(hub.Container) > 63 || !storageQueueName.MatchString(hub.Container) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "checkpoint container name is invalid"}
+	}
+	blobURL := storage.endpoint
+	if blobURL == "" {
+		blobURL = c.blobURL(hub.StorageAccount)
+	}
+
+	partitions, partitionsBody, err := c.partitions(hub, connection, baseURL)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+	checkpoints, checkpointsBody, err := c.checkpoints(hub, storage, blobURL, fullyQualifiedNamespace)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	lag := int64(0)
+	for _, partition := range partitions.Partitions {
+		description := partition.Description
+		partitionLag := description.EndSequenceNumber - description.BeginSequenceNumber + 1
+		if checkpoint, found := checkpoints[partition.ID]; found {
+			partitionLag = description.EndSequenceNumber - checkpoint
+		}
+		if partitionLag > 0 {
+			lag += partitionLag
+		}
+	}
+
+	glog.V(4).Infof("event hub %s consumer group %s lags by %d events", hub.EventHub, hub.ConsumerGroup, lag)
+	if hub.LagPerReplica > 0 {
+		replicas := recommendedReplicas(lag, hub.LagPerReplica, int64(len(partitions.Partitions)))
+		glog.V(4).Infof("event hub %s consumer group %s recommends %d replicas", hub.EventHub, hub.ConsumerGroup, replicas)
+		return AzureExternalMetricResponse{
+			Total: float64(replicas),
+			Raw:   []string{partitionsBody, checkpointsBody},
+		}, nil
+	}
+	return AzureExternalMetricResponse{
+		Total: float64(lag),
+		Raw:   []string{partitionsBody, checkpointsBody},
+	}, nil
+}
+
+// recommendedReplicas returns the number of consumers needed to process the lag at lagPerReplica
+// events each.  A partition is processed by a single consumer of a consumer group at a time, so
+// consumers beyond the number of partitions would be idle and the recommendation is capped there.
+func recommendedReplicas(lag int64, lagPerReplica int64, partitions int64) int64 {
+	replicas := (lag + lagPerReplica - 1) / lagPerReplica
+	if replicas > partitions {
+		return partitions
+	}
+	return replicas
+}
+
+// partitions returns the partitions of the event hub, with the sequence numbers of the first and
+// last event of each
+func (c *eventHubClient) partitions(hub EventHubDefinition, connection serviceBusConnectionString, baseURL string) (eventHubPartitions, string, error) {
+	partitionsURL := fmt.Sprintf("%s/%s/consumergroups/%s/partitions", baseURL, hub.EventHub, url.PathEscape(hub.ConsumerGroup))
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s?api-version=%s", partitionsURL, eventHubsAPIVersion), nil)
+	if err != nil {
+		return eventHubPartitions{}, "", redact.Error(err)
+	}
+
+	if hub.ConnectionString != "" {
+		req.Header.Set("Authorization", connection.sharedAccessSignature(partitionsURL, c.now()))
+	} else {
+		authorizer, err := c.credentials.Authorizer(eventHubsResource)
+		if err != nil {
+			return eventHubPartitions{}, "", redact.Error(err)
+		}
+		if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+			return eventHubPartitions{}, "", redact.Error(err)
+		}
+	}
+
+	glog.V(2).Infof("requesting partitions of event hub %s from %s", hub.EventHub, baseURL)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return eventHubPartitions{}, "", redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxPartitionsResponseSize))
+	if err != nil {
+		return eventHubPartitions{}, "", fmt.Errorf("unable to read partitions of event hub %s: %v", hub.EventHub, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return eventHubPartitions{}, "", fmt.Errorf("partitions of event hub %s returned status %d: %s", hub.EventHub, resp.StatusCode, redact.String(string(body)))
+	}
+
+	var partitions eventHubPartitions
+	if err := xml.Unmarshal(body, &partitions); err != nil {
+		return eventHubPartitions{}, "", fmt.Errorf("unable to parse partitions of event hub %s: %v", hub.EventHub, err)
+	}
+	if partitions.XMLName.Local != "feed" || len(partitions.Partitions) == 0 {
+		return eventHubPartitions{}, "", fmt.Errorf("event hub %s or its consumer group %s not found", hub.EventHub, hub.ConsumerGroup)
+	}
+	return partitions, string(body), nil
+}
+
+// checkpoints returns the sequence number of the last event processed in each partition, by
+// partition id, as checkpointed by the event processors of the consumer group.  Event processors
+// store the checkpoint of a partition in the metadata of the blob
+// <namespace>/<event hub>/<consumer group>/checkpoint/<partition id>, all in lower case.
+func (c *eventHubClient) checkpoints(hub EventHubDefinition, storage storageConnectionString, blobURL string, fullyQualifiedNamespace string) (map[string]int64, string, error) {
+	prefix := strings.ToLower(fmt.Sprintf("%s/%s/%s/checkpoint/", fullyQualifiedNamespace, hub.EventHub, hub.ConsumerGroup))
+	listURL := fmt.Sprintf("%s/%s?restype=container&comp=list&include=metadata&prefix=%s", blobURL, hub.Container, url.QueryEscape(prefix))
+
+	glog.V(2).Infof("requesting checkpoints of event hub %s in container %s of storage account %s", hub.EventHub, hub.Container, hub.StorageAccount)
+	resp, body, err := storageGet(c.client, c.credentials, c.now(), storage, listURL, maxPartitionsResponseSize)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("checkpoints of event hub %s in container %s returned status %d: %s", hub.EventHub, hub.Container, resp.StatusCode, redact.String(string(body)))
+	}
+
+	var blobs checkpointBlobs
+	if err := xml.Unmarshal(body, &blobs); err != nil {
+		return nil, "", fmt.Errorf("unable to parse checkpoints of event hub %s: %v", hub.EventHub, err)
+	}
+
+	checkpoints := map[string]int64{}
+	for _, blob := range blobs.Blobs {
+		if blob.Metadata.SequenceNumber == "" {
+			continue
+		}
+		sequenceNumber, err := strconv.ParseInt(blob.Metadata.SequenceNumber, 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to parse checkpoint %s of event hub %s: %v", blob.Name, hub.EventHub, err)
+		}
+		checkpoints[path.Base(blob.Name)] = sequenceNumber
+	}
+	return checkpoints, string(body), nil
+}
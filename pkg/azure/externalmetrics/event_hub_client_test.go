@@ -0,0 +1,210 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testEventHubPartitions = `<feed xmlns="http://www.w3.org/2005/Atom"><title type="text">Partitions</title>` +
+	`<entry><title type="text">0</title><content type="application/xml"><PartitionDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><BeginSequenceNumber>0</BeginSequenceNumber><EndSequenceNumber>120</EndSequenceNumber></PartitionDescription></content></entry>` +
+	`<entry><title type="text">1</title><content type="application/xml"><PartitionDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><BeginSequenceNumber>10</BeginSequenceNumber><EndSequenceNumber>14</EndSequenceNumber></PartitionDescription></content></entry>` +
+	`<entry><title type="text">2</title><content type="application/xml"><PartitionDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect"><BeginSequenceNumber>0</BeginSequenceNumber><EndSequenceNumber>-1</EndSequenceNumber></PartitionDescription></content></entry>` +
+	`</feed>`
+
+const testCheckpointBlobs = `<?xml version="1.0" encoding="utf-8"?><EnumerationResults ServiceEndpoint="https://account.blob.core.windows.net/" ContainerName="checkpoints"><Blobs>` +
+	`<Blob><Name>orders-eh.servicebus.windows.net/orders/$default/checkpoint/0</Name><Metadata><sequencenumber>100</sequencenumber><offset>4096</offset></Metadata></Blob>` +
+	`</Blobs><NextMarker /></EnumerationResults>`
+
+func TestEventHubLagSumsPartitionsBehindCheckpoints(t *testing.T) {
+	partitionsQuery, checkpointsQuery := "", ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/checkpoints") {
+			checkpointsQuery = r.URL.Query().Get("prefix")
+			fmt.Fprint(w, testCheckpointBlobs)
+			return
+		}
+		partitionsQuery = r.URL.RequestURI()
+		fmt.Fprint(w, testEventHubPartitions)
+	}))
+	defer server.Close()
+
+	client := newTestEventHubClient(server)
+	metricResponse, err := client.GetAzureMetric(newEventHubMetricRequest())
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	// 20 events after the checkpoint of partition 0 and all 5 events of partition 1
+	if metricResponse.Total != 25 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 25)
+	}
+	if partitionsQuery != "/orders/consumergroups/$Default/partitions?api-version=2014-01" {
+		t.Errorf("partitions query = %v, want partitions of the consumer group", partitionsQuery)
+	}
+	if checkpointsQuery != "orders-eh.servicebus.windows.net/orders/$default/checkpoint/" {
+		t.Errorf("checkpoints prefix = %v, want checkpoints of the consumer group", checkpointsQuery)
+	}
+}
+
+func TestEventHubRecommendedReplicasCappedAtPartitions(t *testing.T) {
+	var tests = []struct {
+		lagPerReplica int64
+		want          float64
+	}{
+		// 25 events behind the checkpoints of the 3 partitions
+		{10, 3},
+		{1, 3},
+		{13, 2},
+		{25, 1},
+	}
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/checkpoints") {
+			fmt.Fprint(w, testCheckpointBlobs)
+			return
+		}
+		fmt.Fprint(w, testEventHubPartitions)
+	}))
+	defer server.Close()
+
+	client := newTestEventHubClient(server)
+	for _, tt := range tests {
+		request := newEventHubMetricRequest()
+		request.EventHub.LagPerReplica = tt.lagPerReplica
+		metricResponse, err := client.GetAzureMetric(request)
+
+		if err != nil {
+			t.Fatalf("%d per replica: error after processing got: %v, want nil", tt.lagPerReplica, err)
+		}
+		if metricResponse.Total != tt.want {
+			t.Errorf("%d per replica: metricResponse.Total = %v, want = %v", tt.lagPerReplica, metricResponse.Total, tt.want)
+		}
+	}
+}
+
+func TestEventHubCaughtUpReturnsZero(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/checkpoints") {
+			fmt.Fprint(w, strings.Replace(testCheckpointBlobs, "<sequencenumber>100</sequencenumber>", "<sequencenumber>120</sequencenumber>", 1))
+			return
+		}
+		fmt.Fprint(w, `<feed xmlns="http://www.w3.org/2005/Atom"><entry><title type="text">0</title><content type="application/xml"><PartitionDescription><BeginSequenceNumber>0</BeginSequenceNumber><EndSequenceNumber>120</EndSequenceNumber></PartitionDescription></content></entry></feed>`)
+	}))
+	defer server.Close()
+
+	client := newTestEventHubClient(server)
+	metricResponse, err := client.GetAzureMetric(newEventHubMetricRequest())
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 0 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 0)
+	}
+}
+
+func TestEventHubSignedWithConnectionStrings(t *testing.T) {
+	eventHubAuthorization, storageAuthorization := "", ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/checkpoints") {
+			storageAuthorization = r.Header.Get("Authorization")
+			fmt.Fprint(w, testCheckpointBlobs)
+			return
+		}
+		eventHubAuthorization = r.Header.Get("Authorization")
+		fmt.Fprint(w, testEventHubPartitions)
+	}))
+	defer server.Close()
+
+	client := newTestEventHubClient(server)
+	endpoint := strings.Replace(server.URL, "https://", "sb://", 1)
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type: EventHub,
+		EventHub: EventHubDefinition{
+			ConnectionString:        fmt.Sprintf("Endpoint=%s/;SharedAccessKeyName=manage;SharedAccessKey=c2VjcmV0;EntityPath=orders", endpoint),
+			Container:               "checkpoints",
+			StorageConnectionString: fmt.Sprintf("AccountName=account;AccountKey=c2VjcmV0;BlobEndpoint=%s", server.URL),
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if !strings.HasPrefix(eventHubAuthorization, "SharedAccessSignature sr=") {
+		t.Errorf("event hub authorization = %v, want shared access signature of the manage key", eventHubAuthorization)
+	}
+	if !strings.HasPrefix(storageAuthorization, "SharedKey account:") {
+		t.Errorf("storage authorization = %v, want shared key of the account", storageAuthorization)
+	}
+}
+
+func TestEventHubNotFoundGetError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/checkpoints") {
+			fmt.Fprint(w, testCheckpointBlobs)
+			return
+		}
+		fmt.Fprint(w, `<feed xmlns="http://www.w3.org/2005/Atom"><title type="text">Publicly Listed Services</title></feed>`)
+	}))
+	defer server.Close()
+
+	client := newTestEventHubClient(server)
+	_, err := client.GetAzureMetric(newEventHubMetricRequest())
+
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("error after processing got: %v, want event hub not found", err)
+	}
+}
+
+func TestEventHubInvalidRequestsGetError(t *testing.T) {
+	var tests = []EventHubDefinition{
+		{Namespace: "", EventHub: "orders", StorageAccount: "account", Container: "checkpoints"},
+		{Namespace: "orders-eh", EventHub: "", StorageAccount: "account", Container: "checkpoints"},
+		{Namespace: "orders-eh", EventHub: "orders/../other", StorageAccount: "account", Container: "checkpoints"},
+		{Namespace: "orders-eh", EventHub: "orders", ConsumerGroup: "a/b", StorageAccount: "account", Container: "checkpoints"},
+		{Namespace: "orders-eh", EventHub: "orders", LagPerReplica: -1, StorageAccount: "account", Container: "checkpoints"},
+		{Namespace: "orders-eh", EventHub: "orders", StorageAccount: "Account", Container: "checkpoints"},
+		{Namespace: "orders-eh", EventHub: "orders", StorageAccount: "account", Container: "ch"},
+		{Namespace: "orders-eh", EventHub: "orders", StorageConnectionString: "AccountName=account", Container: "checkpoints"},
+	}
+
+	client := NewEventHubClient(fakeCredentialSource{}, "servicebus.windows.net", "core.windows.net")
+	for _, hub := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{EventHub: hub})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%+v) got %v, want InvalidMetricRequestError", hub, err)
+		}
+	}
+}
+
+func newEventHubMetricRequest() AzureExternalMetricRequest {
+	return AzureExternalMetricRequest{
+		Type: EventHub,
+		EventHub: EventHubDefinition{
+			Namespace:      "orders-eh",
+			EventHub:       "orders",
+			StorageAccount: "account",
+			Container:      "checkpoints",
+		},
+	}
+}
+
+func newTestEventHubClient(server *httptest.Server) *eventHubClient {
+	return &eventHubClient{
+		credentials: nullCredentialSource{},
+		client:      server.Client(),
+		now:         func() time.Time { return testQueueNow },
+		namespaceURL: func(namespace string) string {
+			return server.URL
+		},
+		blobURL: func(account string) string {
+			return server.URL
+		},
+		serviceBusSuffix: "servicebus.windows.net",
+		storageSuffix:    "core.windows.net",
+	}
+}
@@ -1,27 +1,183 @@
 package externalmetrics
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/plugin"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+)
 
 type AzureClientFactory interface {
 	GetAzureExternalMetricClient(clientType string) (AzureExternalMetricClient, error)
 }
 
+// CredentialFactory creates the clients of metrics that reference a named credential
+type CredentialFactory interface {
+	// WithCredentials returns a factory whose clients authenticate with the credential source and
+	// call the endpoints of the cloud of the credential
+	WithCredentials(source credentials.Source, cloud string) (AzureClientFactory, error)
+	// CredentialSource returns the source the clients authenticate with
+	CredentialSource() credentials.Source
+}
+
 type AzureExternalMetricClientFactory struct {
 	DefaultSubscriptionID string
+	Credentials           credentials.Source
+	Plugins               *plugin.Registry
+	Webhooks              WebhookOptions
+	MonitorEndpoints      *MonitorEndpoints
+	// MonitorAPIVersion is the Azure Monitor API version queried unless a metric sets its own
+	MonitorAPIVersion string
+	// Endpoints of the Azure services called by the clients
+	Endpoints credentials.Endpoints
+	// EndpointOverrides are the endpoints set explicitly, which are kept when the clients call the
+	// endpoints of the cloud of a credential
+	EndpointOverrides credentials.Endpoints
+	// ARMQuota tracks the quota remaining to the subscriptions Azure Monitor is queried in
+	ARMQuota *ARMQuota
+	// CostCache keeps the costs queried from Cost Management by the adapter's credentials
+	CostCache *CostCache
+}
+
+// WithCredentials returns a copy of the factory whose clients authenticate with the source.  When
+// the credential is of another cloud than the adapter its clients call the endpoints of that cloud.
+func (f AzureExternalMetricClientFactory) WithCredentials(source credentials.Source, cloud string) (AzureClientFactory, error) {
+	f.Credentials = source
+	// costs are only cached for the adapter's credentials, so a cost is never served to a metric
+	// whose credential isn't allowed to read it
+	f.CostCache = nil
+	if cloud == "" {
+		return f, nil
+	}
+
+	environment, err := credentials.CloudEnvironment(cloud)
+	if err != nil {
+		return nil, err
+	}
+	adapterEnvironment, err := credentials.Environment()
+	if err != nil {
+		return nil, err
+	}
+	if environment.Name == adapterEnvironment.Name {
+		return f, nil
+	}
+
+	f.Endpoints, err = f.EndpointOverrides.ResolveCloud(cloud)
+	if err != nil {
+		return nil, err
+	}
+	f.MonitorEndpoints = NewMonitorEndpoints([]string{f.Endpoints.ResourceManager}, 0).TrackQuota(f.ARMQuota)
+	return f, nil
+}
+
+// CredentialSource returns the source the clients of the factory authenticate with
+func (f AzureExternalMetricClientFactory) CredentialSource() credentials.Source {
+	return f.Credentials
 }
 
 func (f AzureExternalMetricClientFactory) GetAzureExternalMetricClient(clientType string) (client AzureExternalMetricClient, err error) {
 	switch clientType {
 	case Monitor:
-		client = NewMonitorClient(f.DefaultSubscriptionID)
+		client = NewMonitorClient(f.DefaultSubscriptionID, f.Credentials, f.MonitorEndpoints, f.MonitorAPIVersion)
 		break
 	case ServiceBusSubscription:
-		client = NewServiceBusSubscriptionClient(f.DefaultSubscriptionID)
+		client = NewServiceBusSubscriptionClient(f.DefaultSubscriptionID, f.Credentials, f.Endpoints.ResourceManager)
+		break
+	case ServiceBusQueue:
+		client = NewServiceBusQueueClient(f.Credentials, f.Endpoints.ServiceBusSuffix)
+		break
+	case Schedule:
+		client = NewScheduleClient()
+		break
+	case Heartbeat:
+		client = NewHeartbeatClient()
+		break
+	case Predictive:
+		client = NewPredictiveClient(f.DefaultSubscriptionID, f.Credentials, f.MonitorEndpoints, f.MonitorAPIVersion)
+		break
+	case SLOBurnRate:
+		client = NewSLOBurnRateClient(f.Credentials, f.Endpoints.AppInsights, f.Endpoints.AppInsightsResource)
+		break
+	case Plugin:
+		client = NewPluginClient(f.Plugins)
+		break
+	case Webhook:
+		client = NewWebhookClient(f.Credentials, f.Webhooks)
+		break
+	case StorageQueueMessageAge:
+		client = NewStorageQueueClient(f.Credentials, f.Endpoints.StorageSuffix)
+		break
+	case StorageQueueLength:
+		client = NewStorageQueueLengthClient(f.Credentials, f.Endpoints.StorageSuffix)
+		break
+	case EventHub:
+		client = NewEventHubClient(f.Credentials, f.Endpoints.ServiceBusSuffix, f.Endpoints.StorageSuffix)
+		break
+	case FileShare:
+		client = NewFileShareClient(f.DefaultSubscriptionID, f.Credentials, f.MonitorEndpoints, f.MonitorAPIVersion)
+		break
+	case ActivityLog:
+		client = NewActivityLogClient(f.DefaultSubscriptionID, f.Credentials, f.Endpoints.ResourceManager)
+		break
+	case ContainerApp:
+		client = NewContainerAppClient(f.DefaultSubscriptionID, f.Credentials, f.MonitorEndpoints, f.MonitorAPIVersion)
+		break
+	case LogicApp:
+		client = NewLogicAppClient(f.DefaultSubscriptionID, f.Credentials, f.MonitorEndpoints, f.MonitorAPIVersion)
+		break
+	case LogAnalytics:
+		client = NewLogAnalyticsClient(f.Credentials, f.Endpoints.LogAnalytics, f.Endpoints.LogAnalyticsResource)
+		break
+	case DataExplorer:
+		client = NewDataExplorerClient(f.Credentials)
+		break
+	case CosmosDB:
+		client = NewCosmosDBClient(f.DefaultSubscriptionID, f.Credentials, f.MonitorEndpoints, f.MonitorAPIVersion, f.Endpoints.CosmosDBSuffix)
+		break
+	case Prometheus:
+		client = NewPrometheusClient(f.Credentials, f.Endpoints.PrometheusResource)
+		break
+	case IoTHub:
+		client = NewIoTHubClient(f.Credentials, f.Endpoints.ServiceBusSuffix, f.Endpoints.StorageSuffix)
+		break
+	case Batch:
+		client = NewBatchClient(f.Credentials, f.Endpoints.BatchResource)
+		break
+	case BlobCount:
+		client = NewBlobCountClient(f.Credentials, f.Endpoints.StorageSuffix)
+		break
+	case Redis:
+		client = NewRedisClient(f.Credentials)
+		break
+	case AzureDevOps:
+		client = NewAzureDevOpsClient(f.Credentials)
+		break
+	case Cost:
+		client = NewCostClient(f.DefaultSubscriptionID, f.Credentials, f.Endpoints.ResourceManager, f.CostCache)
+		break
+	case Quota:
+		client = NewQuotaClient(f.DefaultSubscriptionID, f.Credentials, f.Endpoints.ResourceManager)
 		break
 	default:
 		err = fmt.Errorf("Unknown Azure external metric client type provided: %s", clientType)
 		break
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	return redactingClient{client: client}, nil
+}
+
+// redactingClient removes credentials from the errors of a client, as the errors of Azure and of
+// the http clients can echo the keys, connection strings and tokens of the request.  Every error is
+// redacted here before it is logged or returned by the adapter.
+type redactingClient struct {
+	client AzureExternalMetricClient
+}
 
-	return client, err
+func (c redactingClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	metricValue, err := c.client.GetAzureMetric(azMetricRequest)
+	return metricValue, redact.Error(err)
 }
@@ -0,0 +1,65 @@
+package externalmetrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+)
+
+type failingClient struct {
+	err error
+}
+
+func (c failingClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	return AzureExternalMetricResponse{}, c.err
+}
+
+func TestFactoryClientsRedactErrors(t *testing.T) {
+	factory := AzureExternalMetricClientFactory{}
+	client, err := factory.GetAzureExternalMetricClient(Schedule)
+	if err != nil {
+		t.Fatalf("unable to create client: %v", err)
+	}
+	if _, ok := client.(redactingClient); !ok {
+		t.Errorf("client = %T, want a redacting client", client)
+	}
+
+	client = redactingClient{client: failingClient{err: errors.New("unable to read queue: DefaultEndpointsProtocol=https;AccountName=acct;AccountKey=c2VjcmV0a2V5")}}
+	_, err = client.GetAzureMetric(AzureExternalMetricRequest{})
+	if err == nil || strings.Contains(err.Error(), "c2VjcmV0a2V5") {
+		t.Errorf("error = %v, want the account key redacted", err)
+	}
+}
+
+func TestFactoryWithCredentialsOfAnotherCloudCallsItsEndpoints(t *testing.T) {
+	overrides := credentials.Endpoints{ServiceBusSuffix: "servicebus.private.test"}
+	endpoints, err := overrides.Resolve()
+	if err != nil {
+		t.Fatalf("unable to resolve endpoints: %v", err)
+	}
+	factory := AzureExternalMetricClientFactory{Endpoints: endpoints, EndpointOverrides: overrides}
+
+	credentialFactory, err := factory.WithCredentials(nullCredentialSource{}, "AzureChinaCloud")
+	if err != nil {
+		t.Fatalf("WithCredentials() error = %v, want nil", err)
+	}
+
+	// every endpoint is of the cloud of the credential, except the ones set explicitly
+	want := credentials.Endpoints{
+		ResourceManager:      "https://management.chinacloudapi.cn",
+		AppInsights:          "https://api.applicationinsights.azure.cn",
+		LogAnalytics:         "https://api.loganalytics.azure.cn",
+		StorageSuffix:        "core.chinacloudapi.cn",
+		ServiceBusSuffix:     "servicebus.private.test",
+		CosmosDBSuffix:       "documents.azure.cn",
+		AppInsightsResource:  "https://api.applicationinsights.azure.cn",
+		LogAnalyticsResource: "https://api.loganalytics.azure.cn",
+		PrometheusResource:   "https://prometheus.monitor.azure.cn",
+		BatchResource:        "https://batch.chinacloudapi.cn/",
+	}
+	if got := credentialFactory.(AzureExternalMetricClientFactory).Endpoints; got != want {
+		t.Errorf("Endpoints = %+v, want %+v", got, want)
+	}
+}
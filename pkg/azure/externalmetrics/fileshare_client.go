@@ -0,0 +1,102 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/golang/glog"
+)
+
+// fileShareDimension splits Azure Files metrics by share
+const fileShareDimension = "FileShare"
+
+// FileShareDefinition names an Azure Files share and the metric of it to serve.  Without a
+// share the metric is served for each share of the account.
+type FileShareDefinition struct {
+	Account string
+	Share   string
+	Metric  string
+}
+
+// fileShareMetric is the Azure Monitor metric of the file service served for a file share metric
+type fileShareMetric struct {
+	metricName  string
+	aggregation string
+}
+
+var fileShareMetrics = map[string]fileShareMetric{
+	"transactions":  {metricName: "Transactions", aggregation: "Total"},
+	"egress":        {metricName: "Egress", aggregation: "Total"},
+	"snapshotcount": {metricName: "FileShareSnapshotCount", aggregation: "Average"},
+	"quota":         {metricName: "FileShareCapacityQuota", aggregation: "Average"},
+	"capacity":      {metricName: "FileCapacity", aggregation: "Average"},
+}
+
+type fileShareClient struct {
+	monitor AzureExternalMetricClient
+}
+
+// NewFileShareClient creates a client that serves Azure Files metrics of a share from Azure Monitor
+func NewFileShareClient(defaultsubscriptionID string, credentialSource credentials.Source, endpoints *MonitorEndpoints, apiVersion string) AzureExternalMetricClient {
+	return &fileShareClient{
+		monitor: NewMonitorClient(defaultsubscriptionID, credentialSource, endpoints, apiVersion),
+	}
+}
+
+func (c *fileShareClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	monitorRequest, err := fileShareRequest(azMetricRequest)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	glog.V(2).Infof("requesting %s of file shares in storage account %s", monitorRequest.MetricName, azMetricRequest.FileShare.Account)
+	return c.monitor.GetAzureMetric(monitorRequest)
+}
+
+// fileShareRequest converts a file share metric to the Azure Monitor query of the file service of the account
+func fileShareRequest(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricRequest, error) {
+	fileShare := azMetricRequest.FileShare
+	metric, ok := fileShareMetrics[strings.ToLower(fileShare.Metric)]
+	if !ok {
+		return AzureExternalMetricRequest{}, InvalidMetricRequestError{err: fmt.Sprintf("file share metric must be one of %s", strings.Join(fileShareMetricNames(), ", "))}
+	}
+	if !storageAccountName.MatchString(fileShare.Account) {
+		return AzureExternalMetricRequest{}, InvalidMetricRequestError{err: "storage account name is invalid"}
+	}
+	if strings.ContainsAny(fileShare.Share, "'/") {
+		return AzureExternalMetricRequest{}, InvalidMetricRequestError{err: "file share name is invalid"}
+	}
+
+	monitorRequest := azMetricRequest
+	monitorRequest.Type = Monitor
+	monitorRequest.ResourceProviderNamespace = "Microsoft.Storage"
+	monitorRequest.ResourceType = "storageAccounts"
+	monitorRequest.ResourceName = fmt.Sprintf("%s/fileServices/default", fileShare.Account)
+	monitorRequest.MetricName = metric.metricName
+	if monitorRequest.Aggregation == "" {
+		monitorRequest.Aggregation = metric.aggregation
+	}
+
+	if fileShare.Share == "" {
+		monitorRequest.SplitDimension = fileShareDimension
+		return monitorRequest, nil
+	}
+
+	filter := fmt.Sprintf("%s eq '%s'", fileShareDimension, fileShare.Share)
+	if monitorRequest.Filter != "" {
+		filter = fmt.Sprintf("%s and %s", monitorRequest.Filter, filter)
+	}
+	monitorRequest.Filter = filter
+	return monitorRequest, nil
+}
+
+func fileShareMetricNames() []string {
+	names := []string{}
+	for name := range fileShareMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,82 @@
+package externalmetrics
+
+import (
+	"testing"
+)
+
+func TestFileShareQueriesShareOfFileService(t *testing.T) {
+	monitorClient := &recordingMonitorClient{result: makeAzureMonitorResponse(120)}
+	monitor := newMonitorClient("", monitorClient)
+	client := fileShareClient{monitor: &monitor}
+
+	request := newFileShareMetricRequest()
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if metricResponse.Total != 120 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 120)
+	}
+
+	wantURI := "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/account/fileServices/default"
+	if monitorClient.resourceURI != wantURI {
+		t.Errorf("resourceURI = %v, want = %v", monitorClient.resourceURI, wantURI)
+	}
+	if monitorClient.metricnames != "Transactions" || monitorClient.aggregation != "Total" {
+		t.Errorf("metric = %v %v, want = Transactions Total", monitorClient.metricnames, monitorClient.aggregation)
+	}
+	if monitorClient.filter != "FileShare eq 'data'" {
+		t.Errorf("filter = %v, want = %v", monitorClient.filter, "FileShare eq 'data'")
+	}
+}
+
+func TestFileShareWithoutShareSplitsByShare(t *testing.T) {
+	request := newFileShareMetricRequest()
+	request.FileShare.Share = ""
+	request.FileShare.Metric = "Capacity"
+
+	monitorRequest, err := fileShareRequest(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if monitorRequest.SplitDimension != fileShareDimension || monitorRequest.Filter != "" {
+		t.Errorf("monitorRequest = %+v, want split by %s", monitorRequest, fileShareDimension)
+	}
+	if monitorRequest.MetricName != "FileCapacity" || monitorRequest.Aggregation != "Average" {
+		t.Errorf("metric = %v %v, want = FileCapacity Average", monitorRequest.MetricName, monitorRequest.Aggregation)
+	}
+}
+
+func TestFileShareInvalidRequestGetError(t *testing.T) {
+	var tests = []FileShareDefinition{
+		{Account: "account", Share: "data", Metric: "iops"},
+		{Account: "", Share: "data", Metric: "egress"},
+		{Account: "account", Share: "data' or FileShare eq 'other", Metric: "egress"},
+	}
+
+	for _, fileShare := range tests {
+		request := newFileShareMetricRequest()
+		request.FileShare = fileShare
+		if _, err := fileShareRequest(request); !IsInvalidMetricRequestError(err) {
+			t.Errorf("fileShareRequest(%+v) got %v, want InvalidMetricRequestError", fileShare, err)
+		}
+	}
+}
+
+func newFileShareMetricRequest() AzureExternalMetricRequest {
+	return AzureExternalMetricRequest{
+		Type:           FileShare,
+		SubscriptionID: "1234",
+		ResourceGroup:  "rg",
+		Timespan:       "PT10",
+		FileShare: FileShareDefinition{
+			Account: "account",
+			Share:   "data",
+			Metric:  "transactions",
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// HeartbeatDefinition describes a metric whose value is constant, or ramps from Value to RampTo
+// and back when RampPeriod is set
+type HeartbeatDefinition struct {
+	Value      float64
+	RampTo     float64
+	RampPeriod string
+}
+
+type heartbeatClient struct {
+	now func() time.Time
+}
+
+// NewHeartbeatClient creates a client that serves heartbeat metrics. No calls are made to Azure.
+func NewHeartbeatClient() AzureExternalMetricClient {
+	return &heartbeatClient{now: time.Now}
+}
+
+func (c *heartbeatClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	value, err := azMetricRequest.Heartbeat.ValueAt(c.now())
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	glog.V(4).Infof("heartbeat metric %s value: %f", azMetricRequest.MetricName, value)
+	return AzureExternalMetricResponse{
+		Total: value,
+	}, nil
+}
+
+// ValueAt returns the value of the heartbeat at t.  The ramp is measured from the unix epoch so
+// every replica of the adapter serves the same value.
+func (h HeartbeatDefinition) ValueAt(t time.Time) (float64, error) {
+	if h.RampPeriod == "" {
+		return h.Value, nil
+	}
+
+	period, err := time.ParseDuration(h.RampPeriod)
+	if err != nil || period <= 0 {
+		return 0, InvalidMetricRequestError{err: fmt.Sprintf("invalid heartbeat ramp period '%s', must be a positive go duration such as 30m", h.RampPeriod)}
+	}
+
+	elapsed := time.Duration(t.UnixNano() % int64(2*period))
+	progress := float64(elapsed) / float64(period)
+	if progress > 1 {
+		// ramping back
+		progress = 2 - progress
+	}
+	return h.Value + (h.RampTo-h.Value)*progress, nil
+}
@@ -0,0 +1,75 @@
+package externalmetrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHeartbeatValueAt(t *testing.T) {
+	ramp := HeartbeatDefinition{Value: 2, RampTo: 10, RampPeriod: "1h"}
+
+	tests := []struct {
+		name      string
+		heartbeat HeartbeatDefinition
+		time      string
+		want      float64
+	}{
+		{
+			name:      "constant",
+			heartbeat: HeartbeatDefinition{Value: 5},
+			time:      "2019-03-04T10:30:00Z",
+			want:      5,
+		},
+		{
+			name:      "start of ramp",
+			heartbeat: ramp,
+			time:      "2019-03-04T10:00:00Z",
+			want:      2,
+		},
+		{
+			name:      "ramping up",
+			heartbeat: ramp,
+			time:      "2019-03-04T10:15:00Z",
+			want:      4,
+		},
+		{
+			name:      "top of ramp",
+			heartbeat: ramp,
+			time:      "2019-03-04T11:00:00Z",
+			want:      10,
+		},
+		{
+			name:      "ramping back",
+			heartbeat: ramp,
+			time:      "2019-03-04T11:45:00Z",
+			want:      4,
+		},
+		{
+			name:      "ramping down",
+			heartbeat: HeartbeatDefinition{Value: 10, RampTo: 0, RampPeriod: "1h"},
+			time:      "2019-03-04T10:30:00Z",
+			want:      5,
+		},
+	}
+
+	for _, tt := range tests {
+		at, _ := time.Parse(time.RFC3339, tt.time)
+		got, err := tt.heartbeat.ValueAt(at)
+		if err != nil {
+			t.Errorf("%s: error = %v, want nil", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: value = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHeartbeatInvalidRampPeriod(t *testing.T) {
+	for _, period := range []string{"soon", "0s", "-5m"} {
+		heartbeat := HeartbeatDefinition{Value: 1, RampTo: 2, RampPeriod: period}
+		if _, err := heartbeat.ValueAt(time.Now()); !IsInvalidMetricRequestError(err) {
+			t.Errorf("period %s: error = %v, want an invalid metric request error", period, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package externalmetrics
+
+import (
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/golang/glog"
+)
+
+// IoTHubDefinition names the consumer group of the built-in endpoint of an IoT hub whose backlog of
+// device-to-cloud messages is served, and the blob container the consumers store their checkpoints
+// in.  The built-in endpoint is read with its Event Hub-compatible connection string, which the
+// provider resolves from the secret, as the endpoint doesn't accept the adapter's credentials.
+type IoTHubDefinition struct {
+	ConsumerGroup          string
+	ConnectionStringSecret string
+	ConnectionStringKey    string
+	ConnectionString       string
+
+	StorageAccount                string
+	Container                     string
+	StorageConnectionStringSecret string
+	StorageConnectionStringKey    string
+	StorageConnectionString       string
+}
+
+type iotHubClient struct {
+	eventHub AzureExternalMetricClient
+}
+
+// NewIoTHubClient creates a client that serves the number of device-to-cloud messages of an IoT
+// hub not yet processed by a consumer group of its built-in endpoint, which is an event hub whose
+// lag is served as an eventhub metric's is
+func NewIoTHubClient(credentialSource credentials.Source, serviceBusSuffix string, storageSuffix string) AzureExternalMetricClient {
+	return &iotHubClient{
+		eventHub: NewEventHubClient(credentialSource, serviceBusSuffix, storageSuffix),
+	}
+}
+
+func (c *iotHubClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	eventHubRequest, err := iotHubRequest(azMetricRequest)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	glog.V(2).Infof("requesting backlog of iot hub built-in endpoint %s", eventHubRequest.EventHub.EventHub)
+	return c.eventHub.GetAzureMetric(eventHubRequest)
+}
+
+// iotHubRequest converts the request to the lag of the event hub of the built-in endpoint, named
+// by the EntityPath of its connection string
+func iotHubRequest(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricRequest, error) {
+	hub := azMetricRequest.IoTHub
+	if hub.ConnectionString == "" {
+		return AzureExternalMetricRequest{}, InvalidMetricRequestError{err: "iot hub metrics require the event hub-compatible connection string of the built-in endpoint"}
+	}
+	connection, err := parseServiceBusConnectionString(hub.ConnectionString)
+	if err != nil {
+		return AzureExternalMetricRequest{}, err
+	}
+	if connection.entityPath == "" {
+		return AzureExternalMetricRequest{}, InvalidMetricRequestError{err: "iot hub connection string must be the event hub-compatible connection string of the built-in endpoint, with an EntityPath"}
+	}
+
+	eventHubRequest := azMetricRequest
+	eventHubRequest.Type = EventHub
+	eventHubRequest.EventHub = EventHubDefinition{
+		EventHub:                connection.entityPath,
+		ConsumerGroup:           hub.ConsumerGroup,
+		ConnectionString:        hub.ConnectionString,
+		StorageAccount:          hub.StorageAccount,
+		Container:               hub.Container,
+		StorageConnectionString: hub.StorageConnectionString,
+	}
+	return eventHubRequest, nil
+}
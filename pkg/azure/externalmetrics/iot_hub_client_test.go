@@ -0,0 +1,65 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIoTHubBacklogOfBuiltInEndpoint(t *testing.T) {
+	partitionsQuery, checkpointsQuery := "", ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/checkpoints") {
+			checkpointsQuery = r.URL.Query().Get("prefix")
+			fmt.Fprint(w, testCheckpointBlobs)
+			return
+		}
+		partitionsQuery = r.URL.RequestURI()
+		fmt.Fprint(w, testEventHubPartitions)
+	}))
+	defer server.Close()
+
+	client := &iotHubClient{eventHub: newTestEventHubClient(server)}
+	endpoint := strings.Replace(server.URL, "https://", "sb://", 1)
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type: IoTHub,
+		IoTHub: IoTHubDefinition{
+			ConsumerGroup:           "telemetry",
+			ConnectionString:        fmt.Sprintf("Endpoint=%s/;SharedAccessKeyName=service;SharedAccessKey=c2VjcmV0;EntityPath=devices-hub", endpoint),
+			Container:               "checkpoints",
+			StorageConnectionString: fmt.Sprintf("AccountName=account;AccountKey=c2VjcmV0;BlobEndpoint=%s", server.URL),
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	// 20 events after the checkpoint of partition 0 and all 5 events of partition 1
+	if metricResponse.Total != 25 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 25)
+	}
+	if partitionsQuery != "/devices-hub/consumergroups/telemetry/partitions?api-version=2014-01" {
+		t.Errorf("partitions query = %v, want partitions of the consumer group of the built-in endpoint", partitionsQuery)
+	}
+	if !strings.HasSuffix(checkpointsQuery, "/devices-hub/telemetry/checkpoint/") {
+		t.Errorf("checkpoints prefix = %v, want checkpoints of the consumer group", checkpointsQuery)
+	}
+}
+
+func TestIoTHubInvalidRequestsGetError(t *testing.T) {
+	var tests = []IoTHubDefinition{
+		{Container: "checkpoints", StorageAccount: "account"},
+		{ConnectionString: "HostName=devices-hub.azure-devices.net;SharedAccessKeyName=service;SharedAccessKey=c2VjcmV0", Container: "checkpoints", StorageAccount: "account"},
+		{ConnectionString: "Endpoint=sb://ihsuprodamres001dednamespace.servicebus.windows.net/;SharedAccessKeyName=service;SharedAccessKey=c2VjcmV0", Container: "checkpoints", StorageAccount: "account"},
+	}
+
+	client := NewIoTHubClient(fakeCredentialSource{}, "servicebus.windows.net", "core.windows.net")
+	for _, hub := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{IoTHub: hub})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%+v) got %v, want InvalidMetricRequestError", hub, err)
+		}
+	}
+}
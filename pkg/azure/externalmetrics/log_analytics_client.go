@@ -0,0 +1,236 @@
+package externalmetrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const (
+	// maxQueryResponseSize limits how much of a query result is read.  A scalar result is a few
+	// hundred bytes, larger results are an error anyway.
+	maxQueryResponseSize = 1024 * 1024
+)
+
+var logAnalyticsWorkspaceID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// LogAnalyticsDefinition is a KQL query of a Log Analytics workspace whose result is a single
+// number.  The timespan uses the go duration format and is parsed when the metric is requested.
+type LogAnalyticsDefinition struct {
+	// Workspace is the workspace id, the customer id of the workspace rather than its resource id
+	Workspace string
+	Query     string
+	// Timespan limits the query to the records of the last timespan, in whole minutes
+	Timespan string
+	// Column is the column of the result served, the first column unless set
+	Column string
+}
+
+type logAnalyticsClient struct {
+	credentials credentials.Source
+	client      *http.Client
+	endpoint    string
+	resource    string
+}
+
+// NewLogAnalyticsClient creates a client that serves the result of KQL queries of Log Analytics
+// workspaces from the Log Analytics api endpoint, with tokens for the resource of the api
+func NewLogAnalyticsClient(credentialSource credentials.Source, logAnalyticsEndpoint string, logAnalyticsResource string) AzureExternalMetricClient {
+	return &logAnalyticsClient{
+		credentials: credentialSource,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		endpoint:    logAnalyticsEndpoint,
+		resource:    logAnalyticsResource,
+	}
+}
+
+// logAnalyticsQuery is the body of a query request
+type logAnalyticsQuery struct {
+	Query    string `json:"query"`
+	Timespan string `json:"timespan,omitempty"`
+}
+
+// logAnalyticsResult is the result of a query, whose first table is the result of its last statement
+type logAnalyticsResult struct {
+	Tables []struct {
+		Columns []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"columns"`
+		Rows [][]interface{} `json:"rows"`
+	} `json:"tables"`
+}
+
+// logAnalyticsError is the error returned by the api for a query that fails
+type logAnalyticsError struct {
+	Error struct {
+		Message    string `json:"message"`
+		Code       string `json:"code"`
+		InnerError struct {
+			Message string `json:"message"`
+		} `json:"innererror"`
+	} `json:"error"`
+}
+
+func (c *logAnalyticsClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	query := azMetricRequest.LogAnalytics
+	if !logAnalyticsWorkspaceID.MatchString(query.Workspace) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "log analytics workspace must be the workspace id, a guid"}
+	}
+	if strings.TrimSpace(query.Query) == "" {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "log analytics query is required"}
+	}
+
+	body := logAnalyticsQuery{Query: query.Query}
+	if query.Timespan != "" {
+		timespan, err := time.ParseDuration(query.Timespan)
+		if err != nil || timespan < time.Minute || timespan%time.Minute != 0 {
+			return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: fmt.Sprintf("log analytics timespan '%s' must be whole minutes, such as 15m", query.Timespan)}
+		}
+		body.Timespan = iso8601Duration(timespan)
+	}
+
+	requestBody, err := json.Marshal(body)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/v1/workspaces/%s/query", c.endpoint, query.Workspace), bytes.NewReader(requestBody))
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	authorizer, err := c.credentials.Authorizer(c.resource)
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+	if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+
+	glog.V(2).Infof("querying log analytics workspace %s", query.Workspace)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxQueryResponseSize))
+	if err != nil {
+		return AzureExternalMetricResponse{}, fmt.Errorf("unable to read result of log analytics query: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return AzureExternalMetricResponse{}, logAnalyticsQueryError(resp.StatusCode, responseBody)
+	}
+
+	value, err := scalarResult(responseBody, query.Column)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	glog.V(4).Infof("log analytics query of workspace %s returned %f", query.Workspace, value)
+	return AzureExternalMetricResponse{
+		Total: value,
+		Raw:   []string{string(responseBody)},
+	}, nil
+}
+
+// scalarResult returns the number of the first table of the result of a query, as queryScalar does
+func scalarResult(body []byte, column string) (float64, error) {
+	var result logAnalyticsResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, fmt.Errorf("unable to parse result of log analytics query: %v", err)
+	}
+	if len(result.Tables) == 0 {
+		return 0, fmt.Errorf("log analytics query returned no table")
+	}
+
+	table := result.Tables[0]
+	columns := []string{}
+	for _, c := range table.Columns {
+		columns = append(columns, c.Name)
+	}
+	return queryScalar("log analytics", columns, table.Rows, column)
+}
+
+// queryScalar returns the number in the column of the single row of a KQL query result, or 0 when
+// the query returned no rows, such as a count of records that were filtered out.  The first
+// column is served unless a column is named.
+func queryScalar(service string, columns []string, rows [][]interface{}, column string) (float64, error) {
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	if len(rows) > 1 {
+		return 0, fmt.Errorf("%s query returned %d rows, summarize the result to a single row", service, len(rows))
+	}
+
+	index := 0
+	if column != "" {
+		index = -1
+		for i, name := range columns {
+			if name == column {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return 0, fmt.Errorf("%s query returned no column %s", service, column)
+		}
+	}
+	if index >= len(rows[0]) {
+		return 0, fmt.Errorf("%s query returned no columns", service)
+	}
+
+	switch value := rows[0][index].(type) {
+	case float64:
+		return value, nil
+	case string:
+		// decimal and long values that don't fit a double are returned as strings, as are NaN and
+		// infinities, which can't be served
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return 0, fmt.Errorf("%s query returned '%s', not a number", service, value)
+		}
+		return number, nil
+	case bool:
+		if value {
+			return 1, nil
+		}
+		return 0, nil
+	case nil:
+		return 0, fmt.Errorf("%s query returned null", service)
+	default:
+		return 0, fmt.Errorf("%s query returned %v, not a number", service, value)
+	}
+}
+
+// logAnalyticsQueryError returns the message of the error of a failed query, which for syntax
+// errors is in the inner error
+func logAnalyticsQueryError(statusCode int, body []byte) error {
+	var queryError logAnalyticsError
+	if err := json.Unmarshal(body, &queryError); err != nil || queryError.Error.Message == "" {
+		return fmt.Errorf("log analytics query returned status %d: %s", statusCode, redact.String(string(body)))
+	}
+
+	message := queryError.Error.Message
+	if queryError.Error.InnerError.Message != "" {
+		message = fmt.Sprintf("%s %s", message, queryError.Error.InnerError.Message)
+	}
+	if statusCode == http.StatusBadRequest {
+		return InvalidMetricRequestError{err: fmt.Sprintf("log analytics query is invalid: %s", message)}
+	}
+	return fmt.Errorf("log analytics query returned status %d: %s: %s", statusCode, queryError.Error.Code, message)
+}
@@ -0,0 +1,118 @@
+package externalmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testWorkspaceID = "00000000-1111-2222-3333-444444444444"
+
+func TestLogAnalyticsReturnsScalarResult(t *testing.T) {
+	var query logAnalyticsQuery
+	path := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&query)
+		fmt.Fprint(w, `{"tables":[{"name":"PrimaryResult","columns":[{"name":"count_","type":"long"}],"rows":[[42]]}]}`)
+	}))
+	defer server.Close()
+
+	client := NewLogAnalyticsClient(nullCredentialSource{}, server.URL, "https://api.loganalytics.io")
+	metricResponse, err := client.GetAzureMetric(newLogAnalyticsMetricRequest(LogAnalyticsDefinition{Query: "AppExceptions | count", Timespan: "15m"}))
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 42 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 42)
+	}
+	if path != fmt.Sprintf("/v1/workspaces/%s/query", testWorkspaceID) {
+		t.Errorf("path = %v, want query of the workspace", path)
+	}
+	if query.Query != "AppExceptions | count" || query.Timespan != "PT15M" {
+		t.Errorf("query = %+v, want the query over the last 15 minutes", query)
+	}
+}
+
+func TestLogAnalyticsResults(t *testing.T) {
+	var tests = []struct {
+		name    string
+		column  string
+		result  string
+		want    float64
+		wantErr string
+	}{
+		{name: "named column", column: "errors", result: `{"tables":[{"columns":[{"name":"total"},{"name":"errors"}],"rows":[[100, 7]]}]}`, want: 7},
+		{name: "decimal as string", result: `{"tables":[{"columns":[{"name":"rate","type":"decimal"}],"rows":[["0.25"]]}]}`, want: 0.25},
+		{name: "no rows", result: `{"tables":[{"columns":[{"name":"count_"}],"rows":[]}]}`, want: 0},
+		{name: "several rows", result: `{"tables":[{"columns":[{"name":"count_"}],"rows":[[1],[2]]}]}`, wantErr: "returned 2 rows"},
+		{name: "missing column", column: "errors", result: `{"tables":[{"columns":[{"name":"count_"}],"rows":[[1]]}]}`, wantErr: "no column errors"},
+		{name: "nan", result: `{"tables":[{"columns":[{"name":"ratio","type":"real"}],"rows":[["NaN"]]}]}`, wantErr: "not a number"},
+		{name: "infinity", result: `{"tables":[{"columns":[{"name":"ratio","type":"real"}],"rows":[["-Infinity"]]}]}`, wantErr: "not a number"},
+		{name: "not a number", result: `{"tables":[{"columns":[{"name":"name"}],"rows":[["orders"]]}]}`, wantErr: "not a number"},
+		{name: "null", result: `{"tables":[{"columns":[{"name":"avg_"}],"rows":[[null]]}]}`, wantErr: "null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := scalarResult([]byte(tt.result), tt.column)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("scalarResult() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("scalarResult() error = %v, want nil", err)
+			}
+			if value != tt.want {
+				t.Errorf("scalarResult() = %v, want %v", value, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogAnalyticsSyntaxErrorIsInvalidRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"The request had some invalid properties","code":"BadArgumentError","innererror":{"code":"SyntaxError","message":"A recognition error occurred in the query."}}}`)
+	}))
+	defer server.Close()
+
+	client := NewLogAnalyticsClient(nullCredentialSource{}, server.URL, "https://api.loganalytics.io")
+	_, err := client.GetAzureMetric(newLogAnalyticsMetricRequest(LogAnalyticsDefinition{Query: "AppExceptions |"}))
+
+	if !IsInvalidMetricRequestError(err) || !strings.Contains(err.Error(), "recognition error") {
+		t.Errorf("error after processing got: %v, want InvalidMetricRequestError with the syntax error", err)
+	}
+}
+
+func TestLogAnalyticsInvalidRequestsGetError(t *testing.T) {
+	var tests = []LogAnalyticsDefinition{
+		{Workspace: "", Query: "AppExceptions | count"},
+		{Workspace: "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.OperationalInsights/workspaces/logs", Query: "AppExceptions | count"},
+		{Workspace: testWorkspaceID, Query: " "},
+		{Workspace: testWorkspaceID, Query: "AppExceptions | count", Timespan: "30s"},
+		{Workspace: testWorkspaceID, Query: "AppExceptions | count", Timespan: "soon"},
+	}
+
+	client := NewLogAnalyticsClient(fakeCredentialSource{}, "https://api.loganalytics.io", "https://api.loganalytics.io")
+	for _, query := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{LogAnalytics: query})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%+v) got %v, want InvalidMetricRequestError", query, err)
+		}
+	}
+}
+
+func newLogAnalyticsMetricRequest(query LogAnalyticsDefinition) AzureExternalMetricRequest {
+	query.Workspace = testWorkspaceID
+	return AzureExternalMetricRequest{
+		Type:         LogAnalytics,
+		LogAnalytics: query,
+	}
+}
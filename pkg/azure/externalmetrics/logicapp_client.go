@@ -0,0 +1,81 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/golang/glog"
+)
+
+var logicAppWorkflowName = regexp.MustCompile(`^[a-zA-Z0-9_.()-]{1,80}$`)
+
+// LogicAppDefinition names a Logic Apps workflow and the run metric of it to serve
+type LogicAppDefinition struct {
+	Workflow string
+	Metric   string
+}
+
+// logicAppMetrics are the Azure Monitor metrics of a workflow served for each metric
+var logicAppMetrics = map[string]fileShareMetric{
+	"started":   {metricName: "RunsStarted", aggregation: "Total"},
+	"completed": {metricName: "RunsCompleted", aggregation: "Total"},
+	"succeeded": {metricName: "RunsSucceeded", aggregation: "Total"},
+	"failed":    {metricName: "RunsFailed", aggregation: "Total"},
+	"throttled": {metricName: "RunThrottledEvents", aggregation: "Total"},
+}
+
+type logicAppClient struct {
+	monitor AzureExternalMetricClient
+}
+
+// NewLogicAppClient creates a client that serves the run metrics of a Logic Apps workflow from Azure Monitor
+func NewLogicAppClient(defaultsubscriptionID string, credentialSource credentials.Source, endpoints *MonitorEndpoints, apiVersion string) AzureExternalMetricClient {
+	return &logicAppClient{
+		monitor: NewMonitorClient(defaultsubscriptionID, credentialSource, endpoints, apiVersion),
+	}
+}
+
+func (c *logicAppClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	monitorRequest, err := logicAppRequest(azMetricRequest)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	glog.V(2).Infof("requesting %s of logic app workflow %s", monitorRequest.MetricName, azMetricRequest.LogicApp.Workflow)
+	return c.monitor.GetAzureMetric(monitorRequest)
+}
+
+// logicAppRequest converts a Logic Apps run metric to the Azure Monitor query of the workflow
+func logicAppRequest(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricRequest, error) {
+	logicApp := azMetricRequest.LogicApp
+	metric, ok := logicAppMetrics[strings.ToLower(logicApp.Metric)]
+	if !ok {
+		return AzureExternalMetricRequest{}, InvalidMetricRequestError{err: fmt.Sprintf("logic app metric must be one of %s", strings.Join(logicAppMetricNames(), ", "))}
+	}
+	if !logicAppWorkflowName.MatchString(logicApp.Workflow) {
+		return AzureExternalMetricRequest{}, InvalidMetricRequestError{err: "logic app workflow name is invalid"}
+	}
+
+	monitorRequest := azMetricRequest
+	monitorRequest.Type = Monitor
+	monitorRequest.ResourceProviderNamespace = "Microsoft.Logic"
+	monitorRequest.ResourceType = "workflows"
+	monitorRequest.ResourceName = logicApp.Workflow
+	monitorRequest.MetricName = metric.metricName
+	if monitorRequest.Aggregation == "" {
+		monitorRequest.Aggregation = metric.aggregation
+	}
+	return monitorRequest, nil
+}
+
+func logicAppMetricNames() []string {
+	names := []string{}
+	for name := range logicAppMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,59 @@
+package externalmetrics
+
+import (
+	"testing"
+)
+
+func TestLogicAppQueriesRunMetricOfWorkflow(t *testing.T) {
+	monitorClient := &recordingMonitorClient{result: makeAzureMonitorResponse(42)}
+	monitor := newMonitorClient("", monitorClient)
+	client := logicAppClient{monitor: &monitor}
+
+	request := newLogicAppMetricRequest()
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if metricResponse.Total != 42 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 42)
+	}
+
+	wantURI := "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Logic/workflows/orders"
+	if monitorClient.resourceURI != wantURI {
+		t.Errorf("resourceURI = %v, want = %v", monitorClient.resourceURI, wantURI)
+	}
+	if monitorClient.metricnames != "RunThrottledEvents" || monitorClient.aggregation != "Total" {
+		t.Errorf("metric = %v %v, want = RunThrottledEvents Total", monitorClient.metricnames, monitorClient.aggregation)
+	}
+}
+
+func TestLogicAppInvalidRequestGetError(t *testing.T) {
+	var tests = []LogicAppDefinition{
+		{Workflow: "orders", Metric: "latency"},
+		{Workflow: "", Metric: "started"},
+		{Workflow: "orders/runs", Metric: "started"},
+	}
+
+	for _, logicApp := range tests {
+		request := newLogicAppMetricRequest()
+		request.LogicApp = logicApp
+		if _, err := logicAppRequest(request); !IsInvalidMetricRequestError(err) {
+			t.Errorf("logicAppRequest(%+v) got %v, want InvalidMetricRequestError", logicApp, err)
+		}
+	}
+}
+
+func newLogicAppMetricRequest() AzureExternalMetricRequest {
+	return AzureExternalMetricRequest{
+		Type:           LogicApp,
+		SubscriptionID: "1234",
+		ResourceGroup:  "rg",
+		Timespan:       "PT10",
+		LogicApp: LogicAppDefinition{
+			Workflow: "orders",
+			Metric:   "Throttled",
+		},
+	}
+}
@@ -0,0 +1,98 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaintenanceDefinition lists the windows during which served values are frozen and Azure is not queried
+type MaintenanceDefinition struct {
+	// TimeZone recurring windows are evaluated in. Defaults to UTC
+	TimeZone string
+	Windows  []MaintenanceWindow
+}
+
+// MaintenanceWindow is either a single period between From and Until, formatted RFC3339, or a
+// period recurring on Days between Start and End, formatted HH:MM, like the windows of a schedule
+type MaintenanceWindow struct {
+	From  string
+	Until string
+	Days  string
+	Start string
+	End   string
+}
+
+// ActiveAt returns true if t is in any of the windows
+func (m MaintenanceDefinition) ActiveAt(t time.Time) (bool, error) {
+	location := time.UTC
+	if m.TimeZone != "" {
+		var err error
+		location, err = time.LoadLocation(m.TimeZone)
+		if err != nil {
+			return false, InvalidMetricRequestError{err: fmt.Sprintf("invalid maintenance time zone '%s': %v", m.TimeZone, err)}
+		}
+	}
+
+	for _, window := range m.Windows {
+		active, err := window.contains(t.In(location))
+		if err != nil {
+			return false, err
+		}
+		if active {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (w MaintenanceWindow) contains(t time.Time) (bool, error) {
+	if w.From == "" && w.Until == "" {
+		return ScheduleWindow{Days: w.Days, Start: w.Start, End: w.End}.contains(t)
+	}
+	if w.Days != "" || w.Start != "" || w.End != "" {
+		return false, InvalidMetricRequestError{err: "a maintenance window has from and until or days, start and end but not both"}
+	}
+
+	from, err := time.Parse(time.RFC3339, w.From)
+	if err != nil {
+		return false, InvalidMetricRequestError{err: fmt.Sprintf("invalid maintenance window from '%s', must be RFC3339", w.From)}
+	}
+	until, err := time.Parse(time.RFC3339, w.Until)
+	if err != nil {
+		return false, InvalidMetricRequestError{err: fmt.Sprintf("invalid maintenance window until '%s', must be RFC3339", w.Until)}
+	}
+
+	return !t.Before(from) && t.Before(until), nil
+}
+
+// ParseMaintenanceIntervals parses single maintenance windows written as <from>/<until> in RFC3339
+func ParseMaintenanceIntervals(intervals []string) (MaintenanceDefinition, error) {
+	maintenance := MaintenanceDefinition{}
+	for _, interval := range intervals {
+		bounds := strings.Split(interval, "/")
+		if len(bounds) != 2 {
+			return MaintenanceDefinition{}, fmt.Errorf("invalid maintenance window '%s', must be <from>/<until>", interval)
+		}
+
+		window := MaintenanceWindow{From: bounds[0], Until: bounds[1]}
+		if _, err := window.contains(time.Time{}); err != nil {
+			return MaintenanceDefinition{}, err
+		}
+		maintenance.Windows = append(maintenance.Windows, window)
+	}
+
+	return maintenance, nil
+}
+
+// PinDefinition pins the value served for a metric until a time, such as during a rollout.  The
+// metric is pinned at Value, or at the value last served when Value is nil.
+type PinDefinition struct {
+	Until time.Time
+	Value *float64
+}
+
+// ActiveAt returns true if the metric is pinned at t
+func (p PinDefinition) ActiveAt(t time.Time) bool {
+	return t.Before(p.Until)
+}
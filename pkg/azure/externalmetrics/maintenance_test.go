@@ -0,0 +1,73 @@
+package externalmetrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaintenanceActiveAt(t *testing.T) {
+	maintenance := MaintenanceDefinition{
+		TimeZone: "America/New_York",
+		Windows: []MaintenanceWindow{
+			{From: "2019-03-13T00:00:00Z", Until: "2019-03-13T06:00:00Z"},
+			{Days: "SAT", Start: "22:00", End: "02:00"},
+		},
+	}
+
+	var tests = []struct {
+		at   string
+		want bool
+	}{
+		{"2019-03-13T05:59:00Z", true},
+		{"2019-03-13T06:00:00Z", false},
+		// saturday 23:00 and sunday 01:00 in new york
+		{"2019-03-03T04:00:00Z", true},
+		{"2019-03-03T06:00:00Z", true},
+		{"2019-03-03T08:00:00Z", false},
+		{"2019-03-06T04:00:00Z", false},
+	}
+
+	for _, tt := range tests {
+		at, _ := time.Parse(time.RFC3339, tt.at)
+		active, err := maintenance.ActiveAt(at)
+		if err != nil {
+			t.Fatalf("ActiveAt(%s) error = %v, want nil", tt.at, err)
+		}
+		if active != tt.want {
+			t.Errorf("ActiveAt(%s) = %v, want %v", tt.at, active, tt.want)
+		}
+	}
+}
+
+func TestMaintenanceInvalidWindowGetError(t *testing.T) {
+	var tests = []MaintenanceWindow{
+		{From: "tonight", Until: "2019-03-10T06:00:00Z"},
+		{From: "2019-03-10T00:00:00Z", Until: "2019-03-10T06:00:00Z", Days: "SUN"},
+		{Days: "SUN", Start: "2am", End: "04:00"},
+	}
+
+	for _, window := range tests {
+		maintenance := MaintenanceDefinition{Windows: []MaintenanceWindow{window}}
+		if _, err := maintenance.ActiveAt(time.Now()); !IsInvalidMetricRequestError(err) {
+			t.Errorf("ActiveAt() with %+v got %v, want InvalidMetricRequestError", window, err)
+		}
+	}
+}
+
+func TestParseMaintenanceIntervals(t *testing.T) {
+	maintenance, err := ParseMaintenanceIntervals([]string{"2019-03-10T00:00:00Z/2019-03-10T06:00:00Z"})
+	if err != nil {
+		t.Fatalf("ParseMaintenanceIntervals() error = %v, want nil", err)
+	}
+
+	want := MaintenanceWindow{From: "2019-03-10T00:00:00Z", Until: "2019-03-10T06:00:00Z"}
+	if len(maintenance.Windows) != 1 || maintenance.Windows[0] != want {
+		t.Errorf("ParseMaintenanceIntervals() = %+v, want %+v", maintenance.Windows, want)
+	}
+
+	for _, interval := range []string{"2019-03-10T00:00:00Z", "2019-03-10/2019-03-11"} {
+		if _, err := ParseMaintenanceIntervals([]string{interval}); err == nil {
+			t.Errorf("ParseMaintenanceIntervals(%s) got nil, want error", interval)
+		}
+	}
+}
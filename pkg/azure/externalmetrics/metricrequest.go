@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/transform"
 	"github.com/golang/glog"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
@@ -13,18 +14,109 @@ import (
 
 type AzureExternalMetricRequest struct {
 	MetricName                string
+	MetricNamespace           string
 	SubscriptionID            string
+	Subscriptions             []string
+	SubscriptionAggregation   string
+	Resources                 []ResourceRef
+	ResourceTags              map[string]string
+	ResourceQuery             string
+	ResourceAggregation       string
 	Type                      string
 	ResourceName              string
 	ResourceProviderNamespace string
 	ResourceType              string
 	Aggregation               string
 	Timespan                  string
+	Window                    string
+	Interval                  string
+	Rate                      bool
 	Filter                    string
+	SplitDimension            string
+	Top                       int32
+	MonitorAPIVersion         string
+	UseUnits                  bool
 	ResourceGroup             string
 	Namespace                 string
 	Topic                     string
 	Subscription              string
+	MessageCounts             []string
+	Credential                string
+	CredentialSecret          string
+	TenantID                  string
+	Schedule                  ScheduleDefinition
+	Prediction                PredictionDefinition
+	SLO                       SLODefinition
+	Plugin                    PluginDefinition
+	Webhook                   WebhookDefinition
+	ServiceBusQueue           ServiceBusQueueDefinition
+	StorageQueue              StorageQueueDefinition
+	EventHub                  EventHubDefinition
+	FileShare                 FileShareDefinition
+	ActivityLog               ActivityLogDefinition
+	ContainerApp              ContainerAppDefinition
+	LogicApp                  LogicAppDefinition
+	LogAnalytics              LogAnalyticsDefinition
+	DataExplorer              DataExplorerDefinition
+	CosmosDB                  CosmosDBDefinition
+	Prometheus                PrometheusDefinition
+	IoTHub                    IoTHubDefinition
+	Batch                     BatchDefinition
+	BlobCount                 BlobCountDefinition
+	EventGrid                 EventGridDefinition
+	Redis                     RedisDefinition
+	AzureDevOps               AzureDevOpsDefinition
+	Cost                      CostDefinition
+	Quota                     QuotaDefinition
+	Activity                  ActivityDefinition
+	Node                      NodeDefinition
+	PerReplica                PerReplicaDefinition
+	Expression                ExpressionDefinition
+	Alert                     AlertDefinition
+	Maintenance               MaintenanceDefinition
+	Pin                       PinDefinition
+	Timeout                   string
+	NoData                    NoDataDefinition
+	Transform                 transform.Transform
+	Sources                   []WeightedSource
+	Ratio                     RatioDefinition
+	Seasonal                  SeasonalDefinition
+	Heartbeat                 HeartbeatDefinition
+	Variants                  []VariantDefinition
+	// Shadow is queried alongside the request and compared with its value but never served
+	Shadow *AzureExternalMetricRequest
+}
+
+// ActivityDefinition describes when an ExternalMetric is considered active.  The window
+// uses the go duration format and is parsed when the activity metric is requested.
+type ActivityDefinition struct {
+	Enabled   bool
+	Threshold float64
+	Window    string
+}
+
+// NodeDefinition resolves the metric for the scale set instance of a node.  The dimension
+// identifies the instance and defaults to VMName.
+type NodeDefinition struct {
+	Enabled   bool
+	Dimension string
+}
+
+// PerReplicaDefinition references the scale target whose current replicas divide the value of
+// the metric.  The scale target defaults to a Deployment of apps/v1.
+type PerReplicaDefinition struct {
+	Enabled    bool
+	APIVersion string
+	Kind       string
+	Name       string
+}
+
+// ExpressionDefinition computes the served value with an expression.  Hour, minute and weekday
+// are read in the time zone and replicas from the scale target when it is enabled.
+type ExpressionDefinition struct {
+	Value       string
+	TimeZone    string
+	ScaleTarget PerReplicaDefinition
 }
 
 func ParseAzureMetric(metricSelector labels.Selector, defaultSubscriptionID string) (AzureExternalMetricRequest, error) {
@@ -111,7 +203,7 @@ type InvalidMetricRequestError struct {
 }
 
 func (i InvalidMetricRequestError) Error() string {
-	return fmt.Sprintf(i.err)
+	return i.err
 }
 
 func IsInvalidMetricRequestError(err error) bool {
@@ -132,6 +224,17 @@ func (amr AzureExternalMetricRequest) Validate() error {
 	if amr.SubscriptionID == "" {
 		return InvalidMetricRequestError{err: "subscriptionID is required. set a default or pass via label selectors"}
 	}
+	if amr.MonitorAPIVersion != "" {
+		if err := ValidateMonitorAPIVersion(amr.MonitorAPIVersion); err != nil {
+			return err
+		}
+	}
+	if err := ValidateAggregation(amr.Aggregation); err != nil {
+		return err
+	}
+	if err := ValidateQueryWindow(amr.Window, amr.Interval); err != nil {
+		return err
+	}
 
 	// Service Bus
 
@@ -151,11 +254,12 @@ func (amr AzureExternalMetricRequest) Validate() error {
 
 // TimeSpan sets the default time to aggregate a metric
 func TimeSpan() string {
-	// defaults to last five minutes.
-	// TODO support configuration via config
-	endtime := time.Now().UTC().Format(time.RFC3339)
-	starttime := time.Now().Add(-(5 * time.Minute)).UTC().Format(time.RFC3339)
-	return fmt.Sprintf("%s/%s", starttime, endtime)
+	// defaults to last five minutes, metrics can set their own window
+	return timespanUntil(time.Now(), defaultTimespan)
+}
+
+func timespanUntil(end time.Time, window time.Duration) string {
+	return fmt.Sprintf("%s/%s", end.Add(-window).UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339))
 }
 
 func (amr AzureExternalMetricRequest) MetricResourceURI() string {
@@ -166,3 +270,9 @@ func (amr AzureExternalMetricRequest) MetricResourceURI() string {
 		amr.ResourceType,
 		amr.ResourceName)
 }
+
+// AcrossResources returns whether the metric is queried on each resource listed, tagged or matched by
+// a Resource Graph query rather than on a single resource
+func (amr AzureExternalMetricRequest) AcrossResources() bool {
+	return len(amr.Resources) > 0 || len(amr.ResourceTags) > 0 || amr.ResourceQuery != ""
+}
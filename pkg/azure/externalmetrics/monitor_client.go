@@ -1,39 +1,142 @@
 package externalmetrics
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
 	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
-	"github.com/Azure/go-autorest/autorest/azure/auth"
+	"github.com/Azure/go-autorest/autorest"
 	"github.com/golang/glog"
 )
 
-type insightsmonitorClient interface {
+const (
+	defaultSplitTop int32 = 10
+	// MaxSplitTop is the most series a split metric can serve
+	MaxSplitTop int32 = 50
+	// DefaultMonitorAPIVersion is the Azure Monitor metrics API version of the insights package
+	DefaultMonitorAPIVersion = "2018-01-01"
+	defaultTimespan          = 5 * time.Minute
+)
+
+// monitorIntervals are the time grains Azure Monitor aggregates metric values over
+var monitorIntervals = []time.Duration{time.Minute, 5 * time.Minute, 15 * time.Minute, 30 * time.Minute, time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour}
+
+var monitorAPIVersion = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}(-preview)?$`)
+
+// ValidateMonitorAPIVersion returns an error if the version isn't an Azure Monitor API version such as 2019-07-01
+func ValidateMonitorAPIVersion(version string) error {
+	if !monitorAPIVersion.MatchString(version) {
+		return InvalidMetricRequestError{err: fmt.Sprintf("invalid azure monitor api version '%s', must be YYYY-MM-DD or YYYY-MM-DD-preview", version)}
+	}
+	return nil
+}
+
+// ValidateQueryWindow returns an error if the timespan of a metric isn't whole minutes, or the
+// interval isn't a time grain of Azure Monitor no longer than the timespan.  Either can be empty.
+func ValidateQueryWindow(timespan string, interval string) error {
+	window := defaultTimespan
+	if timespan != "" {
+		d, err := time.ParseDuration(timespan)
+		if err != nil || d < time.Minute || d%time.Minute != 0 {
+			return InvalidMetricRequestError{err: fmt.Sprintf("invalid timespan '%s', must be whole minutes such as 15m", timespan)}
+		}
+		window = d
+	}
+	if interval == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(interval)
+	if err != nil || !isMonitorInterval(d) {
+		return InvalidMetricRequestError{err: fmt.Sprintf("invalid interval '%s', must be one of 1m, 5m, 15m, 30m, 1h, 6h, 12h or 24h", interval)}
+	}
+	if d > window {
+		return InvalidMetricRequestError{err: fmt.Sprintf("interval %s is longer than the timespan %s", d, window)}
+	}
+	return nil
+}
+
+func isMonitorInterval(d time.Duration) bool {
+	for _, interval := range monitorIntervals {
+		if d == interval {
+			return true
+		}
+	}
+	return false
+}
+
+// monitorAggregations are the aggregations Azure Monitor returns for each interval
+var monitorAggregations = []string{"Average", "Total", "Minimum", "Maximum", "Count"}
+
+// ValidateAggregation returns an error if the aggregation isn't an Azure Monitor aggregation.  An
+// empty aggregation is the default of the metric.
+func ValidateAggregation(aggregation string) error {
+	if aggregation == "" {
+		return nil
+	}
+	for _, a := range monitorAggregations {
+		if strings.EqualFold(a, aggregation) {
+			return nil
+		}
+	}
+	if isPercentile(aggregation) {
+		return InvalidMetricRequestError{err: fmt.Sprintf("invalid aggregation '%s', Azure Monitor only returns Average, Total, Minimum, Maximum or Count of each interval and percentiles can't be computed from them, use a loganalytics or appinsights metric with percentile() in its query", aggregation)}
+	}
+	return InvalidMetricRequestError{err: fmt.Sprintf("invalid aggregation '%s', must be one of Average, Total, Minimum, Maximum or Count", aggregation)}
+}
+
+// isPercentile returns true for aggregations such as P95 asking for a percentile
+func isPercentile(aggregation string) bool {
+	if len(aggregation) < 2 || (aggregation[0] != 'p' && aggregation[0] != 'P') {
+		return false
+	}
+	_, err := strconv.ParseFloat(aggregation[1:], 64)
+	return err == nil
+}
+
+// InsightsMonitorClient lists the metric values of a resource, as the Azure Monitor metrics client
+// of the insights package does
+type InsightsMonitorClient interface {
 	List(ctx context.Context, resourceURI string, timespan string, interval *string, metricnames string, aggregation string, top *int32, orderby string, filter string, resultType insights.ResultType, metricnamespace string) (result insights.Response, err error)
 }
 
 type monitorClient struct {
-	client                insightsmonitorClient
+	client                InsightsMonitorClient
 	DefaultSubscriptionID string
+	now                   func() time.Time
 }
 
-func NewMonitorClient(defaultsubscriptionID string) AzureExternalMetricClient {
-	client := insights.NewMetricsClient(defaultsubscriptionID)
-	authorizer, err := auth.NewAuthorizerFromEnvironment()
-	if err == nil {
-		client.Authorizer = authorizer
-	}
-
+// NewMonitorClient creates a client that queries Azure Monitor with the API version unless the
+// request sets its own
+func NewMonitorClient(defaultsubscriptionID string, credentialSource credentials.Source, endpoints *MonitorEndpoints, apiVersion string) AzureExternalMetricClient {
 	return &monitorClient{
-		client:                client,
+		client:                endpoints.newClient(defaultsubscriptionID, credentialSource, apiVersion),
 		DefaultSubscriptionID: defaultsubscriptionID,
+		now:                   time.Now,
 	}
 }
 
-func newMonitorClient(defaultsubscriptionID string, client insightsmonitorClient) monitorClient {
+// NewMonitorClientFromInsights creates a client that queries Azure Monitor through the insights
+// client, such as a fake of it in the tests of projects embedding the provider
+func NewMonitorClientFromInsights(defaultsubscriptionID string, client InsightsMonitorClient) AzureExternalMetricClient {
+	c := newMonitorClient(defaultsubscriptionID, client)
+	return &c
+}
+
+func newMonitorClient(defaultsubscriptionID string, client InsightsMonitorClient) monitorClient {
 	return monitorClient{
 		client:                client,
 		DefaultSubscriptionID: defaultsubscriptionID,
+		now:                   time.Now,
 	}
 }
 
@@ -44,34 +147,269 @@ func (c *monitorClient) GetAzureMetric(azMetricRequest AzureExternalMetricReques
 		return AzureExternalMetricResponse{}, err
 	}
 
+	if azMetricRequest.SplitDimension != "" {
+		return c.getSplitMetric(azMetricRequest)
+	}
+
 	metricResourceURI := azMetricRequest.MetricResourceURI()
+	timespan, interval := c.queryWindow(azMetricRequest)
 	glog.V(2).Infof("resource uri: %s", metricResourceURI)
 
-	metricResult, err := c.client.List(context.Background(), metricResourceURI,
-		azMetricRequest.Timespan, nil,
+	raw := &bytes.Buffer{}
+	metricResult, err := c.client.List(apiVersionContext(withRawResponse(context.Background(), raw), azMetricRequest.MonitorAPIVersion), metricResourceURI,
+		timespan, interval,
 		azMetricRequest.MetricName, azMetricRequest.Aggregation, nil,
-		"", azMetricRequest.Filter, "", "")
+		"", azMetricRequest.Filter, "", azMetricRequest.MetricNamespace)
 	if err != nil {
-		return AzureExternalMetricResponse{}, err
+		return AzureExternalMetricResponse{}, redact.Error(err)
 	}
 
-	total := extractValue(metricResult)
+	// a filter matching several dimension values returns a time series for each value
+	if series := extractSeries(metricResult, azMetricRequest.Aggregation, azMetricRequest.Rate); len(series) > 1 {
+		response := AzureExternalMetricResponse{Series: series, Unit: responseUnit(metricResult, azMetricRequest.Rate), Raw: rawBodies(raw)}
+		for _, s := range series {
+			response.Total += s.Value
+		}
+
+		glog.V(2).Infof("found %d series, total: %f", len(series), response.Total)
+		return response, nil
+	}
 
-	glog.V(2).Infof("found metric value: %f", total)
+	total, found := extractValue(metricResult, azMetricRequest.Aggregation, azMetricRequest.Rate)
+	if found {
+		glog.V(2).Infof("found metric value: %f", total)
+	} else {
+		glog.V(2).Infof("no values of metric %s in timespan %s", azMetricRequest.MetricName, timespan)
+	}
 
 	// TODO set Value based on aggregations type
 	return AzureExternalMetricResponse{
-		Total: total,
+		Total:  total,
+		Unit:   responseUnit(metricResult, azMetricRequest.Rate),
+		Raw:    rawBodies(raw),
+		NoData: !found,
 	}, nil
 }
 
-func extractValue(metricResult insights.Response) float64 {
-	//TODO extract value based on aggregation type
-	//TODO check for nils
-	metricVals := *metricResult.Value
-	Timeseries := *metricVals[0].Timeseries
-	data := *Timeseries[0].Data
-	total := *data[len(data)-1].Total
+// getSplitMetric queries a series for each value of the split dimension and returns the series
+// with the highest values
+func (c *monitorClient) getSplitMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	dimension := azMetricRequest.SplitDimension
+	if strings.ContainsAny(dimension, "' ") {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: fmt.Sprintf("invalid split dimension '%s'", dimension)}
+	}
+
+	top := azMetricRequest.Top
+	if top == 0 {
+		top = defaultSplitTop
+	}
+	if top < 0 || top > MaxSplitTop {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: fmt.Sprintf("top must be between 1 and %d", MaxSplitTop)}
+	}
+
+	filter := fmt.Sprintf("%s eq '*'", dimension)
+	if azMetricRequest.Filter != "" {
+		filter = fmt.Sprintf("%s and %s", azMetricRequest.Filter, filter)
+	}
+	orderby := ""
+	if azMetricRequest.Aggregation != "" {
+		orderby = fmt.Sprintf("%s desc", azMetricRequest.Aggregation)
+	}
+
+	metricResourceURI := azMetricRequest.MetricResourceURI()
+	timespan, interval := c.queryWindow(azMetricRequest)
+	glog.V(2).Infof("resource uri: %s, split by: %s", metricResourceURI, dimension)
+
+	raw := &bytes.Buffer{}
+	metricResult, err := c.client.List(apiVersionContext(withRawResponse(context.Background(), raw), azMetricRequest.MonitorAPIVersion), metricResourceURI,
+		timespan, interval,
+		azMetricRequest.MetricName, azMetricRequest.Aggregation, &top,
+		orderby, filter, "", azMetricRequest.MetricNamespace)
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+
+	series := extractSeries(metricResult, azMetricRequest.Aggregation, azMetricRequest.Rate)
+	sort.SliceStable(series, func(i, j int) bool {
+		return series[i].Value > series[j].Value
+	})
+	if len(series) > int(top) {
+		series = series[:top]
+	}
+
+	response := AzureExternalMetricResponse{Series: series, Unit: responseUnit(metricResult, azMetricRequest.Rate), Raw: rawBodies(raw), NoData: len(series) == 0}
+	for _, s := range series {
+		response.Total += s.Value
+	}
+
+	glog.V(2).Infof("found %d series, total: %f", len(series), response.Total)
+	return response, nil
+}
+
+// queryWindow returns the timespan and time grain of the query.  A metric with its own window is
+// queried up to now, otherwise over the timespan of the request with the Azure Monitor default
+// time grain.  The request has been validated so the durations parse.
+func (c *monitorClient) queryWindow(azMetricRequest AzureExternalMetricRequest) (string, *string) {
+	timespan := azMetricRequest.Timespan
+	if azMetricRequest.Window != "" {
+		window, _ := time.ParseDuration(azMetricRequest.Window)
+		timespan = timespanUntil(c.now(), window)
+	}
+	if azMetricRequest.Interval == "" {
+		return timespan, nil
+	}
+
+	d, _ := time.ParseDuration(azMetricRequest.Interval)
+	interval := iso8601Duration(d)
+	return timespan, &interval
+}
+
+// extractSeries returns the value of each time series labelled with its dimension values
+func extractSeries(metricResult insights.Response, aggregation string, rate bool) []MetricSeries {
+	series := []MetricSeries{}
+	if metricResult.Value == nil || len(*metricResult.Value) == 0 || (*metricResult.Value)[0].Timeseries == nil {
+		return series
+	}
+
+	for _, timeseries := range *(*metricResult.Value)[0].Timeseries {
+		value, found := timeseriesValue(timeseries, aggregation, rate)
+		if !found {
+			continue
+		}
+
+		series = append(series, MetricSeries{
+			Labels: dimensionLabels(timeseries),
+			Value:  value,
+		})
+	}
+
+	return series
+}
+
+// dimensionLabels returns the dimension values of a time series keyed by the lower case dimension name
+func dimensionLabels(timeseries insights.TimeSeriesElement) map[string]string {
+	dimensionLabels := map[string]string{}
+	if timeseries.Metadatavalues == nil {
+		return dimensionLabels
+	}
+
+	for _, metadata := range *timeseries.Metadatavalues {
+		if metadata.Name != nil && metadata.Name.Value != nil && metadata.Value != nil {
+			dimensionLabels[strings.ToLower(*metadata.Name.Value)] = *metadata.Value
+		}
+	}
+	return dimensionLabels
+}
+
+// metricUnit returns the unit of the metric, or an empty string when there is no metric
+func metricUnit(metricResult insights.Response) string {
+	if metricResult.Value == nil || len(*metricResult.Value) == 0 {
+		return ""
+	}
+	return string((*metricResult.Value)[0].Unit)
+}
+
+// responseUnit returns the unit of the served values, the unit per second of the rate of a counter
+func responseUnit(metricResult insights.Response, rate bool) string {
+	unit := metricUnit(metricResult)
+	if !rate {
+		return unit
+	}
+	return RateUnit(unit)
+}
+
+// extractValue returns the value of the first time series, or false when it has too few values
+func extractValue(metricResult insights.Response, aggregation string, rate bool) (float64, bool) {
+	if metricResult.Value == nil || len(*metricResult.Value) == 0 {
+		return 0, false
+	}
+	timeseries := (*metricResult.Value)[0].Timeseries
+	if timeseries == nil || len(*timeseries) == 0 {
+		return 0, false
+	}
+
+	return timeseriesValue((*timeseries)[0], aggregation, rate)
+}
+
+// timeseriesValue returns the value served of a time series, the per second rate of a counter or
+// its latest value, or false when it has too few values
+func timeseriesValue(timeseries insights.TimeSeriesElement, aggregation string, rate bool) (float64, bool) {
+	if rate {
+		return counterRate(timeseries, aggregation)
+	}
+
+	values := timeseriesValues(timeseries, aggregation)
+	if len(values) == 0 {
+		return 0, false
+	}
+	return values[len(values)-1], true
+}
+
+// counterRate returns the per second rate of a cumulative counter from the difference of its two
+// latest values.  A counter that decreased was reset, so it is counted from zero.
+func counterRate(timeseries insights.TimeSeriesElement, aggregation string) (float64, bool) {
+	if timeseries.Data == nil {
+		return 0, false
+	}
+
+	times := []time.Time{}
+	values := []float64{}
+	for _, point := range *timeseries.Data {
+		value := pointValue(point, aggregation)
+		if value == nil || point.TimeStamp == nil {
+			continue
+		}
+		times = append(times, point.TimeStamp.Time)
+		values = append(values, *value)
+	}
+
+	n := len(values)
+	if n < 2 {
+		return 0, false
+	}
+	seconds := times[n-1].Sub(times[n-2]).Seconds()
+	if seconds <= 0 {
+		return 0, false
+	}
+
+	increase := values[n-1] - values[n-2]
+	if increase < 0 {
+		increase = values[n-1]
+	}
+	return increase / seconds, true
+}
+
+type apiVersionKey struct{}
+
+// apiVersionContext returns a context that queries Azure Monitor with the API version of a request
+func apiVersionContext(ctx context.Context, version string) context.Context {
+	if version == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, apiVersionKey{}, version)
+}
+
+// withAPIVersion sets the api-version of Azure Monitor queries to the version of the request
+// context, or to the default version.  The insights package pins the version it was generated
+// for, but new metric namespaces and dimensions sometimes need a newer one.
+func withAPIVersion(defaultVersion string) autorest.PrepareDecorator {
+	return func(p autorest.Preparer) autorest.Preparer {
+		return autorest.PreparerFunc(func(r *http.Request) (*http.Request, error) {
+			r, err := p.Prepare(r)
+			if err != nil {
+				return r, err
+			}
 
-	return total
+			version, _ := r.Context().Value(apiVersionKey{}).(string)
+			if version == "" {
+				version = defaultVersion
+			}
+			if version != "" {
+				query := r.URL.Query()
+				query.Set("api-version", version)
+				r.URL.RawQuery = query.Encode()
+			}
+			return r, nil
+		})
+	}
 }
@@ -3,9 +3,15 @@ package externalmetrics
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
+	"github.com/Azure/go-autorest/autorest/date"
 )
 
 func TestAzureMonitorIfEmptyRequestGetError(t *testing.T) {
@@ -61,6 +67,331 @@ func TestAzureMonitorIfValidRequestGetResult(t *testing.T) {
 	}
 }
 
+func TestAzureMonitorWithoutValuesHasNoData(t *testing.T) {
+	var tests = []insights.Response{
+		{Value: &[]insights.Metric{}},
+		{Value: &[]insights.Metric{{Timeseries: &[]insights.TimeSeriesElement{}}}},
+		{Value: &[]insights.Metric{{Timeseries: &[]insights.TimeSeriesElement{{Data: &[]insights.MetricValue{{}}}}}}},
+	}
+
+	for i, response := range tests {
+		client := newMonitorClient("", newFakeMonitorClient(response, nil))
+
+		metricResponse, err := client.GetAzureMetric(newAzureMonitorMetricRequest())
+		if err != nil {
+			t.Fatalf("%d: error after processing got: %v, want nil", i, err)
+		}
+		if !metricResponse.NoData || metricResponse.Total != 0 {
+			t.Errorf("%d: metricResponse = %+v, want no data", i, metricResponse)
+		}
+	}
+}
+
+func TestAzureMonitorReturnsUnit(t *testing.T) {
+	response := makeAzureMonitorResponse(1024)
+	(*response.Value)[0].Unit = insights.UnitBytes
+	monitorClient := newFakeMonitorClient(response, nil)
+
+	client := newMonitorClient("", monitorClient)
+
+	metricResponse, err := client.GetAzureMetric(newAzureMonitorMetricRequest())
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if metricResponse.Unit != UnitBytes {
+		t.Errorf("metricResponse.Unit = %v, want = %v", metricResponse.Unit, UnitBytes)
+	}
+}
+
+func TestAzureMonitorReturnsValueOfAggregation(t *testing.T) {
+	average := 42.0
+	response := insights.Response{Value: &[]insights.Metric{{Timeseries: &[]insights.TimeSeriesElement{{Data: &[]insights.MetricValue{{Average: &average}}}}}}}
+	monitorClient := newFakeMonitorClient(response, nil)
+
+	client := newMonitorClient("", monitorClient)
+
+	request := newAzureMonitorMetricRequest()
+	request.Aggregation = "Average"
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if metricResponse.Total != 42 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 42)
+	}
+}
+
+func TestAzureMonitorSplitReturnsTopSeries(t *testing.T) {
+	monitorClient := &splitMonitorClient{values: map[string]float64{"orders": 3, "payments": 12, "shipping": 7}}
+
+	client := newMonitorClient("", monitorClient)
+
+	request := newAzureMonitorMetricRequest()
+	request.SplitDimension = "EntityName"
+	request.Top = 2
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if monitorClient.filter != "Filter and EntityName eq '*'" {
+		t.Errorf("filter = %v, want = %v", monitorClient.filter, "Filter and EntityName eq '*'")
+	}
+	if monitorClient.orderby != "Total desc" {
+		t.Errorf("orderby = %v, want = %v", monitorClient.orderby, "Total desc")
+	}
+
+	want := []MetricSeries{
+		{Labels: map[string]string{"entityname": "payments"}, Value: 12},
+		{Labels: map[string]string{"entityname": "shipping"}, Value: 7},
+	}
+	if !reflect.DeepEqual(metricResponse.Series, want) {
+		t.Errorf("metricResponse.Series = %v, want = %v", metricResponse.Series, want)
+	}
+	if metricResponse.Total != 19 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 19)
+	}
+}
+
+func TestAzureMonitorFilterMatchingSeveralValuesReturnsSeries(t *testing.T) {
+	monitorClient := &splitMonitorClient{values: map[string]float64{"orders": 3, "payments": 12}}
+
+	client := newMonitorClient("", monitorClient)
+
+	request := newAzureMonitorMetricRequest()
+	request.Filter = "EntityName eq 'orders' or EntityName eq 'payments'"
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if len(metricResponse.Series) != 2 {
+		t.Fatalf("len(metricResponse.Series) = %v, want = %v", len(metricResponse.Series), 2)
+	}
+	for _, series := range metricResponse.Series {
+		if want := monitorClient.values[series.Labels["entityname"]]; series.Value != want {
+			t.Errorf("%v value = %v, want = %v", series.Labels, series.Value, want)
+		}
+	}
+	if metricResponse.Total != 15 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 15)
+	}
+}
+
+func TestAzureMonitorQueriesMetricNamespace(t *testing.T) {
+	monitorClient := &recordingMonitorClient{result: makeAzureMonitorResponse(42)}
+
+	client := newMonitorClient("", monitorClient)
+
+	request := newAzureMonitorMetricRequest()
+	request.MetricName = "Memory\\Available Bytes"
+	request.MetricNamespace = "azure.vm.windows.guestmetrics"
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if monitorClient.namespace != "azure.vm.windows.guestmetrics" {
+		t.Errorf("metric namespace = %v, want = %v", monitorClient.namespace, "azure.vm.windows.guestmetrics")
+	}
+	if metricResponse.Total != 42 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 42)
+	}
+}
+
+func TestAzureMonitorQueriesWindowOfMetric(t *testing.T) {
+	monitorClient := &recordingMonitorClient{result: makeAzureMonitorResponse(7)}
+
+	client := newMonitorClient("", monitorClient)
+	client.now = func() time.Time { return time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	request := newAzureMonitorMetricRequest()
+	request.Window = "15m"
+	request.Interval = "15m"
+	_, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if monitorClient.timespan != "2019-03-01T11:45:00Z/2019-03-01T12:00:00Z" {
+		t.Errorf("timespan = %v, want the last 15 minutes", monitorClient.timespan)
+	}
+	if monitorClient.interval != "PT15M" {
+		t.Errorf("interval = %v, want = %v", monitorClient.interval, "PT15M")
+	}
+}
+
+func TestAzureMonitorRateOfCounter(t *testing.T) {
+	var tests = []struct {
+		name   string
+		values []float64
+		want   float64
+		noData bool
+	}{
+		{"increasing", []float64{1000, 1600, 2800}, 20, false},
+		{"reset", []float64{1000, 1600, 300}, 5, false},
+		{"single value", []float64{1000}, 0, true},
+	}
+
+	for _, tt := range tests {
+		start := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+		data := make([]insights.MetricValue, len(tt.values))
+		for i := range tt.values {
+			data[i] = insights.MetricValue{TimeStamp: &date.Time{Time: start.Add(time.Duration(i) * time.Minute)}, Maximum: &tt.values[i]}
+		}
+		timeseries := []insights.TimeSeriesElement{{Data: &data}}
+		response := insights.Response{Value: &[]insights.Metric{{Timeseries: &timeseries, Unit: insights.UnitBytes}}}
+
+		client := newMonitorClient("", newFakeMonitorClient(response, nil))
+
+		request := newAzureMonitorMetricRequest()
+		request.Aggregation = "Maximum"
+		request.Rate = true
+		metricResponse, err := client.GetAzureMetric(request)
+
+		if err != nil {
+			t.Fatalf("%s: error after processing got: %v, want nil", tt.name, err)
+		}
+		if metricResponse.Total != tt.want || metricResponse.NoData != tt.noData {
+			t.Errorf("%s: metricResponse = %v no data %v, want = %v no data %v", tt.name, metricResponse.Total, metricResponse.NoData, tt.want, tt.noData)
+		}
+		if metricResponse.Unit != UnitBytesPerSecond {
+			t.Errorf("%s: unit = %v, want = %v", tt.name, metricResponse.Unit, UnitBytesPerSecond)
+		}
+	}
+}
+
+func TestValidateAggregation(t *testing.T) {
+	for _, aggregation := range []string{"", "Average", "total", "Minimum", "Maximum", "Count"} {
+		if err := ValidateAggregation(aggregation); err != nil {
+			t.Errorf("ValidateAggregation(%q) = %v, want nil", aggregation, err)
+		}
+	}
+	for _, aggregation := range []string{"sum", "P50", "p95", "median"} {
+		if err := ValidateAggregation(aggregation); !IsInvalidMetricRequestError(err) {
+			t.Errorf("ValidateAggregation(%q) = %v, want InvalidMetricRequestError", aggregation, err)
+		}
+	}
+}
+
+func TestValidateQueryWindow(t *testing.T) {
+	var tests = []struct {
+		timespan string
+		interval string
+		valid    bool
+	}{
+		{"", "", true},
+		{"15m", "", true},
+		{"", "5m", true},
+		{"1h", "15m", true},
+		{"90s", "", false},
+		{"soon", "", false},
+		{"", "2m", false},
+		{"", "15m", false},
+		{"15m", "1h", false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateQueryWindow(tt.timespan, tt.interval)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateQueryWindow(%q, %q) = %v, want valid %v", tt.timespan, tt.interval, err, tt.valid)
+		}
+		if err != nil && !IsInvalidMetricRequestError(err) {
+			t.Errorf("ValidateQueryWindow(%q, %q) = %v, want InvalidMetricRequestError", tt.timespan, tt.interval, err)
+		}
+	}
+}
+
+func TestAzureMonitorSplitInvalidTopGetError(t *testing.T) {
+	client := newMonitorClient("", &splitMonitorClient{})
+
+	request := newAzureMonitorMetricRequest()
+	request.SplitDimension = "EntityName"
+	request.Top = MaxSplitTop + 1
+	_, err := client.GetAzureMetric(request)
+
+	if !IsInvalidMetricRequestError(err) {
+		t.Errorf("should be InvalidMetricRequest error got %v, want InvalidMetricRequestError", err)
+	}
+}
+
+func TestAzureMonitorQueriesAPIVersion(t *testing.T) {
+	var tests = []struct {
+		name           string
+		defaultVersion string
+		requestVersion string
+		want           string
+	}{
+		{"insights package version", "", "", "2018-01-01"},
+		{"adapter default", "2019-07-01", "", "2019-07-01"},
+		{"set by the metric", "2019-07-01", "2021-05-01-preview", "2021-05-01-preview"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apiVersion := ""
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				apiVersion = r.URL.Query().Get("api-version")
+				fmt.Fprint(w, `{"value":[{"timeseries":[{"data":[{"total":15}]}]}]}`)
+			}))
+			defer server.Close()
+
+			endpoints := NewMonitorEndpoints([]string{server.URL}, time.Minute)
+			client := NewMonitorClient("", fakeCredentialSource{}, endpoints, tt.defaultVersion)
+			request := newAzureMonitorMetricRequest()
+			request.MonitorAPIVersion = tt.requestVersion
+
+			metricResponse, err := client.GetAzureMetric(request)
+
+			if err != nil {
+				t.Fatalf("error after processing got: %v, want nil", err)
+			}
+			if metricResponse.Total != 15 {
+				t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 15)
+			}
+			if apiVersion != tt.want {
+				t.Errorf("api-version = %v, want %v", apiVersion, tt.want)
+			}
+		})
+	}
+}
+
+func TestAzureMonitorKeepsRawResponse(t *testing.T) {
+	body := `{"value":[{"timeseries":[{"data":[{"total":15}]}]}]}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	client := NewMonitorClient("", fakeCredentialSource{}, NewMonitorEndpoints([]string{server.URL}, time.Minute), "")
+	metricResponse, err := client.GetAzureMetric(newAzureMonitorMetricRequest())
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if !reflect.DeepEqual(metricResponse.Raw, []string{body}) {
+		t.Errorf("metricResponse.Raw = %v, want %v", metricResponse.Raw, []string{body})
+	}
+}
+
+func TestAzureMonitorInvalidAPIVersionGetError(t *testing.T) {
+	client := newMonitorClient("", newFakeMonitorClient(makeAzureMonitorResponse(15), nil))
+	request := newAzureMonitorMetricRequest()
+	request.MonitorAPIVersion = "latest"
+
+	_, err := client.GetAzureMetric(request)
+
+	if !IsInvalidMetricRequestError(err) {
+		t.Errorf("error after processing got: %v, want InvalidMetricRequestError", err)
+	}
+}
+
 func makeAzureMonitorResponse(value float64) insights.Response {
 	// create metric value
 	mv := insights.MetricValue{
@@ -99,12 +430,12 @@ func newAzureMonitorMetricRequest() AzureExternalMetricRequest {
 		SubscriptionID:            "SubscriptionID",
 		MetricName:                "MetricName",
 		Filter:                    "Filter",
-		Aggregation:               "Aggregation",
+		Aggregation:               "Total",
 		Timespan:                  "PT10",
 	}
 }
 
-func newFakeMonitorClient(result insights.Response, err error) insightsmonitorClient {
+func newFakeMonitorClient(result insights.Response, err error) InsightsMonitorClient {
 	return fakeMonitorClient{
 		err:    err,
 		result: result,
@@ -121,3 +452,26 @@ func (f fakeMonitorClient) List(ctx context.Context, resourceURI string, timespa
 	err = f.err
 	return
 }
+
+// splitMonitorClient returns a time series for each dimension value, in no particular order
+type splitMonitorClient struct {
+	values  map[string]float64
+	filter  string
+	orderby string
+}
+
+func (f *splitMonitorClient) List(ctx context.Context, resourceURI string, timespan string, interval *string, metricnames string, aggregation string, top *int32, orderby string, filter string, resultType insights.ResultType, metricnamespace string) (result insights.Response, err error) {
+	f.filter = filter
+	f.orderby = orderby
+
+	timeseries := []insights.TimeSeriesElement{}
+	for dimensionValue, value := range f.values {
+		name, dimensionValue, value := "EntityName", dimensionValue, value
+		timeseries = append(timeseries, insights.TimeSeriesElement{
+			Metadatavalues: &[]insights.MetadataValue{{Name: &insights.LocalizableString{Value: &name}, Value: &dimensionValue}},
+			Data:           &[]insights.MetricValue{{Total: &value}},
+		})
+	}
+
+	return insights.Response{Value: &[]insights.Metric{{Timeseries: &timeseries}}}, nil
+}
@@ -0,0 +1,156 @@
+package externalmetrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const publicResourceManagerHost = "management.azure.com"
+
+// MonitorEndpoints fails Azure Monitor queries over between regional Azure Resource Manager
+// endpoints, such as https://eastus.management.azure.com, in order of preference.  An endpoint
+// that fails is skipped until the cooldown has passed.
+type MonitorEndpoints struct {
+	endpoints []string
+	cooldown  time.Duration
+	now       func() time.Time
+	quota     *ARMQuota
+
+	mu             sync.Mutex
+	unhealthyUntil []time.Time
+}
+
+// NewMonitorEndpoints creates the failover state shared by every Azure Monitor client
+func NewMonitorEndpoints(endpoints []string, cooldown time.Duration) *MonitorEndpoints {
+	return &MonitorEndpoints{
+		endpoints:      endpoints,
+		cooldown:       cooldown,
+		now:            time.Now,
+		unhealthyUntil: make([]time.Time, len(endpoints)),
+	}
+}
+
+// TrackQuota makes the clients of the endpoints record the Azure Resource Manager quota remaining
+// to the subscriptions they query
+func (e *MonitorEndpoints) TrackQuota(quota *ARMQuota) *MonitorEndpoints {
+	e.quota = quota
+	return e
+}
+
+// RegionalEndpoints returns the regional Azure Resource Manager endpoint of the location followed
+// by the global endpoint, so Azure Monitor is queried in the adapter's region and fails over to the
+// global endpoint.  Only the public cloud has regional endpoints, so other resource managers, such
+// as a private endpoint or sovereign cloud, are returned on their own.
+func RegionalEndpoints(location string, resourceManager string) []string {
+	u, err := url.Parse(resourceManager)
+	if location == "" || err != nil || u.Host != publicResourceManagerHost {
+		return []string{resourceManager}
+	}
+
+	return []string{fmt.Sprintf("%s://%s.%s", u.Scheme, strings.ToLower(location), u.Host), resourceManager}
+}
+
+// newClient returns a client for the endpoints that queries the API version, or the version of the
+// insights package when empty.  The public endpoint is used when none are configured.
+func (e *MonitorEndpoints) newClient(subscriptionID string, credentialSource credentials.Source, apiVersion string) InsightsMonitorClient {
+	authorizer, authErr := credentialSource.Authorizer("")
+	newClient := func(client insights.MetricsClient) insights.MetricsClient {
+		if authErr == nil {
+			client.Authorizer = authorizer
+		}
+		client.RequestInspector = withAPIVersion(apiVersion)
+		client.ResponseInspector = copyRawResponse()
+		if e != nil && e.quota != nil {
+			observe := e.quota.observeResponses()
+			client.ResponseInspector = func(r autorest.Responder) autorest.Responder {
+				return copyRawResponse()(observe(r))
+			}
+		}
+		return client
+	}
+
+	if e == nil || len(e.endpoints) == 0 {
+		return newClient(insights.NewMetricsClient(subscriptionID))
+	}
+	if len(e.endpoints) == 1 {
+		return newClient(insights.NewMetricsClientWithBaseURI(e.endpoints[0], subscriptionID))
+	}
+
+	clients := make([]InsightsMonitorClient, len(e.endpoints))
+	for i, endpoint := range e.endpoints {
+		clients[i] = newClient(insights.NewMetricsClientWithBaseURI(endpoint, subscriptionID))
+	}
+
+	return &failoverMonitorClient{endpoints: e, clients: clients}
+}
+
+// order returns the endpoints to try, healthy ones first in order of preference
+func (e *MonitorEndpoints) order() []int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	healthy, unhealthy := []int{}, []int{}
+	for i := range e.endpoints {
+		if now.Before(e.unhealthyUntil[i]) {
+			unhealthy = append(unhealthy, i)
+		} else {
+			healthy = append(healthy, i)
+		}
+	}
+
+	// every endpoint is still tried rather than failing without making a request
+	return append(healthy, unhealthy...)
+}
+
+func (e *MonitorEndpoints) markUnhealthy(i int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.unhealthyUntil[i] = e.now().Add(e.cooldown)
+}
+
+type failoverMonitorClient struct {
+	endpoints *MonitorEndpoints
+	clients   []InsightsMonitorClient
+}
+
+func (c *failoverMonitorClient) List(ctx context.Context, resourceURI string, timespan string, interval *string, metricnames string, aggregation string, top *int32, orderby string, filter string, resultType insights.ResultType, metricnamespace string) (result insights.Response, err error) {
+	for _, i := range c.endpoints.order() {
+		result, err = c.clients[i].List(ctx, resourceURI, timespan, interval, metricnames, aggregation, top, orderby, filter, resultType, metricnamespace)
+		if err == nil || !isRegionalFailure(err) {
+			return result, err
+		}
+
+		glog.Warningf("azure monitor endpoint %s failed, failing over: %v", c.endpoints.endpoints[i], err)
+		c.endpoints.markUnhealthy(i)
+	}
+
+	return result, err
+}
+
+// isRegionalFailure returns true when the request did not get a response or the endpoint
+// returned a server error.  Other errors would fail the same way in every region.
+func isRegionalFailure(err error) bool {
+	detailed, ok := err.(autorest.DetailedError)
+	if !ok {
+		return true
+	}
+
+	statusCode, ok := detailed.StatusCode.(int)
+	if !ok || statusCode == autorest.UndefinedStatusCode {
+		return true
+	}
+
+	return statusCode >= http.StatusInternalServerError
+}
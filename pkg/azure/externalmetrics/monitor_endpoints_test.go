@@ -0,0 +1,149 @@
+package externalmetrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
+	"github.com/Azure/go-autorest/autorest"
+)
+
+func TestMonitorEndpointsFailOver(t *testing.T) {
+	serverError := autorest.NewErrorWithError(errors.New("unavailable"), "insights.MetricsClient", "List", &http.Response{StatusCode: http.StatusServiceUnavailable}, "")
+	badRequest := autorest.NewErrorWithError(errors.New("bad request"), "insights.MetricsClient", "List", &http.Response{StatusCode: http.StatusBadRequest}, "")
+	noResponse := autorest.NewErrorWithError(errors.New("dial tcp: no such host"), "insights.MetricsClient", "List", nil, "")
+
+	var tests = []struct {
+		name      string
+		errs      []error
+		want      float64
+		wantErr   bool
+		unhealthy []bool
+	}{
+		{"primary healthy", []error{nil, nil}, 1, false, []bool{false, false}},
+		{"primary server error", []error{serverError, nil}, 2, false, []bool{true, false}},
+		{"primary unreachable", []error{noResponse, nil}, 2, false, []bool{true, false}},
+		{"bad request is not retried", []error{badRequest, nil}, 0, true, []bool{false, false}},
+		{"all endpoints fail", []error{serverError, serverError}, 0, true, []bool{true, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoints := NewMonitorEndpoints([]string{"https://primary", "https://secondary"}, time.Minute)
+			client := &failoverMonitorClient{endpoints: endpoints}
+			for i, err := range tt.errs {
+				client.clients = append(client.clients, newFakeMonitorClient(makeAzureMonitorResponse(float64(i+1)), err))
+			}
+
+			result, err := client.List(context.Background(), "", "", nil, "", "", nil, "", "", "", "")
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if value, _ := extractValue(result, "", false); err == nil && value != tt.want {
+				t.Errorf("value = %v, want %v", value, tt.want)
+			}
+
+			now := endpoints.now()
+			for i, want := range tt.unhealthy {
+				if got := now.Before(endpoints.unhealthyUntil[i]); got != want {
+					t.Errorf("endpoint %d unhealthy = %v, want %v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMonitorEndpointsSkipUnhealthyUntilCooldown(t *testing.T) {
+	now := time.Now()
+	endpoints := NewMonitorEndpoints([]string{"https://primary", "https://secondary"}, time.Minute)
+	endpoints.now = func() time.Time { return now }
+
+	primary := &countingMonitorClient{}
+	client := &failoverMonitorClient{
+		endpoints: endpoints,
+		clients:   []InsightsMonitorClient{primary, newFakeMonitorClient(makeAzureMonitorResponse(2), nil)},
+	}
+
+	endpoints.markUnhealthy(0)
+	if _, err := client.List(context.Background(), "", "", nil, "", "", nil, "", "", "", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if primary.calls != 0 {
+		t.Errorf("primary called %d times during cooldown, want 0", primary.calls)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := client.List(context.Background(), "", "", nil, "", "", nil, "", "", "", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if primary.calls != 1 {
+		t.Errorf("primary called %d times after cooldown, want 1", primary.calls)
+	}
+}
+
+func TestMonitorEndpointsDefaultToPublicEndpoint(t *testing.T) {
+	var endpoints *MonitorEndpoints
+	client := endpoints.newClient("sub", fakeCredentialSource{}, "")
+	metricsClient, ok := client.(insights.MetricsClient)
+	if !ok {
+		t.Fatalf("client = %T, want insights.MetricsClient", client)
+	}
+	if metricsClient.BaseURI != insights.DefaultBaseURI {
+		t.Errorf("BaseURI = %s, want %s", metricsClient.BaseURI, insights.DefaultBaseURI)
+	}
+}
+
+func TestMonitorEndpointsSingleEndpointDoesNotFailOver(t *testing.T) {
+	endpoints := NewMonitorEndpoints([]string{"https://management.local.azurestack.external"}, time.Minute)
+	client := endpoints.newClient("sub", fakeCredentialSource{}, "")
+	metricsClient, ok := client.(insights.MetricsClient)
+	if !ok {
+		t.Fatalf("client = %T, want insights.MetricsClient", client)
+	}
+	if metricsClient.BaseURI != "https://management.local.azurestack.external" {
+		t.Errorf("BaseURI = %s, want %s", metricsClient.BaseURI, "https://management.local.azurestack.external")
+	}
+}
+
+type countingMonitorClient struct {
+	calls int
+}
+
+func (c *countingMonitorClient) List(ctx context.Context, resourceURI string, timespan string, interval *string, metricnames string, aggregation string, top *int32, orderby string, filter string, resultType insights.ResultType, metricnamespace string) (insights.Response, error) {
+	c.calls++
+	return makeAzureMonitorResponse(1), nil
+}
+
+type fakeCredentialSource struct{}
+
+func (fakeCredentialSource) Authorizer(resource string) (autorest.Authorizer, error) {
+	return nil, errors.New("no credentials")
+}
+
+func (fakeCredentialSource) Value(name string) string {
+	return ""
+}
+
+func TestRegionalEndpointsOnlyInPublicCloud(t *testing.T) {
+	var tests = []struct {
+		name            string
+		location        string
+		resourceManager string
+		want            []string
+	}{
+		{"public cloud", "WestUS2", "https://management.azure.com", []string{"https://westus2.management.azure.com", "https://management.azure.com"}},
+		{"no location", "", "https://management.azure.com", []string{"https://management.azure.com"}},
+		{"sovereign cloud", "chinaeast2", "https://management.chinacloudapi.cn", []string{"https://management.chinacloudapi.cn"}},
+		{"private endpoint", "westus2", "https://arm.contoso.internal", []string{"https://arm.contoso.internal"}},
+	}
+
+	for _, tt := range tests {
+		if got := RegionalEndpoints(tt.location, tt.resourceManager); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: RegionalEndpoints() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
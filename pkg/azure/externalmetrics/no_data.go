@@ -0,0 +1,52 @@
+package externalmetrics
+
+import "fmt"
+
+// Policies deciding the value served when Azure Monitor has no values for a metric
+const (
+	// NoDataZero serves zero, as when no policy is set
+	NoDataZero string = "zero"
+	// NoDataLastValue serves the last value of the metric that had data
+	NoDataLastValue string = "lastValue"
+	// NoDataError fails the request, so the autoscaler keeps the current scale
+	NoDataError string = "error"
+	// NoDataFixed serves the value of the policy
+	NoDataFixed string = "fixed"
+)
+
+// NoDataDefinition decides the value served when Azure Monitor has no values in the timespan of
+// a metric, such as a metric only emitted while there is traffic
+type NoDataDefinition struct {
+	Policy string
+	Value  float64
+}
+
+// Validate checks the policy
+func (n NoDataDefinition) Validate() error {
+	switch n.Policy {
+	case "", NoDataZero, NoDataLastValue, NoDataError, NoDataFixed:
+		return nil
+	}
+	return InvalidMetricRequestError{err: fmt.Sprintf("invalid noDataPolicy '%s', must be %s, %s, %s or %s", n.Policy, NoDataZero, NoDataLastValue, NoDataError, NoDataFixed)}
+}
+
+// Apply returns the response served in place of a response without data, given the last response
+// of the metric that had data when there is one.  It returns false when no value can be served.
+func (n NoDataDefinition) Apply(response AzureExternalMetricResponse, last *AzureExternalMetricResponse) (AzureExternalMetricResponse, bool) {
+	if !response.NoData {
+		return response, true
+	}
+
+	switch n.Policy {
+	case NoDataLastValue:
+		if last == nil {
+			return response, false
+		}
+		return *last, true
+	case NoDataError:
+		return response, false
+	case NoDataFixed:
+		return AzureExternalMetricResponse{Total: n.Value, Unit: response.Unit, Raw: response.Raw, NoData: true}, true
+	}
+	return response, true
+}
@@ -0,0 +1,43 @@
+package externalmetrics
+
+import "testing"
+
+func TestNoDataPolicies(t *testing.T) {
+	last := AzureExternalMetricResponse{Total: 12}
+	var tests = []struct {
+		policy string
+		last   *AzureExternalMetricResponse
+		want   float64
+		served bool
+	}{
+		{"", &last, 0, true},
+		{NoDataZero, &last, 0, true},
+		{NoDataLastValue, &last, 12, true},
+		{NoDataLastValue, nil, 0, false},
+		{NoDataError, &last, 0, false},
+		{NoDataFixed, nil, 3, true},
+	}
+
+	for _, tt := range tests {
+		response, served := NoDataDefinition{Policy: tt.policy, Value: 3}.Apply(AzureExternalMetricResponse{NoData: true}, tt.last)
+		if served != tt.served || (served && response.Total != tt.want) {
+			t.Errorf("%q: Apply() = %v, %v, want %v, %v", tt.policy, response.Total, served, tt.want, tt.served)
+		}
+	}
+}
+
+func TestNoDataPolicyKeepsValueWithData(t *testing.T) {
+	response, served := NoDataDefinition{Policy: NoDataError}.Apply(AzureExternalMetricResponse{Total: 5}, nil)
+
+	if !served || response.Total != 5 {
+		t.Errorf("Apply() = %v, %v, want the value with data", response.Total, served)
+	}
+}
+
+func TestInvalidNoDataPolicyGetError(t *testing.T) {
+	err := NoDataDefinition{Policy: "previous"}.Validate()
+
+	if !IsInvalidMetricRequestError(err) {
+		t.Errorf("Validate() = %v, want InvalidMetricRequestError", err)
+	}
+}
@@ -0,0 +1,42 @@
+package externalmetrics
+
+import (
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/plugin"
+	"github.com/golang/glog"
+)
+
+// PluginDefinition names the MetricSource plugin serving the metric and the parameters passed to it
+type PluginDefinition struct {
+	Name       string
+	Parameters map[string]string
+}
+
+type pluginClient struct {
+	registry *plugin.Registry
+}
+
+// NewPluginClient creates a client that serves metrics from the plugins in the registry
+func NewPluginClient(registry *plugin.Registry) AzureExternalMetricClient {
+	return &pluginClient{registry: registry}
+}
+
+func (c *pluginClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	if azMetricRequest.Plugin.Name == "" {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "plugin name is required"}
+	}
+
+	value, err := c.registry.GetMetric(azMetricRequest.Plugin.Name, &plugin.MetricRequest{
+		MetricName:     azMetricRequest.MetricName,
+		SubscriptionId: azMetricRequest.SubscriptionID,
+		ResourceGroup:  azMetricRequest.ResourceGroup,
+		Parameters:     azMetricRequest.Plugin.Parameters,
+	})
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	glog.V(2).Infof("plugin %s metric %s value: %f", azMetricRequest.Plugin.Name, azMetricRequest.MetricName, value)
+	return AzureExternalMetricResponse{
+		Total: value,
+	}, nil
+}
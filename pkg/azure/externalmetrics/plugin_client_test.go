@@ -0,0 +1,75 @@
+package externalmetrics
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/plugin"
+	"google.golang.org/grpc"
+)
+
+func TestPluginClientReturnsPluginValue(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	server := grpc.NewServer()
+	fake := &fakeMetricSource{value: 12}
+	plugin.RegisterMetricSourceServer(server, fake)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	registry, _ := plugin.NewRegistry([]plugin.Source{{Name: "queue", Address: listener.Addr().String()}})
+	client := NewPluginClient(registry)
+
+	request := AzureExternalMetricRequest{
+		MetricName:     "depth",
+		SubscriptionID: "1234",
+		ResourceGroup:  "rg",
+		Plugin:         PluginDefinition{Name: "queue", Parameters: map[string]string{"queue": "orders"}},
+	}
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if metricResponse.Total != 12 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 12)
+	}
+
+	if fake.request.SubscriptionId != "1234" || fake.request.ResourceGroup != "rg" || fake.request.Parameters["queue"] != "orders" {
+		t.Errorf("plugin request = %v, want subscription, resource group and parameters", fake.request)
+	}
+}
+
+func TestPluginClientWithoutNameGetError(t *testing.T) {
+	client := NewPluginClient(nil)
+
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{MetricName: "depth"})
+
+	if !IsInvalidMetricRequestError(err) {
+		t.Errorf("error after processing got: %v, want InvalidMetricRequestError", err)
+	}
+}
+
+func TestPluginClientWithoutRegistryGetError(t *testing.T) {
+	client := NewPluginClient(nil)
+
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{Plugin: PluginDefinition{Name: "queue"}})
+
+	if err == nil {
+		t.Errorf("no error after processing got: %v, want error", nil)
+	}
+}
+
+type fakeMetricSource struct {
+	value   float64
+	request *plugin.MetricRequest
+}
+
+func (f *fakeMetricSource) GetMetric(ctx context.Context, request *plugin.MetricRequest) (*plugin.MetricResponse, error) {
+	f.request = request
+	return &plugin.MetricResponse{Value: f.value}, nil
+}
@@ -0,0 +1,292 @@
+package externalmetrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
+	"github.com/golang/glog"
+)
+
+const (
+	PredictionLinear      string = "linear"
+	PredictionHoltWinters string = "holtwinters"
+
+	defaultPredictionHistory  = 6 * time.Hour
+	defaultPredictionInterval = 5 * time.Minute
+
+	// smoothing factors for the level, trend and season of holt winters
+	holtWintersAlpha = 0.5
+	holtWintersBeta  = 0.1
+	holtWintersGamma = 0.1
+)
+
+// PredictionDefinition describes how to forecast an Azure Monitor metric.
+// Durations use the go duration format and are parsed when the metric is requested.
+type PredictionDefinition struct {
+	History      string
+	Interval     string
+	Horizon      string
+	Method       string
+	SeasonLength string
+}
+
+type prediction struct {
+	history      time.Duration
+	interval     time.Duration
+	horizon      time.Duration
+	method       string
+	seasonLength time.Duration
+}
+
+type predictiveClient struct {
+	client                InsightsMonitorClient
+	DefaultSubscriptionID string
+	now                   func() time.Time
+}
+
+// NewPredictiveClient creates a client that projects the value of an Azure Monitor metric from its history
+func NewPredictiveClient(defaultsubscriptionID string, credentialSource credentials.Source, endpoints *MonitorEndpoints, apiVersion string) AzureExternalMetricClient {
+	return &predictiveClient{
+		client:                endpoints.newClient(defaultsubscriptionID, credentialSource, apiVersion),
+		DefaultSubscriptionID: defaultsubscriptionID,
+		now:                   time.Now,
+	}
+}
+
+// GetAzureMetric reads the history of the metric from Azure Monitor and returns the forecast value
+func (c *predictiveClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	err := azMetricRequest.Validate()
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	p, err := azMetricRequest.Prediction.parse()
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	end := c.now().UTC()
+	timespan := fmt.Sprintf("%s/%s", end.Add(-p.history).Format(time.RFC3339), end.Format(time.RFC3339))
+	interval := iso8601Duration(p.interval)
+
+	metricResourceURI := azMetricRequest.MetricResourceURI()
+	glog.V(2).Infof("resource uri: %s, history: %s, interval: %s", metricResourceURI, timespan, interval)
+
+	raw := &bytes.Buffer{}
+	metricResult, err := c.client.List(apiVersionContext(withRawResponse(context.Background(), raw), azMetricRequest.MonitorAPIVersion), metricResourceURI,
+		timespan, &interval,
+		azMetricRequest.MetricName, azMetricRequest.Aggregation, nil,
+		"", azMetricRequest.Filter, "", azMetricRequest.MetricNamespace)
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+
+	series := seriesValues(metricResult, azMetricRequest.Aggregation)
+	if len(series) == 0 {
+		return AzureExternalMetricResponse{}, fmt.Errorf("no history found for metric %s", azMetricRequest.MetricName)
+	}
+
+	steps := math.Ceil(float64(p.horizon) / float64(p.interval))
+	var forecast float64
+	switch p.method {
+	case PredictionHoltWinters:
+		forecast = holtWintersForecast(series, int(p.seasonLength/p.interval), steps)
+	default:
+		forecast = linearForecast(series, steps)
+	}
+
+	// metrics such as message counts can not be negative
+	forecast = math.Max(forecast, 0)
+
+	glog.V(2).Infof("forecast metric value %s ahead from %d points: %f", p.horizon, len(series), forecast)
+
+	return AzureExternalMetricResponse{
+		Total: forecast,
+		Unit:  metricUnit(metricResult),
+		Raw:   rawBodies(raw),
+	}, nil
+}
+
+func (d PredictionDefinition) parse() (prediction, error) {
+	p := prediction{
+		history:  defaultPredictionHistory,
+		interval: defaultPredictionInterval,
+		method:   strings.ToLower(d.Method),
+	}
+
+	var err error
+	if d.History != "" {
+		if p.history, err = time.ParseDuration(d.History); err != nil {
+			return p, InvalidMetricRequestError{err: fmt.Sprintf("invalid prediction history '%s'", d.History)}
+		}
+	}
+	if d.Interval != "" {
+		if p.interval, err = time.ParseDuration(d.Interval); err != nil {
+			return p, InvalidMetricRequestError{err: fmt.Sprintf("invalid prediction interval '%s'", d.Interval)}
+		}
+	}
+	if p.horizon, err = time.ParseDuration(d.Horizon); err != nil {
+		return p, InvalidMetricRequestError{err: fmt.Sprintf("invalid prediction horizon '%s'", d.Horizon)}
+	}
+	if d.SeasonLength != "" {
+		if p.seasonLength, err = time.ParseDuration(d.SeasonLength); err != nil {
+			return p, InvalidMetricRequestError{err: fmt.Sprintf("invalid prediction season length '%s'", d.SeasonLength)}
+		}
+	}
+
+	if p.method == "" {
+		p.method = PredictionLinear
+	}
+	if p.method != PredictionLinear && p.method != PredictionHoltWinters {
+		return p, InvalidMetricRequestError{err: fmt.Sprintf("prediction method must be %s or %s", PredictionLinear, PredictionHoltWinters)}
+	}
+	if p.interval < time.Minute || p.interval%time.Minute != 0 || p.history < 2*p.interval {
+		return p, InvalidMetricRequestError{err: "prediction interval must be whole minutes and history at least two intervals"}
+	}
+	if p.horizon < 0 {
+		return p, InvalidMetricRequestError{err: "prediction horizon can not be negative"}
+	}
+
+	return p, nil
+}
+
+// seriesValues returns the values of the first time series for the aggregation, skipping gaps
+func seriesValues(metricResult insights.Response, aggregation string) []float64 {
+	if metricResult.Value == nil || len(*metricResult.Value) == 0 {
+		return []float64{}
+	}
+
+	timeseries := (*metricResult.Value)[0].Timeseries
+	if timeseries == nil || len(*timeseries) == 0 {
+		return []float64{}
+	}
+
+	return timeseriesValues((*timeseries)[0], aggregation)
+}
+
+// timeseriesValues returns the values of a time series for the aggregation, skipping gaps
+func timeseriesValues(timeseries insights.TimeSeriesElement, aggregation string) []float64 {
+	values := []float64{}
+	if timeseries.Data == nil {
+		return values
+	}
+
+	for _, point := range *timeseries.Data {
+		if value := pointValue(point, aggregation); value != nil {
+			values = append(values, *value)
+		}
+	}
+
+	return values
+}
+
+// pointValue returns the value of the aggregation at a point of a time series, or nil for a gap
+func pointValue(point insights.MetricValue, aggregation string) *float64 {
+	switch strings.ToLower(aggregation) {
+	case "average":
+		return point.Average
+	case "minimum":
+		return point.Minimum
+	case "maximum":
+		return point.Maximum
+	case "count":
+		if point.Count != nil {
+			count := float64(*point.Count)
+			return &count
+		}
+		return nil
+	default:
+		return point.Total
+	}
+}
+
+// linearForecast fits a least squares line to the series and projects it steps past the last point
+func linearForecast(series []float64, steps float64) float64 {
+	n := float64(len(series))
+	if len(series) < 2 {
+		return series[len(series)-1]
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range series {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+
+	slope := (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+	intercept := (sumY - slope*sumX) / n
+
+	return intercept + slope*(n-1+steps)
+}
+
+// holtWintersForecast uses additive triple exponential smoothing when there are at least
+// two seasons of history, otherwise double exponential smoothing of the level and trend
+func holtWintersForecast(series []float64, seasonLength int, steps float64) float64 {
+	n := len(series)
+	if n < 2 {
+		return series[n-1]
+	}
+
+	if seasonLength < 2 || n < 2*seasonLength {
+		level, trend := series[0], series[1]-series[0]
+		for _, y := range series[1:] {
+			lastLevel := level
+			level = holtWintersAlpha*y + (1-holtWintersAlpha)*(level+trend)
+			trend = holtWintersBeta*(level-lastLevel) + (1-holtWintersBeta)*trend
+		}
+		return level + steps*trend
+	}
+
+	m := seasonLength
+	level := mean(series[:m])
+	trend := (mean(series[m:2*m]) - level) / float64(m)
+	seasonal := make([]float64, m)
+	for i := 0; i < m; i++ {
+		seasonal[i] = series[i] - level
+	}
+
+	for t := m; t < n; t++ {
+		y := series[t]
+		lastLevel := level
+		level = holtWintersAlpha*(y-seasonal[t%m]) + (1-holtWintersAlpha)*(level+trend)
+		trend = holtWintersBeta*(level-lastLevel) + (1-holtWintersBeta)*trend
+		seasonal[t%m] = holtWintersGamma*(y-level) + (1-holtWintersGamma)*seasonal[t%m]
+	}
+
+	return level + steps*trend + seasonal[(n-1+int(steps))%m]
+}
+
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// iso8601Duration formats the interval the way Azure Monitor expects, such as PT5M or P1D
+func iso8601Duration(d time.Duration) string {
+	day := 24 * time.Hour
+	if d%day == 0 {
+		return fmt.Sprintf("P%dD", d/day)
+	}
+
+	result := "PT"
+	if hours := d / time.Hour; hours > 0 {
+		result += fmt.Sprintf("%dH", hours)
+	}
+	if minutes := (d % time.Hour) / time.Minute; minutes > 0 {
+		result += fmt.Sprintf("%dM", minutes)
+	}
+	return result
+}
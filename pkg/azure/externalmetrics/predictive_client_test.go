@@ -0,0 +1,162 @@
+package externalmetrics
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
+)
+
+func TestLinearForecast(t *testing.T) {
+	series := []float64{10, 12, 14, 16, 18}
+
+	got := linearForecast(series, 3)
+
+	if math.Abs(got-24) > 0.0001 {
+		t.Errorf("linearForecast() = %v, want %v", got, 24)
+	}
+}
+
+func TestHoltWintersForecastFollowsTrendWithoutSeason(t *testing.T) {
+	series := []float64{10, 12, 14, 16, 18, 20, 22, 24}
+
+	got := holtWintersForecast(series, 0, 2)
+
+	if math.Abs(got-28) > 0.5 {
+		t.Errorf("holtWintersForecast() = %v, want about %v", got, 28)
+	}
+}
+
+func TestHoltWintersForecastFollowsSeason(t *testing.T) {
+	// four seasons of a low, rising, peak, falling pattern
+	season := []float64{10, 20, 40, 20}
+	series := []float64{}
+	for i := 0; i < 4; i++ {
+		series = append(series, season...)
+	}
+
+	// the next peak is three steps after the last point
+	got := holtWintersForecast(series, len(season), 3)
+
+	if math.Abs(got-40) > 2 {
+		t.Errorf("holtWintersForecast() = %v, want about %v", got, 40)
+	}
+}
+
+func TestIso8601Duration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{5 * time.Minute, "PT5M"},
+		{time.Hour, "PT1H"},
+		{90 * time.Minute, "PT1H30M"},
+		{24 * time.Hour, "P1D"},
+	}
+	for _, tt := range tests {
+		if got := iso8601Duration(tt.duration); got != tt.want {
+			t.Errorf("iso8601Duration(%v) = %v, want %v", tt.duration, got, tt.want)
+		}
+	}
+}
+
+func TestPredictionInvalidDefinitionGetError(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition PredictionDefinition
+	}{
+		{name: "missing horizon", definition: PredictionDefinition{}},
+		{name: "unknown method", definition: PredictionDefinition{Horizon: "10m", Method: "magic"}},
+		{name: "interval too small", definition: PredictionDefinition{Horizon: "10m", Interval: "30s"}},
+		{name: "history too short", definition: PredictionDefinition{Horizon: "10m", History: "5m", Interval: "5m"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.definition.parse()
+			if !IsInvalidMetricRequestError(err) {
+				t.Errorf("parse() error = %v, want InvalidMetricRequestError", err)
+			}
+		})
+	}
+}
+
+func TestPredictiveClientRequestsHistory(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2019-03-04T10:00:00Z")
+	fake := &recordingMonitorClient{result: makeAzureMonitorSeries([]float64{10, 20, 30, 40})}
+	client := predictiveClient{client: fake, now: func() time.Time { return now }}
+
+	request := newAzureMonitorMetricRequest()
+	request.Aggregation = "Total"
+	request.Prediction = PredictionDefinition{History: "1h", Interval: "15m", Horizon: "30m"}
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if fake.timespan != "2019-03-04T09:00:00Z/2019-03-04T10:00:00Z" {
+		t.Errorf("timespan = %v, want last hour", fake.timespan)
+	}
+
+	if fake.interval != "PT15M" {
+		t.Errorf("interval = %v, want PT15M", fake.interval)
+	}
+
+	if math.Abs(metricResponse.Total-60) > 0.0001 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 60)
+	}
+}
+
+func TestPredictiveClientDoesNotForecastNegativeValues(t *testing.T) {
+	fake := &recordingMonitorClient{result: makeAzureMonitorSeries([]float64{30, 20, 10})}
+	client := predictiveClient{client: fake, now: time.Now}
+
+	request := newAzureMonitorMetricRequest()
+	request.Prediction = PredictionDefinition{Horizon: "1h"}
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if metricResponse.Total != 0 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 0)
+	}
+}
+
+func makeAzureMonitorSeries(values []float64) insights.Response {
+	metricValues := []insights.MetricValue{}
+	for i := range values {
+		metricValues = append(metricValues, insights.MetricValue{Total: &values[i]})
+	}
+
+	timeseries := []insights.TimeSeriesElement{{Data: &metricValues}}
+	metrics := []insights.Metric{{Timeseries: &timeseries}}
+	return insights.Response{Value: &metrics}
+}
+
+type recordingMonitorClient struct {
+	result      insights.Response
+	timespan    string
+	interval    string
+	resourceURI string
+	metricnames string
+	aggregation string
+	filter      string
+	namespace   string
+}
+
+func (f *recordingMonitorClient) List(ctx context.Context, resourceURI string, timespan string, interval *string, metricnames string, aggregation string, top *int32, orderby string, filter string, resultType insights.ResultType, metricnamespace string) (result insights.Response, err error) {
+	f.timespan = timespan
+	f.resourceURI = resourceURI
+	f.metricnames = metricnames
+	f.aggregation = aggregation
+	f.filter = filter
+	f.namespace = metricnamespace
+	if interval != nil {
+		f.interval = *interval
+	}
+	return f.result, nil
+}
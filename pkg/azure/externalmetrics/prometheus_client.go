@@ -0,0 +1,166 @@
+package externalmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+// PrometheusDefinition is a PromQL instant query of an Azure Monitor workspace whose result is a
+// single sample
+type PrometheusDefinition struct {
+	// Endpoint is the query endpoint of the workspace, such as
+	// https://<workspace>.<region>.prometheus.monitor.azure.com
+	Endpoint string
+	Query    string
+}
+
+type prometheusClient struct {
+	credentials credentials.Source
+	client      *http.Client
+	resource    string
+	now         func() time.Time
+}
+
+// NewPrometheusClient creates a client that serves the result of PromQL queries of Azure Monitor
+// workspaces, requested from the query endpoint of the workspace with tokens for the resource of
+// the query endpoints of the cloud
+func NewPrometheusClient(credentialSource credentials.Source, prometheusResource string) AzureExternalMetricClient {
+	return &prometheusClient{
+		credentials: credentialSource,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		resource:    prometheusResource,
+		now:         time.Now,
+	}
+}
+
+// prometheusResponse is the response of the Prometheus query api, whose result is a vector of
+// samples or a scalar depending on the query
+type prometheusResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+// prometheusSample is a sample of a vector, whose value is a timestamp and the value as a string
+type prometheusSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`
+}
+
+func (c *prometheusClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	query := azMetricRequest.Prometheus
+	endpoint, err := url.Parse(query.Endpoint)
+	if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" || strings.Trim(endpoint.Path, "/") != "" || endpoint.RawQuery != "" {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "prometheus endpoint must be the https query endpoint of the azure monitor workspace"}
+	}
+	if strings.TrimSpace(query.Query) == "" {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "prometheus query is required"}
+	}
+
+	form := url.Values{}
+	form.Set("query", query.Query)
+	form.Set("time", strconv.FormatInt(c.now().Unix(), 10))
+	req, err := http.NewRequest("POST", fmt.Sprintf("https://%s/api/v1/query", endpoint.Host), strings.NewReader(form.Encode()))
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	authorizer, err := c.credentials.Authorizer(c.resource)
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+	if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+
+	glog.V(2).Infof("querying prometheus endpoint %s", endpoint.Host)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxQueryResponseSize))
+	if err != nil {
+		return AzureExternalMetricResponse{}, fmt.Errorf("unable to read result of prometheus query: %v", err)
+	}
+
+	var result prometheusResponse
+	if err := json.Unmarshal(responseBody, &result); err != nil || result.Status == "" {
+		return AzureExternalMetricResponse{}, fmt.Errorf("prometheus query returned status %d: %s", resp.StatusCode, redact.String(string(responseBody)))
+	}
+	if result.Status != "success" {
+		if result.ErrorType == "bad_data" {
+			return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: fmt.Sprintf("prometheus query is invalid: %s", result.Error)}
+		}
+		return AzureExternalMetricResponse{}, fmt.Errorf("prometheus query returned status %d: %s: %s", resp.StatusCode, result.ErrorType, result.Error)
+	}
+
+	value, err := prometheusValue(result.Data.ResultType, result.Data.Result)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	glog.V(4).Infof("prometheus query of %s returned %f", endpoint.Host, value)
+	return AzureExternalMetricResponse{
+		Total: value,
+		Raw:   []string{string(responseBody)},
+	}, nil
+}
+
+// prometheusValue returns the value of a scalar result, or of the single sample of a vector, or 0
+// when the vector is empty such as a rate of a series that doesn't exist yet
+func prometheusValue(resultType string, result json.RawMessage) (float64, error) {
+	var sample []interface{}
+	switch resultType {
+	case "scalar":
+		if err := json.Unmarshal(result, &sample); err != nil {
+			return 0, fmt.Errorf("unable to parse result of prometheus query: %v", err)
+		}
+	case "vector":
+		var samples []prometheusSample
+		if err := json.Unmarshal(result, &samples); err != nil {
+			return 0, fmt.Errorf("unable to parse result of prometheus query: %v", err)
+		}
+		if len(samples) == 0 {
+			return 0, nil
+		}
+		if len(samples) > 1 {
+			return 0, fmt.Errorf("prometheus query returned %d series, aggregate the result to a single series such as with sum()", len(samples))
+		}
+		sample = samples[0].Value
+	default:
+		return 0, InvalidMetricRequestError{err: fmt.Sprintf("prometheus query returned a %s, use an instant vector or scalar query", resultType)}
+	}
+
+	if len(sample) != 2 {
+		return 0, fmt.Errorf("prometheus query returned a sample without a value")
+	}
+	text, ok := sample[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("prometheus query returned %v, not a number", sample[1])
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("prometheus query returned '%s', not a number", text)
+	}
+	return value, nil
+}
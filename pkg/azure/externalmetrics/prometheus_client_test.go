@@ -0,0 +1,113 @@
+package externalmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPrometheusReturnsSampleOfQuery(t *testing.T) {
+	path, query, queryTime := "", "", ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		query, queryTime = r.FormValue("query"), r.FormValue("time")
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1551697200,"12.5"]}]}}`)
+	}))
+	defer server.Close()
+
+	credentials := &resourceCredentialSource{}
+	now := time.Date(2019, 3, 4, 11, 0, 0, 0, time.UTC)
+	client := &prometheusClient{credentials: credentials, client: server.Client(), resource: "https://prometheus.monitor.azure.com", now: func() time.Time { return now }}
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type: Prometheus,
+		Prometheus: PrometheusDefinition{
+			Endpoint: server.URL,
+			Query:    `sum(rate(http_requests_total{job="orders"}[5m]))`,
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 12.5 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 12.5)
+	}
+	if path != "/api/v1/query" || !strings.HasPrefix(query, "sum(rate(") || queryTime != "1551697200" {
+		t.Errorf("request = %v %v %v, want instant query at now", path, query, queryTime)
+	}
+	if credentials.resource != "https://prometheus.monitor.azure.com" {
+		t.Errorf("token resource = %v, want %v", credentials.resource, "https://prometheus.monitor.azure.com")
+	}
+}
+
+func TestPrometheusValues(t *testing.T) {
+	var tests = []struct {
+		name       string
+		resultType string
+		result     string
+		want       float64
+		wantErr    string
+	}{
+		{name: "scalar", resultType: "scalar", result: `[1551697200,"3"]`, want: 3},
+		{name: "empty vector", resultType: "vector", result: `[]`, want: 0},
+		{name: "several series", resultType: "vector", result: `[{"metric":{"pod":"a"},"value":[1551697200,"1"]},{"metric":{"pod":"b"},"value":[1551697200,"2"]}]`, wantErr: "returned 2 series"},
+		{name: "range vector", resultType: "matrix", result: `[]`, wantErr: "matrix"},
+		{name: "not a number", resultType: "vector", result: `[{"metric":{},"value":[1551697200,"NaN"]}]`, wantErr: "not a number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := prometheusValue(tt.resultType, json.RawMessage(tt.result))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("prometheusValue() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("prometheusValue() error = %v, want nil", err)
+			}
+			if value != tt.want {
+				t.Errorf("prometheusValue() = %v, want %v", value, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrometheusBadQueryIsInvalidRequest(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"status":"error","errorType":"bad_data","error":"1:5: parse error: unexpected end of input"}`)
+	}))
+	defer server.Close()
+
+	client := &prometheusClient{credentials: nullCredentialSource{}, client: server.Client(), now: time.Now}
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Prometheus: PrometheusDefinition{Endpoint: server.URL, Query: "sum("},
+	})
+
+	if !IsInvalidMetricRequestError(err) || !strings.Contains(err.Error(), "parse error") {
+		t.Errorf("error after processing got: %v, want InvalidMetricRequestError with the parse error", err)
+	}
+}
+
+func TestPrometheusInvalidRequestsGetError(t *testing.T) {
+	var tests = []PrometheusDefinition{
+		{Endpoint: "", Query: "up"},
+		{Endpoint: "http://orders-ws.westeurope.prometheus.monitor.azure.com", Query: "up"},
+		{Endpoint: "https://orders-ws.westeurope.prometheus.monitor.azure.com/api/v1/query", Query: "up"},
+		{Endpoint: "https://orders-ws.westeurope.prometheus.monitor.azure.com", Query: " "},
+	}
+
+	client := NewPrometheusClient(fakeCredentialSource{}, "https://prometheus.monitor.azure.com")
+	for _, query := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{Prometheus: query})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%+v) got %v, want InvalidMetricRequestError", query, err)
+		}
+	}
+}
@@ -7,4 +7,33 @@ type AzureExternalMetricClientProvider interface {
 const (
 	Monitor                string = "azuremonitor"
 	ServiceBusSubscription string = "servicebussubscription"
+	ServiceBusQueue        string = "servicebus"
+	Schedule               string = "schedule"
+	Predictive             string = "predictive"
+	SLOBurnRate            string = "sloburnrate"
+	Plugin                 string = "plugin"
+	Webhook                string = "webhook"
+	StorageQueueMessageAge string = "storagequeuemessageage"
+	StorageQueueLength     string = "storagequeue"
+	EventHub               string = "eventhub"
+	FileShare              string = "fileshare"
+	ActivityLog            string = "activitylog"
+	ContainerApp           string = "containerapp"
+	LogicApp               string = "logicapp"
+	LogAnalytics           string = "loganalytics"
+	DataExplorer           string = "dataexplorer"
+	CosmosDB               string = "cosmosdb"
+	Prometheus             string = "prometheus"
+	IoTHub                 string = "iothub"
+	Batch                  string = "batch"
+	BlobCount              string = "blobcount"
+	EventGrid              string = "eventgrid"
+	Redis                  string = "redis"
+	AzureDevOps            string = "azuredevops"
+	Cost                   string = "cost"
+	Quota                  string = "quota"
+	Combined               string = "combined"
+	Ratio                  string = "ratio"
+	Seasonal               string = "seasonal"
+	Heartbeat              string = "heartbeat"
 )
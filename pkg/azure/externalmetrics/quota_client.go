@@ -0,0 +1,177 @@
+package externalmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const (
+	// QuotaRemaining serves the quota left, the limit less the usage
+	QuotaRemaining = "remaining"
+	// QuotaUsed serves the usage of the quota
+	QuotaUsed = "used"
+	// QuotaLimit serves the limit of the quota
+	QuotaLimit = "limit"
+	// QuotaPercent serves the usage as a percentage of the limit
+	QuotaPercent = "percent"
+
+	// maxQuotaResponseSize limits how much of a response is read
+	maxQuotaResponseSize = 1024 * 1024
+)
+
+// quotaAPIVersions are the api versions of the usages of the resource providers whose quotas are
+// served, such as Microsoft.Compute for vCPUs of a VM family
+var quotaAPIVersions = map[string]string{
+	"microsoft.compute": "2023-07-01",
+	"microsoft.network": "2023-05-01",
+	"microsoft.storage": "2023-01-01",
+}
+
+// QuotaDefinition names the quota of a resource provider in a region of the subscription whose usage
+// is served
+type QuotaDefinition struct {
+	// Provider is Microsoft.Compute unless set, Microsoft.Network or Microsoft.Storage
+	Provider string
+	Location string
+	// Name of the quota, such as cores or standardDSv3Family
+	Name string
+	// Metric is remaining, used, limit or percent, remaining unless set
+	Metric string
+}
+
+type quotaClient struct {
+	defaultSubscriptionID string
+	credentials           credentials.Source
+	client                *http.Client
+	resourceManager       string
+}
+
+// NewQuotaClient creates a client that serves the regional quotas of subscriptions from the usages
+// of their resource providers, through the resource manager endpoint
+func NewQuotaClient(defaultsubscriptionID string, credentialSource credentials.Source, resourceManager string) AzureExternalMetricClient {
+	return &quotaClient{
+		defaultSubscriptionID: defaultsubscriptionID,
+		credentials:           credentialSource,
+		client:                &http.Client{Timeout: 30 * time.Second},
+		resourceManager:       resourceManager,
+	}
+}
+
+// quotaUsages are the usages of the quotas of a resource provider in a region
+type quotaUsages struct {
+	Value []struct {
+		CurrentValue float64 `json:"currentValue"`
+		Limit        float64 `json:"limit"`
+		Name         struct {
+			Value          string `json:"value"`
+			LocalizedValue string `json:"localizedValue"`
+		} `json:"name"`
+	} `json:"value"`
+}
+
+func (c *quotaClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	quota := azMetricRequest.Quota
+	subscriptionID := azMetricRequest.SubscriptionID
+	if subscriptionID == "" {
+		subscriptionID = c.defaultSubscriptionID
+	}
+	if subscriptionID == "" {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "subscriptionID is required for quota metrics"}
+	}
+	provider := quota.Provider
+	if provider == "" {
+		provider = "Microsoft.Compute"
+	}
+	apiVersion, found := quotaAPIVersions[strings.ToLower(provider)]
+	if !found {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "quota provider must be one of Microsoft.Compute, Microsoft.Network, Microsoft.Storage"}
+	}
+	if quota.Location == "" || quota.Name == "" {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "quota location and name are required"}
+	}
+	metric := strings.ToLower(quota.Metric)
+	if metric == "" {
+		metric = QuotaRemaining
+	}
+	if metric != QuotaRemaining && metric != QuotaUsed && metric != QuotaLimit && metric != QuotaPercent {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: fmt.Sprintf("quota metric must be one of %s, %s, %s, %s", QuotaLimit, QuotaPercent, QuotaRemaining, QuotaUsed)}
+	}
+
+	usagesURL := fmt.Sprintf("%s/subscriptions/%s/providers/%s/locations/%s/usages?api-version=%s", c.resourceManager, subscriptionID, provider, url.PathEscape(quota.Location), apiVersion)
+	var usages quotaUsages
+	if err := c.get(usagesURL, &usages); err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	for _, usage := range usages.Value {
+		if !strings.EqualFold(usage.Name.Value, quota.Name) {
+			continue
+		}
+
+		value := usage.Limit - usage.CurrentValue
+		unit := ""
+		switch metric {
+		case QuotaUsed:
+			value = usage.CurrentValue
+		case QuotaLimit:
+			value = usage.Limit
+		case QuotaPercent:
+			value, unit = 0, UnitPercent
+			if usage.Limit > 0 {
+				value = usage.CurrentValue / usage.Limit * 100
+			}
+		}
+		glog.V(4).Infof("quota %s of %s in %s is %v of %v, serving %s %v", quota.Name, provider, quota.Location, usage.CurrentValue, usage.Limit, metric, value)
+		return AzureExternalMetricResponse{
+			Total: value,
+			Unit:  unit,
+			Raw:   []string{fmt.Sprintf("%s: %v of %v", usage.Name.LocalizedValue, usage.CurrentValue, usage.Limit)},
+		}, nil
+	}
+	return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: fmt.Sprintf("quota %s of %s not found in %s", quota.Name, provider, quota.Location)}
+}
+
+// get requests the url with a token of the adapter's credentials and parses the response into result
+func (c *quotaClient) get(requestURL string, result interface{}) error {
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return redact.Error(err)
+	}
+	authorizer, err := c.credentials.Authorizer("")
+	if err != nil {
+		return redact.Error(err)
+	}
+	if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+		return redact.Error(err)
+	}
+
+	glog.V(2).Infof("requesting %s", req.URL.Path)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxQuotaResponseSize))
+	if err != nil {
+		return fmt.Errorf("unable to read %s: %v", req.URL.Path, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("quota request %s returned status %d: %s", req.URL.Path, resp.StatusCode, redact.String(string(body)))
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("unable to parse %s: %v", req.URL.Path, err)
+	}
+	return nil
+}
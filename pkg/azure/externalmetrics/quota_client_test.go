@@ -0,0 +1,100 @@
+package externalmetrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testComputeUsages = `{"value":[
+	{"currentValue":48,"limit":100,"name":{"value":"cores","localizedValue":"Total Regional vCPUs"}},
+	{"currentValue":24,"limit":32,"name":{"value":"standardDSv3Family","localizedValue":"Standard DSv3 Family vCPUs"}}
+]}`
+
+func TestQuotaRemainingOfVMFamily(t *testing.T) {
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Write([]byte(testComputeUsages))
+	}))
+	defer server.Close()
+
+	client := NewQuotaClient("default-subscription", nullCredentialSource{}, server.URL)
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type:  Quota,
+		Quota: QuotaDefinition{Location: "westeurope", Name: "standarddsv3family"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 8 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 8)
+	}
+	if path != "/subscriptions/default-subscription/providers/Microsoft.Compute/locations/westeurope/usages" {
+		t.Errorf("path = %v, want the compute usages of the region", path)
+	}
+}
+
+func TestQuotaMetrics(t *testing.T) {
+	var tests = []struct {
+		metric string
+		want   float64
+		unit   string
+	}{
+		{"used", 48, ""},
+		{"limit", 100, ""},
+		{"percent", 48, UnitPercent},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testComputeUsages))
+	}))
+	defer server.Close()
+
+	client := NewQuotaClient("sub", nullCredentialSource{}, server.URL)
+	for _, tt := range tests {
+		metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+			Quota: QuotaDefinition{Location: "westeurope", Name: "cores", Metric: tt.metric},
+		})
+		if err != nil {
+			t.Fatalf("%s: error after processing got: %v, want nil", tt.metric, err)
+		}
+		if metricResponse.Total != tt.want || metricResponse.Unit != tt.unit {
+			t.Errorf("%s: metricResponse = %v %v, want = %v %v", tt.metric, metricResponse.Total, metricResponse.Unit, tt.want, tt.unit)
+		}
+	}
+}
+
+func TestQuotaInvalidRequestsGetError(t *testing.T) {
+	var tests = []QuotaDefinition{
+		{Name: "cores"},
+		{Location: "westeurope"},
+		{Provider: "Microsoft.Sql", Location: "westeurope", Name: "cores"},
+		{Location: "westeurope", Name: "cores", Metric: "free"},
+	}
+
+	client := NewQuotaClient("sub", nullCredentialSource{}, "https://management.azure.com")
+	for _, quota := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{Quota: quota})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%+v) got %v, want InvalidMetricRequestError", quota, err)
+		}
+	}
+}
+
+func TestQuotaNotFoundGetsError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(testComputeUsages))
+	}))
+	defer server.Close()
+
+	client := NewQuotaClient("sub", nullCredentialSource{}, server.URL)
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Quota: QuotaDefinition{Location: "westeurope", Name: "standardNCv3Family"},
+	})
+
+	if !IsInvalidMetricRequestError(err) {
+		t.Errorf("error after processing got: %v, want InvalidMetricRequestError", err)
+	}
+}
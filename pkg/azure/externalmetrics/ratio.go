@@ -0,0 +1,47 @@
+package externalmetrics
+
+// RatioDefinition is the resource the Azure Monitor metric of a ratio metric is divided by.  The
+// fields left empty are those of the resource of the metric.  The filter of the metric is kept
+// on the denominator unless ReplaceFilter is set, when Filter replaces it.
+type RatioDefinition struct {
+	SubscriptionID            string
+	ResourceGroup             string
+	ResourceProviderNamespace string
+	ResourceType              string
+	ResourceName              string
+	Filter                    string
+	ReplaceFilter             bool
+}
+
+// Numerator returns the Azure Monitor query of the metric on its own resource.  The alert guard of
+// the metric applies to the ratio rather than to each query.
+func (d RatioDefinition) Numerator(request AzureExternalMetricRequest) AzureExternalMetricRequest {
+	request.Type = Monitor
+	request.Ratio = RatioDefinition{}
+	request.Alert = AlertDefinition{}
+	return request
+}
+
+// Denominator returns the Azure Monitor query of the same metric on the denominator resource
+func (d RatioDefinition) Denominator(request AzureExternalMetricRequest) AzureExternalMetricRequest {
+	request = d.Numerator(request)
+	if d.SubscriptionID != "" {
+		request.SubscriptionID = d.SubscriptionID
+	}
+	if d.ResourceGroup != "" {
+		request.ResourceGroup = d.ResourceGroup
+	}
+	if d.ResourceProviderNamespace != "" {
+		request.ResourceProviderNamespace = d.ResourceProviderNamespace
+	}
+	if d.ResourceType != "" {
+		request.ResourceType = d.ResourceType
+	}
+	if d.ResourceName != "" {
+		request.ResourceName = d.ResourceName
+	}
+	if d.ReplaceFilter {
+		request.Filter = d.Filter
+	}
+	return request
+}
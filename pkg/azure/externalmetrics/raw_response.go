@@ -0,0 +1,50 @@
+package externalmetrics
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+type rawResponseKey struct{}
+
+// withRawResponse returns a context whose Azure response body is copied into the buffer
+func withRawResponse(ctx context.Context, raw *bytes.Buffer) context.Context {
+	return context.WithValue(ctx, rawResponseKey{}, raw)
+}
+
+// rawBody copies the body it reads into a buffer
+type rawBody struct {
+	io.Reader
+	io.Closer
+}
+
+// copyRawResponse copies the body of a response into the buffer of its request context, so the
+// body can be compared with the value derived from it after the sdk has unmarshalled it.  The
+// inspector runs both when the response is received and when it is unmarshalled, so the body is
+// only wrapped once.
+func copyRawResponse() autorest.RespondDecorator {
+	return func(r autorest.Responder) autorest.Responder {
+		return autorest.ResponderFunc(func(resp *http.Response) error {
+			if resp != nil && resp.Request != nil && resp.Body != nil {
+				_, copied := resp.Body.(rawBody)
+				raw, ok := resp.Request.Context().Value(rawResponseKey{}).(*bytes.Buffer)
+				if ok && !copied {
+					resp.Body = rawBody{Reader: io.TeeReader(resp.Body, raw), Closer: resp.Body}
+				}
+			}
+			return r.Respond(resp)
+		})
+	}
+}
+
+// rawBodies returns the body copied into the buffer, or nothing when no response was copied
+func rawBodies(raw *bytes.Buffer) []string {
+	if raw.Len() == 0 {
+		return nil
+	}
+	return []string{raw.String()}
+}
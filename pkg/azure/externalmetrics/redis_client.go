@@ -0,0 +1,234 @@
+package externalmetrics
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const (
+	redisResource = "https://redis.azure.com"
+	// redisTLSPort is the tls port of Azure Cache for Redis, used when the host has no port
+	redisTLSPort = "6380"
+	// maxRedisKeyLength limits the key whose length is served
+	maxRedisKeyLength = 1024
+	// maxRedisReplySize limits how much of a reply is read
+	maxRedisReplySize = 64 * 1024
+)
+
+// redisHostSuffixes are the hosts of Azure Cache for Redis in each cloud, the only hosts the key or
+// token of the adapter is sent to
+var redisHostSuffixes = []string{
+	".redis.cache.windows.net",
+	".redisenterprise.cache.azure.net",
+	".redis.cache.chinacloudapi.cn",
+	".redis.cache.usgovcloudapi.net",
+}
+
+// RedisDefinition names the key of an Azure Cache for Redis instance whose length is served, the
+// items of a list or the entries of a stream.  The cache is authenticated with the access key when
+// it is set, which the provider resolves from the secret, or with a token of the adapter's
+// credentials otherwise.
+type RedisDefinition struct {
+	// Host is the host name of the cache, with the port when it isn't 6380
+	Host            string
+	Key             string
+	Database        int
+	AccessKeySecret string
+	AccessKeyKey    string
+	AccessKey       string
+}
+
+type redisClient struct {
+	credentials credentials.Source
+	dial        func(address string, host string) (net.Conn, error)
+}
+
+// NewRedisClient creates a client that serves the length of a list or stream of an Azure Cache for
+// Redis instance, read over tls
+func NewRedisClient(credentialSource credentials.Source) AzureExternalMetricClient {
+	return &redisClient{
+		credentials: credentialSource,
+		dial: func(address string, host string) (net.Conn, error) {
+			return tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", address, &tls.Config{ServerName: host})
+		},
+	}
+}
+
+func (c *redisClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	redis := azMetricRequest.Redis
+	host, port, err := net.SplitHostPort(redis.Host)
+	if err != nil {
+		host, port = redis.Host, redisTLSPort
+	}
+	if !isRedisHost(host) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "redis host must be an azure cache for redis host"}
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "redis port is invalid"}
+	}
+	if redis.Key == "" || len(redis.Key) > maxRedisKeyLength {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "redis key is required"}
+	}
+	if redis.Database < 0 || redis.Database > 15 {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "redis database must be between 0 and 15"}
+	}
+
+	auth := []string{"AUTH", redis.AccessKey}
+	if redis.AccessKey == "" {
+		if auth, err = c.tokenAuth(); err != nil {
+			return AzureExternalMetricResponse{}, err
+		}
+	}
+
+	glog.V(2).Infof("reading key %s of redis %s", redis.Key, host)
+	conn, err := c.dial(net.JoinHostPort(host, port), host)
+	if err != nil {
+		return AzureExternalMetricResponse{}, fmt.Errorf("unable to connect to redis %s: %v", host, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	session := &redisSession{conn: conn, reader: bufio.NewReader(io.LimitReader(conn, maxRedisReplySize))}
+
+	if _, err := session.do(auth...); err != nil {
+		return AzureExternalMetricResponse{}, fmt.Errorf("unable to authenticate to redis %s: %v", host, err)
+	}
+	if redis.Database != 0 {
+		if _, err := session.do("SELECT", strconv.Itoa(redis.Database)); err != nil {
+			return AzureExternalMetricResponse{}, fmt.Errorf("unable to select database %d of redis %s: %v", redis.Database, host, err)
+		}
+	}
+
+	keyType, err := session.do("TYPE", redis.Key)
+	if err != nil {
+		return AzureExternalMetricResponse{}, fmt.Errorf("unable to read the type of key %s of redis %s: %v", redis.Key, host, err)
+	}
+	command := ""
+	switch keyType {
+	case "none":
+		// a missing key is an empty list, as redis removes lists once their last item is popped
+		return AzureExternalMetricResponse{Total: 0, Raw: []string{fmt.Sprintf("key %s does not exist", redis.Key)}}, nil
+	case "list":
+		command = "LLEN"
+	case "stream":
+		command = "XLEN"
+	default:
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: fmt.Sprintf("redis key %s is a %s rather than a list or stream", redis.Key, keyType)}
+	}
+
+	reply, err := session.do(command, redis.Key)
+	if err != nil {
+		return AzureExternalMetricResponse{}, fmt.Errorf("unable to read the length of key %s of redis %s: %v", redis.Key, host, err)
+	}
+	length, err := strconv.ParseInt(reply, 10, 64)
+	if err != nil {
+		return AzureExternalMetricResponse{}, fmt.Errorf("redis %s returned length '%s' of key %s", host, reply, redis.Key)
+	}
+
+	glog.V(4).Infof("%s %s of redis %s has length %d", keyType, redis.Key, host, length)
+	return AzureExternalMetricResponse{
+		Total: float64(length),
+		Raw:   []string{fmt.Sprintf("%s %s has length %d", keyType, redis.Key, length)},
+	}, nil
+}
+
+// tokenAuth returns the AUTH command of a token of the adapter's credentials, whose user name is the
+// object id of the identity the token was issued to
+func (c *redisClient) tokenAuth() ([]string, error) {
+	authorizer, err := c.credentials.Authorizer(redisResource)
+	if err != nil {
+		return nil, redact.Error(err)
+	}
+	req, err := autorest.Prepare(&http.Request{Header: http.Header{}}, authorizer.WithAuthorization())
+	if err != nil {
+		return nil, redact.Error(err)
+	}
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("token for redis is not a jwt")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode token for redis: %v", err)
+	}
+	claims := struct {
+		ObjectID string `json:"oid"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.ObjectID == "" {
+		return nil, fmt.Errorf("token for redis has no object id")
+	}
+	return []string{"AUTH", claims.ObjectID, token}, nil
+}
+
+func isRedisHost(host string) bool {
+	for _, suffix := range redisHostSuffixes {
+		if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// redisSession sends commands to redis and reads their replies
+type redisSession struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// do sends the command and returns its reply, a simple string, integer or bulk string
+func (s *redisSession) do(args ...string) (string, error) {
+	command := fmt.Sprintf("*%d\r\n", len(args))
+	for _, arg := range args {
+		command += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(s.conn, command); err != nil {
+		return "", err
+	}
+
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return "", fmt.Errorf("empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", fmt.Errorf("%s", line[1:])
+	case '$':
+		// -1 is a null reply.  The size is read from the server, so it's checked before it's
+		// allocated: a reply can't be longer than what is read of the connection.
+		size, err := strconv.Atoi(line[1:])
+		if err == nil && size == -1 {
+			return "", nil
+		}
+		if err != nil || size < 0 || size > maxRedisReplySize {
+			return "", fmt.Errorf("invalid bulk reply size '%s'", line[1:])
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(s.reader, value); err != nil {
+			return "", err
+		}
+		return string(value[:size]), nil
+	}
+	return "", fmt.Errorf("unexpected reply '%s'", line)
+}
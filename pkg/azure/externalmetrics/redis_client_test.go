@@ -0,0 +1,203 @@
+package externalmetrics
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/adal"
+)
+
+func TestRedisListLengthWithAccessKey(t *testing.T) {
+	server := &fakeRedis{replies: map[string]string{
+		"AUTH c2VjcmV0":     "+OK\r\n",
+		"SELECT 2":          "+OK\r\n",
+		"TYPE jobs:pending": "+list\r\n",
+		"LLEN jobs:pending": ":12\r\n",
+	}}
+
+	client := &redisClient{credentials: fakeCredentialSource{}, dial: server.dial}
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type:  Redis,
+		Redis: RedisDefinition{Host: "jobs.redis.cache.windows.net", Key: "jobs:pending", Database: 2, AccessKey: "c2VjcmV0"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 12 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 12)
+	}
+	if server.address != "jobs.redis.cache.windows.net:6380" {
+		t.Errorf("address = %v, want the tls port of the cache", server.address)
+	}
+}
+
+func TestRedisStreamLengthWithToken(t *testing.T) {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"oid":"11111111-2222-3333-4444-555555555555"}`))
+	token := "eyJhbGciOiJSUzI1NiJ9." + payload + ".c2lnbmF0dXJl"
+	server := &fakeRedis{replies: map[string]string{
+		"AUTH 11111111-2222-3333-4444-555555555555 " + token: "+OK\r\n",
+		"TYPE events": "$6\r\nstream\r\n",
+		"XLEN events": ":1042\r\n",
+	}}
+
+	credentials := &tokenCredentialSource{token: token}
+	client := &redisClient{credentials: credentials, dial: server.dial}
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Redis: RedisDefinition{Host: "events.redisenterprise.cache.azure.net:10000", Key: "events"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 1042 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 1042)
+	}
+	if credentials.resource != redisResource {
+		t.Errorf("token resource = %v, want %v", credentials.resource, redisResource)
+	}
+}
+
+func TestRedisMissingKeyIsEmpty(t *testing.T) {
+	server := &fakeRedis{replies: map[string]string{
+		"AUTH c2VjcmV0":     "+OK\r\n",
+		"TYPE jobs:pending": "+none\r\n",
+	}}
+
+	client := &redisClient{credentials: fakeCredentialSource{}, dial: server.dial}
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Redis: RedisDefinition{Host: "jobs.redis.cache.windows.net", Key: "jobs:pending", AccessKey: "c2VjcmV0"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 0 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 0)
+	}
+}
+
+func TestRedisInvalidRequestsGetError(t *testing.T) {
+	var tests = []RedisDefinition{
+		{Host: "", Key: "jobs"},
+		{Host: "attacker.example.com", Key: "jobs"},
+		{Host: ".redis.cache.windows.net", Key: "jobs"},
+		{Host: "jobs.redis.cache.windows.net:tls", Key: "jobs"},
+		{Host: "jobs.redis.cache.windows.net"},
+		{Host: "jobs.redis.cache.windows.net", Key: "jobs", Database: 16},
+	}
+
+	client := NewRedisClient(fakeCredentialSource{})
+	for _, redis := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{Redis: redis})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%+v) got %v, want InvalidMetricRequestError", redis, err)
+		}
+	}
+}
+
+func TestRedisHashGetError(t *testing.T) {
+	server := &fakeRedis{replies: map[string]string{
+		"AUTH c2VjcmV0": "+OK\r\n",
+		"TYPE jobs":     "+hash\r\n",
+	}}
+
+	client := &redisClient{credentials: fakeCredentialSource{}, dial: server.dial}
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Redis: RedisDefinition{Host: "jobs.redis.cache.windows.net", Key: "jobs", AccessKey: "c2VjcmV0"},
+	})
+
+	if !IsInvalidMetricRequestError(err) {
+		t.Errorf("error after processing got: %v, want InvalidMetricRequestError", err)
+	}
+}
+
+func TestRedisInvalidBulkSizeGetsError(t *testing.T) {
+	var tests = []string{"$1099511627776\r\n", "$-2\r\n", "$many\r\n"}
+
+	for _, reply := range tests {
+		server := &fakeRedis{replies: map[string]string{
+			"AUTH c2VjcmV0": reply,
+		}}
+
+		client := &redisClient{credentials: fakeCredentialSource{}, dial: server.dial}
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{
+			Redis: RedisDefinition{Host: "jobs.redis.cache.windows.net", Key: "jobs", AccessKey: "c2VjcmV0"},
+		})
+
+		if err == nil || !strings.Contains(err.Error(), "invalid bulk reply size") {
+			t.Errorf("%q: error after processing got: %v, want invalid bulk reply size", reply, err)
+		}
+	}
+}
+
+// fakeRedis answers each command with its reply, or an error for commands it doesn't know
+type fakeRedis struct {
+	replies map[string]string
+	address string
+}
+
+func (r *fakeRedis) dial(address string, host string) (net.Conn, error) {
+	r.address = address
+	client, server := net.Pipe()
+	go func() {
+		defer server.Close()
+		reader := bufio.NewReader(server)
+		for {
+			command, err := readRedisCommand(reader)
+			if err != nil {
+				return
+			}
+			reply, found := r.replies[command]
+			if !found {
+				reply = fmt.Sprintf("-ERR unknown command '%s'\r\n", command)
+			}
+			if _, err := io.WriteString(server, reply); err != nil {
+				return
+			}
+		}
+	}()
+	return client, nil
+}
+
+// readRedisCommand reads a command sent as an array of bulk strings, joined by spaces
+func readRedisCommand(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := []string{}
+	for i := 0; i < count; i++ {
+		if _, err := reader.ReadString('\n'); err != nil {
+			return "", err
+		}
+		arg, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		args = append(args, strings.TrimSuffix(arg, "\r\n"))
+	}
+	return strings.Join(args, " "), nil
+}
+
+type tokenCredentialSource struct {
+	token    string
+	resource string
+}
+
+func (s *tokenCredentialSource) Authorizer(resource string) (autorest.Authorizer, error) {
+	s.resource = resource
+	return autorest.NewBearerAuthorizer(&adal.Token{AccessToken: s.token}), nil
+}
+
+func (s *tokenCredentialSource) Value(name string) string {
+	return ""
+}
@@ -0,0 +1,352 @@
+package externalmetrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const (
+	resourcesAPIVersion     = "2021-04-01"
+	resourceGraphAPIVersion = "2021-03-01"
+	resourcesCacheTTL       = 5 * time.Minute
+	// maxResourceGraphResults limits the resources a Resource Graph query can match
+	maxResourceGraphResults = 1000
+)
+
+// ResourceRef is a resource a metric is queried on, of the resource type of the request
+type ResourceRef struct {
+	ResourceGroup string
+	ResourceName  string
+}
+
+// ResourceLister lists the resources of a type with tags or matched by a Resource Graph query.
+// Resources listed before since are listed again, so the resources of a metric are resolved again
+// once its ExternalMetric changes.
+type ResourceLister interface {
+	// ListResources lists the resources of the type, such as Microsoft.ServiceBus/namespaces, in the
+	// subscription, or in the resource group when it is set, that have all of the tags
+	ListResources(subscriptionID string, resourceGroup string, resourceType string, tags map[string]string, since time.Time) ([]ResourceRef, error)
+	// QueryResources lists the resources of the type in the subscription whose ids are returned by
+	// the Resource Graph query
+	QueryResources(subscriptionID string, resourceType string, query string, since time.Time) ([]ResourceRef, error)
+}
+
+type armResourceLister struct {
+	credentials     credentials.Source
+	client          *http.Client
+	now             func() time.Time
+	resourceManager string
+
+	mu        sync.Mutex
+	resources map[string]listedResources
+	queried   map[string]queriedResources
+	lastPrune time.Time
+}
+
+type queriedResources struct {
+	ids     []string
+	queried time.Time
+}
+
+type listedResources struct {
+	resources []armResource
+	listed    time.Time
+}
+
+type armResource struct {
+	ID   string            `json:"id"`
+	Name string            `json:"name"`
+	Tags map[string]string `json:"tags"`
+}
+
+// NewResourceLister creates a lister that asks Azure Resource Manager for the resources of a type,
+// or Resource Graph for the resources of a query.  The resources of each type and scope, and of each
+// query, are cached for a few minutes, so recreated resources are picked up without listing the
+// resources on every request.  Expired resources are dropped from the cache.
+func NewResourceLister(credentialSource credentials.Source, resourceManager string) ResourceLister {
+	return &armResourceLister{
+		credentials:     credentialSource,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		now:             time.Now,
+		resourceManager: strings.TrimSuffix(resourceManager, "/"),
+		resources:       map[string]listedResources{},
+		queried:         map[string]queriedResources{},
+	}
+}
+
+func (l *armResourceLister) ListResources(subscriptionID string, resourceGroup string, resourceType string, tags map[string]string, since time.Time) ([]ResourceRef, error) {
+	resources, err := l.list(subscriptionID, resourceGroup, resourceType, since)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []ResourceRef{}
+	for _, resource := range resources {
+		if !hasTags(resource.Tags, tags) {
+			continue
+		}
+		refs = append(refs, ResourceRef{ResourceGroup: resourceGroupOfID(resource.ID), ResourceName: resource.Name})
+	}
+	glog.V(4).Infof("found %d of %d %s resources with tags %v", len(refs), len(resources), resourceType, tags)
+	return refs, nil
+}
+
+// list returns the resources of the type in the scope, from the cache unless they have expired or
+// were listed before since
+func (l *armResourceLister) list(subscriptionID string, resourceGroup string, resourceType string, since time.Time) ([]armResource, error) {
+	scope := fmt.Sprintf("/subscriptions/%s", url.PathEscape(subscriptionID))
+	if resourceGroup != "" {
+		scope = fmt.Sprintf("%s/resourceGroups/%s", scope, url.PathEscape(resourceGroup))
+	}
+	key := strings.ToLower(scope + "/" + resourceType)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+	if listed, found := l.resources[key]; found && freshResources(listed.listed, now, since) {
+		return listed.resources, nil
+	}
+
+	authorizer, err := l.credentials.Authorizer("")
+	if err != nil {
+		return nil, redact.Error(err)
+	}
+
+	resources := []armResource{}
+	filter := url.QueryEscape(fmt.Sprintf("resourceType eq '%s'", strings.Replace(resourceType, "'", "''", -1)))
+	next := fmt.Sprintf("%s%s/resources?$filter=%s&api-version=%s", l.resourceManager, scope, filter, resourcesAPIVersion)
+	for next != "" {
+		page, err := l.listPage(next, authorizer)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, page.Value...)
+		next = page.NextLink
+	}
+
+	glog.V(2).Infof("found %d %s resources in %s", len(resources), resourceType, scope)
+	l.resources[key] = listedResources{resources: resources, listed: now}
+	return resources, nil
+}
+
+func (l *armResourceLister) QueryResources(subscriptionID string, resourceType string, query string, since time.Time) ([]ResourceRef, error) {
+	ids, err := l.query(subscriptionID, query, since)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := []ResourceRef{}
+	for _, id := range ids {
+		ref, idType, ok := parseResourceID(id)
+		if !ok || !strings.EqualFold(idType, resourceType) {
+			glog.V(4).Infof("skipping %s returned by resource graph, which is not a %s resource", id, resourceType)
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	glog.V(4).Infof("found %d %s resources of %d returned by resource graph", len(refs), resourceType, len(ids))
+	return refs, nil
+}
+
+// query returns the ids of the resources the query returns in the subscription, from the cache
+// unless they have expired or were queried before since
+func (l *armResourceLister) query(subscriptionID string, query string, since time.Time) ([]string, error) {
+	key := strings.ToLower(subscriptionID) + "/" + query
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+	if queried, found := l.queried[key]; found && freshResources(queried.queried, now, since) {
+		return queried.ids, nil
+	}
+
+	authorizer, err := l.credentials.Authorizer("")
+	if err != nil {
+		return nil, redact.Error(err)
+	}
+
+	body, err := json.Marshal(resourceGraphRequest{
+		Subscriptions: []string{subscriptionID},
+		Query:         query,
+		Options:       resourceGraphOptions{ResultFormat: "objectArray", Top: maxResourceGraphResults},
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", fmt.Sprintf("%s/providers/Microsoft.ResourceGraph/resources?api-version=%s", l.resourceManager, resourceGraphAPIVersion), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if req, err = autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+		return nil, redact.Error(err)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read resource graph results: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to query resource graph, status %d: %s", resp.StatusCode, redact.String(string(respBody)))
+	}
+
+	result := resourceGraphResult{}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("unable to parse resource graph results: %v", err)
+	}
+	if result.SkipToken != "" {
+		return nil, fmt.Errorf("resource graph query returned more than %d resources", maxResourceGraphResults)
+	}
+
+	ids := []string{}
+	for _, row := range result.Data {
+		if row.ID == "" {
+			return nil, fmt.Errorf("resource graph query must return the id of each resource")
+		}
+		ids = append(ids, row.ID)
+	}
+
+	glog.V(2).Infof("resource graph query returned %d resources in subscription %s", len(ids), subscriptionID)
+	l.queried[key] = queriedResources{ids: ids, queried: now}
+	return ids, nil
+}
+
+// freshResources returns true when resources cached at the time can still be served
+func freshResources(cached time.Time, now time.Time, since time.Time) bool {
+	return now.Sub(cached) < resourcesCacheTTL && !cached.Before(since)
+}
+
+// prune drops the resources that have expired, at most once per ttl.  It must be called with the
+// mutex held.
+func (l *armResourceLister) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < resourcesCacheTTL {
+		return
+	}
+	l.lastPrune = now
+
+	for key, listed := range l.resources {
+		if now.Sub(listed.listed) >= resourcesCacheTTL {
+			delete(l.resources, key)
+		}
+	}
+	for key, queried := range l.queried {
+		if now.Sub(queried.queried) >= resourcesCacheTTL {
+			delete(l.queried, key)
+		}
+	}
+}
+
+type resourceGraphRequest struct {
+	Subscriptions []string             `json:"subscriptions"`
+	Query         string               `json:"query"`
+	Options       resourceGraphOptions `json:"options"`
+}
+
+type resourceGraphOptions struct {
+	ResultFormat string `json:"resultFormat"`
+	Top          int    `json:"$top"`
+}
+
+type resourceGraphResult struct {
+	Data []struct {
+		ID string `json:"id"`
+	} `json:"data"`
+	SkipToken string `json:"$skipToken"`
+}
+
+type resourceListResult struct {
+	Value    []armResource `json:"value"`
+	NextLink string        `json:"nextLink"`
+}
+
+func (l *armResourceLister) listPage(url string, authorizer autorest.Authorizer) (resourceListResult, error) {
+	result := resourceListResult{}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return result, err
+	}
+	if req, err = autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+		return result, redact.Error(err)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return result, redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return result, fmt.Errorf("unable to read resources: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("unable to list resources, status %d: %s", resp.StatusCode, redact.String(string(body)))
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return result, fmt.Errorf("unable to parse resources: %v", err)
+	}
+	return result, nil
+}
+
+// hasTags returns whether the resource has each of the tags.  Tag names are matched ignoring case,
+// as Azure does, and values exactly.
+func hasTags(resourceTags map[string]string, tags map[string]string) bool {
+	for name, value := range tags {
+		found := false
+		for resourceName, resourceValue := range resourceTags {
+			if strings.EqualFold(name, resourceName) && value == resourceValue {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// parseResourceID returns the resource group and name of a top level resource id, and its type
+func parseResourceID(id string) (ResourceRef, string, bool) {
+	// /subscriptions/<id>/resourceGroups/<group>/providers/<namespace>/<type>/<name>
+	parts := strings.Split(strings.Trim(id, "/"), "/")
+	if len(parts) != 8 || !strings.EqualFold(parts[2], "resourceGroups") || !strings.EqualFold(parts[4], "providers") {
+		return ResourceRef{}, "", false
+	}
+	return ResourceRef{ResourceGroup: parts[3], ResourceName: parts[7]}, parts[5] + "/" + parts[6], true
+}
+
+// resourceGroupOfID returns the resource group of a resource id
+func resourceGroupOfID(id string) string {
+	parts := strings.Split(id, "/")
+	for i := 0; i < len(parts)-1; i++ {
+		if strings.EqualFold(parts[i], "resourceGroups") {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
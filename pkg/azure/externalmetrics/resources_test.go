@@ -0,0 +1,140 @@
+package externalmetrics
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestListResourcesWithTags(t *testing.T) {
+	requests := 0
+	var filter string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		filter = r.URL.Query().Get("$filter")
+		w.Write([]byte(`{"value":[
+			{"id":"/subscriptions/1111/resourceGroups/orders-eu/providers/Microsoft.ServiceBus/namespaces/orders-eu","name":"orders-eu","tags":{"Workload":"orders"}},
+			{"id":"/subscriptions/1111/resourceGroups/orders-us/providers/Microsoft.ServiceBus/namespaces/orders-us","name":"orders-us","tags":{"workload":"orders","env":"prod"}},
+			{"id":"/subscriptions/1111/resourceGroups/billing/providers/Microsoft.ServiceBus/namespaces/billing","name":"billing","tags":{"workload":"billing"}}
+		]}`))
+	}))
+	defer server.Close()
+
+	lister := NewResourceLister(nullCredentialSource{}, server.URL).(*armResourceLister)
+	resources, err := lister.ListResources("1111", "", "Microsoft.ServiceBus/namespaces", map[string]string{"workload": "orders"}, time.Time{})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	want := []ResourceRef{{ResourceGroup: "orders-eu", ResourceName: "orders-eu"}, {ResourceGroup: "orders-us", ResourceName: "orders-us"}}
+	if len(resources) != len(want) || resources[0] != want[0] || resources[1] != want[1] {
+		t.Errorf("resources = %v, want %v", resources, want)
+	}
+	if filter != "resourceType eq 'Microsoft.ServiceBus/namespaces'" {
+		t.Errorf("filter = %v, want the resource type", filter)
+	}
+
+	lister.ListResources("1111", "", "Microsoft.ServiceBus/namespaces", map[string]string{"env": "prod"}, time.Time{})
+	if requests != 1 {
+		t.Errorf("requests = %v, want the resources to be cached", requests)
+	}
+
+	lister.now = func() time.Time { return time.Now().Add(resourcesCacheTTL + time.Second) }
+	lister.ListResources("1111", "", "Microsoft.ServiceBus/namespaces", nil, time.Time{})
+	if requests != 2 {
+		t.Errorf("requests = %v, want the resources to be listed after the ttl", requests)
+	}
+}
+
+func TestResourcesResolvedAgainAfterMetricChanged(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method == "POST" {
+			w.Write([]byte(`{"count":0,"data":[]}`))
+			return
+		}
+		w.Write([]byte(`{"value":[]}`))
+	}))
+	defer server.Close()
+
+	lister := NewResourceLister(nullCredentialSource{}, server.URL).(*armResourceLister)
+	now := time.Now()
+	lister.now = func() time.Time { return now }
+	lister.ListResources("1111", "", "Microsoft.ServiceBus/namespaces", nil, time.Time{})
+	lister.QueryResources("1111", "Microsoft.ServiceBus/namespaces", "resources | project id", time.Time{})
+
+	// the ExternalMetric changed after the resources were resolved
+	lister.ListResources("1111", "", "Microsoft.ServiceBus/namespaces", nil, now.Add(time.Nanosecond))
+	lister.QueryResources("1111", "Microsoft.ServiceBus/namespaces", "resources | project id", now.Add(time.Nanosecond))
+	if requests != 4 {
+		t.Errorf("requests = %v, want the resources to be resolved again", requests)
+	}
+
+	now = now.Add(time.Second)
+	lister.ListResources("1111", "", "Microsoft.ServiceBus/namespaces", nil, now.Add(-time.Second))
+	lister.QueryResources("1111", "Microsoft.ServiceBus/namespaces", "resources | project id", now.Add(-time.Second))
+	if requests != 4 {
+		t.Errorf("requests = %v, want the resources resolved after the change to be cached", requests)
+	}
+
+	now = now.Add(resourcesCacheTTL)
+	lister.ListResources("1111", "", "Microsoft.ServiceBus/namespaces", nil, time.Time{})
+	if len(lister.queried) != 0 || len(lister.resources) != 1 {
+		t.Errorf("cached %d queries and %d listings, want the expired query to be dropped", len(lister.queried), len(lister.resources))
+	}
+}
+
+func TestAggregateResourcesUnknownAggregationGetError(t *testing.T) {
+	_, err := AggregateResources("median", []float64{1, 2})
+
+	if !IsInvalidMetricRequestError(err) {
+		t.Errorf("AggregateResources() error = %v, want InvalidMetricRequestError", err)
+	}
+}
+
+func TestQueryResourcesOfType(t *testing.T) {
+	var request resourceGraphRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		json.Unmarshal(body, &request)
+		w.Write([]byte(`{"count":3,"data":[
+			{"id":"/subscriptions/1111/resourceGroups/orders-7f3a/providers/Microsoft.ServiceBus/namespaces/orders-7f3a"},
+			{"id":"/subscriptions/1111/resourceGroups/orders-7f3a/providers/Microsoft.ServiceBus/namespaces/orders-7f3a/queues/orders"},
+			{"id":"/subscriptions/1111/resourceGroups/orders-7f3a/providers/Microsoft.Storage/storageAccounts/orders7f3a"}
+		]}`))
+	}))
+	defer server.Close()
+
+	lister := NewResourceLister(nullCredentialSource{}, server.URL)
+	query := "resources | where type =~ 'microsoft.servicebus/namespaces' and name startswith 'orders-' | project id"
+	resources, err := lister.QueryResources("1111", "Microsoft.ServiceBus/namespaces", query, time.Time{})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	want := ResourceRef{ResourceGroup: "orders-7f3a", ResourceName: "orders-7f3a"}
+	if len(resources) != 1 || resources[0] != want {
+		t.Errorf("resources = %v, want %v", resources, want)
+	}
+	if request.Query != query || len(request.Subscriptions) != 1 || request.Subscriptions[0] != "1111" {
+		t.Errorf("request = %+v, want the query in subscription 1111", request)
+	}
+}
+
+func TestQueryResourcesWithoutIDGetError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"count":1,"data":[{"name":"orders-7f3a"}]}`))
+	}))
+	defer server.Close()
+
+	lister := NewResourceLister(nullCredentialSource{}, server.URL)
+	_, err := lister.QueryResources("1111", "Microsoft.ServiceBus/namespaces", "resources | project name", time.Time{})
+
+	if err == nil {
+		t.Errorf("error after processing got nil, want error")
+	}
+}
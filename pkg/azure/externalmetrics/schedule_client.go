@@ -0,0 +1,163 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// ScheduleDefinition describes a metric whose value is defined by time windows
+type ScheduleDefinition struct {
+	TimeZone     string
+	DefaultValue float64
+	Windows      []ScheduleWindow
+}
+
+// ScheduleWindow serves Value between Start and End, formatted HH:MM, on Days in cron day of week format
+type ScheduleWindow struct {
+	Days  string
+	Start string
+	End   string
+	Value float64
+}
+
+var dayNames = map[string]int{
+	"SUN": 0,
+	"MON": 1,
+	"TUE": 2,
+	"WED": 3,
+	"THU": 4,
+	"FRI": 5,
+	"SAT": 6,
+}
+
+type scheduleClient struct {
+	now func() time.Time
+}
+
+// NewScheduleClient creates a client that serves schedule metrics. No calls are made to Azure.
+func NewScheduleClient() AzureExternalMetricClient {
+	return &scheduleClient{now: time.Now}
+}
+
+func (c *scheduleClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	value, err := azMetricRequest.Schedule.ValueAt(c.now())
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	glog.V(4).Infof("schedule metric %s value: %f", azMetricRequest.MetricName, value)
+	return AzureExternalMetricResponse{
+		Total: value,
+	}, nil
+}
+
+// ValueAt returns the value of the first window containing t, or the default value if none do
+func (s ScheduleDefinition) ValueAt(t time.Time) (float64, error) {
+	location := time.UTC
+	if s.TimeZone != "" {
+		var err error
+		location, err = time.LoadLocation(s.TimeZone)
+		if err != nil {
+			return 0, InvalidMetricRequestError{err: fmt.Sprintf("invalid schedule time zone '%s': %v", s.TimeZone, err)}
+		}
+	}
+	t = t.In(location)
+
+	for _, window := range s.Windows {
+		matches, err := window.contains(t)
+		if err != nil {
+			return 0, err
+		}
+		if matches {
+			return window.Value, nil
+		}
+	}
+
+	return s.DefaultValue, nil
+}
+
+func (w ScheduleWindow) contains(t time.Time) (bool, error) {
+	days, err := parseDays(w.Days)
+	if err != nil {
+		return false, err
+	}
+	start, err := parseTimeOfDay(w.Start)
+	if err != nil {
+		return false, err
+	}
+	end, err := parseTimeOfDay(w.End)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	today := int(t.Weekday())
+
+	if start <= end {
+		return days[today] && now >= start && now < end, nil
+	}
+
+	// the window runs past midnight so the start of it belongs to the previous day
+	yesterday := (today + 6) % 7
+	return (days[today] && now >= start) || (days[yesterday] && now < end), nil
+}
+
+// parseDays parses a cron day of week field: *, lists, ranges and three letter day names
+func parseDays(field string) ([7]bool, error) {
+	days := [7]bool{}
+	if field == "" || field == "*" {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+
+	for _, part := range strings.Split(field, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+		first, err := parseDay(bounds[0])
+		if err != nil {
+			return days, err
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = parseDay(bounds[1])
+			if err != nil {
+				return days, err
+			}
+		}
+		if last < first {
+			return days, InvalidMetricRequestError{err: fmt.Sprintf("invalid schedule day range '%s'", part)}
+		}
+
+		for day := first; day <= last; day++ {
+			days[day%7] = true
+		}
+	}
+
+	return days, nil
+}
+
+func parseDay(value string) (int, error) {
+	if day, ok := dayNames[strings.ToUpper(value)]; ok {
+		return day, nil
+	}
+
+	// cron allows both 0 and 7 for sunday
+	day, err := strconv.Atoi(value)
+	if err != nil || day < 0 || day > 7 {
+		return 0, InvalidMetricRequestError{err: fmt.Sprintf("invalid schedule day '%s'", value)}
+	}
+	return day, nil
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, InvalidMetricRequestError{err: fmt.Sprintf("invalid schedule time '%s', must be HH:MM", value)}
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
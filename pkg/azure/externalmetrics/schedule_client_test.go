@@ -0,0 +1,127 @@
+package externalmetrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleValueAt(t *testing.T) {
+	businessHours := ScheduleDefinition{
+		DefaultValue: 2,
+		Windows: []ScheduleWindow{
+			{Days: "MON-FRI", Start: "09:00", End: "17:00", Value: 10},
+			{Days: "5", Start: "22:00", End: "02:00", Value: 5},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		schedule ScheduleDefinition
+		time     string
+		want     float64
+	}{
+		{
+			name:     "in business hours",
+			schedule: businessHours,
+			time:     "2019-03-04T10:30:00Z", // monday
+			want:     10,
+		},
+		{
+			name:     "end of window is exclusive",
+			schedule: businessHours,
+			time:     "2019-03-04T17:00:00Z",
+			want:     2,
+		},
+		{
+			name:     "weekend",
+			schedule: businessHours,
+			time:     "2019-03-09T10:30:00Z", // saturday
+			want:     2,
+		},
+		{
+			name:     "window past midnight on start day",
+			schedule: businessHours,
+			time:     "2019-03-08T23:00:00Z", // friday
+			want:     5,
+		},
+		{
+			name:     "window past midnight on next day",
+			schedule: businessHours,
+			time:     "2019-03-09T01:00:00Z", // saturday
+			want:     5,
+		},
+		{
+			name: "time zone",
+			schedule: ScheduleDefinition{
+				TimeZone:     "America/New_York",
+				DefaultValue: 1,
+				Windows:      []ScheduleWindow{{Start: "09:00", End: "17:00", Value: 3}},
+			},
+			time: "2019-03-04T15:00:00Z", // 10:00 in new york
+			want: 3,
+		},
+		{
+			name: "sunday as 7",
+			schedule: ScheduleDefinition{
+				Windows: []ScheduleWindow{{Days: "6-7", Start: "00:00", End: "23:59", Value: 4}},
+			},
+			time: "2019-03-10T12:00:00Z", // sunday
+			want: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now, _ := time.Parse(time.RFC3339, tt.time)
+			got, err := tt.schedule.ValueAt(now)
+			if err != nil {
+				t.Fatalf("ValueAt() error = %v, want nil", err)
+			}
+			if got != tt.want {
+				t.Errorf("ValueAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduleInvalidWindowGetError(t *testing.T) {
+	tests := []struct {
+		name   string
+		window ScheduleWindow
+	}{
+		{name: "bad start", window: ScheduleWindow{Start: "9am", End: "17:00"}},
+		{name: "bad day", window: ScheduleWindow{Days: "FUNDAY", Start: "09:00", End: "17:00"}},
+		{name: "bad range", window: ScheduleWindow{Days: "5-1", Start: "09:00", End: "17:00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := ScheduleDefinition{Windows: []ScheduleWindow{tt.window}}
+			_, err := schedule.ValueAt(time.Now())
+			if !IsInvalidMetricRequestError(err) {
+				t.Errorf("ValueAt() error = %v, want InvalidMetricRequestError", err)
+			}
+		})
+	}
+}
+
+func TestScheduleClientUsesCurrentTime(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2019-03-04T10:30:00Z")
+	client := scheduleClient{now: func() time.Time { return now }}
+
+	request := AzureExternalMetricRequest{
+		MetricName: "schedule",
+		Type:       Schedule,
+		Schedule: ScheduleDefinition{
+			DefaultValue: 1,
+			Windows:      []ScheduleWindow{{Start: "09:00", End: "17:00", Value: 10}},
+		},
+	}
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Errorf("error after processing got: %v, want nil", err)
+	}
+
+	if metricResponse.Total != 10 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 10)
+	}
+}
@@ -0,0 +1,72 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// SeasonalRatio serves the current value divided by the value of the same window in the past
+	SeasonalRatio = "ratio"
+	// SeasonalDifference serves the current value minus the value of the same window in the past
+	SeasonalDifference = "difference"
+
+	defaultSeasonalOffset = 7 * 24 * time.Hour
+	// maxSeasonalOffset is how long Azure Monitor keeps platform metrics
+	maxSeasonalOffset = 93 * 24 * time.Hour
+)
+
+// SeasonalDefinition compares the Azure Monitor metric of a seasonal metric with the same window
+// an offset ago, a week by default, by their ratio or difference.  The offset uses the go duration
+// format and is parsed when the metric is requested.
+type SeasonalDefinition struct {
+	Offset     string
+	Comparison string
+}
+
+// ParseOffset returns how long ago the past window ends, a week when the metric has no offset
+func (d SeasonalDefinition) ParseOffset() (time.Duration, error) {
+	if d.Offset == "" {
+		return defaultSeasonalOffset, nil
+	}
+	offset, err := time.ParseDuration(d.Offset)
+	if err != nil || offset <= 0 || offset > maxSeasonalOffset {
+		return 0, InvalidMetricRequestError{err: fmt.Sprintf("invalid seasonal offset '%s', must be a go duration such as 168h of at most %s", d.Offset, maxSeasonalOffset)}
+	}
+	return offset, nil
+}
+
+// Validate returns an error if the offset or comparison of the metric is invalid
+func (d SeasonalDefinition) Validate() error {
+	if _, err := d.ParseOffset(); err != nil {
+		return err
+	}
+	switch d.Comparison {
+	case "", SeasonalRatio, SeasonalDifference:
+		return nil
+	}
+	return InvalidMetricRequestError{err: fmt.Sprintf("invalid seasonal comparison '%s', must be %s or %s", d.Comparison, SeasonalRatio, SeasonalDifference)}
+}
+
+// Current returns the Azure Monitor query of the metric over its window up to now.  The alert guard
+// of the metric applies to the comparison rather than to each query.
+func (d SeasonalDefinition) Current(request AzureExternalMetricRequest) AzureExternalMetricRequest {
+	request.Type = Monitor
+	request.Seasonal = SeasonalDefinition{}
+	request.Alert = AlertDefinition{}
+	return request
+}
+
+// Past returns the Azure Monitor query of the metric over the same window ending the offset before
+// now.  The definition has been validated so the offset parses.
+func (d SeasonalDefinition) Past(request AzureExternalMetricRequest, now time.Time) AzureExternalMetricRequest {
+	request = d.Current(request)
+	window := defaultTimespan
+	if request.Window != "" {
+		window, _ = time.ParseDuration(request.Window)
+	}
+	offset, _ := d.ParseOffset()
+	request.Timespan = timespanUntil(now.Add(-offset), window)
+	request.Window = ""
+	return request
+}
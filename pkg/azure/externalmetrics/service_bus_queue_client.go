@@ -0,0 +1,228 @@
+package externalmetrics
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/azure-sdk-for-go/services/servicebus/mgmt/2017-04-01/servicebus"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const (
+	serviceBusResource   = "https://servicebus.azure.net/"
+	serviceBusAPIVersion = "2017-04"
+	// serviceBusTokenValidity is how long the shared access signature of a request is valid
+	serviceBusTokenValidity = 5 * time.Minute
+	// maxQueueDescriptionSize limits how much of a queue description is read
+	maxQueueDescriptionSize = 64 * 1024
+)
+
+var (
+	serviceBusNamespaceName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]{4,48}[a-zA-Z0-9]$`)
+	serviceBusQueueName     = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._-]|/[a-zA-Z0-9])*$`)
+)
+
+// ServiceBusQueueDefinition names the Service Bus queue whose message count is read from the data
+// plane.  The queue is read with the connection string when it is set, which the provider resolves
+// from the secret, or with the adapter's credentials otherwise.
+type ServiceBusQueueDefinition struct {
+	Namespace              string
+	Queue                  string
+	ConnectionStringSecret string
+	ConnectionStringKey    string
+	ConnectionString       string
+}
+
+type serviceBusQueueClient struct {
+	credentials credentials.Source
+	client      *http.Client
+	now         func() time.Time
+	// namespaceURL returns the base url of the data plane of a namespace
+	namespaceURL func(namespace string) string
+}
+
+// NewServiceBusQueueClient creates a client that serves the message count of a Service Bus queue
+// as reported by the data plane of its namespace, under the service bus endpoint suffix, which
+// is current within seconds unlike the Azure Monitor metrics of the queue
+func NewServiceBusQueueClient(credentialSource credentials.Source, serviceBusSuffix string) AzureExternalMetricClient {
+	return &serviceBusQueueClient{
+		credentials: credentialSource,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		now:         time.Now,
+		namespaceURL: func(namespace string) string {
+			return fmt.Sprintf("https://%s.%s", namespace, serviceBusSuffix)
+		},
+	}
+}
+
+// serviceBusConnectionString is the endpoint and shared access key of a connection string
+type serviceBusConnectionString struct {
+	endpoint   string
+	keyName    string
+	key        string
+	entityPath string
+}
+
+// queueDescriptionEntry is the atom entry describing a queue, or a feed when the queue doesn't exist
+type queueDescriptionEntry struct {
+	XMLName      xml.Name
+	CountDetails struct {
+		ActiveMessageCount     *int64 `xml:"ActiveMessageCount"`
+		ScheduledMessageCount  *int64 `xml:"ScheduledMessageCount"`
+		DeadLetterMessageCount *int64 `xml:"DeadLetterMessageCount"`
+	} `xml:"content>QueueDescription>CountDetails"`
+}
+
+func (c *serviceBusQueueClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	queue := azMetricRequest.ServiceBusQueue
+
+	baseURL := ""
+	var connection serviceBusConnectionString
+	if queue.ConnectionString != "" {
+		var err error
+		connection, err = parseServiceBusConnectionString(queue.ConnectionString)
+		if err != nil {
+			return AzureExternalMetricResponse{}, err
+		}
+		baseURL = connection.endpoint
+		if queue.Queue == "" {
+			queue.Queue = connection.entityPath
+		}
+	} else {
+		if !serviceBusNamespaceName.MatchString(queue.Namespace) {
+			return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "service bus namespace name is invalid"}
+		}
+		baseURL = c.namespaceURL(queue.Namespace)
+	}
+	if len(queue.Queue) > 260 || !serviceBusQueueName.MatchString(queue.Queue) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "service bus queue name is invalid"}
+	}
+
+	queueURL := fmt.Sprintf("%s/%s", baseURL, queue.Queue)
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s?api-version=%s", queueURL, serviceBusAPIVersion), nil)
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+
+	if queue.ConnectionString != "" {
+		req.Header.Set("Authorization", connection.sharedAccessSignature(queueURL, c.now()))
+	} else {
+		authorizer, err := c.credentials.Authorizer(serviceBusResource)
+		if err != nil {
+			return AzureExternalMetricResponse{}, redact.Error(err)
+		}
+		if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+			return AzureExternalMetricResponse{}, redact.Error(err)
+		}
+	}
+
+	glog.V(2).Infof("requesting description of service bus queue %s from %s", queue.Queue, baseURL)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxQueueDescriptionSize))
+	if err != nil {
+		return AzureExternalMetricResponse{}, fmt.Errorf("unable to read queue description: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return AzureExternalMetricResponse{}, fmt.Errorf("description of service bus queue %s returned status %d: %s", queue.Queue, resp.StatusCode, redact.String(string(body)))
+	}
+
+	countDetails, err := queueMessageCounts(body)
+	if err != nil {
+		return AzureExternalMetricResponse{}, fmt.Errorf("service bus queue %s: %v", queue.Queue, err)
+	}
+	messageCount, err := sumMessageCounts(countDetails, azMetricRequest.MessageCounts)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	glog.V(4).Infof("Service Bus queue %s message count: %f", queue.Queue, messageCount)
+	return AzureExternalMetricResponse{
+		Total: messageCount,
+		Raw:   []string{string(body)},
+	}, nil
+}
+
+// queueMessageCounts returns the message counts of the queue description
+func queueMessageCounts(body []byte) (*servicebus.MessageCountDetails, error) {
+	var entry queueDescriptionEntry
+	if err := xml.Unmarshal(body, &entry); err != nil {
+		return nil, fmt.Errorf("unable to parse queue description: %v", err)
+	}
+	// service bus answers a description of an entity that doesn't exist with an empty feed
+	if entry.XMLName.Local != "entry" {
+		return nil, fmt.Errorf("queue not found")
+	}
+	if entry.CountDetails.ActiveMessageCount == nil {
+		return nil, fmt.Errorf("no message counts returned, the queue may be a topic")
+	}
+
+	return &servicebus.MessageCountDetails{
+		ActiveMessageCount:     entry.CountDetails.ActiveMessageCount,
+		ScheduledMessageCount:  entry.CountDetails.ScheduledMessageCount,
+		DeadLetterMessageCount: entry.CountDetails.DeadLetterMessageCount,
+	}, nil
+}
+
+// parseServiceBusConnectionString parses a connection string with a shared access key, such as
+// Endpoint=sb://<namespace>.servicebus.windows.net/;SharedAccessKeyName=<name>;SharedAccessKey=<key>
+func parseServiceBusConnectionString(connectionString string) (serviceBusConnectionString, error) {
+	var connection serviceBusConnectionString
+	for _, part := range strings.Split(connectionString, ";") {
+		pair := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(pair) != 2 {
+			continue
+		}
+		switch strings.ToLower(pair[0]) {
+		case "endpoint":
+			endpoint, err := url.Parse(pair[1])
+			if err != nil || endpoint.Host == "" {
+				return serviceBusConnectionString{}, InvalidMetricRequestError{err: "service bus connection string has an invalid endpoint"}
+			}
+			connection.endpoint = "https://" + endpoint.Host
+		case "sharedaccesskeyname":
+			connection.keyName = pair[1]
+		case "sharedaccesskey":
+			connection.key = pair[1]
+		case "entitypath":
+			connection.entityPath = pair[1]
+		}
+	}
+
+	if connection.endpoint == "" || connection.keyName == "" || connection.key == "" {
+		return serviceBusConnectionString{}, InvalidMetricRequestError{err: "service bus connection string requires Endpoint, SharedAccessKeyName and SharedAccessKey"}
+	}
+	return connection, nil
+}
+
+// sharedAccessSignature returns the authorization header of a request to the resource, signed
+// with the key of the connection string
+func (c serviceBusConnectionString) sharedAccessSignature(resource string, now time.Time) string {
+	audience := url.QueryEscape(resource)
+	expiry := strconv.FormatInt(now.Add(serviceBusTokenValidity).Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(c.key))
+	mac.Write([]byte(audience + "\n" + expiry))
+	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return fmt.Sprintf("SharedAccessSignature sr=%s&sig=%s&se=%s&skn=%s", audience, url.QueryEscape(signature), expiry, url.QueryEscape(c.keyName))
+}
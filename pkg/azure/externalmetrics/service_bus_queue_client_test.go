@@ -0,0 +1,131 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testQueueDescription = `<entry xmlns="http://www.w3.org/2005/Atom"><title type="text">orders</title><content type="application/xml"><QueueDescription xmlns="http://schemas.microsoft.com/netservices/2010/10/servicebus/connect" xmlns:i="http://www.w3.org/2001/XMLSchema-instance"><MessageCount>9</MessageCount><CountDetails xmlns:d2p1="http://schemas.microsoft.com/netservices/2011/06/servicebus"><d2p1:ActiveMessageCount>7</d2p1:ActiveMessageCount><d2p1:DeadLetterMessageCount>4</d2p1:DeadLetterMessageCount><d2p1:ScheduledMessageCount>2</d2p1:ScheduledMessageCount></CountDetails></QueueDescription></content></entry>`
+
+func TestServiceBusQueueReturnsActiveMessageCount(t *testing.T) {
+	query := ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RequestURI()
+		fmt.Fprint(w, testQueueDescription)
+	}))
+	defer server.Close()
+
+	client := newTestServiceBusQueueClient(server)
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type:            ServiceBusQueue,
+		ServiceBusQueue: ServiceBusQueueDefinition{Namespace: "orders-sb", Queue: "orders"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 7 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 7)
+	}
+	if query != "/orders?api-version=2017-04" {
+		t.Errorf("query = %v, want description of the queue", query)
+	}
+}
+
+func TestServiceBusQueueSumsRequestedCounts(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testQueueDescription)
+	}))
+	defer server.Close()
+
+	client := newTestServiceBusQueueClient(server)
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type:            ServiceBusQueue,
+		MessageCounts:   []string{MessageCountActive, MessageCountScheduled, MessageCountDeadLetter},
+		ServiceBusQueue: ServiceBusQueueDefinition{Namespace: "orders-sb", Queue: "orders"},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 13 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 13)
+	}
+}
+
+func TestServiceBusQueueSignedWithConnectionString(t *testing.T) {
+	authorization := ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		fmt.Fprint(w, testQueueDescription)
+	}))
+	defer server.Close()
+
+	client := newTestServiceBusQueueClient(server)
+	endpoint := strings.Replace(server.URL, "https://", "sb://", 1)
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type: ServiceBusQueue,
+		ServiceBusQueue: ServiceBusQueueDefinition{
+			ConnectionString: fmt.Sprintf("Endpoint=%s/;SharedAccessKeyName=manage;SharedAccessKey=c2VjcmV0;EntityPath=orders", endpoint),
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	expiry := testQueueNow.Add(serviceBusTokenValidity).Unix()
+	if !strings.HasPrefix(authorization, "SharedAccessSignature sr=") || !strings.Contains(authorization, fmt.Sprintf("&se=%d&skn=manage", expiry)) {
+		t.Errorf("authorization = %v, want shared access signature of the manage key", authorization)
+	}
+}
+
+func TestServiceBusQueueNotFoundGetError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<feed xmlns="http://www.w3.org/2005/Atom"><title type="text">Publicly Listed Services</title></feed>`)
+	}))
+	defer server.Close()
+
+	client := newTestServiceBusQueueClient(server)
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type:            ServiceBusQueue,
+		ServiceBusQueue: ServiceBusQueueDefinition{Namespace: "orders-sb", Queue: "missing"},
+	})
+
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("error after processing got: %v, want queue not found", err)
+	}
+}
+
+func TestServiceBusQueueInvalidRequestsGetError(t *testing.T) {
+	var tests = []ServiceBusQueueDefinition{
+		{Namespace: "", Queue: "orders"},
+		{Namespace: "sb", Queue: "orders"},
+		{Namespace: "orders-sb", Queue: ""},
+		{Namespace: "orders-sb", Queue: "orders/../other"},
+		{ConnectionString: "Endpoint=sb://orders-sb.servicebus.windows.net/;EntityPath=orders"},
+		{ConnectionString: "SharedAccessKeyName=manage;SharedAccessKey=c2VjcmV0", Queue: "orders"},
+	}
+
+	client := NewServiceBusQueueClient(fakeCredentialSource{}, "servicebus.windows.net")
+	for _, queue := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{ServiceBusQueue: queue})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%+v) got %v, want InvalidMetricRequestError", queue, err)
+		}
+	}
+}
+
+func newTestServiceBusQueueClient(server *httptest.Server) *serviceBusQueueClient {
+	return &serviceBusQueueClient{
+		credentials: nullCredentialSource{},
+		client:      server.Client(),
+		now:         func() time.Time { return testQueueNow },
+		namespaceURL: func(namespace string) string {
+			return server.URL
+		},
+	}
+}
@@ -1,13 +1,28 @@
 package externalmetrics
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"strings"
 
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
 	"github.com/Azure/azure-sdk-for-go/services/servicebus/mgmt/2017-04-01/servicebus"
-	"github.com/Azure/go-autorest/autorest/azure/auth"
 	"github.com/golang/glog"
 )
 
+// Service Bus message counts that can be summed for the value of a metric
+const (
+	MessageCountActive    string = "active"
+	MessageCountScheduled string = "scheduled"
+	// MessageCountDeadLetter counts the messages moved to the dead-letter queue, which consumers
+	// processing dead letters scale on
+	MessageCountDeadLetter string = "deadLetter"
+	// MessageCountDeferred is not reported separately by Service Bus as deferred messages remain active
+	MessageCountDeferred string = "deferred"
+)
+
 type servicebusSubscriptionsClient interface {
 	Get(ctx context.Context, resourceGroupName string, namespaceName string, topicName string, subscriptionName string) (result servicebus.SBSubscription, err error)
 }
@@ -17,13 +32,14 @@ type servicebusClient struct {
 	DefaultSubscriptionID string
 }
 
-func NewServiceBusSubscriptionClient(defaultSubscriptionID string) AzureExternalMetricClient {
+func NewServiceBusSubscriptionClient(defaultSubscriptionID string, credentialSource credentials.Source, resourceManager string) AzureExternalMetricClient {
 	glog.V(2).Info("Creating a new Azure Service Bus Subscriptions client")
-	client := servicebus.NewSubscriptionsClient(defaultSubscriptionID)
-	authorizer, err := auth.NewAuthorizerFromEnvironment()
+	client := servicebus.NewSubscriptionsClientWithBaseURI(resourceManager, defaultSubscriptionID)
+	authorizer, err := credentialSource.Authorizer("")
 	if err == nil {
 		client.Authorizer = authorizer
 	}
+	client.ResponseInspector = copyRawResponse()
 
 	return &servicebusClient{
 		client:                client,
@@ -46,26 +62,64 @@ func (c *servicebusClient) GetAzureMetric(azMetricRequest AzureExternalMetricReq
 	}
 
 	glog.V(2).Infof("Requesting Service Bus Subscription %s to topic %s in namespace %s from resource group %s", azMetricRequest.Subscription, azMetricRequest.Topic, azMetricRequest.Namespace, azMetricRequest.ResourceGroup)
+	raw := &bytes.Buffer{}
 	subscriptionResult, err := c.client.Get(
-		context.Background(),
+		withRawResponse(context.Background(), raw),
 		azMetricRequest.ResourceGroup,
 		azMetricRequest.Namespace,
 		azMetricRequest.Topic,
 		azMetricRequest.Subscription,
 	)
 	if err != nil {
-		return AzureExternalMetricResponse{}, err
+		return AzureExternalMetricResponse{}, redact.Error(err)
 	}
 
 	glog.V(2).Infof("Successfully retrieved Service Bus Subscription %s to topic %s in namespace %s from resource group %s", azMetricRequest.Subscription, azMetricRequest.Topic, azMetricRequest.Namespace, azMetricRequest.ResourceGroup)
 	glog.V(6).Infof("%v", subscriptionResult.Response)
 
-	activeMessageCount := float64(*subscriptionResult.SBSubscriptionProperties.CountDetails.ActiveMessageCount)
+	messageCount, err := sumMessageCounts(subscriptionResult.SBSubscriptionProperties.CountDetails, azMetricRequest.MessageCounts)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
 
-	glog.V(4).Infof("Service Bus Subscription active message count: %f", activeMessageCount)
+	glog.V(4).Infof("Service Bus Subscription message count: %f", messageCount)
 
 	// TODO set Value based on aggregations type
 	return AzureExternalMetricResponse{
-		Total: activeMessageCount,
+		Total: messageCount,
+		Raw:   rawBodies(raw),
 	}, nil
 }
+
+// sumMessageCounts adds up the requested message counts, or returns the active count when none are requested
+func sumMessageCounts(countDetails *servicebus.MessageCountDetails, messageCounts []string) (float64, error) {
+	if countDetails == nil {
+		return 0, fmt.Errorf("no message counts returned")
+	}
+	if len(messageCounts) == 0 {
+		messageCounts = []string{MessageCountActive}
+	}
+
+	total := 0.0
+	for _, messageCount := range messageCounts {
+		var count *int64
+		switch strings.ToLower(messageCount) {
+		case MessageCountActive:
+			count = countDetails.ActiveMessageCount
+		case MessageCountScheduled:
+			count = countDetails.ScheduledMessageCount
+		case strings.ToLower(MessageCountDeadLetter):
+			count = countDetails.DeadLetterMessageCount
+		case MessageCountDeferred:
+			return 0, InvalidMetricRequestError{err: "deferred messages are included in the active message count"}
+		default:
+			return 0, InvalidMetricRequestError{err: fmt.Sprintf("message count must be %s, %s or %s", MessageCountActive, MessageCountScheduled, MessageCountDeadLetter)}
+		}
+
+		if count != nil {
+			total += float64(*count)
+		}
+	}
+
+	return total, nil
+}
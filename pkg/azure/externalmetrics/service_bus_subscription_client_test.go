@@ -61,6 +61,52 @@ func TestIfValidRequestGetResult(t *testing.T) {
 	}
 }
 
+func TestServiceBusMessageCounts(t *testing.T) {
+	response := makeServiceBusSubscriptionResponse(15)
+	scheduled := int64(7)
+	response.SBSubscriptionProperties.CountDetails.ScheduledMessageCount = &scheduled
+	deadLetter := int64(3)
+	response.SBSubscriptionProperties.CountDetails.DeadLetterMessageCount = &deadLetter
+	serviceBusClient := newFakeServicebusClient(response, nil)
+
+	client := newServiceBusSubscriptionClient("", serviceBusClient)
+
+	var tests = []struct {
+		messageCounts []string
+		want          float64
+		wantErr       bool
+	}{
+		{nil, 15, false},
+		{[]string{"scheduled"}, 7, false},
+		{[]string{"active", "Scheduled"}, 22, false},
+		{[]string{"deferred"}, 0, true},
+		{[]string{"deadLetter"}, 3, false},
+		{[]string{"active", "deadletter"}, 18, false},
+		{[]string{"transferDeadLetter"}, 0, true},
+	}
+
+	for _, tt := range tests {
+		request := newServiceBusSubscriptionMetricRequest()
+		request.MessageCounts = tt.messageCounts
+		metricResponse, err := client.GetAzureMetric(request)
+
+		if tt.wantErr {
+			if !IsInvalidMetricRequestError(err) {
+				t.Errorf("%v: should be InvalidMetricRequest error got %v, want InvalidMetricRequestError", tt.messageCounts, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%v: error after processing got: %v, want nil", tt.messageCounts, err)
+		}
+
+		if metricResponse.Total != tt.want {
+			t.Errorf("%v: metricResponse.Total = %v, want = %v", tt.messageCounts, metricResponse.Total, tt.want)
+		}
+	}
+}
+
 func makeServiceBusSubscriptionResponse(value int64) servicebus.SBSubscription {
 	messageCountDetails := servicebus.MessageCountDetails{
 		ActiveMessageCount: &value,
@@ -0,0 +1,186 @@
+package externalmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+const (
+	serviceHealthAPIVersion = "2022-10-01"
+	// serviceHealthRefresh is how long the active service issues of a subscription are kept
+	serviceHealthRefresh = time.Minute
+	// maxServiceHealthResponseSize limits how much of the service issues of a subscription is read
+	maxServiceHealthResponseSize = 1024 * 1024
+)
+
+// monitorServices are the services of Azure Service Health whose issues affect metric queries
+var monitorServices = []string{"Azure Monitor", "Alerts & Metrics"}
+
+// ServiceHealth reports Azure Monitor incidents known to Azure Service Health
+type ServiceHealth interface {
+	// Incident returns the tracking id of an active service issue of Azure Monitor in the region
+	// of the adapter, as seen by the subscription
+	Incident(subscriptionID string) (string, bool, error)
+}
+
+type armServiceHealth struct {
+	credentials credentials.Source
+	client      *http.Client
+	region      string
+	now         func() time.Time
+	// baseURL returns the Azure Resource Manager endpoint
+	baseURL func() (string, error)
+
+	mu     sync.Mutex
+	issues map[string]serviceIssues
+}
+
+type serviceIssues struct {
+	checked  time.Time
+	incident string
+}
+
+// NewServiceHealth creates a checker that asks the Azure Resource Health api of the Azure
+// Resource Manager endpoint for the active service issues affecting Azure Monitor in the region
+func NewServiceHealth(credentialSource credentials.Source, resourceManager string, region string) ServiceHealth {
+	return &armServiceHealth{
+		credentials: credentialSource,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		region:      region,
+		now:         time.Now,
+		baseURL: func() (string, error) {
+			return strings.TrimSuffix(resourceManager, "/"), nil
+		},
+		issues: map[string]serviceIssues{},
+	}
+}
+
+type serviceIssueListResult struct {
+	Value []struct {
+		// Name is the tracking id of the issue
+		Name       string `json:"name"`
+		Properties struct {
+			Title  string `json:"title"`
+			Status string `json:"status"`
+			Impact []struct {
+				ImpactedService string `json:"impactedService"`
+				ImpactedRegions []struct {
+					ImpactedRegion string `json:"impactedRegion"`
+				} `json:"impactedRegions"`
+			} `json:"impact"`
+		} `json:"properties"`
+	} `json:"value"`
+}
+
+// Incident returns the tracking id of an active Azure Monitor service issue in the region.  The
+// issues of a subscription are read at most once a minute.
+func (h *armServiceHealth) Incident(subscriptionID string) (string, bool, error) {
+	h.mu.Lock()
+	issues, found := h.issues[subscriptionID]
+	h.mu.Unlock()
+	if found && h.now().Sub(issues.checked) < serviceHealthRefresh {
+		return issues.incident, issues.incident != "", nil
+	}
+
+	incident, err := h.activeIncident(subscriptionID)
+	if err != nil {
+		return "", false, err
+	}
+	if incident != "" && incident != issues.incident {
+		glog.Warningf("azure service health reports incident %s of azure monitor in %s", incident, h.region)
+	}
+
+	h.mu.Lock()
+	h.issues[subscriptionID] = serviceIssues{checked: h.now(), incident: incident}
+	h.mu.Unlock()
+	return incident, incident != "", nil
+}
+
+func (h *armServiceHealth) activeIncident(subscriptionID string) (string, error) {
+	baseURL, err := h.baseURL()
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("api-version", serviceHealthAPIVersion)
+	query.Set("$filter", "properties/eventType eq 'ServiceIssue' and properties/status eq 'Active'")
+	endpoint := fmt.Sprintf("%s/subscriptions/%s/providers/Microsoft.ResourceHealth/events?%s", baseURL, subscriptionID, query.Encode())
+
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return "", redact.Error(err)
+	}
+	authorizer, err := h.credentials.Authorizer("")
+	if err != nil {
+		return "", redact.Error(err)
+	}
+	if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+		return "", redact.Error(err)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return "", redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxServiceHealthResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("unable to read service health: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unable to list service issues of subscription %s, status %d: %s", subscriptionID, resp.StatusCode, redact.String(string(body)))
+	}
+
+	var result serviceIssueListResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("unable to parse service issues: %v", err)
+	}
+
+	for _, issue := range result.Value {
+		if issue.Properties.Status != "Active" {
+			continue
+		}
+		for _, impact := range issue.Properties.Impact {
+			if !isMonitorService(impact.ImpactedService) {
+				continue
+			}
+			for _, region := range impact.ImpactedRegions {
+				if sameRegion(region.ImpactedRegion, h.region) {
+					return issue.Name, nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+func isMonitorService(service string) bool {
+	for _, monitorService := range monitorServices {
+		if strings.EqualFold(service, monitorService) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameRegion compares the display name of a region, such as West Europe, with its name, westeurope
+func sameRegion(a string, b string) bool {
+	normalize := func(region string) string {
+		return strings.ToLower(strings.Replace(region, " ", "", -1))
+	}
+	return normalize(a) == normalize(b)
+}
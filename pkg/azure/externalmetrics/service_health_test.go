@@ -0,0 +1,98 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServiceHealthIncident(t *testing.T) {
+	var tests = []struct {
+		name   string
+		issues string
+		want   string
+	}{
+		{"monitor in region", `{"value":[{"name":"AB-123","properties":{"status":"Active","impact":[{"impactedService":"Azure Monitor","impactedRegions":[{"impactedRegion":"West Europe"}]}]}}]}`, "AB-123"},
+		{"other region", `{"value":[{"name":"AB-123","properties":{"status":"Active","impact":[{"impactedService":"Azure Monitor","impactedRegions":[{"impactedRegion":"East US"}]}]}}]}`, ""},
+		{"other service", `{"value":[{"name":"AB-123","properties":{"status":"Active","impact":[{"impactedService":"Virtual Machines","impactedRegions":[{"impactedRegion":"West Europe"}]}]}}]}`, ""},
+		{"resolved", `{"value":[{"name":"AB-123","properties":{"status":"Resolved","impact":[{"impactedService":"Azure Monitor","impactedRegions":[{"impactedRegion":"West Europe"}]}]}}]}`, ""},
+		{"none", `{"value":[]}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := ""
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+				fmt.Fprint(w, tt.issues)
+			}))
+			defer server.Close()
+
+			incident, found, err := newTestServiceHealth(server).Incident("1234")
+
+			if err != nil {
+				t.Fatalf("error after processing got: %v, want nil", err)
+			}
+			if incident != tt.want || found != (tt.want != "") {
+				t.Errorf("Incident() = %v, %v, want %v", incident, found, tt.want)
+			}
+			if path != "/subscriptions/1234/providers/Microsoft.ResourceHealth/events" {
+				t.Errorf("path = %v, want the events of the subscription", path)
+			}
+		})
+	}
+}
+
+func TestServiceHealthIsReadOncePerRefresh(t *testing.T) {
+	calls := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprint(w, `{"value":[]}`)
+	}))
+	defer server.Close()
+
+	now := time.Date(2019, 3, 10, 12, 0, 0, 0, time.UTC)
+	health := newTestServiceHealth(server)
+	health.now = func() time.Time { return now }
+
+	health.Incident("1234")
+	health.Incident("1234")
+	if calls != 1 {
+		t.Errorf("calls = %v, want %v", calls, 1)
+	}
+
+	now = now.Add(serviceHealthRefresh)
+	health.Incident("1234")
+	if calls != 2 {
+		t.Errorf("calls after refresh = %v, want %v", calls, 2)
+	}
+}
+
+func TestServiceHealthErrorStatusGetError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, _, err := newTestServiceHealth(server).Incident("1234")
+
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("error after processing got: %v, want the status", err)
+	}
+}
+
+func newTestServiceHealth(server *httptest.Server) *armServiceHealth {
+	return &armServiceHealth{
+		credentials: nullCredentialSource{},
+		client:      server.Client(),
+		region:      "westeurope",
+		now:         time.Now,
+		baseURL: func() (string, error) {
+			return server.URL, nil
+		},
+		issues: map[string]serviceIssues{},
+	}
+}
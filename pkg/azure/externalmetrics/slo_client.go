@@ -0,0 +1,137 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/custommetrics"
+	"github.com/golang/glog"
+)
+
+const (
+	defaultSLOLongWindow   = time.Hour
+	defaultSLOShortWindow  = 5 * time.Minute
+	defaultSLOTotalMetric  = "requests/count"
+	defaultSLOFailedMetric = "requests/failed"
+)
+
+// SLODefinition describes an availability objective measured with Application Insights.
+// Windows use the go duration format and are parsed when the metric is requested.
+type SLODefinition struct {
+	Objective    string
+	LongWindow   string
+	ShortWindow  string
+	TotalMetric  string
+	FailedMetric string
+}
+
+type slo struct {
+	objective    float64
+	longWindow   time.Duration
+	shortWindow  time.Duration
+	totalMetric  string
+	failedMetric string
+}
+
+type sloBurnRateClient struct {
+	client custommetrics.AzureAppInsightsClient
+}
+
+// NewSLOBurnRateClient creates a client that computes error budget burn rates from the Application
+// Insights api endpoint
+func NewSLOBurnRateClient(credentialSource credentials.Source, appInsightsEndpoint string, appInsightsResource string) AzureExternalMetricClient {
+	return &sloBurnRateClient{
+		client: custommetrics.NewClient(credentialSource, appInsightsEndpoint, appInsightsResource),
+	}
+}
+
+// GetAzureMetric returns the lower of the burn rates over the long and short windows.  A burn
+// rate of 1 spends the error budget exactly over the objective's period, so the value only rises
+// when the budget is burning over both windows and falls as soon as the short window recovers.
+func (c *sloBurnRateClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	s, err := azMetricRequest.SLO.parse()
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	longBurnRate, err := c.burnRate(s, s.longWindow)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	shortBurnRate, err := c.burnRate(s, s.shortWindow)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	glog.V(2).Infof("slo burn rate %s: %f, %s: %f", s.longWindow, longBurnRate, s.shortWindow, shortBurnRate)
+
+	return AzureExternalMetricResponse{
+		Total: math.Min(longBurnRate, shortBurnRate),
+	}, nil
+}
+
+func (c *sloBurnRateClient) burnRate(s slo, window time.Duration) (float64, error) {
+	timespan := iso8601Duration(window)
+
+	totalRequest := custommetrics.NewMetricRequest(s.totalMetric)
+	totalRequest.Timespan = timespan
+	total, err := c.client.GetMetricTotal(totalRequest)
+	if err != nil {
+		return 0, err
+	}
+
+	// no traffic does not spend any of the budget
+	if total <= 0 {
+		return 0, nil
+	}
+
+	failedRequest := custommetrics.NewMetricRequest(s.failedMetric)
+	failedRequest.Timespan = timespan
+	failed, err := c.client.GetMetricTotal(failedRequest)
+	if err != nil {
+		return 0, err
+	}
+
+	errorBudget := 1 - s.objective/100
+	return (failed / total) / errorBudget, nil
+}
+
+func (d SLODefinition) parse() (slo, error) {
+	s := slo{
+		longWindow:   defaultSLOLongWindow,
+		shortWindow:  defaultSLOShortWindow,
+		totalMetric:  d.TotalMetric,
+		failedMetric: d.FailedMetric,
+	}
+
+	var err error
+	if s.objective, err = strconv.ParseFloat(d.Objective, 64); err != nil || s.objective <= 0 || s.objective >= 100 {
+		return s, InvalidMetricRequestError{err: fmt.Sprintf("slo objective '%s' must be a percentage between 0 and 100", d.Objective)}
+	}
+	if d.LongWindow != "" {
+		if s.longWindow, err = time.ParseDuration(d.LongWindow); err != nil {
+			return s, InvalidMetricRequestError{err: fmt.Sprintf("invalid slo long window '%s'", d.LongWindow)}
+		}
+	}
+	if d.ShortWindow != "" {
+		if s.shortWindow, err = time.ParseDuration(d.ShortWindow); err != nil {
+			return s, InvalidMetricRequestError{err: fmt.Sprintf("invalid slo short window '%s'", d.ShortWindow)}
+		}
+	}
+	if s.shortWindow < time.Minute || s.shortWindow%time.Minute != 0 || s.longWindow%time.Minute != 0 || s.longWindow < s.shortWindow {
+		return s, InvalidMetricRequestError{err: "slo windows must be whole minutes and the long window at least the short window"}
+	}
+
+	if s.totalMetric == "" {
+		s.totalMetric = defaultSLOTotalMetric
+	}
+	if s.failedMetric == "" {
+		s.failedMetric = defaultSLOFailedMetric
+	}
+
+	return s, nil
+}
@@ -0,0 +1,125 @@
+package externalmetrics
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/custommetrics"
+)
+
+func TestSLOBurnRateIsLowerOfWindows(t *testing.T) {
+	// 99% objective leaves a 1% budget. the long window fails 2% and the short window 4%
+	fake := fakeAppInsightsTotals{
+		"requests/count PT1H":  1000,
+		"requests/failed PT1H": 20,
+		"requests/count PT5M":  100,
+		"requests/failed PT5M": 4,
+	}
+	client := sloBurnRateClient{client: fake}
+
+	request := AzureExternalMetricRequest{SLO: SLODefinition{Objective: "99"}}
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if math.Abs(metricResponse.Total-2) > 0.0001 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 2)
+	}
+}
+
+func TestSLOBurnRateUsesConfiguredMetricsAndWindows(t *testing.T) {
+	fake := fakeAppInsightsTotals{
+		"dependencies/count PT6H":   400,
+		"dependencies/failed PT6H":  2,
+		"dependencies/count PT30M":  40,
+		"dependencies/failed PT30M": 1,
+	}
+	client := sloBurnRateClient{client: fake}
+
+	request := AzureExternalMetricRequest{SLO: SLODefinition{
+		Objective:    "99.5",
+		LongWindow:   "6h",
+		ShortWindow:  "30m",
+		TotalMetric:  "dependencies/count",
+		FailedMetric: "dependencies/failed",
+	}}
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if math.Abs(metricResponse.Total-1) > 0.0001 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 1)
+	}
+}
+
+func TestSLOBurnRateWithoutTrafficIsZero(t *testing.T) {
+	client := sloBurnRateClient{client: fakeAppInsightsTotals{}}
+
+	request := AzureExternalMetricRequest{SLO: SLODefinition{Objective: "99.9"}}
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if metricResponse.Total != 0 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 0)
+	}
+}
+
+func TestSLOBurnRateReturnsAppInsightsError(t *testing.T) {
+	client := sloBurnRateClient{client: failingAppInsightsClient{}}
+
+	request := AzureExternalMetricRequest{SLO: SLODefinition{Objective: "99.9"}}
+	_, err := client.GetAzureMetric(request)
+
+	if err == nil {
+		t.Errorf("no error after processing got: %v, want error", nil)
+	}
+}
+
+func TestSLOInvalidDefinitionGetError(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition SLODefinition
+	}{
+		{name: "missing objective", definition: SLODefinition{}},
+		{name: "objective of 100", definition: SLODefinition{Objective: "100"}},
+		{name: "invalid window", definition: SLODefinition{Objective: "99", LongWindow: "an hour"}},
+		{name: "short window longer", definition: SLODefinition{Objective: "99", LongWindow: "5m", ShortWindow: "1h"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.definition.parse()
+			if !IsInvalidMetricRequestError(err) {
+				t.Errorf("parse() error = %v, want InvalidMetricRequestError", err)
+			}
+		})
+	}
+}
+
+// fakeAppInsightsTotals returns totals keyed by metric name and timespan
+type fakeAppInsightsTotals map[string]float64
+
+func (f fakeAppInsightsTotals) GetCustomMetric(request custommetrics.MetricRequest) (float64, error) {
+	return 0, nil
+}
+
+func (f fakeAppInsightsTotals) GetMetricTotal(request custommetrics.MetricRequest) (float64, error) {
+	return f[request.MetricName+" "+request.Timespan], nil
+}
+
+type failingAppInsightsClient struct{}
+
+func (f failingAppInsightsClient) GetCustomMetric(request custommetrics.MetricRequest) (float64, error) {
+	return 0, errors.New("app insights unavailable")
+}
+
+func (f failingAppInsightsClient) GetMetricTotal(request custommetrics.MetricRequest) (float64, error) {
+	return 0, errors.New("app insights unavailable")
+}
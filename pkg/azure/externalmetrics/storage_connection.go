@@ -0,0 +1,161 @@
+package externalmetrics
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+)
+
+const (
+	storageResource = "https://storage.azure.com/"
+	// storageVersion is the version of the storage services' api used by the requests
+	storageVersion = "2017-11-09"
+)
+
+// storageGet sends a GET of the url of a storage service, authorized by the connection string when
+// it has a key or shared access signature or with the credentials otherwise, and returns the
+// response with up to limit bytes of its body
+func storageGet(client *http.Client, credentialSource credentials.Source, now time.Time, connection storageConnectionString, requestURL string, limit int64) (*http.Response, []byte, error) {
+	if connection.sas != "" {
+		requestURL = fmt.Sprintf("%s&%s", requestURL, strings.TrimPrefix(connection.sas, "?"))
+	}
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, nil, redact.Error(err)
+	}
+	req.Header.Set("x-ms-version", storageVersion)
+
+	switch {
+	case connection.key != "":
+		req.Header.Set("x-ms-date", now.UTC().Format(http.TimeFormat))
+		if err := connection.signSharedKey(req); err != nil {
+			return nil, nil, err
+		}
+	case connection.sas == "":
+		authorizer, err := credentialSource.Authorizer(storageResource)
+		if err != nil {
+			return nil, nil, redact.Error(err)
+		}
+		if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+			return nil, nil, redact.Error(err)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read response of %s: %v", req.URL.Path, err)
+	}
+	return resp, body, nil
+}
+
+// storageConnectionString is the account, service endpoint and key or shared access signature of
+// a Storage connection string
+type storageConnectionString struct {
+	account  string
+	key      string
+	sas      string
+	endpoint string
+}
+
+// parseStorageConnectionString parses a connection string with an account key, such as
+// DefaultEndpointsProtocol=https;AccountName=<name>;AccountKey=<key>;EndpointSuffix=core.windows.net,
+// or a shared access signature.  The endpoint of the service, queue or blob, is the QueueEndpoint or
+// BlobEndpoint when it is given, or the account's under the endpoint suffix.
+func parseStorageConnectionString(connectionString string, storageSuffix string, service string) (storageConnectionString, error) {
+	var connection storageConnectionString
+	protocol, suffix := "https", storageSuffix
+	for _, part := range strings.Split(connectionString, ";") {
+		pair := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(pair) != 2 {
+			continue
+		}
+		switch name := strings.ToLower(pair[0]); name {
+		case service + "endpoint":
+			connection.endpoint = strings.TrimSuffix(pair[1], "/")
+		case "accountname":
+			connection.account = pair[1]
+		case "accountkey":
+			connection.key = pair[1]
+		case "sharedaccesssignature":
+			connection.sas = pair[1]
+		case "endpointsuffix":
+			suffix = pair[1]
+		case "defaultendpointsprotocol":
+			protocol = pair[1]
+		}
+	}
+
+	if connection.key == "" && connection.sas == "" {
+		return storageConnectionString{}, InvalidMetricRequestError{err: "storage connection string requires AccountKey or SharedAccessSignature"}
+	}
+	if connection.key != "" && connection.account == "" {
+		return storageConnectionString{}, InvalidMetricRequestError{err: "storage connection string with an AccountKey requires AccountName"}
+	}
+	if connection.endpoint == "" {
+		if connection.account == "" {
+			return storageConnectionString{}, InvalidMetricRequestError{err: fmt.Sprintf("storage connection string requires AccountName or the %s endpoint", service)}
+		}
+		connection.endpoint = fmt.Sprintf("%s://%s.%s.%s", protocol, connection.account, service, suffix)
+	}
+	endpoint, err := url.Parse(connection.endpoint)
+	if err != nil || endpoint.Host == "" {
+		return storageConnectionString{}, InvalidMetricRequestError{err: fmt.Sprintf("storage connection string has an invalid %s endpoint", service)}
+	}
+	if connection.account == "" {
+		// the account of a shared access signature is only needed to name it in logs
+		connection.account = strings.SplitN(endpoint.Hostname(), ".", 2)[0]
+	}
+	return connection, nil
+}
+
+// signSharedKey authorizes the GET request with the account key, signing its x-ms headers and
+// resource as the storage services expect
+func (c storageConnectionString) signSharedKey(req *http.Request) error {
+	key, err := base64.StdEncoding.DecodeString(c.key)
+	if err != nil {
+		return InvalidMetricRequestError{err: "storage account key is not base64 encoded"}
+	}
+
+	headers := []string{}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-ms-") {
+			headers = append(headers, fmt.Sprintf("%s:%s\n", name, strings.Join(values, ",")))
+		}
+	}
+	sort.Strings(headers)
+
+	parameters := []string{}
+	for name, values := range req.URL.Query() {
+		sort.Strings(values)
+		parameters = append(parameters, fmt.Sprintf("\n%s:%s", strings.ToLower(name), strings.Join(values, ",")))
+	}
+	sort.Strings(parameters)
+
+	// the verb is followed by the standard headers, which the request doesn't set, each on a line
+	stringToSign := req.Method + "\n" + strings.Repeat("\n", 11) + strings.Join(headers, "") +
+		"/" + c.account + req.URL.EscapedPath() + strings.Join(parameters, "")
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("SharedKey %s:%s", c.account, base64.StdEncoding.EncodeToString(mac.Sum(nil))))
+	return nil
+}
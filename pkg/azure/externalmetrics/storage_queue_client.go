@@ -0,0 +1,187 @@
+package externalmetrics
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/golang/glog"
+)
+
+const (
+	// maxPeekResponseSize limits how much of a peek response is read. A single message is at most 64KiB
+	maxPeekResponseSize = 256 * 1024
+	// approximateMessagesCountHeader is the metadata header of the number of messages in a queue
+	approximateMessagesCountHeader = "x-ms-approximate-messages-count"
+)
+
+var (
+	storageAccountName = regexp.MustCompile(`^[a-z0-9]{3,24}$`)
+	storageQueueName   = regexp.MustCompile(`^[a-z0-9](-?[a-z0-9])*$`)
+)
+
+// StorageQueueDefinition names the Storage queue whose oldest message age or message count is
+// served.  The queue is read with the connection string when it is set, which the provider
+// resolves from the secret, or with the adapter's credentials otherwise.
+type StorageQueueDefinition struct {
+	Account                string
+	Queue                  string
+	ConnectionStringSecret string
+	ConnectionStringKey    string
+	ConnectionString       string
+}
+
+type storageQueueClient struct {
+	credentials credentials.Source
+	client      *http.Client
+	now         func() time.Time
+	// queueURL returns the base url of the queue service of an account
+	queueURL func(account string) (string, error)
+	// storageSuffix is the suffix of the queue services of connection strings without endpoints
+	storageSuffix string
+	// messageCount serves the approximate number of messages rather than the oldest message's age
+	messageCount bool
+}
+
+// NewStorageQueueClient creates a client that serves the age in seconds of the oldest message in a
+// Storage queue of an account under the storage endpoint suffix
+func NewStorageQueueClient(credentialSource credentials.Source, storageSuffix string) AzureExternalMetricClient {
+	return newStorageQueueClient(credentialSource, storageSuffix, false)
+}
+
+// NewStorageQueueLengthClient creates a client that serves the approximate number of messages in a
+// Storage queue of an account under the storage endpoint suffix
+func NewStorageQueueLengthClient(credentialSource credentials.Source, storageSuffix string) AzureExternalMetricClient {
+	return newStorageQueueClient(credentialSource, storageSuffix, true)
+}
+
+func newStorageQueueClient(credentialSource credentials.Source, storageSuffix string, messageCount bool) *storageQueueClient {
+	return &storageQueueClient{
+		credentials: credentialSource,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		now:         time.Now,
+		queueURL: func(account string) (string, error) {
+			return fmt.Sprintf("https://%s.queue.%s", account, storageSuffix), nil
+		},
+		storageSuffix: storageSuffix,
+		messageCount:  messageCount,
+	}
+}
+
+// queueMessagesList is the response of peeking messages
+type queueMessagesList struct {
+	Messages []struct {
+		InsertionTime string `xml:"InsertionTime"`
+	} `xml:"QueueMessage"`
+}
+
+func (c *storageQueueClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	queue := azMetricRequest.StorageQueue
+	var connection storageConnectionString
+	if queue.ConnectionString != "" {
+		var err error
+		connection, err = parseStorageConnectionString(queue.ConnectionString, c.storageSuffix, "queue")
+		if err != nil {
+			return AzureExternalMetricResponse{}, err
+		}
+		queue.Account = connection.account
+	}
+	if !storageAccountName.MatchString(queue.Account) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "storage account name is invalid"}
+	}
+	if len(queue.Queue) < 3 || len(queue.Queue) > 63 || !storageQueueName.MatchString(queue.Queue) {
+		return AzureExternalMetricResponse{}, InvalidMetricRequestError{err: "storage queue name is invalid"}
+	}
+
+	baseURL := connection.endpoint
+	if baseURL == "" {
+		var err error
+		baseURL, err = c.queueURL(queue.Account)
+		if err != nil {
+			return AzureExternalMetricResponse{}, err
+		}
+	}
+
+	if c.messageCount {
+		return c.approximateMessageCount(queue, baseURL, connection)
+	}
+
+	// peeking leaves the message visible and does not change its dequeue count
+	resp, body, err := c.do(queue, connection, fmt.Sprintf("%s/%s/messages?peekonly=true&numofmessages=1", baseURL, queue.Queue))
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return AzureExternalMetricResponse{}, fmt.Errorf("peek of queue %s returned status %d: %s", queue.Queue, resp.StatusCode, redact.String(string(body)))
+	}
+
+	age, err := oldestMessageAge(body, c.now())
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	glog.V(2).Infof("oldest message of queue %s is %f seconds old", queue.Queue, age)
+	return AzureExternalMetricResponse{
+		Total: age,
+		Raw:   []string{string(body)},
+	}, nil
+}
+
+// approximateMessageCount serves the message count the queue reports in its metadata.  It is
+// approximate as messages may be added or removed while it is read.
+func (c *storageQueueClient) approximateMessageCount(queue StorageQueueDefinition, baseURL string, connection storageConnectionString) (AzureExternalMetricResponse, error) {
+	resp, body, err := c.do(queue, connection, fmt.Sprintf("%s/%s?comp=metadata", baseURL, queue.Queue))
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return AzureExternalMetricResponse{}, fmt.Errorf("metadata of queue %s returned status %d: %s", queue.Queue, resp.StatusCode, redact.String(string(body)))
+	}
+
+	header := resp.Header.Get(approximateMessagesCountHeader)
+	count, err := strconv.ParseInt(header, 10, 64)
+	if err != nil {
+		return AzureExternalMetricResponse{}, fmt.Errorf("unable to parse message count '%s' of queue %s: %v", header, queue.Queue, err)
+	}
+
+	glog.V(2).Infof("queue %s has approximately %d messages", queue.Queue, count)
+	return AzureExternalMetricResponse{
+		Total: float64(count),
+		Raw:   []string{fmt.Sprintf("%s: %s", approximateMessagesCountHeader, header)},
+	}, nil
+}
+
+// do sends a GET of the url of the queue service, authorized by the connection string when it is
+// set or the adapter's credentials, and returns the response with its body
+func (c *storageQueueClient) do(queue StorageQueueDefinition, connection storageConnectionString, queueURL string) (*http.Response, []byte, error) {
+	glog.V(2).Infof("requesting queue %s in storage account %s", queue.Queue, queue.Account)
+	return storageGet(c.client, c.credentials, c.now(), connection, queueURL, maxPeekResponseSize)
+}
+
+// oldestMessageAge returns the seconds since the peeked message was inserted, or 0 when the queue is empty
+func oldestMessageAge(body []byte, now time.Time) (float64, error) {
+	var list queueMessagesList
+	if err := xml.Unmarshal(body, &list); err != nil {
+		return 0, fmt.Errorf("unable to parse peek response: %v", err)
+	}
+	if len(list.Messages) == 0 {
+		return 0, nil
+	}
+
+	inserted, err := time.Parse(time.RFC1123, list.Messages[0].InsertionTime)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse message insertion time '%s': %v", list.Messages[0].InsertionTime, err)
+	}
+
+	age := now.Sub(inserted).Seconds()
+	if age < 0 {
+		// clock skew between the adapter and storage
+		return 0, nil
+	}
+	return age, nil
+}
@@ -0,0 +1,234 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest"
+)
+
+var testQueueNow = time.Date(2019, 3, 4, 12, 0, 0, 0, time.UTC)
+
+func TestStorageQueueReturnsOldestMessageAge(t *testing.T) {
+	query := ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RequestURI()
+		fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><QueueMessagesList><QueueMessage><MessageId>1</MessageId><InsertionTime>Mon, 04 Mar 2019 11:58:30 GMT</InsertionTime><DequeueCount>0</DequeueCount><MessageText>aGVsbG8=</MessageText></QueueMessage></QueueMessagesList>`)
+	}))
+	defer server.Close()
+
+	client := newTestStorageQueueClient(server)
+	metricResponse, err := client.GetAzureMetric(newStorageQueueMetricRequest())
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if metricResponse.Total != 90 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 90)
+	}
+
+	if query != "/orders/messages?peekonly=true&numofmessages=1" {
+		t.Errorf("query = %v, want peek of one message", query)
+	}
+}
+
+func TestStorageQueueEmptyReturnsZero(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><QueueMessagesList />`)
+	}))
+	defer server.Close()
+
+	client := newTestStorageQueueClient(server)
+	metricResponse, err := client.GetAzureMetric(newStorageQueueMetricRequest())
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if metricResponse.Total != 0 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 0)
+	}
+}
+
+func TestStorageQueueErrorStatusGetError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "QueueNotFound", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := newTestStorageQueueClient(server)
+	_, err := client.GetAzureMetric(newStorageQueueMetricRequest())
+
+	if err == nil {
+		t.Errorf("no error after processing got: %v, want error", nil)
+	}
+}
+
+func TestStorageQueueInvalidNamesGetError(t *testing.T) {
+	var tests = []StorageQueueDefinition{
+		{Account: "", Queue: "orders"},
+		{Account: "Account", Queue: "orders"},
+		{Account: "account", Queue: "or"},
+		{Account: "account", Queue: "orders--high"},
+		{Account: "account", Queue: "orders/../other"},
+	}
+
+	client := NewStorageQueueClient(fakeCredentialSource{}, "core.windows.net")
+	for _, queue := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{StorageQueue: queue})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%+v) got %v, want InvalidMetricRequestError", queue, err)
+		}
+	}
+}
+
+func TestStorageQueueLengthReturnsApproximateMessageCount(t *testing.T) {
+	query := ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RequestURI()
+		w.Header().Set("x-ms-approximate-messages-count", "42")
+	}))
+	defer server.Close()
+
+	client := newTestStorageQueueClient(server)
+	client.messageCount = true
+	request := newStorageQueueMetricRequest()
+	request.Type = StorageQueueLength
+	metricResponse, err := client.GetAzureMetric(request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if metricResponse.Total != 42 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 42)
+	}
+	if query != "/orders?comp=metadata" {
+		t.Errorf("query = %v, want metadata of the queue", query)
+	}
+}
+
+func TestStorageQueueLengthMissingCountGetError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := newTestStorageQueueClient(server)
+	client.messageCount = true
+	_, err := client.GetAzureMetric(newStorageQueueMetricRequest())
+
+	if err == nil {
+		t.Errorf("no error after processing got: %v, want error", nil)
+	}
+}
+
+func TestStorageQueueSignedWithAccountKey(t *testing.T) {
+	authorization, date := "", ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization, date = r.Header.Get("Authorization"), r.Header.Get("x-ms-date")
+		w.Header().Set("x-ms-approximate-messages-count", "3")
+	}))
+	defer server.Close()
+
+	client := NewStorageQueueLengthClient(fakeCredentialSource{}, "core.windows.net").(*storageQueueClient)
+	client.client = server.Client()
+	client.now = func() time.Time { return testQueueNow }
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type: StorageQueueLength,
+		StorageQueue: StorageQueueDefinition{
+			Queue:            "orders",
+			ConnectionString: fmt.Sprintf("DefaultEndpointsProtocol=https;AccountName=account;AccountKey=c2VjcmV0;QueueEndpoint=%s/", server.URL),
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if !strings.HasPrefix(authorization, "SharedKey account:") {
+		t.Errorf("authorization = %v, want shared key of the account", authorization)
+	}
+	if date != "Mon, 04 Mar 2019 12:00:00 GMT" {
+		t.Errorf("x-ms-date = %v, want the time of the request", date)
+	}
+}
+
+func TestStorageQueueSharedAccessSignatureAddedToQuery(t *testing.T) {
+	query, authorization := "", ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query, authorization = r.URL.RawQuery, r.Header.Get("Authorization")
+		w.Header().Set("x-ms-approximate-messages-count", "3")
+	}))
+	defer server.Close()
+
+	client := NewStorageQueueLengthClient(fakeCredentialSource{}, "core.windows.net").(*storageQueueClient)
+	client.client = server.Client()
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{
+		Type: StorageQueueLength,
+		StorageQueue: StorageQueueDefinition{
+			Queue:            "orders",
+			ConnectionString: fmt.Sprintf("QueueEndpoint=%s;SharedAccessSignature=sv=2018-03-28&sp=r&sig=abc", server.URL),
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if query != "comp=metadata&sv=2018-03-28&sp=r&sig=abc" {
+		t.Errorf("query = %v, want the shared access signature added", query)
+	}
+	if authorization != "" {
+		t.Errorf("authorization = %v, want none with a shared access signature", authorization)
+	}
+}
+
+func TestStorageQueueInvalidConnectionStringsGetError(t *testing.T) {
+	var tests = []string{
+		"AccountKey=c2VjcmV0",
+		"AccountName=account",
+		"AccountName=account;AccountKey=not base64!",
+		"AccountName=account;AccountKey=c2VjcmV0;QueueEndpoint=://queue",
+	}
+
+	client := NewStorageQueueLengthClient(fakeCredentialSource{}, "core.windows.net")
+	for _, connectionString := range tests {
+		_, err := client.GetAzureMetric(AzureExternalMetricRequest{StorageQueue: StorageQueueDefinition{Queue: "orders", ConnectionString: connectionString}})
+		if !IsInvalidMetricRequestError(err) {
+			t.Errorf("GetAzureMetric(%q) got %v, want InvalidMetricRequestError", connectionString, err)
+		}
+	}
+}
+
+func newStorageQueueMetricRequest() AzureExternalMetricRequest {
+	return AzureExternalMetricRequest{
+		Type:          StorageQueueMessageAge,
+		ResourceGroup: "rg",
+		StorageQueue: StorageQueueDefinition{
+			Account: "account",
+			Queue:   "orders",
+		},
+	}
+}
+
+func newTestStorageQueueClient(server *httptest.Server) *storageQueueClient {
+	return &storageQueueClient{
+		credentials: nullCredentialSource{},
+		client:      server.Client(),
+		now:         func() time.Time { return testQueueNow },
+		queueURL: func(account string) (string, error) {
+			return server.URL, nil
+		},
+	}
+}
+
+type nullCredentialSource struct{}
+
+func (nullCredentialSource) Authorizer(resource string) (autorest.Authorizer, error) {
+	return autorest.NullAuthorizer{}, nil
+}
+
+func (nullCredentialSource) Value(name string) string {
+	return ""
+}
@@ -0,0 +1,178 @@
+package externalmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+// AllSubscriptions in the subscriptions of a request queries every subscription the adapter can access
+const AllSubscriptions = "*"
+
+// Aggregations of the values of a metric across subscriptions
+const (
+	SubscriptionSum     string = "sum"
+	SubscriptionAverage string = "average"
+	SubscriptionMinimum string = "minimum"
+	SubscriptionMaximum string = "maximum"
+)
+
+const (
+	subscriptionsAPIVersion = "2016-06-01"
+	subscriptionsCacheTTL   = 5 * time.Minute
+)
+
+// SubscriptionLister lists the subscriptions the adapter's identity can access
+type SubscriptionLister interface {
+	ListSubscriptions() ([]string, error)
+}
+
+type armSubscriptionLister struct {
+	credentials     credentials.Source
+	client          *http.Client
+	now             func() time.Time
+	resourceManager string
+
+	mu            sync.Mutex
+	subscriptions []string
+	expires       time.Time
+}
+
+// NewSubscriptionLister creates a lister that asks Azure Resource Manager for the enabled
+// subscriptions.  The list is cached for a few minutes as it rarely changes.
+func NewSubscriptionLister(credentialSource credentials.Source, resourceManager string) SubscriptionLister {
+	return &armSubscriptionLister{
+		credentials:     credentialSource,
+		client:          &http.Client{Timeout: 30 * time.Second},
+		now:             time.Now,
+		resourceManager: strings.TrimSuffix(resourceManager, "/"),
+	}
+}
+
+func (l *armSubscriptionLister) ListSubscriptions() ([]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.subscriptions != nil && l.now().Before(l.expires) {
+		return l.subscriptions, nil
+	}
+
+	authorizer, err := l.credentials.Authorizer("")
+	if err != nil {
+		return nil, redact.Error(err)
+	}
+
+	subscriptions := []string{}
+	next := fmt.Sprintf("%s/subscriptions?api-version=%s", l.resourceManager, subscriptionsAPIVersion)
+	for next != "" {
+		page, err := l.listPage(next, authorizer)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, s := range page.Value {
+			if s.State == "Enabled" {
+				subscriptions = append(subscriptions, s.SubscriptionID)
+			}
+		}
+		next = page.NextLink
+	}
+
+	glog.V(2).Infof("found %d accessible subscriptions", len(subscriptions))
+	l.subscriptions = subscriptions
+	l.expires = l.now().Add(subscriptionsCacheTTL)
+	return subscriptions, nil
+}
+
+type subscriptionListResult struct {
+	Value []struct {
+		SubscriptionID string `json:"subscriptionId"`
+		State          string `json:"state"`
+	} `json:"value"`
+	NextLink string `json:"nextLink"`
+}
+
+func (l *armSubscriptionLister) listPage(url string, authorizer autorest.Authorizer) (subscriptionListResult, error) {
+	result := subscriptionListResult{}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return result, err
+	}
+	if req, err = autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+		return result, redact.Error(err)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return result, redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return result, fmt.Errorf("unable to read subscriptions: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return result, fmt.Errorf("unable to list subscriptions, status %d: %s", resp.StatusCode, redact.String(string(body)))
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return result, fmt.Errorf("unable to parse subscriptions: %v", err)
+	}
+	return result, nil
+}
+
+// AggregateSubscriptions combines the values of a metric from each subscription
+func AggregateSubscriptions(aggregation string, values []float64) (float64, error) {
+	return aggregate("subscription", aggregation, values)
+}
+
+// AggregateResources combines the values of a metric from each resource
+func AggregateResources(aggregation string, values []float64) (float64, error) {
+	return aggregate("resource", aggregation, values)
+}
+
+// aggregate combines the values of a metric with sum, average, minimum or maximum, naming the kind
+// of aggregation in its error
+func aggregate(kind string, aggregation string, values []float64) (float64, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+
+	result := values[0]
+	switch strings.ToLower(aggregation) {
+	case "", SubscriptionSum, SubscriptionAverage:
+		for _, v := range values[1:] {
+			result += v
+		}
+		if strings.ToLower(aggregation) == SubscriptionAverage {
+			result = result / float64(len(values))
+		}
+	case SubscriptionMinimum:
+		for _, v := range values[1:] {
+			if v < result {
+				result = v
+			}
+		}
+	case SubscriptionMaximum:
+		for _, v := range values[1:] {
+			if v > result {
+				result = v
+			}
+		}
+	default:
+		return 0, InvalidMetricRequestError{err: fmt.Sprintf("%s aggregation must be %s, %s, %s or %s", kind, SubscriptionSum, SubscriptionAverage, SubscriptionMinimum, SubscriptionMaximum)}
+	}
+
+	return result, nil
+}
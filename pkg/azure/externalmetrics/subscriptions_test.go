@@ -0,0 +1,36 @@
+package externalmetrics
+
+import (
+	"testing"
+)
+
+func TestAggregateSubscriptions(t *testing.T) {
+	values := []float64{4, 10, 1}
+	tests := []struct {
+		aggregation string
+		want        float64
+	}{
+		{aggregation: "", want: 15},
+		{aggregation: "sum", want: 15},
+		{aggregation: "Average", want: 5},
+		{aggregation: "minimum", want: 1},
+		{aggregation: "maximum", want: 10},
+	}
+	for _, tt := range tests {
+		got, err := AggregateSubscriptions(tt.aggregation, values)
+		if err != nil {
+			t.Errorf("AggregateSubscriptions(%s) error = %v, want nil", tt.aggregation, err)
+		}
+		if got != tt.want {
+			t.Errorf("AggregateSubscriptions(%s) = %v, want %v", tt.aggregation, got, tt.want)
+		}
+	}
+}
+
+func TestAggregateSubscriptionsUnknownAggregationGetError(t *testing.T) {
+	_, err := AggregateSubscriptions("median", []float64{1, 2})
+
+	if !IsInvalidMetricRequestError(err) {
+		t.Errorf("AggregateSubscriptions() error = %v, want InvalidMetricRequestError", err)
+	}
+}
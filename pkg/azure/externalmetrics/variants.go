@@ -0,0 +1,48 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/transform"
+	"github.com/Azure/azure-sdk-for-go/services/preview/monitor/mgmt/2018-03-01/insights"
+)
+
+// VariantDefinition is a view of the value of a metric served under the metric's name with the
+// suffix, derived from the value without querying Azure again
+type VariantDefinition struct {
+	Suffix    string
+	Rate      bool
+	Transform transform.Transform
+}
+
+// ValidateVariants returns an error if a variant has no suffix, or the same suffix as another
+// variant, or an invalid transform.  Suffixes start with - so the metric of a variant is found by
+// its name.
+func ValidateVariants(variants []VariantDefinition) error {
+	suffixes := map[string]bool{}
+	for _, variant := range variants {
+		if len(variant.Suffix) < 2 || !strings.HasPrefix(variant.Suffix, "-") {
+			return InvalidMetricRequestError{err: fmt.Sprintf("invalid variant suffix '%s', must start with - such as -rate", variant.Suffix)}
+		}
+		if suffixes[variant.Suffix] {
+			return InvalidMetricRequestError{err: fmt.Sprintf("duplicate variant suffix '%s'", variant.Suffix)}
+		}
+		suffixes[variant.Suffix] = true
+		if err := variant.Transform.Validate(); err != nil {
+			return InvalidMetricRequestError{err: fmt.Sprintf("invalid transform of variant '%s': %v", variant.Suffix, err)}
+		}
+	}
+	return nil
+}
+
+// RateUnit returns the unit of the per second rate of a value in the unit
+func RateUnit(unit string) string {
+	switch unit {
+	case UnitBytes:
+		return UnitBytesPerSecond
+	case string(insights.UnitCount):
+		return string(insights.UnitCountPerSecond)
+	}
+	return unit
+}
@@ -0,0 +1,175 @@
+package externalmetrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/golang/glog"
+)
+
+// maxWebhookResponseSize limits how much of a webhook response is read
+const maxWebhookResponseSize = 64 * 1024
+
+// WebhookDefinition describes an https endpoint that returns the value of a metric as a json number.
+// At most one of AADResource and BearerToken is set.
+type WebhookDefinition struct {
+	URL         string
+	AADResource string
+	BearerToken string
+}
+
+// WebhookOptions are set by the adapter administrator to control which endpoints webhook metrics can call
+type WebhookOptions struct {
+	// AllowedHosts are the host names webhook metrics can call. Webhook metrics are disabled when empty
+	AllowedHosts []string
+	// TokenDir contains the bearer token files webhook metrics can reference by name
+	TokenDir string
+}
+
+type webhookClient struct {
+	options     WebhookOptions
+	credentials credentials.Source
+	client      *http.Client
+}
+
+// NewWebhookClient creates a client that serves metrics from https endpoints
+func NewWebhookClient(credentialSource credentials.Source, options WebhookOptions) AzureExternalMetricClient {
+	return &webhookClient{
+		options:     options,
+		credentials: credentialSource,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			// a redirect could send the token to a host that is not allowed
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+func (c *webhookClient) GetAzureMetric(azMetricRequest AzureExternalMetricRequest) (AzureExternalMetricResponse, error) {
+	webhook := azMetricRequest.Webhook
+
+	endpoint, err := c.validate(webhook)
+	if err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	req, err := http.NewRequest("GET", endpoint.String(), nil)
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	if err := c.authorize(req, webhook); err != nil {
+		return AzureExternalMetricResponse{}, err
+	}
+
+	glog.V(2).Infof("request to webhook: %s", redact.URL(endpoint))
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return AzureExternalMetricResponse{}, redact.Error(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxWebhookResponseSize))
+	if err != nil {
+		return AzureExternalMetricResponse{}, fmt.Errorf("unable to read webhook response: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return AzureExternalMetricResponse{}, fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, redact.String(string(body)))
+	}
+
+	var value float64
+	if err := json.Unmarshal(body, &value); err != nil {
+		return AzureExternalMetricResponse{}, fmt.Errorf("webhook response must be a json number")
+	}
+
+	glog.V(2).Infof("webhook metric %s value: %f", azMetricRequest.MetricName, value)
+	return AzureExternalMetricResponse{
+		Total: value,
+		Raw:   []string{string(body)},
+	}, nil
+}
+
+func (c *webhookClient) validate(webhook WebhookDefinition) (*url.URL, error) {
+	if len(c.options.AllowedHosts) == 0 {
+		return nil, InvalidMetricRequestError{err: "webhook metrics are not enabled. set --webhook-allowed-hosts"}
+	}
+
+	endpoint, err := url.Parse(webhook.URL)
+	if err != nil || webhook.URL == "" {
+		return nil, InvalidMetricRequestError{err: "webhook url is invalid"}
+	}
+	if endpoint.Scheme != "https" {
+		return nil, InvalidMetricRequestError{err: "webhook url must use https"}
+	}
+	if !c.hostAllowed(endpoint.Hostname()) {
+		return nil, InvalidMetricRequestError{err: fmt.Sprintf("webhook host %s is not allowed", endpoint.Hostname())}
+	}
+
+	if webhook.AADResource != "" && webhook.BearerToken != "" {
+		return nil, InvalidMetricRequestError{err: "webhook can use aadResource or bearerToken but not both"}
+	}
+
+	return endpoint, nil
+}
+
+func (c *webhookClient) hostAllowed(host string) bool {
+	for _, allowed := range c.options.AllowedHosts {
+		if strings.EqualFold(allowed, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *webhookClient) authorize(req *http.Request, webhook WebhookDefinition) error {
+	switch {
+	case webhook.AADResource != "":
+		authorizer, err := c.credentials.Authorizer(webhook.AADResource)
+		if err != nil {
+			return redact.Error(err)
+		}
+		if _, err := autorest.Prepare(req, authorizer.WithAuthorization()); err != nil {
+			return redact.Error(err)
+		}
+	case webhook.BearerToken != "":
+		token, err := c.readToken(webhook.BearerToken)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return nil
+}
+
+// readToken reads the named token every request so rotated tokens are used without a restart
+func (c *webhookClient) readToken(name string) (string, error) {
+	if c.options.TokenDir == "" {
+		return "", InvalidMetricRequestError{err: "webhook bearer tokens are not enabled. set --webhook-token-dir"}
+	}
+	// only files directly in the token directory can be referenced
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", InvalidMetricRequestError{err: fmt.Sprintf("invalid webhook bearer token name '%s'", name)}
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(c.options.TokenDir, name))
+	if err != nil {
+		return "", fmt.Errorf("unable to read webhook bearer token %s", name)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,140 @@
+package externalmetrics
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWebhookReturnsJsonNumber(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "42.5")
+	}))
+	defer server.Close()
+
+	client := newTestWebhookClient(server, WebhookOptions{})
+	metricResponse, err := client.GetAzureMetric(AzureExternalMetricRequest{Webhook: WebhookDefinition{URL: server.URL}})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if metricResponse.Total != 42.5 {
+		t.Errorf("metricResponse.Total = %v, want = %v", metricResponse.Total, 42.5)
+	}
+}
+
+func TestWebhookSendsBearerToken(t *testing.T) {
+	authorization := ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		fmt.Fprint(w, "1")
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "webhook")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "scaler"), []byte("secret-token\n"), 0600)
+
+	client := newTestWebhookClient(server, WebhookOptions{TokenDir: dir})
+	_, err = client.GetAzureMetric(AzureExternalMetricRequest{Webhook: WebhookDefinition{URL: server.URL, BearerToken: "scaler"}})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if authorization != "Bearer secret-token" {
+		t.Errorf("Authorization = %v, want bearer token", authorization)
+	}
+}
+
+func TestWebhookErrorStatusGetError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := newTestWebhookClient(server, WebhookOptions{})
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{Webhook: WebhookDefinition{URL: server.URL}})
+
+	if err == nil {
+		t.Errorf("no error after processing got: %v, want error", nil)
+	}
+}
+
+func TestWebhookNonNumberGetError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"value": 1}`)
+	}))
+	defer server.Close()
+
+	client := newTestWebhookClient(server, WebhookOptions{})
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{Webhook: WebhookDefinition{URL: server.URL}})
+
+	if err == nil {
+		t.Errorf("no error after processing got: %v, want error", nil)
+	}
+}
+
+func TestWebhookDoesNotFollowRedirects(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirected" {
+			fmt.Fprint(w, "1")
+			return
+		}
+		http.Redirect(w, r, "/redirected", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client := newTestWebhookClient(server, WebhookOptions{})
+	_, err := client.GetAzureMetric(AzureExternalMetricRequest{Webhook: WebhookDefinition{URL: server.URL}})
+
+	if err == nil {
+		t.Errorf("no error after processing got: %v, want error", nil)
+	}
+}
+
+func TestWebhookInvalidDefinitionGetError(t *testing.T) {
+	options := WebhookOptions{AllowedHosts: []string{"metrics.contoso.com"}, TokenDir: "/tokens"}
+	tests := []struct {
+		name    string
+		options WebhookOptions
+		webhook WebhookDefinition
+	}{
+		{name: "not enabled", options: WebhookOptions{}, webhook: WebhookDefinition{URL: "https://metrics.contoso.com/value"}},
+		{name: "http", options: options, webhook: WebhookDefinition{URL: "http://metrics.contoso.com/value"}},
+		{name: "host not allowed", options: options, webhook: WebhookDefinition{URL: "https://attacker.example.com/value"}},
+		{name: "both auth methods", options: options, webhook: WebhookDefinition{URL: "https://metrics.contoso.com/value", AADResource: "https://contoso.com", BearerToken: "scaler"}},
+		{name: "token outside dir", options: options, webhook: WebhookDefinition{URL: "https://metrics.contoso.com/value", BearerToken: "../secret"}},
+		{name: "tokens not enabled", options: WebhookOptions{AllowedHosts: options.AllowedHosts}, webhook: WebhookDefinition{URL: "https://metrics.contoso.com/value", BearerToken: "scaler"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewWebhookClient(nil, tt.options)
+			_, err := client.GetAzureMetric(AzureExternalMetricRequest{Webhook: tt.webhook})
+			if !IsInvalidMetricRequestError(err) {
+				t.Errorf("GetAzureMetric() error = %v, want InvalidMetricRequestError", err)
+			}
+		})
+	}
+}
+
+// newTestWebhookClient allows the test server's host and trusts its certificate
+func newTestWebhookClient(server *httptest.Server, options WebhookOptions) AzureExternalMetricClient {
+	u, _ := url.Parse(server.URL)
+	options.AllowedHosts = []string{u.Hostname()}
+
+	client := NewWebhookClient(nil, options).(*webhookClient)
+	httpClient := server.Client()
+	httpClient.CheckRedirect = client.client.CheckRedirect
+	client.client = httpClient
+	return client
+}
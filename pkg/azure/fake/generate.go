@@ -0,0 +1,24 @@
+// Package fake provides fakes of the Azure clients used by the provider, so projects embedding the
+// provider can test it without mocking Azure at the http level.  The fakes are generated by
+// counterfeiter, are safe for concurrent use and record the calls they are given.
+package fake
+
+import (
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+)
+
+//go:generate counterfeiter -o external_metric_client.go ../externalmetrics AzureExternalMetricClient
+//go:generate counterfeiter -o client_factory.go . ClientFactory
+//go:generate counterfeiter -o insights_monitor_client.go ../externalmetrics InsightsMonitorClient
+//go:generate counterfeiter -o app_insights_client.go ../custommetrics AzureAppInsightsClient
+//go:generate counterfeiter -o alert_checker.go ../externalmetrics AlertChecker
+//go:generate counterfeiter -o subscription_lister.go ../externalmetrics SubscriptionLister
+//go:generate counterfeiter -o resource_lister.go ../externalmetrics ResourceLister
+//go:generate counterfeiter -o service_health.go ../externalmetrics ServiceHealth
+
+// ClientFactory is the factory of the clients of each metric type, which also creates the clients
+// of metrics that reference a named credential
+type ClientFactory interface {
+	externalmetrics.AzureClientFactory
+	externalmetrics.CredentialFactory
+}
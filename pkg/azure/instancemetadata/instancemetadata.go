@@ -6,41 +6,117 @@
 package instancemetadata
 
 import (
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/golang/glog"
 )
 
+// metadataEndpoint is the Azure Instance Metadata Service of the vm the adapter runs on
+var metadataEndpoint = "http://169.254.169.254"
+
+// SetEndpoint sets the address of the instance metadata service, such as the node managed identity
+// endpoint of aad-pod-identity
+func SetEndpoint(endpoint string) {
+	metadataEndpoint = strings.TrimSuffix(endpoint, "/")
+}
+
+// AzureConfig is the Azure configuration of the vm the adapter runs on
 type AzureConfig struct {
 	SubscriptionID string
+	// Location is the region of the vm, such as westus2
+	Location string
+	// Environment is the name of the cloud of the vm, such as AzurePublicCloud
+	Environment string
+	// TenantID is the tenant of the vm's managed identity. Empty when the vm has no identity
+	TenantID string
+	// ResourceGroup is the resource group of the vm, the node resource group on AKS
+	ResourceGroup string
 }
 
+// AKSCluster returns the resource group and name of the AKS cluster of a node resource group named
+// MC_<resource group>_<cluster>_<location>.  Names containing underscores can't be told apart,
+// so found is false for them and for resource groups not created by AKS.
+func (c AzureConfig) AKSCluster() (resourceGroup string, name string, found bool) {
+	suffix := "_" + strings.ToLower(c.Location)
+	if c.Location == "" || !strings.HasPrefix(c.ResourceGroup, "MC_") || !strings.HasSuffix(strings.ToLower(c.ResourceGroup), suffix) {
+		return "", "", false
+	}
+
+	parts := strings.Split(c.ResourceGroup[len("MC_"):len(c.ResourceGroup)-len(suffix)], "_")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+type computeMetadata struct {
+	SubscriptionID string `json:"subscriptionId"`
+	Location       string `json:"location"`
+	AzEnvironment  string `json:"azEnvironment"`
+	ResourceGroup  string `json:"resourceGroupName"`
+}
+
+type identityInfo struct {
+	TenantID string `json:"tenantId"`
+}
+
+// GetAzureConfig reads the subscription, location, resource group and cloud of the vm, and the
+// tenant of its managed identity, from instance metadata
 func GetAzureConfig() (AzureConfig, error) {
-	client := &http.Client{}
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	compute := computeMetadata{}
+	if err := getMetadata(client, "/metadata/instance/compute", "2019-06-04", &compute); err != nil {
+		glog.Errorf("unable to get metadata for azure vm: %v", err)
+		return AzureConfig{}, err
+	}
+
+	glog.V(2).Infoln("connected to sub:", compute.SubscriptionID)
 
-	req, _ := http.NewRequest("GET", "http://169.254.169.254/metadata/instance/compute/subscriptionId", nil)
+	config := AzureConfig{
+		SubscriptionID: compute.SubscriptionID,
+		Location:       compute.Location,
+		Environment:    compute.AzEnvironment,
+		ResourceGroup:  compute.ResourceGroup,
+	}
+
+	// the tenant is only known when the vm has a managed identity
+	identity := identityInfo{}
+	if err := getMetadata(client, "/metadata/identity/info", "2018-02-01", &identity); err != nil {
+		glog.V(2).Infof("unable to get identity metadata for azure vm: %v", err)
+	}
+	config.TenantID = identity.TenantID
+
+	return config, nil
+}
+
+func getMetadata(client *http.Client, path string, apiVersion string, result interface{}) error {
+	req, _ := http.NewRequest("GET", metadataEndpoint+path, nil)
 	req.Header.Add("Metadata", "True")
 
 	q := req.URL.Query()
-	q.Add("format", "text")
-	q.Add("api-version", "2017-12-01")
+	q.Add("format", "json")
+	q.Add("api-version", apiVersion)
 	req.URL.RawQuery = q.Encode()
 
 	resp, err := client.Do(req)
 	if err != nil {
-		glog.Errorf("unable to get metadata for azure vm: %v", err)
-		return AzureConfig{}, err
+		return err
 	}
 
 	defer resp.Body.Close()
-	respBody, _ := ioutil.ReadAll(resp.Body)
-	subID := string(respBody[:])
-
-	glog.V(2).Infoln("connected to sub:", subID)
-
-	config := AzureConfig{
-		SubscriptionID: subID,
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
 	}
-	return config, nil
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("instance metadata returned %s", resp.Status)
+	}
+
+	return json.Unmarshal(respBody, result)
 }
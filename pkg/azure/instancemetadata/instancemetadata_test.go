@@ -0,0 +1,79 @@
+package instancemetadata
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetAzureConfigReadsComputeAndIdentity(t *testing.T) {
+	var tests = []struct {
+		name     string
+		identity int
+		want     AzureConfig
+	}{
+		{"with identity", http.StatusOK, AzureConfig{SubscriptionID: "sub", Location: "westus2", Environment: "AzurePublicCloud", TenantID: "tenant", ResourceGroup: "MC_rg_aks_westus2"}},
+		{"without identity", http.StatusNotFound, AzureConfig{SubscriptionID: "sub", Location: "westus2", Environment: "AzurePublicCloud", ResourceGroup: "MC_rg_aks_westus2"}},
+	}
+
+	for _, tt := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Metadata") != "True" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			switch r.URL.Path {
+			case "/metadata/instance/compute":
+				w.Write([]byte(`{"subscriptionId":"sub","location":"westus2","azEnvironment":"AzurePublicCloud","name":"aks-nodepool1-0","resourceGroupName":"MC_rg_aks_westus2"}`))
+			case "/metadata/identity/info":
+				w.WriteHeader(tt.identity)
+				w.Write([]byte(`{"tenantId":"tenant"}`))
+			}
+		}))
+		metadataEndpoint = server.URL
+
+		config, err := GetAzureConfig()
+		server.Close()
+
+		if err != nil {
+			t.Fatalf("%s: GetAzureConfig() error = %v, want nil", tt.name, err)
+		}
+		if config != tt.want {
+			t.Errorf("%s: GetAzureConfig() = %+v, want %+v", tt.name, config, tt.want)
+		}
+	}
+}
+
+func TestGetAzureConfigFailsWithoutMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+	metadataEndpoint = server.URL
+
+	if _, err := GetAzureConfig(); err == nil {
+		t.Errorf("GetAzureConfig() error = nil, want error")
+	}
+}
+
+func TestAKSClusterParsedFromNodeResourceGroup(t *testing.T) {
+	var tests = []struct {
+		resourceGroup string
+		wantGroup     string
+		wantName      string
+		wantFound     bool
+	}{
+		{"MC_rg_aks_westus2", "rg", "aks", true},
+		{"MC_my-rg_my-aks_WestUS2", "my-rg", "my-aks", true},
+		{"MC_my_rg_aks_westus2", "", "", false},
+		{"custom-node-rg", "", "", false},
+		{"MC_rg_aks_eastus", "", "", false},
+	}
+
+	for _, tt := range tests {
+		group, name, found := AzureConfig{Location: "westus2", ResourceGroup: tt.resourceGroup}.AKSCluster()
+		if group != tt.wantGroup || name != tt.wantName || found != tt.wantFound {
+			t.Errorf("%s: AKSCluster() = %s, %s, %v, want %s, %s, %v", tt.resourceGroup, group, name, found, tt.wantGroup, tt.wantName, tt.wantFound)
+		}
+	}
+}
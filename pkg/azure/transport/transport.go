@@ -0,0 +1,39 @@
+// Package transport configures the http transport the Azure clients of the adapter send their
+// requests with.  Every client uses the default transport, which honors HTTPS_PROXY and NO_PROXY,
+// so proxies and certificate authorities configured here apply to all of them.
+package transport
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// AddCABundle trusts the PEM encoded certificates of the file, such as the certificate authority
+// of a TLS intercepting proxy, in addition to the system's certificate authorities
+func AddCABundle(path string) error {
+	bundle, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("unable to read ca bundle %s: %v", path, err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(bundle) {
+		return fmt.Errorf("ca bundle %s has no PEM encoded certificates", path)
+	}
+
+	transport, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("the default transport is a %T, not an http transport", http.DefaultTransport)
+	}
+	if transport.TLSClientConfig == nil {
+		transport.TLSClientConfig = &tls.Config{}
+	}
+	transport.TLSClientConfig.RootCAs = pool
+	return nil
+}
@@ -0,0 +1,55 @@
+package transport
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCABundleTrustsServerCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := &http.Client{}
+	if _, err := client.Get(server.URL); err == nil {
+		t.Fatalf("server with an untrusted certificate was called without error")
+	}
+
+	dir, _ := ioutil.TempDir("", "transport")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ca.pem")
+	ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0600)
+
+	if err := AddCABundle(path); err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	http.DefaultTransport.(*http.Transport).CloseIdleConnections()
+	if _, err := client.Get(server.URL); err != nil {
+		t.Errorf("error calling the server got: %v, want nil", err)
+	}
+}
+
+func TestInvalidCABundle(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "transport")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ca.pem")
+	ioutil.WriteFile(path, []byte("not a certificate"), 0600)
+
+	var tests = []struct {
+		name string
+		path string
+	}{
+		{"missing file", filepath.Join(dir, "missing.pem")},
+		{"no certificates", path},
+	}
+
+	for _, tt := range tests {
+		if err := AddCABundle(tt.path); err == nil {
+			t.Errorf("%s: error after processing got: nil, want error", tt.name)
+		}
+	}
+}
@@ -38,6 +38,7 @@ type ExternalMetricsGetter interface {
 type ExternalMetricInterface interface {
 	Create(*v1alpha2.ExternalMetric) (*v1alpha2.ExternalMetric, error)
 	Update(*v1alpha2.ExternalMetric) (*v1alpha2.ExternalMetric, error)
+	UpdateStatus(*v1alpha2.ExternalMetric) (*v1alpha2.ExternalMetric, error)
 	Delete(name string, options *v1.DeleteOptions) error
 	DeleteCollection(options *v1.DeleteOptions, listOptions v1.ListOptions) error
 	Get(name string, options v1.GetOptions) (*v1alpha2.ExternalMetric, error)
@@ -130,6 +131,22 @@ func (c *externalMetrics) Update(externalMetric *v1alpha2.ExternalMetric) (resul
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+
+func (c *externalMetrics) UpdateStatus(externalMetric *v1alpha2.ExternalMetric) (result *v1alpha2.ExternalMetric, err error) {
+	result = &v1alpha2.ExternalMetric{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("externalmetrics").
+		Name(externalMetric.Name).
+		SubResource("status").
+		Body(externalMetric).
+		Do().
+		Into(result)
+	return
+}
+
 // Delete takes name of the externalMetric and deletes it. Returns an error if one occurs.
 func (c *externalMetrics) Delete(name string, options *v1.DeleteOptions) error {
 	return c.client.Delete().
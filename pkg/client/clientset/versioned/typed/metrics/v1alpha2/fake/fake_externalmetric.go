@@ -99,6 +99,18 @@ func (c *FakeExternalMetrics) Update(externalMetric *v1alpha2.ExternalMetric) (r
 	return obj.(*v1alpha2.ExternalMetric), err
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeExternalMetrics) UpdateStatus(externalMetric *v1alpha2.ExternalMetric) (*v1alpha2.ExternalMetric, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(externalmetricsResource, "status", c.ns, externalMetric), &v1alpha2.ExternalMetric{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha2.ExternalMetric), err
+}
+
 // Delete takes name of the externalMetric and deletes it. Returns an error if one occurs.
 func (c *FakeExternalMetrics) Delete(name string, options *v1.DeleteOptions) error {
 	_, err := c.Fake.
@@ -28,6 +28,14 @@ type FakeAzureV1alpha2 struct {
 	*testing.Fake
 }
 
+func (c *FakeAzureV1alpha2) AdapterPolicies() v1alpha2.AdapterPolicyInterface {
+	return &FakeAdapterPolicies{c}
+}
+
+func (c *FakeAzureV1alpha2) AzureCredentials(namespace string) v1alpha2.AzureCredentialInterface {
+	return &FakeAzureCredentials{c, namespace}
+}
+
 func (c *FakeAzureV1alpha2) CustomMetrics(namespace string) v1alpha2.CustomMetricInterface {
 	return &FakeCustomMetrics{c, namespace}
 }
@@ -36,6 +44,14 @@ func (c *FakeAzureV1alpha2) ExternalMetrics(namespace string) v1alpha2.ExternalM
 	return &FakeExternalMetrics{c, namespace}
 }
 
+func (c *FakeAzureV1alpha2) ExternalMetricGroups(namespace string) v1alpha2.ExternalMetricGroupInterface {
+	return &FakeExternalMetricGroups{c, namespace}
+}
+
+func (c *FakeAzureV1alpha2) MetricProbes(namespace string) v1alpha2.MetricProbeInterface {
+	return &FakeMetricProbes{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeAzureV1alpha2) RESTClient() rest.Interface {
@@ -18,6 +18,14 @@ limitations under the License.
 
 package v1alpha2
 
+type AdapterPolicyExpansion interface{}
+
+type AzureCredentialExpansion interface{}
+
 type CustomMetricExpansion interface{}
 
 type ExternalMetricExpansion interface{}
+
+type ExternalMetricGroupExpansion interface{}
+
+type MetricProbeExpansion interface{}
@@ -27,8 +27,12 @@ import (
 
 type AzureV1alpha2Interface interface {
 	RESTClient() rest.Interface
+	AdapterPoliciesGetter
+	AzureCredentialsGetter
 	CustomMetricsGetter
 	ExternalMetricsGetter
+	ExternalMetricGroupsGetter
+	MetricProbesGetter
 }
 
 // AzureV1alpha2Client is used to interact with features provided by the azure.com group.
@@ -36,6 +40,14 @@ type AzureV1alpha2Client struct {
 	restClient rest.Interface
 }
 
+func (c *AzureV1alpha2Client) AdapterPolicies() AdapterPolicyInterface {
+	return newAdapterPolicies(c)
+}
+
+func (c *AzureV1alpha2Client) AzureCredentials(namespace string) AzureCredentialInterface {
+	return newAzureCredentials(c, namespace)
+}
+
 func (c *AzureV1alpha2Client) CustomMetrics(namespace string) CustomMetricInterface {
 	return newCustomMetrics(c, namespace)
 }
@@ -44,6 +56,14 @@ func (c *AzureV1alpha2Client) ExternalMetrics(namespace string) ExternalMetricIn
 	return newExternalMetrics(c, namespace)
 }
 
+func (c *AzureV1alpha2Client) ExternalMetricGroups(namespace string) ExternalMetricGroupInterface {
+	return newExternalMetricGroups(c, namespace)
+}
+
+func (c *AzureV1alpha2Client) MetricProbes(namespace string) MetricProbeInterface {
+	return newMetricProbes(c, namespace)
+}
+
 // NewForConfig creates a new AzureV1alpha2Client for the given config.
 func NewForConfig(c *rest.Config) (*AzureV1alpha2Client, error) {
 	config := *c
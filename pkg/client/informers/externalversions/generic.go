@@ -53,10 +53,18 @@ func (f *genericInformer) Lister() cache.GenericLister {
 func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource) (GenericInformer, error) {
 	switch resource {
 	// Group=azure.com, Version=v1alpha2
+	case v1alpha2.SchemeGroupVersion.WithResource("adapterpolicies"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Azure().V1alpha2().AdapterPolicies().Informer()}, nil
+	case v1alpha2.SchemeGroupVersion.WithResource("azurecredentials"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Azure().V1alpha2().AzureCredentials().Informer()}, nil
 	case v1alpha2.SchemeGroupVersion.WithResource("custommetrics"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Azure().V1alpha2().CustomMetrics().Informer()}, nil
 	case v1alpha2.SchemeGroupVersion.WithResource("externalmetrics"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Azure().V1alpha2().ExternalMetrics().Informer()}, nil
+	case v1alpha2.SchemeGroupVersion.WithResource("externalmetricgroups"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Azure().V1alpha2().ExternalMetricGroups().Informer()}, nil
+	case v1alpha2.SchemeGroupVersion.WithResource("metricprobes"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Azure().V1alpha2().MetricProbes().Informer()}, nil
 
 	}
 
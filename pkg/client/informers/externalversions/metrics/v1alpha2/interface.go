@@ -24,10 +24,18 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// AdapterPolicies returns a AdapterPolicyInformer.
+	AdapterPolicies() AdapterPolicyInformer
+	// AzureCredentials returns a AzureCredentialInformer.
+	AzureCredentials() AzureCredentialInformer
 	// CustomMetrics returns a CustomMetricInformer.
 	CustomMetrics() CustomMetricInformer
 	// ExternalMetrics returns a ExternalMetricInformer.
 	ExternalMetrics() ExternalMetricInformer
+	// ExternalMetricGroups returns a ExternalMetricGroupInformer.
+	ExternalMetricGroups() ExternalMetricGroupInformer
+	// MetricProbes returns a MetricProbeInformer.
+	MetricProbes() MetricProbeInformer
 }
 
 type version struct {
@@ -41,6 +49,16 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// AdapterPolicies returns a AdapterPolicyInformer.
+func (v *version) AdapterPolicies() AdapterPolicyInformer {
+	return &adapterPolicyInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
+// AzureCredentials returns a AzureCredentialInformer.
+func (v *version) AzureCredentials() AzureCredentialInformer {
+	return &azureCredentialInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // CustomMetrics returns a CustomMetricInformer.
 func (v *version) CustomMetrics() CustomMetricInformer {
 	return &customMetricInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -50,3 +68,13 @@ func (v *version) CustomMetrics() CustomMetricInformer {
 func (v *version) ExternalMetrics() ExternalMetricInformer {
 	return &externalMetricInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// ExternalMetricGroups returns a ExternalMetricGroupInformer.
+func (v *version) ExternalMetricGroups() ExternalMetricGroupInformer {
+	return &externalMetricGroupInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// MetricProbes returns a MetricProbeInformer.
+func (v *version) MetricProbes() MetricProbeInformer {
+	return &metricProbeInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
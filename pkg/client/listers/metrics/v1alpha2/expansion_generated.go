@@ -18,6 +18,18 @@ limitations under the License.
 
 package v1alpha2
 
+// AdapterPolicyListerExpansion allows custom methods to be added to
+// AdapterPolicyLister.
+type AdapterPolicyListerExpansion interface{}
+
+// AzureCredentialListerExpansion allows custom methods to be added to
+// AzureCredentialLister.
+type AzureCredentialListerExpansion interface{}
+
+// AzureCredentialNamespaceListerExpansion allows custom methods to be added to
+// AzureCredentialNamespaceLister.
+type AzureCredentialNamespaceListerExpansion interface{}
+
 // CustomMetricListerExpansion allows custom methods to be added to
 // CustomMetricLister.
 type CustomMetricListerExpansion interface{}
@@ -33,3 +45,19 @@ type ExternalMetricListerExpansion interface{}
 // ExternalMetricNamespaceListerExpansion allows custom methods to be added to
 // ExternalMetricNamespaceLister.
 type ExternalMetricNamespaceListerExpansion interface{}
+
+// ExternalMetricGroupListerExpansion allows custom methods to be added to
+// ExternalMetricGroupLister.
+type ExternalMetricGroupListerExpansion interface{}
+
+// ExternalMetricGroupNamespaceListerExpansion allows custom methods to be added to
+// ExternalMetricGroupNamespaceLister.
+type ExternalMetricGroupNamespaceListerExpansion interface{}
+
+// MetricProbeListerExpansion allows custom methods to be added to
+// MetricProbeLister.
+type MetricProbeListerExpansion interface{}
+
+// MetricProbeNamespaceListerExpansion allows custom methods to be added to
+// MetricProbeNamespaceLister.
+type MetricProbeNamespaceListerExpansion interface{}
@@ -21,17 +21,20 @@ type Controller struct {
 	metricQueue          workqueue.RateLimitingInterface
 	externalMetricSynced cache.InformerSynced
 	customMetricSynced   cache.InformerSynced
+	groupSynced          cache.InformerSynced
 	enqueuer             func(obj interface{})
 	metricHandler        ControllerHandler
 }
 
-// NewController returns a new controller for handling external and custom metric types
-func NewController(externalMetricInformer informers.ExternalMetricInformer, customMetricInformer informers.CustomMetricInformer, metricHandler ControllerHandler) *Controller {
+// NewController returns a new controller for handling external and custom metric types and
+// groups of external metrics
+func NewController(externalMetricInformer informers.ExternalMetricInformer, customMetricInformer informers.CustomMetricInformer, groupInformer informers.ExternalMetricGroupInformer, metricHandler ControllerHandler) *Controller {
 	controller := &Controller{
 		externalMetricSynced: externalMetricInformer.Informer().HasSynced,
 		metricQueue:          workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "metrics"),
 		metricHandler:        metricHandler,
 		customMetricSynced:   customMetricInformer.Informer().HasSynced,
+		groupSynced:          groupInformer.Informer().HasSynced,
 	}
 
 	// wire up enque step.  This provides a hook for testing enqueue step
@@ -58,6 +61,15 @@ func NewController(externalMetricInformer informers.ExternalMetricInformer, cust
 		DeleteFunc: controller.enqueuer,
 	})
 
+	glog.Info("Setting up external metric group event handlers")
+	groupInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueuer,
+		UpdateFunc: func(old, new interface{}) {
+			controller.enqueuer(new)
+		},
+		DeleteFunc: controller.enqueuer,
+	})
+
 	return controller
 }
 
@@ -69,7 +81,7 @@ func (c *Controller) Run(numberOfWorkers int, interval time.Duration, stopCh <-c
 	glog.V(2).Info("initializing controller")
 
 	// do the initial synchronization (one time) to populate resources
-	if !cache.WaitForCacheSync(stopCh, c.externalMetricSynced, c.customMetricSynced) {
+	if !cache.WaitForCacheSync(stopCh, c.externalMetricSynced, c.customMetricSynced, c.groupSynced) {
 		runtime.HandleError(fmt.Errorf("Error syncing controller cache"))
 		return
 	}
@@ -181,6 +193,8 @@ func getKind(obj interface{}) string {
 		return "ExternalMetric"
 	case *v1alpha2.CustomMetric:
 		return "CustomMetric"
+	case *v1alpha2.ExternalMetricGroup:
+		return "ExternalMetricGroup"
 	default:
 		glog.Error("No known type of object")
 		return ""
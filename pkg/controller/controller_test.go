@@ -292,11 +292,12 @@ func newController(config controllerConfig) (*Controller, informers.SharedInform
 	fakeClient := fake.NewSimpleClientset(config.store...)
 	i := informers.NewSharedInformerFactory(fakeClient, 0)
 
-	c := NewController(i.Azure().V1alpha2().ExternalMetrics(), i.Azure().V1alpha2().CustomMetrics(), config.handler)
+	c := NewController(i.Azure().V1alpha2().ExternalMetrics(), i.Azure().V1alpha2().CustomMetrics(), i.Azure().V1alpha2().ExternalMetricGroups(), config.handler)
 
 	// override for testing
 	c.externalMetricSynced = config.syncedFunction
 	c.customMetricSynced = config.syncedFunction
+	c.groupSynced = config.syncedFunction
 
 	if config.enqueuer != nil {
 		// override for testings
@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+)
+
+// GroupMemberSpec returns the spec a metric of an ExternalMetricGroup is served with: the template
+// of the group with the fields the metric sets replacing those of the template.  Sections such as
+// azure are merged field by field, while lists replace the list of the template.
+func GroupMemberSpec(template api.ExternalMetricSpec, member api.ExternalMetricGroupMember) (api.ExternalMetricSpec, error) {
+	fields, err := specFields(template)
+	if err != nil {
+		return api.ExternalMetricSpec{}, err
+	}
+	overrides, err := specFields(member.ExternalMetricSpec)
+	if err != nil {
+		return api.ExternalMetricSpec{}, err
+	}
+	mergeFields(fields, overrides)
+
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return api.ExternalMetricSpec{}, err
+	}
+	spec := api.ExternalMetricSpec{}
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return api.ExternalMetricSpec{}, fmt.Errorf("unable to merge metric %s with the template: %v", member.Name, err)
+	}
+	return spec, nil
+}
+
+func specFields(spec api.ExternalMetricSpec) (map[string]interface{}, error) {
+	raw, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	err = json.Unmarshal(raw, &fields)
+	return fields, err
+}
+
+// mergeFields sets the fields of the overrides that are not empty on the fields
+func mergeFields(fields map[string]interface{}, overrides map[string]interface{}) {
+	for key, value := range overrides {
+		if isEmptyField(value) {
+			continue
+		}
+		if section, ok := value.(map[string]interface{}); ok {
+			if templateSection, ok := fields[key].(map[string]interface{}); ok {
+				mergeFields(templateSection, section)
+				continue
+			}
+		}
+		fields[key] = value
+	}
+}
+
+func isEmptyField(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		for _, field := range v {
+			if !isEmptyField(field) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// groupMembers records the names of the metrics each ExternalMetricGroup declares, by the
+// namespace/name of the group, so the metrics a group no longer declares are removed from the cache
+type groupMembers struct {
+	mu      sync.Mutex
+	members map[string][]string
+}
+
+func newGroupMembers() *groupMembers {
+	return &groupMembers{members: map[string][]string{}}
+}
+
+// set records the metrics declared by the group and returns those it declared before
+func (g *groupMembers) set(namespaceKey string, names []string) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	previous := g.members[namespaceKey]
+	if len(names) == 0 {
+		delete(g.members, namespaceKey)
+	} else {
+		g.members[namespaceKey] = names
+	}
+	return previous
+}
+
+// declaring returns the names of the groups in the namespace that declare the metric
+func (g *groupMembers) declaring(namespace string, name string) []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	groups := []string{}
+	for namespaceKey, names := range g.members {
+		if !strings.HasPrefix(namespaceKey, namespace+"/") {
+			continue
+		}
+		for _, member := range names {
+			if member == name {
+				groups = append(groups, strings.TrimPrefix(namespaceKey, namespace+"/"))
+				break
+			}
+		}
+	}
+	sort.Strings(groups)
+	return groups
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"testing"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestGroupMemberSpecMergesTemplate(t *testing.T) {
+	template := newFullExternalMetric("template").Spec
+	member := api.ExternalMetricGroupMember{
+		Name: "dead-letters",
+		ExternalMetricSpec: api.ExternalMetricSpec{
+			AzureConfig:  api.AzureConfig{ResourceName: "other"},
+			MetricConfig: api.ExternalMetricConfig{MetricName: "DeadletteredMessages"},
+		},
+	}
+
+	spec, err := GroupMemberSpec(template, member)
+
+	if err != nil {
+		t.Fatalf("error after processing = %v, want nil", err)
+	}
+	if spec.MetricConfig.MetricName != "DeadletteredMessages" || spec.AzureConfig.ResourceName != "other" {
+		t.Errorf("metric = %s of %s, want the fields of the member", spec.MetricConfig.MetricName, spec.AzureConfig.ResourceName)
+	}
+	if spec.MetricConfig.Aggregation != "Total" || spec.AzureConfig.ResourceGroup != "rg" || spec.MetricConfig.Top != 5 {
+		t.Errorf("spec = %+v, want the other fields of the template", spec)
+	}
+}
+
+func TestExternalMetricGroupMembersAreStored(t *testing.T) {
+	group := newExternalMetricGroup("servicebus", "active", "dead-letters")
+	handler, metriccache, i := newGroupHandler([]runtime.Object{group}, nil, nil)
+	i.Azure().V1alpha2().ExternalMetricGroups().Informer().GetIndexer().Add(group)
+
+	if err := handler.Process(getGroupKey(group)); err != nil {
+		t.Fatalf("error after processing = %v, want nil", err)
+	}
+
+	for _, name := range []string{"active", "dead-letters"} {
+		metricRequest, exists := metriccache.GetAzureExternalMetricRequest(group.Namespace, name)
+		if !exists {
+			t.Fatalf("metric %s exists = %v, want true", name, exists)
+		}
+		if metricRequest.MetricName != name || metricRequest.ResourceGroup != "rg" {
+			t.Errorf("metric %s request = %s of %s, want the member merged with the template", name, metricRequest.MetricName, metricRequest.ResourceGroup)
+		}
+	}
+
+	// a metric removed from the group is no longer served
+	updated := newExternalMetricGroup("servicebus", "active")
+	i.Azure().V1alpha2().ExternalMetricGroups().Informer().GetIndexer().Update(updated)
+	if err := handler.Process(getGroupKey(updated)); err != nil {
+		t.Fatalf("error after processing = %v, want nil", err)
+	}
+
+	if _, exists := metriccache.GetAzureExternalMetricRequest(group.Namespace, "dead-letters"); exists {
+		t.Errorf("removed metric exists = %v, want false", exists)
+	}
+	if _, exists := metriccache.GetAzureExternalMetricRequest(group.Namespace, "active"); !exists {
+		t.Errorf("metric exists = %v, want true", exists)
+	}
+
+	// deleting the group removes its metrics
+	i.Azure().V1alpha2().ExternalMetricGroups().Informer().GetIndexer().Delete(updated)
+	if err := handler.Process(getGroupKey(updated)); err != nil {
+		t.Fatalf("error after processing = %v, want nil", err)
+	}
+
+	if _, exists := metriccache.GetAzureExternalMetricRequest(group.Namespace, "active"); exists {
+		t.Errorf("metric of deleted group exists = %v, want false", exists)
+	}
+}
+
+func TestExternalMetricGroupMemberWithInvalidAggregationIsNotStored(t *testing.T) {
+	group := newExternalMetricGroup("servicebus", "active", "latency")
+	group.Spec.Metrics[1].MetricConfig.Aggregation = "P95"
+	handler, metriccache, i := newGroupHandler([]runtime.Object{group}, nil, nil)
+	i.Azure().V1alpha2().ExternalMetricGroups().Informer().GetIndexer().Add(group)
+
+	if err := handler.Process(getGroupKey(group)); err != nil {
+		t.Fatalf("error after processing = %v, want nil", err)
+	}
+
+	if _, exists := metriccache.GetAzureExternalMetricRequest(group.Namespace, "latency"); exists {
+		t.Errorf("invalid metric exists = %v, want false", exists)
+	}
+	if _, exists := metriccache.GetAzureExternalMetricRequest(group.Namespace, "active"); !exists {
+		t.Errorf("metric exists = %v, want the other metrics of the group served", exists)
+	}
+}
+
+func TestExternalMetricTakesPrecedenceOverGroup(t *testing.T) {
+	group := newExternalMetricGroup("servicebus", "active")
+	externalMetric := newFullExternalMetric("active")
+	handler, metriccache, i := newGroupHandler([]runtime.Object{group, externalMetric}, []*api.ExternalMetric{externalMetric}, nil)
+	i.Azure().V1alpha2().ExternalMetricGroups().Informer().GetIndexer().Add(group)
+
+	handler.Process(getExternalKey(externalMetric))
+	if err := handler.Process(getGroupKey(group)); err != nil {
+		t.Fatalf("error after processing = %v, want nil", err)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(group.Namespace, "active")
+	if metricRequest.MetricName != "Name" {
+		t.Errorf("metricRequest MetricName = %v, want the ExternalMetric's %v", metricRequest.MetricName, "Name")
+	}
+
+	// once the ExternalMetric is deleted the group's metric is served
+	i.Azure().V1alpha2().ExternalMetrics().Informer().GetIndexer().Delete(externalMetric)
+	if err := handler.Process(getExternalKey(externalMetric)); err != nil {
+		t.Fatalf("error after processing = %v, want nil", err)
+	}
+
+	metricRequest, exists := metriccache.GetAzureExternalMetricRequest(group.Namespace, "active")
+	if !exists || metricRequest.MetricName != "active" {
+		t.Errorf("metricRequest MetricName = %v, want the group's %v", metricRequest.MetricName, "active")
+	}
+}
+
+func getGroupKey(group *api.ExternalMetricGroup) namespacedQueueItem {
+	return namespacedQueueItem{
+		namespaceKey: group.Namespace + "/" + group.Name,
+		kind:         group.TypeMeta.Kind,
+	}
+}
+
+func newExternalMetricGroup(name string, metricNames ...string) *api.ExternalMetricGroup {
+	group := &api.ExternalMetricGroup{
+		TypeMeta: metav1.TypeMeta{APIVersion: api.SchemeGroupVersion.String(), Kind: "ExternalMetricGroup"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: metav1.NamespaceDefault,
+		},
+		Spec: api.ExternalMetricGroupSpec{
+			Template: api.ExternalMetricSpec{
+				Type: externalmetrics.Monitor,
+				AzureConfig: api.AzureConfig{
+					ResourceGroup:             "rg",
+					ResourceName:              "sb",
+					ResourceProviderNamespace: "Microsoft.ServiceBus",
+					ResourceType:              "namespaces",
+				},
+				MetricConfig: api.ExternalMetricConfig{Aggregation: "Total"},
+			},
+		},
+	}
+	for _, metricName := range metricNames {
+		group.Spec.Metrics = append(group.Spec.Metrics, api.ExternalMetricGroupMember{
+			Name:               metricName,
+			ExternalMetricSpec: api.ExternalMetricSpec{MetricConfig: api.ExternalMetricConfig{MetricName: metricName}},
+		})
+	}
+	return group
+}
@@ -1,31 +1,62 @@
 package controller
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/custommetrics"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
 	listers "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/listers/metrics/v1alpha2"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/metriccache"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/transform"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/variables"
 	"github.com/golang/glog"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
 )
 
+// ShadowAnnotation on an ExternalMetric holds an alternative spec, as json, that is queried
+// alongside the spec so a new filter or aggregation can be compared before switching to it
+const ShadowAnnotation = "azure.com/shadow"
+
+// PinAnnotation on an ExternalMetric pins its served value until a time, so release tooling can
+// hold autoscaler inputs steady during a rollout.  It holds json such as
+// {"until": "2019-03-10T02:00:00Z", "value": "10"}; without a value the last served value is pinned.
+const PinAnnotation = "azure.com/pin"
+
+type pinSpec struct {
+	Until string             `json:"until"`
+	Value *resource.Quantity `json:"value,omitempty"`
+}
+
 // Handler processes the events from the controler for external metrics
 type Handler struct {
-	externalmetricLister listers.ExternalMetricLister
-	metriccache          *metriccache.MetricCache
-	customMetricLister   listers.CustomMetricLister
+	externalmetricLister      listers.ExternalMetricLister
+	metriccache               *metriccache.MetricCache
+	customMetricLister        listers.CustomMetricLister
+	externalMetricGroupLister listers.ExternalMetricGroupLister
+	variables                 variables.Variables
+	// kubeClient reads the ConfigMaps specs take variables from
+	kubeClient dynamic.Interface
+	groups     *groupMembers
 }
 
-// NewHandler created a new handler
-func NewHandler(externalmetricLister listers.ExternalMetricLister, customMetricLister listers.CustomMetricLister, metricCache *metriccache.MetricCache) Handler {
+// NewHandler created a new handler.  The variables, and those of the ConfigMaps read with the
+// client, are expanded in the specs of ExternalMetrics.
+func NewHandler(externalmetricLister listers.ExternalMetricLister, customMetricLister listers.CustomMetricLister, externalMetricGroupLister listers.ExternalMetricGroupLister, metricCache *metriccache.MetricCache, specVariables variables.Variables, kubeClient dynamic.Interface) Handler {
 	return Handler{
-		externalmetricLister: externalmetricLister,
-		customMetricLister:   customMetricLister,
-		metriccache:          metricCache,
+		externalmetricLister:      externalmetricLister,
+		customMetricLister:        customMetricLister,
+		externalMetricGroupLister: externalMetricGroupLister,
+		metriccache:               metricCache,
+		variables:                 specVariables,
+		kubeClient:                kubeClient,
+		groups:                    newGroupMembers(),
 	}
 }
 
@@ -47,6 +78,8 @@ func (h *Handler) Process(queueItem namespacedQueueItem) error {
 		return h.handleCustomMetric(ns, name, queueItem)
 	case "ExternalMetric":
 		return h.handleExternalMetric(ns, name, queueItem)
+	case "ExternalMetricGroup":
+		return h.handleExternalMetricGroup(ns, name, queueItem)
 	}
 
 	return nil
@@ -69,6 +102,13 @@ func (h *Handler) handleCustomMetric(ns, name string, queueItem namespacedQueueI
 
 	metric := custommetrics.MetricRequest{
 		MetricName: customMetricInfo.Spec.MetricConfig.MetricName,
+		Transform:  transformDefinition(customMetricInfo.Spec.Transform),
+	}
+	if err := metric.Transform.Validate(); err != nil {
+		// retrying won't help until the spec is changed, so the metric is not served
+		glog.Errorf("unable to serve '%s' in namespace '%s': %v", name, ns, err)
+		h.metriccache.Remove(queueItem.Key())
+		return nil
 	}
 
 	glog.V(2).Infof("adding to cache item '%s' in namespace '%s'", name, ns)
@@ -86,26 +126,66 @@ func (h *Handler) handleExternalMetric(ns, name string, queueItem namespacedQueu
 			// Then this we should remove
 			glog.V(2).Infof("removing item from cache '%s' in namespace '%s'", name, ns)
 			h.metriccache.Remove(queueItem.Key())
+
+			// a group metric of the same name is served again
+			for _, group := range h.groups.declaring(ns, name) {
+				groupItem := namespacedQueueItem{namespaceKey: fmt.Sprintf("%s/%s", ns, group), kind: "ExternalMetricGroup"}
+				if err := h.handleExternalMetricGroup(ns, group, groupItem); err != nil {
+					return err
+				}
+			}
 			return nil
 		}
 
 		return err
 	}
 
-	// TODO: Map the new fields here for Service Bus
-	azureMetricRequest := externalmetrics.AzureExternalMetricRequest{
-		ResourceGroup:             externalMetricInfo.Spec.AzureConfig.ResourceGroup,
-		ResourceName:              externalMetricInfo.Spec.AzureConfig.ResourceName,
-		ResourceProviderNamespace: externalMetricInfo.Spec.AzureConfig.ResourceProviderNamespace,
-		ResourceType:              externalMetricInfo.Spec.AzureConfig.ResourceType,
-		SubscriptionID:            externalMetricInfo.Spec.AzureConfig.SubscriptionID,
-		MetricName:                externalMetricInfo.Spec.MetricConfig.MetricName,
-		Filter:                    externalMetricInfo.Spec.MetricConfig.Filter,
-		Aggregation:               externalMetricInfo.Spec.MetricConfig.Aggregation,
-		Topic:                     externalMetricInfo.Spec.AzureConfig.ServiceBusTopic,
-		Type:                      externalMetricInfo.Spec.Type,
-		Namespace:                 externalMetricInfo.Spec.AzureConfig.ServiceBusNamespace,
-		Subscription:              externalMetricInfo.Spec.AzureConfig.ServiceBusSubscription,
+	values, err := variables.ConfigMapValues(h.kubeClient, ns, externalMetricInfo.Spec.VariablesConfigMap)
+	if err != nil && !errors.IsNotFound(err) {
+		// the metric is served with its previous spec until the ConfigMap can be read
+		return err
+	}
+	metricVariables := h.variables.ForMetric(ns, name, values)
+	spec := externalMetricInfo.Spec
+	if err == nil {
+		spec, err = metricVariables.ExpandSpec(spec)
+	}
+	if err == nil {
+		err = validateSpec(spec)
+	}
+	if err != nil {
+		// retrying won't help until the spec is changed, so the metric is not served
+		glog.Errorf("unable to serve '%s' in namespace '%s': %v", name, ns, err)
+		h.metriccache.Remove(queueItem.Key())
+		return nil
+	}
+
+	azureMetricRequest := ExternalMetricRequest(spec)
+	if shadowSpec, ok := externalMetricInfo.Annotations[ShadowAnnotation]; ok {
+		shadow := api.ExternalMetricSpec{}
+		err := json.Unmarshal([]byte(shadowSpec), &shadow)
+		if err == nil {
+			shadow, err = metricVariables.ExpandSpec(shadow)
+		}
+		if err == nil {
+			err = validateSpec(shadow)
+		}
+		if err != nil {
+			// the primary spec is still served
+			glog.Errorf("ignoring invalid shadow spec of '%s' in namespace '%s': %v", name, ns, err)
+		} else {
+			shadowRequest := ExternalMetricRequest(shadow)
+			azureMetricRequest.Shadow = &shadowRequest
+		}
+	}
+	if pinAnnotation, ok := externalMetricInfo.Annotations[PinAnnotation]; ok {
+		pin, err := parsePin(pinAnnotation)
+		if err != nil {
+			// the metric is served as if it wasn't pinned
+			glog.Errorf("ignoring invalid pin of '%s' in namespace '%s': %v", name, ns, err)
+		} else {
+			azureMetricRequest.Pin = pin
+		}
 	}
 
 	glog.V(2).Infof("adding to cache item '%s' in namespace '%s'", name, ns)
@@ -113,3 +193,723 @@ func (h *Handler) handleExternalMetric(ns, name string, queueItem namespacedQueu
 
 	return nil
 }
+
+// validateSpec returns an error if the expanded spec can't be served, so retrying won't help until
+// it is changed
+func validateSpec(spec api.ExternalMetricSpec) error {
+	if err := externalmetrics.ValidateAggregation(spec.MetricConfig.Aggregation); err != nil {
+		return err
+	}
+	if err := externalmetrics.ValidateQueryWindow(spec.MetricConfig.Timespan, spec.MetricConfig.Interval); err != nil {
+		return err
+	}
+	if err := (externalmetrics.NoDataDefinition{Policy: spec.NoDataPolicy}).Validate(); err != nil {
+		return err
+	}
+	if err := transformDefinition(spec.Transform).Validate(); err != nil {
+		return err
+	}
+	return externalmetrics.ValidateVariants(variantDefinitions(spec.Variants))
+}
+
+// parsePin parses the json of a pin annotation
+func parsePin(annotation string) (externalmetrics.PinDefinition, error) {
+	spec := pinSpec{}
+	if err := json.Unmarshal([]byte(annotation), &spec); err != nil {
+		return externalmetrics.PinDefinition{}, err
+	}
+
+	until, err := time.Parse(time.RFC3339, spec.Until)
+	if err != nil {
+		return externalmetrics.PinDefinition{}, fmt.Errorf("until '%s' must be RFC3339", spec.Until)
+	}
+	pin := externalmetrics.PinDefinition{Until: until}
+	if spec.Value != nil {
+		value := float64(spec.Value.MilliValue()) / 1000
+		pin.Value = &value
+	}
+	return pin, nil
+}
+
+// handleExternalMetricGroup caches a request for each metric of the group, as if it was an
+// ExternalMetric of that name.  An ExternalMetric of the same name takes precedence.
+func (h *Handler) handleExternalMetricGroup(ns, name string, queueItem namespacedQueueItem) error {
+	glog.V(2).Infof("processing group '%s' in namespace '%s'", name, ns)
+	names := []string{}
+	requests := map[string]externalmetrics.AzureExternalMetricRequest{}
+
+	group, err := h.externalMetricGroupLister.ExternalMetricGroups(ns).Get(name)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil {
+		for _, member := range group.Spec.Metrics {
+			if member.Name == "" {
+				glog.Errorf("ignoring metric without a name in group '%s' in namespace '%s'", name, ns)
+				continue
+			}
+			if _, found := requests[member.Name]; found {
+				glog.Errorf("ignoring duplicate metric '%s' in group '%s' in namespace '%s'", member.Name, name, ns)
+				continue
+			}
+
+			spec, err := GroupMemberSpec(group.Spec.Template, member)
+			var values map[string]string
+			if err == nil {
+				values, err = variables.ConfigMapValues(h.kubeClient, ns, spec.VariablesConfigMap)
+			}
+			if err == nil {
+				spec, err = h.variables.ForMetric(ns, member.Name, values).ExpandSpec(spec)
+			}
+			if err == nil {
+				err = validateSpec(spec)
+			}
+			if err != nil {
+				// the other metrics of the group are still served
+				glog.Errorf("unable to serve metric '%s' of group '%s' in namespace '%s': %v", member.Name, name, ns, err)
+				continue
+			}
+			names = append(names, member.Name)
+			requests[member.Name] = ExternalMetricRequest(spec)
+		}
+	}
+
+	previous := h.groups.set(queueItem.namespaceKey, names)
+	for _, member := range previous {
+		if _, declared := requests[member]; !declared && !h.externalMetricExists(ns, member) {
+			glog.V(2).Infof("removing metric '%s' of group '%s' from cache in namespace '%s'", member, name, ns)
+			h.metriccache.Remove(externalMetricItem(ns, member).Key())
+		}
+	}
+	for _, member := range names {
+		if h.externalMetricExists(ns, member) {
+			glog.Warningf("metric '%s' of group '%s' in namespace '%s' is defined by an ExternalMetric of the same name", member, name, ns)
+			continue
+		}
+		glog.V(2).Infof("adding metric '%s' of group '%s' to cache in namespace '%s'", member, name, ns)
+		h.metriccache.Update(externalMetricItem(ns, member).Key(), requests[member])
+	}
+
+	return nil
+}
+
+func (h *Handler) externalMetricExists(ns, name string) bool {
+	_, err := h.externalmetricLister.ExternalMetrics(ns).Get(name)
+	return err == nil
+}
+
+func externalMetricItem(ns, name string) namespacedQueueItem {
+	return namespacedQueueItem{namespaceKey: fmt.Sprintf("%s/%s", ns, name), kind: "ExternalMetric"}
+}
+
+// ExternalMetricRequest converts the spec of an ExternalMetric to the request made for its value
+func ExternalMetricRequest(spec api.ExternalMetricSpec) externalmetrics.AzureExternalMetricRequest {
+	// TODO: Map the new fields here for Service Bus
+	return externalmetrics.AzureExternalMetricRequest{
+		ResourceGroup:             spec.AzureConfig.ResourceGroup,
+		ResourceName:              spec.AzureConfig.ResourceName,
+		ResourceProviderNamespace: spec.AzureConfig.ResourceProviderNamespace,
+		ResourceType:              spec.AzureConfig.ResourceType,
+		SubscriptionID:            spec.AzureConfig.SubscriptionID,
+		Subscriptions:             spec.AzureConfig.Subscriptions,
+		SubscriptionAggregation:   spec.AzureConfig.SubscriptionAggregation,
+		Resources:                 resourceRefs(spec.AzureConfig.Resources),
+		ResourceTags:              spec.AzureConfig.ResourceTags,
+		ResourceQuery:             spec.AzureConfig.ResourceGraphQuery,
+		ResourceAggregation:       spec.AzureConfig.ResourceAggregation,
+		MetricName:                spec.MetricConfig.MetricName,
+		MetricNamespace:           spec.MetricConfig.MetricNamespace,
+		Window:                    spec.MetricConfig.Timespan,
+		Interval:                  spec.MetricConfig.Interval,
+		Rate:                      spec.MetricConfig.Rate,
+		Filter:                    spec.MetricConfig.Filter,
+		Aggregation:               spec.MetricConfig.Aggregation,
+		SplitDimension:            spec.MetricConfig.SplitDimension,
+		Top:                       spec.MetricConfig.Top,
+		MonitorAPIVersion:         spec.MetricConfig.MonitorAPIVersion,
+		UseUnits:                  spec.MetricConfig.UseUnits,
+		Topic:                     spec.AzureConfig.ServiceBusTopic,
+		Type:                      spec.Type,
+		Credential:                spec.Credential,
+		CredentialSecret:          spec.CredentialSecret,
+		TenantID:                  spec.TenantID,
+		Namespace:                 spec.AzureConfig.ServiceBusNamespace,
+		Subscription:              spec.AzureConfig.ServiceBusSubscription,
+		MessageCounts:             spec.AzureConfig.ServiceBusMessageCounts,
+		Schedule:                  scheduleDefinition(spec.Schedule),
+		Prediction:                predictionDefinition(spec.Prediction),
+		SLO:                       sloDefinition(spec.SLO),
+		Plugin:                    pluginDefinition(spec.Plugin),
+		Webhook:                   webhookDefinition(spec.Webhook),
+		Activity:                  activityDefinition(spec.Activity),
+		Node:                      nodeDefinition(spec.Node),
+		PerReplica:                perReplicaDefinition(spec.PerReplica),
+		Expression:                expressionDefinition(spec.Expression),
+		ServiceBusQueue:           serviceBusQueueDefinition(spec.ServiceBusQueue),
+		StorageQueue:              storageQueueDefinition(spec.StorageQueue),
+		EventHub:                  eventHubDefinition(spec.EventHub),
+		FileShare:                 fileShareDefinition(spec.FileShare),
+		ActivityLog:               activityLogDefinition(spec.ActivityLog),
+		ContainerApp:              containerAppDefinition(spec.ContainerApp),
+		LogicApp:                  logicAppDefinition(spec.LogicApp),
+		LogAnalytics:              logAnalyticsDefinition(spec.LogAnalytics),
+		DataExplorer:              dataExplorerDefinition(spec.DataExplorer),
+		CosmosDB:                  cosmosDBDefinition(spec.CosmosDB),
+		Prometheus:                prometheusDefinition(spec.Prometheus),
+		IoTHub:                    iotHubDefinition(spec.IoTHub),
+		Batch:                     batchDefinition(spec.Batch),
+		BlobCount:                 blobCountDefinition(spec.BlobCount),
+		EventGrid:                 eventGridDefinition(spec.EventGrid),
+		Redis:                     redisDefinition(spec.Redis),
+		AzureDevOps:               azureDevOpsDefinition(spec.AzureDevOps),
+		Cost:                      costDefinition(spec.Cost),
+		Quota:                     quotaDefinition(spec.Quota),
+		Alert:                     alertDefinition(spec.Alert),
+		Maintenance:               maintenanceDefinition(spec.Maintenance),
+		Timeout:                   spec.Timeout,
+		NoData:                    externalmetrics.NoDataDefinition{Policy: spec.NoDataPolicy, Value: float64(spec.NoDataValue)},
+		Sources:                   weightedSources(spec.Sources),
+		Ratio:                     ratioDefinition(spec.Ratio),
+		Seasonal:                  seasonalDefinition(spec.Seasonal),
+		Heartbeat:                 heartbeatDefinition(spec.Heartbeat),
+		Transform:                 transformDefinition(spec.Transform),
+		Variants:                  variantDefinitions(spec.Variants),
+	}
+}
+
+func variantDefinitions(configs []api.VariantConfig) []externalmetrics.VariantDefinition {
+	var variants []externalmetrics.VariantDefinition
+	for _, config := range configs {
+		variants = append(variants, externalmetrics.VariantDefinition{
+			Suffix:    config.Suffix,
+			Rate:      config.Rate,
+			Transform: transformDefinition(config.Transform),
+		})
+	}
+	return variants
+}
+
+func scheduleDefinition(config *api.ScheduleConfig) externalmetrics.ScheduleDefinition {
+	if config == nil {
+		return externalmetrics.ScheduleDefinition{}
+	}
+
+	schedule := externalmetrics.ScheduleDefinition{
+		TimeZone:     config.TimeZone,
+		DefaultValue: float64(config.DefaultValue),
+	}
+	for _, window := range config.Windows {
+		schedule.Windows = append(schedule.Windows, externalmetrics.ScheduleWindow{
+			Days:  window.Days,
+			Start: window.Start,
+			End:   window.End,
+			Value: float64(window.Value),
+		})
+	}
+
+	return schedule
+}
+
+func heartbeatDefinition(config *api.HeartbeatConfig) externalmetrics.HeartbeatDefinition {
+	if config == nil {
+		return externalmetrics.HeartbeatDefinition{}
+	}
+
+	return externalmetrics.HeartbeatDefinition{
+		Value:      float64(config.Value),
+		RampTo:     float64(config.RampTo),
+		RampPeriod: config.RampPeriod,
+	}
+}
+
+func transformDefinition(config *api.TransformConfig) transform.Transform {
+	if config == nil {
+		return transform.Transform{}
+	}
+
+	return transform.Transform{
+		Multiplier: config.Multiplier,
+		Offset:     config.Offset,
+		Unit:       config.Unit,
+	}
+}
+
+func predictionDefinition(config *api.PredictionConfig) externalmetrics.PredictionDefinition {
+	if config == nil {
+		return externalmetrics.PredictionDefinition{}
+	}
+
+	return externalmetrics.PredictionDefinition{
+		History:      config.History,
+		Interval:     config.Interval,
+		Horizon:      config.Horizon,
+		Method:       config.Method,
+		SeasonLength: config.SeasonLength,
+	}
+}
+
+func sloDefinition(config *api.SLOConfig) externalmetrics.SLODefinition {
+	if config == nil {
+		return externalmetrics.SLODefinition{}
+	}
+
+	return externalmetrics.SLODefinition{
+		Objective:    config.Objective,
+		LongWindow:   config.LongWindow,
+		ShortWindow:  config.ShortWindow,
+		TotalMetric:  config.TotalMetric,
+		FailedMetric: config.FailedMetric,
+	}
+}
+
+func pluginDefinition(config *api.PluginConfig) externalmetrics.PluginDefinition {
+	if config == nil {
+		return externalmetrics.PluginDefinition{}
+	}
+
+	return externalmetrics.PluginDefinition{
+		Name:       config.Name,
+		Parameters: config.Parameters,
+	}
+}
+
+func webhookDefinition(config *api.WebhookConfig) externalmetrics.WebhookDefinition {
+	if config == nil {
+		return externalmetrics.WebhookDefinition{}
+	}
+
+	return externalmetrics.WebhookDefinition{
+		URL:         config.URL,
+		AADResource: config.AADResource,
+		BearerToken: config.BearerToken,
+	}
+}
+
+func activityDefinition(config *api.ActivityConfig) externalmetrics.ActivityDefinition {
+	if config == nil {
+		return externalmetrics.ActivityDefinition{}
+	}
+
+	return externalmetrics.ActivityDefinition{
+		Enabled:   true,
+		Threshold: float64(config.Threshold),
+		Window:    config.Window,
+	}
+}
+
+func nodeDefinition(config *api.NodeConfig) externalmetrics.NodeDefinition {
+	if config == nil {
+		return externalmetrics.NodeDefinition{}
+	}
+
+	return externalmetrics.NodeDefinition{
+		Enabled:   true,
+		Dimension: config.Dimension,
+	}
+}
+
+func perReplicaDefinition(config *api.PerReplicaConfig) externalmetrics.PerReplicaDefinition {
+	if config == nil {
+		return externalmetrics.PerReplicaDefinition{}
+	}
+
+	return externalmetrics.PerReplicaDefinition{
+		Enabled:    true,
+		APIVersion: config.APIVersion,
+		Kind:       config.Kind,
+		Name:       config.Name,
+	}
+}
+
+func expressionDefinition(config *api.ExpressionConfig) externalmetrics.ExpressionDefinition {
+	if config == nil {
+		return externalmetrics.ExpressionDefinition{}
+	}
+
+	return externalmetrics.ExpressionDefinition{
+		Value:       config.Value,
+		TimeZone:    config.TimeZone,
+		ScaleTarget: perReplicaDefinition(config.ScaleTarget),
+	}
+}
+
+func serviceBusQueueDefinition(config *api.ServiceBusQueueConfig) externalmetrics.ServiceBusQueueDefinition {
+	if config == nil {
+		return externalmetrics.ServiceBusQueueDefinition{}
+	}
+
+	definition := externalmetrics.ServiceBusQueueDefinition{
+		Namespace: config.Namespace,
+		Queue:     config.Queue,
+	}
+	if config.ConnectionStringRef != nil {
+		definition.ConnectionStringSecret = config.ConnectionStringRef.Name
+		definition.ConnectionStringKey = config.ConnectionStringRef.Key
+	}
+	return definition
+}
+
+func storageQueueDefinition(config *api.StorageQueueConfig) externalmetrics.StorageQueueDefinition {
+	if config == nil {
+		return externalmetrics.StorageQueueDefinition{}
+	}
+
+	definition := externalmetrics.StorageQueueDefinition{
+		Account: config.Account,
+		Queue:   config.Queue,
+	}
+	if config.ConnectionStringRef != nil {
+		definition.ConnectionStringSecret = config.ConnectionStringRef.Name
+		definition.ConnectionStringKey = config.ConnectionStringRef.Key
+	}
+	return definition
+}
+
+func eventHubDefinition(config *api.EventHubConfig) externalmetrics.EventHubDefinition {
+	if config == nil {
+		return externalmetrics.EventHubDefinition{}
+	}
+
+	definition := externalmetrics.EventHubDefinition{
+		Namespace:      config.Namespace,
+		EventHub:       config.EventHub,
+		ConsumerGroup:  config.ConsumerGroup,
+		LagPerReplica:  config.LagPerReplica,
+		StorageAccount: config.CheckpointStore.Account,
+		Container:      config.CheckpointStore.Container,
+	}
+	if config.ConnectionStringRef != nil {
+		definition.ConnectionStringSecret = config.ConnectionStringRef.Name
+		definition.ConnectionStringKey = config.ConnectionStringRef.Key
+	}
+	if ref := config.CheckpointStore.ConnectionStringRef; ref != nil {
+		definition.StorageConnectionStringSecret = ref.Name
+		definition.StorageConnectionStringKey = ref.Key
+	}
+	return definition
+}
+
+func fileShareDefinition(config *api.FileShareConfig) externalmetrics.FileShareDefinition {
+	if config == nil {
+		return externalmetrics.FileShareDefinition{}
+	}
+
+	return externalmetrics.FileShareDefinition{
+		Account: config.Account,
+		Share:   config.Share,
+		Metric:  config.Metric,
+	}
+}
+
+func activityLogDefinition(config *api.ActivityLogConfig) externalmetrics.ActivityLogDefinition {
+	if config == nil {
+		return externalmetrics.ActivityLogDefinition{}
+	}
+
+	return externalmetrics.ActivityLogDefinition{
+		Window:           config.Window,
+		ResourceID:       config.ResourceID,
+		ResourceProvider: config.ResourceProvider,
+		Category:         config.Category,
+		OperationName:    config.OperationName,
+		Status:           config.Status,
+		Level:            config.Level,
+	}
+}
+
+func containerAppDefinition(config *api.ContainerAppConfig) externalmetrics.ContainerAppDefinition {
+	if config == nil {
+		return externalmetrics.ContainerAppDefinition{}
+	}
+
+	return externalmetrics.ContainerAppDefinition{
+		App:         config.App,
+		Environment: config.Environment,
+		Revision:    config.Revision,
+		Metric:      config.Metric,
+	}
+}
+
+func logicAppDefinition(config *api.LogicAppConfig) externalmetrics.LogicAppDefinition {
+	if config == nil {
+		return externalmetrics.LogicAppDefinition{}
+	}
+
+	return externalmetrics.LogicAppDefinition{
+		Workflow: config.Workflow,
+		Metric:   config.Metric,
+	}
+}
+
+func logAnalyticsDefinition(config *api.LogAnalyticsConfig) externalmetrics.LogAnalyticsDefinition {
+	if config == nil {
+		return externalmetrics.LogAnalyticsDefinition{}
+	}
+
+	return externalmetrics.LogAnalyticsDefinition{
+		Workspace: config.Workspace,
+		Query:     config.Query,
+		Timespan:  config.Timespan,
+		Column:    config.Column,
+	}
+}
+
+func dataExplorerDefinition(config *api.DataExplorerConfig) externalmetrics.DataExplorerDefinition {
+	if config == nil {
+		return externalmetrics.DataExplorerDefinition{}
+	}
+
+	return externalmetrics.DataExplorerDefinition{
+		Cluster:  config.Cluster,
+		Database: config.Database,
+		Query:    config.Query,
+		Column:   config.Column,
+	}
+}
+
+func cosmosDBDefinition(config *api.CosmosDBConfig) externalmetrics.CosmosDBDefinition {
+	if config == nil {
+		return externalmetrics.CosmosDBDefinition{}
+	}
+
+	definition := externalmetrics.CosmosDBDefinition{
+		Account:        config.Account,
+		Database:       config.Database,
+		Container:      config.Container,
+		Metric:         config.Metric,
+		LeaseDatabase:  config.LeaseDatabase,
+		LeaseContainer: config.LeaseContainer,
+		LeasePrefix:    config.LeasePrefix,
+	}
+	if config.ConnectionStringRef != nil {
+		definition.ConnectionStringSecret = config.ConnectionStringRef.Name
+		definition.ConnectionStringKey = config.ConnectionStringRef.Key
+	}
+	return definition
+}
+
+func prometheusDefinition(config *api.PrometheusConfig) externalmetrics.PrometheusDefinition {
+	if config == nil {
+		return externalmetrics.PrometheusDefinition{}
+	}
+
+	return externalmetrics.PrometheusDefinition{
+		Endpoint: config.Endpoint,
+		Query:    config.Query,
+	}
+}
+
+func iotHubDefinition(config *api.IoTHubConfig) externalmetrics.IoTHubDefinition {
+	if config == nil {
+		return externalmetrics.IoTHubDefinition{}
+	}
+
+	definition := externalmetrics.IoTHubDefinition{
+		ConsumerGroup:  config.ConsumerGroup,
+		StorageAccount: config.CheckpointStore.Account,
+		Container:      config.CheckpointStore.Container,
+	}
+	if config.ConnectionStringRef != nil {
+		definition.ConnectionStringSecret = config.ConnectionStringRef.Name
+		definition.ConnectionStringKey = config.ConnectionStringRef.Key
+	}
+	if ref := config.CheckpointStore.ConnectionStringRef; ref != nil {
+		definition.StorageConnectionStringSecret = ref.Name
+		definition.StorageConnectionStringKey = ref.Key
+	}
+	return definition
+}
+
+func batchDefinition(config *api.BatchConfig) externalmetrics.BatchDefinition {
+	if config == nil {
+		return externalmetrics.BatchDefinition{}
+	}
+
+	return externalmetrics.BatchDefinition{
+		Endpoint: config.Endpoint,
+		Job:      config.Job,
+		Pool:     config.Pool,
+		Tasks:    config.Tasks,
+	}
+}
+
+func blobCountDefinition(config *api.BlobCountConfig) externalmetrics.BlobCountDefinition {
+	if config == nil {
+		return externalmetrics.BlobCountDefinition{}
+	}
+
+	definition := externalmetrics.BlobCountDefinition{
+		Account:   config.Account,
+		Container: config.Container,
+		Prefix:    config.Prefix,
+	}
+	if config.ConnectionStringRef != nil {
+		definition.ConnectionStringSecret = config.ConnectionStringRef.Name
+		definition.ConnectionStringKey = config.ConnectionStringRef.Key
+	}
+	return definition
+}
+
+func eventGridDefinition(config *api.EventGridConfig) externalmetrics.EventGridDefinition {
+	if config == nil {
+		return externalmetrics.EventGridDefinition{}
+	}
+
+	return externalmetrics.EventGridDefinition{
+		KeySecret: config.KeyRef.Name,
+		KeyKey:    config.KeyRef.Key,
+		Value:     config.Value,
+		MaxAge:    config.MaxAge,
+	}
+}
+
+func redisDefinition(config *api.RedisConfig) externalmetrics.RedisDefinition {
+	if config == nil {
+		return externalmetrics.RedisDefinition{}
+	}
+
+	definition := externalmetrics.RedisDefinition{
+		Host:     config.Host,
+		Key:      config.Key,
+		Database: config.Database,
+	}
+	if config.AccessKeyRef != nil {
+		definition.AccessKeySecret = config.AccessKeyRef.Name
+		definition.AccessKeyKey = config.AccessKeyRef.Key
+	}
+	return definition
+}
+
+func azureDevOpsDefinition(config *api.AzureDevOpsConfig) externalmetrics.AzureDevOpsDefinition {
+	if config == nil {
+		return externalmetrics.AzureDevOpsDefinition{}
+	}
+
+	definition := externalmetrics.AzureDevOpsDefinition{
+		OrganizationURL: config.OrganizationURL,
+		PoolID:          config.PoolID,
+		PoolName:        config.PoolName,
+		Jobs:            config.Jobs,
+	}
+	if config.PersonalAccessTokenRef != nil {
+		definition.PersonalAccessTokenSecret = config.PersonalAccessTokenRef.Name
+		definition.PersonalAccessTokenKey = config.PersonalAccessTokenRef.Key
+	}
+	return definition
+}
+
+func resourceRefs(resources []api.AzureResource) []externalmetrics.ResourceRef {
+	if len(resources) == 0 {
+		return nil
+	}
+
+	refs := make([]externalmetrics.ResourceRef, len(resources))
+	for i, resource := range resources {
+		refs[i] = externalmetrics.ResourceRef{ResourceGroup: resource.ResourceGroup, ResourceName: resource.ResourceName}
+	}
+	return refs
+}
+
+func costDefinition(config *api.CostConfig) externalmetrics.CostDefinition {
+	if config == nil {
+		return externalmetrics.CostDefinition{}
+	}
+
+	return externalmetrics.CostDefinition{
+		Metric:    config.Metric,
+		Timeframe: config.Timeframe,
+		Days:      config.Days,
+		CostType:  config.CostType,
+		Budget:    config.Budget,
+	}
+}
+
+func quotaDefinition(config *api.QuotaConfig) externalmetrics.QuotaDefinition {
+	if config == nil {
+		return externalmetrics.QuotaDefinition{}
+	}
+
+	return externalmetrics.QuotaDefinition{
+		Provider: config.Provider,
+		Location: config.Location,
+		Name:     config.Name,
+		Metric:   config.Metric,
+	}
+}
+
+func alertDefinition(config *api.AlertConfig) externalmetrics.AlertDefinition {
+	if config == nil {
+		return externalmetrics.AlertDefinition{}
+	}
+
+	return externalmetrics.AlertDefinition{
+		RuleID: config.RuleID,
+		Action: config.Action,
+		Value:  float64(config.Value),
+	}
+}
+
+func maintenanceDefinition(config *api.MaintenanceConfig) externalmetrics.MaintenanceDefinition {
+	if config == nil {
+		return externalmetrics.MaintenanceDefinition{}
+	}
+
+	maintenance := externalmetrics.MaintenanceDefinition{
+		TimeZone: config.TimeZone,
+	}
+	for _, window := range config.Windows {
+		maintenance.Windows = append(maintenance.Windows, externalmetrics.MaintenanceWindow{
+			From:  window.From,
+			Until: window.Until,
+			Days:  window.Days,
+			Start: window.Start,
+			End:   window.End,
+		})
+	}
+
+	return maintenance
+}
+
+func ratioDefinition(config *api.RatioConfig) externalmetrics.RatioDefinition {
+	if config == nil {
+		return externalmetrics.RatioDefinition{}
+	}
+
+	denominator := config.Denominator
+	definition := externalmetrics.RatioDefinition{
+		SubscriptionID:            denominator.SubscriptionID,
+		ResourceGroup:             denominator.ResourceGroup,
+		ResourceProviderNamespace: denominator.ResourceProviderNamespace,
+		ResourceType:              denominator.ResourceType,
+		ResourceName:              denominator.ResourceName,
+	}
+	if denominator.Filter != nil {
+		definition.Filter = *denominator.Filter
+		definition.ReplaceFilter = true
+	}
+	return definition
+}
+
+func seasonalDefinition(config *api.SeasonalConfig) externalmetrics.SeasonalDefinition {
+	if config == nil {
+		return externalmetrics.SeasonalDefinition{}
+	}
+
+	return externalmetrics.SeasonalDefinition{
+		Offset:     config.Offset,
+		Comparison: config.Comparison,
+	}
+}
+
+func weightedSources(sources []api.WeightedSource) []externalmetrics.WeightedSource {
+	var weighted []externalmetrics.WeightedSource
+	for _, source := range sources {
+		weighted = append(weighted, externalmetrics.WeightedSource{
+			Name:    source.Name,
+			Weight:  source.Weight,
+			Request: ExternalMetricRequest(source.ExternalMetricSpec),
+		})
+	}
+
+	return weighted
+}
@@ -2,16 +2,22 @@ package controller
 
 import (
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/custommetrics"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/fake"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/scheme"
 	informers "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/informers/externalversions"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/metriccache"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/variables"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "k8s.io/client-go/dynamic/fake"
 )
 
 func getExternalKey(externalMetric *api.ExternalMetric) namespacedQueueItem {
@@ -55,6 +61,1376 @@ func TestExternalMetricValueIsStored(t *testing.T) {
 	validateExternalMetricResult(metricRequest, externalMetric, t)
 }
 
+func TestScheduleExternalMetricIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("schedule")
+	externalMetric.Spec.Type = externalmetrics.Schedule
+	externalMetric.Spec.Schedule = &api.ScheduleConfig{
+		TimeZone:     "Europe/London",
+		DefaultValue: 2,
+		Windows:      []api.ScheduleWindow{{Days: "1-5", Start: "09:00", End: "17:00", Value: 10}},
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	schedule := metricRequest.Schedule
+	if schedule.TimeZone != "Europe/London" || schedule.DefaultValue != 2 {
+		t.Errorf("metricRequest Schedule = %v, want time zone Europe/London and default 2", schedule)
+	}
+
+	if len(schedule.Windows) != 1 || schedule.Windows[0].Value != 10 || schedule.Windows[0].Days != "1-5" {
+		t.Errorf("metricRequest Schedule Windows = %v, want one window with value 10", schedule.Windows)
+	}
+}
+
+func TestHeartbeatExternalMetricIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("heartbeat")
+	externalMetric.Spec.Type = externalmetrics.Heartbeat
+	externalMetric.Spec.Heartbeat = &api.HeartbeatConfig{Value: 1, RampTo: 10, RampPeriod: "30m"}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.HeartbeatDefinition{Value: 1, RampTo: 10, RampPeriod: "30m"}
+	if metricRequest.Heartbeat != want {
+		t.Errorf("metricRequest Heartbeat = %+v, want %+v", metricRequest.Heartbeat, want)
+	}
+}
+
+func TestPredictiveExternalMetricIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("predictive")
+	externalMetric.Spec.Type = externalmetrics.Predictive
+	externalMetric.Spec.Prediction = &api.PredictionConfig{
+		History:  "24h",
+		Interval: "15m",
+		Horizon:  "10m",
+		Method:   "holtwinters",
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.PredictionDefinition{History: "24h", Interval: "15m", Horizon: "10m", Method: "holtwinters"}
+	if metricRequest.Prediction != want {
+		t.Errorf("metricRequest Prediction = %v, want %v", metricRequest.Prediction, want)
+	}
+
+	validateExternalMetricResult(metricRequest, externalMetric, t)
+}
+
+func TestSLOBurnRateExternalMetricIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("slo")
+	externalMetric.Spec.Type = externalmetrics.SLOBurnRate
+	externalMetric.Spec.SLO = &api.SLOConfig{
+		Objective:   "99.9",
+		LongWindow:  "6h",
+		ShortWindow: "30m",
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.SLODefinition{Objective: "99.9", LongWindow: "6h", ShortWindow: "30m"}
+	if metricRequest.SLO != want {
+		t.Errorf("metricRequest SLO = %v, want %v", metricRequest.SLO, want)
+	}
+}
+
+func TestPluginExternalMetricIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("plugin")
+	externalMetric.Spec.Type = externalmetrics.Plugin
+	externalMetric.Spec.Plugin = &api.PluginConfig{
+		Name:       "rabbitmq",
+		Parameters: map[string]string{"queue": "orders"},
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	if metricRequest.Plugin.Name != "rabbitmq" || metricRequest.Plugin.Parameters["queue"] != "orders" {
+		t.Errorf("metricRequest Plugin = %v, want rabbitmq with queue parameter", metricRequest.Plugin)
+	}
+}
+
+func TestWebhookExternalMetricIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("webhook")
+	externalMetric.Spec.Type = externalmetrics.Webhook
+	externalMetric.Spec.Webhook = &api.WebhookConfig{
+		URL:         "https://metrics.contoso.com/orders/backlog",
+		AADResource: "api://orders",
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.WebhookDefinition{URL: "https://metrics.contoso.com/orders/backlog", AADResource: "api://orders"}
+	if metricRequest.Webhook != want {
+		t.Errorf("metricRequest Webhook = %v, want %v", metricRequest.Webhook, want)
+	}
+}
+
+func TestExternalMetricActivityIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("activity")
+	externalMetric.Spec.Activity = &api.ActivityConfig{Threshold: 3, Window: "10m"}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.ActivityDefinition{Enabled: true, Threshold: 3, Window: "10m"}
+	if metricRequest.Activity != want {
+		t.Errorf("metricRequest Activity = %v, want %v", metricRequest.Activity, want)
+	}
+}
+
+func TestExternalMetricNodeIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("node")
+	externalMetric.Spec.Node = &api.NodeConfig{Dimension: "VMName"}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.NodeDefinition{Enabled: true, Dimension: "VMName"}
+	if metricRequest.Node != want {
+		t.Errorf("metricRequest Node = %v, want %v", metricRequest.Node, want)
+	}
+}
+
+func TestExternalMetricPerReplicaIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("perreplica")
+	externalMetric.Spec.PerReplica = &api.PerReplicaConfig{Name: "consumer"}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.PerReplicaDefinition{Enabled: true, Name: "consumer"}
+	if metricRequest.PerReplica != want {
+		t.Errorf("metricRequest PerReplica = %v, want %v", metricRequest.PerReplica, want)
+	}
+}
+
+func TestExternalMetricExpressionIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("expression")
+	externalMetric.Spec.Expression = &api.ExpressionConfig{
+		Value:       "value / replicas",
+		TimeZone:    "Europe/Paris",
+		ScaleTarget: &api.PerReplicaConfig{Name: "consumer"},
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.ExpressionDefinition{
+		Value:       "value / replicas",
+		TimeZone:    "Europe/Paris",
+		ScaleTarget: externalmetrics.PerReplicaDefinition{Enabled: true, Name: "consumer"},
+	}
+	if metricRequest.Expression != want {
+		t.Errorf("metricRequest Expression = %v, want %v", metricRequest.Expression, want)
+	}
+}
+
+func TestExternalMetricStorageQueueIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("queue-age")
+	externalMetric.Spec.Type = externalmetrics.StorageQueueMessageAge
+	externalMetric.Spec.StorageQueue = &api.StorageQueueConfig{Account: "ordersaccount", Queue: "orders"}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.StorageQueueDefinition{Account: "ordersaccount", Queue: "orders"}
+	if metricRequest.StorageQueue != want {
+		t.Errorf("metricRequest StorageQueue = %v, want %v", metricRequest.StorageQueue, want)
+	}
+}
+
+func TestExternalMetricStorageQueueLengthIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("queue-length")
+	externalMetric.Spec.Type = externalmetrics.StorageQueueLength
+	externalMetric.Spec.StorageQueue = &api.StorageQueueConfig{
+		Queue:               "orders",
+		ConnectionStringRef: &api.SecretKeyRef{Name: "orders-storage", Key: "connectionString"},
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.StorageQueueDefinition{Queue: "orders", ConnectionStringSecret: "orders-storage", ConnectionStringKey: "connectionString"}
+	if metricRequest.Type != externalmetrics.StorageQueueLength || metricRequest.StorageQueue != want {
+		t.Errorf("metricRequest = %v %v, want %v %v", metricRequest.Type, metricRequest.StorageQueue, externalmetrics.StorageQueueLength, want)
+	}
+}
+
+func TestExternalMetricEventHubIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("orders-lag")
+	externalMetric.Spec.Type = externalmetrics.EventHub
+	externalMetric.Spec.EventHub = &api.EventHubConfig{
+		Namespace:     "orders-eh",
+		EventHub:      "orders",
+		ConsumerGroup: "processor",
+		CheckpointStore: api.CheckpointStoreConfig{
+			Container:           "checkpoints",
+			ConnectionStringRef: &api.SecretKeyRef{Name: "checkpoints", Key: "connectionString"},
+		},
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.EventHubDefinition{
+		Namespace:                     "orders-eh",
+		EventHub:                      "orders",
+		ConsumerGroup:                 "processor",
+		Container:                     "checkpoints",
+		StorageConnectionStringSecret: "checkpoints",
+		StorageConnectionStringKey:    "connectionString",
+	}
+	if metricRequest.EventHub != want {
+		t.Errorf("metricRequest EventHub = %v, want %v", metricRequest.EventHub, want)
+	}
+}
+
+func TestExternalMetricLogAnalyticsIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("errors")
+	externalMetric.Spec.Type = externalmetrics.LogAnalytics
+	externalMetric.Spec.LogAnalytics = &api.LogAnalyticsConfig{
+		Workspace: "00000000-1111-2222-3333-444444444444",
+		Query:     "AppExceptions | count",
+		Timespan:  "15m",
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.LogAnalyticsDefinition{Workspace: "00000000-1111-2222-3333-444444444444", Query: "AppExceptions | count", Timespan: "15m"}
+	if metricRequest.LogAnalytics != want {
+		t.Errorf("metricRequest LogAnalytics = %v, want %v", metricRequest.LogAnalytics, want)
+	}
+}
+
+func TestExternalMetricDataExplorerIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("backlog")
+	externalMetric.Spec.Type = externalmetrics.DataExplorer
+	externalMetric.Spec.DataExplorer = &api.DataExplorerConfig{
+		Cluster:  "https://ingest.westeurope.kusto.windows.net",
+		Database: "Ingestion",
+		Query:    "Backlog | count",
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.DataExplorerDefinition{Cluster: "https://ingest.westeurope.kusto.windows.net", Database: "Ingestion", Query: "Backlog | count"}
+	if metricRequest.DataExplorer != want {
+		t.Errorf("metricRequest DataExplorer = %v, want %v", metricRequest.DataExplorer, want)
+	}
+}
+
+func TestExternalMetricCosmosDBIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("change-feed-lag")
+	externalMetric.Spec.Type = externalmetrics.CosmosDB
+	externalMetric.Spec.CosmosDB = &api.CosmosDBConfig{
+		Database:            "orders",
+		Container:           "events",
+		Metric:              "changefeedlag",
+		LeaseContainer:      "events-leases",
+		ConnectionStringRef: &api.SecretKeyRef{Name: "orders-db", Key: "connectionString"},
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.CosmosDBDefinition{Database: "orders", Container: "events", Metric: "changefeedlag", LeaseContainer: "events-leases", ConnectionStringSecret: "orders-db", ConnectionStringKey: "connectionString"}
+	if metricRequest.CosmosDB != want {
+		t.Errorf("metricRequest CosmosDB = %v, want %v", metricRequest.CosmosDB, want)
+	}
+}
+
+func TestExternalMetricPrometheusIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("requests")
+	externalMetric.Spec.Type = externalmetrics.Prometheus
+	externalMetric.Spec.Prometheus = &api.PrometheusConfig{
+		Endpoint: "https://orders-ws.westeurope.prometheus.monitor.azure.com",
+		Query:    "sum(rate(http_requests_total[5m]))",
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.PrometheusDefinition{Endpoint: "https://orders-ws.westeurope.prometheus.monitor.azure.com", Query: "sum(rate(http_requests_total[5m]))"}
+	if metricRequest.Prometheus != want {
+		t.Errorf("metricRequest Prometheus = %v, want %v", metricRequest.Prometheus, want)
+	}
+}
+
+func TestExternalMetricIoTHubIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("telemetry-backlog")
+	externalMetric.Spec.Type = externalmetrics.IoTHub
+	externalMetric.Spec.IoTHub = &api.IoTHubConfig{
+		ConsumerGroup:       "telemetry",
+		ConnectionStringRef: &api.SecretKeyRef{Name: "devices-hub", Key: "eventHubConnectionString"},
+		CheckpointStore:     api.CheckpointStoreConfig{Account: "checkpoints", Container: "telemetry"},
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.IoTHubDefinition{ConsumerGroup: "telemetry", ConnectionStringSecret: "devices-hub", ConnectionStringKey: "eventHubConnectionString", StorageAccount: "checkpoints", Container: "telemetry"}
+	if metricRequest.IoTHub != want {
+		t.Errorf("metricRequest IoTHub = %v, want %v", metricRequest.IoTHub, want)
+	}
+}
+
+func TestExternalMetricBatchIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("pending-tasks")
+	externalMetric.Spec.Type = externalmetrics.Batch
+	externalMetric.Spec.Batch = &api.BatchConfig{
+		Endpoint: "https://render.westeurope.batch.azure.com",
+		Pool:     "gpu",
+		Tasks:    "pending",
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.BatchDefinition{Endpoint: "https://render.westeurope.batch.azure.com", Pool: "gpu", Tasks: "pending"}
+	if metricRequest.Batch != want {
+		t.Errorf("metricRequest Batch = %v, want %v", metricRequest.Batch, want)
+	}
+}
+
+func TestExternalMetricBlobCountIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("uploads")
+	externalMetric.Spec.Type = externalmetrics.BlobCount
+	externalMetric.Spec.BlobCount = &api.BlobCountConfig{
+		Account:   "uploads",
+		Container: "files",
+		Prefix:    "incoming/",
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.BlobCountDefinition{Account: "uploads", Container: "files", Prefix: "incoming/"}
+	if metricRequest.BlobCount != want {
+		t.Errorf("metricRequest BlobCount = %v, want %v", metricRequest.BlobCount, want)
+	}
+}
+
+func TestExternalMetricEventGridIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("backlog")
+	externalMetric.Spec.Type = externalmetrics.EventGrid
+	externalMetric.Spec.EventGrid = &api.EventGridConfig{
+		KeyRef: api.SecretKeyRef{Name: "event-grid", Key: "key"},
+		Value:  "metrics.backlog",
+		MaxAge: "10m",
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.EventGridDefinition{KeySecret: "event-grid", KeyKey: "key", Value: "metrics.backlog", MaxAge: "10m"}
+	if metricRequest.EventGrid != want {
+		t.Errorf("metricRequest EventGrid = %v, want %v", metricRequest.EventGrid, want)
+	}
+}
+
+func TestExternalMetricRedisIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("jobs")
+	externalMetric.Spec.Type = externalmetrics.Redis
+	externalMetric.Spec.Redis = &api.RedisConfig{
+		Host:         "jobs.redis.cache.windows.net",
+		Key:          "jobs:pending",
+		Database:     2,
+		AccessKeyRef: &api.SecretKeyRef{Name: "jobs-redis", Key: "accessKey"},
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.RedisDefinition{Host: "jobs.redis.cache.windows.net", Key: "jobs:pending", Database: 2, AccessKeySecret: "jobs-redis", AccessKeyKey: "accessKey"}
+	if metricRequest.Redis != want {
+		t.Errorf("metricRequest Redis = %v, want %v", metricRequest.Redis, want)
+	}
+}
+
+func TestExternalMetricAzureDevOpsIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("agents")
+	externalMetric.Spec.Type = externalmetrics.AzureDevOps
+	externalMetric.Spec.AzureDevOps = &api.AzureDevOpsConfig{
+		OrganizationURL:        "https://dev.azure.com/contoso",
+		PoolName:               "aks agents",
+		Jobs:                   "pending",
+		PersonalAccessTokenRef: &api.SecretKeyRef{Name: "azure-devops", Key: "pat"},
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.AzureDevOpsDefinition{
+		OrganizationURL:           "https://dev.azure.com/contoso",
+		PoolName:                  "aks agents",
+		Jobs:                      "pending",
+		PersonalAccessTokenSecret: "azure-devops",
+		PersonalAccessTokenKey:    "pat",
+	}
+	if metricRequest.AzureDevOps != want {
+		t.Errorf("metricRequest AzureDevOps = %v, want %v", metricRequest.AzureDevOps, want)
+	}
+}
+
+func TestExternalMetricCostIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("spend")
+	externalMetric.Spec.Type = externalmetrics.Cost
+	externalMetric.Spec.Cost = &api.CostConfig{
+		Metric:   "spendrate",
+		Days:     14,
+		CostType: "AmortizedCost",
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.CostDefinition{
+		Metric:   "spendrate",
+		Days:     14,
+		CostType: "AmortizedCost",
+	}
+	if metricRequest.Cost != want {
+		t.Errorf("metricRequest Cost = %v, want %v", metricRequest.Cost, want)
+	}
+}
+
+func TestExternalMetricQuotaIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("vcpus")
+	externalMetric.Spec.Type = externalmetrics.Quota
+	externalMetric.Spec.Quota = &api.QuotaConfig{
+		Location: "westeurope",
+		Name:     "standardDSv3Family",
+		Metric:   "percent",
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.QuotaDefinition{
+		Location: "westeurope",
+		Name:     "standardDSv3Family",
+		Metric:   "percent",
+	}
+	if metricRequest.Quota != want {
+		t.Errorf("metricRequest Quota = %v, want %v", metricRequest.Quota, want)
+	}
+}
+
+func TestExternalMetricServiceBusQueueIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("queue-length")
+	externalMetric.Spec.Type = externalmetrics.ServiceBusQueue
+	externalMetric.Spec.ServiceBusQueue = &api.ServiceBusQueueConfig{
+		Queue:               "orders",
+		ConnectionStringRef: &api.SecretKeyRef{Name: "orders-servicebus", Key: "connectionString"},
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.ServiceBusQueueDefinition{Queue: "orders", ConnectionStringSecret: "orders-servicebus", ConnectionStringKey: "connectionString"}
+	if metricRequest.ServiceBusQueue != want {
+		t.Errorf("metricRequest ServiceBusQueue = %v, want %v", metricRequest.ServiceBusQueue, want)
+	}
+}
+
+func TestExternalMetricFileShareIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("share-transactions")
+	externalMetric.Spec.Type = externalmetrics.FileShare
+	externalMetric.Spec.FileShare = &api.FileShareConfig{Account: "dataaccount", Share: "data", Metric: "transactions"}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.FileShareDefinition{Account: "dataaccount", Share: "data", Metric: "transactions"}
+	if metricRequest.FileShare != want {
+		t.Errorf("metricRequest FileShare = %v, want %v", metricRequest.FileShare, want)
+	}
+}
+
+func TestExternalMetricActivityLogIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("autoscale-events")
+	externalMetric.Spec.Type = externalmetrics.ActivityLog
+	externalMetric.Spec.ActivityLog = &api.ActivityLogConfig{Window: "30m", Category: "Autoscale", Status: "Succeeded"}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.ActivityLogDefinition{Window: "30m", Category: "Autoscale", Status: "Succeeded"}
+	if metricRequest.ActivityLog != want {
+		t.Errorf("metricRequest ActivityLog = %v, want %v", metricRequest.ActivityLog, want)
+	}
+}
+
+func TestExternalMetricContainerAppIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("api-requests")
+	externalMetric.Spec.Type = externalmetrics.ContainerApp
+	externalMetric.Spec.ContainerApp = &api.ContainerAppConfig{App: "api", Revision: "api--v2", Metric: "requests"}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.ContainerAppDefinition{App: "api", Revision: "api--v2", Metric: "requests"}
+	if metricRequest.ContainerApp != want {
+		t.Errorf("metricRequest ContainerApp = %v, want %v", metricRequest.ContainerApp, want)
+	}
+}
+
+func TestExternalMetricLogicAppIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("order-runs")
+	externalMetric.Spec.Type = externalmetrics.LogicApp
+	externalMetric.Spec.LogicApp = &api.LogicAppConfig{Workflow: "orders", Metric: "started"}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.LogicAppDefinition{Workflow: "orders", Metric: "started"}
+	if metricRequest.LogicApp != want {
+		t.Errorf("metricRequest LogicApp = %v, want %v", metricRequest.LogicApp, want)
+	}
+}
+
+func TestExternalMetricCredentialIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("team-a-queue")
+	externalMetric.Spec.Credential = "team-a"
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	if metricRequest.Credential != "team-a" {
+		t.Errorf("metricRequest Credential = %v, want %v", metricRequest.Credential, "team-a")
+	}
+}
+
+func TestExternalMetricRatioIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	total := ""
+	externalMetric := newFullExternalMetric("share")
+	externalMetric.Spec.Type = externalmetrics.Ratio
+	externalMetric.Spec.Ratio = &api.RatioConfig{
+		Denominator: api.RatioDenominator{ResourceName: "app-staging", Filter: &total},
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.RatioDefinition{ResourceName: "app-staging", ReplaceFilter: true}
+	if metricRequest.Ratio != want {
+		t.Errorf("metricRequest Ratio = %+v, want %+v", metricRequest.Ratio, want)
+	}
+}
+
+func TestExternalMetricAlertIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	ruleID := "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Insights/metricAlerts/backlog"
+	externalMetric := newFullExternalMetric("guarded")
+	externalMetric.Spec.Alert = &api.AlertConfig{RuleID: ruleID, Action: "floor", Value: 20}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.AlertDefinition{RuleID: ruleID, Action: "floor", Value: 20}
+	if metricRequest.Alert != want {
+		t.Errorf("metricRequest Alert = %v, want %v", metricRequest.Alert, want)
+	}
+}
+
+func TestExternalMetricMaintenanceIsStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("maintained")
+	externalMetric.Spec.Maintenance = &api.MaintenanceConfig{
+		TimeZone: "Europe/London",
+		Windows:  []api.MaintenanceWindow{{Days: "SUN", Start: "02:00", End: "04:00"}},
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	want := externalmetrics.MaintenanceDefinition{
+		TimeZone: "Europe/London",
+		Windows:  []externalmetrics.MaintenanceWindow{{Days: "SUN", Start: "02:00", End: "04:00"}},
+	}
+	if !reflect.DeepEqual(metricRequest.Maintenance, want) {
+		t.Errorf("metricRequest Maintenance = %v, want %v", metricRequest.Maintenance, want)
+	}
+}
+
+func TestExternalMetricShadowIsStored(t *testing.T) {
+	var tests = []struct {
+		shadow     string
+		wantFilter string
+	}{
+		{`{"metric":{"metricName":"Messages","filter":"EntityName eq 'orders'"}}`, "EntityName eq 'orders'"},
+		// the primary spec is stored without the invalid shadow
+		{`{"metric":`, ""},
+		{`{"metric":{"metricName":"Messages","aggregation":"P95"}}`, ""},
+	}
+
+	for _, tt := range tests {
+		var storeObjects []runtime.Object
+		var externalMetricsListerCache []*api.ExternalMetric
+		var customMetricsListerCache []*api.CustomMetric
+
+		externalMetric := newFullExternalMetric("shadowed")
+		externalMetric.Annotations = map[string]string{ShadowAnnotation: tt.shadow}
+		storeObjects = append(storeObjects, externalMetric)
+		externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+		handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+		queueItem := getExternalKey(externalMetric)
+		err := handler.Process(queueItem)
+
+		if err != nil {
+			t.Errorf("error after processing = %v, want %v", err, nil)
+		}
+
+		metricRequest, exists := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+		if !exists {
+			t.Fatalf("metricRequest not stored for shadow %s", tt.shadow)
+		}
+
+		if tt.wantFilter == "" {
+			if metricRequest.Shadow != nil {
+				t.Errorf("metricRequest Shadow = %v, want nil", metricRequest.Shadow)
+			}
+			continue
+		}
+		if metricRequest.Shadow == nil || metricRequest.Shadow.Filter != tt.wantFilter {
+			t.Errorf("metricRequest Shadow = %v, want filter %v", metricRequest.Shadow, tt.wantFilter)
+		}
+	}
+}
+
+func TestExternalMetricPinIsStored(t *testing.T) {
+	until, _ := time.Parse(time.RFC3339, "2019-03-10T02:00:00Z")
+	value := 10.5
+
+	var tests = []struct {
+		pin  string
+		want externalmetrics.PinDefinition
+	}{
+		{`{"until":"2019-03-10T02:00:00Z","value":"10500m"}`, externalmetrics.PinDefinition{Until: until, Value: &value}},
+		{`{"until":"2019-03-10T02:00:00Z"}`, externalmetrics.PinDefinition{Until: until}},
+		// the metric is stored without the invalid pin
+		{`{"until":"in an hour"}`, externalmetrics.PinDefinition{}},
+		{`{"until":`, externalmetrics.PinDefinition{}},
+	}
+
+	for _, tt := range tests {
+		externalMetric := newFullExternalMetric("pinned")
+		externalMetric.Annotations = map[string]string{PinAnnotation: tt.pin}
+
+		handler, metriccache := newHandler([]runtime.Object{externalMetric}, []*api.ExternalMetric{externalMetric}, nil)
+
+		if err := handler.Process(getExternalKey(externalMetric)); err != nil {
+			t.Errorf("error after processing = %v, want %v", err, nil)
+		}
+
+		metricRequest, exists := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+		if !exists {
+			t.Fatalf("metricRequest not stored for pin %s", tt.pin)
+		}
+		if !reflect.DeepEqual(metricRequest.Pin, tt.want) {
+			t.Errorf("pin %s: metricRequest Pin = %v, want %v", tt.pin, metricRequest.Pin, tt.want)
+		}
+	}
+}
+
+func TestExternalMetricSourcesAreStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("combined")
+	externalMetric.Spec.Type = externalmetrics.Combined
+	externalMetric.Spec.Sources = []api.WeightedSource{
+		{Weight: "0.7", ExternalMetricSpec: api.ExternalMetricSpec{Type: externalmetrics.Monitor, MetricConfig: api.ExternalMetricConfig{MetricName: "Messages"}}},
+		{Weight: "0.3", ExternalMetricSpec: api.ExternalMetricSpec{Type: externalmetrics.SLOBurnRate, SLO: &api.SLOConfig{Objective: "99.9"}}},
+	}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	if len(metricRequest.Sources) != 2 {
+		t.Fatalf("metricRequest Sources = %v, want 2 sources", metricRequest.Sources)
+	}
+	if metricRequest.Sources[0].Weight != "0.7" || metricRequest.Sources[0].Request.MetricName != "Messages" {
+		t.Errorf("metricRequest Sources[0] = %+v, want weight 0.7 of Messages", metricRequest.Sources[0])
+	}
+	if metricRequest.Sources[1].Weight != "0.3" || metricRequest.Sources[1].Request.SLO.Objective != "99.9" {
+		t.Errorf("metricRequest Sources[1] = %+v, want weight 0.3 of the slo", metricRequest.Sources[1])
+	}
+}
+
+func TestExternalMetricVariablesAreExpanded(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("nodes")
+	externalMetric.Spec.AzureConfig.ResourceGroup = "{{ .NodeResourceGroup }}"
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+
+	if metricRequest.ResourceGroup != "MC_rg_aks_westus2" {
+		t.Errorf("metricRequest ResourceGroup = %v, want %v", metricRequest.ResourceGroup, "MC_rg_aks_westus2")
+	}
+}
+
+func TestExternalMetricVariablesOfMetricAndConfigMapAreExpanded(t *testing.T) {
+	externalMetric := newFullExternalMetric("orders")
+	externalMetric.Spec.VariablesConfigMap = "environment"
+	externalMetric.Spec.AzureConfig.ResourceGroup = "{{ .resourceGroup }}"
+	externalMetric.Spec.AzureConfig.ResourceName = "{{ .Namespace }}-{{ .Name }}"
+	// the values of the config map can't replace the variables of the metric
+	configMap := newConfigMap(externalMetric.Namespace, "environment", map[string]interface{}{"resourceGroup": "rg-prod", "Name": "other"})
+
+	handler, metriccache, _ := newGroupHandler([]runtime.Object{externalMetric}, []*api.ExternalMetric{externalMetric}, nil, configMap)
+	if err := handler.Process(getExternalKey(externalMetric)); err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	metricRequest, _ := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name)
+	want := fmt.Sprintf("%s-orders", externalMetric.Namespace)
+	if metricRequest.ResourceGroup != "rg-prod" || metricRequest.ResourceName != want {
+		t.Errorf("metricRequest resource = %s/%s, want rg-prod/%s", metricRequest.ResourceGroup, metricRequest.ResourceName, want)
+	}
+}
+
+func TestExternalMetricWithMissingVariablesConfigMapIsNotServed(t *testing.T) {
+	externalMetric := newFullExternalMetric("orders")
+	externalMetric.Spec.VariablesConfigMap = "environment"
+
+	handler, metriccache := newHandler([]runtime.Object{externalMetric}, []*api.ExternalMetric{externalMetric}, nil)
+	metriccache.Update(getExternalKey(externalMetric).Key(), externalmetrics.AzureExternalMetricRequest{})
+	if err := handler.Process(getExternalKey(externalMetric)); err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	if _, exists := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name); exists {
+		t.Errorf("exist = %v, want %v", exists, false)
+	}
+}
+
+func TestExternalMetricWithUnknownVariableIsNotServed(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("nodes")
+	externalMetric.Spec.AzureConfig.ResourceName = "{{ .ClusterName }}"
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+	metriccache.Update(getExternalKey(externalMetric).Key(), externalmetrics.AzureExternalMetricRequest{})
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	if _, exists := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name); exists {
+		t.Errorf("exist = %v, want %v", exists, false)
+	}
+}
+
+func TestExternalMetricWithInvalidAggregationIsNotServed(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("latency")
+	externalMetric.Spec.MetricConfig.Aggregation = "P95"
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+	metriccache.Update(getExternalKey(externalMetric).Key(), externalmetrics.AzureExternalMetricRequest{})
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	if _, exists := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name); exists {
+		t.Errorf("exist = %v, want %v", exists, false)
+	}
+}
+
+func TestExternalMetricWithInvalidQueryWindowIsNotServed(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("latency")
+	externalMetric.Spec.MetricConfig.Timespan = "5m"
+	externalMetric.Spec.MetricConfig.Interval = "15m"
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+	metriccache.Update(getExternalKey(externalMetric).Key(), externalmetrics.AzureExternalMetricRequest{})
+
+	queueItem := getExternalKey(externalMetric)
+	err := handler.Process(queueItem)
+
+	if err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	if _, exists := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name); exists {
+		t.Errorf("exist = %v, want %v", exists, false)
+	}
+}
+
+func TestExternalMetricVariantsAreStored(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("queue-depth")
+	externalMetric.Spec.Variants = []api.VariantConfig{{Suffix: "-rate", Rate: true}}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	if err := handler.Process(getExternalKey(externalMetric)); err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	name, variant, found := metriccache.GetExternalMetricVariant(externalMetric.Namespace, "queue-depth-rate")
+	if !found || name != "queue-depth" || !variant.Rate {
+		t.Errorf("variant of queue-depth-rate = %v %+v %v, want the rate of queue-depth", name, variant, found)
+	}
+}
+
+func TestExternalMetricWithInvalidVariantIsNotServed(t *testing.T) {
+	var storeObjects []runtime.Object
+	var externalMetricsListerCache []*api.ExternalMetric
+	var customMetricsListerCache []*api.CustomMetric
+
+	externalMetric := newFullExternalMetric("queue-depth")
+	externalMetric.Spec.Variants = []api.VariantConfig{{Suffix: "rate", Rate: true}}
+	storeObjects = append(storeObjects, externalMetric)
+	externalMetricsListerCache = append(externalMetricsListerCache, externalMetric)
+
+	handler, metriccache := newHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+
+	if err := handler.Process(getExternalKey(externalMetric)); err != nil {
+		t.Errorf("error after processing = %v, want %v", err, nil)
+	}
+
+	if _, exists := metriccache.GetAzureExternalMetricRequest(externalMetric.Namespace, externalMetric.Name); exists {
+		t.Errorf("exist = %v, want %v", exists, false)
+	}
+}
+
 func TestShouldBeAbleToStoreCustomAndExternalWithSameNameAndNamespace(t *testing.T) {
 	var storeObjects []runtime.Object
 	var externalMetricsListerCache []*api.ExternalMetric
@@ -236,11 +1612,19 @@ func TestWhenItemKindIsUnknown(t *testing.T) {
 }
 
 func newHandler(storeObjects []runtime.Object, externalMetricsListerCache []*api.ExternalMetric, customMetricsListerCache []*api.CustomMetric) (Handler, *metriccache.MetricCache) {
+	handler, metriccache, _ := newGroupHandler(storeObjects, externalMetricsListerCache, customMetricsListerCache)
+	return handler, metriccache
+}
+
+// newGroupHandler creates a handler of the metrics and groups.  The kube objects, such as the
+// ConfigMaps of variables, are read with its dynamic client.
+func newGroupHandler(storeObjects []runtime.Object, externalMetricsListerCache []*api.ExternalMetric, customMetricsListerCache []*api.CustomMetric, kubeObjects ...runtime.Object) (Handler, *metriccache.MetricCache, informers.SharedInformerFactory) {
 	fakeClient := fake.NewSimpleClientset(storeObjects...)
 	i := informers.NewSharedInformerFactory(fakeClient, 0)
 
 	externalMetricLister := i.Azure().V1alpha2().ExternalMetrics().Lister()
 	customMetricLister := i.Azure().V1alpha2().CustomMetrics().Lister()
+	groupLister := i.Azure().V1alpha2().ExternalMetricGroups().Lister()
 
 	for _, em := range externalMetricsListerCache {
 		i.Azure().V1alpha2().ExternalMetrics().Informer().GetIndexer().Add(em)
@@ -251,9 +1635,20 @@ func newHandler(storeObjects []runtime.Object, externalMetricsListerCache []*api
 	}
 
 	metriccache := metriccache.NewMetricCache()
-	handler := NewHandler(externalMetricLister, customMetricLister, metriccache)
+	handler := NewHandler(externalMetricLister, customMetricLister, groupLister, metriccache, variables.Variables{variables.NodeResourceGroup: "MC_rg_aks_westus2"}, k8sclient.NewSimpleDynamicClient(scheme.Scheme, kubeObjects...))
 
-	return handler, metriccache
+	return handler, metriccache, i
+}
+
+func newConfigMap(namespace, name string, data map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"namespace": namespace, "name": name},
+			"data":       data,
+		},
+	}
 }
 
 func validateExternalMetricResult(metricRequest externalmetrics.AzureExternalMetricRequest, externalMetricInfo *api.ExternalMetric, t *testing.T) {
@@ -271,6 +1666,18 @@ func validateExternalMetricResult(metricRequest externalmetrics.AzureExternalMet
 		t.Errorf("metricRequest Aggregation = %v, want %v", metricRequest.Aggregation, externalMetricInfo.Spec.MetricConfig.Aggregation)
 	}
 
+	if metricRequest.SplitDimension != externalMetricInfo.Spec.MetricConfig.SplitDimension {
+		t.Errorf("metricRequest SplitDimension = %v, want %v", metricRequest.SplitDimension, externalMetricInfo.Spec.MetricConfig.SplitDimension)
+	}
+
+	if metricRequest.Top != externalMetricInfo.Spec.MetricConfig.Top {
+		t.Errorf("metricRequest Top = %v, want %v", metricRequest.Top, externalMetricInfo.Spec.MetricConfig.Top)
+	}
+
+	if metricRequest.UseUnits != externalMetricInfo.Spec.MetricConfig.UseUnits {
+		t.Errorf("metricRequest UseUnits = %v, want %v", metricRequest.UseUnits, externalMetricInfo.Spec.MetricConfig.UseUnits)
+	}
+
 	// Azure Config
 	if metricRequest.ResourceGroup != externalMetricInfo.Spec.AzureConfig.ResourceGroup {
 		t.Errorf("metricRequest ResourceGroup = %v, want %v", metricRequest.ResourceGroup, externalMetricInfo.Spec.AzureConfig.ResourceGroup)
@@ -292,6 +1699,10 @@ func validateExternalMetricResult(metricRequest externalmetrics.AzureExternalMet
 		t.Errorf("metricRequest SubscriptionID = %v, want %v", metricRequest.SubscriptionID, externalMetricInfo.Spec.AzureConfig.SubscriptionID)
 	}
 
+	if !reflect.DeepEqual(metricRequest.MessageCounts, externalMetricInfo.Spec.AzureConfig.ServiceBusMessageCounts) {
+		t.Errorf("metricRequest MessageCounts = %v, want %v", metricRequest.MessageCounts, externalMetricInfo.Spec.AzureConfig.ServiceBusMessageCounts)
+	}
+
 }
 
 func validateCustomMetricResult(metricRequest custommetrics.MetricRequest, customMetricInfo *api.CustomMetric, t *testing.T) {
@@ -316,11 +1727,15 @@ func newFullExternalMetric(name string) *api.ExternalMetric {
 				ResourceName:              "rn",
 				ResourceProviderNamespace: "Resource.NameSpace",
 				ResourceType:              "rt",
+				ServiceBusMessageCounts:   []string{"active", "scheduled"},
 			},
 			MetricConfig: api.ExternalMetricConfig{
-				Aggregation: "Total",
-				MetricName:  "Name",
-				Filter:      "EntityName eq 'externalq'",
+				Aggregation:    "Total",
+				MetricName:     "Name",
+				Filter:         "EntityName eq 'externalq'",
+				SplitDimension: "Region",
+				Top:            5,
+				UseUnits:       true,
 			},
 		},
 	}
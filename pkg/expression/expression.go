@@ -0,0 +1,545 @@
+// Package expression evaluates the value expressions of ExternalMetrics, written in a small
+// arithmetic language of its own:
+//
+//	expression = or [ "?" expression ":" expression ]
+//	or         = and { "||" and }
+//	and        = comparison { "&&" comparison }
+//	comparison = sum { ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) sum }
+//	sum        = product { ( "+" | "-" ) product }
+//	product    = unary { ( "*" | "/" | "%" ) unary }
+//	unary      = ( "-" | "!" ) unary | primary
+//	primary    = number | "true" | "false" | variable | function "(" [ expression { "," expression } ] ")" | "(" expression ")"
+//
+// Every number is a float64, so 2 and 2.0 are the same value and % is the floating point remainder.
+// The functions are min and max of two or more arguments, and abs, floor and ceil of one.  There
+// are no loops, strings or access to anything but the variables, so evaluation always terminates and
+// can't affect the adapter.
+package expression
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MaxLength is the longest expression accepted
+const MaxLength = 1024
+
+// Expression is a parsed expression
+type Expression struct {
+	source string
+	root   node
+}
+
+// Parse parses the expression
+func Parse(source string) (*Expression, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("the expression is empty")
+	}
+	if len(source) > MaxLength {
+		return nil, fmt.Errorf("the expression is longer than %d characters", MaxLength)
+	}
+
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	root, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected '%s' at %d", p.peek().text, p.peek().pos)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+// String returns the source of the expression
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Variables returns the names of the variables the expression reads
+func (e *Expression) Variables() []string {
+	seen := map[string]bool{}
+	names := []string{}
+	e.root.walk(func(n node) {
+		if v, ok := n.(variable); ok && !seen[string(v)] {
+			seen[string(v)] = true
+			names = append(names, string(v))
+		}
+	})
+	return names
+}
+
+// Eval evaluates the expression with the variables.  The expression must evaluate to a number.
+func (e *Expression) Eval(variables map[string]float64) (float64, error) {
+	result, err := e.root.eval(variables)
+	if err != nil {
+		return 0, err
+	}
+	if result.isBool {
+		return 0, fmt.Errorf("the expression evaluates to a bool, not a number")
+	}
+	if math.IsNaN(result.number) || math.IsInf(result.number, 0) {
+		return 0, fmt.Errorf("the expression evaluates to %v", result.number)
+	}
+	return result.number, nil
+}
+
+type value struct {
+	number  float64
+	boolean bool
+	isBool  bool
+}
+
+func (v value) asNumber(op string) (float64, error) {
+	if v.isBool {
+		return 0, fmt.Errorf("'%s' requires numbers, not a bool", op)
+	}
+	return v.number, nil
+}
+
+func (v value) asBool(op string) (bool, error) {
+	if !v.isBool {
+		return false, fmt.Errorf("'%s' requires bools, not a number", op)
+	}
+	return v.boolean, nil
+}
+
+type node interface {
+	eval(variables map[string]float64) (value, error)
+	walk(visit func(node))
+}
+
+type number float64
+
+func (n number) eval(map[string]float64) (value, error) {
+	return value{number: float64(n)}, nil
+}
+
+func (n number) walk(visit func(node)) {
+	visit(n)
+}
+
+type boolean bool
+
+func (b boolean) eval(map[string]float64) (value, error) {
+	return value{boolean: bool(b), isBool: true}, nil
+}
+
+func (b boolean) walk(visit func(node)) {
+	visit(b)
+}
+
+type variable string
+
+func (v variable) eval(variables map[string]float64) (value, error) {
+	n, ok := variables[string(v)]
+	if !ok {
+		return value{}, fmt.Errorf("undeclared variable '%s'", string(v))
+	}
+	return value{number: n}, nil
+}
+
+func (v variable) walk(visit func(node)) {
+	visit(v)
+}
+
+type unary struct {
+	op      string
+	operand node
+}
+
+func (u unary) eval(variables map[string]float64) (value, error) {
+	operand, err := u.operand.eval(variables)
+	if err != nil {
+		return value{}, err
+	}
+	if u.op == "!" {
+		b, err := operand.asBool(u.op)
+		return value{boolean: !b, isBool: true}, err
+	}
+	n, err := operand.asNumber(u.op)
+	return value{number: -n}, err
+}
+
+func (u unary) walk(visit func(node)) {
+	visit(u)
+	u.operand.walk(visit)
+}
+
+type binary struct {
+	op          string
+	left, right node
+}
+
+func (b binary) eval(variables map[string]float64) (value, error) {
+	left, err := b.left.eval(variables)
+	if err != nil {
+		return value{}, err
+	}
+
+	// && and || short circuit, so the right operand is only evaluated when it decides the result
+	if b.op == "&&" || b.op == "||" {
+		l, err := left.asBool(b.op)
+		if err != nil {
+			return value{}, err
+		}
+		if (b.op == "&&" && !l) || (b.op == "||" && l) {
+			return value{boolean: l, isBool: true}, nil
+		}
+		right, err := b.right.eval(variables)
+		if err != nil {
+			return value{}, err
+		}
+		r, err := right.asBool(b.op)
+		return value{boolean: r, isBool: true}, err
+	}
+
+	right, err := b.right.eval(variables)
+	if err != nil {
+		return value{}, err
+	}
+	if b.op == "==" || b.op == "!=" {
+		if left.isBool != right.isBool {
+			return value{}, fmt.Errorf("'%s' requires operands of the same type", b.op)
+		}
+		equal := left == right
+		return value{boolean: equal == (b.op == "=="), isBool: true}, nil
+	}
+
+	l, err := left.asNumber(b.op)
+	if err != nil {
+		return value{}, err
+	}
+	r, err := right.asNumber(b.op)
+	if err != nil {
+		return value{}, err
+	}
+	switch b.op {
+	case "+":
+		return value{number: l + r}, nil
+	case "-":
+		return value{number: l - r}, nil
+	case "*":
+		return value{number: l * r}, nil
+	case "/":
+		if r == 0 {
+			return value{}, fmt.Errorf("division by zero")
+		}
+		return value{number: l / r}, nil
+	case "%":
+		if r == 0 {
+			return value{}, fmt.Errorf("modulus by zero")
+		}
+		return value{number: math.Mod(l, r)}, nil
+	case "<":
+		return value{boolean: l < r, isBool: true}, nil
+	case "<=":
+		return value{boolean: l <= r, isBool: true}, nil
+	case ">":
+		return value{boolean: l > r, isBool: true}, nil
+	default:
+		return value{boolean: l >= r, isBool: true}, nil
+	}
+}
+
+func (b binary) walk(visit func(node)) {
+	visit(b)
+	b.left.walk(visit)
+	b.right.walk(visit)
+}
+
+type conditional struct {
+	condition, then, otherwise node
+}
+
+func (c conditional) eval(variables map[string]float64) (value, error) {
+	condition, err := c.condition.eval(variables)
+	if err != nil {
+		return value{}, err
+	}
+	chosen, err := condition.asBool("?:")
+	if err != nil {
+		return value{}, err
+	}
+	if chosen {
+		return c.then.eval(variables)
+	}
+	return c.otherwise.eval(variables)
+}
+
+func (c conditional) walk(visit func(node)) {
+	visit(c)
+	c.condition.walk(visit)
+	c.then.walk(visit)
+	c.otherwise.walk(visit)
+}
+
+// functions take and return numbers
+var functions = map[string]struct {
+	minArgs, maxArgs int
+	apply            func(args []float64) float64
+}{
+	"min": {2, -1, func(args []float64) float64 {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Min(result, arg)
+		}
+		return result
+	}},
+	"max": {2, -1, func(args []float64) float64 {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Max(result, arg)
+		}
+		return result
+	}},
+	"abs":   {1, 1, func(args []float64) float64 { return math.Abs(args[0]) }},
+	"floor": {1, 1, func(args []float64) float64 { return math.Floor(args[0]) }},
+	"ceil":  {1, 1, func(args []float64) float64 { return math.Ceil(args[0]) }},
+}
+
+type call struct {
+	function string
+	args     []node
+}
+
+func (c call) eval(variables map[string]float64) (value, error) {
+	args := make([]float64, len(c.args))
+	for i, arg := range c.args {
+		result, err := arg.eval(variables)
+		if err != nil {
+			return value{}, err
+		}
+		if args[i], err = result.asNumber(c.function); err != nil {
+			return value{}, err
+		}
+	}
+	return value{number: functions[c.function].apply(args)}, nil
+}
+
+func (c call) walk(visit func(node)) {
+	visit(c)
+	for _, arg := range c.args {
+		arg.walk(visit)
+	}
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators are matched longest first
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "+", "-", "*", "/", "%", "<", ">", "!", "?", ":", "(", ")", ","}
+
+func lex(source string) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(source); {
+		c := rune(source[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			start := i
+			for i < len(source) && (unicode.IsDigit(rune(source[i])) || source[i] == '.' || source[i] == 'e' || source[i] == 'E' ||
+				((source[i] == '+' || source[i] == '-') && (source[i-1] == 'e' || source[i-1] == 'E'))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: source[start:i], pos: start})
+		case unicode.IsLetter(c) || c == '_':
+			start := i
+			for i < len(source) && (unicode.IsLetter(rune(source[i])) || unicode.IsDigit(rune(source[i])) || source[i] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: source[start:i], pos: start})
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(source[i:], op) {
+					tokens = append(tokens, token{kind: tokenOperator, text: op, pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, fmt.Errorf("unexpected '%c' at %d", c, i)
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, text: "end of expression", pos: len(source)}), nil
+}
+
+// parser is a recursive descent parser of the grammar of the package
+type parser struct {
+	tokens []token
+	next   int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) accept(ops ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			p.next++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		return fmt.Errorf("expected '%s' at %d, found '%s'", op, p.peek().pos, p.peek().text)
+	}
+	return nil
+}
+
+func (p *parser) ternary() (node, error) {
+	condition, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("?"); !ok {
+		return condition, nil
+	}
+	then, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	otherwise, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return conditional{condition: condition, then: then, otherwise: otherwise}, nil
+}
+
+// precedence lists the binary operators from the loosest binding
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *parser) binary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(precedence[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if op, ok := p.accept("-", "!"); ok {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unary{op: op, operand: operand}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenNumber:
+		p.next++
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number '%s' at %d", t.text, t.pos)
+		}
+		return number(n), nil
+	case tokenIdent:
+		p.next++
+		switch t.text {
+		case "true":
+			return boolean(true), nil
+		case "false":
+			return boolean(false), nil
+		}
+		if _, ok := p.accept("("); !ok {
+			return variable(t.text), nil
+		}
+		return p.call(t)
+	}
+
+	if _, ok := p.accept("("); ok {
+		inner, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	}
+	return nil, fmt.Errorf("unexpected '%s' at %d", t.text, t.pos)
+}
+
+func (p *parser) call(name token) (node, error) {
+	function, ok := functions[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown function '%s' at %d", name.text, name.pos)
+	}
+
+	args := []node{}
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.ternary()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(args) < function.minArgs || (function.maxArgs >= 0 && len(args) > function.maxArgs) {
+		return nil, fmt.Errorf("wrong number of arguments to '%s' at %d", name.text, name.pos)
+	}
+	return call{function: name.text, args: args}, nil
+}
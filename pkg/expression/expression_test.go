@@ -0,0 +1,100 @@
+package expression
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEval(t *testing.T) {
+	variables := map[string]float64{"orders": 120, "payments": 30, "replicas": 4, "hour": 9}
+	tests := []struct {
+		expression string
+		want       float64
+	}{
+		{"orders", 120},
+		{"orders + payments * 2", 180},
+		{"(orders + payments) / replicas", 37.5},
+		{"orders % 50", 20},
+		{"-payments + 1e2", 70},
+		{"max(orders, payments, 200)", 200},
+		{"min(orders, payments)", 30},
+		{"ceil(orders / 7)", 18},
+		{"floor(abs(-2.5))", 2},
+		{"hour >= 8 && hour < 18 ? orders : 0", 120},
+		{"hour < 8 || hour >= 18 ? orders : 0", 0},
+		{"!(orders > payments) ? 1 : 2", 2},
+		{"orders == 120 ? 1 : orders > 100 ? 2 : 3", 1},
+		{"true != false ? .5 : 0", 0.5},
+	}
+
+	for _, tt := range tests {
+		expression, err := Parse(tt.expression)
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v, want nil", tt.expression, err)
+		}
+		got, err := expression.Eval(variables)
+		if err != nil {
+			t.Errorf("Eval(%s) error = %v, want nil", tt.expression, err)
+		}
+		if got != tt.want {
+			t.Errorf("Eval(%s) = %v, want %v", tt.expression, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalidGetError(t *testing.T) {
+	tests := []string{
+		"",
+		"orders +",
+		"(orders",
+		"orders payments",
+		"orders ? 1",
+		"exec(orders)",
+		"max(orders)",
+		"orders = 1",
+		"orders.size",
+		"'orders'",
+		strings.Repeat("1+", MaxLength),
+	}
+
+	for _, source := range tests {
+		if _, err := Parse(source); err == nil {
+			t.Errorf("Parse(%s) got nil, want error", source)
+		}
+	}
+}
+
+func TestEvalInvalidGetError(t *testing.T) {
+	variables := map[string]float64{"orders": 120, "empty": 0}
+	tests := []string{
+		"unknown",
+		"orders / empty",
+		"orders % empty",
+		"orders > 1",
+		"orders && true",
+		"orders ? 1 : 0",
+		"true + 1",
+		"true == 1",
+		"max(true, 1)",
+	}
+
+	for _, source := range tests {
+		expression, err := Parse(source)
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v, want nil", source, err)
+		}
+		if _, err := expression.Eval(variables); err == nil {
+			t.Errorf("Eval(%s) got nil, want error", source)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	expression, _ := Parse("max(orders, payments) / replicas + orders")
+
+	want := []string{"orders", "payments", "replicas"}
+	if got := expression.Variables(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Variables() = %v, want %v", got, want)
+	}
+}
@@ -2,32 +2,56 @@ package metriccache
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/custommetrics"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
 	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 // MetricCache holds the loaded metric request info in the system
 type MetricCache struct {
 	metricMutext   sync.RWMutex
 	metricRequests map[string]interface{}
+	// removed holds when each metric request was removed, until it is set again
+	removed map[string]time.Time
+	// changed holds when each metric request was last set to a different request
+	changed map[string]time.Time
+	// pushed holds the latest value pushed to each metric request, until it is removed
+	pushed map[string]PushedValue
+}
+
+// PushedValue is a value pushed to the adapter for a metric, such as by Azure Event Grid, and when
+// it was pushed
+type PushedValue struct {
+	Value     float64
+	Timestamp time.Time
 }
 
 // NewMetricCache creates the cache
 func NewMetricCache() *MetricCache {
 	return &MetricCache{
 		metricRequests: make(map[string]interface{}),
+		removed:        make(map[string]time.Time),
+		changed:        make(map[string]time.Time),
+		pushed:         make(map[string]PushedValue),
 	}
 }
 
-// Update sets a metric request in the cache
+// Update sets a metric request in the cache, recording when it changed
 func (mc *MetricCache) Update(key string, metricRequest interface{}) {
 	mc.metricMutext.Lock()
 	defer mc.metricMutext.Unlock()
 
+	if current, exists := mc.metricRequests[key]; !exists || !reflect.DeepEqual(current, metricRequest) {
+		mc.changed[key] = time.Now()
+	}
 	mc.metricRequests[key] = metricRequest
+	delete(mc.removed, key)
 }
 
 // GetAzureExternalMetricRequest retrieves a metric request from the cache
@@ -45,6 +69,43 @@ func (mc *MetricCache) GetAzureExternalMetricRequest(namepace, name string) (ext
 	return metricRequest.(externalmetrics.AzureExternalMetricRequest), true
 }
 
+// GetExternalMetricVariant returns the name of the external metric request the named metric is a
+// variant of, and the variant.  A metric that is itself a request isn't a variant.
+func (mc *MetricCache) GetExternalMetricVariant(namespace, name string) (string, externalmetrics.VariantDefinition, bool) {
+	mc.metricMutext.RLock()
+	defer mc.metricMutext.RUnlock()
+
+	if _, exists := mc.metricRequests[externalMetricKey(namespace, name)]; exists {
+		return "", externalmetrics.VariantDefinition{}, false
+	}
+	// variant suffixes start with -, so the request is named by a prefix of the name ending before one
+	for i := strings.Index(name, "-"); i > 0; i = nextIndex(name, "-", i) {
+		metricRequest, exists := mc.metricRequests[externalMetricKey(namespace, name[:i])]
+		if !exists {
+			continue
+		}
+		request, ok := metricRequest.(externalmetrics.AzureExternalMetricRequest)
+		if !ok {
+			continue
+		}
+		for _, variant := range request.Variants {
+			if variant.Suffix == name[i:] {
+				return name[:i], variant, true
+			}
+		}
+	}
+	return "", externalmetrics.VariantDefinition{}, false
+}
+
+// nextIndex returns the index of the next occurrence of sep in s after i, or -1
+func nextIndex(s string, sep string, i int) int {
+	next := strings.Index(s[i+1:], sep)
+	if next < 0 {
+		return -1
+	}
+	return i + 1 + next
+}
+
 // GetAppInsightsRequest retrieves a metric request from the cache
 func (mc *MetricCache) GetAppInsightsRequest(namespace, name string) (custommetrics.MetricRequest, bool) {
 	mc.metricMutext.RLock()
@@ -60,12 +121,90 @@ func (mc *MetricCache) GetAppInsightsRequest(namespace, name string) (custommetr
 	return metricRequest.(custommetrics.MetricRequest), true
 }
 
+// ListAzureExternalMetricRequests returns the external metric requests in the cache by namespace and name
+func (mc *MetricCache) ListAzureExternalMetricRequests() map[types.NamespacedName]externalmetrics.AzureExternalMetricRequest {
+	mc.metricMutext.RLock()
+	defer mc.metricMutext.RUnlock()
+
+	requests := map[types.NamespacedName]externalmetrics.AzureExternalMetricRequest{}
+	for key, metricRequest := range mc.metricRequests {
+		if request, ok := metricRequest.(externalmetrics.AzureExternalMetricRequest); ok {
+			requests[namespacedName(key)] = request
+		}
+	}
+	return requests
+}
+
+// ListAppInsightsRequests returns the custom metric requests in the cache by namespace and name
+func (mc *MetricCache) ListAppInsightsRequests() map[types.NamespacedName]custommetrics.MetricRequest {
+	mc.metricMutext.RLock()
+	defer mc.metricMutext.RUnlock()
+
+	requests := map[types.NamespacedName]custommetrics.MetricRequest{}
+	for key, metricRequest := range mc.metricRequests {
+		if request, ok := metricRequest.(custommetrics.MetricRequest); ok {
+			requests[namespacedName(key)] = request
+		}
+	}
+	return requests
+}
+
 // Remove retrieves a metric request from the cache
 func (mc *MetricCache) Remove(key string) {
 	mc.metricMutext.Lock()
 	defer mc.metricMutext.Unlock()
 
+	if _, exists := mc.metricRequests[key]; exists {
+		mc.removed[key] = time.Now()
+	}
 	delete(mc.metricRequests, key)
+	delete(mc.changed, key)
+	delete(mc.pushed, key)
+}
+
+// UpdatePushedValue sets the value pushed to an external metric request, unless a later value was
+// already pushed.  It returns false when the request is not in the cache.
+func (mc *MetricCache) UpdatePushedValue(namespace, name string, value PushedValue) bool {
+	mc.metricMutext.Lock()
+	defer mc.metricMutext.Unlock()
+
+	key := externalMetricKey(namespace, name)
+	if _, exists := mc.metricRequests[key]; !exists {
+		return false
+	}
+	if pushed, exists := mc.pushed[key]; !exists || !value.Timestamp.Before(pushed.Timestamp) {
+		mc.pushed[key] = value
+	}
+	return true
+}
+
+// GetPushedValue retrieves the latest value pushed to an external metric request
+func (mc *MetricCache) GetPushedValue(namespace, name string) (PushedValue, bool) {
+	mc.metricMutext.RLock()
+	defer mc.metricMutext.RUnlock()
+
+	value, exists := mc.pushed[externalMetricKey(namespace, name)]
+	return value, exists
+}
+
+// ExternalMetricRemovedAt returns when an external metric request was removed from the cache, if it
+// has not been set again since
+func (mc *MetricCache) ExternalMetricRemovedAt(namespace, name string) (time.Time, bool) {
+	mc.metricMutext.RLock()
+	defer mc.metricMutext.RUnlock()
+
+	removedAt, removed := mc.removed[externalMetricKey(namespace, name)]
+	return removedAt, removed
+}
+
+// ExternalMetricChangedAt returns when an external metric request was last set to a different
+// request, so data resolved for its previous request isn't served
+func (mc *MetricCache) ExternalMetricChangedAt(namespace, name string) (time.Time, bool) {
+	mc.metricMutext.RLock()
+	defer mc.metricMutext.RUnlock()
+
+	changedAt, changed := mc.changed[externalMetricKey(namespace, name)]
+	return changedAt, changed
 }
 
 func externalMetricKey(namespace string, name string) string {
@@ -75,3 +214,12 @@ func externalMetricKey(namespace string, name string) string {
 func customMetricKey(namespace string, name string) string {
 	return fmt.Sprintf("CustomMetric/%s/%s", namespace, name)
 }
+
+// namespacedName parses the namespace and name from a Kind/namespace/name key
+func namespacedName(key string) types.NamespacedName {
+	parts := strings.SplitN(key, "/", 3)
+	if len(parts) != 3 {
+		return types.NamespacedName{Name: key}
+	}
+	return types.NamespacedName{Namespace: parts[1], Name: parts[2]}
+}
@@ -0,0 +1,147 @@
+// Package plugin lets external metrics be served by MetricSource gRPC plugins, so new
+// backends can be added as sidecars of the adapter rather than built into it.
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/golang/glog"
+	"google.golang.org/grpc"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+	unixScheme     = "unix://"
+)
+
+// Config is the plugin configuration file format
+type Config struct {
+	Plugins []Source `json:"plugins"`
+}
+
+// Source is a plugin serving the MetricSource service
+type Source struct {
+	// Name is referenced by ExternalMetrics of type plugin
+	Name string `json:"name"`
+	// Address is a host:port or unix:///path/to/socket
+	Address string `json:"address"`
+	// Timeout for each call, in the go duration format. Defaults to 10s
+	Timeout string `json:"timeout,omitempty"`
+}
+
+type source struct {
+	address string
+	timeout time.Duration
+	client  MetricSourceClient
+}
+
+// Registry looks up plugins by name and holds a connection to each of them
+type Registry struct {
+	mu      sync.Mutex
+	sources map[string]*source
+}
+
+// LoadRegistry reads the plugins from a yaml or json configuration file
+func LoadRegistry(configFile string) (*Registry, error) {
+	data, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read plugin config: %v", err)
+	}
+
+	config := Config{}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("unable to parse plugin config: %v", err)
+	}
+
+	return NewRegistry(config.Plugins)
+}
+
+// NewRegistry creates a registry of the plugins.  Connections are made when a plugin is first used.
+func NewRegistry(plugins []Source) (*Registry, error) {
+	r := &Registry{sources: map[string]*source{}}
+	for _, p := range plugins {
+		if p.Name == "" || p.Address == "" {
+			return nil, fmt.Errorf("plugins require a name and address")
+		}
+		if _, exists := r.sources[p.Name]; exists {
+			return nil, fmt.Errorf("plugin %s is configured more than once", p.Name)
+		}
+
+		timeout := defaultTimeout
+		if p.Timeout != "" {
+			var err error
+			if timeout, err = time.ParseDuration(p.Timeout); err != nil {
+				return nil, fmt.Errorf("invalid timeout '%s' for plugin %s", p.Timeout, p.Name)
+			}
+		}
+
+		glog.V(2).Infof("registered metric source plugin %s at %s", p.Name, p.Address)
+		r.sources[p.Name] = &source{address: p.Address, timeout: timeout}
+	}
+
+	return r, nil
+}
+
+// GetMetric asks the named plugin for the value of the metric
+func (r *Registry) GetMetric(name string, request *MetricRequest) (float64, error) {
+	s, err := r.source(name)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	response, err := s.client.GetMetric(ctx, request)
+	if err != nil {
+		return 0, fmt.Errorf("plugin %s failed: %v", name, err)
+	}
+
+	return response.GetValue(), nil
+}
+
+func (r *Registry) source(name string) (*source, error) {
+	if r == nil {
+		return nil, fmt.Errorf("no metric source plugins are configured")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.sources[name]
+	if !ok {
+		return nil, fmt.Errorf("metric source plugin %s is not configured", name)
+	}
+
+	if s.client == nil {
+		conn, err := dial(s.address)
+		if err != nil {
+			return nil, fmt.Errorf("unable to connect to plugin %s: %v", name, err)
+		}
+		s.client = NewMetricSourceClient(conn)
+	}
+
+	return s, nil
+}
+
+// dial does not wait for the connection so a plugin that is still starting does not block requests
+func dial(address string) (*grpc.ClientConn, error) {
+	// plugins are sidecars reached over a local socket or the pod network namespace
+	options := []grpc.DialOption{grpc.WithInsecure()}
+
+	if strings.HasPrefix(address, unixScheme) {
+		address = strings.TrimPrefix(address, unixScheme)
+		options = append(options, grpc.WithDialer(func(path string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", path, timeout)
+		}))
+	}
+
+	return grpc.Dial(address, options...)
+}
@@ -0,0 +1,147 @@
+package plugin
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+func TestRegistryCallsPlugin(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	fake := &fakeMetricSource{value: 42.5}
+	stop := serve(listener, fake)
+	defer stop()
+
+	registry, err := NewRegistry([]Source{{Name: "queue", Address: listener.Addr().String()}})
+	if err != nil {
+		t.Fatalf("NewRegistry() error = %v, want nil", err)
+	}
+
+	value, err := registry.GetMetric("queue", &MetricRequest{
+		MetricName: "depth",
+		Parameters: map[string]string{"queue": "orders"},
+	})
+
+	if err != nil {
+		t.Fatalf("GetMetric() error = %v, want nil", err)
+	}
+
+	if value != 42.5 {
+		t.Errorf("GetMetric() = %v, want %v", value, 42.5)
+	}
+
+	if fake.request.MetricName != "depth" || fake.request.Parameters["queue"] != "orders" {
+		t.Errorf("plugin request = %v, want metric depth with queue parameter", fake.request)
+	}
+}
+
+func TestRegistryCallsPluginOnUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugin")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "plugin.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("unable to listen: %v", err)
+	}
+	stop := serve(listener, &fakeMetricSource{value: 7})
+	defer stop()
+
+	registry, _ := NewRegistry([]Source{{Name: "queue", Address: "unix://" + socket}})
+	value, err := registry.GetMetric("queue", &MetricRequest{MetricName: "depth"})
+
+	if err != nil {
+		t.Fatalf("GetMetric() error = %v, want nil", err)
+	}
+
+	if value != 7 {
+		t.Errorf("GetMetric() = %v, want %v", value, 7)
+	}
+}
+
+func TestUnknownPluginGetError(t *testing.T) {
+	registry, _ := NewRegistry([]Source{{Name: "queue", Address: "127.0.0.1:1"}})
+
+	_, err := registry.GetMetric("other", &MetricRequest{})
+
+	if err == nil {
+		t.Errorf("GetMetric() error = %v, want error", err)
+	}
+}
+
+func TestNilRegistryGetError(t *testing.T) {
+	var registry *Registry
+
+	_, err := registry.GetMetric("queue", &MetricRequest{})
+
+	if err == nil {
+		t.Errorf("GetMetric() error = %v, want error", err)
+	}
+}
+
+func TestInvalidConfigGetError(t *testing.T) {
+	tests := []struct {
+		name    string
+		plugins []Source
+	}{
+		{name: "missing address", plugins: []Source{{Name: "queue"}}},
+		{name: "duplicate name", plugins: []Source{{Name: "queue", Address: "a:1"}, {Name: "queue", Address: "b:1"}}},
+		{name: "invalid timeout", plugins: []Source{{Name: "queue", Address: "a:1", Timeout: "soon"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRegistry(tt.plugins)
+			if err == nil {
+				t.Errorf("NewRegistry() error = %v, want error", err)
+			}
+		})
+	}
+}
+
+func TestLoadRegistryFromYaml(t *testing.T) {
+	file, err := ioutil.TempFile("", "plugins")
+	if err != nil {
+		t.Fatalf("unable to create temp file: %v", err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("plugins:\n- name: queue\n  address: localhost:9000\n  timeout: 2s\n")
+	file.Close()
+
+	registry, err := LoadRegistry(file.Name())
+
+	if err != nil {
+		t.Fatalf("LoadRegistry() error = %v, want nil", err)
+	}
+
+	if s := registry.sources["queue"]; s == nil || s.address != "localhost:9000" || s.timeout.Seconds() != 2 {
+		t.Errorf("LoadRegistry() source = %v, want queue at localhost:9000 with 2s timeout", s)
+	}
+}
+
+func serve(listener net.Listener, source MetricSourceServer) func() {
+	server := grpc.NewServer()
+	RegisterMetricSourceServer(server, source)
+	go server.Serve(listener)
+	return server.Stop
+}
+
+type fakeMetricSource struct {
+	value   float64
+	request *MetricRequest
+}
+
+func (f *fakeMetricSource) GetMetric(ctx context.Context, request *MetricRequest) (*MetricResponse, error) {
+	f.request = request
+	return &MetricResponse{Value: f.value}, nil
+}
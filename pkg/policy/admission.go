@@ -0,0 +1,175 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/controller"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/variables"
+	"github.com/golang/glog"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+)
+
+// AdmissionPath is the path the validating webhook is served on
+const AdmissionPath = "/admission/externalmetrics"
+
+// AdmissionHandler is a validating admission webhook that rejects ExternalMetrics and
+// ExternalMetricGroups which target an Azure scope that is not permitted for their namespace
+type AdmissionHandler struct {
+	enforcer              *Enforcer
+	defaultSubscriptionID string
+	variables             variables.Variables
+	kubeClient            dynamic.Interface
+}
+
+// NewAdmissionHandler creates the validating webhook handler.  The variables, and those of the
+// ConfigMaps read with the client, are expanded in the specs before their scope is checked.
+func NewAdmissionHandler(enforcer *Enforcer, defaultSubscriptionID string, specVariables variables.Variables, kubeClient dynamic.Interface) *AdmissionHandler {
+	return &AdmissionHandler{
+		enforcer:              enforcer,
+		defaultSubscriptionID: defaultSubscriptionID,
+		variables:             specVariables,
+		kubeClient:            kubeClient,
+	}
+}
+
+// ScopeForExternalMetric builds the Azure scope an ExternalMetric will query
+func ScopeForExternalMetric(externalMetric *api.ExternalMetric, defaultSubscriptionID string) Scope {
+	request := externalmetrics.AzureExternalMetricRequest{
+		Type:                      externalMetric.Spec.Type,
+		SubscriptionID:            externalMetric.Spec.AzureConfig.SubscriptionID,
+		ResourceGroup:             externalMetric.Spec.AzureConfig.ResourceGroup,
+		ResourceProviderNamespace: externalMetric.Spec.AzureConfig.ResourceProviderNamespace,
+		ResourceType:              externalMetric.Spec.AzureConfig.ResourceType,
+	}
+
+	if request.SubscriptionID == "" {
+		request.SubscriptionID = defaultSubscriptionID
+	}
+
+	return ScopeForRequest(request)
+}
+
+// scopesForExternalMetric builds the scope for each listed subscription or resource of an
+// ExternalMetric.  Accessible subscriptions and resources selected by tags are checked when the
+// metric is requested instead.
+func scopesForExternalMetric(externalMetric *api.ExternalMetric, defaultSubscriptionID string) []Scope {
+	if resources := externalMetric.Spec.AzureConfig.Resources; len(resources) > 0 {
+		scopes := []Scope{}
+		for _, resource := range resources {
+			listed := externalMetric.DeepCopy()
+			listed.Spec.AzureConfig.ResourceGroup = resource.ResourceGroup
+			scopes = append(scopes, ScopeForExternalMetric(listed, defaultSubscriptionID))
+		}
+		return scopes
+	}
+
+	subscriptions := externalMetric.Spec.AzureConfig.Subscriptions
+	if len(subscriptions) == 0 {
+		return []Scope{ScopeForExternalMetric(externalMetric, defaultSubscriptionID)}
+	}
+
+	scopes := []Scope{}
+	for _, subscriptionID := range subscriptions {
+		if subscriptionID == externalmetrics.AllSubscriptions {
+			continue
+		}
+
+		listed := externalMetric.DeepCopy()
+		listed.Spec.AzureConfig.SubscriptionID = subscriptionID
+		scopes = append(scopes, ScopeForExternalMetric(listed, defaultSubscriptionID))
+	}
+	return scopes
+}
+
+func (h *AdmissionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to read request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	review := admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(body, &review); err != nil || review.Request == nil {
+		http.Error(w, "expected an AdmissionReview request", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = h.review(review.Request)
+	review.Response.UID = review.Request.UID
+	review.Request = nil
+
+	resp, err := json.Marshal(review)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to encode response: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(resp)
+}
+
+func (h *AdmissionHandler) review(request *admissionv1beta1.AdmissionRequest) *admissionv1beta1.AdmissionResponse {
+	// the specs and the names of their metrics
+	specs, names := []api.ExternalMetricSpec{}, []string{}
+	switch request.Kind.Kind {
+	case "ExternalMetric":
+		externalMetric := api.ExternalMetric{}
+		if err := json.Unmarshal(request.Object.Raw, &externalMetric); err != nil {
+			return deny(fmt.Sprintf("unable to decode ExternalMetric: %v", err))
+		}
+		specs, names = append(specs, externalMetric.Spec), append(names, request.Name)
+	case "ExternalMetricGroup":
+		group := api.ExternalMetricGroup{}
+		if err := json.Unmarshal(request.Object.Raw, &group); err != nil {
+			return deny(fmt.Sprintf("unable to decode ExternalMetricGroup: %v", err))
+		}
+		for _, member := range group.Spec.Metrics {
+			spec, err := controller.GroupMemberSpec(group.Spec.Template, member)
+			if err != nil {
+				return deny(err.Error())
+			}
+			specs, names = append(specs, spec), append(names, member.Name)
+		}
+	default:
+		return &admissionv1beta1.AdmissionResponse{Allowed: true}
+	}
+
+	for i, spec := range specs {
+		values, err := variables.ConfigMapValues(h.kubeClient, request.Namespace, spec.VariablesConfigMap)
+		if err != nil {
+			return deny(fmt.Sprintf("unable to read the variables of the spec: %v", err))
+		}
+		spec, err := h.variables.ForMetric(request.Namespace, names[i], values).ExpandSpec(spec)
+		if err != nil {
+			return deny(err.Error())
+		}
+
+		externalMetric := api.ExternalMetric{Spec: spec}
+		for _, scope := range scopesForExternalMetric(&externalMetric, h.defaultSubscriptionID) {
+			if err := h.enforcer.Authorize(request.Namespace, scope); err != nil {
+				glog.V(2).Infof("rejecting %s '%s' in namespace '%s': %v", request.Kind.Kind, request.Name, request.Namespace, err)
+				return deny(err.Error())
+			}
+		}
+	}
+
+	return &admissionv1beta1.AdmissionResponse{Allowed: true}
+}
+
+func deny(message string) *admissionv1beta1.AdmissionResponse {
+	return &admissionv1beta1.AdmissionResponse{
+		Allowed: false,
+		Result: &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonForbidden,
+			Message: message,
+		},
+	}
+}
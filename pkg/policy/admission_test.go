@@ -0,0 +1,227 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/scheme"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/variables"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "k8s.io/client-go/dynamic/fake"
+)
+
+func TestAdmissionRejectsExternalMetricOutsidePolicy(t *testing.T) {
+	enforcer := newEnforcer(newPolicy("restricted", []string{"team-a"}, []string{"1234"}))
+	handler := NewAdmissionHandler(enforcer, "9876", nil, nil)
+
+	response := sendReview(t, handler, "team-a", newExternalMetric(""))
+
+	if response.Allowed {
+		t.Errorf("response.Allowed = %v, want %v", response.Allowed, false)
+	}
+
+	if response.UID != "uid" {
+		t.Errorf("response.UID = %v, want %v", response.UID, "uid")
+	}
+}
+
+func TestAdmissionAllowsExternalMetricInsidePolicy(t *testing.T) {
+	enforcer := newEnforcer(newPolicy("restricted", []string{"team-a"}, []string{"1234"}))
+	handler := NewAdmissionHandler(enforcer, "9876", nil, nil)
+
+	response := sendReview(t, handler, "team-a", newExternalMetric("1234"))
+
+	if !response.Allowed {
+		t.Errorf("response.Allowed = %v, want %v", response.Allowed, true)
+	}
+}
+
+func TestAdmissionRejectsListedSubscriptionOutsidePolicy(t *testing.T) {
+	enforcer := newEnforcer(newPolicy("restricted", []string{"team-a"}, []string{"1234"}))
+	handler := NewAdmissionHandler(enforcer, "1234", nil, nil)
+
+	externalMetric := newExternalMetric("")
+	externalMetric.Spec.AzureConfig.Subscriptions = []string{"1234", "9876"}
+	response := sendReview(t, handler, "team-a", externalMetric)
+
+	if response.Allowed {
+		t.Errorf("response.Allowed = %v, want %v", response.Allowed, false)
+	}
+}
+
+func TestAdmissionChecksListedResources(t *testing.T) {
+	policy := newPolicy("restricted", []string{"team-a"}, nil)
+	policy.Spec.ResourceGroups = []string{"orders-eu", "orders-us"}
+	handler := NewAdmissionHandler(newEnforcer(policy), "1234", nil, nil)
+
+	var tests = []struct {
+		name          string
+		resourceGroup string
+		want          bool
+	}{
+		{"inside policy", "orders-us", true},
+		{"outside policy", "billing", false},
+	}
+
+	for _, tt := range tests {
+		externalMetric := newExternalMetric("")
+		externalMetric.Spec.AzureConfig.Resources = []api.AzureResource{
+			{ResourceGroup: "orders-eu", ResourceName: "orders-eu"},
+			{ResourceGroup: tt.resourceGroup, ResourceName: "orders"},
+		}
+		if response := sendReview(t, handler, "team-a", externalMetric); response.Allowed != tt.want {
+			t.Errorf("%s: response.Allowed = %v, want %v", tt.name, response.Allowed, tt.want)
+		}
+	}
+}
+
+func TestAdmissionAllowsAllAccessibleSubscriptions(t *testing.T) {
+	enforcer := newEnforcer(newPolicy("restricted", []string{"team-a"}, []string{"1234"}))
+	handler := NewAdmissionHandler(enforcer, "9876", nil, nil)
+
+	externalMetric := newExternalMetric("")
+	externalMetric.Spec.AzureConfig.Subscriptions = []string{"*"}
+	response := sendReview(t, handler, "team-a", externalMetric)
+
+	if !response.Allowed {
+		t.Errorf("response.Allowed = %v, want %v", response.Allowed, true)
+	}
+}
+
+func TestAdmissionChecksExpandedVariables(t *testing.T) {
+	enforcer := newEnforcer(newPolicy("restricted", []string{"team-a"}, []string{"1234"}))
+
+	externalMetric := newExternalMetric("{{ .SubscriptionID }}")
+	var tests = []struct {
+		name      string
+		variables variables.Variables
+		want      bool
+	}{
+		{"inside policy", variables.Variables{variables.SubscriptionID: "1234"}, true},
+		{"outside policy", variables.Variables{variables.SubscriptionID: "9876"}, false},
+		{"unknown variable", nil, false},
+	}
+
+	for _, tt := range tests {
+		handler := NewAdmissionHandler(enforcer, "1234", tt.variables, nil)
+		if response := sendReview(t, handler, "team-a", externalMetric); response.Allowed != tt.want {
+			t.Errorf("%s: response.Allowed = %v, want %v", tt.name, response.Allowed, tt.want)
+		}
+	}
+}
+
+func TestAdmissionChecksVariablesOfConfigMap(t *testing.T) {
+	enforcer := newEnforcer(newPolicy("restricted", []string{"team-a"}, []string{"1234"}))
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"namespace": "team-a", "name": "environment"},
+		"data":       map[string]interface{}{"subscription": "9876"},
+	}}
+	handler := NewAdmissionHandler(enforcer, "1234", nil, k8sclient.NewSimpleDynamicClient(scheme.Scheme, configMap))
+
+	externalMetric := newExternalMetric("{{ .subscription }}")
+	externalMetric.Spec.VariablesConfigMap = "environment"
+	if response := sendReview(t, handler, "team-a", externalMetric); response.Allowed {
+		t.Errorf("response.Allowed = %v, want the subscription of the config map to be checked", response.Allowed)
+	}
+
+	externalMetric.Spec.VariablesConfigMap = "missing"
+	if response := sendReview(t, handler, "team-a", externalMetric); response.Allowed {
+		t.Errorf("response.Allowed = %v, want a missing config map to be rejected", response.Allowed)
+	}
+}
+
+func TestAdmissionChecksExternalMetricGroupMembers(t *testing.T) {
+	enforcer := newEnforcer(newPolicy("restricted", []string{"team-a"}, []string{"1234"}))
+	handler := NewAdmissionHandler(enforcer, "9876", nil, nil)
+
+	group := &api.ExternalMetricGroup{
+		TypeMeta:   metav1.TypeMeta{APIVersion: api.SchemeGroupVersion.String(), Kind: "ExternalMetricGroup"},
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Spec: api.ExternalMetricGroupSpec{
+			Template: newExternalMetric("1234").Spec,
+			Metrics: []api.ExternalMetricGroupMember{
+				{Name: "inside"},
+			},
+		},
+	}
+	if response := sendObjectReview(t, handler, "team-a", "ExternalMetricGroup", group); !response.Allowed {
+		t.Errorf("response.Allowed = %v, want %v", response.Allowed, true)
+	}
+
+	group.Spec.Metrics = append(group.Spec.Metrics, api.ExternalMetricGroupMember{
+		Name:               "outside",
+		ExternalMetricSpec: api.ExternalMetricSpec{AzureConfig: api.AzureConfig{SubscriptionID: "9876"}},
+	})
+	if response := sendObjectReview(t, handler, "team-a", "ExternalMetricGroup", group); response.Allowed {
+		t.Errorf("response.Allowed = %v, want %v", response.Allowed, false)
+	}
+}
+
+func TestAdmissionRejectsInvalidBody(t *testing.T) {
+	handler := NewAdmissionHandler(newEnforcer(), "", nil, nil)
+
+	req := httptest.NewRequest("POST", AdmissionPath, bytes.NewBufferString("not json"))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status code = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func sendReview(t *testing.T, handler http.Handler, namespace string, externalMetric *api.ExternalMetric) *admissionv1beta1.AdmissionResponse {
+	return sendObjectReview(t, handler, namespace, "ExternalMetric", externalMetric)
+}
+
+func sendObjectReview(t *testing.T, handler http.Handler, namespace string, kind string, object metav1.Object) *admissionv1beta1.AdmissionResponse {
+	raw, _ := json.Marshal(object)
+	review := admissionv1beta1.AdmissionReview{
+		Request: &admissionv1beta1.AdmissionRequest{
+			UID:       "uid",
+			Kind:      metav1.GroupVersionKind{Group: "azure.com", Version: "v1alpha2", Kind: kind},
+			Namespace: namespace,
+			Name:      object.GetName(),
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+	body, _ := json.Marshal(review)
+
+	req := httptest.NewRequest("POST", AdmissionPath, bytes.NewBuffer(body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status code = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	result := admissionv1beta1.AdmissionReview{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("unable to decode response: %v", err)
+	}
+
+	return result.Response
+}
+
+func newExternalMetric(subscriptionID string) *api.ExternalMetric {
+	return &api.ExternalMetric{
+		TypeMeta: metav1.TypeMeta{APIVersion: api.SchemeGroupVersion.String(), Kind: "ExternalMetric"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test",
+		},
+		Spec: api.ExternalMetricSpec{
+			AzureConfig: api.AzureConfig{
+				SubscriptionID: subscriptionID,
+				ResourceGroup:  "rg",
+			},
+		},
+	}
+}
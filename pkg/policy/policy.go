@@ -0,0 +1,223 @@
+// Package policy enforces the AdapterPolicy resources which scope the Azure resources
+// that metrics in a namespace are allowed to query
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	listers "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/listers/metrics/v1alpha2"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+const allNamespaces = "*"
+
+// Scope is the Azure scope a metric request will query
+type Scope struct {
+	SubscriptionID string
+	ResourceGroup  string
+	ResourceType   string
+}
+
+// ScopeForRequest builds the Azure scope that an external metric request targets
+func ScopeForRequest(request externalmetrics.AzureExternalMetricRequest) Scope {
+	scope := Scope{
+		SubscriptionID: request.SubscriptionID,
+		ResourceGroup:  request.ResourceGroup,
+	}
+
+	switch request.Type {
+	case externalmetrics.Schedule:
+		// schedules are computed by the adapter and do not query azure
+		return Scope{}
+	case externalmetrics.Heartbeat:
+		// heartbeats are served by the adapter and do not query azure
+		return Scope{}
+	case externalmetrics.SLOBurnRate:
+		// burn rates query the adapter's application insights app, like custom metrics
+		return Scope{}
+	case externalmetrics.Webhook:
+		// webhooks are restricted to the hosts the adapter allows
+		return Scope{}
+	case externalmetrics.Combined:
+		// each source of a combined metric is checked when it is queried
+		return Scope{}
+	case externalmetrics.Ratio:
+		// both resources of a ratio metric are checked when they are queried
+		return Scope{}
+	case externalmetrics.Seasonal:
+		// the current and past queries of a seasonal metric are checked when they are queried
+		return Scope{}
+	case externalmetrics.EventGrid:
+		// event grid values are pushed to the adapter, which does not query azure
+		return Scope{}
+	case externalmetrics.AzureDevOps:
+		// azure devops organizations are not azure resources
+		return Scope{}
+	case externalmetrics.ServiceBusSubscription, externalmetrics.ServiceBusQueue:
+		scope.ResourceType = "Microsoft.ServiceBus/namespaces"
+	case externalmetrics.EventHub:
+		scope.ResourceType = "Microsoft.EventHub/namespaces"
+	case externalmetrics.StorageQueueMessageAge, externalmetrics.StorageQueueLength, externalmetrics.FileShare, externalmetrics.BlobCount:
+		scope.ResourceType = "Microsoft.Storage/storageAccounts"
+	case externalmetrics.ActivityLog:
+		scope.ResourceType = "Microsoft.Insights/eventtypes"
+	case externalmetrics.Cost:
+		scope.ResourceType = "Microsoft.CostManagement/query"
+		if strings.ToLower(request.Cost.Metric) == externalmetrics.CostBudget {
+			scope.ResourceType = "Microsoft.Consumption/budgets"
+		}
+	case externalmetrics.Quota:
+		scope.ResourceType = "Microsoft.Compute/locations/usages"
+		if request.Quota.Provider != "" {
+			scope.ResourceType = request.Quota.Provider + "/locations/usages"
+		}
+	case externalmetrics.ContainerApp:
+		scope.ResourceType = "Microsoft.App/containerApps"
+		if request.ContainerApp.Environment != "" {
+			scope.ResourceType = "Microsoft.App/managedEnvironments"
+		}
+	case externalmetrics.LogicApp:
+		scope.ResourceType = "Microsoft.Logic/workflows"
+	case externalmetrics.LogAnalytics:
+		scope.ResourceType = "Microsoft.OperationalInsights/workspaces"
+	case externalmetrics.DataExplorer:
+		scope.ResourceType = "Microsoft.Kusto/clusters"
+	case externalmetrics.CosmosDB:
+		scope.ResourceType = "Microsoft.DocumentDB/databaseAccounts"
+	case externalmetrics.Prometheus:
+		scope.ResourceType = "Microsoft.Monitor/accounts"
+	case externalmetrics.IoTHub:
+		scope.ResourceType = "Microsoft.Devices/IotHubs"
+	case externalmetrics.Batch:
+		scope.ResourceType = "Microsoft.Batch/batchAccounts"
+	case externalmetrics.Redis:
+		scope.ResourceType = "Microsoft.Cache/Redis"
+	default:
+		if request.ResourceProviderNamespace != "" || request.ResourceType != "" {
+			scope.ResourceType = fmt.Sprintf("%s/%s", request.ResourceProviderNamespace, request.ResourceType)
+		}
+	}
+
+	return scope
+}
+
+func (s Scope) String() string {
+	return fmt.Sprintf("subscription '%s', resource group '%s', resource type '%s'", s.SubscriptionID, s.ResourceGroup, s.ResourceType)
+}
+
+// ViolationError is returned when a request falls outside the scopes permitted for the namespace
+type ViolationError struct {
+	err string
+}
+
+func (v ViolationError) Error() string {
+	return v.err
+}
+
+// IsViolationError checks if the error is because of a policy violation
+func IsViolationError(err error) bool {
+	if _, ok := err.(ViolationError); ok {
+		return true
+	}
+	return false
+}
+
+// NotSyncedError is returned when policies have not been loaded yet
+// and so no decision can be made
+type NotSyncedError struct{}
+
+func (NotSyncedError) Error() string {
+	return "adapter policies have not been synced yet"
+}
+
+// IsNotSyncedError checks if the error is because the policies are not loaded yet
+func IsNotSyncedError(err error) bool {
+	if _, ok := err.(NotSyncedError); ok {
+		return true
+	}
+	return false
+}
+
+// Enforcer checks metric requests against the AdapterPolicies in the cluster.
+// Namespaces that are not selected by any policy are unrestricted.
+type Enforcer struct {
+	policyLister listers.AdapterPolicyLister
+	policySynced cache.InformerSynced
+}
+
+// NewEnforcer creates an Enforcer backed by the policy lister
+func NewEnforcer(policyLister listers.AdapterPolicyLister, policySynced cache.InformerSynced) *Enforcer {
+	return &Enforcer{
+		policyLister: policyLister,
+		policySynced: policySynced,
+	}
+}
+
+// Authorize returns an error if metrics in the namespace are not permitted to query the scope
+func (e *Enforcer) Authorize(namespace string, scope Scope) error {
+	if e == nil || e.policyLister == nil {
+		return nil
+	}
+
+	if e.policySynced != nil && !e.policySynced() {
+		return NotSyncedError{}
+	}
+
+	// requests that do not query azure have no scope to restrict
+	if scope == (Scope{}) {
+		return nil
+	}
+
+	policies, err := e.policyLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	selected := 0
+	for _, policy := range policies {
+		if !contains(policy.Spec.Namespaces, namespace) && !contains(policy.Spec.Namespaces, allNamespaces) {
+			continue
+		}
+
+		selected++
+		if permits(policy, scope) {
+			glog.V(4).Infof("namespace '%s' permitted to query %s by policy '%s'", namespace, scope, policy.Name)
+			return nil
+		}
+	}
+
+	if selected == 0 {
+		return nil
+	}
+
+	return ViolationError{err: fmt.Sprintf("namespace '%s' is not permitted to query %s", namespace, scope)}
+}
+
+func permits(policy *api.AdapterPolicy, scope Scope) bool {
+	return allowed(policy.Spec.Subscriptions, scope.SubscriptionID) &&
+		allowed(policy.Spec.ResourceGroups, scope.ResourceGroup) &&
+		allowed(policy.Spec.ResourceTypes, scope.ResourceType)
+}
+
+func allowed(permitted []string, value string) bool {
+	if len(permitted) == 0 {
+		return true
+	}
+
+	return contains(permitted, value)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		// azure resource names are case insensitive
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+package policy
+
+import (
+	"testing"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/fake"
+	informers "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/informers/externalversions"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNamespaceWithoutPolicyIsUnrestricted(t *testing.T) {
+	enforcer := newEnforcer(newPolicy("restricted", []string{"team-a"}, []string{"1234"}))
+
+	err := enforcer.Authorize("team-b", Scope{SubscriptionID: "9876"})
+
+	if err != nil {
+		t.Errorf("authorize got error: %v, want nil", err)
+	}
+}
+
+func TestNamespaceWithPolicyRejectsOtherSubscription(t *testing.T) {
+	enforcer := newEnforcer(newPolicy("restricted", []string{"team-a"}, []string{"1234"}))
+
+	err := enforcer.Authorize("team-a", Scope{SubscriptionID: "9876"})
+
+	if !IsViolationError(err) {
+		t.Errorf("authorize got error: %v, want ViolationError", err)
+	}
+}
+
+func TestNamespaceWithPolicyAllowsPermittedScope(t *testing.T) {
+	policy := newPolicy("restricted", []string{"team-a"}, []string{"1234"})
+	policy.Spec.ResourceGroups = []string{"team-a-rg"}
+	policy.Spec.ResourceTypes = []string{"Microsoft.ServiceBus/namespaces"}
+	enforcer := newEnforcer(policy)
+
+	scope := ScopeForRequest(externalmetrics.AzureExternalMetricRequest{
+		SubscriptionID:            "1234",
+		ResourceGroup:             "Team-A-RG",
+		ResourceProviderNamespace: "Microsoft.ServiceBus",
+		ResourceType:              "namespaces",
+	})
+	err := enforcer.Authorize("team-a", scope)
+
+	if err != nil {
+		t.Errorf("authorize got error: %v, want nil", err)
+	}
+}
+
+func TestAnyMatchingPolicyAllowsScope(t *testing.T) {
+	enforcer := newEnforcer(
+		newPolicy("first", []string{"team-a"}, []string{"1234"}),
+		newPolicy("second", []string{"*"}, []string{"9876"}),
+	)
+
+	err := enforcer.Authorize("team-a", Scope{SubscriptionID: "9876"})
+
+	if err != nil {
+		t.Errorf("authorize got error: %v, want nil", err)
+	}
+}
+
+func TestServiceBusSubscriptionScopeUsesNamespacesType(t *testing.T) {
+	scope := ScopeForRequest(externalmetrics.AzureExternalMetricRequest{
+		Type:           externalmetrics.ServiceBusSubscription,
+		SubscriptionID: "1234",
+		ResourceGroup:  "rg",
+	})
+
+	if scope.ResourceType != "Microsoft.ServiceBus/namespaces" {
+		t.Errorf("scope.ResourceType = %v, want %v", scope.ResourceType, "Microsoft.ServiceBus/namespaces")
+	}
+}
+
+func TestStorageQueueScopeUsesStorageAccountsType(t *testing.T) {
+	scope := ScopeForRequest(externalmetrics.AzureExternalMetricRequest{
+		Type:           externalmetrics.StorageQueueMessageAge,
+		SubscriptionID: "1234",
+		ResourceGroup:  "rg",
+	})
+
+	if scope.ResourceType != "Microsoft.Storage/storageAccounts" {
+		t.Errorf("scope.ResourceType = %v, want %v", scope.ResourceType, "Microsoft.Storage/storageAccounts")
+	}
+}
+
+func TestActivityLogScopeUsesEventTypesType(t *testing.T) {
+	scope := ScopeForRequest(externalmetrics.AzureExternalMetricRequest{
+		Type:           externalmetrics.ActivityLog,
+		SubscriptionID: "1234",
+		ResourceGroup:  "rg",
+	})
+
+	if scope.ResourceType != "Microsoft.Insights/eventtypes" {
+		t.Errorf("scope.ResourceType = %v, want %v", scope.ResourceType, "Microsoft.Insights/eventtypes")
+	}
+}
+
+func TestContainerAppScopeUsesAppOrEnvironmentType(t *testing.T) {
+	var tests = []struct {
+		containerApp externalmetrics.ContainerAppDefinition
+		want         string
+	}{
+		{externalmetrics.ContainerAppDefinition{App: "api", Metric: "requests"}, "Microsoft.App/containerApps"},
+		{externalmetrics.ContainerAppDefinition{Environment: "prod", Metric: "EnvCoresQuotaUtilization"}, "Microsoft.App/managedEnvironments"},
+	}
+
+	for _, tt := range tests {
+		scope := ScopeForRequest(externalmetrics.AzureExternalMetricRequest{
+			Type:           externalmetrics.ContainerApp,
+			SubscriptionID: "1234",
+			ResourceGroup:  "rg",
+			ContainerApp:   tt.containerApp,
+		})
+
+		if scope.ResourceType != tt.want {
+			t.Errorf("scope.ResourceType = %v, want %v", scope.ResourceType, tt.want)
+		}
+	}
+}
+
+func TestLogicAppScopeUsesWorkflowsType(t *testing.T) {
+	scope := ScopeForRequest(externalmetrics.AzureExternalMetricRequest{
+		Type:           externalmetrics.LogicApp,
+		SubscriptionID: "1234",
+		ResourceGroup:  "rg",
+		LogicApp:       externalmetrics.LogicAppDefinition{Workflow: "orders", Metric: "started"},
+	})
+
+	if scope.ResourceType != "Microsoft.Logic/workflows" {
+		t.Errorf("scope.ResourceType = %v, want %v", scope.ResourceType, "Microsoft.Logic/workflows")
+	}
+}
+
+func TestScheduleMetricsAreNotRestricted(t *testing.T) {
+	enforcer := newEnforcer(newPolicy("restricted", []string{"team-a"}, []string{"1234"}))
+
+	scope := ScopeForRequest(externalmetrics.AzureExternalMetricRequest{
+		Type:           externalmetrics.Schedule,
+		SubscriptionID: "9876",
+	})
+	err := enforcer.Authorize("team-a", scope)
+
+	if err != nil {
+		t.Errorf("authorize got error: %v, want nil", err)
+	}
+}
+
+func TestSLOBurnRateMetricsAreNotRestricted(t *testing.T) {
+	enforcer := newEnforcer(newPolicy("restricted", []string{"team-a"}, []string{"1234"}))
+
+	scope := ScopeForRequest(externalmetrics.AzureExternalMetricRequest{
+		Type:           externalmetrics.SLOBurnRate,
+		SubscriptionID: "9876",
+	})
+	err := enforcer.Authorize("team-a", scope)
+
+	if err != nil {
+		t.Errorf("authorize got error: %v, want nil", err)
+	}
+}
+
+func TestNotSyncedPoliciesAreRejected(t *testing.T) {
+	enforcer := newEnforcer()
+	enforcer.policySynced = func() bool { return false }
+
+	err := enforcer.Authorize("team-a", Scope{})
+
+	if !IsNotSyncedError(err) {
+		t.Errorf("authorize got error: %v, want NotSyncedError", err)
+	}
+}
+
+func TestNilEnforcerAllowsEverything(t *testing.T) {
+	var enforcer *Enforcer
+
+	err := enforcer.Authorize("team-a", Scope{})
+
+	if err != nil {
+		t.Errorf("authorize got error: %v, want nil", err)
+	}
+}
+
+func newEnforcer(policies ...*api.AdapterPolicy) *Enforcer {
+	fakeClient := fake.NewSimpleClientset()
+	i := informers.NewSharedInformerFactory(fakeClient, 0)
+
+	for _, p := range policies {
+		i.Azure().V1alpha2().AdapterPolicies().Informer().GetIndexer().Add(p)
+	}
+
+	return NewEnforcer(i.Azure().V1alpha2().AdapterPolicies().Lister(), nil)
+}
+
+func newPolicy(name string, namespaces []string, subscriptions []string) *api.AdapterPolicy {
+	return &api.AdapterPolicy{
+		TypeMeta: metav1.TypeMeta{APIVersion: api.SchemeGroupVersion.String(), Kind: "AdapterPolicy"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: api.AdapterPolicySpec{
+			Namespaces:    namespaces,
+			Subscriptions: subscriptions,
+		},
+	}
+}
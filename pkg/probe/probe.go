@@ -0,0 +1,253 @@
+// Package probe evaluates the MetricProbe resources, which check that external metrics keep
+// serving values in their expected range
+package probe
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	clientset "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/typed/metrics/v1alpha2"
+	listers "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/listers/metrics/v1alpha2"
+	"github.com/golang/glog"
+	"github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+)
+
+const (
+	// Passed is the result of a probe whose metric was served with a value in range
+	Passed = "Passed"
+	// Failed is the result of a probe whose metric failed or was served out of range
+	Failed = "Failed"
+
+	// DefaultInterval is the interval between probes of a metric unless its probe sets one
+	DefaultInterval = time.Minute
+	// checkInterval is how often the probes are checked for being due
+	checkInterval = 10 * time.Second
+	// eventSource is the component of the events of probes
+	eventSource = "azure-k8s-metrics-adapter"
+)
+
+var probePassing = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "azure_metrics_adapter_metric_probe_passing",
+	Help: "1 when the last probe of the MetricProbe passed, 0 when it failed.",
+}, []string{"namespace", "probe"})
+
+func init() {
+	prometheus.MustRegister(probePassing)
+}
+
+// MetricGetter serves the value of an external metric, as the adapter serves it to HPAs
+type MetricGetter interface {
+	GetExternalMetric(namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error)
+}
+
+// Prober probes the metric of each MetricProbe at its interval and writes the result to its
+// status.  An event is recorded on the probe each time its result changes.
+type Prober struct {
+	lister listers.MetricProbeLister
+	synced cache.InformerSynced
+	client clientset.MetricProbesGetter
+	events corev1client.EventsGetter
+	now    func() time.Time
+
+	// probed is when each probe, by namespace/name, was last probed.  The status is only seen by
+	// the lister once the update has been watched, so the probe isn't due again before then.
+	probed map[string]time.Time
+}
+
+// NewProber creates a prober of the MetricProbes listed by the lister, updating their status and
+// recording their events with the clients
+func NewProber(lister listers.MetricProbeLister, synced cache.InformerSynced, client clientset.MetricProbesGetter, events corev1client.EventsGetter) *Prober {
+	return &Prober{
+		lister: lister,
+		synced: synced,
+		client: client,
+		events: events,
+		now:    time.Now,
+		probed: map[string]time.Time{},
+	}
+}
+
+// Run probes the metrics served by the getter until the channel is closed
+func (p *Prober) Run(metrics MetricGetter, stopCh <-chan struct{}) {
+	glog.V(2).Info("starting metric prober")
+	wait.Until(func() { p.probeDue(metrics) }, checkInterval, stopCh)
+}
+
+// probeDue probes the metrics whose interval has passed since they were last probed
+func (p *Prober) probeDue(metrics MetricGetter) {
+	if p.synced != nil && !p.synced() {
+		return
+	}
+	probes, err := p.lister.List(labels.Everything())
+	if err != nil {
+		glog.Errorf("unable to list metric probes: %v", err)
+		return
+	}
+
+	listed := map[string]bool{}
+	for _, probe := range probes {
+		key := fmt.Sprintf("%s/%s", probe.Namespace, probe.Name)
+		listed[key] = true
+		if p.due(key, probe) {
+			p.probe(metrics, key, probe)
+		}
+	}
+	for key := range p.probed {
+		if !listed[key] {
+			delete(p.probed, key)
+			namespace, name, _ := cache.SplitMetaNamespaceKey(key)
+			probePassing.DeleteLabelValues(namespace, name)
+		}
+	}
+}
+
+func (p *Prober) due(key string, probe *api.MetricProbe) bool {
+	last, found := p.probed[key]
+	if probe.Status.LastProbeTime != nil && probe.Status.LastProbeTime.Time.After(last) {
+		last, found = probe.Status.LastProbeTime.Time, true
+	}
+	if !found {
+		return true
+	}
+	interval, err := probeInterval(probe)
+	if err != nil {
+		interval = DefaultInterval
+	}
+	return !p.now().Before(last.Add(interval))
+}
+
+// probe evaluates the probe, updates its status and records an event when its result changed
+func (p *Prober) probe(metrics MetricGetter, key string, probe *api.MetricProbe) {
+	now := metav1.NewTime(p.now())
+	p.probed[key] = now.Time
+
+	status := evaluate(metrics, probe)
+	status.LastProbeTime = &now
+	status.LastTransitionTime = probe.Status.LastTransitionTime
+	if status.Result != probe.Status.Result {
+		status.LastTransitionTime = &now
+		p.recordEvent(probe, status)
+	}
+
+	passing := 0.0
+	if status.Result == Passed {
+		passing = 1
+	}
+	probePassing.WithLabelValues(probe.Namespace, probe.Name).Set(passing)
+	glog.V(4).Infof("metric probe %s: %s %s", key, status.Result, status.Message)
+
+	updated := probe.DeepCopy()
+	updated.Status = status
+	if _, err := p.client.MetricProbes(probe.Namespace).UpdateStatus(updated); err != nil {
+		glog.Errorf("unable to update the status of metric probe %s: %v", key, err)
+	}
+}
+
+// evaluate requests the metric of the probe and checks its value is in range
+func evaluate(metrics MetricGetter, probe *api.MetricProbe) api.MetricProbeStatus {
+	if _, err := probeInterval(probe); err != nil {
+		return failed("", err.Error())
+	}
+	min, err := parseBound(probe.Spec.Min)
+	if err != nil {
+		return failed("", fmt.Sprintf("invalid min: %v", err))
+	}
+	max, err := parseBound(probe.Spec.Max)
+	if err != nil {
+		return failed("", fmt.Sprintf("invalid max: %v", err))
+	}
+
+	selector := labels.SelectorFromSet(labels.Set(probe.Spec.MetricSelector))
+	values, err := metrics.GetExternalMetric(probe.Namespace, selector, provider.ExternalMetricInfo{Metric: probe.Spec.ExternalMetric})
+	if err != nil {
+		return failed("", fmt.Sprintf("external metric %s failed: %v", probe.Spec.ExternalMetric, err))
+	}
+	if len(values.Items) == 0 {
+		return failed("", fmt.Sprintf("external metric %s served no value", probe.Spec.ExternalMetric))
+	}
+
+	total := 0.0
+	for _, item := range values.Items {
+		total += float64(item.Value.MilliValue()) / 1000
+	}
+	value := strconv.FormatFloat(total, 'f', -1, 64)
+	if min != nil && total < *min {
+		return failed(value, fmt.Sprintf("value %s is below the min %s", value, probe.Spec.Min))
+	}
+	if max != nil && total > *max {
+		return failed(value, fmt.Sprintf("value %s is above the max %s", value, probe.Spec.Max))
+	}
+	return api.MetricProbeStatus{Result: Passed, Value: value}
+}
+
+func failed(value string, message string) api.MetricProbeStatus {
+	return api.MetricProbeStatus{Result: Failed, Value: value, Message: message}
+}
+
+// probeInterval returns the interval of the probe, or the default when it sets none
+func probeInterval(probe *api.MetricProbe) (time.Duration, error) {
+	if probe.Spec.Interval == "" {
+		return DefaultInterval, nil
+	}
+	interval, err := time.ParseDuration(probe.Spec.Interval)
+	if err != nil || interval <= 0 {
+		return 0, fmt.Errorf("invalid interval '%s', use the go duration format such as 5m", probe.Spec.Interval)
+	}
+	return interval, nil
+}
+
+// parseBound returns the bound, or nil when it is empty
+func parseBound(bound string) (*float64, error) {
+	if bound == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(bound, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+// recordEvent records the result of the probe as an event on it, a warning when it failed
+func (p *Prober) recordEvent(probe *api.MetricProbe, status api.MetricProbeStatus) {
+	eventType, reason, message := corev1.EventTypeNormal, "ProbePassed", fmt.Sprintf("external metric %s served %s", probe.Spec.ExternalMetric, status.Value)
+	if status.Result == Failed {
+		eventType, reason, message = corev1.EventTypeWarning, "ProbeFailed", status.Message
+	}
+
+	now := metav1.NewTime(p.now())
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: probe.Name + "-",
+			Namespace:    probe.Namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion:      api.SchemeGroupVersion.String(),
+			Kind:            "MetricProbe",
+			Namespace:       probe.Namespace,
+			Name:            probe.Name,
+			UID:             probe.UID,
+			ResourceVersion: probe.ResourceVersion,
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         corev1.EventSource{Component: eventSource},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := p.events.Events(probe.Namespace).Create(event); err != nil {
+		glog.Errorf("unable to record event of metric probe %s/%s: %v", probe.Namespace, probe.Name, err)
+	}
+}
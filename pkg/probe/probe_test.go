@@ -0,0 +1,196 @@
+package probe
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/fake"
+	informers "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/informers/externalversions"
+	listers "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/listers/metrics/v1alpha2"
+	"github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+)
+
+var testProbeNow = time.Date(2019, 3, 4, 12, 0, 0, 0, time.UTC)
+
+func TestProbeResults(t *testing.T) {
+	var tests = []struct {
+		name        string
+		min         string
+		max         string
+		value       int64
+		err         error
+		wantResult  string
+		wantMessage string
+	}{
+		{name: "in range", min: "1", max: "100", value: 50, wantResult: Passed},
+		{name: "no bounds", value: 50, wantResult: Passed},
+		{name: "below min", min: "60", value: 50, wantResult: Failed, wantMessage: "below the min 60"},
+		{name: "above max", max: "10", value: 50, wantResult: Failed, wantMessage: "above the max 10"},
+		{name: "metric fails", err: errors.New("authorization failed"), wantResult: Failed, wantMessage: "authorization failed"},
+		{name: "invalid min", min: "low", value: 50, wantResult: Failed, wantMessage: "invalid min"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			probe := newMetricProbe("queue")
+			probe.Spec.Min, probe.Spec.Max = tt.min, tt.max
+			prober, client, _ := newTestProber(probe)
+
+			prober.probeDue(fakeMetrics{value: tt.value, err: tt.err})
+
+			status := probeStatus(t, client, probe)
+			if status.Result != tt.wantResult {
+				t.Errorf("result = %v, want %v", status.Result, tt.wantResult)
+			}
+			if !strings.Contains(status.Message, tt.wantMessage) {
+				t.Errorf("message = %q, want it to contain %q", status.Message, tt.wantMessage)
+			}
+			if status.LastProbeTime == nil || !status.LastProbeTime.Time.Equal(testProbeNow) {
+				t.Errorf("last probe time = %v, want %v", status.LastProbeTime, testProbeNow)
+			}
+		})
+	}
+}
+
+func TestProbeSumsValuesOfSplitMetric(t *testing.T) {
+	probe := newMetricProbe("queue")
+	probe.Spec.MetricSelector = map[string]string{"region": "westeurope"}
+	prober, client, _ := newTestProber(probe)
+
+	prober.probeDue(fakeMetrics{value: 5, items: 3})
+
+	status := probeStatus(t, client, probe)
+	if status.Value != "15" {
+		t.Errorf("value = %v, want %v", status.Value, "15")
+	}
+}
+
+func TestProbeNotDueBeforeInterval(t *testing.T) {
+	probe := newMetricProbe("queue")
+	probe.Spec.Interval = "5m"
+	last := metav1.NewTime(testProbeNow.Add(-time.Minute))
+	probe.Status = api.MetricProbeStatus{Result: Passed, LastProbeTime: &last}
+	prober, client, _ := newTestProber(probe)
+
+	prober.probeDue(fakeMetrics{value: 5})
+
+	if len(client.Actions()) != 0 {
+		t.Errorf("actions = %v, want none before the interval has passed", client.Actions())
+	}
+
+	prober.now = func() time.Time { return testProbeNow.Add(4 * time.Minute) }
+	prober.probeDue(fakeMetrics{value: 5})
+	if len(client.Actions()) != 1 {
+		t.Errorf("actions = %v, want the status updated once the interval has passed", client.Actions())
+	}
+}
+
+func TestProbeEventRecordedWhenResultChanges(t *testing.T) {
+	probe := newMetricProbe("queue")
+	probe.Spec.Max = "10"
+	prober, _, events := newTestProber(probe)
+
+	prober.probeDue(fakeMetrics{value: 50})
+	if len(events.created) != 1 || events.created[0].Reason != "ProbeFailed" || events.created[0].Type != corev1.EventTypeWarning {
+		t.Fatalf("events = %+v, want one ProbeFailed warning", events.created)
+	}
+	if events.created[0].InvolvedObject.Kind != "MetricProbe" || events.created[0].InvolvedObject.Name != "queue" {
+		t.Errorf("involved object = %+v, want the probe", events.created[0].InvolvedObject)
+	}
+
+	// the result hasn't changed since the status the probe was listed with
+	probe.Status.Result = Failed
+	prober.probed = map[string]time.Time{}
+	prober.lister = newProbeLister(probe)
+	prober.probeDue(fakeMetrics{value: 50})
+	if len(events.created) != 1 {
+		t.Errorf("events = %+v, want no event while the probe keeps failing", events.created)
+	}
+
+	prober.probed = map[string]time.Time{}
+	prober.probeDue(fakeMetrics{value: 5})
+	if len(events.created) != 2 || events.created[1].Reason != "ProbePassed" {
+		t.Errorf("events = %+v, want a ProbePassed event once the probe passes", events.created)
+	}
+}
+
+func newTestProber(probe *api.MetricProbe) (*Prober, *fake.Clientset, *fakeEvents) {
+	client := fake.NewSimpleClientset(probe)
+	events := &fakeEvents{}
+	prober := NewProber(newProbeLister(probe), nil, client.AzureV1alpha2(), events)
+	prober.now = func() time.Time { return testProbeNow }
+	client.ClearActions()
+	return prober, client, events
+}
+
+func newProbeLister(probe *api.MetricProbe) listers.MetricProbeLister {
+	i := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	i.Azure().V1alpha2().MetricProbes().Informer().GetIndexer().Add(probe)
+	return i.Azure().V1alpha2().MetricProbes().Lister()
+}
+
+// probeStatus returns the status of the probe written by the prober
+func probeStatus(t *testing.T, client *fake.Clientset, probe *api.MetricProbe) api.MetricProbeStatus {
+	updated, err := client.AzureV1alpha2().MetricProbes(probe.Namespace).Get(probe.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unable to get probe: %v", err)
+	}
+	return updated.Status
+}
+
+func newMetricProbe(metric string) *api.MetricProbe {
+	return &api.MetricProbe{
+		TypeMeta: metav1.TypeMeta{APIVersion: api.SchemeGroupVersion.String(), Kind: "MetricProbe"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      metric,
+			Namespace: "default",
+		},
+		Spec: api.MetricProbeSpec{ExternalMetric: metric},
+	}
+}
+
+// fakeMetrics serves the value in each of the items, one by default, or the error
+type fakeMetrics struct {
+	value int64
+	items int
+	err   error
+}
+
+func (m fakeMetrics) GetExternalMetric(namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	items := m.items
+	if items == 0 {
+		items = 1
+	}
+	list := &external_metrics.ExternalMetricValueList{}
+	for i := 0; i < items; i++ {
+		list.Items = append(list.Items, external_metrics.ExternalMetricValue{MetricName: info.Metric, Value: *resource.NewQuantity(m.value, resource.DecimalSI)})
+	}
+	return list, nil
+}
+
+// fakeEvents records the events created in any namespace
+type fakeEvents struct {
+	corev1client.EventInterface
+	created []*corev1.Event
+}
+
+func (e *fakeEvents) Events(namespace string) corev1client.EventInterface {
+	return e
+}
+
+func (e *fakeEvents) Create(event *corev1.Event) (*corev1.Event, error) {
+	e.created = append(e.created, event)
+	return event, nil
+}
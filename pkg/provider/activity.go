@@ -0,0 +1,66 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+)
+
+// ActivitySuffix is added to the name of an ExternalMetric with activity enabled
+// to request its 0/1 activity metric
+const ActivitySuffix = "-activity"
+
+const defaultActivityWindow = 5 * time.Minute
+
+// activityTracker remembers when each metric was last above its activity threshold
+type activityTracker struct {
+	mu         sync.Mutex
+	lastActive map[string]time.Time
+	now        func() time.Time
+}
+
+func newActivityTracker() *activityTracker {
+	return &activityTracker{
+		lastActive: map[string]time.Time{},
+		now:        time.Now,
+	}
+}
+
+// activityMetricName returns the ExternalMetric name an activity metric name refers to
+func activityMetricName(metricName string) (string, bool) {
+	if !strings.HasSuffix(metricName, ActivitySuffix) {
+		return "", false
+	}
+	return strings.TrimSuffix(metricName, ActivitySuffix), true
+}
+
+// observe records the value and returns 1 if it has been above the threshold within the window, otherwise 0
+func (t *activityTracker) observe(key string, value float64, activity externalmetrics.ActivityDefinition) (float64, error) {
+	window := defaultActivityWindow
+	if activity.Window != "" {
+		var err error
+		if window, err = time.ParseDuration(activity.Window); err != nil || window < 0 {
+			return 0, fmt.Errorf("invalid activity window '%s'", activity.Window)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if value > activity.Threshold {
+		t.lastActive[key] = now
+		return 1, nil
+	}
+
+	lastActive, ok := t.lastActive[key]
+	if ok && now.Sub(lastActive) <= window {
+		return 1, nil
+	}
+
+	delete(t.lastActive, key)
+	return 0, nil
+}
@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+)
+
+func TestActivityStaysActiveWithinWindow(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2019-03-04T10:00:00Z")
+	tracker := newActivityTracker()
+	tracker.now = func() time.Time { return now }
+	activity := externalmetrics.ActivityDefinition{Enabled: true, Threshold: 5, Window: "10m"}
+
+	tests := []struct {
+		name  string
+		after time.Duration
+		value float64
+		want  float64
+	}{
+		{name: "above threshold", after: 0, value: 6, want: 1},
+		{name: "below threshold within window", after: 9 * time.Minute, value: 5, want: 1},
+		{name: "below threshold after window", after: 20 * time.Minute, value: 0, want: 0},
+		{name: "active again", after: 21 * time.Minute, value: 100, want: 1},
+	}
+	start := now
+	for _, tt := range tests {
+		now = start.Add(tt.after)
+		got, err := tracker.observe("default/queue", tt.value, activity)
+		if err != nil {
+			t.Fatalf("%s: observe() error = %v, want nil", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: observe() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestActivityIsTrackedPerMetric(t *testing.T) {
+	tracker := newActivityTracker()
+	activity := externalmetrics.ActivityDefinition{Enabled: true}
+
+	tracker.observe("default/busy", 1, activity)
+	got, _ := tracker.observe("default/idle", 0, activity)
+
+	if got != 0 {
+		t.Errorf("observe() = %v, want %v", got, 0)
+	}
+}
+
+func TestActivityInvalidWindowGetError(t *testing.T) {
+	tracker := newActivityTracker()
+
+	_, err := tracker.observe("default/queue", 1, externalmetrics.ActivityDefinition{Enabled: true, Window: "a while"})
+
+	if err == nil {
+		t.Errorf("observe() error = %v, want error", err)
+	}
+}
+
+func TestActivityMetricName(t *testing.T) {
+	name, ok := activityMetricName("queue-activity")
+	if !ok || name != "queue" {
+		t.Errorf("activityMetricName() = %v, %v, want queue, true", name, ok)
+	}
+
+	if _, ok := activityMetricName("queue"); ok {
+		t.Errorf("activityMetricName() ok = %v, want false", ok)
+	}
+}
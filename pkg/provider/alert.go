@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/policy"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// applyAlert floors or caps the value and each series of the metric while the alert rule is firing.
+// When the state of the alert can't be read the request fails, so the autoscaler holds its scale.
+func (p *AzureProvider) applyAlert(namespace string, metricName string, alert externalmetrics.AlertDefinition, metricValue externalmetrics.AzureExternalMetricResponse) (externalmetrics.AzureExternalMetricResponse, error) {
+	if err := alert.Validate(); err != nil {
+		return metricValue, errors.NewBadRequest(err.Error())
+	}
+
+	rule, _ := externalmetrics.ParseAlertRuleID(alert.RuleID)
+	err := p.policyEnforcer.Authorize(namespace, policy.Scope{
+		SubscriptionID: rule.SubscriptionID,
+		ResourceGroup:  rule.ResourceGroup,
+		ResourceType:   rule.ResourceType,
+	})
+	if err != nil {
+		return metricValue, policyError(metricName, err)
+	}
+
+	firing, err := p.alertChecker.IsFiring(alert.RuleID)
+	if err != nil {
+		err = redact.Error(err)
+		glog.Errorf("unable to check alert rule %s: %v", rule.Name, err)
+		return metricValue, errors.NewServiceUnavailable(err.Error())
+	}
+	if !firing {
+		return metricValue, nil
+	}
+
+	glog.V(2).Infof("alert rule %s is firing, applying %s of %f to %s", rule.Name, alert.Action, alert.Value, metricName)
+	guarded := externalmetrics.AzureExternalMetricResponse{
+		Total: alert.Apply(metricValue.Total),
+		Unit:  metricValue.Unit,
+		Raw:   metricValue.Raw,
+	}
+	for _, series := range metricValue.Series {
+		guarded.Series = append(guarded.Series, externalmetrics.MetricSeries{
+			Labels: series.Labels,
+			Value:  alert.Apply(series.Value),
+		})
+	}
+	return guarded, nil
+}
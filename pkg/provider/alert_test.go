@@ -0,0 +1,92 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	azurefake "github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/fake"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const testAlertRuleID = "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Insights/metricAlerts/backlog"
+
+func TestAlertFloorsValueWhileFiring(t *testing.T) {
+	// the fake client returns 15
+	tests := []struct {
+		firing bool
+		want   int64
+	}{
+		{firing: true, want: 20},
+		{firing: false, want: 15},
+	}
+	for _, tt := range tests {
+		provider := newProvider(fakeAzureExternalClientFactory{})
+		provider.alertChecker = newFakeAlertChecker(tt.firing, nil)
+		provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+			MetricName: "Messages",
+			Alert:      externalmetrics.AlertDefinition{RuleID: testAlertRuleID, Action: externalmetrics.AlertActionFloor, Value: 20},
+		})
+
+		selector, _ := labels.Parse("")
+		returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+
+		if err != nil {
+			t.Fatalf("error after processing got: %v, want nil", err)
+		}
+
+		if returnList.Items[0].Value.Value() != tt.want {
+			t.Errorf("firing %v: externalMetric.Value = %v, want there %v", tt.firing, returnList.Items[0].Value.Value(), tt.want)
+		}
+	}
+}
+
+func TestAlertCapsEachSeriesWhileFiring(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.alertChecker = newFakeAlertChecker(true, nil)
+	provider.metricCache.Update("ExternalMetric/default/queues", externalmetrics.AzureExternalMetricRequest{
+		MetricName:     "ActiveMessages",
+		SplitDimension: "EntityName",
+		Alert:          externalmetrics.AlertDefinition{RuleID: testAlertRuleID, Action: externalmetrics.AlertActionCap, Value: 8},
+	})
+
+	selector, _ := labels.Parse("")
+	returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queues"})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	want := map[string]int64{"orders": 8, "payments": 5}
+	for _, item := range returnList.Items {
+		entity := item.MetricLabels["entityname"]
+		if item.Value.Value() != want[entity] {
+			t.Errorf("%s value = %v, want there %v", entity, item.Value.Value(), want[entity])
+		}
+	}
+}
+
+func TestAlertUnavailableFailsRequest(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.alertChecker = newFakeAlertChecker(false, errors.New("alerts unavailable"))
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		Alert:      externalmetrics.AlertDefinition{RuleID: testAlertRuleID, Action: externalmetrics.AlertActionFloor, Value: 20},
+	})
+
+	selector, _ := labels.Parse("")
+	_, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+
+	if !k8serrors.IsServiceUnavailable(err) {
+		t.Errorf("error after processing got: %v, want service unavailable", err)
+	}
+}
+
+// newFakeAlertChecker reports whether every alert rule is firing, or the error
+func newFakeAlertChecker(firing bool, err error) *azurefake.FakeAlertChecker {
+	checker := &azurefake.FakeAlertChecker{}
+	checker.IsFiringReturns(firing, err)
+	return checker
+}
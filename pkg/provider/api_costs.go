@@ -0,0 +1,148 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// APICostPath is the path the Azure api calls made for each metric are reported on.  It is served
+// behind the authentication and authorization of the metrics apis, like the raw responses.
+const APICostPath = "/debug/apicosts"
+
+// apiCostBuckets is the number of minutes of the window calls are reported over
+const apiCostBuckets = 60
+
+// appInsightsBackend is the backend of the calls of custom metrics
+const appInsightsBackend = "appinsights"
+
+var azureAPICalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "azure_metrics_adapter_azure_api_calls_total",
+	Help: "Azure api calls made to serve each ExternalMetric or CustomMetric, by the backend called.",
+}, []string{"namespace", "metric", "backend"})
+
+func init() {
+	prometheus.MustRegister(azureAPICalls)
+}
+
+// APICosts counts the Azure api calls made for each metric and the backend, such as Azure Monitor
+// or Application Insights, they are made to, so expensive metrics can be found and charged back
+type APICosts struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	metrics map[apiCostKey]*apiCost
+}
+
+type apiCostKey struct {
+	namespace string
+	metric    string
+	backend   string
+}
+
+// apiCost counts the calls of each minute of the last hour, in the bucket of the minute modulo
+// the number of buckets, and since the adapter started
+type apiCost struct {
+	total   int64
+	calls   [apiCostBuckets]int64
+	minutes [apiCostBuckets]int64
+}
+
+// apiCostReport is the report of the calls of a metric
+type apiCostReport struct {
+	Namespace     string `json:"namespace"`
+	Metric        string `json:"metric"`
+	Backend       string `json:"backend"`
+	CallsLastHour int64  `json:"callsLastHour"`
+	CallsTotal    int64  `json:"callsTotal"`
+}
+
+// NewAPICosts creates the counts of the Azure api calls of each metric
+func NewAPICosts() *APICosts {
+	return &APICosts{
+		now:     time.Now,
+		metrics: map[apiCostKey]*apiCost{},
+	}
+}
+
+// record counts a call to the backend made for the metric
+func (c *APICosts) record(namespace string, metricName string, backend string) {
+	if c == nil {
+		return
+	}
+	azureAPICalls.WithLabelValues(namespace, metricName, backend).Inc()
+
+	minute := c.now().Unix() / 60
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := apiCostKey{namespace: namespace, metric: metricName, backend: backend}
+	cost, found := c.metrics[key]
+	if !found {
+		cost = &apiCost{}
+		c.metrics[key] = cost
+	}
+
+	bucket := minute % apiCostBuckets
+	if cost.minutes[bucket] != minute {
+		cost.minutes[bucket], cost.calls[bucket] = minute, 0
+	}
+	cost.calls[bucket]++
+	cost.total++
+}
+
+// report returns the calls of the metrics of the namespace, or every namespace when empty, most
+// calls in the last hour first
+func (c *APICosts) report(namespace string) []apiCostReport {
+	minute := c.now().Unix() / 60
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	reports := []apiCostReport{}
+	for key, cost := range c.metrics {
+		if namespace != "" && key.namespace != namespace {
+			continue
+		}
+		report := apiCostReport{Namespace: key.namespace, Metric: key.metric, Backend: key.backend, CallsTotal: cost.total}
+		for i, calls := range cost.calls {
+			if minute-cost.minutes[i] < apiCostBuckets {
+				report.CallsLastHour += calls
+			}
+		}
+		reports = append(reports, report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].CallsLastHour != reports[j].CallsLastHour {
+			return reports[i].CallsLastHour > reports[j].CallsLastHour
+		}
+		if reports[i].Namespace != reports[j].Namespace {
+			return reports[i].Namespace < reports[j].Namespace
+		}
+		if reports[i].Metric != reports[j].Metric {
+			return reports[i].Metric < reports[j].Metric
+		}
+		return reports[i].Backend < reports[j].Backend
+	})
+	return reports
+}
+
+// ServeHTTP writes the report of the calls of each metric as json, of the metrics of the namespace
+// query parameter when it is set, in pages of the limit query parameter when it is set
+func (c *APICosts) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	reports := c.report(req.URL.Query().Get("namespace"))
+	start, end, ok := page(w, req, len(reports))
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports[start:end])
+}
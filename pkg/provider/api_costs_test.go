@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestAPICostsCountCallsOfEachMetric(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.apiCosts = NewAPICosts()
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		Type:       externalmetrics.Monitor,
+		MetricName: "Messages",
+	})
+
+	selector, _ := labels.Parse("")
+	for i := 0; i < 3; i++ {
+		if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"}); err != nil {
+			t.Fatalf("error after processing got: %v, want nil", err)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	provider.apiCosts.ServeHTTP(recorder, httptest.NewRequest("GET", APICostPath, nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %v, want %v", recorder.Code, http.StatusOK)
+	}
+	reports := []apiCostReport{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &reports); err != nil {
+		t.Fatalf("unable to parse report: %v", err)
+	}
+	want := []apiCostReport{{Namespace: "default", Metric: "queue", Backend: externalmetrics.Monitor, CallsLastHour: 3, CallsTotal: 3}}
+	if !reflect.DeepEqual(reports, want) {
+		t.Errorf("report = %+v, want %+v", reports, want)
+	}
+}
+
+func TestAPICostsReportLastHour(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2019-03-10T00:00:00Z")
+	costs := NewAPICosts()
+	costs.now = func() time.Time { return now }
+
+	costs.record("default", "queue", externalmetrics.Monitor)
+	now = now.Add(30 * time.Minute)
+	costs.record("default", "queue", externalmetrics.Monitor)
+	costs.record("team-a", "logs", appInsightsBackend)
+	costs.record("team-a", "logs", appInsightsBackend)
+	now = now.Add(31 * time.Minute)
+
+	var tests = []struct {
+		namespace string
+		want      []apiCostReport
+	}{
+		{"", []apiCostReport{
+			{Namespace: "team-a", Metric: "logs", Backend: appInsightsBackend, CallsLastHour: 2, CallsTotal: 2},
+			{Namespace: "default", Metric: "queue", Backend: externalmetrics.Monitor, CallsLastHour: 1, CallsTotal: 2},
+		}},
+		{"default", []apiCostReport{
+			{Namespace: "default", Metric: "queue", Backend: externalmetrics.Monitor, CallsLastHour: 1, CallsTotal: 2},
+		}},
+		{"team-b", []apiCostReport{}},
+	}
+
+	for _, tt := range tests {
+		if got := costs.report(tt.namespace); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("namespace %q: report = %+v, want %+v", tt.namespace, got, tt.want)
+		}
+	}
+
+	// a bucket reused an hour later only counts its new calls
+	now = now.Add(29 * time.Minute)
+	costs.record("default", "queue", externalmetrics.Monitor)
+	if got := costs.report("default")[0].CallsLastHour; got != 1 {
+		t.Errorf("calls in the last hour = %v, want %v", got, 1)
+	}
+}
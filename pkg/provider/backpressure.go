@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/golang/glog"
+)
+
+// backpressureInterval is how much longer the value of a metric is reused for each step its
+// subscription's Azure Resource Manager quota is degraded by
+const backpressureInterval = 30 * time.Second
+
+// maxBackpressureInterval is the longest time the value of a metric is reused for, once the quota
+// of its subscription is exhausted.  When the metric was queried longer ago it is queried again,
+// so when it was queried is no longer kept.
+const maxBackpressureInterval = (externalmetrics.MaxARMQuotaDegradation - 1) * backpressureInterval
+
+// backpressure spaces out the queries of metrics in subscriptions running out of Azure Resource
+// Manager quota, serving their previous value in between, so the adapter sheds load before Azure
+// throttles every metric of the subscription
+type backpressure struct {
+	quota *externalmetrics.ARMQuota
+	now   func() time.Time
+
+	mu        sync.Mutex
+	queried   map[string]time.Time
+	lastPrune time.Time
+}
+
+func newBackpressure(quota *externalmetrics.ARMQuota) *backpressure {
+	if quota == nil {
+		return nil
+	}
+	return &backpressure{
+		quota:   quota,
+		now:     time.Now,
+		queried: map[string]time.Time{},
+	}
+}
+
+// interval returns how long after the metric was queried Azure is queried for it again
+func (b *backpressure) interval(subscriptionID string) time.Duration {
+	degradation := b.quota.Degradation(subscriptionID)
+	return time.Duration((degradation - 1) * float64(backpressureInterval))
+}
+
+// record keeps when the metric was queried
+func (b *backpressure) record(key string) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.now()
+	b.queried[key] = now
+	b.prune(now)
+}
+
+// prune drops when the metrics were queried once it is longer ago than the longest interval, at
+// most once per interval.  It must be called with the mutex held.
+func (b *backpressure) prune(now time.Time) {
+	if now.Sub(b.lastPrune) < maxBackpressureInterval {
+		return
+	}
+	b.lastPrune = now
+
+	for key, queried := range b.queried {
+		if now.Sub(queried) >= maxBackpressureInterval {
+			delete(b.queried, key)
+		}
+	}
+}
+
+// servedUnderBackpressure returns the value last served for a metric queried more recently than
+// the quota of its subscription allows
+func (p *AzureProvider) servedUnderBackpressure(valueKey string, azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, bool) {
+	if p.backpressure == nil {
+		return externalmetrics.AzureExternalMetricResponse{}, false
+	}
+
+	subscriptionID := azMetricRequest.SubscriptionID
+	if subscriptionID == "" {
+		subscriptionID = p.defaultSubscriptionID
+	}
+	interval := p.backpressure.interval(subscriptionID)
+	if interval <= 0 {
+		return externalmetrics.AzureExternalMetricResponse{}, false
+	}
+
+	p.backpressure.mu.Lock()
+	queried, found := p.backpressure.queried[valueKey]
+	p.backpressure.mu.Unlock()
+	if !found || p.backpressure.now().Sub(queried) >= interval {
+		return externalmetrics.AzureExternalMetricResponse{}, false
+	}
+
+	value, found := p.maintenance.last(valueKey)
+	if found {
+		glog.V(2).Infof("serving the previous value of %s, queried %s ago, as the azure resource manager quota of subscription %s is low", valueKey, p.backpressure.now().Sub(queried), subscriptionID)
+	}
+	return value, found
+}
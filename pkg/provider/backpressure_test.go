@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestLowARMQuotaSpacesOutQueries(t *testing.T) {
+	var tests = []struct {
+		name      string
+		remaining string
+		wantCalls []int
+	}{
+		// a degradation of 2 reuses values for 30 seconds
+		{"quota below threshold", "500", []int{1, 1, 2, 2}},
+		{"quota above threshold", "5000", []int{1, 2, 3, 4}},
+	}
+
+	for _, tt := range tests {
+		quota := externalmetrics.NewARMQuota(1000)
+		u, _ := url.Parse("https://management.azure.com/subscriptions/sub/providers/microsoft.insights/metrics")
+		quota.Observe(&http.Response{Header: http.Header{"X-Ms-Ratelimit-Remaining-Subscription-Reads": []string{tt.remaining}}, Request: &http.Request{URL: u}})
+
+		now := time.Now()
+		client := &countingExternalClient{}
+		provider := newProvider(fakeAzureExternalClientFactory{})
+		provider.azureClientFactory = countingClientFactory{client: client}
+		provider.maintenance = newMaintenance(externalmetrics.MaintenanceDefinition{}, 0)
+		provider.backpressure = newBackpressure(quota)
+		provider.backpressure.now = func() time.Time { return now }
+		provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+			MetricName:     "Messages",
+			SubscriptionID: "sub",
+		})
+
+		selector, _ := labels.Parse("")
+		for i, wantCalls := range tt.wantCalls {
+			returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+			if err != nil {
+				t.Fatalf("%s: error after processing got: %v, want nil", tt.name, err)
+			}
+			if client.calls != wantCalls {
+				t.Errorf("%s: after %d requests calls = %v, want %v", tt.name, i+1, client.calls, wantCalls)
+			}
+			if returnList.Items[0].Value.Value() != int64(client.calls) {
+				t.Errorf("%s: value = %v, want the last queried value %v", tt.name, returnList.Items[0].Value.Value(), client.calls)
+			}
+			now = now.Add(20 * time.Second)
+		}
+	}
+}
+
+func TestBackpressureDropsQueriesOlderThanLongestInterval(t *testing.T) {
+	now := time.Now()
+	b := newBackpressure(externalmetrics.NewARMQuota(1000))
+	b.now = func() time.Time { return now }
+
+	b.record("default/queue/app=old")
+	now = now.Add(maxBackpressureInterval)
+	b.record("default/queue/")
+
+	if _, found := b.queried["default/queue/app=old"]; found {
+		t.Errorf("query older than the longest interval kept, want it dropped")
+	}
+	if _, found := b.queried["default/queue/"]; !found {
+		t.Errorf("recent query dropped, want it kept")
+	}
+}
@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// getCombinedMetric queries the sources of a combined metric in parallel and returns the sum of their
+// weighted values.  Each source is checked by policy and the request fails if any source fails.
+func (p *AzureProvider) getCombinedMetric(namespace string, metricName string, azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	if len(azMetricRequest.Sources) == 0 {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest("a combined metric requires sources")
+	}
+
+	weights := make([]float64, len(azMetricRequest.Sources))
+	named := map[string]bool{}
+	for i, source := range azMetricRequest.Sources {
+		if source.Request.Type == externalmetrics.Combined {
+			return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest("the sources of a combined metric can not be combined")
+		}
+		if source.Name != "" {
+			if named[source.Name] {
+				return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest(fmt.Sprintf("more than one source is named %s", source.Name))
+			}
+			named[source.Name] = true
+		}
+		weight, err := source.ParseWeight()
+		if err != nil {
+			return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest(err.Error())
+		}
+		weights[i] = weight
+	}
+
+	values := make([]float64, len(azMetricRequest.Sources))
+	raw := make([][]string, len(azMetricRequest.Sources))
+	errs := make([]error, len(azMetricRequest.Sources))
+	var wg sync.WaitGroup
+	for i, source := range azMetricRequest.Sources {
+		wg.Add(1)
+		go func(i int, request externalmetrics.AzureExternalMetricRequest) {
+			defer wg.Done()
+
+			request.Timespan = externalmetrics.TimeSpan()
+			if request.SubscriptionID == "" {
+				request.SubscriptionID = p.defaultSubscriptionID
+			}
+			metricValue, err := p.queryExternalMetric(namespace, metricName, request)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			values[i] = metricValue.Total
+			raw[i] = metricValue.Raw
+		}(i, source.Request)
+	}
+	wg.Wait()
+
+	combined := externalmetrics.AzureExternalMetricResponse{}
+	for i, err := range errs {
+		if err != nil {
+			glog.Errorf("source %d of %s failed: %v", i, metricName, err)
+			return externalmetrics.AzureExternalMetricResponse{}, err
+		}
+		combined.Total += weights[i] * values[i]
+		combined.Raw = append(combined.Raw, raw[i]...)
+		if name := azMetricRequest.Sources[i].Name; name != "" {
+			if combined.Sources == nil {
+				combined.Sources = map[string]float64{}
+			}
+			combined.Sources[name] = values[i]
+		}
+	}
+
+	glog.V(2).Infof("combined %d sources of %s: %f", len(values), metricName, combined.Total)
+	return combined, nil
+}
@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestCombinedMetricSumsWeightedSources(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = metricValuesClientFactory{"Messages": 100, "Latency": 20}
+	provider.metricCache.Update("ExternalMetric/default/load", externalmetrics.AzureExternalMetricRequest{
+		Type: externalmetrics.Combined,
+		Sources: []externalmetrics.WeightedSource{
+			{Weight: "0.7", Request: externalmetrics.AzureExternalMetricRequest{Type: externalmetrics.ServiceBusSubscription, MetricName: "Messages"}},
+			{Weight: "0.3", Request: externalmetrics.AzureExternalMetricRequest{Type: externalmetrics.Monitor, MetricName: "Latency"}},
+		},
+	})
+
+	selector, _ := labels.Parse("")
+	returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "load"})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if returnList.Items[0].Value.Value() != 76 {
+		t.Errorf("externalMetric.Value = %v, want there %v", returnList.Items[0].Value.Value(), 76)
+	}
+}
+
+func TestCombinedMetricInvalidSourcesIsBadRequest(t *testing.T) {
+	var tests = []struct {
+		name    string
+		sources []externalmetrics.WeightedSource
+	}{
+		{"no sources", nil},
+		{"invalid weight", []externalmetrics.WeightedSource{{Weight: "most", Request: externalmetrics.AzureExternalMetricRequest{MetricName: "Messages"}}}},
+		{"infinite weight", []externalmetrics.WeightedSource{{Weight: "+Inf", Request: externalmetrics.AzureExternalMetricRequest{MetricName: "Messages"}}}},
+		{"nested", []externalmetrics.WeightedSource{{Weight: "1", Request: externalmetrics.AzureExternalMetricRequest{Type: externalmetrics.Combined}}}},
+		{"duplicate name", []externalmetrics.WeightedSource{{Name: "a", Request: externalmetrics.AzureExternalMetricRequest{MetricName: "Messages"}}, {Name: "a", Request: externalmetrics.AzureExternalMetricRequest{MetricName: "Messages"}}}},
+	}
+
+	for _, tt := range tests {
+		provider := newProvider(fakeAzureExternalClientFactory{})
+		provider.metricCache.Update("ExternalMetric/default/load", externalmetrics.AzureExternalMetricRequest{
+			Type:    externalmetrics.Combined,
+			Sources: tt.sources,
+		})
+
+		selector, _ := labels.Parse("")
+		_, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "load"})
+
+		if !k8serrors.IsBadRequest(err) {
+			t.Errorf("%s: error after processing got: %v, want bad request", tt.name, err)
+		}
+	}
+}
+
+// metricValuesClientFactory returns a client serving the value for each metric name
+type metricValuesClientFactory map[string]float64
+
+func (f metricValuesClientFactory) GetAzureExternalMetricClient(clientType string) (externalmetrics.AzureExternalMetricClient, error) {
+	return f, nil
+}
+
+func (f metricValuesClientFactory) GetAzureMetric(azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	return externalmetrics.AzureExternalMetricResponse{Total: f[azMetricRequest.MetricName]}, nil
+}
@@ -0,0 +1,470 @@
+package provider
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sync"
+	"time"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	listers "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/listers/metrics/v1alpha2"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+// credentialSecretRefresh is how often the client secret of a credential is read again, so
+// rotated secrets are picked up
+const credentialSecretRefresh = 5 * time.Minute
+
+var secretsResource = schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+// CredentialPool resolves the AzureCredentials referenced by ExternalMetrics.  The source of each
+// credential is kept, so its tokens are reused, until the credential changes, one of its secrets
+// changes when they are watched, or its secrets are due to be read again.
+type CredentialPool struct {
+	lister     listers.AzureCredentialLister
+	synced     cache.InformerSynced
+	kubeClient dynamic.Interface
+	now        func() time.Time
+	// stopCh stops the watches of the secrets, which are only watched when it is set
+	stopCh <-chan struct{}
+
+	mu      sync.Mutex
+	sources map[string]pooledCredential
+	// secretCredentials are the credentials referencing each secret, by namespace/name
+	secretCredentials map[string]map[string]bool
+	// secretVersions are the resource versions of the secrets last read
+	secretVersions map[string]string
+	// secretChanges count the changes of each secret, so a source read while its secret changed
+	// isn't kept
+	secretChanges map[string]int
+	// secretWatches stop the watch of each secret once no credential references it
+	secretWatches map[string]chan struct{}
+}
+
+type pooledCredential struct {
+	resourceVersion string
+	loaded          time.Time
+	source          credentials.Source
+	cloud           string
+}
+
+// NewCredentialPool creates the pool of the AzureCredentials listed by the lister, reading their
+// secrets with the client
+func NewCredentialPool(lister listers.AzureCredentialLister, synced cache.InformerSynced, kubeClient dynamic.Interface) *CredentialPool {
+	return &CredentialPool{
+		lister:     lister,
+		synced:     synced,
+		kubeClient: kubeClient,
+		now:        time.Now,
+		sources:    map[string]pooledCredential{},
+
+		secretCredentials: map[string]map[string]bool{},
+		secretVersions:    map[string]string{},
+		secretChanges:     map[string]int{},
+		secretWatches:     map[string]chan struct{}{},
+	}
+}
+
+// WatchSecrets watches the secrets referenced by credentials until the channel is closed, so a
+// rotated client secret or certificate is used as soon as it changes rather than when it is next
+// read.  The secrets are still read again periodically.
+func (c *CredentialPool) WatchSecrets(stopCh <-chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopCh = stopCh
+	go func() {
+		<-stopCh
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for secretKey, stop := range c.secretWatches {
+			close(stop)
+			delete(c.secretWatches, secretKey)
+		}
+	}()
+}
+
+// CredentialDeleted drops the source of a deleted AzureCredential and stops watching the secrets
+// no other credential references.  It handles the deletions of the AzureCredential informer.
+func (c *CredentialPool) CredentialDeleted(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		glog.Errorf("unable to get the key of deleted azure credential: %v", err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.sources, key)
+	c.release(key, nil)
+}
+
+// source returns the credential source and cloud of the AzureCredential in the namespace
+func (c *CredentialPool) source(namespace string, name string) (credentials.Source, string, error) {
+	if c == nil {
+		return nil, "", errors.NewBadRequest("azure credentials are not enabled")
+	}
+	if c.synced != nil && !c.synced() {
+		return nil, "", errors.NewServiceUnavailable("azure credentials have not been synced")
+	}
+
+	key := fmt.Sprintf("%s/%s", namespace, name)
+	credential, err := c.lister.AzureCredentials(namespace).Get(name)
+	if errors.IsNotFound(err) {
+		c.mu.Lock()
+		delete(c.sources, key)
+		c.release(key, nil)
+		c.mu.Unlock()
+		return nil, "", errors.NewBadRequest(fmt.Sprintf("azure credential %s not found in namespace %s", name, namespace))
+	}
+	if err != nil {
+		return nil, "", errors.NewInternalError(err)
+	}
+
+	var secretNames []string
+	for _, ref := range []*api.SecretKeyRef{credential.Spec.ClientSecretRef, credential.Spec.ClientCertificateRef, credential.Spec.ClientCertificatePasswordRef} {
+		if ref != nil {
+			secretNames = append(secretNames, ref.Name)
+		}
+	}
+
+	c.mu.Lock()
+	pooled, found := c.sources[key]
+	if found && pooled.resourceVersion == credential.ResourceVersion && c.now().Sub(pooled.loaded) < credentialSecretRefresh {
+		c.mu.Unlock()
+		return pooled.source, pooled.cloud, nil
+	}
+	changes := c.watchSecrets(namespace, secretNames, key)
+	// the secrets the credential referenced before it changed are no longer watched for it
+	c.release(key, changes)
+	c.mu.Unlock()
+
+	// the secrets are read without the lock, so a slow api server doesn't hold up other credentials
+	versions := map[string]string{}
+	config := credentials.Config{
+		TenantID: credential.Spec.TenantID,
+		ClientID: credential.Spec.ClientID,
+		Cloud:    credential.Spec.Cloud,
+	}
+	if ref := credential.Spec.ClientSecretRef; ref != nil {
+		config.ClientSecret, err = c.secretValue(namespace, ref.Name, ref.Key, versions)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	if ref := credential.Spec.ClientCertificateRef; ref != nil {
+		config.ClientCertificate, err = c.secretValue(namespace, ref.Name, ref.Key, versions)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	if ref := credential.Spec.ClientCertificatePasswordRef; ref != nil {
+		config.ClientCertificatePassword, err = c.secretValue(namespace, ref.Name, ref.Key, versions)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	// a secret that is unchanged keeps the source and its cached tokens
+	if found && pooled.resourceVersion == credential.ResourceVersion {
+		if named, ok := pooled.source.(*credentials.NamedSource); ok && named.Config() == config {
+			pooled.loaded = c.now()
+			c.keep(key, pooled, changes, versions)
+			return pooled.source, pooled.cloud, nil
+		}
+	}
+
+	source, err := credentials.NewNamedSource(config)
+	if err != nil {
+		return nil, "", errors.NewBadRequest(fmt.Sprintf("azure credential %s is invalid: %v", name, err))
+	}
+
+	glog.V(2).Infof("loaded azure credential %s", key)
+	c.keep(key, pooledCredential{
+		resourceVersion: credential.ResourceVersion,
+		loaded:          c.now(),
+		source:          source,
+		cloud:           credential.Spec.Cloud,
+	}, changes, versions)
+	return source, credential.Spec.Cloud, nil
+}
+
+// secretSource returns the credential source of the service principal or managed identity held
+// by the secret in the namespace, whose keys are named as in the secret of the helm chart
+// (azure-tenant-id, azure-client-id, azure-client-secret, azure-client-certificate and
+// azure-client-certificate-password).  The source is kept like that of an AzureCredential.
+func (c *CredentialPool) secretSource(namespace string, name string) (credentials.Source, error) {
+	if c == nil {
+		return nil, errors.NewBadRequest("secrets of metrics are not enabled")
+	}
+
+	key := fmt.Sprintf("secret:%s/%s", namespace, name)
+	c.mu.Lock()
+	pooled, found := c.sources[key]
+	if found && c.now().Sub(pooled.loaded) < credentialSecretRefresh {
+		c.mu.Unlock()
+		return pooled.source, nil
+	}
+	changes := c.watchSecrets(namespace, []string{name}, key)
+	c.mu.Unlock()
+
+	data, resourceVersion, err := c.secretData(namespace, name)
+	if errors.IsBadRequest(err) {
+		// the secret may have been deleted, so it is no longer watched for the credential
+		c.mu.Lock()
+		delete(c.sources, key)
+		c.release(key, nil)
+		c.mu.Unlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+	versions := map[string]string{fmt.Sprintf("%s/%s", namespace, name): resourceVersion}
+	config := credentials.SecretConfig(data)
+
+	// a secret that is unchanged keeps the source and its cached tokens
+	if found {
+		if named, ok := pooled.source.(*credentials.NamedSource); ok && named.Config() == config {
+			pooled.loaded = c.now()
+			c.keep(key, pooled, changes, versions)
+			return pooled.source, nil
+		}
+	}
+
+	source, err := credentials.NewNamedSource(config)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("credential of secret %s is invalid: %v", name, err))
+	}
+
+	glog.V(2).Infof("loaded azure credential of secret %s/%s", namespace, name)
+	c.keep(key, pooledCredential{loaded: c.now(), source: source}, changes, versions)
+	return source, nil
+}
+
+// keep pools the source of the credential read from the secrets at the versions, unless one of
+// the secrets changed while it was read, in which case it is read again when next used
+func (c *CredentialPool) keep(credentialKey string, pooled pooledCredential, changes map[string]int, versions map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for secretKey, count := range changes {
+		if c.secretChanges[secretKey] != count {
+			glog.V(2).Infof("secret %s changed while azure credential %s was loaded, it is loaded again when next used", secretKey, credentialKey)
+			delete(c.sources, credentialKey)
+			return
+		}
+	}
+	for secretKey, resourceVersion := range versions {
+		c.secretVersions[secretKey] = resourceVersion
+	}
+	c.sources[credentialKey] = pooled
+}
+
+// watchSecrets records that the credential references the secrets and starts watching the
+// secrets not watched yet when secrets are watched.  It returns the count of changes of each
+// secret.  The caller holds the lock.
+func (c *CredentialPool) watchSecrets(namespace string, names []string, credentialKey string) map[string]int {
+	changes := map[string]int{}
+	for _, name := range names {
+		secretKey := fmt.Sprintf("%s/%s", namespace, name)
+		c.watchSecret(namespace, name, credentialKey)
+		changes[secretKey] = c.secretChanges[secretKey]
+	}
+	return changes
+}
+
+// watchSecret records that the credential references the secret and starts watching the secret
+// when secrets are watched.  The caller holds the lock.
+func (c *CredentialPool) watchSecret(namespace string, name string, credentialKey string) {
+	secretKey := fmt.Sprintf("%s/%s", namespace, name)
+	credentialKeys, referenced := c.secretCredentials[secretKey]
+	if !referenced {
+		credentialKeys = map[string]bool{}
+		c.secretCredentials[secretKey] = credentialKeys
+	}
+	credentialKeys[credentialKey] = true
+	if _, watched := c.secretWatches[secretKey]; watched || c.stopCh == nil {
+		return
+	}
+	select {
+	case <-c.stopCh:
+		return
+	default:
+	}
+
+	// only the secret is listed and watched, so other secrets of the namespace aren't held
+	selector := fields.OneTermEqualSelector("metadata.name", name).String()
+	listWatch := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = selector
+			return c.kubeClient.Resource(secretsResource).Namespace(namespace).List(options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+			return c.kubeClient.Resource(secretsResource).Namespace(namespace).Watch(options)
+		},
+	}
+	changed := func(obj interface{}) {
+		if secret, ok := obj.(*unstructured.Unstructured); ok && secret.GetName() == name {
+			c.secretChanged(secretKey, secret.GetResourceVersion())
+		}
+	}
+	_, informer := cache.NewInformer(listWatch, &unstructured.Unstructured{}, 0, cache.ResourceEventHandlerFuncs{
+		AddFunc: changed,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			changed(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			c.secretChanged(secretKey, "")
+		},
+	})
+	stop := make(chan struct{})
+	c.secretWatches[secretKey] = stop
+	glog.V(2).Infof("watching secret %s of azure credentials", secretKey)
+	go informer.Run(stop)
+}
+
+// release records that the credential no longer references its secrets other than the ones it
+// still references, and stops watching the secrets no other credential references.  The caller
+// holds the lock.
+func (c *CredentialPool) release(credentialKey string, referenced map[string]int) {
+	for secretKey, credentialKeys := range c.secretCredentials {
+		if _, found := referenced[secretKey]; found || !credentialKeys[credentialKey] {
+			continue
+		}
+		delete(credentialKeys, credentialKey)
+		if len(credentialKeys) > 0 {
+			continue
+		}
+
+		delete(c.secretCredentials, secretKey)
+		delete(c.secretVersions, secretKey)
+		delete(c.secretChanges, secretKey)
+		if stop, watched := c.secretWatches[secretKey]; watched {
+			glog.V(2).Infof("no azure credential references secret %s, no longer watching it", secretKey)
+			close(stop)
+			delete(c.secretWatches, secretKey)
+		}
+	}
+}
+
+// secretChanged drops the sources of the credentials referencing the secret when it has another
+// resource version than was read, or has been deleted, so they are created again with its new
+// values when next used
+func (c *CredentialPool) secretChanged(secretKey string, resourceVersion string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if read, found := c.secretVersions[secretKey]; found && read == resourceVersion {
+		return
+	}
+	delete(c.secretVersions, secretKey)
+	c.secretChanges[secretKey]++
+	for credentialKey := range c.secretCredentials[secretKey] {
+		if _, found := c.sources[credentialKey]; found {
+			glog.V(2).Infof("secret %s changed, reloading azure credential %s", secretKey, credentialKey)
+			delete(c.sources, credentialKey)
+		}
+	}
+}
+
+// secret reads the key of a secret referenced by a metric, such as the connection string of a
+// Service Bus queue, in the namespace of the metric
+func (c *CredentialPool) secret(namespace string, name string, key string) (string, error) {
+	if c == nil {
+		return "", errors.NewBadRequest("secrets of metrics are not enabled")
+	}
+	return c.secretValue(namespace, name, key, map[string]string{})
+}
+
+// secretValue reads the key of the secret in the namespace, recording the resource version read
+// in versions
+func (c *CredentialPool) secretValue(namespace string, name string, key string, versions map[string]string) (string, error) {
+	data, resourceVersion, err := c.secretData(namespace, name)
+	if err != nil {
+		return "", err
+	}
+	versions[fmt.Sprintf("%s/%s", namespace, name)] = resourceVersion
+	value, found := data[key]
+	if !found {
+		return "", errors.NewBadRequest(fmt.Sprintf("secret %s has no key %s", name, key))
+	}
+	return value, nil
+}
+
+// secretData reads the decoded keys and the resource version of the secret in the namespace
+func (c *CredentialPool) secretData(namespace string, name string) (map[string]string, string, error) {
+	secret, err := c.kubeClient.Resource(secretsResource).Namespace(namespace).Get(name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, "", errors.NewBadRequest(fmt.Sprintf("secret %s not found in namespace %s", name, namespace))
+	}
+	if err != nil {
+		glog.Errorf("unable to read secret %s/%s: %v", namespace, name, err)
+		return nil, "", errors.NewInternalError(fmt.Errorf("unable to read secret %s of namespace %s", name, namespace))
+	}
+
+	encoded, _, err := unstructured.NestedStringMap(secret.Object, "data")
+	if err != nil {
+		return nil, "", errors.NewBadRequest(fmt.Sprintf("secret %s is invalid: %v", name, err))
+	}
+	data := map[string]string{}
+	for key, value := range encoded {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, "", errors.NewBadRequest(fmt.Sprintf("key %s of secret %s is not base64 encoded", key, name))
+		}
+		data[key] = string(decoded)
+	}
+	return data, secret.GetResourceVersion(), nil
+}
+
+// clientFactory returns the factory of the clients of the request, which authenticate with the
+// AzureCredential or secret it references or the adapter's credentials, in the tenant of the
+// request when it overrides the tenant of the credential
+func (p *AzureProvider) clientFactory(namespace string, azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureClientFactory, error) {
+	if azMetricRequest.Credential == "" && azMetricRequest.CredentialSecret == "" && azMetricRequest.TenantID == "" {
+		return p.azureClientFactory, nil
+	}
+
+	factory, ok := p.azureClientFactory.(externalmetrics.CredentialFactory)
+	if !ok {
+		return nil, errors.NewBadRequest("azure credentials are not supported")
+	}
+
+	source, cloud := factory.CredentialSource(), ""
+	if azMetricRequest.Credential != "" {
+		var err error
+		source, cloud, err = p.credentials.source(namespace, azMetricRequest.Credential)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if azMetricRequest.CredentialSecret != "" {
+		var err error
+		source, err = p.credentials.secretSource(namespace, azMetricRequest.CredentialSecret)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if azMetricRequest.TenantID != "" {
+		var err error
+		source, err = p.tenants.source(source, azMetricRequest.TenantID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	credentialFactory, err := factory.WithCredentials(source, cloud)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("azure credential %s is invalid: %v", azMetricRequest.Credential, err))
+	}
+	return credentialFactory, nil
+}
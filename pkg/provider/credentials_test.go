@@ -0,0 +1,448 @@
+package provider
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/fake"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/scheme"
+	informers "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/informers/externalversions"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sclient "k8s.io/client-go/dynamic/fake"
+)
+
+func TestMetricQueriedWithReferencedCredential(t *testing.T) {
+	credential := newAzureCredential("default", "team-a", &api.SecretKeyRef{Name: "team-a-sp", Key: "secret"})
+	factory := &credentialClientFactory{}
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = factory
+	provider.credentials = newTestCredentialPool([]*api.AzureCredential{credential}, newSecret("default", "team-a-sp", "secret", "s3cret"))
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		Credential: "team-a",
+	})
+
+	selector, _ := labels.Parse("")
+	returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if returnList.Items[0].Value.MilliValue() != 15000 {
+		t.Errorf("value = %v, want %v", returnList.Items[0].Value.MilliValue(), 15000)
+	}
+
+	named, ok := factory.source.(*credentials.NamedSource)
+	if !ok {
+		t.Fatalf("source = %T, want the named source of the credential", factory.source)
+	}
+	want := credentials.Config{TenantID: "tenant", ClientID: "client", ClientSecret: "s3cret"}
+	if named.Config() != want {
+		t.Errorf("credential = %+v, want %+v", named.Config(), want)
+	}
+}
+
+func TestMetricQueriedWithCredentialOfSecret(t *testing.T) {
+	secret := newSecret("default", "team-a-sp", "azure-tenant-id", "tenant")
+	unstructured.SetNestedField(secret.Object, base64.StdEncoding.EncodeToString([]byte("client")), "data", "azure-client-id")
+	unstructured.SetNestedField(secret.Object, base64.StdEncoding.EncodeToString([]byte("s3cret")), "data", "azure-client-secret")
+	factory := &credentialClientFactory{}
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = factory
+	provider.credentials = newTestCredentialPool(nil, secret)
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName:       "Messages",
+		CredentialSecret: "team-a-sp",
+	})
+
+	selector, _ := labels.Parse("")
+	if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"}); err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	named, ok := factory.source.(*credentials.NamedSource)
+	if !ok {
+		t.Fatalf("source = %T, want the named source of the secret", factory.source)
+	}
+	want := credentials.Config{TenantID: "tenant", ClientID: "client", ClientSecret: "s3cret"}
+	if named.Config() != want {
+		t.Errorf("credential = %+v, want %+v", named.Config(), want)
+	}
+
+	// the source and its tokens are kept for the next query
+	first := factory.source
+	provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+	if factory.source != first {
+		t.Errorf("source was recreated for an unchanged secret")
+	}
+
+	// a secret of another namespace isn't found
+	if _, err := provider.credentials.secretSource("team-b", "team-a-sp"); !k8serrors.IsBadRequest(err) {
+		t.Errorf("error after processing got: %v, want bad request", err)
+	}
+}
+
+func TestMetricQueriedInOverriddenTenant(t *testing.T) {
+	credential := newAzureCredential("default", "team-a", &api.SecretKeyRef{Name: "team-a-sp", Key: "secret"})
+	adapterSource, _ := credentials.NewNamedSource(credentials.Config{TenantID: "tenant", ClientID: "adapter", ClientSecret: "adapter-secret"})
+
+	var tests = []struct {
+		name       string
+		credential string
+		want       credentials.Config
+	}{
+		{"adapter credentials", "", credentials.Config{TenantID: "other-tenant", ClientID: "adapter", ClientSecret: "adapter-secret"}},
+		{"referenced credential", "team-a", credentials.Config{TenantID: "other-tenant", ClientID: "client", ClientSecret: "s3cret"}},
+	}
+
+	for _, tt := range tests {
+		factory := &credentialClientFactory{adapterSource: adapterSource}
+		provider := newProvider(fakeAzureExternalClientFactory{})
+		provider.azureClientFactory = factory
+		provider.credentials = newTestCredentialPool([]*api.AzureCredential{credential}, newSecret("default", "team-a-sp", "secret", "s3cret"))
+		provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+			MetricName: "Messages",
+			Credential: tt.credential,
+			TenantID:   "other-tenant",
+		})
+
+		selector, _ := labels.Parse("")
+		if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"}); err != nil {
+			t.Fatalf("%s: error after processing got: %v, want nil", tt.name, err)
+		}
+
+		named, ok := factory.source.(*credentials.NamedSource)
+		if !ok {
+			t.Fatalf("%s: source = %T, want a named source in the tenant", tt.name, factory.source)
+		}
+		if named.Config() != tt.want {
+			t.Errorf("%s: credential = %+v, want %+v", tt.name, named.Config(), tt.want)
+		}
+
+		// the source of the tenant is reused
+		first := factory.source
+		provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+		if factory.source != first {
+			t.Errorf("%s: source was recreated for an unchanged credential", tt.name)
+		}
+	}
+}
+
+func TestManagedIdentityCantOverrideTenant(t *testing.T) {
+	adapterSource, _ := credentials.NewNamedSource(credentials.Config{ClientID: "identity"})
+	factory := &credentialClientFactory{adapterSource: adapterSource}
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = factory
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		TenantID:   "other-tenant",
+	})
+
+	selector, _ := labels.Parse("")
+	if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"}); !k8serrors.IsBadRequest(err) {
+		t.Errorf("error after processing got: %v, want bad request", err)
+	}
+}
+
+func TestCredentialSourceIsReusedUntilCredentialChanges(t *testing.T) {
+	credential := newAzureCredential("default", "team-a", nil)
+	pool := newTestCredentialPool([]*api.AzureCredential{credential})
+
+	first, _, err := pool.source("default", "team-a")
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	second, _, _ := pool.source("default", "team-a")
+	if first != second {
+		t.Errorf("source was recreated for an unchanged credential")
+	}
+
+	updated := credential.DeepCopy()
+	updated.ResourceVersion = "2"
+	updated.Spec.ClientID = "other-client"
+	pool.lister = newTestCredentialPool([]*api.AzureCredential{updated}).lister
+
+	third, _, _ := pool.source("default", "team-a")
+	if third == first || third.Value(credentials.ClientID) != "other-client" {
+		t.Errorf("source client id = %v, want the updated credential", third.Value(credentials.ClientID))
+	}
+}
+
+func TestInvalidCredentialGetsBadRequest(t *testing.T) {
+	var tests = []struct {
+		name       string
+		credential string
+		secrets    []runtime.Object
+	}{
+		{"unknown credential", "team-b", nil},
+		{"missing secret", "team-a", nil},
+		{"missing key", "team-a", []runtime.Object{newSecret("default", "team-a-sp", "other", "s3cret")}},
+	}
+
+	credential := newAzureCredential("default", "team-a", &api.SecretKeyRef{Name: "team-a-sp", Key: "secret"})
+	for _, tt := range tests {
+		pool := newTestCredentialPool([]*api.AzureCredential{credential}, tt.secrets...)
+		if _, _, err := pool.source("default", tt.credential); !k8serrors.IsBadRequest(err) {
+			t.Errorf("%s: error after processing got: %v, want bad request", tt.name, err)
+		}
+	}
+}
+
+func TestCredentialCertificateReadFromSecret(t *testing.T) {
+	credential := newAzureCredential("default", "team-a", nil)
+	credential.Spec.ClientCertificateRef = &api.SecretKeyRef{Name: "team-a-cert", Key: "certificate"}
+
+	pool := newTestCredentialPool([]*api.AzureCredential{credential})
+	if _, _, err := pool.source("default", "team-a"); !k8serrors.IsBadRequest(err) {
+		t.Errorf("missing secret: error after processing got: %v, want bad request", err)
+	}
+
+	// the certificate is decoded when the source is created
+	pool = newTestCredentialPool([]*api.AzureCredential{credential}, newSecret("default", "team-a-cert", "certificate", "not a certificate"))
+	_, _, err := pool.source("default", "team-a")
+	if !k8serrors.IsBadRequest(err) || !strings.Contains(err.Error(), "pkcs12") {
+		t.Errorf("invalid certificate: error after processing got: %v, want bad request decoding the certificate", err)
+	}
+}
+
+func TestCredentialOfAnotherNamespaceNotFound(t *testing.T) {
+	pool := newTestCredentialPool([]*api.AzureCredential{newAzureCredential("team-a", "shared", nil)})
+
+	if _, _, err := pool.source("team-b", "shared"); !k8serrors.IsBadRequest(err) {
+		t.Errorf("error after processing got: %v, want bad request", err)
+	}
+}
+
+func TestServiceBusQueueConnectionStringReadFromSecret(t *testing.T) {
+	client := newFakeExternalMetricClient(externalmetrics.AzureExternalMetricResponse{Total: 12}, nil)
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = newFakeClientFactory(client)
+	provider.credentials = newTestCredentialPool(nil, newSecret("default", "orders-sb", "connectionString", "Endpoint=sb://orders.servicebus.windows.net/;SharedAccessKeyName=listen;SharedAccessKey=key"))
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "activeMessageCount",
+		Type:       externalmetrics.ServiceBusQueue,
+		ServiceBusQueue: externalmetrics.ServiceBusQueueDefinition{
+			Queue:                  "orders",
+			ConnectionStringSecret: "orders-sb",
+			ConnectionStringKey:    "connectionString",
+		},
+	})
+
+	selector, _ := labels.Parse("")
+	if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"}); err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if client.GetAzureMetricCallCount() != 1 || !strings.HasPrefix(client.GetAzureMetricArgsForCall(0).ServiceBusQueue.ConnectionString, "Endpoint=sb://orders") {
+		t.Fatalf("requests = %+v, want one request with the connection string of the secret", client.Invocations())
+	}
+	cached, _ := provider.metricCache.GetAzureExternalMetricRequest("default", "queue")
+	if cached.ServiceBusQueue.ConnectionString != "" {
+		t.Errorf("cached connection string = %q, want it left out of the cache", cached.ServiceBusQueue.ConnectionString)
+	}
+}
+
+func TestStorageQueueConnectionStringReadFromSecret(t *testing.T) {
+	client := newFakeExternalMetricClient(externalmetrics.AzureExternalMetricResponse{Total: 4}, nil)
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = newFakeClientFactory(client)
+	provider.credentials = newTestCredentialPool(nil, newSecret("default", "orders-storage", "connectionString", "AccountName=orders;AccountKey=a2V5"))
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "ApproximateMessagesCount",
+		Type:       externalmetrics.StorageQueueLength,
+		StorageQueue: externalmetrics.StorageQueueDefinition{
+			Queue:                  "orders",
+			ConnectionStringSecret: "orders-storage",
+			ConnectionStringKey:    "connectionString",
+		},
+	})
+
+	selector, _ := labels.Parse("")
+	if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"}); err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if client.GetAzureMetricCallCount() != 1 || client.GetAzureMetricArgsForCall(0).StorageQueue.ConnectionString != "AccountName=orders;AccountKey=a2V5" {
+		t.Fatalf("requests = %+v, want one request with the connection string of the secret", client.Invocations())
+	}
+	cached, _ := provider.metricCache.GetAzureExternalMetricRequest("default", "queue")
+	if cached.StorageQueue.ConnectionString != "" {
+		t.Errorf("cached connection string = %q, want it left out of the cache", cached.StorageQueue.ConnectionString)
+	}
+}
+
+func TestEventHubConnectionStringsReadFromSecrets(t *testing.T) {
+	client := newFakeExternalMetricClient(externalmetrics.AzureExternalMetricResponse{Total: 25}, nil)
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = newFakeClientFactory(client)
+	provider.credentials = newTestCredentialPool(nil,
+		newSecret("default", "orders-eh", "connectionString", "Endpoint=sb://orders-eh.servicebus.windows.net/;SharedAccessKeyName=manage;SharedAccessKey=key"),
+		newSecret("default", "checkpoints", "connectionString", "AccountName=checkpoints;AccountKey=a2V5"))
+	provider.metricCache.Update("ExternalMetric/default/lag", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "lag",
+		Type:       externalmetrics.EventHub,
+		EventHub: externalmetrics.EventHubDefinition{
+			EventHub:                      "orders",
+			ConnectionStringSecret:        "orders-eh",
+			ConnectionStringKey:           "connectionString",
+			Container:                     "checkpoints",
+			StorageConnectionStringSecret: "checkpoints",
+			StorageConnectionStringKey:    "connectionString",
+		},
+	})
+
+	selector, _ := labels.Parse("")
+	if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "lag"}); err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if client.GetAzureMetricCallCount() != 1 {
+		t.Fatalf("requests = %+v, want one request", client.Invocations())
+	}
+	if hub := client.GetAzureMetricArgsForCall(0).EventHub; !strings.HasPrefix(hub.ConnectionString, "Endpoint=sb://orders-eh") || hub.StorageConnectionString != "AccountName=checkpoints;AccountKey=a2V5" {
+		t.Fatalf("event hub = %+v, want the connection strings of the secrets", hub)
+	}
+	cached, _ := provider.metricCache.GetAzureExternalMetricRequest("default", "lag")
+	if cached.EventHub.ConnectionString != "" || cached.EventHub.StorageConnectionString != "" {
+		t.Errorf("cached connection strings = %q %q, want them left out of the cache", cached.EventHub.ConnectionString, cached.EventHub.StorageConnectionString)
+	}
+}
+
+func newTestCredentialPool(azureCredentials []*api.AzureCredential, secrets ...runtime.Object) *CredentialPool {
+	i := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	for _, credential := range azureCredentials {
+		i.Azure().V1alpha2().AzureCredentials().Informer().GetIndexer().Add(credential)
+	}
+
+	return NewCredentialPool(i.Azure().V1alpha2().AzureCredentials().Lister(), nil, k8sclient.NewSimpleDynamicClient(scheme.Scheme, secrets...))
+}
+
+func newAzureCredential(namespace, name string, secretRef *api.SecretKeyRef) *api.AzureCredential {
+	return &api.AzureCredential{
+		TypeMeta: metav1.TypeMeta{APIVersion: api.SchemeGroupVersion.String(), Kind: "AzureCredential"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			ResourceVersion: "1",
+		},
+		Spec: api.AzureCredentialSpec{
+			TenantID:        "tenant",
+			ClientID:        "client",
+			ClientSecretRef: secretRef,
+		},
+	}
+}
+
+func newSecret(namespace, name, key, value string) *unstructured.Unstructured {
+	secret := newUnstructured("v1", "Secret", namespace, name)
+	unstructured.SetNestedField(secret.Object, base64.StdEncoding.EncodeToString([]byte(value)), "data", key)
+	return secret
+}
+
+type credentialClientFactory struct {
+	adapterSource credentials.Source
+	source        credentials.Source
+}
+
+func (f *credentialClientFactory) GetAzureExternalMetricClient(clientType string) (externalmetrics.AzureExternalMetricClient, error) {
+	return nil, k8serrors.NewBadRequest("queried without the credential")
+}
+
+func (f *credentialClientFactory) WithCredentials(source credentials.Source, cloud string) (externalmetrics.AzureClientFactory, error) {
+	f.source = source
+	return fakeAzureExternalClientFactory{}, nil
+}
+
+func (f *credentialClientFactory) CredentialSource() credentials.Source {
+	return f.adapterSource
+}
+
+func TestCredentialReloadedWhenWatchedSecretChanges(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	credential := newAzureCredential("default", "team-a", &api.SecretKeyRef{Name: "team-a-sp", Key: "secret"})
+	pool := newTestCredentialPool([]*api.AzureCredential{credential}, newSecret("default", "team-a-sp", "secret", "s3cret"))
+	pool.WatchSecrets(stopCh)
+
+	first, _, err := pool.source("default", "team-a")
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	rotated := newSecret("default", "team-a-sp", "secret", "rotated")
+	rotated.SetResourceVersion("2")
+	if _, err := pool.kubeClient.Resource(secretsResource).Namespace("default").Update(rotated, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("unable to update secret: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		source, _, err := pool.source("default", "team-a")
+		if err != nil {
+			t.Fatalf("error after processing got: %v, want nil", err)
+		}
+		if source != first {
+			if secret := source.(*credentials.NamedSource).Config().ClientSecret; secret != "rotated" {
+				t.Errorf("client secret = %v, want %v", secret, "rotated")
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("credential was not reloaded after its secret changed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSecretNoLongerWatchedOnceCredentialsStopReferencingIt(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+
+	credential := newAzureCredential("default", "team-a", &api.SecretKeyRef{Name: "team-a-sp", Key: "secret"})
+	pool := newTestCredentialPool([]*api.AzureCredential{credential}, newSecret("default", "team-a-sp", "secret", "s3cret"), newSecret("default", "team-a-rotated", "secret", "rotated"))
+	pool.WatchSecrets(stopCh)
+
+	if _, _, err := pool.source("default", "team-a"); err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	stop, watched := pool.secretWatches["default/team-a-sp"]
+	if !watched {
+		t.Fatalf("secret of the credential is not watched")
+	}
+
+	// the credential references another secret
+	updated := credential.DeepCopy()
+	updated.ResourceVersion = "2"
+	updated.Spec.ClientSecretRef = &api.SecretKeyRef{Name: "team-a-rotated", Key: "secret"}
+	pool.lister = newTestCredentialPool([]*api.AzureCredential{updated}).lister
+	if _, _, err := pool.source("default", "team-a"); err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if _, watched := pool.secretWatches["default/team-a-sp"]; watched {
+		t.Errorf("secret no longer referenced is still watched")
+	}
+	select {
+	case <-stop:
+	default:
+		t.Errorf("watch of the secret no longer referenced was not stopped")
+	}
+
+	pool.CredentialDeleted(updated)
+	if _, found := pool.sources["default/team-a"]; found {
+		t.Errorf("source of the deleted credential is kept")
+	}
+	if len(pool.secretWatches) != 0 || len(pool.secretCredentials) != 0 {
+		t.Errorf("secrets watched = %v, want none once the credential is deleted", pool.secretWatches)
+	}
+}
@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+)
+
+// deletionGrace keeps serving the last value of an ExternalMetric for a grace period after it is
+// deleted, so an accidental deletion doesn't zero the scale of workloads still autoscaled on it
+type deletionGrace struct {
+	period time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	values    map[string]gracedValue
+	lastPrune time.Time
+}
+
+// gracedValue is the value last served for a metric, the label selector it was served for and when
+type gracedValue struct {
+	selector string
+	served   *external_metrics.ExternalMetricValueList
+	recorded time.Time
+}
+
+func newDeletionGrace(period time.Duration) *deletionGrace {
+	return &deletionGrace{
+		period: period,
+		now:    time.Now,
+		values: map[string]gracedValue{},
+	}
+}
+
+// record keeps the value served for the metric while it is defined.  Only the value of the
+// selector the metric was last served for is kept.
+func (g *deletionGrace) record(key string, selector string, served *external_metrics.ExternalMetricValueList) {
+	if g == nil || g.period <= 0 {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	g.values[key] = gracedValue{selector: selector, served: served, recorded: now}
+	g.prune(now)
+}
+
+// prune drops the values recorded longer ago than the grace period, at most once per period.  A
+// metric is deleted after its value was last recorded, so its grace period has ended by then.  It
+// must be called with the mutex held.
+func (g *deletionGrace) prune(now time.Time) {
+	if now.Sub(g.lastPrune) < g.period {
+		return
+	}
+	g.lastPrune = now
+
+	for key, value := range g.values {
+		if now.Sub(value.recorded) >= g.period {
+			delete(g.values, key)
+		}
+	}
+}
+
+// served returns the value last served for the metric and selector if the metric was deleted
+// within the grace period.  Once the period has passed the value is dropped.
+func (g *deletionGrace) served(key string, selector string, removedAt time.Time) (*external_metrics.ExternalMetricValueList, bool) {
+	if g == nil || g.period <= 0 {
+		return nil, false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	value, found := g.values[key]
+	if !found {
+		return nil, false
+	}
+
+	deadline := removedAt.Add(g.period)
+	if !g.now().Before(deadline) {
+		glog.Warningf("the grace period of deleted external metric %s has ended, it is no longer served", key)
+		delete(g.values, key)
+		return nil, false
+	}
+	if value.selector != selector {
+		return nil, false
+	}
+
+	glog.Warningf("external metric %s was deleted at %s, serving its last value until %s", key, removedAt.Format(time.RFC3339), deadline.Format(time.RFC3339))
+	return value.served, true
+}
@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestDeletedMetricServedDuringGracePeriod(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.deletionGrace = newDeletionGrace(time.Hour)
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{MetricName: "Messages"})
+
+	selector, _ := labels.Parse("")
+	info := k8sprovider.ExternalMetricInfo{Metric: "queue"}
+	if _, err := provider.GetExternalMetric("default", selector, info); err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	// the fake client would serve the selector as a request once the metric is deleted
+	provider.azureClientFactory = metricValuesClientFactory{}
+	provider.metricCache.Remove("ExternalMetric/default/queue")
+	returnList, err := provider.GetExternalMetric("default", selector, info)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if returnList.Items[0].Value.Value() != 15 {
+		t.Errorf("externalMetric.Value = %v, want there %v", returnList.Items[0].Value.Value(), 15)
+	}
+}
+
+func TestDeletedMetricNotServedAfterGracePeriod(t *testing.T) {
+	removedAt := time.Date(2019, 3, 10, 12, 0, 0, 0, time.UTC)
+	grace := newDeletionGrace(time.Hour)
+	grace.now = func() time.Time { return removedAt }
+	grace.record("default/queue", "", nil)
+
+	grace.now = func() time.Time { return removedAt.Add(59 * time.Minute) }
+	if _, found := grace.served("default/queue", "", removedAt); !found {
+		t.Errorf("served within the grace period got not found, want found")
+	}
+	if _, found := grace.served("default/queue", "app=other", removedAt); found {
+		t.Errorf("served for another selector got found, want not found")
+	}
+
+	grace.now = func() time.Time { return removedAt.Add(time.Hour) }
+	if _, found := grace.served("default/queue", "", removedAt); found {
+		t.Errorf("served after the grace period got found, want not found")
+	}
+	if _, found := grace.values["default/queue"]; found {
+		t.Errorf("value kept after the grace period, want it dropped")
+	}
+}
+
+func TestDeletedMetricNotServedWithoutGracePeriod(t *testing.T) {
+	grace := newDeletionGrace(0)
+	grace.record("default/queue", "", nil)
+
+	if _, found := grace.served("default/queue", "", time.Now()); found {
+		t.Errorf("served without a grace period got found, want not found")
+	}
+}
+
+func TestDeletionGraceKeepsOneValuePerMetric(t *testing.T) {
+	now := time.Date(2019, 3, 10, 12, 0, 0, 0, time.UTC)
+	grace := newDeletionGrace(time.Hour)
+	grace.now = func() time.Time { return now }
+
+	grace.record("default/queue", "app=a", nil)
+	grace.record("default/queue", "app=b", nil)
+	grace.record("default/stale", "", nil)
+	if len(grace.values) != 2 {
+		t.Errorf("values = %v, want one per metric", len(grace.values))
+	}
+
+	now = now.Add(time.Hour)
+	grace.record("default/queue", "app=b", nil)
+	if _, found := grace.values["default/stale"]; found {
+		t.Errorf("value not recorded within the grace period kept, want it dropped")
+	}
+}
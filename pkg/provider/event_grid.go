@@ -0,0 +1,218 @@
+package provider
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/metriccache"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// EventGridPath is the path Azure Event Grid subscriptions push events to, as
+// <path><namespace>/<name>?key=<key> for the ExternalMetric of type eventgrid named by the path.
+// Event Grid can't authenticate to the metrics apis, so the path is served on its own listener and
+// events are authenticated by the key of the metric.
+const EventGridPath = "/eventgrid/"
+
+const (
+	// maxEventGridBody limits the size of a pushed batch of events, which Event Grid keeps under 1MB
+	maxEventGridBody = 1 << 20
+	// eventGridValidationEvent is the event type of the handshake of a new subscription
+	eventGridValidationEvent = "Microsoft.EventGrid.SubscriptionValidationEvent"
+)
+
+// EventGrid stores the values of the events Azure Event Grid pushes to ExternalMetrics of type
+// eventgrid in the metric cache, where they are served from
+type EventGrid struct {
+	metricCache *metriccache.MetricCache
+	credentials *CredentialPool
+	now         func() time.Time
+}
+
+// eventGridEvent is an event of the Event Grid schema
+type eventGridEvent struct {
+	ID        string          `json:"id"`
+	EventType string          `json:"eventType"`
+	EventTime string          `json:"eventTime"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewEventGrid creates the endpoint Event Grid pushes events to, which reads the keys of metrics
+// from their secrets
+func NewEventGrid(metricCache *metriccache.MetricCache, credentials *CredentialPool) *EventGrid {
+	return &EventGrid{
+		metricCache: metricCache,
+		credentials: credentials,
+		now:         time.Now,
+	}
+}
+
+// ServeHTTP answers the validation handshake of a subscription, and stores the value of the latest
+// event of a notification, for the metric named by the path
+func (g *EventGrid) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, EventGridPath), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		http.Error(w, fmt.Sprintf("path must be %s<namespace>/<name>", EventGridPath), http.StatusNotFound)
+		return
+	}
+	namespace, name := parts[0], parts[1]
+
+	request, found := g.metricCache.GetAzureExternalMetricRequest(namespace, name)
+	if !found || request.Type != externalmetrics.EventGrid {
+		http.Error(w, fmt.Sprintf("no external metric of type eventgrid %s in namespace %s", name, namespace), http.StatusNotFound)
+		return
+	}
+	key, err := g.credentials.secret(namespace, request.EventGrid.KeySecret, request.EventGrid.KeyKey)
+	if err != nil {
+		glog.Errorf("unable to read the event grid key of %s/%s: %v", namespace, name, err)
+		http.Error(w, "unable to read the key of the metric", http.StatusInternalServerError)
+		return
+	}
+	if key == "" || subtle.ConstantTimeCompare([]byte(req.URL.Query().Get("key")), []byte(key)) != 1 {
+		http.Error(w, "the key of the metric is required", http.StatusUnauthorized)
+		return
+	}
+
+	events := []eventGridEvent{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxEventGridBody)).Decode(&events); err != nil {
+		http.Error(w, fmt.Sprintf("unable to parse events: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	switch req.Header.Get("aeg-event-type") {
+	case "SubscriptionValidation":
+		g.validate(w, namespace, name, events)
+		return
+	case "Notification":
+	default:
+		// such as the deletion of the subscription, which needs no answer
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if len(events) == 0 {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	path := request.EventGrid.ValuePath()
+	var latest metriccache.PushedValue
+	for i, event := range events {
+		value, err := eventGridValue(event.Data, path)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("event %s: %v", event.ID, err), http.StatusBadRequest)
+			return
+		}
+
+		// values are ordered by the time of their events, which can't be later than now so a
+		// sender's clock can't keep its value served
+		timestamp := g.now()
+		if eventTime, err := time.Parse(time.RFC3339Nano, event.EventTime); err == nil && eventTime.Before(timestamp) {
+			timestamp = eventTime
+		}
+		if i == 0 || !timestamp.Before(latest.Timestamp) {
+			latest = metriccache.PushedValue{Value: value, Timestamp: timestamp}
+		}
+	}
+	if !g.metricCache.UpdatePushedValue(namespace, name, latest) {
+		http.Error(w, fmt.Sprintf("no external metric of type eventgrid %s in namespace %s", name, namespace), http.StatusNotFound)
+		return
+	}
+	glog.V(2).Infof("event grid pushed %d events to %s/%s", len(events), namespace, name)
+	w.WriteHeader(http.StatusOK)
+}
+
+// validate answers the handshake of a new subscription with its validation code
+func (g *EventGrid) validate(w http.ResponseWriter, namespace string, name string, events []eventGridEvent) {
+	for _, event := range events {
+		if event.EventType != eventGridValidationEvent {
+			continue
+		}
+		validation := struct {
+			ValidationCode string `json:"validationCode"`
+		}{}
+		if err := json.Unmarshal(event.Data, &validation); err != nil || validation.ValidationCode == "" {
+			http.Error(w, "validation event has no validation code", http.StatusBadRequest)
+			return
+		}
+
+		glog.V(0).Infof("validated event grid subscription of %s/%s", namespace, name)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"validationResponse": validation.ValidationCode})
+		return
+	}
+	http.Error(w, "no validation event", http.StatusBadRequest)
+}
+
+// eventGridValue reads the value at the path of field names in the data of an event, a json number
+// or a string holding one
+func eventGridValue(data json.RawMessage, path []string) (float64, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return 0, fmt.Errorf("unable to parse data: %v", err)
+	}
+	for _, field := range path {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return 0, fmt.Errorf("data has no field %s", strings.Join(path, "."))
+		}
+		if value, ok = object[field]; !ok {
+			return 0, fmt.Errorf("data has no field %s", strings.Join(path, "."))
+		}
+	}
+
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case string:
+		parsed, err := strconv.ParseFloat(v, 64)
+		if err != nil || math.IsNaN(parsed) || math.IsInf(parsed, 0) {
+			return 0, fmt.Errorf("field %s is not a number", strings.Join(path, "."))
+		}
+		return parsed, nil
+	}
+	return 0, fmt.Errorf("field %s is not a number", strings.Join(path, "."))
+}
+
+// getEventGridMetric serves the latest value pushed by Event Grid to the ExternalMetric, unless it
+// is older than the max age of the metric
+func (p *AzureProvider) getEventGridMetric(namespace string, metricName string, azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	maxAge, err := azMetricRequest.EventGrid.ParseMaxAge()
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest(err.Error())
+	}
+
+	// an activity metric is served from the value pushed to the ExternalMetric it is named after
+	name := metricName
+	if activityName, ok := activityMetricName(metricName); ok {
+		if request, found := p.metricCache.GetAzureExternalMetricRequest(namespace, activityName); found && request.Activity.Enabled {
+			name = activityName
+		}
+	}
+
+	pushed, found := p.metricCache.GetPushedValue(namespace, name)
+	if !found {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewServiceUnavailable(fmt.Sprintf("no event has been pushed to %s", name))
+	}
+	if maxAge > 0 && time.Since(pushed.Timestamp) > maxAge {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewServiceUnavailable(fmt.Sprintf("the latest event pushed to %s is older than %s", name, maxAge))
+	}
+
+	return externalmetrics.AzureExternalMetricResponse{
+		Total: pushed.Value,
+		Raw:   []string{fmt.Sprintf("%v pushed at %s", pushed.Value, pushed.Timestamp.Format(time.RFC3339))},
+	}, nil
+}
@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestEventGridSubscriptionIsValidated(t *testing.T) {
+	provider, eventGrid := newEventGridProvider(externalmetrics.EventGridDefinition{KeySecret: "event-grid", KeyKey: "key"})
+
+	recorder := postEventGrid(eventGrid, "/eventgrid/default/backlog?key=s3cret", "SubscriptionValidation",
+		`[{"id":"1","eventType":"Microsoft.EventGrid.SubscriptionValidationEvent","data":{"validationCode":"512d38b6-c7b8-40c8-89fe-f46f9e9622b6"}}]`)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %v, want %v", recorder.Code, http.StatusOK)
+	}
+	response := map[string]string{}
+	json.Unmarshal(recorder.Body.Bytes(), &response)
+	if response["validationResponse"] != "512d38b6-c7b8-40c8-89fe-f46f9e9622b6" {
+		t.Errorf("validationResponse = %v, want the validation code", response["validationResponse"])
+	}
+	if _, found := provider.metricCache.GetPushedValue("default", "backlog"); found {
+		t.Errorf("pushed value found after validation, want none")
+	}
+}
+
+func TestEventGridLatestEventServed(t *testing.T) {
+	provider, eventGrid := newEventGridProvider(externalmetrics.EventGridDefinition{KeySecret: "event-grid", KeyKey: "key", Value: "metrics.backlog"})
+	now := time.Now().UTC()
+
+	recorder := postEventGrid(eventGrid, "/eventgrid/default/backlog?key=s3cret", "Notification",
+		`[{"id":"2","eventTime":"`+now.Add(-time.Second).Format(time.RFC3339Nano)+`","data":{"metrics":{"backlog":"17"}}},`+
+			`{"id":"1","eventTime":"`+now.Add(-time.Minute).Format(time.RFC3339Nano)+`","data":{"metrics":{"backlog":40}}}]`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %v, want %v: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+
+	selector, _ := labels.Parse("")
+	info := k8sprovider.ExternalMetricInfo{Metric: "backlog"}
+	returnList, err := provider.GetExternalMetric("default", selector, info)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if len(returnList.Items) != 1 || returnList.Items[0].Value.MilliValue() != 17000 {
+		t.Errorf("returnList.Items = %v, want the value of the latest event", returnList.Items)
+	}
+}
+
+func TestEventGridValueOlderThanMaxAgeNotServed(t *testing.T) {
+	provider, eventGrid := newEventGridProvider(externalmetrics.EventGridDefinition{KeySecret: "event-grid", KeyKey: "key", MaxAge: "10m"})
+
+	recorder := postEventGrid(eventGrid, "/eventgrid/default/backlog?key=s3cret", "Notification",
+		`[{"id":"1","eventTime":"`+time.Now().Add(-time.Hour).Format(time.RFC3339)+`","data":{"value":40}}]`)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %v, want %v: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+
+	selector, _ := labels.Parse("")
+	_, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "backlog"})
+
+	if !errors.IsServiceUnavailable(err) {
+		t.Errorf("error after processing got: %v, want service unavailable", err)
+	}
+}
+
+func TestEventGridRequestsRejected(t *testing.T) {
+	var tests = []struct {
+		name string
+		path string
+		body string
+		code int
+	}{
+		{"no key", "/eventgrid/default/backlog", `[{"id":"1","data":{"value":1}}]`, http.StatusUnauthorized},
+		{"wrong key", "/eventgrid/default/backlog?key=guess", `[{"id":"1","data":{"value":1}}]`, http.StatusUnauthorized},
+		{"unknown metric", "/eventgrid/default/other?key=s3cret", `[{"id":"1","data":{"value":1}}]`, http.StatusNotFound},
+		{"no value", "/eventgrid/default/backlog?key=s3cret", `[{"id":"1","data":{"count":1}}]`, http.StatusBadRequest},
+		{"not a number", "/eventgrid/default/backlog?key=s3cret", `[{"id":"1","data":{"value":"many"}}]`, http.StatusBadRequest},
+	}
+
+	provider, eventGrid := newEventGridProvider(externalmetrics.EventGridDefinition{KeySecret: "event-grid", KeyKey: "key"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := postEventGrid(eventGrid, tt.path, "Notification", tt.body)
+			if recorder.Code != tt.code {
+				t.Errorf("status = %v, want %v", recorder.Code, tt.code)
+			}
+		})
+	}
+	if _, found := provider.metricCache.GetPushedValue("default", "backlog"); found {
+		t.Errorf("pushed value found after rejected requests, want none")
+	}
+}
+
+func newEventGridProvider(definition externalmetrics.EventGridDefinition) (AzureProvider, *EventGrid) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.metricCache.Update("ExternalMetric/default/backlog", externalmetrics.AzureExternalMetricRequest{
+		Type:      externalmetrics.EventGrid,
+		EventGrid: definition,
+	})
+	credentials := newTestCredentialPool(nil, newSecret("default", "event-grid", "key", "s3cret"))
+	return provider, NewEventGrid(provider.metricCache, credentials)
+}
+
+func postEventGrid(eventGrid *EventGrid, path string, eventType string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set("aeg-event-type", eventType)
+	recorder := httptest.NewRecorder()
+	eventGrid.ServeHTTP(recorder, req)
+	return recorder
+}
@@ -0,0 +1,88 @@
+package provider
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/expression"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ValueVariable holds the queried value, or the value of the series, in an expression
+const ValueVariable = "value"
+
+// builtinVariables can't be used to name the sources of a combined metric
+var builtinVariables = map[string]bool{ValueVariable: true, "hour": true, "minute": true, "weekday": true, "replicas": true}
+
+// evaluateExpression serves the value of the expression in place of the value and each series of
+// the metric.  The named sources of a combined metric are read by name.
+func (p *AzureProvider) evaluateExpression(namespace string, metricName string, definition externalmetrics.ExpressionDefinition, metricValue externalmetrics.AzureExternalMetricResponse) (externalmetrics.AzureExternalMetricResponse, error) {
+	parsed, err := expression.Parse(definition.Value)
+	if err != nil {
+		return metricValue, errors.NewBadRequest(fmt.Sprintf("invalid expression of %s: %v", metricName, err))
+	}
+
+	variables, err := expressionVariables(definition, time.Now())
+	if err != nil {
+		return metricValue, errors.NewBadRequest(err.Error())
+	}
+	for name, value := range metricValue.Sources {
+		if builtinVariables[name] {
+			return metricValue, errors.NewBadRequest(fmt.Sprintf("the source name %s is reserved for the expression", name))
+		}
+		variables[name] = value
+	}
+	if definition.ScaleTarget.Enabled {
+		replicas, err := p.currentReplicas(namespace, definition.ScaleTarget)
+		if err != nil {
+			return metricValue, err
+		}
+		variables["replicas"] = float64(replicas)
+	}
+
+	variables[ValueVariable] = metricValue.Total
+	total, err := parsed.Eval(variables)
+	if err != nil {
+		return metricValue, errors.NewBadRequest(fmt.Sprintf("unable to evaluate the expression of %s: %v", metricName, err))
+	}
+	evaluated := externalmetrics.AzureExternalMetricResponse{
+		Total: total,
+		Raw:   metricValue.Raw,
+	}
+	for _, series := range metricValue.Series {
+		variables[ValueVariable] = series.Value
+		value, err := parsed.Eval(variables)
+		if err != nil {
+			return metricValue, errors.NewBadRequest(fmt.Sprintf("unable to evaluate the expression of %s for %v: %v", metricName, series.Labels, err))
+		}
+		evaluated.Series = append(evaluated.Series, externalmetrics.MetricSeries{
+			Labels: series.Labels,
+			Value:  value,
+		})
+	}
+
+	glog.V(2).Infof("expression of %s evaluated to %f", metricName, evaluated.Total)
+	return evaluated, nil
+}
+
+// expressionVariables returns the time of day variables at t: hour, minute and weekday, where
+// Sunday is 0, in the time zone of the expression
+func expressionVariables(definition externalmetrics.ExpressionDefinition, t time.Time) (map[string]float64, error) {
+	location := time.UTC
+	if definition.TimeZone != "" {
+		var err error
+		location, err = time.LoadLocation(definition.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expression time zone '%s': %v", definition.TimeZone, err)
+		}
+	}
+
+	t = t.In(location)
+	return map[string]float64{
+		"hour":    float64(t.Hour()),
+		"minute":  float64(t.Minute()),
+		"weekday": float64(t.Weekday()),
+	}, nil
+}
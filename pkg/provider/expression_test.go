@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestExpressionOverNamedSources(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = metricValuesClientFactory{"Messages": 100, "Latency": 20}
+	provider.metricCache.Update("ExternalMetric/default/load", externalmetrics.AzureExternalMetricRequest{
+		Type: externalmetrics.Combined,
+		Sources: []externalmetrics.WeightedSource{
+			{Name: "backlog", Request: externalmetrics.AzureExternalMetricRequest{Type: externalmetrics.ServiceBusSubscription, MetricName: "Messages"}},
+			{Name: "latency", Request: externalmetrics.AzureExternalMetricRequest{Type: externalmetrics.Monitor, MetricName: "Latency"}},
+		},
+		Expression: externalmetrics.ExpressionDefinition{Value: "latency > 10 ? max(backlog, value / 2) : backlog / 2"},
+	})
+
+	selector, _ := labels.Parse("")
+	returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "load"})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if returnList.Items[0].Value.Value() != 100 {
+		t.Errorf("externalMetric.Value = %v, want there %v", returnList.Items[0].Value.Value(), 100)
+	}
+}
+
+func TestExpressionOfEachSeriesWithReplicas(t *testing.T) {
+	provider := newPerReplicaProvider(newDeployment("default", "consumer", 5))
+	provider.metricCache.Update("ExternalMetric/default/queues", externalmetrics.AzureExternalMetricRequest{
+		MetricName:     "ActiveMessages",
+		SplitDimension: "EntityName",
+		Expression: externalmetrics.ExpressionDefinition{
+			Value:       "value * 2 / replicas",
+			ScaleTarget: externalmetrics.PerReplicaDefinition{Enabled: true, Name: "consumer"},
+		},
+	})
+
+	selector, _ := labels.Parse("")
+	returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queues"})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	want := map[string]int64{"orders": 4, "payments": 2}
+	for _, item := range returnList.Items {
+		entity := item.MetricLabels["entityname"]
+		if item.Value.Value() != want[entity] {
+			t.Errorf("%s value = %v, want there %v", entity, item.Value.Value(), want[entity])
+		}
+	}
+}
+
+func TestExpressionInvalidIsBadRequest(t *testing.T) {
+	var tests = []struct {
+		name       string
+		expression externalmetrics.ExpressionDefinition
+	}{
+		{"syntax", externalmetrics.ExpressionDefinition{Value: "value +"}},
+		{"undeclared variable", externalmetrics.ExpressionDefinition{Value: "replicas * 2"}},
+		{"bool", externalmetrics.ExpressionDefinition{Value: "value > 2"}},
+		{"division by zero", externalmetrics.ExpressionDefinition{Value: "value / (hour - hour)"}},
+		{"time zone", externalmetrics.ExpressionDefinition{Value: "value", TimeZone: "Mars/Olympus"}},
+	}
+
+	for _, tt := range tests {
+		provider := newProvider(fakeAzureExternalClientFactory{})
+		provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+			MetricName: "Messages",
+			Expression: tt.expression,
+		})
+
+		selector, _ := labels.Parse("")
+		_, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+
+		if !k8serrors.IsBadRequest(err) {
+			t.Errorf("%s: error after processing got: %v, want bad request", tt.name, err)
+		}
+	}
+}
+
+func TestExpressionVariablesInTimeZone(t *testing.T) {
+	// Sunday 23:30 UTC is Monday 08:30 in Tokyo
+	at := time.Date(2019, 6, 2, 23, 30, 0, 0, time.UTC)
+
+	variables, err := expressionVariables(externalmetrics.ExpressionDefinition{TimeZone: "Asia/Tokyo"}, at)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	want := map[string]float64{"hour": 8, "minute": 30, "weekday": 1}
+	for name, value := range want {
+		if variables[name] != value {
+			t.Errorf("%s = %v, want %v", name, variables[name], value)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/metrics/pkg/apis/custom_metrics"
+
+	"github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider/helpers"
+)
+
+// IngestPath is the path in-cluster apps post custom metric values to, as <path><namespace>.  It is
+// served behind the authentication and authorization of the metrics apis, so callers need a role
+// allowing post on the non resource url of their namespace.
+const IngestPath = "/ingest/custommetrics/"
+
+const (
+	// maxIngestBody limits the size of a posted request
+	maxIngestBody = 1 << 20
+	// maxIngestMetrics limits the values of a posted request
+	maxIngestMetrics = 1000
+	// maxIngestSeries limits the values, each a metric of a pod or of every pod, kept for a
+	// namespace so apps posting new names can't grow the memory of the adapter
+	maxIngestSeries = 10000
+	// podsResource is the resource ingested metrics are served for
+	podsResource = "pods"
+)
+
+var ingestedMetricName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.-]{0,252}$`)
+
+// IngestedMetrics keeps the custom metric values posted by in-cluster apps, for apps that can't
+// publish to Application Insights.  A value is served for the pods of the namespace it was posted
+// for until it is older than the ttl.
+type IngestedMetrics struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu sync.Mutex
+	// values of each namespace by metric and pod name, where the empty name is the value of every pod
+	values map[string]map[string]map[string]ingestedValue
+	// lastPrune is when expired values were last dropped from every namespace
+	lastPrune time.Time
+}
+
+type ingestedValue struct {
+	value     float64
+	timestamp time.Time
+}
+
+// ingestRequest is the json posted to the ingest path
+type ingestRequest struct {
+	Metrics []ingestedMetric `json:"metrics"`
+}
+
+// ingestedMetric is a value of a metric.  Without a pod the value is served for every pod that
+// doesn't have its own value.
+type ingestedMetric struct {
+	Name  string   `json:"name"`
+	Pod   string   `json:"pod,omitempty"`
+	Value *float64 `json:"value"`
+}
+
+// NewIngestedMetrics creates the store of posted custom metric values, served until they are
+// older than the ttl
+func NewIngestedMetrics(ttl time.Duration) *IngestedMetrics {
+	return &IngestedMetrics{
+		ttl:    ttl,
+		now:    time.Now,
+		values: map[string]map[string]map[string]ingestedValue{},
+	}
+}
+
+// ServeHTTP stores the values posted for the namespace named by the path
+func (m *IngestedMetrics) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	namespace := strings.TrimPrefix(req.URL.Path, IngestPath)
+	if namespace == "" || strings.Contains(namespace, "/") {
+		http.Error(w, fmt.Sprintf("path must be %s<namespace>", IngestPath), http.StatusNotFound)
+		return
+	}
+
+	request := ingestRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxIngestBody)).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("unable to parse metrics: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(request.Metrics) > maxIngestMetrics {
+		http.Error(w, fmt.Sprintf("at most %d metrics can be posted at once", maxIngestMetrics), http.StatusBadRequest)
+		return
+	}
+	for _, metric := range request.Metrics {
+		if !ingestedMetricName.MatchString(metric.Name) {
+			http.Error(w, fmt.Sprintf("invalid metric name '%s'", metric.Name), http.StatusBadRequest)
+			return
+		}
+		if metric.Value == nil {
+			http.Error(w, fmt.Sprintf("metric %s has no value", metric.Name), http.StatusBadRequest)
+			return
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.prune(now)
+
+	metrics := m.values[namespace]
+	if metrics == nil {
+		metrics = map[string]map[string]ingestedValue{}
+	}
+	series := 0
+	for _, pods := range metrics {
+		series += len(pods)
+	}
+	added := map[string]bool{}
+	for _, metric := range request.Metrics {
+		if _, found := metrics[metric.Name][metric.Pod]; !found {
+			added[metric.Name+"/"+metric.Pod] = true
+		}
+	}
+	if series+len(added) > maxIngestSeries {
+		http.Error(w, fmt.Sprintf("at most %d metric values can be kept for a namespace", maxIngestSeries), http.StatusBadRequest)
+		return
+	}
+
+	for _, metric := range request.Metrics {
+		if metrics[metric.Name] == nil {
+			metrics[metric.Name] = map[string]ingestedValue{}
+		}
+		metrics[metric.Name][metric.Pod] = ingestedValue{value: *metric.Value, timestamp: now}
+	}
+	m.values[namespace] = metrics
+
+	glog.V(2).Infof("ingested %d custom metric values for namespace %s", len(request.Metrics), namespace)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// get returns the values of the metric by pod name that are within the ttl, dropping the others
+func (m *IngestedMetrics) get(namespace string, metricName string) (map[string]ingestedValue, bool) {
+	if m == nil {
+		return nil, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pruneMetric(m.now(), namespace, metricName)
+	pods := m.values[namespace][metricName]
+	if len(pods) == 0 {
+		return nil, false
+	}
+
+	values := map[string]ingestedValue{}
+	for pod, value := range pods {
+		values[pod] = value
+	}
+	return values, true
+}
+
+// prune drops the values older than the ttl of every namespace, at most once per ttl.  It must be
+// called with the mutex held.
+func (m *IngestedMetrics) prune(now time.Time) {
+	if now.Sub(m.lastPrune) < m.ttl {
+		return
+	}
+	m.lastPrune = now
+
+	for namespace, metrics := range m.values {
+		for metricName := range metrics {
+			m.pruneMetric(now, namespace, metricName)
+		}
+	}
+}
+
+// pruneMetric drops the values of the metric older than the ttl, and the metric and its namespace
+// once they have no values.  It must be called with the mutex held.
+func (m *IngestedMetrics) pruneMetric(now time.Time, namespace string, metricName string) {
+	metrics := m.values[namespace]
+	for pod, value := range metrics[metricName] {
+		if now.Sub(value.timestamp) > m.ttl {
+			delete(metrics[metricName], pod)
+		}
+	}
+	if len(metrics[metricName]) == 0 {
+		delete(metrics, metricName)
+	}
+	if len(metrics) == 0 {
+		delete(m.values, namespace)
+	}
+}
+
+// names returns the names of the metrics with values within the ttl, in any namespace
+func (m *IngestedMetrics) names() []string {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	names := map[string]bool{}
+	for _, metrics := range m.values {
+		for metricName, pods := range metrics {
+			for _, value := range pods {
+				if m.now().Sub(value.timestamp) <= m.ttl {
+					names[metricName] = true
+					break
+				}
+			}
+		}
+	}
+
+	list := []string{}
+	for name := range names {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// getIngestedMetric serves the value posted for the pod, or for every pod of the namespace
+func (p *AzureProvider) getIngestedMetric(name types.NamespacedName, info provider.CustomMetricInfo, values map[string]ingestedValue) (*custom_metrics.MetricValue, error) {
+	value, found := values[name.Name]
+	if !found {
+		value, found = values[""]
+	}
+	if !found {
+		return nil, errors.NewNotFound(info.GroupResource, name.Name)
+	}
+
+	ref, err := helpers.ReferenceFor(p.mapper, name, info)
+	if err != nil {
+		return nil, err
+	}
+
+	return &custom_metrics.MetricValue{
+		DescribedObject: ref,
+		Metric: custom_metrics.MetricIdentifier{
+			Name: info.Metric,
+		},
+		Timestamp: metav1.NewTime(value.timestamp),
+		Value:     *resource.NewMilliQuantity(int64(value.value*1000), resource.DecimalSI),
+	}, nil
+}
+
+// getIngestedMetrics serves the values posted for the pods matching the selector.  Pods without a
+// value of their own, or of every pod, are left out.
+func (p *AzureProvider) getIngestedMetrics(namespace string, selector labels.Selector, info provider.CustomMetricInfo, values map[string]ingestedValue) (*custom_metrics.MetricValueList, error) {
+	resourceNames, err := helpers.ListObjectNames(p.mapper, p.kubeClient, namespace, selector, info)
+	if err != nil {
+		glog.Errorf("not able to list objects from api server: %v", err)
+		return nil, errors.NewInternalError(fmt.Errorf("not able to list objects from api server for this resource"))
+	}
+
+	metricList := make([]custom_metrics.MetricValue, 0)
+	for _, name := range resourceNames {
+		metricValue, err := p.getIngestedMetric(types.NamespacedName{Namespace: namespace, Name: name}, info, values)
+		if errors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		metricList = append(metricList, *metricValue)
+	}
+
+	return &custom_metrics.MetricValueList{
+		Items: metricList,
+	}, nil
+}
@@ -0,0 +1,169 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestIngestedMetricServedForEachPod(t *testing.T) {
+	store := []runtime.Object{
+		newUnstructured("v1", "Pod", "default", "pod1"),
+		newUnstructured("v1", "Pod", "default", "pod2"),
+		newUnstructured("v1", "Pod", "other", "pod3"),
+	}
+	provider, _ := newFakeCustomProvider(fakeAppInsightsClient{result: 15}, store)
+	provider.ingestedMetrics = NewIngestedMetrics(time.Minute)
+
+	if code := postIngest(provider.ingestedMetrics, IngestPath+"default", `{"metrics":[{"name":"queue_depth","value":12},{"name":"queue_depth","pod":"pod2","value":3.5}]}`); code != http.StatusNoContent {
+		t.Fatalf("status = %v, want %v", code, http.StatusNoContent)
+	}
+
+	selector, _ := labels.Parse("")
+	info := k8sprovider.CustomMetricInfo{Namespaced: true, Metric: "queue_depth", GroupResource: schema.GroupResource{Resource: "pods"}}
+	returnList, err := provider.GetMetricBySelector("default", selector, info)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if len(returnList.Items) != 2 {
+		t.Fatalf("returnList.Items length = %v, want there 2", len(returnList.Items))
+	}
+	want := map[string]int64{"pod1": 12000, "pod2": 3500}
+	for _, item := range returnList.Items {
+		if item.Value.MilliValue() != want[item.DescribedObject.Name] {
+			t.Errorf("value of %s = %v, want %v", item.DescribedObject.Name, item.Value.MilliValue(), want[item.DescribedObject.Name])
+		}
+	}
+
+	value, err := provider.GetMetricByName(types.NamespacedName{Namespace: "default", Name: "pod2"}, info)
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if value.Value.MilliValue() != 3500 {
+		t.Errorf("value of pod2 = %v, want %v", value.Value.MilliValue(), 3500)
+	}
+
+	// values are served for the namespace they were posted for
+	returnList, err = provider.GetMetricBySelector("other", selector, info)
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if len(returnList.Items) != 1 || returnList.Items[0].Value.MilliValue() != 15000 {
+		t.Errorf("returnList.Items = %v, want the application insights value", returnList.Items)
+	}
+}
+
+func TestIngestedMetricExpiresAfterTTL(t *testing.T) {
+	now := time.Date(2019, 3, 10, 12, 0, 0, 0, time.UTC)
+	ingested := NewIngestedMetrics(time.Minute)
+	ingested.now = func() time.Time { return now }
+
+	postIngest(ingested, IngestPath+"default", `{"metrics":[{"name":"queue_depth","value":12}]}`)
+
+	if _, found := ingested.get("default", "queue_depth"); !found {
+		t.Errorf("queue_depth found = %v, want true", found)
+	}
+	if names := ingested.names(); len(names) != 1 || names[0] != "queue_depth" {
+		t.Errorf("names = %v, want [queue_depth]", names)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, found := ingested.get("default", "queue_depth"); found {
+		t.Errorf("queue_depth found = %v after the ttl, want false", found)
+	}
+	if names := ingested.names(); len(names) != 0 {
+		t.Errorf("names = %v after the ttl, want none", names)
+	}
+}
+
+func TestIngestDropsExpiredValuesOfEveryNamespace(t *testing.T) {
+	now := time.Date(2019, 3, 10, 12, 0, 0, 0, time.UTC)
+	ingested := NewIngestedMetrics(time.Minute)
+	ingested.now = func() time.Time { return now }
+
+	postIngest(ingested, IngestPath+"default", `{"metrics":[{"name":"queue_depth","value":12}]}`)
+	now = now.Add(2 * time.Minute)
+	postIngest(ingested, IngestPath+"other", `{"metrics":[{"name":"queue_depth","value":3}]}`)
+
+	if _, found := ingested.values["default"]; found {
+		t.Errorf("values = %v, want the expired values of default dropped", ingested.values)
+	}
+	if _, found := ingested.values["other"]; !found {
+		t.Errorf("values = %v, want the values of other kept", ingested.values)
+	}
+}
+
+func TestIngestLimitsValuesOfNamespace(t *testing.T) {
+	ingested := NewIngestedMetrics(time.Minute)
+	for i := 0; i < maxIngestSeries/maxIngestMetrics; i++ {
+		if code := postIngest(ingested, IngestPath+"default", ingestBody(fmt.Sprintf("metric_%d_", i), maxIngestMetrics)); code != http.StatusNoContent {
+			t.Fatalf("status = %v, want %v", code, http.StatusNoContent)
+		}
+	}
+
+	if code := postIngest(ingested, IngestPath+"default", `{"metrics":[{"name":"queue_depth","value":1}]}`); code != http.StatusBadRequest {
+		t.Errorf("status of a new value = %v, want %v", code, http.StatusBadRequest)
+	}
+	if code := postIngest(ingested, IngestPath+"default", `{"metrics":[{"name":"metric_0_0","value":1}]}`); code != http.StatusNoContent {
+		t.Errorf("status of an updated value = %v, want %v", code, http.StatusNoContent)
+	}
+	if code := postIngest(ingested, IngestPath+"other", `{"metrics":[{"name":"queue_depth","value":1}]}`); code != http.StatusNoContent {
+		t.Errorf("status of another namespace = %v, want %v", code, http.StatusNoContent)
+	}
+}
+
+func TestIngestInvalidRequestRejected(t *testing.T) {
+	var tests = []struct {
+		name string
+		path string
+		body string
+		want int
+	}{
+		{"no namespace", IngestPath, `{"metrics":[]}`, http.StatusNotFound},
+		{"nested path", IngestPath + "default/extra", `{"metrics":[]}`, http.StatusNotFound},
+		{"invalid json", IngestPath + "default", `{"metrics":`, http.StatusBadRequest},
+		{"invalid name", IngestPath + "default", `{"metrics":[{"name":"queue/depth","value":1}]}`, http.StatusBadRequest},
+		{"no value", IngestPath + "default", `{"metrics":[{"name":"queue_depth"}]}`, http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		ingested := NewIngestedMetrics(time.Minute)
+		if code := postIngest(ingested, tt.path, tt.body); code != tt.want {
+			t.Errorf("%s: status = %v, want %v", tt.name, code, tt.want)
+		}
+		if names := ingested.names(); len(names) != 0 {
+			t.Errorf("%s: names = %v, want none", tt.name, names)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	NewIngestedMetrics(time.Minute).ServeHTTP(recorder, httptest.NewRequest("GET", IngestPath+"default", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %v, want %v", recorder.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+// ingestBody posts count metrics named with the prefix
+func ingestBody(prefix string, count int) string {
+	metrics := []string{}
+	for i := 0; i < count; i++ {
+		metrics = append(metrics, fmt.Sprintf(`{"name":"%s%d","value":1}`, prefix, i))
+	}
+	return fmt.Sprintf(`{"metrics":[%s]}`, strings.Join(metrics, ","))
+}
+
+func postIngest(ingested *IngestedMetrics, path string, body string) int {
+	recorder := httptest.NewRecorder()
+	ingested.ServeHTTP(recorder, httptest.NewRequest("POST", path, strings.NewReader(body)))
+	return recorder.Code
+}
@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/golang/glog"
+	"github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider/helpers"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ApplicationGatewayIDAnnotation on an Ingress selects the Application Gateway its metrics are
+// read from instead of the adapter's default gateway
+const ApplicationGatewayIDAnnotation = "azure.com/application-gateway-id"
+
+const (
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+	agicIngressClass       = "azure/application-gateway"
+
+	// Application Gateway metrics of each backend are split by pool and http settings as <pool>~<settings>
+	backendSettingsPoolDimension = "BackendSettingsPool"
+)
+
+// applicationGatewayMetric is an Application Gateway metric served as a custom metric of an Ingress
+type applicationGatewayMetric struct {
+	metricName  string
+	aggregation string
+	// divisor converts the per minute value of the metric, such as a request count, to the served unit
+	divisor float64
+}
+
+var applicationGatewayMetrics = map[string]applicationGatewayMetric{
+	"requests-per-second":                  {metricName: "TotalRequests", aggregation: "Total", divisor: 60},
+	"failed-requests-per-second":           {metricName: "FailedRequests", aggregation: "Total", divisor: 60},
+	"healthy-host-count":                   {metricName: "HealthyHostCount", aggregation: "Average", divisor: 1},
+	"unhealthy-host-count":                 {metricName: "UnhealthyHostCount", aggregation: "Average", divisor: 1},
+	"requests-per-minute-per-healthy-host": {metricName: "AvgRequestCountPerHealthyHost", aggregation: "Average", divisor: 1},
+}
+
+// ingressGroupResource is the resource the Application Gateway metrics are listed for
+var ingressGroupResource = schema.GroupResource{Group: "extensions", Resource: "ingresses"}
+
+// getIngressMetric reads the Application Gateway metric of the backends of an Ingress managed
+// by the Application Gateway Ingress Controller
+func (p *AzureProvider) getIngressMetric(name types.NamespacedName, info provider.CustomMetricInfo) (float64, error) {
+	gatewayMetric, ok := applicationGatewayMetrics[info.Metric]
+	if !ok {
+		return 0, errors.NewBadRequest(fmt.Sprintf("metric %s is not available for ingresses", info.Metric))
+	}
+
+	resource, err := helpers.ResourceFor(p.mapper, info)
+	if err != nil {
+		return 0, errors.NewBadRequest(err.Error())
+	}
+	ingress, err := p.kubeClient.Resource(resource).Namespace(name.Namespace).Get(name.Name, metav1.GetOptions{})
+	if err != nil {
+		glog.Errorf("unable to get ingress %s: %v", name, err)
+		return 0, err
+	}
+
+	annotations := ingress.GetAnnotations()
+	if annotations[ingressClassAnnotation] != agicIngressClass {
+		return 0, errors.NewBadRequest(fmt.Sprintf("ingress %s is not managed by the application gateway ingress controller", name))
+	}
+
+	gatewayID := p.applicationGatewayID
+	if id, ok := annotations[ApplicationGatewayIDAnnotation]; ok {
+		gatewayID = id
+	}
+	azMetricRequest, err := applicationGatewayRequest(gatewayID, gatewayMetric)
+	if err != nil {
+		return 0, errors.NewBadRequest(err.Error())
+	}
+
+	metricValue, err := p.getAzureMetric(name.Namespace, info.Metric, azMetricRequest)
+	if err != nil {
+		return 0, err
+	}
+
+	total, found := 0.0, false
+	for _, series := range metricValue.Series {
+		if isIngressBackend(series.Labels[strings.ToLower(backendSettingsPoolDimension)], name) {
+			total += series.Value
+			found = true
+		}
+	}
+	if !found {
+		glog.V(2).Infof("no application gateway backends found for ingress %s", name)
+	}
+
+	return total / gatewayMetric.divisor, nil
+}
+
+// applicationGatewayRequest queries the metric of every backend of the gateway with the given resource id
+func applicationGatewayRequest(gatewayID string, gatewayMetric applicationGatewayMetric) (externalmetrics.AzureExternalMetricRequest, error) {
+	// /subscriptions/<id>/resourceGroups/<group>/providers/Microsoft.Network/applicationGateways/<name>
+	parts := strings.Split(strings.Trim(gatewayID, "/"), "/")
+	if len(parts) != 8 || !strings.EqualFold(parts[0], "subscriptions") || !strings.EqualFold(parts[2], "resourceGroups") ||
+		!strings.EqualFold(parts[4], "providers") || !strings.EqualFold(parts[5], "Microsoft.Network") || !strings.EqualFold(parts[6], "applicationGateways") {
+		return externalmetrics.AzureExternalMetricRequest{}, fmt.Errorf("invalid application gateway resource id '%s'", gatewayID)
+	}
+
+	return externalmetrics.AzureExternalMetricRequest{
+		Type:                      externalmetrics.Monitor,
+		SubscriptionID:            parts[1],
+		ResourceGroup:             parts[3],
+		ResourceProviderNamespace: parts[5],
+		ResourceType:              parts[6],
+		ResourceName:              parts[7],
+		MetricName:                gatewayMetric.metricName,
+		Aggregation:               gatewayMetric.aggregation,
+		Timespan:                  externalmetrics.TimeSpan(),
+		SplitDimension:            backendSettingsPoolDimension,
+		Top:                       externalmetrics.MaxSplitTop,
+	}, nil
+}
+
+// isIngressBackend returns true if the http settings were created by the ingress controller for
+// the Ingress.  Settings are named bp-<namespace>-<service>-<service port>-<backend port>-<ingress>.
+func isIngressBackend(backendSettingsPool string, name types.NamespacedName) bool {
+	parts := strings.SplitN(backendSettingsPool, "~", 2)
+	if len(parts) != 2 {
+		return false
+	}
+
+	settings := parts[1]
+	return strings.HasPrefix(settings, fmt.Sprintf("bp-%s-", name.Namespace)) && strings.HasSuffix(settings, fmt.Sprintf("-%s", name.Name))
+}
@@ -0,0 +1,134 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/scheme"
+	"github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/dynamicmapper"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	k8sclient "k8s.io/client-go/dynamic/fake"
+	core "k8s.io/client-go/testing"
+)
+
+const testGatewayID = "/subscriptions/1234/resourceGroups/rg/providers/Microsoft.Network/applicationGateways/gw"
+
+func TestIngressRequestsPerSecondFromApplicationGateway(t *testing.T) {
+	ingress := newUnstructured("extensions/v1beta1", "Ingress", "default", "web")
+	ingress.SetAnnotations(map[string]string{ingressClassAnnotation: agicIngressClass})
+
+	factory := &fakeApplicationGatewayFactory{
+		response: externalmetrics.AzureExternalMetricResponse{
+			Series: []externalmetrics.MetricSeries{
+				{Labels: map[string]string{"backendsettingspool": "pool-default-web-80-bp-8080~bp-default-web-80-8080-web"}, Value: 600},
+				{Labels: map[string]string{"backendsettingspool": "pool-default-api-80-bp-8080~bp-default-api-80-8080-web"}, Value: 120},
+				{Labels: map[string]string{"backendsettingspool": "pool-default-web-80-bp-8080~bp-default-web-80-8080-other"}, Value: 6000},
+				{Labels: map[string]string{"backendsettingspool": "pool-prod-web-80-bp-8080~bp-prod-web-80-8080-web"}, Value: 6000},
+			},
+		},
+	}
+	provider := newIngressProvider(factory, ingress)
+
+	info := k8sprovider.CustomMetricInfo{GroupResource: ingressGroupResource, Namespaced: true, Metric: "requests-per-second"}
+	metricValue, err := provider.GetMetricByName(types.NamespacedName{Namespace: "default", Name: "web"}, info)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	// both backends of the ingress, per second
+	if metricValue.Value.MilliValue() != 12000 {
+		t.Errorf("metricValue.Value.MilliValue() = %v, want there %v", metricValue.Value.MilliValue(), 12000)
+	}
+
+	if metricValue.DescribedObject.Kind != "Ingress" || metricValue.DescribedObject.Name != "web" {
+		t.Errorf("metricValue.DescribedObject = %v, want there Ingress web", metricValue.DescribedObject)
+	}
+
+	request := factory.request
+	if request.ResourceName != "gw" || request.SubscriptionID != "1234" || request.MetricName != "TotalRequests" || request.SplitDimension != backendSettingsPoolDimension {
+		t.Errorf("request = %+v, want TotalRequests of gateway gw split by %s", request, backendSettingsPoolDimension)
+	}
+}
+
+func TestIngressNotManagedByApplicationGatewayIsBadRequest(t *testing.T) {
+	ingress := newUnstructured("extensions/v1beta1", "Ingress", "default", "web")
+	ingress.SetAnnotations(map[string]string{ingressClassAnnotation: "nginx"})
+
+	provider := newIngressProvider(&fakeApplicationGatewayFactory{}, ingress)
+
+	info := k8sprovider.CustomMetricInfo{GroupResource: ingressGroupResource, Namespaced: true, Metric: "requests-per-second"}
+	_, err := provider.GetMetricByName(types.NamespacedName{Namespace: "default", Name: "web"}, info)
+
+	if !k8serrors.IsBadRequest(err) {
+		t.Errorf("error after processing got: %v, want bad request", err)
+	}
+}
+
+func TestIngressUnknownMetricIsBadRequest(t *testing.T) {
+	ingress := newUnstructured("extensions/v1beta1", "Ingress", "default", "web")
+	ingress.SetAnnotations(map[string]string{ingressClassAnnotation: agicIngressClass})
+
+	provider := newIngressProvider(&fakeApplicationGatewayFactory{}, ingress)
+
+	info := k8sprovider.CustomMetricInfo{GroupResource: ingressGroupResource, Namespaced: true, Metric: "bytes-per-second"}
+	_, err := provider.GetMetricByName(types.NamespacedName{Namespace: "default", Name: "web"}, info)
+
+	if !k8serrors.IsBadRequest(err) {
+		t.Errorf("error after processing got: %v, want bad request", err)
+	}
+}
+
+func TestApplicationGatewayRequestInvalidID(t *testing.T) {
+	var tests = []string{
+		"",
+		"/subscriptions/1234/resourceGroups/rg",
+		"/subscriptions/1234/resourceGroups/rg/providers/Microsoft.ServiceBus/namespaces/ns",
+	}
+
+	for _, id := range tests {
+		if _, err := applicationGatewayRequest(id, applicationGatewayMetrics["requests-per-second"]); err == nil {
+			t.Errorf("applicationGatewayRequest(%s) got nil, want error", id)
+		}
+	}
+}
+
+func newIngressProvider(factory *fakeApplicationGatewayFactory, ingress *unstructured.Unstructured) AzureProvider {
+	fakeDiscovery := &dynamicmapper.FakeDiscovery{Fake: &core.Fake{}}
+	mapper, _ := dynamicmapper.NewRESTMapper(fakeDiscovery, 1*time.Second)
+	fakeDiscovery.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "extensions/v1beta1",
+			APIResources: []metav1.APIResource{
+				{Name: "ingresses", Namespaced: true, Kind: "Ingress"},
+			},
+		},
+	}
+	mapper.RegenerateMappings()
+
+	return AzureProvider{
+		mapper:               mapper,
+		kubeClient:           k8sclient.NewSimpleDynamicClient(scheme.Scheme, ingress),
+		azureClientFactory:   factory,
+		applicationGatewayID: testGatewayID,
+	}
+}
+
+type fakeApplicationGatewayFactory struct {
+	response externalmetrics.AzureExternalMetricResponse
+	request  externalmetrics.AzureExternalMetricRequest
+}
+
+func (f *fakeApplicationGatewayFactory) GetAzureExternalMetricClient(clientType string) (externalmetrics.AzureExternalMetricClient, error) {
+	return f, nil
+}
+
+func (f *fakeApplicationGatewayFactory) GetAzureMetric(azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	f.request = azMetricRequest
+	return f.response, nil
+}
@@ -0,0 +1,144 @@
+package provider
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/golang/glog"
+)
+
+// maintenanceRetention is how long the value last served for a metric is kept after it was last
+// recorded.  Values are kept per label selector, so the values of selectors no longer requested
+// are dropped.
+const maintenanceRetention = 24 * time.Hour
+
+// maintenanceValue is the value last served for a metric and when it was served
+type maintenanceValue struct {
+	value    externalmetrics.AzureExternalMetricResponse
+	recorded time.Time
+}
+
+// maintenance freezes external metrics at the value last served before a maintenance window, and
+// serves pinned metrics at their pinned value
+type maintenance struct {
+	global    externalmetrics.MaintenanceDefinition
+	maxPin    time.Duration
+	retention time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	values    map[string]maintenanceValue
+	lastPrune time.Time
+}
+
+func newMaintenance(global externalmetrics.MaintenanceDefinition, maxPin time.Duration) *maintenance {
+	// a value a metric is pinned at is kept until the pin expires
+	retention := maintenanceRetention
+	if maxPin > retention {
+		retention = maxPin
+	}
+	return &maintenance{
+		global:    global,
+		maxPin:    maxPin,
+		retention: retention,
+		now:       time.Now,
+		values:    map[string]maintenanceValue{},
+	}
+}
+
+// frozen returns the value last served for the metric when the adapter or the metric is in a
+// maintenance window.  A metric first requested during a window has no value to freeze at.
+func (m *maintenance) frozen(key string, windows externalmetrics.MaintenanceDefinition) (externalmetrics.AzureExternalMetricResponse, bool, error) {
+	if m == nil {
+		return externalmetrics.AzureExternalMetricResponse{}, false, nil
+	}
+
+	now := m.now()
+	active, err := m.global.ActiveAt(now)
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, false, err
+	}
+	if !active {
+		if active, err = windows.ActiveAt(now); err != nil {
+			return externalmetrics.AzureExternalMetricResponse{}, false, err
+		}
+	}
+	if !active {
+		return externalmetrics.AzureExternalMetricResponse{}, false, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, found := m.values[key]
+	if !found {
+		glog.V(2).Infof("no value of %s to freeze during maintenance", key)
+	}
+	return value.value, found, nil
+}
+
+// pinned returns the value a metric is pinned at until its pin expires.  A pin ending further
+// ahead than the maximum pin duration is ignored, so a metric can't be pinned indefinitely.
+func (m *maintenance) pinned(key string, pin externalmetrics.PinDefinition) (externalmetrics.AzureExternalMetricResponse, bool) {
+	if m == nil {
+		return externalmetrics.AzureExternalMetricResponse{}, false
+	}
+
+	now := m.now()
+	if !pin.ActiveAt(now) {
+		return externalmetrics.AzureExternalMetricResponse{}, false
+	}
+	if pin.Until.Sub(now) > m.maxPin {
+		glog.Warningf("ignoring pin of %s until %s, further ahead than the maximum pin duration of %s", key, pin.Until.Format(time.RFC3339), m.maxPin)
+		return externalmetrics.AzureExternalMetricResponse{}, false
+	}
+	if pin.Value != nil {
+		return externalmetrics.AzureExternalMetricResponse{Total: *pin.Value}, true
+	}
+
+	value, found := m.last(key)
+	if !found {
+		glog.V(2).Infof("no value of %s to pin", key)
+	}
+	return value, found
+}
+
+// last returns the value last served for the metric
+func (m *maintenance) last(key string) (externalmetrics.AzureExternalMetricResponse, bool) {
+	if m == nil {
+		return externalmetrics.AzureExternalMetricResponse{}, false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	value, found := m.values[key]
+	return value.value, found
+}
+
+// record keeps the value served for the metric so it can be frozen during maintenance, pinned or served during an incident
+func (m *maintenance) record(key string, value externalmetrics.AzureExternalMetricResponse) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.values[key] = maintenanceValue{value: value, recorded: now}
+	m.prune(now)
+}
+
+// prune drops the values recorded longer ago than the retention, at most once per retention.  It
+// must be called with the mutex held.
+func (m *maintenance) prune(now time.Time) {
+	if now.Sub(m.lastPrune) < m.retention {
+		return
+	}
+	m.lastPrune = now
+
+	for key, value := range m.values {
+		if now.Sub(value.recorded) > m.retention {
+			delete(m.values, key)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestMaintenanceFreezesValueAndSkipsAzure(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2019-03-10T00:00:00Z")
+	client := &countingExternalClient{}
+
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = countingClientFactory{client: client}
+	provider.maintenance = newMaintenance(externalmetrics.MaintenanceDefinition{}, 0)
+	provider.maintenance.now = func() time.Time { return now }
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		Maintenance: externalmetrics.MaintenanceDefinition{
+			Windows: []externalmetrics.MaintenanceWindow{{From: "2019-03-10T01:00:00Z", Until: "2019-03-10T02:00:00Z"}},
+		},
+	})
+
+	tests := []struct {
+		at        string
+		wantValue int64
+		wantCalls int
+	}{
+		{at: "2019-03-10T00:59:00Z", wantValue: 1, wantCalls: 1},
+		{at: "2019-03-10T01:00:00Z", wantValue: 1, wantCalls: 1},
+		{at: "2019-03-10T01:30:00Z", wantValue: 1, wantCalls: 1},
+		{at: "2019-03-10T02:00:00Z", wantValue: 2, wantCalls: 2},
+	}
+	for _, tt := range tests {
+		now, _ = time.Parse(time.RFC3339, tt.at)
+
+		selector, _ := labels.Parse("")
+		returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+
+		if err != nil {
+			t.Fatalf("%s: error after processing got: %v, want nil", tt.at, err)
+		}
+		if returnList.Items[0].Value.Value() != tt.wantValue {
+			t.Errorf("%s: externalMetric.Value = %v, want there %v", tt.at, returnList.Items[0].Value.Value(), tt.wantValue)
+		}
+		if client.calls != tt.wantCalls {
+			t.Errorf("%s: calls = %v, want there %v", tt.at, client.calls, tt.wantCalls)
+		}
+	}
+}
+
+func TestMaintenanceWithoutValueQueriesAzure(t *testing.T) {
+	client := &countingExternalClient{}
+
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = countingClientFactory{client: client}
+	provider.maintenance = newMaintenance(externalmetrics.MaintenanceDefinition{
+		Windows: []externalmetrics.MaintenanceWindow{{Days: "*", Start: "00:00", End: "00:00"}},
+	}, 0)
+
+	selector := createLabelSelector("MetricName", "")
+	_, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "MetricName"})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if client.calls != 1 {
+		t.Errorf("calls = %v, want there %v", client.calls, 1)
+	}
+}
+
+func TestPinnedMetricServedUntilPinExpires(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2019-03-10T00:00:00Z")
+	until, _ := time.Parse(time.RFC3339, "2019-03-10T01:00:00Z")
+	pinnedValue := 10.0
+	client := &countingExternalClient{}
+
+	tests := []struct {
+		name      string
+		pin       externalmetrics.PinDefinition
+		wantValue int64
+		wantCalls int
+	}{
+		{name: "last value", pin: externalmetrics.PinDefinition{Until: until}, wantValue: 1, wantCalls: 1},
+		{name: "pinned value", pin: externalmetrics.PinDefinition{Until: until, Value: &pinnedValue}, wantValue: 10, wantCalls: 1},
+		// a pin beyond the maximum duration is ignored
+		{name: "too long", pin: externalmetrics.PinDefinition{Until: until.Add(time.Hour), Value: &pinnedValue}, wantValue: 2, wantCalls: 2},
+	}
+
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = countingClientFactory{client: client}
+	provider.maintenance = newMaintenance(externalmetrics.MaintenanceDefinition{}, time.Hour+time.Minute)
+	provider.maintenance.now = func() time.Time { return now }
+	provider.rawResponses = NewRawResponses()
+
+	selector, _ := labels.Parse("")
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{MetricName: "Messages"})
+	provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+
+	for _, tt := range tests {
+		provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{MetricName: "Messages", Pin: tt.pin})
+		returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+
+		if err != nil {
+			t.Fatalf("%s: error after processing got: %v, want nil", tt.name, err)
+		}
+		if returnList.Items[0].Value.Value() != tt.wantValue {
+			t.Errorf("%s: externalMetric.Value = %v, want there %v", tt.name, returnList.Items[0].Value.Value(), tt.wantValue)
+		}
+		if client.calls != tt.wantCalls {
+			t.Errorf("%s: calls = %v, want there %v", tt.name, client.calls, tt.wantCalls)
+		}
+	}
+
+	// the pin expires on its own
+	now = until
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{MetricName: "Messages", Pin: externalmetrics.PinDefinition{Until: until}})
+	returnList, _ := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+	if returnList.Items[0].Value.Value() != 3 || client.calls != 3 {
+		t.Errorf("expired pin: externalMetric.Value = %v after %v calls, want there 3", returnList.Items[0].Value.Value(), client.calls)
+	}
+}
+
+func TestPinnedMetricRecordedInRawResponses(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2019-03-10T00:00:00Z")
+	until := now.Add(time.Hour)
+	pinnedValue := 10.0
+
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.maintenance = newMaintenance(externalmetrics.MaintenanceDefinition{}, 2*time.Hour)
+	provider.maintenance.now = func() time.Time { return now }
+	provider.rawResponses = NewRawResponses()
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		Pin:        externalmetrics.PinDefinition{Until: until, Value: &pinnedValue},
+	})
+
+	selector, _ := labels.Parse("")
+	if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"}); err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	response := provider.rawResponses.responses["default/queue"]
+	if response.PinnedUntil == nil || !response.PinnedUntil.Equal(until) || response.Value != pinnedValue {
+		t.Errorf("raw response = %+v, want pinned at %v until %v", response, pinnedValue, until)
+	}
+}
+
+type countingClientFactory struct {
+	client *countingExternalClient
+}
+
+func (f countingClientFactory) GetAzureExternalMetricClient(clientType string) (externalmetrics.AzureExternalMetricClient, error) {
+	return f.client, nil
+}
+
+// countingExternalClient returns the number of times it has been called
+type countingExternalClient struct {
+	calls int
+}
+
+func (c *countingExternalClient) GetAzureMetric(azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	c.calls++
+	return externalmetrics.AzureExternalMetricResponse{Total: float64(c.calls)}, nil
+}
+
+func TestMaintenanceDropsValuesNotRecordedWithinRetention(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2019-03-10T00:00:00Z")
+	m := newMaintenance(externalmetrics.MaintenanceDefinition{}, time.Hour)
+	m.now = func() time.Time { return now }
+
+	m.record("default/queue/app=old", externalmetrics.AzureExternalMetricResponse{Total: 1})
+	now = now.Add(maintenanceRetention / 2)
+	m.record("default/queue/app=recent", externalmetrics.AzureExternalMetricResponse{Total: 2})
+	now = now.Add(maintenanceRetention)
+	m.record("default/queue/", externalmetrics.AzureExternalMetricResponse{Total: 3})
+
+	if _, found := m.last("default/queue/app=old"); found {
+		t.Errorf("value of a selector not recorded within the retention kept, want it dropped")
+	}
+	for _, key := range []string{"default/queue/app=recent", "default/queue/"} {
+		if _, found := m.last(key); !found {
+			t.Errorf("value of %s dropped, want it kept", key)
+		}
+	}
+}
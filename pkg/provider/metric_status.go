@@ -0,0 +1,183 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	clientset "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/typed/metrics/v1alpha2"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// IncidentCondition is the condition of an external metric whose previous value is served during an
+// Azure Monitor incident reported by Azure Service Health
+const IncidentCondition = "AzureServiceIncident"
+
+// MetricStatuses writes how external metrics are served, such as an active pin, to the status of
+// their ExternalMetric so it can be seen with kubectl instead of only in the logs of the adapter.
+// Statuses are written in the background when they change, so serving a metric doesn't wait on
+// the kubernetes api.
+type MetricStatuses struct {
+	client clientset.ExternalMetricsGetter
+	now    func() time.Time
+
+	mu      sync.Mutex
+	desired map[string]api.ExternalMetricStatus
+	written map[string]api.ExternalMetricStatus
+	pending map[string]bool
+}
+
+// NewMetricStatuses creates the writer of the status of ExternalMetrics
+func NewMetricStatuses(client clientset.ExternalMetricsGetter) *MetricStatuses {
+	return &MetricStatuses{
+		client:  client,
+		now:     time.Now,
+		desired: map[string]api.ExternalMetricStatus{},
+		written: map[string]api.ExternalMetricStatus{},
+		pending: map[string]bool{},
+	}
+}
+
+// Run writes the statuses that changed every interval until the channel is closed
+func (s *MetricStatuses) Run(interval time.Duration, stopCh <-chan struct{}) {
+	wait.Until(s.flush, interval, stopCh)
+}
+
+// pinned records the pin a metric is served at, or that it isn't pinned when the pin is nil
+func (s *MetricStatuses) pinned(namespace string, name string, pin *externalmetrics.PinDefinition) {
+	if s == nil {
+		return
+	}
+
+	var status *api.PinStatus
+	if pin != nil {
+		status = &api.PinStatus{Until: meta_v1.NewTime(pin.Until.UTC().Truncate(time.Second))}
+		if pin.Value != nil {
+			status.Value = fmt.Sprintf("%g", *pin.Value)
+		}
+	}
+	s.update(namespace, name, func(metricStatus *api.ExternalMetricStatus) {
+		metricStatus.Pin = status
+	})
+}
+
+// incident records the tracking id of the Azure incident a metric is served its previous value
+// during, or that it was queried when the incident is empty
+func (s *MetricStatuses) incident(namespace string, name string, incident string) {
+	if s == nil {
+		return
+	}
+
+	now := s.now()
+	s.update(namespace, name, func(metricStatus *api.ExternalMetricStatus) {
+		conditions := []api.ExternalMetricCondition{}
+		for _, condition := range metricStatus.Conditions {
+			if condition.Type != IncidentCondition {
+				conditions = append(conditions, condition)
+				continue
+			}
+			if condition.Reason == incident {
+				// the transition time is kept while the incident continues
+				return
+			}
+		}
+		if incident != "" {
+			conditions = append(conditions, api.ExternalMetricCondition{
+				Type:               IncidentCondition,
+				Status:             "True",
+				Reason:             incident,
+				Message:            fmt.Sprintf("the previous value is served as the metric can't be queried during azure service incident %s", incident),
+				LastTransitionTime: meta_v1.NewTime(now.UTC().Truncate(time.Second)),
+			})
+		}
+		if len(conditions) == 0 {
+			conditions = nil
+		}
+		metricStatus.Conditions = conditions
+	})
+}
+
+// observed records the value a metric was evaluated at in observer mode, or the error it failed with
+func (s *MetricStatuses) observed(namespace string, name string, value float64, err error) {
+	if s == nil {
+		return
+	}
+
+	status := &api.ObservedStatus{}
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Value = fmt.Sprintf("%g", value)
+	}
+	s.update(namespace, name, func(metricStatus *api.ExternalMetricStatus) {
+		metricStatus.Observed = status
+	})
+}
+
+// update changes the desired status of a metric, which is written on the next flush if it differs
+// from the status last written
+func (s *MetricStatuses) update(namespace string, name string, change func(*api.ExternalMetricStatus)) {
+	key := fmt.Sprintf("%s/%s", namespace, name)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	desired := s.desired[key]
+	status := *desired.DeepCopy()
+	change(&status)
+	s.desired[key] = status
+	if written, found := s.written[key]; !found || !equality.Semantic.DeepEqual(written, status) {
+		s.pending[key] = true
+	}
+}
+
+// flush writes the pending statuses.  A status that couldn't be written stays pending.
+func (s *MetricStatuses) flush() {
+	s.mu.Lock()
+	statuses := map[string]api.ExternalMetricStatus{}
+	for key := range s.pending {
+		desired := s.desired[key]
+		statuses[key] = *desired.DeepCopy()
+	}
+	s.pending = map[string]bool{}
+	s.mu.Unlock()
+
+	for key, status := range statuses {
+		err := s.write(key, status)
+		s.mu.Lock()
+		if err != nil {
+			glog.Errorf("unable to update the status of external metric %s: %v", key, err)
+			s.pending[key] = true
+		} else {
+			s.written[key] = status
+		}
+		s.mu.Unlock()
+	}
+}
+
+// write updates the status of the ExternalMetric when it differs.  Metrics served from an
+// ExternalMetricGroup have no ExternalMetric, so their status isn't written.
+func (s *MetricStatuses) write(key string, status api.ExternalMetricStatus) error {
+	parts := strings.SplitN(key, "/", 2)
+	metric, err := s.client.ExternalMetrics(parts[0]).Get(parts[1], meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if equality.Semantic.DeepEqual(metric.Status, status) {
+		return nil
+	}
+
+	updated := metric.DeepCopy()
+	updated.Status = status
+	_, err = s.client.ExternalMetrics(parts[0]).UpdateStatus(updated)
+	return err
+}
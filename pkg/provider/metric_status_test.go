@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/fake"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestPinnedMetricWrittenToStatus(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2019-03-10T00:00:00Z")
+	until := now.Add(time.Hour)
+	pinnedValue := 10.0
+
+	client := fake.NewSimpleClientset(&api.ExternalMetric{ObjectMeta: metav1.ObjectMeta{Name: "queue", Namespace: "default"}})
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.maintenance = newMaintenance(externalmetrics.MaintenanceDefinition{}, 2*time.Hour)
+	provider.maintenance.now = func() time.Time { return now }
+	provider.statuses = NewMetricStatuses(client.AzureV1alpha2())
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		Pin:        externalmetrics.PinDefinition{Until: until, Value: &pinnedValue},
+	})
+
+	selector, _ := labels.Parse("")
+	if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"}); err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	provider.statuses.flush()
+
+	status := externalMetricStatus(t, client)
+	if status.Pin == nil || !status.Pin.Until.Time.Equal(until) || status.Pin.Value != "10" {
+		t.Errorf("status.Pin = %+v, want pinned at 10 until %v", status.Pin, until)
+	}
+
+	// the status isn't written again while it doesn't change
+	client.ClearActions()
+	provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+	provider.statuses.flush()
+	if len(client.Actions()) != 0 {
+		t.Errorf("actions = %v, want none while the status is unchanged", client.Actions())
+	}
+
+	// once the pin expires it is removed from the status
+	provider.maintenance.now = func() time.Time { return until }
+	provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+	provider.statuses.flush()
+
+	status = externalMetricStatus(t, client)
+	if status.Pin != nil {
+		t.Errorf("status.Pin = %+v, want nil after the pin expired", status.Pin)
+	}
+}
+
+func TestIncidentConditionWrittenToStatus(t *testing.T) {
+	externalClient := newFakeExternalMetricClient(externalmetrics.AzureExternalMetricResponse{Total: 5}, nil)
+	client := fake.NewSimpleClientset(&api.ExternalMetric{ObjectMeta: metav1.ObjectMeta{Name: "queue", Namespace: "default"}})
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = newFakeClientFactory(externalClient)
+	provider.defaultSubscriptionID = "1234"
+	provider.serviceHealth = newFakeServiceHealth(map[string]string{"1234": "AB-123"}, nil)
+	provider.maintenance = newMaintenance(externalmetrics.MaintenanceDefinition{}, 0)
+	provider.statuses = NewMetricStatuses(client.AzureV1alpha2())
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{MetricName: "Messages"})
+
+	selector, _ := labels.Parse("")
+	provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+	externalClient.GetAzureMetricReturns(externalmetrics.AzureExternalMetricResponse{}, errors.New("monitor unavailable"))
+	if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"}); err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	provider.statuses.flush()
+
+	status := externalMetricStatus(t, client)
+	if len(status.Conditions) != 1 || status.Conditions[0].Type != IncidentCondition || status.Conditions[0].Reason != "AB-123" {
+		t.Errorf("status.Conditions = %+v, want %s with reason AB-123", status.Conditions, IncidentCondition)
+	}
+
+	// the condition is removed once the metric is queried again
+	externalClient.GetAzureMetricReturns(externalmetrics.AzureExternalMetricResponse{Total: 5}, nil)
+	provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+	provider.statuses.flush()
+
+	status = externalMetricStatus(t, client)
+	if len(status.Conditions) != 0 {
+		t.Errorf("status.Conditions = %+v, want none after the metric was queried", status.Conditions)
+	}
+}
+
+func TestStatusOfMetricWithoutExternalMetricIsNotWritten(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	statuses := NewMetricStatuses(client.AzureV1alpha2())
+
+	statuses.pinned("default", "group-member", &externalmetrics.PinDefinition{Until: time.Now().Add(time.Hour)})
+	statuses.flush()
+
+	if len(statuses.pending) != 0 {
+		t.Errorf("pending = %v, want none for a metric without an ExternalMetric", statuses.pending)
+	}
+}
+
+// externalMetricStatus returns the status written to the queue ExternalMetric
+func externalMetricStatus(t *testing.T, client *fake.Clientset) api.ExternalMetricStatus {
+	metric, err := client.AzureV1alpha2().ExternalMetrics("default").Get("queue", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unable to get external metric: %v", err)
+	}
+	return metric.Status
+}
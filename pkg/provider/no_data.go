@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// noDataValues keeps the last value that had data of each metric with the lastValue no data
+// policy, so a metric that Azure Monitor briefly has no values for doesn't flap its autoscaler
+type noDataValues struct {
+	mu     sync.Mutex
+	values map[string]externalmetrics.AzureExternalMetricResponse
+}
+
+func newNoDataValues() *noDataValues {
+	return &noDataValues{values: map[string]externalmetrics.AzureExternalMetricResponse{}}
+}
+
+// apply records a value with data, or returns the value the no data policy of the metric serves
+// in place of a value without data
+func (v *noDataValues) apply(key string, noData externalmetrics.NoDataDefinition, metricValue externalmetrics.AzureExternalMetricResponse) (externalmetrics.AzureExternalMetricResponse, error) {
+	var last *externalmetrics.AzureExternalMetricResponse
+	if v != nil && noData.Policy == externalmetrics.NoDataLastValue {
+		v.mu.Lock()
+		if !metricValue.NoData {
+			v.values[key] = metricValue
+		} else if value, found := v.values[key]; found {
+			last = &value
+		}
+		v.mu.Unlock()
+	}
+
+	served, ok := noData.Apply(metricValue, last)
+	if !ok {
+		return served, errors.NewServiceUnavailable(fmt.Sprintf("no values of %s in its timespan", key))
+	}
+	if metricValue.NoData && noData.Policy != "" {
+		glog.V(2).Infof("no values of %s in its timespan, serving %f by the %s policy", key, served.Total, noData.Policy)
+	}
+	return served, nil
+}
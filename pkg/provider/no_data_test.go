@@ -0,0 +1,37 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestNoDataServesLastValue(t *testing.T) {
+	values := newNoDataValues()
+	noData := externalmetrics.NoDataDefinition{Policy: externalmetrics.NoDataLastValue}
+
+	_, err := values.apply("default/queue/", noData, externalmetrics.AzureExternalMetricResponse{NoData: true})
+	if !k8serrors.IsServiceUnavailable(err) {
+		t.Errorf("error before any value got: %v, want service unavailable", err)
+	}
+
+	values.apply("default/queue/", noData, externalmetrics.AzureExternalMetricResponse{Total: 7})
+	served, err := values.apply("default/queue/", noData, externalmetrics.AzureExternalMetricResponse{NoData: true})
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if served.Total != 7 {
+		t.Errorf("value = %v, want the last value %v", served.Total, 7)
+	}
+}
+
+func TestNoDataOfMetricWithoutPolicyIsZero(t *testing.T) {
+	var values *noDataValues
+
+	served, err := values.apply("default/queue/", externalmetrics.NoDataDefinition{}, externalmetrics.AzureExternalMetricResponse{NoData: true})
+
+	if err != nil || served.Total != 0 {
+		t.Errorf("apply() = %v, %v, want 0", served.Total, err)
+	}
+}
@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// NodeLabel in the metric selector names the node an ExternalMetric with node enabled is resolved for
+const NodeLabel = "node"
+
+const defaultNodeDimension = "VMName"
+
+var nodesResource = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+
+// scaleSetInstance is the virtual machine scale set instance backing a node
+type scaleSetInstance struct {
+	subscriptionID string
+	resourceGroup  string
+	scaleSet       string
+	instanceID     string
+}
+
+// resolveNode points the request at the scale set of the node named in the selector and filters
+// the metric to the node's instance
+func (p *AzureProvider) resolveNode(metricSelector labels.Selector, azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricRequest, error) {
+	if azMetricRequest.SplitDimension != "" || len(azMetricRequest.Subscriptions) > 0 {
+		return azMetricRequest, errors.NewBadRequest("a node metric can not be split or aggregated across subscriptions")
+	}
+
+	nodeName := ""
+	requirements, _ := metricSelector.Requirements()
+	for _, requirement := range requirements {
+		if requirement.Key() == NodeLabel && (requirement.Operator() == selection.Equals || requirement.Operator() == selection.DoubleEquals) {
+			nodeName = requirement.Values().List()[0]
+		}
+	}
+	if nodeName == "" {
+		return azMetricRequest, errors.NewBadRequest(fmt.Sprintf("the metric selector must name a node with the %s label", NodeLabel))
+	}
+
+	node, err := p.kubeClient.Resource(nodesResource).Get(nodeName, metav1.GetOptions{})
+	if err != nil {
+		glog.Errorf("unable to get node %s: %v", nodeName, err)
+		return azMetricRequest, err
+	}
+
+	providerID, _, _ := unstructured.NestedString(node.Object, "spec", "providerID")
+	instance, err := parseScaleSetProviderID(providerID)
+	if err != nil {
+		return azMetricRequest, errors.NewBadRequest(fmt.Sprintf("node %s: %v", nodeName, err))
+	}
+
+	dimension := azMetricRequest.Node.Dimension
+	if dimension == "" {
+		dimension = defaultNodeDimension
+	}
+	filter := fmt.Sprintf("%s eq '%s_%s'", dimension, instance.scaleSet, instance.instanceID)
+	if azMetricRequest.Filter != "" {
+		filter = fmt.Sprintf("%s and %s", azMetricRequest.Filter, filter)
+	}
+
+	glog.V(2).Infof("resolved node %s to scale set %s instance %s", nodeName, instance.scaleSet, instance.instanceID)
+	azMetricRequest.SubscriptionID = instance.subscriptionID
+	azMetricRequest.ResourceGroup = instance.resourceGroup
+	azMetricRequest.ResourceProviderNamespace = "Microsoft.Compute"
+	azMetricRequest.ResourceType = "virtualMachineScaleSets"
+	azMetricRequest.ResourceName = instance.scaleSet
+	azMetricRequest.Filter = filter
+	return azMetricRequest, nil
+}
+
+// parseScaleSetProviderID parses the provider id the azure cloud provider sets on scale set nodes:
+// azure:///subscriptions/<id>/resourceGroups/<group>/providers/Microsoft.Compute/virtualMachineScaleSets/<name>/virtualMachines/<instance>
+func parseScaleSetProviderID(providerID string) (scaleSetInstance, error) {
+	parts := strings.Split(strings.TrimPrefix(providerID, "azure:///"), "/")
+	if len(parts) != 10 || !strings.EqualFold(parts[0], "subscriptions") || !strings.EqualFold(parts[2], "resourceGroups") ||
+		!strings.EqualFold(parts[4], "providers") || !strings.EqualFold(parts[5], "Microsoft.Compute") ||
+		!strings.EqualFold(parts[6], "virtualMachineScaleSets") || !strings.EqualFold(parts[8], "virtualMachines") {
+		return scaleSetInstance{}, fmt.Errorf("provider id '%s' is not a virtual machine scale set instance", providerID)
+	}
+
+	return scaleSetInstance{
+		subscriptionID: parts[1],
+		resourceGroup:  parts[3],
+		scaleSet:       parts[7],
+		instanceID:     parts[9],
+	}, nil
+}
@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/scheme"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sclient "k8s.io/client-go/dynamic/fake"
+)
+
+const testProviderID = "azure:///subscriptions/1234/resourceGroups/mc_rg_cluster_eastus/providers/Microsoft.Compute/virtualMachineScaleSets/aks-nodepool1-12345678-vmss/virtualMachines/3"
+
+func TestResolveNodeToScaleSetInstance(t *testing.T) {
+	provider := newNodeProvider(testProviderID)
+
+	selector, _ := labels.Parse("node=aks-nodepool1-12345678-vmss000003")
+	request := externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Percentage CPU",
+		Filter:     "Region eq 'eastus'",
+		Node:       externalmetrics.NodeDefinition{Enabled: true},
+	}
+
+	resolved, err := provider.resolveNode(selector, request)
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if resolved.MetricResourceURI() != "/subscriptions/1234/resourceGroups/mc_rg_cluster_eastus/providers/Microsoft.Compute/virtualMachineScaleSets/aks-nodepool1-12345678-vmss" {
+		t.Errorf("resolved.MetricResourceURI() = %v, want the scale set", resolved.MetricResourceURI())
+	}
+
+	if resolved.Filter != "Region eq 'eastus' and VMName eq 'aks-nodepool1-12345678-vmss_3'" {
+		t.Errorf("resolved.Filter = %v, want the instance filter", resolved.Filter)
+	}
+}
+
+func TestResolveNodeErrors(t *testing.T) {
+	var tests = []struct {
+		name       string
+		providerID string
+		selector   string
+	}{
+		{"no node label", testProviderID, ""},
+		{"unknown node", testProviderID, "node=missing"},
+		{"availability set node", "azure:///subscriptions/1234/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/aks-agentpool-0", "node=aks-nodepool1-12345678-vmss000003"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := newNodeProvider(tt.providerID)
+
+			selector, _ := labels.Parse(tt.selector)
+			request := externalmetrics.AzureExternalMetricRequest{Node: externalmetrics.NodeDefinition{Enabled: true}}
+			_, err := provider.resolveNode(selector, request)
+
+			if err == nil {
+				t.Errorf("no error after processing got: %v, want error", nil)
+			}
+			if tt.name != "unknown node" && !k8serrors.IsBadRequest(err) {
+				t.Errorf("error after processing got: %v, want bad request", err)
+			}
+		})
+	}
+}
+
+func newNodeProvider(providerID string) AzureProvider {
+	node := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Node",
+			"metadata": map[string]interface{}{
+				"name": "aks-nodepool1-12345678-vmss000003",
+			},
+			"spec": map[string]interface{}{
+				"providerID": providerID,
+			},
+		},
+	}
+
+	return AzureProvider{
+		kubeClient: k8sclient.NewSimpleDynamicClient(scheme.Scheme, node),
+	}
+}
@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/metriccache"
+	"github.com/golang/glog"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// observeParallelism is the number of metrics evaluated at the same time in observer mode
+const observeParallelism = 10
+
+var (
+	observedValue = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "azure_metrics_adapter_external_metric_value",
+		Help: "Value of the ExternalMetric when it was last evaluated in observer mode.",
+	}, []string{"namespace", "metric"})
+	observeFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "azure_metrics_adapter_external_metric_failures_total",
+		Help: "Evaluations of the ExternalMetric in observer mode that failed.",
+	}, []string{"namespace", "metric"})
+)
+
+func init() {
+	prometheus.MustRegister(observedValue, observeFailures)
+}
+
+// Observer evaluates every external metric at an interval as an autoscaler requesting it would,
+// recording its value in the status of its ExternalMetric and as a prometheus gauge.  Platform
+// teams can run the adapter as an observer, without registering it as the external metrics api,
+// alongside their current metrics provider to compare the signals before switching to it.
+type Observer struct {
+	provider    k8sprovider.ExternalMetricsProvider
+	metricCache *metriccache.MetricCache
+	statuses    *MetricStatuses
+
+	mu sync.Mutex
+	// observed are the metrics that have been evaluated, so have series in the gauge or counter
+	observed map[types.NamespacedName]bool
+}
+
+// NewObserver creates an observer of the metrics of the cache served by the provider
+func NewObserver(metricsProvider k8sprovider.ExternalMetricsProvider, metricCache *metriccache.MetricCache, statuses *MetricStatuses) *Observer {
+	return &Observer{
+		provider:    metricsProvider,
+		metricCache: metricCache,
+		statuses:    statuses,
+		observed:    map[types.NamespacedName]bool{},
+	}
+}
+
+// Run evaluates the metrics every interval until the channel is closed
+func (o *Observer) Run(interval time.Duration, stopCh <-chan struct{}) {
+	wait.Until(o.observe, interval, stopCh)
+}
+
+// observe evaluates every metric once, a few at a time so a slow query doesn't hold up the others
+func (o *Observer) observe() {
+	metrics := o.metricCache.ListAzureExternalMetricRequests()
+	o.forget(metrics)
+
+	names := make(chan types.NamespacedName)
+	wg := sync.WaitGroup{}
+	for i := 0; i < observeParallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range names {
+				o.evaluate(name)
+			}
+		}()
+	}
+	for name := range metrics {
+		names <- name
+	}
+	close(names)
+	wg.Wait()
+}
+
+// evaluate requests the metric and records its value, the sum of the values served for it
+func (o *Observer) evaluate(name types.NamespacedName) {
+	o.mu.Lock()
+	o.observed[name] = true
+	o.mu.Unlock()
+
+	values, err := o.provider.GetExternalMetric(name.Namespace, labels.Everything(), k8sprovider.ExternalMetricInfo{Metric: name.Name})
+	if err != nil {
+		glog.V(2).Infof("unable to observe external metric %s: %v", name, err)
+		observeFailures.WithLabelValues(name.Namespace, name.Name).Inc()
+		// a stale value isn't compared with the signal of the current provider
+		observedValue.DeleteLabelValues(name.Namespace, name.Name)
+		o.statuses.observed(name.Namespace, name.Name, 0, err)
+		return
+	}
+
+	total := 0.0
+	for _, value := range values.Items {
+		total += float64(value.Value.MilliValue()) / 1000
+	}
+	glog.V(4).Infof("observed external metric %s at %g", name, total)
+	observedValue.WithLabelValues(name.Namespace, name.Name).Set(total)
+	o.statuses.observed(name.Namespace, name.Name, total, nil)
+}
+
+// forget removes the series of the metrics that are no longer served
+func (o *Observer) forget(metrics map[types.NamespacedName]externalmetrics.AzureExternalMetricRequest) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for name := range o.observed {
+		if _, found := metrics[name]; !found {
+			observedValue.DeleteLabelValues(name.Namespace, name.Name)
+			observeFailures.DeleteLabelValues(name.Namespace, name.Name)
+			delete(o.observed, name)
+		}
+	}
+}
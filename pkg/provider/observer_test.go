@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/fake"
+	dto "github.com/prometheus/client_model/go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestObserverRecordsValueOfEveryMetric(t *testing.T) {
+	externalClient := newFakeExternalMetricClient(externalmetrics.AzureExternalMetricResponse{Total: 5}, nil)
+	client := fake.NewSimpleClientset(&api.ExternalMetric{ObjectMeta: metav1.ObjectMeta{Name: "queue", Namespace: "default"}})
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = newFakeClientFactory(externalClient)
+	provider.statuses = NewMetricStatuses(client.AzureV1alpha2())
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{MetricName: "Messages"})
+	observer := NewObserver(&provider, provider.metricCache, provider.statuses)
+
+	observer.observe()
+	provider.statuses.flush()
+
+	if status := externalMetricStatus(t, client); status.Observed == nil || status.Observed.Value != "5" {
+		t.Errorf("status.Observed = %+v, want the value 5", status.Observed)
+	}
+	if value := observedGauge(t, "default", "queue"); value != 5 {
+		t.Errorf("gauge = %v, want 5", value)
+	}
+
+	// a failed evaluation is recorded rather than the stale value
+	externalClient.GetAzureMetricReturns(externalmetrics.AzureExternalMetricResponse{}, errors.New("monitor unavailable"))
+	observer.observe()
+	provider.statuses.flush()
+
+	if status := externalMetricStatus(t, client); status.Observed == nil || status.Observed.Value != "" || status.Observed.Error == "" {
+		t.Errorf("status.Observed = %+v, want the error", status.Observed)
+	}
+	if deleted := observedValue.DeleteLabelValues("default", "queue"); deleted {
+		t.Errorf("gauge was kept after the evaluation failed")
+	}
+
+	// the series of a removed metric are deleted
+	provider.metricCache.Remove("ExternalMetric/default/queue")
+	observer.observe()
+	if deleted := observeFailures.DeleteLabelValues("default", "queue"); deleted {
+		t.Errorf("failures were kept after the metric was removed")
+	}
+}
+
+// observedGauge returns the value of the gauge of the metric
+func observedGauge(t *testing.T, namespace string, name string) float64 {
+	metric := &dto.Metric{}
+	if err := observedValue.WithLabelValues(namespace, name).Write(metric); err != nil {
+		t.Fatalf("unable to read gauge: %v", err)
+	}
+	return metric.GetGauge().GetValue()
+}
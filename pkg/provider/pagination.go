@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ContinueHeader is the response header of a paginated debug listing holding the continue token of
+// the next page, unset on the last page
+const ContinueHeader = "X-Continue"
+
+// page returns the bounds of the items of a listing of count items served in the page requested
+// by the limit and continue query parameters, and sets the continue token of the next page.  The
+// whole listing is served without a limit.  An invalid limit or token is written as a bad request
+// and false is returned.
+func page(w http.ResponseWriter, req *http.Request, count int) (int, int, bool) {
+	query := req.URL.Query()
+	start := 0
+	if token := query.Get("continue"); token != "" {
+		var err error
+		if start, err = strconv.Atoi(token); err != nil || start < 0 {
+			http.Error(w, fmt.Sprintf("invalid continue token '%s'", token), http.StatusBadRequest)
+			return 0, 0, false
+		}
+	}
+	if start > count {
+		start = count
+	}
+
+	end := count
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			http.Error(w, fmt.Sprintf("invalid limit '%s', must be a positive number", value), http.StatusBadRequest)
+			return 0, 0, false
+		}
+		if start+limit < count {
+			end = start + limit
+			w.Header().Set(ContinueHeader, strconv.Itoa(end))
+		}
+	}
+	return start, end, true
+}
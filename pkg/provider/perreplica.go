@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	defaultPerReplicaAPIVersion = "apps/v1"
+	defaultPerReplicaKind       = "Deployment"
+)
+
+// perReplica divides the value and each series of the metric by the current replicas of the
+// scale target.  A target scaled to zero divides by one so the autoscaler can scale it back up.
+func (p *AzureProvider) perReplica(namespace string, target externalmetrics.PerReplicaDefinition, metricValue externalmetrics.AzureExternalMetricResponse) (externalmetrics.AzureExternalMetricResponse, error) {
+	replicas, err := p.currentReplicas(namespace, target)
+	if err != nil {
+		return metricValue, err
+	}
+	if replicas < 1 {
+		replicas = 1
+	}
+
+	divided := externalmetrics.AzureExternalMetricResponse{
+		Total: metricValue.Total / float64(replicas),
+		Unit:  metricValue.Unit,
+		Raw:   metricValue.Raw,
+	}
+	for _, series := range metricValue.Series {
+		divided.Series = append(divided.Series, externalmetrics.MetricSeries{
+			Labels: series.Labels,
+			Value:  series.Value / float64(replicas),
+		})
+	}
+	return divided, nil
+}
+
+// currentReplicas reads the replicas in the status of the scale subresource of the target
+func (p *AzureProvider) currentReplicas(namespace string, target externalmetrics.PerReplicaDefinition) (int64, error) {
+	if target.Name == "" {
+		return 0, errors.NewBadRequest("perReplica must name the scale target")
+	}
+
+	apiVersion, kind := target.APIVersion, target.Kind
+	if apiVersion == "" {
+		apiVersion = defaultPerReplicaAPIVersion
+	}
+	if kind == "" {
+		kind = defaultPerReplicaKind
+	}
+	groupVersion, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return 0, errors.NewBadRequest(fmt.Sprintf("invalid perReplica apiVersion '%s': %v", apiVersion, err))
+	}
+
+	mapping, err := p.mapper.RESTMapping(groupVersion.WithKind(kind).GroupKind(), groupVersion.Version)
+	if err != nil {
+		return 0, errors.NewBadRequest(fmt.Sprintf("unable to find the scale target kind %s: %v", kind, err))
+	}
+
+	scale, err := p.kubeClient.Resource(mapping.Resource).Namespace(namespace).Get(target.Name, metav1.GetOptions{}, "scale")
+	if err != nil {
+		glog.Errorf("unable to get the scale of %s %s/%s: %v", kind, namespace, target.Name, err)
+		return 0, err
+	}
+
+	replicas, _, err := unstructured.NestedInt64(scale.Object, "status", "replicas")
+	if err != nil {
+		return 0, errors.NewBadRequest(fmt.Sprintf("invalid replicas in the scale of %s %s/%s: %v", kind, namespace, target.Name, err))
+	}
+	return replicas, nil
+}
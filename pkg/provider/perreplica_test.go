@@ -0,0 +1,125 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/scheme"
+	"github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/dynamicmapper"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sclient "k8s.io/client-go/dynamic/fake"
+	core "k8s.io/client-go/testing"
+)
+
+func TestPerReplicaDividesByCurrentReplicas(t *testing.T) {
+	// the fake client returns 15
+	tests := []struct {
+		replicas int64
+		want     int64
+	}{
+		{replicas: 3, want: 5},
+		{replicas: 0, want: 15},
+	}
+	for _, tt := range tests {
+		provider := newPerReplicaProvider(newDeployment("default", "consumer", tt.replicas))
+		provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+			MetricName: "Messages",
+			PerReplica: externalmetrics.PerReplicaDefinition{Enabled: true, Name: "consumer"},
+		})
+
+		selector, _ := labels.Parse("")
+		returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+
+		if err != nil {
+			t.Fatalf("error after processing got: %v, want nil", err)
+		}
+
+		if returnList.Items[0].Value.Value() != tt.want {
+			t.Errorf("%d replicas: externalMetric.Value = %v, want there %v", tt.replicas, returnList.Items[0].Value.Value(), tt.want)
+		}
+	}
+}
+
+func TestPerReplicaDividesEachSeries(t *testing.T) {
+	provider := newPerReplicaProvider(newDeployment("default", "consumer", 5))
+	provider.metricCache.Update("ExternalMetric/default/queues", externalmetrics.AzureExternalMetricRequest{
+		MetricName:     "ActiveMessages",
+		SplitDimension: "EntityName",
+		PerReplica:     externalmetrics.PerReplicaDefinition{Enabled: true, Kind: "Deployment", Name: "consumer"},
+	})
+
+	selector, _ := labels.Parse("")
+	returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queues"})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	want := map[string]int64{"orders": 2, "payments": 1}
+	for _, item := range returnList.Items {
+		entity := item.MetricLabels["entityname"]
+		if item.Value.Value() != want[entity] {
+			t.Errorf("%s value = %v, want there %v", entity, item.Value.Value(), want[entity])
+		}
+	}
+}
+
+func TestPerReplicaMissingTargetGetError(t *testing.T) {
+	provider := newPerReplicaProvider(newDeployment("default", "consumer", 3))
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		PerReplica: externalmetrics.PerReplicaDefinition{Enabled: true, Name: "producer"},
+	})
+
+	selector, _ := labels.Parse("")
+	_, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+
+	if !k8serrors.IsNotFound(err) {
+		t.Errorf("error after processing got: %v, want not found", err)
+	}
+}
+
+func TestPerReplicaUnknownKindGetError(t *testing.T) {
+	provider := newPerReplicaProvider(newDeployment("default", "consumer", 3))
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		PerReplica: externalmetrics.PerReplicaDefinition{Enabled: true, Kind: "Rollout", Name: "consumer"},
+	})
+
+	selector, _ := labels.Parse("")
+	_, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+
+	if !k8serrors.IsBadRequest(err) {
+		t.Errorf("error after processing got: %v, want bad request", err)
+	}
+}
+
+func newDeployment(namespace, name string, replicas int64) *unstructured.Unstructured {
+	deployment := newUnstructured("apps/v1", "Deployment", namespace, name)
+	unstructured.SetNestedField(deployment.Object, replicas, "status", "replicas")
+	return deployment
+}
+
+func newPerReplicaProvider(deployment *unstructured.Unstructured) AzureProvider {
+	fakeDiscovery := &dynamicmapper.FakeDiscovery{Fake: &core.Fake{}}
+	mapper, _ := dynamicmapper.NewRESTMapper(fakeDiscovery, 1*time.Second)
+	fakeDiscovery.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", Namespaced: true, Kind: "Deployment"},
+			},
+		},
+	}
+	mapper.RegenerateMappings()
+
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.mapper = mapper
+	provider.kubeClient = k8sclient.NewSimpleDynamicClient(scheme.Scheme, deployment)
+	return provider
+}
@@ -3,9 +3,12 @@
 package provider
 
 import (
+	"time"
+
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/custommetrics"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/metriccache"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/policy"
 	"github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/client-go/dynamic"
@@ -18,15 +21,91 @@ type AzureProvider struct {
 	metricCache           *metriccache.MetricCache
 	azureClientFactory    externalmetrics.AzureClientFactory
 	defaultSubscriptionID string
+	policyEnforcer        *policy.Enforcer
+	activityTracker       *activityTracker
+	subscriptionLister    externalmetrics.SubscriptionLister
+	resourceLister        externalmetrics.ResourceLister
+	applicationGatewayID  string
+	alertChecker          externalmetrics.AlertChecker
+	serviceHealth         externalmetrics.ServiceHealth
+	backpressure          *backpressure
+	maintenance           *maintenance
+	shadows               *shadowComparisons
+	rawResponses          *RawResponses
+	statuses              *MetricStatuses
+	apiCosts              *APICosts
+	timeouts              *timeouts
+	deletionGrace         *deletionGrace
+	undiscovered          bool
+	noData                *noDataValues
+	ingestedMetrics       *IngestedMetrics
+	credentials           *CredentialPool
+	tenants               *tenantSources
+	variants              *variantValues
+}
+
+// AzureProviderOptions configure the AzureProvider.  The features whose options are left unset are
+// disabled.
+type AzureProviderOptions struct {
+	// DefaultSubscriptionID is the subscription of the metrics that don't name one
+	DefaultSubscriptionID string
+	Mapper                apimeta.RESTMapper
+	KubeClient            dynamic.Interface
+	AppInsightsClient     custommetrics.AzureAppInsightsClient
+	AzureClientFactory    externalmetrics.AzureClientFactory
+	MetricCache           *metriccache.MetricCache
+	PolicyEnforcer        *policy.Enforcer
+	SubscriptionLister    externalmetrics.SubscriptionLister
+	ResourceLister        externalmetrics.ResourceLister
+	// ApplicationGatewayID is the application gateway serving the custom metrics of ingresses
+	ApplicationGatewayID string
+	AlertChecker         externalmetrics.AlertChecker
+	ServiceHealth        externalmetrics.ServiceHealth
+	// ARMQuota spaces out the queries of subscriptions running out of quota when set
+	ARMQuota *externalmetrics.ARMQuota
+	// MaintenanceWindows freeze every external metric
+	MaintenanceWindows externalmetrics.MaintenanceDefinition
+	// MaxPinDuration is the longest time ahead an external metric can be pinned
+	MaxPinDuration time.Duration
+	RawResponses   *RawResponses
+	Statuses       *MetricStatuses
+	APICosts       *APICosts
+	// DeletionGracePeriod is how long deleted external metrics keep being served
+	DeletionGracePeriod time.Duration
+	// Undiscovered hides the external metrics from the discovery api
+	Undiscovered    bool
+	IngestedMetrics *IngestedMetrics
+	CredentialPool  *CredentialPool
 }
 
-func NewAzureProvider(defaultSubscriptionID string, mapper apimeta.RESTMapper, kubeClient dynamic.Interface, appinsightsClient custommetrics.AzureAppInsightsClient, azureClientFactory externalmetrics.AzureClientFactory, metricCache *metriccache.MetricCache) provider.MetricsProvider {
+func NewAzureProvider(options AzureProviderOptions) provider.MetricsProvider {
 	return &AzureProvider{
-		defaultSubscriptionID: defaultSubscriptionID,
-		mapper:                mapper,
-		kubeClient:            kubeClient,
-		appinsightsClient:     appinsightsClient,
-		metricCache:           metricCache,
-		azureClientFactory:    azureClientFactory,
+		defaultSubscriptionID: options.DefaultSubscriptionID,
+		mapper:                options.Mapper,
+		kubeClient:            options.KubeClient,
+		appinsightsClient:     options.AppInsightsClient,
+		metricCache:           options.MetricCache,
+		azureClientFactory:    options.AzureClientFactory,
+		policyEnforcer:        options.PolicyEnforcer,
+		activityTracker:       newActivityTracker(),
+		subscriptionLister:    options.SubscriptionLister,
+		resourceLister:        options.ResourceLister,
+		applicationGatewayID:  options.ApplicationGatewayID,
+		alertChecker:          options.AlertChecker,
+		serviceHealth:         options.ServiceHealth,
+		backpressure:          newBackpressure(options.ARMQuota),
+		maintenance:           newMaintenance(options.MaintenanceWindows, options.MaxPinDuration),
+		shadows:               newShadowComparisons(),
+		rawResponses:          options.RawResponses,
+		statuses:              options.Statuses,
+		apiCosts:              options.APICosts,
+		timeouts:              newTimeouts(),
+		deletionGrace:         newDeletionGrace(options.DeletionGracePeriod),
+		undiscovered:          options.Undiscovered,
+		noData:                newNoDataValues(),
+		ingestedMetrics:       options.IngestedMetrics,
+		credentials:           options.CredentialPool,
+		tenants:               newTenantSources(),
+		variants:              newVariantValues(),
 	}
 }
@@ -4,15 +4,17 @@ package provider
 
 import (
 	"fmt"
+	"sort"
 	"strings"
-	"time"
 
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/custommetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
 	"github.com/golang/glog"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/metrics/pkg/apis/custom_metrics"
 
@@ -21,15 +23,57 @@ import (
 )
 
 // GetMetricByName fetches a particular metric for a particular object.
-// The namespace will be empty if the metric is root-scoped.
+// The namespace will be empty if the metric is root-scoped.  Errors are redacted as they are
+// returned to the callers of the metrics apis.
 func (p *AzureProvider) GetMetricByName(name types.NamespacedName, info provider.CustomMetricInfo) (*custom_metrics.MetricValue, error) {
-	// not implemented yet
-	return nil, errors.NewServiceUnavailable("not implemented yet")
+	value, err := p.getMetricByName(name, info)
+	return value, redact.Error(err)
+}
+
+func (p *AzureProvider) getMetricByName(name types.NamespacedName, info provider.CustomMetricInfo) (*custom_metrics.MetricValue, error) {
+	glog.V(0).Infof("Received request for custom metric: groupresource: %s, name: %s, metric name: %s", info.GroupResource.String(), name, info.Metric)
+
+	// values posted by apps of the namespace are served for its pods
+	if info.GroupResource.Resource == podsResource {
+		if values, found := p.ingestedMetrics.get(name.Namespace, info.Metric); found {
+			return p.getIngestedMetric(name, info, values)
+		}
+	}
+
+	// ingresses are served from the metrics of their Application Gateway
+	if info.GroupResource.Resource != ingressGroupResource.Resource {
+		return nil, errors.NewServiceUnavailable("not implemented yet")
+	}
+
+	val, err := p.getIngressMetric(name, info)
+	if err != nil {
+		return nil, err
+	}
+
+	ref, err := helpers.ReferenceFor(p.mapper, name, info)
+	if err != nil {
+		return nil, err
+	}
+
+	return &custom_metrics.MetricValue{
+		DescribedObject: ref,
+		Metric: custom_metrics.MetricIdentifier{
+			Name: info.Metric,
+		},
+		Timestamp: metav1.Now(),
+		Value:     *resource.NewMilliQuantity(int64(val*1000), resource.DecimalSI),
+	}, nil
 }
 
 // GetMetricBySelector fetches a particular metric for a set of objects matching
 // the given label selector.  The namespace will be empty if the metric is root-scoped.
+// Errors are redacted as they are returned to the callers of the metrics apis.
 func (p *AzureProvider) GetMetricBySelector(namespace string, selector labels.Selector, info provider.CustomMetricInfo) (*custom_metrics.MetricValueList, error) {
+	values, err := p.getMetricBySelector(namespace, selector, info)
+	return values, redact.Error(err)
+}
+
+func (p *AzureProvider) getMetricBySelector(namespace string, selector labels.Selector, info provider.CustomMetricInfo) (*custom_metrics.MetricValueList, error) {
 	glog.V(0).Infof("Received request for custom metric: groupresource: %s, namespace: %s, metric name: %s, selectors: %s", info.GroupResource.String(), namespace, info.Metric, selector.String())
 
 	_, selectable := selector.Requirements()
@@ -37,11 +81,22 @@ func (p *AzureProvider) GetMetricBySelector(namespace string, selector labels.Se
 		return nil, errors.NewBadRequest("label is set to not selectable. this should not happen")
 	}
 
+	if info.GroupResource.Resource == podsResource {
+		if values, found := p.ingestedMetrics.get(namespace, info.Metric); found {
+			return p.getIngestedMetrics(namespace, selector, info, values)
+		}
+	}
+
 	metricRequestInfo := p.getCustomMetricRequest(namespace, selector, info)
 
 	// TODO use selector info to restrict metric query to specific app.
+	p.apiCosts.record(namespace, info.Metric, appInsightsBackend)
 	val, err := p.appinsightsClient.GetCustomMetric(metricRequestInfo)
+	if err == nil {
+		val, err = metricRequestInfo.Transform.Apply(val, "")
+	}
 	if err != nil {
+		err = redact.Error(err)
 		glog.Errorf("bad request: %v", err)
 		return nil, errors.NewBadRequest(err.Error())
 	}
@@ -69,7 +124,7 @@ func (p *AzureProvider) GetMetricBySelector(namespace string, selector labels.Se
 			Metric: custom_metrics.MetricIdentifier{
 				Name: info.Metric,
 			},
-			Timestamp: metav1.Time{time.Now()},
+			Timestamp: metav1.Now(),
 			Value:     *resource.NewMilliQuantity(int64(val*1000), resource.DecimalSI),
 		}
 
@@ -95,8 +150,44 @@ func (p *AzureProvider) GetMetricBySelector(namespace string, selector labels.Se
 // an error, so it is reccomended that implementors cache and
 // periodically update this list, instead of querying every time.
 func (p *AzureProvider) ListAllMetrics() []provider.CustomMetricInfo {
-	// not implemented yet
-	return []provider.CustomMetricInfo{}
+	// the cache is kept up to date from the CustomMetric resources so it is cheap to list.
+	// custom metrics are served for the pods in the namespace of the CustomMetric.
+	names := map[string]bool{}
+	for name := range p.metricCache.ListAppInsightsRequests() {
+		names[name.Name] = true
+	}
+	for _, name := range p.ingestedMetrics.names() {
+		names[name] = true
+	}
+
+	customMetricsInfo := []provider.CustomMetricInfo{}
+	for name := range names {
+		customMetricsInfo = append(customMetricsInfo, provider.CustomMetricInfo{
+			GroupResource: schema.GroupResource{Resource: podsResource},
+			Namespaced:    true,
+			Metric:        name,
+		})
+	}
+	sort.Slice(customMetricsInfo, func(i, j int) bool {
+		return customMetricsInfo[i].Metric < customMetricsInfo[j].Metric
+	})
+
+	if p.applicationGatewayID != "" {
+		ingressMetrics := []provider.CustomMetricInfo{}
+		for name := range applicationGatewayMetrics {
+			ingressMetrics = append(ingressMetrics, provider.CustomMetricInfo{
+				GroupResource: ingressGroupResource,
+				Namespaced:    true,
+				Metric:        name,
+			})
+		}
+		sort.Slice(ingressMetrics, func(i, j int) bool {
+			return ingressMetrics[i].Metric < ingressMetrics[j].Metric
+		})
+		customMetricsInfo = append(customMetricsInfo, ingressMetrics...)
+	}
+
+	return customMetricsInfo
 }
 
 func (p *AzureProvider) getCustomMetricRequest(namespace string, selector labels.Selector, info provider.CustomMetricInfo) custommetrics.MetricRequest {
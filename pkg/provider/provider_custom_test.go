@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/custommetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/scheme"
 
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/metriccache"
@@ -185,6 +186,27 @@ func TestReturnsErrorIfAppInsightsFails(t *testing.T) {
 	}
 }
 
+func TestListAllMetricsFromCache(t *testing.T) {
+	provider, cache := newFakeCustomProvider(fakeAppInsightsClient{}, nil)
+
+	cache.Update("CustomMetric/default/rps", custommetrics.MetricRequest{MetricName: "performanceCounters/requestsPerSecond"})
+	cache.Update("CustomMetric/other/rps", custommetrics.MetricRequest{MetricName: "performanceCounters/requestsPerSecond"})
+	cache.Update("CustomMetric/default/latency", custommetrics.MetricRequest{MetricName: "requests/duration"})
+	cache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{MetricName: "Messages"})
+
+	metrics := provider.ListAllMetrics()
+
+	if len(metrics) != 2 {
+		t.Fatalf("len(metrics) = %v, want there 2", len(metrics))
+	}
+
+	for i, name := range []string{"latency", "rps"} {
+		if metrics[i].Metric != name || metrics[i].GroupResource.Resource != "pods" || !metrics[i].Namespaced {
+			t.Errorf("metrics[%d] = %v, want there namespaced pods metric %v", i, metrics[i], name)
+		}
+	}
+}
+
 func newFakeCustomProvider(fakeclient fakeAppInsightsClient, store []runtime.Object) (AzureProvider, *metriccache.MetricCache) {
 	metricCache := metriccache.NewMetricCache()
 
@@ -227,3 +249,7 @@ type fakeAppInsightsClient struct {
 func (f fakeAppInsightsClient) GetCustomMetric(request custommetrics.MetricRequest) (float64, error) {
 	return f.result, f.err
 }
+
+func (f fakeAppInsightsClient) GetMetricTotal(request custommetrics.MetricRequest) (float64, error) {
+	return f.result, f.err
+}
@@ -3,7 +3,14 @@
 package provider
 
 import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/policy"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
 	"github.com/golang/glog"
 	"github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -17,7 +24,13 @@ import (
 // Metric is normally identified by a name and a set of labels/tags. It is up to a specific
 // implementation how to translate metricSelector to a filter for metric values.
 // Namespace can be used by the implementation for metric identification, access control or ignored.
+// Errors are redacted as they are returned to the callers of the metrics apis.
 func (p *AzureProvider) GetExternalMetric(namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
+	values, err := p.getExternalMetric(namespace, metricSelector, info)
+	return values, redact.Error(err)
+}
+
+func (p *AzureProvider) getExternalMetric(namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo) (*external_metrics.ExternalMetricValueList, error) {
 	// Note:
 	//		metric name and namespace is used to lookup for the CRD which contains configuration to call azure
 	// 		if not found then ignored and label selector is parsed for all the metrics
@@ -28,46 +41,346 @@ func (p *AzureProvider) GetExternalMetric(namespace string, metricSelector label
 		return nil, errors.NewBadRequest("label is set to not selectable. this should not happen")
 	}
 
-	azMetricRequest, err := p.getMetricRequest(namespace, info.Metric, metricSelector)
-	if err != nil {
-		return nil, errors.NewBadRequest(err.Error())
+	// variants are served from the value of the ExternalMetric they are named after
+	if name, variant, ok := p.metricCache.GetExternalMetricVariant(namespace, info.Metric); ok {
+		return p.getVariantMetric(namespace, metricSelector, info, name, variant)
+	}
+
+	// activity metrics are served from the ExternalMetric they are named after
+	metricName, isActivity := info.Metric, false
+	if name, ok := activityMetricName(info.Metric); ok {
+		if request, found := p.metricCache.GetAzureExternalMetricRequest(namespace, name); found && request.Activity.Enabled {
+			metricName, isActivity = name, true
+		}
+	}
+
+	// shadow difference metrics are served from the ExternalMetric with the shadow spec
+	isShadowDifference := false
+	if name, ok := shadowMetricName(info.Metric); ok {
+		if request, found := p.metricCache.GetAzureExternalMetricRequest(namespace, name); found && request.Shadow != nil {
+			metricName, isShadowDifference = name, true
+		}
+	}
+
+	// a deleted ExternalMetric is served at its last value for the grace period
+	servedKey := fmt.Sprintf("%s/%s", namespace, info.Metric)
+	_, defined := p.metricCache.GetAzureExternalMetricRequest(namespace, metricName)
+	if !defined {
+		if removedAt, removed := p.metricCache.ExternalMetricRemovedAt(namespace, info.Metric); removed {
+			if served, found := p.deletionGrace.served(servedKey, metricSelector.String(), removedAt); found {
+				return served, nil
+			}
+		}
 	}
 
-	externalMetricClient, err := p.azureClientFactory.GetAzureExternalMetricClient(azMetricRequest.Type)
+	azMetricRequest, err := p.getMetricRequest(namespace, metricName, metricSelector)
 	if err != nil {
 		return nil, errors.NewBadRequest(err.Error())
 	}
 
-	metricValue, err := externalMetricClient.GetAzureMetric(azMetricRequest)
+	if azMetricRequest.Node.Enabled {
+		azMetricRequest, err = p.resolveNode(metricSelector, azMetricRequest)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// a pinned metric, during maintenance the value served before the window, or when the quota of
+	// its subscription is low a recently queried value, is served without querying azure
+	valueKey := fmt.Sprintf("%s/%s/%s", namespace, metricName, metricSelector.String())
+	metricValue, frozen := p.maintenance.pinned(valueKey, azMetricRequest.Pin)
+	if frozen {
+		glog.V(2).Infof("serving %s pinned until %s", valueKey, azMetricRequest.Pin.Until.Format(time.RFC3339))
+		p.rawResponses.recordPinned(namespace, metricName, metricSelector.String(), metricValue, azMetricRequest.Pin.Until)
+		p.statuses.pinned(namespace, metricName, &azMetricRequest.Pin)
+	} else {
+		p.statuses.pinned(namespace, metricName, nil)
+		metricValue, frozen, err = p.maintenance.frozen(valueKey, azMetricRequest.Maintenance)
+		if err != nil {
+			return nil, errors.NewBadRequest(err.Error())
+		}
+	}
+	if !frozen {
+		metricValue, frozen = p.servedUnderBackpressure(valueKey, azMetricRequest)
+	}
+	if !frozen {
+		if azMetricRequest.Timeout != "" {
+			metricValue, err = p.timeouts.query(valueKey, azMetricRequest.Timeout, func() (externalmetrics.AzureExternalMetricResponse, error) {
+				return p.queryExternalMetric(namespace, info.Metric, azMetricRequest)
+			})
+		} else {
+			metricValue, err = p.queryExternalMetric(namespace, info.Metric, azMetricRequest)
+		}
+		if err == nil {
+			metricValue, err = p.noData.apply(valueKey, azMetricRequest.NoData, metricValue)
+		}
+		if err != nil {
+			stale, incident, found := p.servedDuringIncident(valueKey, azMetricRequest, err)
+			if !found {
+				return nil, err
+			}
+			metricValue = stale
+			p.rawResponses.recordIncident(namespace, metricName, metricSelector.String(), metricValue, incident)
+			p.statuses.incident(namespace, metricName, incident)
+		} else {
+			p.maintenance.record(valueKey, metricValue)
+			p.backpressure.record(valueKey)
+			p.rawResponses.record(namespace, metricName, metricSelector.String(), metricValue)
+			p.statuses.incident(namespace, metricName, "")
+
+			if azMetricRequest.Shadow != nil {
+				p.compareShadow(namespace, metricName, metricSelector, *azMetricRequest.Shadow, metricValue)
+			}
+		}
+	}
+
+	if isShadowDifference {
+		difference, found := p.shadows.difference(namespace, metricName)
+		if !found {
+			return nil, errors.NewServiceUnavailable(fmt.Sprintf("the shadow spec of %s has not been compared", metricName))
+		}
+		metricValue = externalmetrics.AzureExternalMetricResponse{Total: difference}
+	}
+
+	if isActivity {
+		value, err := p.activityTracker.observe(fmt.Sprintf("%s/%s", namespace, metricName), metricValue.Total, azMetricRequest.Activity)
+		if err != nil {
+			return nil, errors.NewBadRequest(err.Error())
+		}
+		metricValue = externalmetrics.AzureExternalMetricResponse{Total: value}
+	}
+
+	if azMetricRequest.Expression.Value != "" && !isActivity && !isShadowDifference {
+		metricValue, err = p.evaluateExpression(namespace, metricName, azMetricRequest.Expression, metricValue)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if azMetricRequest.PerReplica.Enabled && !isActivity && !isShadowDifference {
+		metricValue, err = p.perReplica(namespace, azMetricRequest.PerReplica, metricValue)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if azMetricRequest.Transform.Enabled() && !isActivity && !isShadowDifference {
+		metricValue, err = transformValue(azMetricRequest.Transform, metricValue)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(azMetricRequest.Variants) > 0 && !isActivity && !isShadowDifference {
+		p.variants.record(valueKey, metricValue)
+	}
+
+	served := &external_metrics.ExternalMetricValueList{
+		Items: externalMetricValues(info.Metric, metricValue, azMetricRequest.UseUnits, metricSelector, defined),
+	}
+	if defined {
+		p.deletionGrace.record(servedKey, metricSelector.String(), served)
+	}
+	return served, nil
+}
+
+// queryExternalMetric queries Azure for the value of the metric, combining its sources, dividing it
+// by its denominator, comparing it with its past value or aggregating it across subscriptions or
+// resources when the request lists them, or serves the value pushed to it by Event Grid, and applies
+// its alert guard
+func (p *AzureProvider) queryExternalMetric(namespace string, metricName string, azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	var metricValue externalmetrics.AzureExternalMetricResponse
+	var err error
+	if azMetricRequest.Type == externalmetrics.Combined {
+		metricValue, err = p.getCombinedMetric(namespace, metricName, azMetricRequest)
+	} else if azMetricRequest.Type == externalmetrics.Ratio {
+		metricValue, err = p.getRatioMetric(namespace, metricName, azMetricRequest)
+	} else if azMetricRequest.Type == externalmetrics.Seasonal {
+		metricValue, err = p.getSeasonalMetric(namespace, metricName, azMetricRequest)
+	} else if azMetricRequest.Type == externalmetrics.EventGrid {
+		metricValue, err = p.getEventGridMetric(namespace, metricName, azMetricRequest)
+	} else if len(azMetricRequest.Subscriptions) > 0 {
+		if azMetricRequest.SplitDimension != "" {
+			return metricValue, errors.NewBadRequest("a split metric can not be aggregated across subscriptions")
+		}
+		if azMetricRequest.AcrossResources() {
+			return metricValue, errors.NewBadRequest("a metric can not be aggregated across both subscriptions and resources")
+		}
+		metricValue, err = p.getMetricAcrossSubscriptions(namespace, metricName, azMetricRequest)
+	} else if azMetricRequest.AcrossResources() {
+		if azMetricRequest.SplitDimension != "" {
+			return metricValue, errors.NewBadRequest("a split metric can not be aggregated across resources")
+		}
+		metricValue, err = p.getMetricAcrossResources(namespace, metricName, azMetricRequest)
+	} else {
+		metricValue, err = p.getAzureMetric(namespace, metricName, azMetricRequest)
+	}
 	if err != nil {
-		glog.Errorf("bad request: %v", err)
-		return nil, errors.NewBadRequest(err.Error())
+		return metricValue, err
 	}
 
-	externalmetric := external_metrics.ExternalMetricValue{
-		MetricName: info.Metric,
-		Value:      *resource.NewQuantity(int64(metricValue.Total), resource.DecimalSI),
-		Timestamp:  metav1.Now(),
+	if azMetricRequest.Alert.RuleID != "" {
+		return p.applyAlert(namespace, metricName, azMetricRequest.Alert, metricValue)
 	}
+	return metricValue, nil
+}
 
+// externalMetricValues returns the items served for the value, or an item for each series labelled
+// with its dimension values.  The selector of a metric defined by an ExternalMetric picks series,
+// otherwise it already described the query.
+func externalMetricValues(metricName string, metricValue externalmetrics.AzureExternalMetricResponse, useUnits bool, metricSelector labels.Selector, selectSeries bool) []external_metrics.ExternalMetricValue {
 	matchingMetrics := []external_metrics.ExternalMetricValue{}
-	matchingMetrics = append(matchingMetrics, externalmetric)
+	if len(metricValue.Series) == 0 {
+		return append(matchingMetrics, newExternalMetricValue(metricName, metricQuantity(metricValue.Total, metricValue.Unit, useUnits), nil))
+	}
+	for _, series := range metricValue.Series {
+		if !selectSeries || metricSelector.Matches(labels.Set(series.Labels)) {
+			matchingMetrics = append(matchingMetrics, newExternalMetricValue(metricName, metricQuantity(series.Value, metricValue.Unit, useUnits), series.Labels))
+		}
+	}
+	return matchingMetrics
+}
+
+func newExternalMetricValue(metricName string, value resource.Quantity, metricLabels map[string]string) external_metrics.ExternalMetricValue {
+	return external_metrics.ExternalMetricValue{
+		MetricName:   metricName,
+		MetricLabels: metricLabels,
+		Value:        value,
+		Timestamp:    metav1.Now(),
+	}
+}
 
-	return &external_metrics.ExternalMetricValueList{
-		Items: matchingMetrics,
-	}, nil
+// metricQuantity scales the value by its Azure Monitor unit when units are enabled, so bytes are
+// served with binary suffixes such as 1536Mi and percentages and milliseconds as fractions such as 250m
+func metricQuantity(value float64, unit string, useUnits bool) resource.Quantity {
+	if useUnits {
+		switch unit {
+		case externalmetrics.UnitBytes, externalmetrics.UnitBytesPerSecond, externalmetrics.UnitByteSeconds:
+			return *resource.NewQuantity(int64(math.Round(value)), resource.BinarySI)
+		case externalmetrics.UnitPercent:
+			value = value / 100
+		case externalmetrics.UnitMilliSeconds:
+			value = value / 1000
+		}
+	}
+
+	return *resource.NewMilliQuantity(int64(value*1000), resource.DecimalSI)
 }
 
-// ListAllExternalMetrics calls out to azure and builds a list of metrics that can be queried against
+// getAzureMetric checks the request is permitted by policy and queries Azure for the value
+func (p *AzureProvider) getAzureMetric(namespace string, metricName string, azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	err := p.policyEnforcer.Authorize(namespace, policy.ScopeForRequest(azMetricRequest))
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, policyError(metricName, err)
+	}
+
+	factory, err := p.clientFactory(namespace, azMetricRequest)
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, err
+	}
+
+	externalMetricClient, err := factory.GetAzureExternalMetricClient(azMetricRequest.Type)
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest(err.Error())
+	}
+
+	// the connection strings are only set on this copy of the request, so they are never cached
+	switch azMetricRequest.Type {
+	case externalmetrics.ServiceBusQueue:
+		if queue := azMetricRequest.ServiceBusQueue; queue.ConnectionStringSecret != "" {
+			azMetricRequest.ServiceBusQueue.ConnectionString, err = p.credentials.secret(namespace, queue.ConnectionStringSecret, queue.ConnectionStringKey)
+		}
+	case externalmetrics.StorageQueueMessageAge, externalmetrics.StorageQueueLength:
+		if queue := azMetricRequest.StorageQueue; queue.ConnectionStringSecret != "" {
+			azMetricRequest.StorageQueue.ConnectionString, err = p.credentials.secret(namespace, queue.ConnectionStringSecret, queue.ConnectionStringKey)
+		}
+	case externalmetrics.EventHub:
+		hub := azMetricRequest.EventHub
+		if hub.ConnectionStringSecret != "" {
+			azMetricRequest.EventHub.ConnectionString, err = p.credentials.secret(namespace, hub.ConnectionStringSecret, hub.ConnectionStringKey)
+		}
+		if err == nil && hub.StorageConnectionStringSecret != "" {
+			azMetricRequest.EventHub.StorageConnectionString, err = p.credentials.secret(namespace, hub.StorageConnectionStringSecret, hub.StorageConnectionStringKey)
+		}
+	case externalmetrics.BlobCount:
+		if blobs := azMetricRequest.BlobCount; blobs.ConnectionStringSecret != "" {
+			azMetricRequest.BlobCount.ConnectionString, err = p.credentials.secret(namespace, blobs.ConnectionStringSecret, blobs.ConnectionStringKey)
+		}
+	case externalmetrics.Redis:
+		if redis := azMetricRequest.Redis; redis.AccessKeySecret != "" {
+			azMetricRequest.Redis.AccessKey, err = p.credentials.secret(namespace, redis.AccessKeySecret, redis.AccessKeyKey)
+		}
+	case externalmetrics.AzureDevOps:
+		if devops := azMetricRequest.AzureDevOps; devops.PersonalAccessTokenSecret != "" {
+			azMetricRequest.AzureDevOps.PersonalAccessToken, err = p.credentials.secret(namespace, devops.PersonalAccessTokenSecret, devops.PersonalAccessTokenKey)
+		}
+	case externalmetrics.IoTHub:
+		hub := azMetricRequest.IoTHub
+		if hub.ConnectionStringSecret != "" {
+			azMetricRequest.IoTHub.ConnectionString, err = p.credentials.secret(namespace, hub.ConnectionStringSecret, hub.ConnectionStringKey)
+		}
+		if err == nil && hub.StorageConnectionStringSecret != "" {
+			azMetricRequest.IoTHub.StorageConnectionString, err = p.credentials.secret(namespace, hub.StorageConnectionStringSecret, hub.StorageConnectionStringKey)
+		}
+	case externalmetrics.CosmosDB:
+		if cosmos := azMetricRequest.CosmosDB; cosmos.ConnectionStringSecret != "" {
+			azMetricRequest.CosmosDB.ConnectionString, err = p.credentials.secret(namespace, cosmos.ConnectionStringSecret, cosmos.ConnectionStringKey)
+		}
+	}
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, err
+	}
+
+	p.apiCosts.record(namespace, metricName, azMetricRequest.Type)
+	metricValue, err := externalMetricClient.GetAzureMetric(azMetricRequest)
+	if err != nil {
+		err = redact.Error(err)
+		glog.Errorf("bad request: %v", err)
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest(err.Error())
+	}
+
+	return metricValue, nil
+}
+
+func policyError(metricName string, err error) error {
+	glog.Errorf("policy check failed: %v", err)
+	if policy.IsNotSyncedError(err) {
+		return errors.NewServiceUnavailable(err.Error())
+	}
+	return errors.NewForbidden(external_metrics.Resource(metricName), metricName, err)
+}
+
+// ListAllExternalMetrics lists the metrics defined by ExternalMetric resources in any namespace,
+// including the activity metric of those with activity enabled, the difference metric of those
+// with a shadow spec and their variants.  The list is the discovery document of the external metrics api, which can't
+// be paginated, so none are listed when discovery is disabled; metrics are still served by name.
 func (p *AzureProvider) ListAllExternalMetrics() []provider.ExternalMetricInfo {
-	externalMetricsInfo := []provider.ExternalMetricInfo{}
+	if p.undiscovered {
+		return []provider.ExternalMetricInfo{}
+	}
 
-	// not implemented yet
+	names := map[string]bool{}
+	for name, request := range p.metricCache.ListAzureExternalMetricRequests() {
+		glog.V(6).Infof("listing external metric %s of type %s in namespace %s", name.Name, request.Type, name.Namespace)
+		names[name.Name] = true
+		if request.Activity.Enabled {
+			names[name.Name+ActivitySuffix] = true
+		}
+		if request.Shadow != nil {
+			names[name.Name+ShadowDifferenceSuffix] = true
+		}
+		for _, variant := range request.Variants {
+			names[name.Name+variant.Suffix] = true
+		}
+	}
 
-	// TODO
-	// iterate over all of the resources we have access
-	// build metric info from https://docs.microsoft.com/en-us/azure/monitoring-and-diagnostics/monitoring-rest-api-walkthrough#retrieve-metric-definitions-multi-dimensional-api
-	// important to remember to cache this and only get it at given interval
+	externalMetricsInfo := []provider.ExternalMetricInfo{}
+	for name := range names {
+		externalMetricsInfo = append(externalMetricsInfo, provider.ExternalMetricInfo{Metric: name})
+	}
+	sort.Slice(externalMetricsInfo, func(i, j int) bool {
+		return externalMetricsInfo[i].Metric < externalMetricsInfo[j].Metric
+	})
 
 	return externalMetricsInfo
 }
@@ -1,12 +1,25 @@
 package provider
 
 import (
+	"flag"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
 	"testing"
 
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	azurefake "github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/fake"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/fake"
+	informers "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/informers/externalversions"
 	"github.com/Azure/azure-k8s-metrics-adapter/pkg/metriccache"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/policy"
+	"github.com/golang/glog"
 	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 )
 
@@ -194,6 +207,189 @@ func TestReturnsExeternalMetric(t *testing.T) {
 	}
 }
 
+func TestReturnsActivityOfExternalMetric(t *testing.T) {
+	fakeFactory := fakeAzureExternalClientFactory{}
+	provider := newProvider(fakeFactory)
+
+	// the fake client returns 15
+	tests := []struct {
+		threshold float64
+		want      int64
+	}{
+		{threshold: 0, want: 1},
+		{threshold: 20, want: 0},
+	}
+	for _, tt := range tests {
+		provider.activityTracker = newActivityTracker()
+		provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+			MetricName: "Messages",
+			Activity:   externalmetrics.ActivityDefinition{Enabled: true, Threshold: tt.threshold},
+		})
+
+		selector, _ := labels.Parse("")
+		info := k8sprovider.ExternalMetricInfo{Metric: "queue-activity"}
+		returnList, err := provider.GetExternalMetric("default", selector, info)
+
+		if err != nil {
+			t.Fatalf("error after processing got: %v, want nil", err)
+		}
+
+		externalMetric := returnList.Items[0]
+		if externalMetric.MetricName != "queue-activity" {
+			t.Errorf("externalMetric.MetricName = %v, want there %v", externalMetric.MetricName, "queue-activity")
+		}
+
+		if externalMetric.Value.Value() != tt.want {
+			t.Errorf("threshold %v: externalMetric.Value = %v, want there %v", tt.threshold, externalMetric.Value.Value(), tt.want)
+		}
+	}
+}
+
+func TestReturnsSeriesOfSplitExternalMetric(t *testing.T) {
+	fakeFactory := fakeAzureExternalClientFactory{}
+	provider := newProvider(fakeFactory)
+	provider.metricCache.Update("ExternalMetric/default/queues", externalmetrics.AzureExternalMetricRequest{
+		MetricName:     "ActiveMessages",
+		SplitDimension: "EntityName",
+	})
+
+	tests := []struct {
+		selector string
+		want     map[string]int64
+	}{
+		{selector: "", want: map[string]int64{"orders": 10, "payments": 5}},
+		{selector: "entityname=payments", want: map[string]int64{"payments": 5}},
+		{selector: "entityname=shipping", want: map[string]int64{}},
+	}
+	for _, tt := range tests {
+		selector, _ := labels.Parse(tt.selector)
+		info := k8sprovider.ExternalMetricInfo{Metric: "queues"}
+		returnList, err := provider.GetExternalMetric("default", selector, info)
+
+		if err != nil {
+			t.Fatalf("error after processing got: %v, want nil", err)
+		}
+
+		if len(returnList.Items) != len(tt.want) {
+			t.Errorf("selector '%s': returnList.Items length = %v, want there %v", tt.selector, len(returnList.Items), len(tt.want))
+		}
+
+		for _, item := range returnList.Items {
+			entity := item.MetricLabels["entityname"]
+			if item.Value.Value() != tt.want[entity] {
+				t.Errorf("selector '%s': %s value = %v, want there %v", tt.selector, entity, item.Value.Value(), tt.want[entity])
+			}
+		}
+	}
+}
+
+func TestReturnsEachSeriesOfSelectorExternalMetric(t *testing.T) {
+	fakeFactory := fakeAzureExternalClientFactory{}
+	provider := newProvider(fakeFactory)
+
+	// the selector describes the query so it does not filter the series
+	selector := createLabelSelector("ActiveMessages", "")
+	info := k8sprovider.ExternalMetricInfo{Metric: "queues"}
+	returnList, err := provider.GetExternalMetric("default", selector, info)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if len(returnList.Items) != 2 {
+		t.Fatalf("returnList.Items length = %v, want there 2", len(returnList.Items))
+	}
+
+	for i, entity := range []string{"orders", "payments"} {
+		if returnList.Items[i].MetricLabels["entityname"] != entity {
+			t.Errorf("returnList.Items[%d].MetricLabels = %v, want entityname %v", i, returnList.Items[i].MetricLabels, entity)
+		}
+	}
+}
+
+func TestExternalMetricOutsidePolicyIsForbidden(t *testing.T) {
+	fakeFactory := fakeAzureExternalClientFactory{}
+
+	i := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	i.Azure().V1alpha2().AdapterPolicies().Informer().GetIndexer().Add(&api.AdapterPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "restricted"},
+		Spec: api.AdapterPolicySpec{
+			Namespaces:    []string{"default"},
+			Subscriptions: []string{"1234"},
+		},
+	})
+
+	provider := newProvider(fakeFactory)
+	provider.policyEnforcer = policy.NewEnforcer(i.Azure().V1alpha2().AdapterPolicies().Lister(), nil)
+
+	selector := createLabelSelector("MetricName", "9876")
+	info := k8sprovider.ExternalMetricInfo{
+		Metric: "MetricName",
+	}
+
+	_, err := provider.GetExternalMetric("default", selector, info)
+
+	if !k8serrors.IsForbidden(err) {
+		t.Errorf("error after processing got: %v, want forbidden", err)
+	}
+}
+
+func TestListAllExternalMetricsFromCache(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		Activity:   externalmetrics.ActivityDefinition{Enabled: true},
+	})
+	provider.metricCache.Update("ExternalMetric/other/queue", externalmetrics.AzureExternalMetricRequest{MetricName: "Messages"})
+	provider.metricCache.Update("ExternalMetric/default/requests", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Requests",
+		Shadow:     &externalmetrics.AzureExternalMetricRequest{MetricName: "Requests"},
+	})
+
+	metrics := provider.ListAllExternalMetrics()
+
+	want := []k8sprovider.ExternalMetricInfo{{Metric: "queue"}, {Metric: "queue-activity"}, {Metric: "requests"}, {Metric: "requests-shadow-difference"}}
+	if !reflect.DeepEqual(metrics, want) {
+		t.Errorf("metrics = %v, want there %v", metrics, want)
+	}
+}
+
+func TestListAllExternalMetricsWithDiscoveryDisabled(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.undiscovered = true
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{MetricName: "Messages"})
+
+	if metrics := provider.ListAllExternalMetrics(); len(metrics) != 0 {
+		t.Errorf("metrics = %v, want none with discovery disabled", metrics)
+	}
+}
+
+func TestMetricQuantityUsesUnits(t *testing.T) {
+	var tests = []struct {
+		value    float64
+		unit     string
+		useUnits bool
+		want     string
+	}{
+		{1610612736, externalmetrics.UnitBytes, true, "1536Mi"},
+		{1610612736, externalmetrics.UnitBytes, false, "1610612736"},
+		{2048, externalmetrics.UnitBytesPerSecond, true, "2Ki"},
+		{25, externalmetrics.UnitPercent, true, "250m"},
+		{25, externalmetrics.UnitPercent, false, "25"},
+		{250, externalmetrics.UnitMilliSeconds, true, "250m"},
+		{1.5, "Seconds", true, "1500m"},
+		{42, "Count", true, "42"},
+	}
+
+	for _, tt := range tests {
+		quantity := metricQuantity(tt.value, tt.unit, tt.useUnits)
+		if quantity.String() != tt.want {
+			t.Errorf("metricQuantity(%v, %s, %v) = %v, want %v", tt.value, tt.unit, tt.useUnits, quantity.String(), tt.want)
+		}
+	}
+}
+
 func newProvider(fakeFactory fakeAzureExternalClientFactory) AzureProvider {
 	// func newProvider(fakeclient fakeAzureMonitorClient) AzureProvider {
 	metricCache := metriccache.NewMetricCache()
@@ -201,6 +397,8 @@ func newProvider(fakeFactory fakeAzureExternalClientFactory) AzureProvider {
 	provider := AzureProvider{
 		metricCache:        metricCache,
 		azureClientFactory: fakeFactory,
+		activityTracker:    newActivityTracker(),
+		tenants:            newTenantSources(),
 	}
 
 	return provider
@@ -208,6 +406,20 @@ func newProvider(fakeFactory fakeAzureExternalClientFactory) AzureProvider {
 
 // externalMetricClient, err := p.azureExternalClientFactory.GetAzureExternalMetricClient(azMetricRequest.Type)
 
+// newFakeClientFactory returns a factory of the client for every metric type
+func newFakeClientFactory(client externalmetrics.AzureExternalMetricClient) *azurefake.FakeClientFactory {
+	factory := &azurefake.FakeClientFactory{}
+	factory.GetAzureExternalMetricClientReturns(client, nil)
+	return factory
+}
+
+// newFakeExternalMetricClient returns a client serving the response, or the error
+func newFakeExternalMetricClient(response externalmetrics.AzureExternalMetricResponse, err error) *azurefake.FakeAzureExternalMetricClient {
+	client := &azurefake.FakeAzureExternalMetricClient{}
+	client.GetAzureMetricReturns(response, err)
+	return client
+}
+
 type fakeAzureExternalClientFactory struct {
 }
 
@@ -226,5 +438,63 @@ type fakeAzureMonitorClient struct {
 }
 
 func (f fakeAzureMonitorClient) GetAzureMetric(azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	// a series for each queue, as if split or filtered on several queue names
+	if azMetricRequest.MetricName == "ActiveMessages" {
+		return externalmetrics.AzureExternalMetricResponse{
+			Total: 15,
+			Series: []externalmetrics.MetricSeries{
+				{Labels: map[string]string{"entityname": "orders"}, Value: 10},
+				{Labels: map[string]string{"entityname": "payments"}, Value: 5},
+			},
+		}, f.err
+	}
 	return f.result, f.err
 }
+
+func TestClientErrorCredentialsNeverLoggedOrReturned(t *testing.T) {
+	secret := "c2VjcmV0a2V5"
+	client := newFakeExternalMetricClient(externalmetrics.AzureExternalMetricResponse{}, fmt.Errorf("unable to connect with Endpoint=sb://ns.servicebus.windows.net/;SharedAccessKeyName=Root;SharedAccessKey=%s", secret))
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = newFakeClientFactory(client)
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{Type: externalmetrics.ServiceBusQueue, MetricName: "Messages"})
+
+	selector, _ := labels.Parse("")
+	var err error
+	logs := captureLogs(t, func() {
+		_, err = provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+	})
+
+	if err == nil {
+		t.Fatalf("error after processing got: nil, want error")
+	}
+	if strings.Contains(err.Error(), secret) {
+		t.Errorf("error = %v, want the key redacted", err)
+	}
+	if !strings.Contains(logs, "bad request") {
+		t.Errorf("logs = %q, want the error logged", logs)
+	}
+	if strings.Contains(logs, secret) {
+		t.Errorf("logs = %q, want the key redacted", logs)
+	}
+}
+
+// captureLogs returns what is logged to stderr while f runs
+func captureLogs(t *testing.T, f func()) string {
+	toStderr := flag.Lookup("logtostderr").Value.String()
+	flag.Set("logtostderr", "true")
+	defer flag.Set("logtostderr", toStderr)
+
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("unable to capture logs: %v", err)
+	}
+	stderr := os.Stderr
+	os.Stderr = writer
+	f()
+	glog.Flush()
+	os.Stderr = stderr
+	writer.Close()
+
+	logs, _ := ioutil.ReadAll(reader)
+	return string(logs)
+}
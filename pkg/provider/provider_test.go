@@ -1,6 +1,32 @@
 package provider
 
-import "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+import (
+	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/metriccache"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestProviderWithUnsetOptionsServesExternalMetrics(t *testing.T) {
+	metricCache := metriccache.NewMetricCache()
+	metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{MetricName: "Messages"})
+	provider := NewAzureProvider(AzureProviderOptions{
+		MetricCache:        metricCache,
+		AzureClientFactory: fakeAzureExternalClientFactory{},
+	})
+
+	selector, _ := labels.Parse("")
+	returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if returnList.Items[0].Value.MilliValue() != 15000 {
+		t.Errorf("value = %v, want %v", returnList.Items[0].Value.MilliValue(), 15000)
+	}
+}
 
 // This function is from  dynamic fake
 // and is licensed under the Apache License, Version 2.0 (the "License");
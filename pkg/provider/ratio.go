@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// getRatioMetric queries the metric on its resource and on the denominator resource in parallel and
+// returns the ratio of their values.  Each query is checked by policy and the request fails if either
+// fails.  A denominator of 0 has no ratio: the no data policy of the metric decides what is served,
+// and without a policy the request fails so the autoscaler keeps the current scale rather than scale
+// in on an empty or broken denominator.
+func (p *AzureProvider) getRatioMetric(namespace string, metricName string, azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	if azMetricRequest.SplitDimension != "" {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest("a ratio metric can not be split by dimension")
+	}
+
+	requests := []externalmetrics.AzureExternalMetricRequest{
+		azMetricRequest.Ratio.Numerator(azMetricRequest),
+		azMetricRequest.Ratio.Denominator(azMetricRequest),
+	}
+	values := make([]externalmetrics.AzureExternalMetricResponse, len(requests))
+	errs := make([]error, len(requests))
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		wg.Add(1)
+		go func(i int, request externalmetrics.AzureExternalMetricRequest) {
+			defer wg.Done()
+			values[i], errs[i] = p.queryExternalMetric(namespace, metricName, request)
+		}(i, request)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			glog.Errorf("ratio query of %s failed: %v", metricName, err)
+			return externalmetrics.AzureExternalMetricResponse{}, err
+		}
+	}
+
+	numerator, denominator := values[0], values[1]
+	ratio := externalmetrics.AzureExternalMetricResponse{
+		Raw: append(append([]string{}, numerator.Raw...), denominator.Raw...),
+	}
+	if denominator.Total == 0 {
+		if azMetricRequest.NoData.Policy == "" {
+			return ratio, errors.NewServiceUnavailable(fmt.Sprintf("the denominator of %s is 0", metricName))
+		}
+		ratio.NoData = true
+		return ratio, nil
+	}
+	ratio.Total = numerator.Total / denominator.Total
+
+	glog.V(2).Infof("ratio of %s: %f / %f = %f", metricName, numerator.Total, denominator.Total, ratio.Total)
+	return ratio, nil
+}
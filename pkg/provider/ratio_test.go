@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestRatioMetricDividesByDenominatorResource(t *testing.T) {
+	var tests = []struct {
+		name  string
+		ratio externalmetrics.RatioDefinition
+		want  int64
+	}{
+		{"other slot", externalmetrics.RatioDefinition{ResourceName: "app-staging"}, 250},
+		{"filter replaced", externalmetrics.RatioDefinition{Filter: "", ReplaceFilter: true}, 200},
+		{"filter kept", externalmetrics.RatioDefinition{}, 1000},
+	}
+
+	for _, tt := range tests {
+		provider := newProvider(fakeAzureExternalClientFactory{})
+		provider.azureClientFactory = resourceValuesClientFactory{
+			"app|Region eq 'westus'":         20,
+			"app|":                           100,
+			"app-staging|Region eq 'westus'": 80,
+		}
+		provider.metricCache.Update("ExternalMetric/default/share", externalmetrics.AzureExternalMetricRequest{
+			Type:         externalmetrics.Ratio,
+			MetricName:   "Requests",
+			ResourceName: "app",
+			Filter:       "Region eq 'westus'",
+			Ratio:        tt.ratio,
+		})
+
+		selector, _ := labels.Parse("")
+		returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "share"})
+
+		if err != nil {
+			t.Fatalf("%s: error after processing got: %v, want nil", tt.name, err)
+		}
+		if returnList.Items[0].Value.MilliValue() != tt.want {
+			t.Errorf("%s: value = %v, want %v", tt.name, returnList.Items[0].Value.MilliValue(), tt.want)
+		}
+	}
+}
+
+func TestRatioMetricWithEmptyDenominator(t *testing.T) {
+	var tests = []struct {
+		noData  externalmetrics.NoDataDefinition
+		want    int64
+		wantErr bool
+	}{
+		{externalmetrics.NoDataDefinition{}, 0, true},
+		{externalmetrics.NoDataDefinition{Policy: externalmetrics.NoDataFixed, Value: 1}, 1000, false},
+		{externalmetrics.NoDataDefinition{Policy: externalmetrics.NoDataZero}, 0, false},
+	}
+
+	for _, tt := range tests {
+		provider := newProvider(fakeAzureExternalClientFactory{})
+		provider.azureClientFactory = resourceValuesClientFactory{"app|": 20}
+		provider.metricCache.Update("ExternalMetric/default/share", externalmetrics.AzureExternalMetricRequest{
+			Type:         externalmetrics.Ratio,
+			MetricName:   "Requests",
+			ResourceName: "app",
+			Ratio:        externalmetrics.RatioDefinition{ResourceName: "app-idle"},
+			NoData:       tt.noData,
+		})
+
+		selector, _ := labels.Parse("")
+		returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "share"})
+
+		if tt.wantErr {
+			if !k8serrors.IsServiceUnavailable(err) {
+				t.Errorf("%q: error after processing got: %v, want service unavailable", tt.noData.Policy, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%q: error after processing got: %v, want nil", tt.noData.Policy, err)
+		}
+		if returnList.Items[0].Value.MilliValue() != tt.want {
+			t.Errorf("%q: value = %v, want %v", tt.noData.Policy, returnList.Items[0].Value.MilliValue(), tt.want)
+		}
+	}
+}
+
+func TestRatioMetricSplitByDimensionIsBadRequest(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.metricCache.Update("ExternalMetric/default/share", externalmetrics.AzureExternalMetricRequest{
+		Type:           externalmetrics.Ratio,
+		MetricName:     "Requests",
+		SplitDimension: "Region",
+	})
+
+	selector, _ := labels.Parse("")
+	_, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "share"})
+
+	if !k8serrors.IsBadRequest(err) {
+		t.Errorf("error after processing got: %v, want bad request", err)
+	}
+}
+
+// resourceValuesClientFactory returns a client serving the value for each resource name and filter
+type resourceValuesClientFactory map[string]float64
+
+func (f resourceValuesClientFactory) GetAzureExternalMetricClient(clientType string) (externalmetrics.AzureExternalMetricClient, error) {
+	return f, nil
+}
+
+func (f resourceValuesClientFactory) GetAzureMetric(azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	return externalmetrics.AzureExternalMetricResponse{Total: f[azMetricRequest.ResourceName+"|"+azMetricRequest.Filter]}, nil
+}
@@ -0,0 +1,196 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+)
+
+// RawResponsePath is the path the latest raw responses of an external metric are served on, as
+// <path><namespace>/<metric name>, and the metrics queried are listed on, as <path> or
+// <path><namespace>/.  It is served behind the authentication and authorization of the metrics
+// apis, so callers need a role allowing get on the non resource url.
+const RawResponsePath = "/debug/externalmetrics/"
+
+// RawResponses keeps the latest Azure responses of each external metric with the value the
+// adapter derived from them, to compare the value served to an autoscaler with what Azure returned
+type RawResponses struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	responses map[string]rawResponse
+}
+
+// rawResponse is the latest query of a metric.  The value is the value served before units are
+// applied, after any alert guard.
+type rawResponse struct {
+	Namespace string    `json:"namespace"`
+	Metric    string    `json:"metric"`
+	Selector  string    `json:"selector,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+	Unit      string    `json:"unit,omitempty"`
+	// PinnedUntil is set while the value is pinned and azure isn't queried
+	PinnedUntil *time.Time `json:"pinnedUntil,omitempty"`
+	// Incident is the tracking id of the Azure incident the previous value is served during
+	Incident  string        `json:"incident,omitempty"`
+	Series    []seriesValue `json:"series,omitempty"`
+	Responses []string      `json:"responses"`
+}
+
+// queriedMetric is the latest query of a metric in the listing of the metrics queried, without
+// the responses
+type queriedMetric struct {
+	Namespace string    `json:"namespace"`
+	Metric    string    `json:"metric"`
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+type seriesValue struct {
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+}
+
+// NewRawResponses creates the store of the latest raw responses of each external metric
+func NewRawResponses() *RawResponses {
+	return &RawResponses{
+		now:       time.Now,
+		responses: map[string]rawResponse{},
+	}
+}
+
+// record keeps the value of the metric and the responses it was derived from
+func (r *RawResponses) record(namespace string, metricName string, selector string, metricValue externalmetrics.AzureExternalMetricResponse) {
+	if r == nil {
+		return
+	}
+
+	response := rawResponse{
+		Namespace: namespace,
+		Metric:    metricName,
+		Selector:  selector,
+		Timestamp: r.now(),
+		Value:     metricValue.Total,
+		Unit:      metricValue.Unit,
+		Responses: []string{},
+	}
+	for _, series := range metricValue.Series {
+		response.Series = append(response.Series, seriesValue{Labels: series.Labels, Value: series.Value})
+	}
+	for _, raw := range metricValue.Raw {
+		response.Responses = append(response.Responses, redact.String(raw))
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses[fmt.Sprintf("%s/%s", namespace, metricName)] = response
+}
+
+// recordPinned keeps the value served for a pinned metric, with the responses of its last query
+func (r *RawResponses) recordPinned(namespace string, metricName string, selector string, metricValue externalmetrics.AzureExternalMetricResponse, until time.Time) {
+	r.recordStale(namespace, metricName, selector, metricValue, func(response *rawResponse) {
+		response.PinnedUntil = &until
+	})
+}
+
+// recordIncident keeps the value served for a metric that couldn't be queried during an Azure
+// incident, with the responses of its last query
+func (r *RawResponses) recordIncident(namespace string, metricName string, selector string, metricValue externalmetrics.AzureExternalMetricResponse, incident string) {
+	r.recordStale(namespace, metricName, selector, metricValue, func(response *rawResponse) {
+		response.Incident = incident
+	})
+}
+
+// recordStale keeps a value served without querying azure, marked with the reason
+func (r *RawResponses) recordStale(namespace string, metricName string, selector string, metricValue externalmetrics.AzureExternalMetricResponse, mark func(*rawResponse)) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := fmt.Sprintf("%s/%s", namespace, metricName)
+	response, found := r.responses[key]
+	if !found {
+		response = rawResponse{Namespace: namespace, Metric: metricName, Responses: []string{}}
+	}
+	response.Selector = selector
+	response.Timestamp = r.now()
+	response.Value = metricValue.Total
+	response.Series = nil
+	for _, series := range metricValue.Series {
+		response.Series = append(response.Series, seriesValue{Labels: series.Labels, Value: series.Value})
+	}
+	response.PinnedUntil = nil
+	response.Incident = ""
+	mark(&response)
+	r.responses[key] = response
+}
+
+// ServeHTTP writes the latest responses of the metric named by the path as json
+func (r *RawResponses) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, RawResponsePath), "/")
+	if len(parts) == 1 && parts[0] == "" {
+		r.serveList(w, req, "")
+		return
+	}
+	if len(parts) == 2 && parts[0] != "" && parts[1] == "" {
+		r.serveList(w, req, parts[0])
+		return
+	}
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		http.Error(w, fmt.Sprintf("path must be %s<namespace>/<metric name>", RawResponsePath), http.StatusNotFound)
+		return
+	}
+
+	r.mu.Lock()
+	response, found := r.responses[fmt.Sprintf("%s/%s", parts[0], parts[1])]
+	r.mu.Unlock()
+	if !found {
+		http.Error(w, fmt.Sprintf("metric %s has not been queried in namespace %s", parts[1], parts[0]), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// serveList writes the metrics queried in the namespace, or every namespace when empty, sorted by
+// namespace and name, in pages of the limit query parameter when it is set
+func (r *RawResponses) serveList(w http.ResponseWriter, req *http.Request, namespace string) {
+	r.mu.Lock()
+	metrics := []queriedMetric{}
+	for _, response := range r.responses {
+		if namespace != "" && response.Namespace != namespace {
+			continue
+		}
+		metrics = append(metrics, queriedMetric{Namespace: response.Namespace, Metric: response.Metric, Timestamp: response.Timestamp, Value: response.Value})
+	}
+	r.mu.Unlock()
+	sort.Slice(metrics, func(i, j int) bool {
+		if metrics[i].Namespace != metrics[j].Namespace {
+			return metrics[i].Namespace < metrics[j].Namespace
+		}
+		return metrics[i].Metric < metrics[j].Metric
+	})
+
+	start, end, ok := page(w, req, len(metrics))
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metrics[start:end])
+}
@@ -0,0 +1,139 @@
+package provider
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestRawResponsesServeLatestResponseOfMetric(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.rawResponses = NewRawResponses()
+	provider.azureClientFactory = rawResponseClientFactory{response: externalmetrics.AzureExternalMetricResponse{
+		Total: 7,
+		Raw:   []string{`{"value":[{"timeseries":[{"data":[{"total":7}]}]}]}`},
+	}}
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+	})
+
+	selector, _ := labels.Parse("")
+	if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"}); err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	provider.rawResponses.ServeHTTP(recorder, httptest.NewRequest("GET", RawResponsePath+"default/queue", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %v, want %v", recorder.Code, http.StatusOK)
+	}
+	response := rawResponse{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("unable to parse response: %v", err)
+	}
+	if response.Value != 7 {
+		t.Errorf("value = %v, want %v", response.Value, 7)
+	}
+	if len(response.Responses) != 1 || response.Responses[0] != `{"value":[{"timeseries":[{"data":[{"total":7}]}]}]}` {
+		t.Errorf("responses = %v, want the raw azure response", response.Responses)
+	}
+}
+
+func TestRawResponsesUnknownMetricNotFound(t *testing.T) {
+	var tests = []string{
+		RawResponsePath + "default/queue",
+		RawResponsePath + "default",
+		RawResponsePath + "default/queue/extra",
+	}
+
+	rawResponses := NewRawResponses()
+	for _, path := range tests {
+		recorder := httptest.NewRecorder()
+		rawResponses.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+
+		if recorder.Code != http.StatusNotFound {
+			t.Errorf("%s status = %v, want %v", path, recorder.Code, http.StatusNotFound)
+		}
+	}
+}
+
+func TestRawResponsesListQueriedMetricsInPages(t *testing.T) {
+	rawResponses := NewRawResponses()
+	for _, name := range []string{"default/queue", "default/requests", "default/backlog", "other/queue"} {
+		parts := strings.Split(name, "/")
+		rawResponses.record(parts[0], parts[1], "", externalmetrics.AzureExternalMetricResponse{Total: 1})
+	}
+
+	var tests = []struct {
+		path         string
+		want         []string
+		wantContinue string
+	}{
+		{RawResponsePath, []string{"default/backlog", "default/queue", "default/requests", "other/queue"}, ""},
+		{RawResponsePath + "?limit=3", []string{"default/backlog", "default/queue", "default/requests"}, "3"},
+		{RawResponsePath + "?limit=3&continue=3", []string{"other/queue"}, ""},
+		{RawResponsePath + "default/?limit=2", []string{"default/backlog", "default/queue"}, "2"},
+		{RawResponsePath + "other/", []string{"other/queue"}, ""},
+	}
+
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		rawResponses.ServeHTTP(recorder, httptest.NewRequest("GET", tt.path, nil))
+
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s status = %v, want %v", tt.path, recorder.Code, http.StatusOK)
+		}
+		metrics := []queriedMetric{}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &metrics); err != nil {
+			t.Fatalf("%s unable to parse response: %v", tt.path, err)
+		}
+		got := []string{}
+		for _, metric := range metrics {
+			got = append(got, metric.Namespace+"/"+metric.Metric)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s metrics = %v, want %v", tt.path, got, tt.want)
+		}
+		if token := recorder.Header().Get(ContinueHeader); token != tt.wantContinue {
+			t.Errorf("%s continue = %q, want %q", tt.path, token, tt.wantContinue)
+		}
+	}
+}
+
+func TestRawResponsesListWithInvalidPageIsBadRequest(t *testing.T) {
+	var tests = []string{
+		RawResponsePath + "?limit=0",
+		RawResponsePath + "?limit=many",
+		RawResponsePath + "?continue=-1",
+	}
+
+	rawResponses := NewRawResponses()
+	for _, path := range tests {
+		recorder := httptest.NewRecorder()
+		rawResponses.ServeHTTP(recorder, httptest.NewRequest("GET", path, nil))
+
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("%s status = %v, want %v", path, recorder.Code, http.StatusBadRequest)
+		}
+	}
+}
+
+type rawResponseClientFactory struct {
+	response externalmetrics.AzureExternalMetricResponse
+}
+
+func (f rawResponseClientFactory) GetAzureExternalMetricClient(clientType string) (externalmetrics.AzureExternalMetricClient, error) {
+	return f, nil
+}
+
+func (f rawResponseClientFactory) GetAzureMetric(azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	return f.response, nil
+}
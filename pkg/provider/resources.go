@@ -0,0 +1,140 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/policy"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// getMetricAcrossResources queries the metric on each resource of the request, listed, selected by
+// tags or matched by a Resource Graph query, in parallel and aggregates the values.  The request
+// fails if any resource fails so a partial value is never served.
+func (p *AzureProvider) getMetricAcrossResources(namespace string, metricName string, azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	if azMetricRequest.SubscriptionID == "" {
+		azMetricRequest.SubscriptionID = p.defaultSubscriptionID
+	}
+	resources, err := p.permittedResources(namespace, metricName, azMetricRequest)
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, err
+	}
+	if len(resources) == 0 {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest("no resources to query")
+	}
+
+	factory, err := p.clientFactory(namespace, azMetricRequest)
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, err
+	}
+
+	externalMetricClient, err := factory.GetAzureExternalMetricClient(azMetricRequest.Type)
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest(err.Error())
+	}
+
+	requests := make([]externalmetrics.AzureExternalMetricRequest, len(resources))
+	for i, resource := range resources {
+		requests[i] = resourceRequest(azMetricRequest, resource)
+	}
+	values, raw, err := p.queryEach(namespace, metricName, externalMetricClient, requests, func(request externalmetrics.AzureExternalMetricRequest) string {
+		return fmt.Sprintf("resource %s/%s", request.ResourceGroup, request.ResourceName)
+	})
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, err
+	}
+
+	value, err := externalmetrics.AggregateResources(azMetricRequest.ResourceAggregation, values)
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest(err.Error())
+	}
+
+	glog.V(2).Infof("aggregated metric value across %d resources: %f", len(resources), value)
+	return externalmetrics.AzureExternalMetricResponse{Total: value, Raw: raw}, nil
+}
+
+// permittedResources lists the resources selected by tags or the query and checks each resource
+// against the policies.  Listed resources must all be permitted, while resources selected by tags
+// or the query that a policy does not permit are left out.
+func (p *AzureProvider) permittedResources(namespace string, metricName string, azMetricRequest externalmetrics.AzureExternalMetricRequest) ([]externalmetrics.ResourceRef, error) {
+	seen := map[string]bool{}
+	resources := []externalmetrics.ResourceRef{}
+	add := func(resource externalmetrics.ResourceRef, listed bool) error {
+		key := strings.ToLower(resource.ResourceGroup + "/" + resource.ResourceName)
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+
+		err := p.policyEnforcer.Authorize(namespace, policy.ScopeForRequest(resourceRequest(azMetricRequest, resource)))
+		if policy.IsViolationError(err) && !listed {
+			glog.V(4).Infof("skipping resource %s/%s: %v", resource.ResourceGroup, resource.ResourceName, err)
+			return nil
+		}
+		if err != nil {
+			return policyError(metricName, err)
+		}
+
+		resources = append(resources, resource)
+		return nil
+	}
+
+	for _, resource := range azMetricRequest.Resources {
+		if err := add(resource, true); err != nil {
+			return nil, err
+		}
+	}
+
+	resourceType := fmt.Sprintf("%s/%s", azMetricRequest.ResourceProviderNamespace, azMetricRequest.ResourceType)
+	// resources resolved before the ExternalMetric last changed are resolved again
+	changedAt, _ := p.metricCache.ExternalMetricChangedAt(namespace, metricName)
+	if len(azMetricRequest.ResourceTags) > 0 {
+		if p.resourceLister == nil {
+			return nil, errors.NewBadRequest("listing resources by tag is not configured")
+		}
+		tagged, err := p.resourceLister.ListResources(azMetricRequest.SubscriptionID, azMetricRequest.ResourceGroup, resourceType, azMetricRequest.ResourceTags, changedAt)
+		if err != nil {
+			err = redact.Error(err)
+			glog.Errorf("unable to list resources: %v", err)
+			return nil, errors.NewServiceUnavailable(err.Error())
+		}
+		for _, resource := range tagged {
+			if err := add(resource, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if azMetricRequest.ResourceQuery != "" {
+		if p.resourceLister == nil {
+			return nil, errors.NewBadRequest("querying resources with resource graph is not configured")
+		}
+		matched, err := p.resourceLister.QueryResources(azMetricRequest.SubscriptionID, resourceType, azMetricRequest.ResourceQuery, changedAt)
+		if err != nil {
+			err = redact.Error(err)
+			glog.Errorf("unable to query resources: %v", err)
+			return nil, errors.NewServiceUnavailable(err.Error())
+		}
+		for _, resource := range matched {
+			if err := add(resource, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return resources, nil
+}
+
+// resourceRequest is the request of the metric on a single resource
+func resourceRequest(azMetricRequest externalmetrics.AzureExternalMetricRequest, resource externalmetrics.ResourceRef) externalmetrics.AzureExternalMetricRequest {
+	request := azMetricRequest
+	request.ResourceGroup = resource.ResourceGroup
+	request.ResourceName = resource.ResourceName
+	request.Resources = nil
+	request.ResourceTags = nil
+	request.ResourceQuery = ""
+	return request
+}
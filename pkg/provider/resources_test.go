@@ -0,0 +1,225 @@
+package provider
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	azurefake "github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/fake"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/fake"
+	informers "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/informers/externalversions"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/policy"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAggregatesListedResources(t *testing.T) {
+	client := &perResourceClient{values: map[string]float64{"orders-eu": 4, "orders-us": 6}}
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = resourceClientFactory{client}
+
+	request := externalmetrics.AzureExternalMetricRequest{
+		MetricName: "ActiveMessages",
+		Resources: []externalmetrics.ResourceRef{
+			{ResourceGroup: "eu", ResourceName: "orders-eu"},
+			{ResourceGroup: "us", ResourceName: "orders-us"},
+			{ResourceGroup: "EU", ResourceName: "orders-eu"},
+		},
+		ResourceAggregation: externalmetrics.SubscriptionAverage,
+	}
+	value, err := provider.getMetricAcrossResources("default", "orders", request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if value.Total != 5 {
+		t.Errorf("value = %v, want %v", value.Total, 5)
+	}
+	if len(client.requested) != 2 {
+		t.Errorf("requested resources = %v, want each resource once", client.requested)
+	}
+}
+
+func TestTaggedResourcesSkipsThoseOutsidePolicy(t *testing.T) {
+	client := &perResourceClient{values: map[string]float64{"orders-eu": 4, "orders-us": 6, "orders-asia": 8}}
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = resourceClientFactory{client}
+	provider.resourceLister = newFakeResourceLister([]externalmetrics.ResourceRef{
+		{ResourceGroup: "eu", ResourceName: "orders-eu"},
+		{ResourceGroup: "us", ResourceName: "orders-us"},
+		{ResourceGroup: "asia", ResourceName: "orders-asia"},
+	})
+	provider.policyEnforcer = newResourceGroupEnforcer("eu", "us")
+
+	request := externalmetrics.AzureExternalMetricRequest{
+		MetricName:          "ActiveMessages",
+		ResourceTags:        map[string]string{"workload": "orders"},
+		ResourceAggregation: externalmetrics.SubscriptionMaximum,
+	}
+	value, err := provider.getMetricAcrossResources("default", "orders", request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if value.Total != 6 {
+		t.Errorf("value = %v, want %v", value.Total, 6)
+	}
+	for _, name := range client.requested {
+		if name == "orders-asia" {
+			t.Errorf("requested resources = %v, want orders-asia skipped", client.requested)
+		}
+	}
+}
+
+func TestListedResourceOutsidePolicyIsForbidden(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.policyEnforcer = newResourceGroupEnforcer("eu")
+
+	request := externalmetrics.AzureExternalMetricRequest{
+		MetricName: "ActiveMessages",
+		Resources: []externalmetrics.ResourceRef{
+			{ResourceGroup: "eu", ResourceName: "orders-eu"},
+			{ResourceGroup: "us", ResourceName: "orders-us"},
+		},
+	}
+	_, err := provider.getMetricAcrossResources("default", "orders", request)
+
+	if !k8serrors.IsForbidden(err) {
+		t.Errorf("error after processing got: %v, want forbidden", err)
+	}
+}
+
+func TestFailedResourceFailsRequest(t *testing.T) {
+	client := &perResourceClient{values: map[string]float64{"orders-eu": 4}}
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = resourceClientFactory{client}
+
+	request := externalmetrics.AzureExternalMetricRequest{
+		MetricName: "ActiveMessages",
+		Resources: []externalmetrics.ResourceRef{
+			{ResourceGroup: "eu", ResourceName: "orders-eu"},
+			{ResourceGroup: "us", ResourceName: "orders-us"},
+		},
+	}
+	_, err := provider.getMetricAcrossResources("default", "orders", request)
+
+	if !k8serrors.IsBadRequest(err) || !strings.Contains(err.Error(), "orders-us") {
+		t.Errorf("error after processing got: %v, want bad request naming the resource", err)
+	}
+}
+
+func TestQueriedResourcesAreAggregated(t *testing.T) {
+	client := &perResourceClient{values: map[string]float64{"orders-7f3a": 4, "orders-91bc": 6}}
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = resourceClientFactory{client}
+	provider.resourceLister = newFakeResourceLister([]externalmetrics.ResourceRef{
+		{ResourceGroup: "orders-7f3a", ResourceName: "orders-7f3a"},
+		{ResourceGroup: "orders-91bc", ResourceName: "orders-91bc"},
+	})
+
+	request := externalmetrics.AzureExternalMetricRequest{
+		MetricName:    "ActiveMessages",
+		ResourceQuery: "resources | where name startswith 'orders-' | project id",
+	}
+	value, err := provider.getMetricAcrossResources("default", "orders", request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if value.Total != 10 {
+		t.Errorf("value = %v, want %v", value.Total, 10)
+	}
+}
+
+func TestResourcesResolvedAgainOnceExternalMetricChanged(t *testing.T) {
+	client := &perResourceClient{values: map[string]float64{"orders-eu": 4}}
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = resourceClientFactory{client}
+	lister := newFakeResourceLister([]externalmetrics.ResourceRef{{ResourceGroup: "eu", ResourceName: "orders-eu"}})
+	provider.resourceLister = lister
+
+	request := externalmetrics.AzureExternalMetricRequest{
+		MetricName:   "ActiveMessages",
+		ResourceTags: map[string]string{"workload": "orders"},
+	}
+	provider.metricCache.Update("ExternalMetric/default/orders", request)
+	changedAt, _ := provider.metricCache.ExternalMetricChangedAt("default", "orders")
+
+	// resyncing the same spec is not a change
+	provider.metricCache.Update("ExternalMetric/default/orders", request)
+	if resynced, _ := provider.metricCache.ExternalMetricChangedAt("default", "orders"); resynced != changedAt {
+		t.Errorf("changed at = %v after resyncing the same spec, want %v", resynced, changedAt)
+	}
+
+	if _, err := provider.getMetricAcrossResources("default", "orders", request); err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if _, _, _, _, since := lister.ListResourcesArgsForCall(0); since != changedAt {
+		t.Errorf("resources listed since %v, want since the ExternalMetric changed at %v", since, changedAt)
+	}
+}
+
+func TestNoTaggedResourcesGetError(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.resourceLister = newFakeResourceLister(nil)
+
+	request := externalmetrics.AzureExternalMetricRequest{
+		MetricName:   "ActiveMessages",
+		ResourceTags: map[string]string{"workload": "orders"},
+	}
+	_, err := provider.getMetricAcrossResources("default", "orders", request)
+
+	if !k8serrors.IsBadRequest(err) {
+		t.Errorf("error after processing got: %v, want bad request", err)
+	}
+}
+
+func newResourceGroupEnforcer(resourceGroups ...string) *policy.Enforcer {
+	i := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	i.Azure().V1alpha2().AdapterPolicies().Informer().GetIndexer().Add(&api.AdapterPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "restricted"},
+		Spec: api.AdapterPolicySpec{
+			Namespaces:     []string{"default"},
+			ResourceGroups: resourceGroups,
+		},
+	})
+	return policy.NewEnforcer(i.Azure().V1alpha2().AdapterPolicies().Lister(), nil)
+}
+
+type resourceClientFactory struct {
+	client *perResourceClient
+}
+
+func (f resourceClientFactory) GetAzureExternalMetricClient(clientType string) (externalmetrics.AzureExternalMetricClient, error) {
+	return f.client, nil
+}
+
+// perResourceClient returns the value for the resource name of the request
+type perResourceClient struct {
+	mu        sync.Mutex
+	values    map[string]float64
+	requested []string
+}
+
+func (c *perResourceClient) GetAzureMetric(azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requested = append(c.requested, azMetricRequest.ResourceName)
+	value, ok := c.values[azMetricRequest.ResourceName]
+	if !ok {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.New("resource not found")
+	}
+	return externalmetrics.AzureExternalMetricResponse{Total: value}, nil
+}
+
+// newFakeResourceLister lists and queries the resources
+func newFakeResourceLister(resources []externalmetrics.ResourceRef) *azurefake.FakeResourceLister {
+	lister := &azurefake.FakeResourceLister{}
+	lister.ListResourcesReturns(resources, nil)
+	lister.QueryResourcesReturns(resources, nil)
+	return lister
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// getSeasonalMetric queries the metric over its window now and over the same window an offset ago in
+// parallel and returns their ratio or difference, so workloads can scale on traffic compared with the
+// same hour of the previous week.  Each query is checked by policy and the request fails if either
+// fails.  A past value of 0 has no ratio and is handled like the empty denominator of a ratio metric.
+func (p *AzureProvider) getSeasonalMetric(namespace string, metricName string, azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	if azMetricRequest.SplitDimension != "" {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest("a seasonal metric can not be split by dimension")
+	}
+	seasonal := azMetricRequest.Seasonal
+	if err := seasonal.Validate(); err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest(err.Error())
+	}
+
+	requests := []externalmetrics.AzureExternalMetricRequest{
+		seasonal.Current(azMetricRequest),
+		seasonal.Past(azMetricRequest, time.Now()),
+	}
+	values := make([]externalmetrics.AzureExternalMetricResponse, len(requests))
+	errs := make([]error, len(requests))
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		wg.Add(1)
+		go func(i int, request externalmetrics.AzureExternalMetricRequest) {
+			defer wg.Done()
+			values[i], errs[i] = p.queryExternalMetric(namespace, metricName, request)
+		}(i, request)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			glog.Errorf("seasonal query of %s failed: %v", metricName, err)
+			return externalmetrics.AzureExternalMetricResponse{}, err
+		}
+	}
+
+	current, past := values[0], values[1]
+	comparison := externalmetrics.AzureExternalMetricResponse{
+		Raw: append(append([]string{}, current.Raw...), past.Raw...),
+	}
+	if seasonal.Comparison == externalmetrics.SeasonalDifference {
+		comparison.Total = current.Total - past.Total
+		glog.V(2).Infof("seasonal difference of %s: %f - %f = %f", metricName, current.Total, past.Total, comparison.Total)
+		return comparison, nil
+	}
+
+	if past.Total == 0 {
+		if azMetricRequest.NoData.Policy == "" {
+			return comparison, errors.NewServiceUnavailable(fmt.Sprintf("the past value of %s is 0", metricName))
+		}
+		comparison.NoData = true
+		return comparison, nil
+	}
+	comparison.Total = current.Total / past.Total
+
+	glog.V(2).Infof("seasonal ratio of %s: %f / %f = %f", metricName, current.Total, past.Total, comparison.Total)
+	return comparison, nil
+}
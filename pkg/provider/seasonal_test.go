@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestSeasonalMetricComparesWithPastWindow(t *testing.T) {
+	var tests = []struct {
+		name     string
+		seasonal externalmetrics.SeasonalDefinition
+		past     float64
+		want     int64
+	}{
+		{"ratio with last week", externalmetrics.SeasonalDefinition{}, 100, 1200},
+		{"ratio with yesterday", externalmetrics.SeasonalDefinition{Offset: "24h", Comparison: externalmetrics.SeasonalRatio}, 60, 2000},
+		{"difference with last week", externalmetrics.SeasonalDefinition{Comparison: externalmetrics.SeasonalDifference}, 100, 20000},
+	}
+
+	for _, tt := range tests {
+		offset, _ := tt.seasonal.ParseOffset()
+		factory := &timespanClientFactory{offset: offset, current: 120, past: tt.past}
+		provider := newProvider(fakeAzureExternalClientFactory{})
+		provider.azureClientFactory = factory
+		provider.metricCache.Update("ExternalMetric/default/requests", externalmetrics.AzureExternalMetricRequest{
+			Type:         externalmetrics.Seasonal,
+			MetricName:   "Requests",
+			ResourceName: "app",
+			Window:       "1h",
+			Seasonal:     tt.seasonal,
+		})
+
+		selector, _ := labels.Parse("")
+		returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "requests"})
+
+		if err != nil {
+			t.Fatalf("%s: error after processing got: %v, want nil", tt.name, err)
+		}
+		if returnList.Items[0].Value.MilliValue() != tt.want {
+			t.Errorf("%s: value = %v, want %v", tt.name, returnList.Items[0].Value.MilliValue(), tt.want)
+		}
+		if factory.pastWindow != time.Hour {
+			t.Errorf("%s: past window = %v, want the window of the metric", tt.name, factory.pastWindow)
+		}
+	}
+}
+
+func TestSeasonalMetricWithInvalidDefinitionIsBadRequest(t *testing.T) {
+	var tests = []struct {
+		name     string
+		seasonal externalmetrics.SeasonalDefinition
+	}{
+		{"offset beyond retention", externalmetrics.SeasonalDefinition{Offset: "2400h"}},
+		{"unknown comparison", externalmetrics.SeasonalDefinition{Comparison: "percent"}},
+	}
+
+	for _, tt := range tests {
+		provider := newProvider(fakeAzureExternalClientFactory{})
+		provider.metricCache.Update("ExternalMetric/default/requests", externalmetrics.AzureExternalMetricRequest{
+			Type:       externalmetrics.Seasonal,
+			MetricName: "Requests",
+			Seasonal:   tt.seasonal,
+		})
+
+		selector, _ := labels.Parse("")
+		_, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "requests"})
+
+		if !k8serrors.IsBadRequest(err) {
+			t.Errorf("%s: error after processing got: %v, want bad request", tt.name, err)
+		}
+	}
+}
+
+// timespanClientFactory returns a client serving the past value for queries of a timespan ending
+// the offset ago and the current value otherwise, and records the window of the past query
+type timespanClientFactory struct {
+	offset  time.Duration
+	current float64
+	past    float64
+
+	pastWindow time.Duration
+}
+
+func (f *timespanClientFactory) GetAzureExternalMetricClient(clientType string) (externalmetrics.AzureExternalMetricClient, error) {
+	return f, nil
+}
+
+func (f *timespanClientFactory) GetAzureMetric(azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	if azMetricRequest.Window != "" {
+		return externalmetrics.AzureExternalMetricResponse{Total: f.current}, nil
+	}
+
+	bounds := strings.Split(azMetricRequest.Timespan, "/")
+	start, _ := time.Parse(time.RFC3339, bounds[0])
+	end, _ := time.Parse(time.RFC3339, bounds[1])
+	if ago := time.Since(end); ago < f.offset || ago > f.offset+time.Minute {
+		return externalmetrics.AzureExternalMetricResponse{}, k8serrors.NewBadRequest("timespan doesn't end the offset ago")
+	}
+	f.pastWindow = end.Sub(start)
+	return externalmetrics.AzureExternalMetricResponse{Total: f.past}, nil
+}
@@ -0,0 +1,40 @@
+package provider
+
+import (
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// servedDuringIncident returns the value last served for a metric whose query failed while Azure
+// Service Health reports an Azure Monitor incident in the adapter's region, so autoscalers hold
+// their inputs rather than every metric failing until the incident is resolved.  A metric has
+// to have been served before, and metrics denied by policy still fail.
+func (p *AzureProvider) servedDuringIncident(valueKey string, azMetricRequest externalmetrics.AzureExternalMetricRequest, queryErr error) (externalmetrics.AzureExternalMetricResponse, string, bool) {
+	if p.serviceHealth == nil || errors.IsForbidden(queryErr) {
+		return externalmetrics.AzureExternalMetricResponse{}, "", false
+	}
+
+	subscriptionID := azMetricRequest.SubscriptionID
+	if subscriptionID == "" {
+		subscriptionID = p.defaultSubscriptionID
+	}
+	incident, found, err := p.serviceHealth.Incident(subscriptionID)
+	if err != nil {
+		glog.Errorf("unable to check azure service health: %v", redact.Error(err))
+		return externalmetrics.AzureExternalMetricResponse{}, "", false
+	}
+	if !found {
+		return externalmetrics.AzureExternalMetricResponse{}, "", false
+	}
+
+	value, found := p.maintenance.last(valueKey)
+	if !found {
+		glog.V(2).Infof("no value of %s to serve during incident %s", valueKey, incident)
+		return externalmetrics.AzureExternalMetricResponse{}, "", false
+	}
+
+	glog.V(2).Infof("serving the previous value of %s during incident %s: %v", valueKey, incident, queryErr)
+	return value, incident, true
+}
@@ -0,0 +1,87 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	azurefake "github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/fake"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestPreviousValueServedDuringIncident(t *testing.T) {
+	var tests = []struct {
+		name         string
+		health       *azurefake.FakeServiceHealth
+		wantIncident string
+		wantErr      bool
+	}{
+		{"incident", newFakeServiceHealth(map[string]string{"1234": "AB-123"}, nil), "AB-123", false},
+		{"no incident", newFakeServiceHealth(nil, nil), "", true},
+		{"incident of another subscription", newFakeServiceHealth(map[string]string{"5678": "AB-123"}, nil), "", true},
+		{"service health unavailable", newFakeServiceHealth(nil, errors.New("forbidden")), "", true},
+	}
+
+	for _, tt := range tests {
+		client := newFakeExternalMetricClient(externalmetrics.AzureExternalMetricResponse{Total: 5}, nil)
+		provider := newProvider(fakeAzureExternalClientFactory{})
+		provider.azureClientFactory = newFakeClientFactory(client)
+		provider.defaultSubscriptionID = "1234"
+		provider.serviceHealth = tt.health
+		provider.maintenance = newMaintenance(externalmetrics.MaintenanceDefinition{}, 0)
+		provider.rawResponses = NewRawResponses()
+		provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{MetricName: "Messages"})
+
+		selector, _ := labels.Parse("")
+		if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"}); err != nil {
+			t.Fatalf("%s: error after processing got: %v, want nil", tt.name, err)
+		}
+
+		client.GetAzureMetricReturns(externalmetrics.AzureExternalMetricResponse{}, errors.New("monitor unavailable"))
+		returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: error after processing got: nil, want error", tt.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: error after processing got: %v, want nil", tt.name, err)
+		}
+		if returnList.Items[0].Value.Value() != 5 {
+			t.Errorf("%s: externalMetric.Value = %v, want there %v", tt.name, returnList.Items[0].Value.Value(), 5)
+		}
+		if incident := provider.rawResponses.responses["default/queue"].Incident; incident != tt.wantIncident {
+			t.Errorf("%s: raw response incident = %v, want %v", tt.name, incident, tt.wantIncident)
+		}
+		if requests := client.GetAzureMetricCallCount(); requests != 2 {
+			t.Errorf("%s: requests = %v, want %v", tt.name, requests, 2)
+		}
+	}
+}
+
+func TestMetricFirstQueriedDuringIncidentFails(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = newFakeClientFactory(newFakeExternalMetricClient(externalmetrics.AzureExternalMetricResponse{}, errors.New("monitor unavailable")))
+	provider.serviceHealth = newFakeServiceHealth(map[string]string{"": "AB-123"}, nil)
+	provider.maintenance = newMaintenance(externalmetrics.MaintenanceDefinition{}, 0)
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{MetricName: "Messages"})
+
+	selector, _ := labels.Parse("")
+	if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"}); !k8serrors.IsBadRequest(err) {
+		t.Errorf("error after processing got: %v, want the error of the query", err)
+	}
+}
+
+// newFakeServiceHealth reports the incident of each subscription, or the error
+func newFakeServiceHealth(incidents map[string]string, err error) *azurefake.FakeServiceHealth {
+	health := &azurefake.FakeServiceHealth{}
+	health.IncidentCalls(func(subscriptionID string) (string, bool, error) {
+		incident, found := incidents[subscriptionID]
+		return incident, found, err
+	})
+	return health
+}
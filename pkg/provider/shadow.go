@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ShadowDifferenceSuffix is added to the name of an ExternalMetric with a shadow spec to request
+// the difference between the value of the shadow spec and the value served
+const ShadowDifferenceSuffix = "-shadow-difference"
+
+// shadowComparisons remembers the latest difference between the shadow and primary value of each metric
+type shadowComparisons struct {
+	mu          sync.Mutex
+	differences map[string]float64
+}
+
+func newShadowComparisons() *shadowComparisons {
+	return &shadowComparisons{
+		differences: map[string]float64{},
+	}
+}
+
+// shadowMetricName returns the ExternalMetric name a shadow difference metric name refers to
+func shadowMetricName(metricName string) (string, bool) {
+	if !strings.HasSuffix(metricName, ShadowDifferenceSuffix) {
+		return "", false
+	}
+	return strings.TrimSuffix(metricName, ShadowDifferenceSuffix), true
+}
+
+// compareShadow queries the shadow spec of a metric and records the difference from the primary
+// value.  Failures are only logged as the primary value is served regardless.
+func (p *AzureProvider) compareShadow(namespace string, metricName string, metricSelector labels.Selector, shadow externalmetrics.AzureExternalMetricRequest, primary externalmetrics.AzureExternalMetricResponse) {
+	if p.shadows == nil {
+		return
+	}
+
+	shadow.Timespan = externalmetrics.TimeSpan()
+	if shadow.SubscriptionID == "" {
+		shadow.SubscriptionID = p.defaultSubscriptionID
+	}
+
+	var err error
+	if shadow.Node.Enabled {
+		shadow, err = p.resolveNode(metricSelector, shadow)
+	}
+	var shadowValue externalmetrics.AzureExternalMetricResponse
+	if err == nil {
+		shadowValue, err = p.queryExternalMetric(namespace, metricName, shadow)
+	}
+	if err != nil {
+		glog.Errorf("shadow query of %s in namespace %s failed: %v", metricName, namespace, err)
+		return
+	}
+
+	difference := shadowValue.Total - primary.Total
+	glog.V(2).Infof("shadow value of %s in namespace %s is %f, primary is %f, difference %f", metricName, namespace, shadowValue.Total, primary.Total, difference)
+
+	p.shadows.mu.Lock()
+	defer p.shadows.mu.Unlock()
+	p.shadows.differences[fmt.Sprintf("%s/%s", namespace, metricName)] = difference
+}
+
+// difference returns the latest difference between the shadow and primary value of the metric
+func (s *shadowComparisons) difference(namespace string, metricName string) (float64, bool) {
+	if s == nil {
+		return 0, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	difference, found := s.differences[fmt.Sprintf("%s/%s", namespace, metricName)]
+	return difference, found
+}
@@ -0,0 +1,70 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestShadowIsQueriedButPrimaryIsServed(t *testing.T) {
+	client := &countingExternalClient{}
+
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = countingClientFactory{client: client}
+	provider.shadows = newShadowComparisons()
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		Shadow:     &externalmetrics.AzureExternalMetricRequest{MetricName: "ActiveMessages"},
+	})
+
+	selector, _ := labels.Parse("")
+
+	// the primary value is 1 and the shadow value 2
+	returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if returnList.Items[0].Value.Value() != 1 {
+		t.Errorf("externalMetric.Value = %v, want there %v", returnList.Items[0].Value.Value(), 1)
+	}
+	if client.calls != 2 {
+		t.Errorf("calls = %v, want there %v", client.calls, 2)
+	}
+
+	// the primary value is 3 and the shadow value 4
+	returnList, err = provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue-shadow-difference"})
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if returnList.Items[0].MetricName != "queue-shadow-difference" || returnList.Items[0].Value.Value() != 1 {
+		t.Errorf("externalMetric = %v %v, want there queue-shadow-difference 1", returnList.Items[0].MetricName, returnList.Items[0].Value.Value())
+	}
+}
+
+func TestShadowFailureServesPrimary(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.shadows = newShadowComparisons()
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		// an invalid alert fails the shadow query
+		Shadow: &externalmetrics.AzureExternalMetricRequest{MetricName: "Messages", Alert: externalmetrics.AlertDefinition{RuleID: "invalid"}},
+	})
+
+	selector, _ := labels.Parse("")
+	returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if returnList.Items[0].Value.Value() != 15 {
+		t.Errorf("externalMetric.Value = %v, want there %v", returnList.Items[0].Value.Value(), 15)
+	}
+
+	_, err = provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue-shadow-difference"})
+	if !k8serrors.IsServiceUnavailable(err) {
+		t.Errorf("error after processing got: %v, want service unavailable", err)
+	}
+}
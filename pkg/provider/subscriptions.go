@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/policy"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/redact"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// getMetricAcrossSubscriptions queries the metric in each subscription of the request in parallel and
+// aggregates the values.  The request fails if any subscription fails so a partial value is never served.
+func (p *AzureProvider) getMetricAcrossSubscriptions(namespace string, metricName string, azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	subscriptions, err := p.permittedSubscriptions(namespace, metricName, azMetricRequest)
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, err
+	}
+	if len(subscriptions) == 0 {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest("no subscriptions to query")
+	}
+
+	factory, err := p.clientFactory(namespace, azMetricRequest)
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, err
+	}
+
+	externalMetricClient, err := factory.GetAzureExternalMetricClient(azMetricRequest.Type)
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest(err.Error())
+	}
+
+	requests := make([]externalmetrics.AzureExternalMetricRequest, len(subscriptions))
+	for i, subscriptionID := range subscriptions {
+		requests[i] = azMetricRequest
+		requests[i].SubscriptionID = subscriptionID
+	}
+	values, raw, err := p.queryEach(namespace, metricName, externalMetricClient, requests, func(request externalmetrics.AzureExternalMetricRequest) string {
+		return fmt.Sprintf("subscription %s", request.SubscriptionID)
+	})
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, err
+	}
+
+	value, err := externalmetrics.AggregateSubscriptions(azMetricRequest.SubscriptionAggregation, values)
+	if err != nil {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest(err.Error())
+	}
+
+	glog.V(2).Infof("aggregated metric value across %d subscriptions: %f", len(subscriptions), value)
+	return externalmetrics.AzureExternalMetricResponse{Total: value, Raw: raw}, nil
+}
+
+// queryEach queries the client for each request in parallel, returning the value of each request
+// and their raw responses.  The requests fail if any request fails, naming it with describe.
+func (p *AzureProvider) queryEach(namespace string, metricName string, externalMetricClient externalmetrics.AzureExternalMetricClient, requests []externalmetrics.AzureExternalMetricRequest, describe func(externalmetrics.AzureExternalMetricRequest) string) ([]float64, []string, error) {
+	values := make([]float64, len(requests))
+	raw := make([][]string, len(requests))
+	errs := make([]error, len(requests))
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		wg.Add(1)
+		go func(i int, request externalmetrics.AzureExternalMetricRequest) {
+			defer wg.Done()
+
+			p.apiCosts.record(namespace, metricName, request.Type)
+			metricValue, err := externalMetricClient.GetAzureMetric(request)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %v", describe(request), err)
+				return
+			}
+			values[i] = metricValue.Total
+			raw[i] = metricValue.Raw
+		}(i, request)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			err = redact.Error(err)
+			glog.Errorf("bad request: %v", err)
+			return nil, nil, errors.NewBadRequest(err.Error())
+		}
+	}
+
+	allRaw := []string{}
+	for _, r := range raw {
+		allRaw = append(allRaw, r...)
+	}
+	return values, allRaw, nil
+}
+
+// permittedSubscriptions expands all accessible subscriptions and checks each against the policies.
+// Listed subscriptions must all be permitted, while accessible subscriptions a policy does not
+// permit are left out.
+func (p *AzureProvider) permittedSubscriptions(namespace string, metricName string, azMetricRequest externalmetrics.AzureExternalMetricRequest) ([]string, error) {
+	seen := map[string]bool{}
+	subscriptions := []string{}
+	add := func(subscriptionID string, listed bool) error {
+		key := strings.ToLower(subscriptionID)
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+
+		request := azMetricRequest
+		request.SubscriptionID = subscriptionID
+		err := p.policyEnforcer.Authorize(namespace, policy.ScopeForRequest(request))
+		if policy.IsViolationError(err) && !listed {
+			glog.V(4).Infof("skipping subscription %s: %v", subscriptionID, err)
+			return nil
+		}
+		if err != nil {
+			return policyError(metricName, err)
+		}
+
+		subscriptions = append(subscriptions, subscriptionID)
+		return nil
+	}
+
+	for _, subscriptionID := range azMetricRequest.Subscriptions {
+		if subscriptionID != externalmetrics.AllSubscriptions {
+			if err := add(subscriptionID, true); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if p.subscriptionLister == nil {
+			return nil, errors.NewBadRequest("listing accessible subscriptions is not configured")
+		}
+		accessible, err := p.subscriptionLister.ListSubscriptions()
+		if err != nil {
+			err = redact.Error(err)
+			glog.Errorf("unable to list subscriptions: %v", err)
+			return nil, errors.NewServiceUnavailable(err.Error())
+		}
+		for _, accessibleID := range accessible {
+			if err := add(accessibleID, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return subscriptions, nil
+}
@@ -0,0 +1,142 @@
+package provider
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	azurefake "github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/fake"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/fake"
+	informers "github.com/Azure/azure-k8s-metrics-adapter/pkg/client/informers/externalversions"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/policy"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAggregatesListedSubscriptions(t *testing.T) {
+	client := &perSubscriptionClient{values: map[string]float64{"1111": 4, "2222": 6}}
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = subscriptionClientFactory{client}
+
+	request := externalmetrics.AzureExternalMetricRequest{
+		MetricName:    "Messages",
+		Subscriptions: []string{"1111", "2222", "1111"},
+	}
+	value, err := provider.getMetricAcrossSubscriptions("default", "queue", request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if value.Total != 10 {
+		t.Errorf("value = %v, want %v", value.Total, 10)
+	}
+
+	if len(client.requested) != 2 {
+		t.Errorf("requested subscriptions = %v, want each subscription once", client.requested)
+	}
+}
+
+func TestAllAccessibleSubscriptionsSkipsThoseOutsidePolicy(t *testing.T) {
+	client := &perSubscriptionClient{values: map[string]float64{"1111": 4, "2222": 6, "3333": 8}}
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = subscriptionClientFactory{client}
+	lister := &azurefake.FakeSubscriptionLister{}
+	lister.ListSubscriptionsReturns([]string{"1111", "2222", "3333"}, nil)
+	provider.subscriptionLister = lister
+	provider.policyEnforcer = newSubscriptionEnforcer("1111", "3333")
+
+	request := externalmetrics.AzureExternalMetricRequest{
+		MetricName:              "Messages",
+		Subscriptions:           []string{externalmetrics.AllSubscriptions},
+		SubscriptionAggregation: externalmetrics.SubscriptionMaximum,
+	}
+	value, err := provider.getMetricAcrossSubscriptions("default", "queue", request)
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+
+	if value.Total != 8 {
+		t.Errorf("value = %v, want %v", value.Total, 8)
+	}
+
+	for _, subscriptionID := range client.requested {
+		if subscriptionID == "2222" {
+			t.Errorf("requested subscriptions = %v, want 2222 skipped", client.requested)
+		}
+	}
+}
+
+func TestListedSubscriptionOutsidePolicyIsForbidden(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.policyEnforcer = newSubscriptionEnforcer("1111")
+
+	request := externalmetrics.AzureExternalMetricRequest{
+		MetricName:    "Messages",
+		Subscriptions: []string{"1111", "2222"},
+	}
+	_, err := provider.getMetricAcrossSubscriptions("default", "queue", request)
+
+	if !k8serrors.IsForbidden(err) {
+		t.Errorf("error after processing got: %v, want forbidden", err)
+	}
+}
+
+func TestFailedSubscriptionFailsRequest(t *testing.T) {
+	client := &perSubscriptionClient{values: map[string]float64{"1111": 4}}
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = subscriptionClientFactory{client}
+
+	request := externalmetrics.AzureExternalMetricRequest{
+		MetricName:    "Messages",
+		Subscriptions: []string{"1111", "2222"},
+	}
+	_, err := provider.getMetricAcrossSubscriptions("default", "queue", request)
+
+	if !k8serrors.IsBadRequest(err) || !strings.Contains(err.Error(), "2222") {
+		t.Errorf("error after processing got: %v, want bad request naming the subscription", err)
+	}
+}
+
+func newSubscriptionEnforcer(subscriptions ...string) *policy.Enforcer {
+	i := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	i.Azure().V1alpha2().AdapterPolicies().Informer().GetIndexer().Add(&api.AdapterPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "restricted"},
+		Spec: api.AdapterPolicySpec{
+			Namespaces:    []string{"default"},
+			Subscriptions: subscriptions,
+		},
+	})
+	return policy.NewEnforcer(i.Azure().V1alpha2().AdapterPolicies().Lister(), nil)
+}
+
+type subscriptionClientFactory struct {
+	client *perSubscriptionClient
+}
+
+func (f subscriptionClientFactory) GetAzureExternalMetricClient(clientType string) (externalmetrics.AzureExternalMetricClient, error) {
+	return f.client, nil
+}
+
+// perSubscriptionClient returns the value for the subscription of the request
+type perSubscriptionClient struct {
+	mu        sync.Mutex
+	values    map[string]float64
+	requested []string
+}
+
+func (c *perSubscriptionClient) GetAzureMetric(azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requested = append(c.requested, azMetricRequest.SubscriptionID)
+	value, ok := c.values[azMetricRequest.SubscriptionID]
+	if !ok {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.New("subscription not found")
+	}
+	return externalmetrics.AzureExternalMetricResponse{Total: value}, nil
+}
@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/credentials"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// tenantSources keeps a source for each credential and tenant metrics override its tenant with,
+// so their tokens are reused.  Like AzureCredentials they are read again once their secret is due
+// to be, and kept while it is unchanged.
+type tenantSources struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	sources map[tenantKey]pooledTenant
+}
+
+type tenantKey struct {
+	source   credentials.Source
+	tenantID string
+}
+
+type pooledTenant struct {
+	loaded time.Time
+	source *credentials.NamedSource
+}
+
+func newTenantSources() *tenantSources {
+	return &tenantSources{
+		now:     time.Now,
+		sources: map[tenantKey]pooledTenant{},
+	}
+}
+
+// source returns a source authenticating as the application of the source in the tenant
+func (t *tenantSources) source(source credentials.Source, tenantID string) (credentials.Source, error) {
+	key := tenantKey{source: source, tenantID: tenantID}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pooled, found := t.sources[key]
+	if found && t.now().Sub(pooled.loaded) < credentialSecretRefresh {
+		return pooled.source, nil
+	}
+
+	config, err := credentials.TenantConfig(source, tenantID)
+	if err != nil {
+		return nil, errors.NewBadRequest(err.Error())
+	}
+	if found && pooled.source.Config() == config {
+		pooled.loaded = t.now()
+		t.sources[key] = pooled
+		return pooled.source, nil
+	}
+
+	named, err := credentials.NewNamedSource(config)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("unable to authenticate in tenant %s: %v", tenantID, err))
+	}
+
+	glog.V(2).Infof("authenticating as %s in tenant %s", config.ClientID, tenantID)
+	t.sources[key] = pooledTenant{loaded: t.now(), source: named}
+	return named, nil
+}
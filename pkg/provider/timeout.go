@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/golang/glog"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// timeouts serves the previous value of a metric when a query exceeds the response budget of the
+// metric, so a slow Azure api doesn't hold up the sync loop of the horizontal pod autoscaler
+type timeouts struct {
+	mu       sync.Mutex
+	values   map[string]externalmetrics.AzureExternalMetricResponse
+	inflight map[string]*timedQuery
+}
+
+// timedQuery is a query that may complete after the request that started it was served
+type timedQuery struct {
+	done  chan struct{}
+	value externalmetrics.AzureExternalMetricResponse
+	err   error
+}
+
+func newTimeouts() *timeouts {
+	return &timeouts{
+		values:   map[string]externalmetrics.AzureExternalMetricResponse{},
+		inflight: map[string]*timedQuery{},
+	}
+}
+
+// query runs the query and waits up to the timeout for it.  When the query takes longer the
+// previous value is served and the query completes in the background, updating the value
+// served next.  Requests of the metric while the query is running wait for it rather than
+// starting another query.
+func (t *timeouts) query(key string, timeout string, query func() (externalmetrics.AzureExternalMetricResponse, error)) (externalmetrics.AzureExternalMetricResponse, error) {
+	budget, err := time.ParseDuration(timeout)
+	if err != nil || budget <= 0 {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewBadRequest(fmt.Sprintf("invalid timeout '%s', must be a positive go duration such as 2s", timeout))
+	}
+
+	t.mu.Lock()
+	q, found := t.inflight[key]
+	if !found {
+		q = &timedQuery{done: make(chan struct{})}
+		t.inflight[key] = q
+		go t.run(key, q, query)
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-q.done:
+		return q.value, q.err
+	case <-time.After(budget):
+	}
+
+	t.mu.Lock()
+	value, found := t.values[key]
+	t.mu.Unlock()
+	if !found {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewServiceUnavailable(fmt.Sprintf("azure did not respond within %s and there is no previous value", budget))
+	}
+
+	glog.Warningf("azure did not respond within %s, serving the previous value of %s", budget, key)
+	return value, nil
+}
+
+func (t *timeouts) run(key string, q *timedQuery, query func() (externalmetrics.AzureExternalMetricResponse, error)) {
+	q.value, q.err = query()
+
+	t.mu.Lock()
+	delete(t.inflight, key)
+	if q.err == nil {
+		t.values[key] = q.value
+	}
+	t.mu.Unlock()
+	close(q.done)
+}
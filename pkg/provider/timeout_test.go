@@ -0,0 +1,124 @@
+package provider
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestTimeoutServesPreviousValueWhileQueryCompletes(t *testing.T) {
+	client := &slowExternalClient{release: make(chan struct{}), value: 10}
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.timeouts = newTimeouts()
+	provider.azureClientFactory = slowClientFactory{client}
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		Timeout:    "50ms",
+	})
+	selector, _ := labels.Parse("")
+
+	// the first query completes within the budget
+	close(client.release)
+	returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if returnList.Items[0].Value.Value() != 10 {
+		t.Errorf("externalMetric.Value = %v, want there %v", returnList.Items[0].Value.Value(), 10)
+	}
+
+	// the second query is slow so the previous value is served
+	client.setSlow(20)
+	returnList, err = provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if returnList.Items[0].Value.Value() != 10 {
+		t.Errorf("externalMetric.Value = %v, want the previous value %v", returnList.Items[0].Value.Value(), 10)
+	}
+
+	// once the query completes in the background its value is served
+	close(client.release)
+	deadline := time.Now().Add(time.Second)
+	for {
+		returnList, err = provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+		if err == nil && returnList.Items[0].Value.Value() == 20 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("value of the background query was not served, got %v, %v", returnList, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTimeoutWithoutPreviousValueGetError(t *testing.T) {
+	client := &slowExternalClient{release: make(chan struct{}), value: 10}
+	defer close(client.release)
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.timeouts = newTimeouts()
+	provider.azureClientFactory = slowClientFactory{client}
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		Timeout:    "10ms",
+	})
+
+	selector, _ := labels.Parse("")
+	_, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+
+	if !k8serrors.IsServiceUnavailable(err) {
+		t.Errorf("error after processing got: %v, want service unavailable", err)
+	}
+}
+
+func TestInvalidTimeoutGetError(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.timeouts = newTimeouts()
+	provider.metricCache.Update("ExternalMetric/default/queue", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		Timeout:    "soon",
+	})
+
+	selector, _ := labels.Parse("")
+	_, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue"})
+
+	if !k8serrors.IsBadRequest(err) {
+		t.Errorf("error after processing got: %v, want bad request", err)
+	}
+}
+
+type slowClientFactory struct {
+	client *slowExternalClient
+}
+
+func (f slowClientFactory) GetAzureExternalMetricClient(clientType string) (externalmetrics.AzureExternalMetricClient, error) {
+	return f.client, nil
+}
+
+// slowExternalClient returns its value once released
+type slowExternalClient struct {
+	mu      sync.Mutex
+	release chan struct{}
+	value   float64
+}
+
+func (c *slowExternalClient) setSlow(value float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.release = make(chan struct{})
+	c.value = value
+}
+
+func (c *slowExternalClient) GetAzureMetric(azMetricRequest externalmetrics.AzureExternalMetricRequest) (externalmetrics.AzureExternalMetricResponse, error) {
+	c.mu.Lock()
+	release, value := c.release, c.value
+	c.mu.Unlock()
+
+	<-release
+	return externalmetrics.AzureExternalMetricResponse{Total: value}, nil
+}
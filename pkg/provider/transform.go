@@ -0,0 +1,33 @@
+package provider
+
+import (
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/transform"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// transformValue applies the transform to the value and each series of the metric.  The value is
+// then in the unit of the transform, so it is not scaled again by its Azure Monitor unit.
+func transformValue(t transform.Transform, metricValue externalmetrics.AzureExternalMetricResponse) (externalmetrics.AzureExternalMetricResponse, error) {
+	total, err := t.Apply(metricValue.Total, metricValue.Unit)
+	if err != nil {
+		return metricValue, errors.NewBadRequest(err.Error())
+	}
+
+	transformed := externalmetrics.AzureExternalMetricResponse{
+		Total:  total,
+		Raw:    metricValue.Raw,
+		NoData: metricValue.NoData,
+	}
+	for _, series := range metricValue.Series {
+		value, err := t.Apply(series.Value, metricValue.Unit)
+		if err != nil {
+			return metricValue, errors.NewBadRequest(err.Error())
+		}
+		transformed.Series = append(transformed.Series, externalmetrics.MetricSeries{
+			Labels: series.Labels,
+			Value:  value,
+		})
+	}
+	return transformed, nil
+}
@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/transform"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestTransformConvertsEachSeries(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = rawResponseClientFactory{response: externalmetrics.AzureExternalMetricResponse{
+		Total: 6 << 20,
+		Unit:  externalmetrics.UnitBytes,
+		Series: []externalmetrics.MetricSeries{
+			{Labels: map[string]string{"container": "orders"}, Value: 4 << 20},
+			{Labels: map[string]string{"container": "payments"}, Value: 2 << 20},
+		},
+	}}
+	provider.metricCache.Update("ExternalMetric/default/memory", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "MemoryWorkingSet",
+		UseUnits:   true,
+		Transform:  transform.Transform{Unit: transform.MiB, Offset: "1"},
+	})
+
+	selector, _ := labels.Parse("")
+	returnList, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "memory"})
+
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	want := map[string]int64{"orders": 5, "payments": 3}
+	for _, item := range returnList.Items {
+		container := item.MetricLabels["container"]
+		if item.Value.Value() != want[container] {
+			t.Errorf("%s: externalMetric.Value = %v, want %v", container, item.Value.String(), want[container])
+		}
+	}
+}
+
+func TestTransformOfOtherUnitGetsBadRequest(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = rawResponseClientFactory{response: externalmetrics.AzureExternalMetricResponse{
+		Total: 250,
+		Unit:  externalmetrics.UnitMilliSeconds,
+	}}
+	provider.metricCache.Update("ExternalMetric/default/latency", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "SuccessE2ELatency",
+		Transform:  transform.Transform{Unit: transform.MiB},
+	})
+
+	selector, _ := labels.Parse("")
+	_, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "latency"})
+
+	if !k8serrors.IsBadRequest(err) {
+		t.Errorf("error after processing got: %v, want bad request", err)
+	}
+}
@@ -0,0 +1,150 @@
+package provider
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/metrics/pkg/apis/external_metrics"
+)
+
+const (
+	// variantFreshness is how long the value of a metric is served to its variants before the
+	// metric is queried again
+	variantFreshness = 30 * time.Second
+	// variantRateInterval is the shortest time between the two values a rate is computed from, so
+	// requests close together don't make the rate noisy
+	variantRateInterval = 10 * time.Second
+)
+
+// variantSample is a value of a metric with variants and when it was queried
+type variantSample struct {
+	value externalmetrics.AzureExternalMetricResponse
+	at    time.Time
+}
+
+// variantSamples are the latest value of a metric and the value the rate of its variants is
+// computed from
+type variantSamples struct {
+	previous *variantSample
+	latest   *variantSample
+}
+
+// variantValues keeps the recent values of the metrics with variants, so variants are served
+// without querying Azure again
+type variantValues struct {
+	mu      sync.Mutex
+	samples map[string]*variantSamples
+	now     func() time.Time
+}
+
+func newVariantValues() *variantValues {
+	return &variantValues{
+		samples: map[string]*variantSamples{},
+		now:     time.Now,
+	}
+}
+
+// record keeps the value of the metric.  The latest value becomes the previous one once it is
+// older than the rate interval.
+func (v *variantValues) record(key string, value externalmetrics.AzureExternalMetricResponse) {
+	if v == nil {
+		return
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	samples, found := v.samples[key]
+	if !found {
+		samples = &variantSamples{}
+		v.samples[key] = samples
+	}
+	now := v.now()
+	if samples.latest != nil && now.Sub(samples.latest.at) >= variantRateInterval {
+		samples.previous = samples.latest
+	}
+	samples.latest = &variantSample{value: value, at: now}
+}
+
+// fresh returns true when the metric was queried recently enough to serve its variants
+func (v *variantValues) fresh(key string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	samples, found := v.samples[key]
+	return found && samples.latest != nil && v.now().Sub(samples.latest.at) < variantFreshness
+}
+
+// value returns the latest value of the metric, or the per second rate of change of the value and
+// each of its series between its previous and latest value
+func (v *variantValues) value(key string, rate bool) (externalmetrics.AzureExternalMetricResponse, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	samples, found := v.samples[key]
+	if !found || samples.latest == nil {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewServiceUnavailable(fmt.Sprintf("%s has not been queried", key))
+	}
+	latest := samples.latest
+	if !rate {
+		return latest.value, nil
+	}
+	if samples.previous == nil {
+		return externalmetrics.AzureExternalMetricResponse{}, errors.NewServiceUnavailable(fmt.Sprintf("the rate of %s needs two values %s apart", key, variantRateInterval))
+	}
+
+	previous := samples.previous
+	seconds := latest.at.Sub(previous.at).Seconds()
+	previousSeries := map[string]float64{}
+	for _, series := range previous.value.Series {
+		previousSeries[labels.Set(series.Labels).String()] = series.Value
+	}
+
+	response := externalmetrics.AzureExternalMetricResponse{
+		Total:  (latest.value.Total - previous.value.Total) / seconds,
+		Unit:   externalmetrics.RateUnit(latest.value.Unit),
+		NoData: latest.value.NoData,
+	}
+	for _, series := range latest.value.Series {
+		// a series that just appeared has no rate yet
+		if value, found := previousSeries[labels.Set(series.Labels).String()]; found {
+			response.Series = append(response.Series, externalmetrics.MetricSeries{
+				Labels: series.Labels,
+				Value:  (series.Value - value) / seconds,
+			})
+		}
+	}
+	return response, nil
+}
+
+// getVariantMetric serves the variant of the metric from the metric's recent value, querying the
+// metric when it wasn't queried recently
+func (p *AzureProvider) getVariantMetric(namespace string, metricSelector labels.Selector, info provider.ExternalMetricInfo, metricName string, variant externalmetrics.VariantDefinition) (*external_metrics.ExternalMetricValueList, error) {
+	key := fmt.Sprintf("%s/%s/%s", namespace, metricName, metricSelector.String())
+	if !p.variants.fresh(key) {
+		if _, err := p.getExternalMetric(namespace, metricSelector, provider.ExternalMetricInfo{Metric: metricName}); err != nil {
+			return nil, err
+		}
+	}
+
+	metricValue, err := p.variants.value(key, variant.Rate)
+	if err != nil {
+		return nil, err
+	}
+	if variant.Transform.Enabled() {
+		metricValue, err = transformValue(variant.Transform, metricValue)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	request, _ := p.metricCache.GetAzureExternalMetricRequest(namespace, metricName)
+	return &external_metrics.ExternalMetricValueList{
+		Items: externalMetricValues(info.Metric, metricValue, request.UseUnits, metricSelector, true),
+	}, nil
+}
@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/transform"
+	k8sprovider "github.com/kubernetes-incubator/custom-metrics-apiserver/pkg/provider"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestVariantsServedFromOneQuery(t *testing.T) {
+	now := time.Now()
+	externalClient := newFakeExternalMetricClient(externalmetrics.AzureExternalMetricResponse{Total: 5}, nil)
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.azureClientFactory = newFakeClientFactory(externalClient)
+	provider.variants = newVariantValues()
+	provider.variants.now = func() time.Time { return now }
+	provider.metricCache.Update("ExternalMetric/default/queue-depth", externalmetrics.AzureExternalMetricRequest{
+		MetricName: "Messages",
+		Variants: []externalmetrics.VariantDefinition{
+			{Suffix: "-rate", Rate: true},
+			{Suffix: "-thousands", Transform: transform.Transform{Multiplier: "0.001"}},
+		},
+	})
+
+	selector := labels.Everything()
+	if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue-depth"}); err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	values, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue-depth-thousands"})
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if len(values.Items) != 1 || values.Items[0].MetricName != "queue-depth-thousands" || values.Items[0].Value.MilliValue() != 5 {
+		t.Errorf("values = %+v, want 0.005", values.Items)
+	}
+	if _, err := provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue-depth-rate"}); err == nil {
+		t.Errorf("error = nil for a rate with one value, want error")
+	}
+	if calls := externalClient.GetAzureMetricCallCount(); calls != 1 {
+		t.Errorf("queries = %d, want the variants served from one query", calls)
+	}
+
+	// once the value is stale the rate variant queries the metric again
+	now = now.Add(time.Minute)
+	externalClient.GetAzureMetricReturns(externalmetrics.AzureExternalMetricResponse{Total: 35}, nil)
+	values, err = provider.GetExternalMetric("default", selector, k8sprovider.ExternalMetricInfo{Metric: "queue-depth-rate"})
+	if err != nil {
+		t.Fatalf("error after processing got: %v, want nil", err)
+	}
+	if len(values.Items) != 1 || values.Items[0].Value.MilliValue() != 500 {
+		t.Errorf("values = %+v, want the rate 0.5", values.Items)
+	}
+	if calls := externalClient.GetAzureMetricCallCount(); calls != 2 {
+		t.Errorf("queries = %d, want 2", calls)
+	}
+}
+
+func TestVariantsAreListed(t *testing.T) {
+	provider := newProvider(fakeAzureExternalClientFactory{})
+	provider.metricCache.Update("ExternalMetric/default/queue-depth", externalmetrics.AzureExternalMetricRequest{
+		Variants: []externalmetrics.VariantDefinition{{Suffix: "-rate", Rate: true}},
+	})
+
+	metrics := provider.ListAllExternalMetrics()
+	if len(metrics) != 2 || metrics[0].Metric != "queue-depth" || metrics[1].Metric != "queue-depth-rate" {
+		t.Errorf("metrics = %v, want queue-depth and queue-depth-rate", metrics)
+	}
+}
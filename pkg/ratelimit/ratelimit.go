@@ -0,0 +1,157 @@
+// Package ratelimit limits the requests each client can make to the metrics apis
+// so ad-hoc users or misbehaving controllers can not starve the autoscaler
+package ratelimit
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"golang.org/x/time/rate"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+const (
+	customMetricsGroup   = "custom.metrics.k8s.io"
+	externalMetricsGroup = "external.metrics.k8s.io"
+
+	// idleTimeout is how long the bucket of a user is kept after their last request.  A bucket
+	// that has been idle this long is full again, so removing it doesn't change the limit.
+	idleTimeout = 10 * time.Minute
+)
+
+// DefaultPriorityUsers are the identities used by the horizontal pod autoscaler.  The controller
+// manager uses its own identity unless it is configured to use service account credentials.
+var DefaultPriorityUsers = []string{
+	"system:serviceaccount:kube-system:horizontal-pod-autoscaler",
+	"system:kube-controller-manager",
+}
+
+// Limit is the rate and burst allowed for a single client. A qps of zero or less is unlimited.
+type Limit struct {
+	QPS   float64
+	Burst int
+}
+
+func (l Limit) unlimited() bool {
+	return l.QPS <= 0
+}
+
+// Limiter tracks a token bucket per requesting user.  Priority users, such as the
+// horizontal pod autoscaler, get their own limit so other clients can't use up their quota.
+type Limiter struct {
+	limit         Limit
+	priorityLimit Limit
+	priorityUsers map[string]bool
+
+	now       func() time.Time
+	mutex     sync.Mutex
+	limiters  map[string]*userLimiter
+	lastSweep time.Time
+}
+
+type userLimiter struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+// NewLimiter creates a Limiter that applies priorityLimit to priorityUsers and limit to everyone else
+func NewLimiter(limit Limit, priorityLimit Limit, priorityUsers []string) *Limiter {
+	users := make(map[string]bool)
+	for _, user := range priorityUsers {
+		users[user] = true
+	}
+
+	return &Limiter{
+		limit:         limit,
+		priorityLimit: priorityLimit,
+		priorityUsers: users,
+		now:           time.Now,
+		limiters:      make(map[string]*userLimiter),
+	}
+}
+
+// Allow reports whether the user can make a request now
+func (l *Limiter) Allow(user string) bool {
+	limit := l.limitFor(user)
+	if limit.unlimited() {
+		return true
+	}
+
+	l.mutex.Lock()
+	now := l.now()
+	l.sweep(now)
+	limiter, ok := l.limiters[user]
+	if !ok {
+		limiter = &userLimiter{limiter: rate.NewLimiter(rate.Limit(limit.QPS), limit.Burst)}
+		l.limiters[user] = limiter
+	}
+	limiter.lastUsed = now
+	l.mutex.Unlock()
+
+	return limiter.limiter.AllowN(now, 1)
+}
+
+// sweep removes the buckets of users that have been idle for the idle timeout, at most once per
+// timeout, so clients with short lived identities don't grow the limiters forever.  It must be
+// called with the mutex held.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < idleTimeout {
+		return
+	}
+	l.lastSweep = now
+
+	for user, limiter := range l.limiters {
+		if now.Sub(limiter.lastUsed) >= idleTimeout {
+			delete(l.limiters, user)
+		}
+	}
+}
+
+func (l *Limiter) limitFor(user string) Limit {
+	if l.priorityUsers[user] {
+		return l.priorityLimit
+	}
+	return l.limit
+}
+
+// WithRateLimit rejects requests to the custom and external metrics apis once the requesting
+// user is over their limit.  It must run after authentication and request info have been added to the context.
+func WithRateLimit(handler http.Handler, limiter *Limiter) http.Handler {
+	if limiter == nil {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ctx := req.Context()
+
+		info, ok := request.RequestInfoFrom(ctx)
+		if !ok || !isMetricsRequest(info) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+
+		name := ""
+		if user, ok := request.UserFrom(ctx); ok {
+			name = user.GetName()
+		}
+
+		if !limiter.Allow(name) {
+			glog.V(2).Infof("rate limited request from %q to %s", name, req.URL.Path)
+			tooManyRequests(w)
+			return
+		}
+
+		handler.ServeHTTP(w, req)
+	})
+}
+
+func isMetricsRequest(info *request.RequestInfo) bool {
+	return info.IsResourceRequest && (info.APIGroup == customMetricsGroup || info.APIGroup == externalMetricsGroup)
+}
+
+func tooManyRequests(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "1")
+	http.Error(w, "Too many requests, please try again later.", http.StatusTooManyRequests)
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+const hpaUser = "system:serviceaccount:kube-system:horizontal-pod-autoscaler"
+
+func TestLimiterLimitsEachUserSeparately(t *testing.T) {
+	limiter := NewLimiter(Limit{QPS: 0.001, Burst: 1}, Limit{}, nil)
+
+	if !limiter.Allow("alice") {
+		t.Errorf("first request from alice was limited")
+	}
+	if limiter.Allow("alice") {
+		t.Errorf("second request from alice was allowed")
+	}
+	if !limiter.Allow("bob") {
+		t.Errorf("bob was limited by requests from alice")
+	}
+}
+
+func TestLimiterUsesPriorityLimitForPriorityUsers(t *testing.T) {
+	limiter := NewLimiter(Limit{QPS: 0.001, Burst: 1}, Limit{}, DefaultPriorityUsers)
+
+	for i := 0; i < 10; i++ {
+		if !limiter.Allow(hpaUser) {
+			t.Fatalf("request %d from the autoscaler was limited", i)
+		}
+	}
+}
+
+func TestLimiterRemovesIdleUsers(t *testing.T) {
+	now := time.Now()
+	limiter := NewLimiter(Limit{QPS: 0.001, Burst: 1}, Limit{}, nil)
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow("alice")
+	limiter.Allow("bob")
+	now = now.Add(idleTimeout / 2)
+	limiter.Allow("bob")
+	now = now.Add(idleTimeout / 2)
+	limiter.Allow("carol")
+
+	if _, found := limiter.limiters["alice"]; found {
+		t.Errorf("limiters = %v, want alice removed after the idle timeout", limiter.limiters)
+	}
+	if _, found := limiter.limiters["bob"]; !found {
+		t.Errorf("limiters = %v, want bob kept", limiter.limiters)
+	}
+}
+
+func TestWithRateLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		info     *request.RequestInfo
+		wantCode int
+	}{
+		{
+			name:     "external metrics request over limit",
+			user:     "alice",
+			info:     &request.RequestInfo{IsResourceRequest: true, APIGroup: externalMetricsGroup},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name:     "custom metrics request over limit",
+			user:     "alice",
+			info:     &request.RequestInfo{IsResourceRequest: true, APIGroup: customMetricsGroup},
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name:     "priority user is not limited",
+			user:     hpaUser,
+			info:     &request.RequestInfo{IsResourceRequest: true, APIGroup: externalMetricsGroup},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "discovery request is not limited",
+			user:     "alice",
+			info:     &request.RequestInfo{IsResourceRequest: false, Path: "/apis"},
+			wantCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := NewLimiter(Limit{QPS: 0.001, Burst: 1}, Limit{}, DefaultPriorityUsers)
+			handler := WithRateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}), limiter)
+
+			var code int
+			for i := 0; i < 2; i++ {
+				req := httptest.NewRequest("GET", "/apis/external.metrics.k8s.io/v1beta1/namespaces/default/queuelength", nil)
+				ctx := request.WithRequestInfo(req.Context(), tt.info)
+				ctx = request.WithUser(ctx, &user.DefaultInfo{Name: tt.user})
+
+				recorder := httptest.NewRecorder()
+				handler.ServeHTTP(recorder, req.WithContext(ctx))
+				code = recorder.Code
+			}
+
+			if code != tt.wantCode {
+				t.Errorf("status = %v, want %v", code, tt.wantCode)
+			}
+		})
+	}
+}
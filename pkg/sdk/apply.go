@@ -0,0 +1,54 @@
+package sdk
+
+import (
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned"
+	"k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ApplyExternalMetric validates the ExternalMetric and creates it, or replaces the spec, labels
+// and annotations of the existing one
+func ApplyExternalMetric(client versioned.Interface, metric *api.ExternalMetric) (*api.ExternalMetric, error) {
+	if err := ValidateExternalMetric(metric); err != nil {
+		return nil, err
+	}
+
+	externalMetrics := client.AzureV1alpha2().ExternalMetrics(metric.Namespace)
+	existing, err := externalMetrics.Get(metric.Name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return externalMetrics.Create(metric)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	updated := existing.DeepCopy()
+	updated.Labels = metric.Labels
+	updated.Annotations = metric.Annotations
+	updated.Spec = metric.Spec
+	return externalMetrics.Update(updated)
+}
+
+// ApplyCustomMetric validates the CustomMetric and creates it, or replaces the spec, labels
+// and annotations of the existing one
+func ApplyCustomMetric(client versioned.Interface, metric *api.CustomMetric) (*api.CustomMetric, error) {
+	if err := ValidateCustomMetric(metric); err != nil {
+		return nil, err
+	}
+
+	customMetrics := client.AzureV1alpha2().CustomMetrics(metric.Namespace)
+	existing, err := customMetrics.Get(metric.Name, meta_v1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return customMetrics.Create(metric)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	updated := existing.DeepCopy()
+	updated.Labels = metric.Labels
+	updated.Annotations = metric.Annotations
+	updated.Spec = metric.Spec
+	return customMetrics.Update(updated)
+}
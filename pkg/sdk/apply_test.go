@@ -0,0 +1,65 @@
+package sdk
+
+import (
+	"testing"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/client/clientset/versioned/fake"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyExternalMetricCreatesThenUpdates(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	monitor := Resource{ResourceGroup: "rg", ProviderNamespace: "Microsoft.Servicebus", Type: "namespaces", Name: "sb"}
+
+	metric, err := NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "Total").Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ApplyExternalMetric(client, metric); err != nil {
+		t.Fatalf("unexpected error creating: %v", err)
+	}
+
+	metric.Spec.MetricConfig.Aggregation = "Average"
+	if _, err := ApplyExternalMetric(client, metric); err != nil {
+		t.Fatalf("unexpected error updating: %v", err)
+	}
+
+	stored, err := client.AzureV1alpha2().ExternalMetrics("default").Get("queue", meta_v1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stored.Spec.MetricConfig.Aggregation != "Average" {
+		t.Errorf("aggregation = %v, want %v", stored.Spec.MetricConfig.Aggregation, "Average")
+	}
+}
+
+func TestApplyInvalidExternalMetricGetError(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	metric := &api.ExternalMetric{ObjectMeta: meta_v1.ObjectMeta{Namespace: "default", Name: "queue"}}
+
+	if _, err := ApplyExternalMetric(client, metric); err == nil {
+		t.Errorf("ApplyExternalMetric() got nil, want error")
+	}
+
+	list, _ := client.AzureV1alpha2().ExternalMetrics("default").List(meta_v1.ListOptions{})
+	if len(list.Items) != 0 {
+		t.Errorf("stored %d metrics, want none", len(list.Items))
+	}
+}
+
+func TestApplyCustomMetricCreates(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	metric, err := NewCustomMetric("default", "rps", "performanceCounters/requestsPerSecond", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := ApplyCustomMetric(client, metric); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := client.AzureV1alpha2().CustomMetrics("default").Get("rps", meta_v1.GetOptions{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
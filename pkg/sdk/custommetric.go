@@ -0,0 +1,44 @@
+package sdk
+
+import (
+	"fmt"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// NewCustomMetric returns a CustomMetric serving the Application Insights metric.  An empty
+// application id uses the application of the adapter.
+func NewCustomMetric(namespace string, name string, metricName string, applicationID string) (*api.CustomMetric, error) {
+	metric := &api.CustomMetric{
+		TypeMeta:   meta_v1.TypeMeta{APIVersion: api.SchemeGroupVersion.String(), Kind: "CustomMetric"},
+		ObjectMeta: meta_v1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: api.CustomMetricSpec{
+			MetricConfig: api.CustomMetricConfig{
+				MetricName:    metricName,
+				ApplicationID: applicationID,
+			},
+		},
+	}
+
+	if err := ValidateCustomMetric(metric); err != nil {
+		return nil, err
+	}
+	return metric, nil
+}
+
+// ValidateCustomMetric returns an error if the CustomMetric has no name, namespace or metric name
+func ValidateCustomMetric(metric *api.CustomMetric) error {
+	if errs := validation.IsDNS1123Subdomain(metric.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid name '%s': %v", metric.Name, errs)
+	}
+	if errs := validation.IsDNS1123Label(metric.Namespace); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace '%s': %v", metric.Namespace, errs)
+	}
+	if metric.Spec.MetricConfig.MetricName == "" {
+		return fmt.Errorf("metric.metricName is required")
+	}
+
+	return nil
+}
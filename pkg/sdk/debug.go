@@ -0,0 +1,81 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/provider"
+)
+
+// RawResponse is the latest query of an external metric served by the debug endpoint of the adapter
+type RawResponse struct {
+	Namespace string    `json:"namespace"`
+	Metric    string    `json:"metric"`
+	Selector  string    `json:"selector,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// Value is the value served before units are applied
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit,omitempty"`
+	Series []struct {
+		Labels map[string]string `json:"labels"`
+		Value  float64           `json:"value"`
+	} `json:"series,omitempty"`
+	// Responses are the bodies of the Azure responses the value was derived from
+	Responses []string `json:"responses"`
+}
+
+// DebugClient queries the debug endpoints of the adapter
+type DebugClient struct {
+	baseURL string
+	token   string
+	client  *http.Client
+}
+
+// NewDebugClient creates a client of the adapter at the base url, such as a forwarded port, that
+// authenticates with the bearer token.  The http client must trust the serving certificate of
+// the adapter.
+func NewDebugClient(baseURL string, token string, client *http.Client) *DebugClient {
+	return &DebugClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		client:  client,
+	}
+}
+
+// RawResponse returns the latest Azure responses of the external metric and the value the
+// adapter derived from them
+func (c *DebugClient) RawResponse(namespace string, name string) (*RawResponse, error) {
+	endpoint := fmt.Sprintf("%s%s%s/%s", c.baseURL, provider.RawResponsePath, url.PathEscape(namespace), url.PathEscape(name))
+	req, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read raw response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("raw response of %s in namespace %s returned status %d: %s", name, namespace, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	response := &RawResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, fmt.Errorf("unable to parse raw response: %v", err)
+	}
+	return response, nil
+}
@@ -0,0 +1,45 @@
+package sdk
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDebugClientRawResponse(t *testing.T) {
+	path, authorization := "", ""
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, authorization = r.URL.Path, r.Header.Get("Authorization")
+		fmt.Fprint(w, `{"namespace":"default","metric":"queue","value":7,"responses":["{\"value\":[]}"]}`)
+	}))
+	defer server.Close()
+
+	response, err := NewDebugClient(server.URL, "token", server.Client()).RawResponse("default", "queue")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/debug/externalmetrics/default/queue" {
+		t.Errorf("path = %v, want %v", path, "/debug/externalmetrics/default/queue")
+	}
+	if authorization != "Bearer token" {
+		t.Errorf("authorization = %v, want %v", authorization, "Bearer token")
+	}
+	if response.Value != 7 || len(response.Responses) != 1 {
+		t.Errorf("response = %+v, want value 7 and one response", response)
+	}
+}
+
+func TestDebugClientNotFoundGetError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "metric queue has not been queried in namespace default", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	_, err := NewDebugClient(server.URL, "", server.Client()).RawResponse("default", "queue")
+
+	if err == nil {
+		t.Errorf("RawResponse() got nil, want error")
+	}
+}
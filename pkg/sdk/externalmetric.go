@@ -0,0 +1,619 @@
+// Package sdk builds, validates and applies ExternalMetric and CustomMetric resources and
+// queries the debug endpoints of the adapter, for platforms that manage metrics programmatically
+package sdk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/controller"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/expression"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Resource identifies the Azure resource an Azure Monitor metric is read from
+type Resource struct {
+	SubscriptionID    string
+	ResourceGroup     string
+	ProviderNamespace string
+	Type              string
+	Name              string
+}
+
+// ExternalMetricBuilder builds an ExternalMetric.  Methods set parts of the spec and Build
+// validates the result.
+type ExternalMetricBuilder struct {
+	metric *api.ExternalMetric
+}
+
+// NewExternalMetric starts an ExternalMetric with the name in the namespace
+func NewExternalMetric(namespace string, name string) *ExternalMetricBuilder {
+	return &ExternalMetricBuilder{
+		metric: &api.ExternalMetric{
+			TypeMeta:   meta_v1.TypeMeta{APIVersion: api.SchemeGroupVersion.String(), Kind: "ExternalMetric"},
+			ObjectMeta: meta_v1.ObjectMeta{Namespace: namespace, Name: name},
+		},
+	}
+}
+
+// Labels adds labels to the ExternalMetric
+func (b *ExternalMetricBuilder) Labels(labels map[string]string) *ExternalMetricBuilder {
+	if b.metric.Labels == nil {
+		b.metric.Labels = map[string]string{}
+	}
+	for k, v := range labels {
+		b.metric.Labels[k] = v
+	}
+	return b
+}
+
+// AzureMonitor reads the metric of the resource from Azure Monitor
+func (b *ExternalMetricBuilder) AzureMonitor(resource Resource) *ExternalMetricBuilder {
+	b.metric.Spec.Type = externalmetrics.Monitor
+	b.metric.Spec.AzureConfig.SubscriptionID = resource.SubscriptionID
+	b.metric.Spec.AzureConfig.ResourceGroup = resource.ResourceGroup
+	b.metric.Spec.AzureConfig.ResourceProviderNamespace = resource.ProviderNamespace
+	b.metric.Spec.AzureConfig.ResourceType = resource.Type
+	b.metric.Spec.AzureConfig.ResourceName = resource.Name
+	return b
+}
+
+// ServiceBusSubscription serves the active message count of a Service Bus topic subscription
+func (b *ExternalMetricBuilder) ServiceBusSubscription(resourceGroup string, namespace string, topic string, subscription string) *ExternalMetricBuilder {
+	b.metric.Spec.Type = externalmetrics.ServiceBusSubscription
+	b.metric.Spec.AzureConfig.ResourceGroup = resourceGroup
+	b.metric.Spec.AzureConfig.ServiceBusNamespace = namespace
+	b.metric.Spec.AzureConfig.ServiceBusTopic = topic
+	b.metric.Spec.AzureConfig.ServiceBusSubscription = subscription
+	if b.metric.Spec.MetricConfig.MetricName == "" {
+		b.metric.Spec.MetricConfig.MetricName = "activeMessageCount"
+	}
+	return b
+}
+
+// Subscriptions queries the metric in each subscription and aggregates the values with sum,
+// average, minimum or maximum
+func (b *ExternalMetricBuilder) Subscriptions(aggregation string, subscriptions ...string) *ExternalMetricBuilder {
+	b.metric.Spec.AzureConfig.Subscriptions = subscriptions
+	b.metric.Spec.AzureConfig.SubscriptionAggregation = aggregation
+	return b
+}
+
+// Resources queries the metric on each resource, of the type of the Azure Monitor resource, and
+// aggregates the values with sum, average, minimum or maximum
+func (b *ExternalMetricBuilder) Resources(aggregation string, resources ...api.AzureResource) *ExternalMetricBuilder {
+	b.metric.Spec.AzureConfig.Resources = resources
+	b.metric.Spec.AzureConfig.ResourceAggregation = aggregation
+	return b
+}
+
+// ResourceTags queries the metric on each resource, of the type of the Azure Monitor resource, with
+// all of the tags and aggregates the values with sum, average, minimum or maximum
+func (b *ExternalMetricBuilder) ResourceTags(aggregation string, tags map[string]string) *ExternalMetricBuilder {
+	b.metric.Spec.AzureConfig.ResourceTags = tags
+	b.metric.Spec.AzureConfig.ResourceAggregation = aggregation
+	return b
+}
+
+// ResourceGraphQuery queries the metric on each resource, of the type of the Azure Monitor resource,
+// whose id is returned by the Resource Graph query and aggregates the values with sum, average,
+// minimum or maximum
+func (b *ExternalMetricBuilder) ResourceGraphQuery(aggregation string, query string) *ExternalMetricBuilder {
+	b.metric.Spec.AzureConfig.ResourceGraphQuery = query
+	b.metric.Spec.AzureConfig.ResourceAggregation = aggregation
+	return b
+}
+
+// Metric sets the Azure Monitor metric name and aggregation
+func (b *ExternalMetricBuilder) Metric(name string, aggregation string) *ExternalMetricBuilder {
+	b.metric.Spec.MetricConfig.MetricName = name
+	b.metric.Spec.MetricConfig.Aggregation = aggregation
+	return b
+}
+
+// MetricNamespace sets the Azure Monitor namespace of custom metrics, such as azure.vm.linux.guestmetrics
+func (b *ExternalMetricBuilder) MetricNamespace(namespace string) *ExternalMetricBuilder {
+	b.metric.Spec.MetricConfig.MetricNamespace = namespace
+	return b
+}
+
+// Window sets the timespan of the Azure Monitor query and the interval the values are aggregated
+// over, the default of either is kept when it is zero
+func (b *ExternalMetricBuilder) Window(timespan time.Duration, interval time.Duration) *ExternalMetricBuilder {
+	if timespan != 0 {
+		b.metric.Spec.MetricConfig.Timespan = timespan.String()
+	}
+	if interval != 0 {
+		b.metric.Spec.MetricConfig.Interval = interval.String()
+	}
+	return b
+}
+
+// Rate serves the per second rate of a cumulative counter instead of its value
+func (b *ExternalMetricBuilder) Rate() *ExternalMetricBuilder {
+	b.metric.Spec.MetricConfig.Rate = true
+	return b
+}
+
+// Filter sets the Azure Monitor filter, such as EntityName eq 'orders'
+func (b *ExternalMetricBuilder) Filter(filter string) *ExternalMetricBuilder {
+	b.metric.Spec.MetricConfig.Filter = filter
+	return b
+}
+
+// Split serves the series of the top values of the dimension as separate items
+func (b *ExternalMetricBuilder) Split(dimension string, top int32) *ExternalMetricBuilder {
+	b.metric.Spec.MetricConfig.SplitDimension = dimension
+	b.metric.Spec.MetricConfig.Top = top
+	return b
+}
+
+// Timeout sets the response budget of a query
+func (b *ExternalMetricBuilder) Timeout(timeout time.Duration) *ExternalMetricBuilder {
+	b.metric.Spec.Timeout = timeout.String()
+	return b
+}
+
+// NoData sets the value served when Azure Monitor has no values for the metric, the value is only
+// served by the fixed policy
+func (b *ExternalMetricBuilder) NoData(policy string, value int64) *ExternalMetricBuilder {
+	b.metric.Spec.NoDataPolicy = policy
+	b.metric.Spec.NoDataValue = value
+	return b
+}
+
+// Transform converts the served value to a unit such as MiB or s, multiplies it and adds the offset.
+// The multiplier and offset are decimals, and are left unset when empty
+func (b *ExternalMetricBuilder) Transform(multiplier string, offset string, unit string) *ExternalMetricBuilder {
+	b.metric.Spec.Transform = &api.TransformConfig{Multiplier: multiplier, Offset: offset, Unit: unit}
+	return b
+}
+
+// Spec changes any other part of the spec
+func (b *ExternalMetricBuilder) Spec(change func(spec *api.ExternalMetricSpec)) *ExternalMetricBuilder {
+	change(&b.metric.Spec)
+	return b
+}
+
+// Build returns the ExternalMetric, or an error if it isn't valid
+func (b *ExternalMetricBuilder) Build() (*api.ExternalMetric, error) {
+	metric := b.metric.DeepCopy()
+	if err := ValidateExternalMetric(metric); err != nil {
+		return nil, err
+	}
+	return metric, nil
+}
+
+// externalMetricTypes are the types an ExternalMetric can have
+var externalMetricTypes = map[string]bool{
+	externalmetrics.Monitor:                true,
+	externalmetrics.ServiceBusSubscription: true,
+	externalmetrics.ServiceBusQueue:        true,
+	externalmetrics.Schedule:               true,
+	externalmetrics.Predictive:             true,
+	externalmetrics.SLOBurnRate:            true,
+	externalmetrics.Plugin:                 true,
+	externalmetrics.Webhook:                true,
+	externalmetrics.StorageQueueMessageAge: true,
+	externalmetrics.StorageQueueLength:     true,
+	externalmetrics.EventHub:               true,
+	externalmetrics.FileShare:              true,
+	externalmetrics.ActivityLog:            true,
+	externalmetrics.ContainerApp:           true,
+	externalmetrics.LogicApp:               true,
+	externalmetrics.LogAnalytics:           true,
+	externalmetrics.DataExplorer:           true,
+	externalmetrics.CosmosDB:               true,
+	externalmetrics.Prometheus:             true,
+	externalmetrics.IoTHub:                 true,
+	externalmetrics.Batch:                  true,
+	externalmetrics.BlobCount:              true,
+	externalmetrics.EventGrid:              true,
+	externalmetrics.Redis:                  true,
+	externalmetrics.AzureDevOps:            true,
+	externalmetrics.Cost:                   true,
+	externalmetrics.Quota:                  true,
+	externalmetrics.Combined:               true,
+	externalmetrics.Ratio:                  true,
+	externalmetrics.Seasonal:               true,
+	externalmetrics.Heartbeat:              true,
+}
+
+// ValidateExternalMetric returns an error if the ExternalMetric is missing settings its type
+// requires or has settings the adapter can't parse.  Settings only checked when the metric is
+// requested, such as the windows of a schedule, are left to the adapter.
+func ValidateExternalMetric(metric *api.ExternalMetric) error {
+	if errs := validation.IsDNS1123Subdomain(metric.Name); len(errs) > 0 {
+		return fmt.Errorf("invalid name '%s': %v", metric.Name, errs)
+	}
+	if errs := validation.IsDNS1123Label(metric.Namespace); len(errs) > 0 {
+		return fmt.Errorf("invalid namespace '%s': %v", metric.Namespace, errs)
+	}
+
+	return validateSpec(metric.Spec, false)
+}
+
+func validateSpec(spec api.ExternalMetricSpec, source bool) error {
+	if !externalMetricTypes[spec.Type] {
+		return fmt.Errorf("unknown type '%s'", spec.Type)
+	}
+	request := controller.ExternalMetricRequest(spec)
+	if request.Credential != "" && request.CredentialSecret != "" {
+		return fmt.Errorf("a metric can reference either a credential or a credential secret")
+	}
+
+	acrossResources := request.AcrossResources()
+	if acrossResources && spec.Type != externalmetrics.Monitor {
+		return fmt.Errorf("only azuremonitor metrics can be aggregated across resources")
+	}
+
+	switch spec.Type {
+	case externalmetrics.Monitor:
+		fields := map[string]string{
+			"metric.metricName":               request.MetricName,
+			"azure.resourceProviderNamespace": request.ResourceProviderNamespace,
+			"azure.resourceType":              request.ResourceType,
+		}
+		if !acrossResources {
+			fields["azure.resourceGroup"] = request.ResourceGroup
+			fields["azure.resourceName"] = request.ResourceName
+		}
+		for i, resource := range request.Resources {
+			fields[fmt.Sprintf("azure.resources[%d].resourceGroup", i)] = resource.ResourceGroup
+			fields[fmt.Sprintf("azure.resources[%d].resourceName", i)] = resource.ResourceName
+		}
+		if err := required(fields); err != nil {
+			return err
+		}
+		if acrossResources && len(request.Subscriptions) > 0 {
+			return fmt.Errorf("a metric can not be aggregated across both subscriptions and resources")
+		}
+		if acrossResources && request.SplitDimension != "" {
+			return fmt.Errorf("a split metric can not be aggregated across resources")
+		}
+		if _, err := externalmetrics.AggregateResources(request.ResourceAggregation, []float64{0}); err != nil {
+			return err
+		}
+	case externalmetrics.ServiceBusSubscription:
+		if err := required(map[string]string{
+			"azure.resourceGroup":          request.ResourceGroup,
+			"azure.serviceBusNamespace":    request.Namespace,
+			"azure.serviceBusTopic":        request.Topic,
+			"azure.serviceBusSubscription": request.Subscription,
+		}); err != nil {
+			return err
+		}
+	case externalmetrics.ServiceBusQueue:
+		if spec.ServiceBusQueue == nil {
+			return fmt.Errorf("a servicebus metric requires a serviceBusQueue section")
+		}
+		if ref := spec.ServiceBusQueue.ConnectionStringRef; ref != nil {
+			if err := required(map[string]string{
+				"serviceBusQueue.connectionStringRef.name": ref.Name,
+				"serviceBusQueue.connectionStringRef.key":  ref.Key,
+			}); err != nil {
+				return err
+			}
+		} else if err := required(map[string]string{
+			"serviceBusQueue.namespace": request.ServiceBusQueue.Namespace,
+			"serviceBusQueue.queue":     request.ServiceBusQueue.Queue,
+		}); err != nil {
+			return err
+		}
+	case externalmetrics.StorageQueueMessageAge, externalmetrics.StorageQueueLength:
+		if spec.StorageQueue == nil {
+			return fmt.Errorf("a %s metric requires a storageQueue section", spec.Type)
+		}
+		fields := map[string]string{"storageQueue.queue": request.StorageQueue.Queue}
+		if ref := spec.StorageQueue.ConnectionStringRef; ref != nil {
+			fields["storageQueue.connectionStringRef.name"] = ref.Name
+			fields["storageQueue.connectionStringRef.key"] = ref.Key
+		} else {
+			fields["storageQueue.account"] = request.StorageQueue.Account
+		}
+		if err := required(fields); err != nil {
+			return err
+		}
+	case externalmetrics.EventHub:
+		if spec.EventHub == nil {
+			return fmt.Errorf("an eventhub metric requires an eventHub section")
+		}
+		fields := map[string]string{"eventHub.checkpointStore.container": request.EventHub.Container}
+		if ref := spec.EventHub.ConnectionStringRef; ref != nil {
+			fields["eventHub.connectionStringRef.name"] = ref.Name
+			fields["eventHub.connectionStringRef.key"] = ref.Key
+		} else {
+			fields["eventHub.namespace"] = request.EventHub.Namespace
+			fields["eventHub.eventHub"] = request.EventHub.EventHub
+		}
+		if ref := spec.EventHub.CheckpointStore.ConnectionStringRef; ref != nil {
+			fields["eventHub.checkpointStore.connectionStringRef.name"] = ref.Name
+			fields["eventHub.checkpointStore.connectionStringRef.key"] = ref.Key
+		} else {
+			fields["eventHub.checkpointStore.account"] = request.EventHub.StorageAccount
+		}
+		if err := required(fields); err != nil {
+			return err
+		}
+		if request.EventHub.LagPerReplica < 0 {
+			return fmt.Errorf("eventHub.lagPerReplica must not be negative")
+		}
+	case externalmetrics.LogAnalytics:
+		if spec.LogAnalytics == nil {
+			return fmt.Errorf("a loganalytics metric requires a logAnalytics section")
+		}
+		if err := required(map[string]string{
+			"logAnalytics.workspace": request.LogAnalytics.Workspace,
+			"logAnalytics.query":     request.LogAnalytics.Query,
+		}); err != nil {
+			return err
+		}
+	case externalmetrics.DataExplorer:
+		if spec.DataExplorer == nil {
+			return fmt.Errorf("a dataexplorer metric requires a dataExplorer section")
+		}
+		if err := required(map[string]string{
+			"dataExplorer.cluster":  request.DataExplorer.Cluster,
+			"dataExplorer.database": request.DataExplorer.Database,
+			"dataExplorer.query":    request.DataExplorer.Query,
+		}); err != nil {
+			return err
+		}
+	case externalmetrics.CosmosDB:
+		if spec.CosmosDB == nil {
+			return fmt.Errorf("a cosmosdb metric requires a cosmosDB section")
+		}
+		fields := map[string]string{
+			"cosmosDB.database": request.CosmosDB.Database,
+			"cosmosDB.metric":   request.CosmosDB.Metric,
+		}
+		if ref := spec.CosmosDB.ConnectionStringRef; ref != nil {
+			fields["cosmosDB.connectionStringRef.name"] = ref.Name
+			fields["cosmosDB.connectionStringRef.key"] = ref.Key
+		} else {
+			fields["cosmosDB.account"] = request.CosmosDB.Account
+		}
+		if strings.ToLower(request.CosmosDB.Metric) == externalmetrics.CosmosDBChangeFeedLag {
+			fields["cosmosDB.container"] = request.CosmosDB.Container
+		}
+		if err := required(fields); err != nil {
+			return err
+		}
+	case externalmetrics.Prometheus:
+		if spec.Prometheus == nil {
+			return fmt.Errorf("a prometheus metric requires a prometheus section")
+		}
+		if err := required(map[string]string{
+			"prometheus.endpoint": request.Prometheus.Endpoint,
+			"prometheus.query":    request.Prometheus.Query,
+		}); err != nil {
+			return err
+		}
+	case externalmetrics.IoTHub:
+		if spec.IoTHub == nil {
+			return fmt.Errorf("an iothub metric requires an iotHub section")
+		}
+		fields := map[string]string{
+			"iotHub.connectionStringRef.name":  request.IoTHub.ConnectionStringSecret,
+			"iotHub.connectionStringRef.key":   request.IoTHub.ConnectionStringKey,
+			"iotHub.checkpointStore.container": request.IoTHub.Container,
+		}
+		if ref := spec.IoTHub.CheckpointStore.ConnectionStringRef; ref != nil {
+			fields["iotHub.checkpointStore.connectionStringRef.name"] = ref.Name
+			fields["iotHub.checkpointStore.connectionStringRef.key"] = ref.Key
+		} else {
+			fields["iotHub.checkpointStore.account"] = request.IoTHub.StorageAccount
+		}
+		if err := required(fields); err != nil {
+			return err
+		}
+	case externalmetrics.Batch:
+		if spec.Batch == nil {
+			return fmt.Errorf("a batch metric requires a batch section")
+		}
+		if err := required(map[string]string{"batch.endpoint": request.Batch.Endpoint}); err != nil {
+			return err
+		}
+		if (request.Batch.Job == "") == (request.Batch.Pool == "") {
+			return fmt.Errorf("a batch metric requires either batch.job or batch.pool")
+		}
+	case externalmetrics.BlobCount:
+		if spec.BlobCount == nil {
+			return fmt.Errorf("a blobcount metric requires a blobCount section")
+		}
+		fields := map[string]string{"blobCount.container": request.BlobCount.Container}
+		if ref := spec.BlobCount.ConnectionStringRef; ref != nil {
+			fields["blobCount.connectionStringRef.name"] = ref.Name
+			fields["blobCount.connectionStringRef.key"] = ref.Key
+		} else {
+			fields["blobCount.account"] = request.BlobCount.Account
+		}
+		if err := required(fields); err != nil {
+			return err
+		}
+	case externalmetrics.Redis:
+		if spec.Redis == nil {
+			return fmt.Errorf("a redis metric requires a redis section")
+		}
+		fields := map[string]string{
+			"redis.host": request.Redis.Host,
+			"redis.key":  request.Redis.Key,
+		}
+		if ref := spec.Redis.AccessKeyRef; ref != nil {
+			fields["redis.accessKeyRef.name"] = ref.Name
+			fields["redis.accessKeyRef.key"] = ref.Key
+		}
+		if err := required(fields); err != nil {
+			return err
+		}
+	case externalmetrics.AzureDevOps:
+		if spec.AzureDevOps == nil {
+			return fmt.Errorf("an azuredevops metric requires an azureDevOps section")
+		}
+		fields := map[string]string{"azureDevOps.organizationURL": request.AzureDevOps.OrganizationURL}
+		if ref := spec.AzureDevOps.PersonalAccessTokenRef; ref != nil {
+			fields["azureDevOps.personalAccessTokenRef.name"] = ref.Name
+			fields["azureDevOps.personalAccessTokenRef.key"] = ref.Key
+		}
+		if err := required(fields); err != nil {
+			return err
+		}
+		if (request.AzureDevOps.PoolID == 0) == (request.AzureDevOps.PoolName == "") {
+			return fmt.Errorf("an azuredevops metric requires either azureDevOps.poolID or azureDevOps.poolName")
+		}
+	case externalmetrics.Cost:
+		if spec.Cost == nil {
+			return fmt.Errorf("a cost metric requires a cost section")
+		}
+		fields := map[string]string{"cost.metric": request.Cost.Metric}
+		if strings.ToLower(request.Cost.Metric) == externalmetrics.CostBudget {
+			fields["cost.budget"] = request.Cost.Budget
+		}
+		if err := required(fields); err != nil {
+			return err
+		}
+	case externalmetrics.Quota:
+		if spec.Quota == nil {
+			return fmt.Errorf("a quota metric requires a quota section")
+		}
+		if err := required(map[string]string{
+			"quota.location": request.Quota.Location,
+			"quota.name":     request.Quota.Name,
+		}); err != nil {
+			return err
+		}
+	case externalmetrics.EventGrid:
+		if source {
+			return fmt.Errorf("the sources of a combined metric can not be pushed by event grid")
+		}
+		if spec.EventGrid == nil {
+			return fmt.Errorf("an eventgrid metric requires an eventGrid section")
+		}
+		if err := required(map[string]string{
+			"eventGrid.keyRef.name": request.EventGrid.KeySecret,
+			"eventGrid.keyRef.key":  request.EventGrid.KeyKey,
+		}); err != nil {
+			return err
+		}
+		if _, err := request.EventGrid.ParseMaxAge(); err != nil {
+			return err
+		}
+	case externalmetrics.Ratio:
+		if spec.Ratio == nil {
+			return fmt.Errorf("a ratio metric requires a ratio section")
+		}
+		if err := required(map[string]string{
+			"metric.metricName":               request.MetricName,
+			"azure.resourceGroup":             request.ResourceGroup,
+			"azure.resourceProviderNamespace": request.ResourceProviderNamespace,
+			"azure.resourceType":              request.ResourceType,
+			"azure.resourceName":              request.ResourceName,
+		}); err != nil {
+			return err
+		}
+		if request.SplitDimension != "" {
+			return fmt.Errorf("a ratio metric can not be split by dimension")
+		}
+	case externalmetrics.Seasonal:
+		if err := required(map[string]string{
+			"metric.metricName":               request.MetricName,
+			"azure.resourceGroup":             request.ResourceGroup,
+			"azure.resourceProviderNamespace": request.ResourceProviderNamespace,
+			"azure.resourceType":              request.ResourceType,
+			"azure.resourceName":              request.ResourceName,
+		}); err != nil {
+			return err
+		}
+		if request.SplitDimension != "" {
+			return fmt.Errorf("a seasonal metric can not be split by dimension")
+		}
+		if err := request.Seasonal.Validate(); err != nil {
+			return err
+		}
+	case externalmetrics.Combined:
+		if source {
+			return fmt.Errorf("the sources of a combined metric can not be combined")
+		}
+		if len(spec.Sources) == 0 {
+			return fmt.Errorf("a combined metric requires sources")
+		}
+		for i, weighted := range spec.Sources {
+			if _, err := request.Sources[i].ParseWeight(); err != nil {
+				return fmt.Errorf("source %d: %v", i, err)
+			}
+			if err := validateSpec(weighted.ExternalMetricSpec, true); err != nil {
+				return fmt.Errorf("source %d: %v", i, err)
+			}
+		}
+	}
+
+	if request.MonitorAPIVersion != "" {
+		if err := externalmetrics.ValidateMonitorAPIVersion(request.MonitorAPIVersion); err != nil {
+			return err
+		}
+	}
+	if err := externalmetrics.ValidateAggregation(request.Aggregation); err != nil {
+		return err
+	}
+	if err := request.NoData.Validate(); err != nil {
+		return err
+	}
+	if err := request.Transform.Validate(); err != nil {
+		return err
+	}
+	if err := externalmetrics.ValidateQueryWindow(request.Window, request.Interval); err != nil {
+		return err
+	}
+	if request.Timeout != "" {
+		if timeout, err := time.ParseDuration(request.Timeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout '%s', must be a positive go duration such as 2s", request.Timeout)
+		}
+	}
+	if request.Alert.RuleID != "" {
+		if err := request.Alert.Validate(); err != nil {
+			return err
+		}
+	}
+	if _, err := request.Maintenance.ActiveAt(time.Now()); err != nil {
+		return err
+	}
+	if request.PerReplica.Enabled && request.PerReplica.Name == "" {
+		return fmt.Errorf("perReplica.name is required")
+	}
+	if spec.Expression != nil {
+		if _, err := expression.Parse(request.Expression.Value); err != nil {
+			return fmt.Errorf("invalid expression: %v", err)
+		}
+		if request.Expression.TimeZone != "" {
+			if _, err := time.LoadLocation(request.Expression.TimeZone); err != nil {
+				return fmt.Errorf("invalid expression time zone '%s'", request.Expression.TimeZone)
+			}
+		}
+		if request.Expression.ScaleTarget.Enabled && request.Expression.ScaleTarget.Name == "" {
+			return fmt.Errorf("expression.scaleTarget.name is required")
+		}
+	}
+
+	return nil
+}
+
+// required returns an error naming the empty fields
+func required(fields map[string]string) error {
+	missing := []string{}
+	for name, value := range fields {
+		if value == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	return fmt.Errorf("%s is required", strings.Join(missing, ", "))
+}
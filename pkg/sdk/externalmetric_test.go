@@ -0,0 +1,192 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	"github.com/Azure/azure-k8s-metrics-adapter/pkg/azure/externalmetrics"
+)
+
+func TestBuildAzureMonitorMetric(t *testing.T) {
+	metric, err := NewExternalMetric("default", "queuemessages").
+		AzureMonitor(Resource{ResourceGroup: "sb-external-example", ProviderNamespace: "Microsoft.Servicebus", Type: "namespaces", Name: "sb-external-ns"}).
+		Metric("Messages", "Total").
+		Filter("EntityName eq 'externalq'").
+		Window(15*time.Minute, 5*time.Minute).
+		Timeout(2 * time.Second).
+		Build()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if metric.Kind != "ExternalMetric" || metric.APIVersion != api.SchemeGroupVersion.String() {
+		t.Errorf("type = %v, want ExternalMetric", metric.TypeMeta)
+	}
+	if metric.Spec.Type != externalmetrics.Monitor || metric.Spec.AzureConfig.ResourceName != "sb-external-ns" || metric.Spec.MetricConfig.Filter != "EntityName eq 'externalq'" {
+		t.Errorf("spec = %+v, want azure monitor metric of sb-external-ns", metric.Spec)
+	}
+	if metric.Spec.MetricConfig.Timespan != "15m0s" || metric.Spec.MetricConfig.Interval != "5m0s" {
+		t.Errorf("window = %v/%v, want 15m0s/5m0s", metric.Spec.MetricConfig.Timespan, metric.Spec.MetricConfig.Interval)
+	}
+	if metric.Spec.Timeout != "2s" {
+		t.Errorf("timeout = %v, want %v", metric.Spec.Timeout, "2s")
+	}
+}
+
+func TestBuildMetricAcrossTaggedResources(t *testing.T) {
+	metric, err := NewExternalMetric("default", "orders").
+		AzureMonitor(Resource{ProviderNamespace: "Microsoft.ServiceBus", Type: "namespaces"}).
+		Metric("ActiveMessages", "Total").
+		Filter("EntityName eq 'orders'").
+		ResourceTags(externalmetrics.SubscriptionMaximum, map[string]string{"workload": "orders"}).
+		Build()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if metric.Spec.AzureConfig.ResourceTags["workload"] != "orders" || metric.Spec.AzureConfig.ResourceAggregation != "maximum" {
+		t.Errorf("azure = %+v, want the resources tagged workload=orders", metric.Spec.AzureConfig)
+	}
+}
+
+func TestBuildCombinedMetric(t *testing.T) {
+	backlog := api.ExternalMetricSpec{Type: externalmetrics.ServiceBusSubscription, AzureConfig: api.AzureConfig{ResourceGroup: "rg", ServiceBusNamespace: "ns", ServiceBusTopic: "topic", ServiceBusSubscription: "sub"}}
+	schedule := api.ExternalMetricSpec{Type: externalmetrics.Schedule}
+
+	_, err := NewExternalMetric("default", "load").
+		Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.Combined
+			spec.Sources = []api.WeightedSource{
+				{Weight: "0.7", ExternalMetricSpec: backlog},
+				{Weight: "0.3", ExternalMetricSpec: schedule},
+			}
+		}).
+		Build()
+
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestBuildInvalidMetricGetError(t *testing.T) {
+	monitor := Resource{ResourceGroup: "rg", ProviderNamespace: "Microsoft.Servicebus", Type: "namespaces", Name: "sb"}
+	var tests = []struct {
+		name    string
+		builder *ExternalMetricBuilder
+	}{
+		{"invalid name", NewExternalMetric("default", "Queue_Messages").AzureMonitor(monitor).Metric("Messages", "Total")},
+		{"no type", NewExternalMetric("default", "queue").Metric("Messages", "Total")},
+		{"no metric name", NewExternalMetric("default", "queue").AzureMonitor(monitor)},
+		{"no resource", NewExternalMetric("default", "queue").AzureMonitor(Resource{}).Metric("Messages", "Total")},
+		{"resource without name", NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "Total").Resources("", api.AzureResource{ResourceGroup: "rg"})},
+		{"resources across subscriptions", NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "Total").Subscriptions("", "1111").ResourceTags("", map[string]string{"workload": "orders"})},
+		{"unknown resource aggregation", NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "Total").ResourceTags("median", map[string]string{"workload": "orders"})},
+		{"no service bus topic", NewExternalMetric("default", "queue").ServiceBusSubscription("rg", "ns", "", "sub")},
+		{"no service bus queue", NewExternalMetric("default", "queue").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.ServiceBusQueue
+			spec.ServiceBusQueue = &api.ServiceBusQueueConfig{Namespace: "orders-sb"}
+		})},
+		{"no storage queue", NewExternalMetric("default", "queue").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.StorageQueueLength
+			spec.StorageQueue = &api.StorageQueueConfig{Queue: "orders"}
+		})},
+		{"no checkpoint container", NewExternalMetric("default", "lag").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.EventHub
+			spec.EventHub = &api.EventHubConfig{Namespace: "orders-eh", EventHub: "orders", CheckpointStore: api.CheckpointStoreConfig{Account: "checkpoints"}}
+		})},
+		{"no log analytics query", NewExternalMetric("default", "errors").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.LogAnalytics
+			spec.LogAnalytics = &api.LogAnalyticsConfig{Workspace: "00000000-1111-2222-3333-444444444444"}
+		})},
+		{"no data explorer database", NewExternalMetric("default", "backlog").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.DataExplorer
+			spec.DataExplorer = &api.DataExplorerConfig{Cluster: "https://ingest.westeurope.kusto.windows.net", Query: "Backlog | count"}
+		})},
+		{"no cosmos db container", NewExternalMetric("default", "lag").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.CosmosDB
+			spec.CosmosDB = &api.CosmosDBConfig{Account: "orders-db", Database: "orders", Metric: "changefeedlag"}
+		})},
+		{"no prometheus query", NewExternalMetric("default", "requests").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.Prometheus
+			spec.Prometheus = &api.PrometheusConfig{Endpoint: "https://orders-ws.westeurope.prometheus.monitor.azure.com"}
+		})},
+		{"no iot hub connection string", NewExternalMetric("default", "backlog").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.IoTHub
+			spec.IoTHub = &api.IoTHubConfig{CheckpointStore: api.CheckpointStoreConfig{Account: "checkpoints", Container: "telemetry"}}
+		})},
+		{"batch job and pool", NewExternalMetric("default", "tasks").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.Batch
+			spec.Batch = &api.BatchConfig{Endpoint: "https://render.westeurope.batch.azure.com", Job: "render-frames", Pool: "gpu"}
+		})},
+		{"no blob container", NewExternalMetric("default", "uploads").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.BlobCount
+			spec.BlobCount = &api.BlobCountConfig{Account: "uploads", Prefix: "incoming/"}
+		})},
+		{"no redis key", NewExternalMetric("default", "jobs").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.Redis
+			spec.Redis = &api.RedisConfig{Host: "jobs.redis.cache.windows.net"}
+		})},
+		{"no azure devops pool", NewExternalMetric("default", "agents").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.AzureDevOps
+			spec.AzureDevOps = &api.AzureDevOpsConfig{OrganizationURL: "https://dev.azure.com/contoso"}
+		})},
+		{"no cost budget", NewExternalMetric("default", "spend").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.Cost
+			spec.Cost = &api.CostConfig{Metric: "budget"}
+		})},
+		{"no quota name", NewExternalMetric("default", "vcpus").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.Quota
+			spec.Quota = &api.QuotaConfig{Location: "westeurope"}
+		})},
+		{"invalid event grid max age", NewExternalMetric("default", "backlog").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.EventGrid
+			spec.EventGrid = &api.EventGridConfig{KeyRef: api.SecretKeyRef{Name: "event-grid", Key: "key"}, MaxAge: "soon"}
+		})},
+		{"credential and credential secret", NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "Total").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Credential, spec.CredentialSecret = "team-a", "team-a-sp"
+		})},
+		{"invalid timeout", NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "Total").Spec(func(spec *api.ExternalMetricSpec) { spec.Timeout = "soon" })},
+		{"invalid aggregation", NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "Median")},
+		{"percentile of monitor metric", NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "P95")},
+		{"invalid no data policy", NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "Total").NoData("previous", 0)},
+		{"invalid transform unit", NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "Total").Transform("", "", "MB")},
+		{"invalid interval", NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "Total").Window(5*time.Minute, 15*time.Minute)},
+		{"invalid api version", NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "Total").Spec(func(spec *api.ExternalMetricSpec) { spec.MetricConfig.MonitorAPIVersion = "latest" })},
+		{"invalid alert", NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "Total").Spec(func(spec *api.ExternalMetricSpec) { spec.Alert = &api.AlertConfig{RuleID: "rule", Action: "hold"} })},
+		{"unnamed per replica target", NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "Total").Spec(func(spec *api.ExternalMetricSpec) { spec.PerReplica = &api.PerReplicaConfig{Kind: "Deployment"} })},
+		{"invalid expression", NewExternalMetric("default", "queue").AzureMonitor(monitor).Metric("Messages", "Total").Spec(func(spec *api.ExternalMetricSpec) { spec.Expression = &api.ExpressionConfig{Value: "value +"} })},
+		{"no ratio section", NewExternalMetric("default", "share").AzureMonitor(monitor).Metric("Requests", "Total").Spec(func(spec *api.ExternalMetricSpec) { spec.Type = externalmetrics.Ratio })},
+		{"invalid seasonal offset", NewExternalMetric("default", "requests").AzureMonitor(monitor).Metric("Requests", "Total").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.Seasonal
+			spec.Seasonal = &api.SeasonalConfig{Offset: "-168h"}
+		})},
+		{"no sources", NewExternalMetric("default", "load").Spec(func(spec *api.ExternalMetricSpec) { spec.Type = externalmetrics.Combined })},
+		{"invalid weight", NewExternalMetric("default", "load").Spec(func(spec *api.ExternalMetricSpec) {
+			spec.Type = externalmetrics.Combined
+			spec.Sources = []api.WeightedSource{{Weight: "most", ExternalMetricSpec: api.ExternalMetricSpec{Type: externalmetrics.Schedule}}}
+		})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.builder.Build(); err == nil {
+				t.Errorf("Build() got nil, want error")
+			}
+		})
+	}
+}
+
+func TestNewCustomMetric(t *testing.T) {
+	metric, err := NewCustomMetric("default", "rps", "performanceCounters/requestsPerSecond", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if metric.Kind != "CustomMetric" || metric.Spec.MetricConfig.MetricName != "performanceCounters/requestsPerSecond" {
+		t.Errorf("metric = %+v, want CustomMetric of performanceCounters/requestsPerSecond", metric)
+	}
+
+	if _, err := NewCustomMetric("default", "rps", "", ""); err == nil {
+		t.Errorf("NewCustomMetric() without a metric name got nil, want error")
+	}
+}
@@ -0,0 +1,115 @@
+// Package transform scales the values served by metrics into the units the targets of autoscalers
+// are written in.  A value is converted from bytes or milliseconds, multiplied and offset.
+package transform
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Units values are converted to
+const (
+	KiB     = "KiB"
+	MiB     = "MiB"
+	GiB     = "GiB"
+	Seconds = "s"
+)
+
+// Azure Monitor units of the values that can be converted
+const (
+	unitBytes          = "Bytes"
+	unitBytesPerSecond = "BytesPerSecond"
+	unitMilliSeconds   = "MilliSeconds"
+)
+
+// Transform converts the unit of a value, multiplies it and adds the offset
+type Transform struct {
+	// Multiplier is a decimal such as "0.001". Defaults to 1
+	Multiplier string
+	// Offset is a decimal added to the multiplied value
+	Offset string
+	// Unit is KiB, MiB or GiB for values in bytes, or s for values in milliseconds
+	Unit string
+}
+
+// Enabled returns whether the transform changes values
+func (t Transform) Enabled() bool {
+	return t.Multiplier != "" || t.Offset != "" || t.Unit != ""
+}
+
+// Validate checks the decimals and unit of the transform
+func (t Transform) Validate() error {
+	_, _, err := t.parse()
+	if err != nil {
+		return err
+	}
+	if _, err := t.divisor(""); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Apply returns the transformed value.  The unit is the Azure Monitor unit of the value when it is
+// known, such as Bytes, otherwise a converted value is taken to be in bytes or milliseconds.
+func (t Transform) Apply(value float64, unit string) (float64, error) {
+	multiplier, offset, err := t.parse()
+	if err != nil {
+		return 0, err
+	}
+	divisor, err := t.divisor(unit)
+	if err != nil {
+		return 0, err
+	}
+	return value/divisor*multiplier + offset, nil
+}
+
+func (t Transform) parse() (float64, float64, error) {
+	multiplier, offset := 1.0, 0.0
+	var err error
+	if t.Multiplier != "" {
+		if multiplier, err = strconv.ParseFloat(t.Multiplier, 64); err != nil || !finite(multiplier) {
+			return 0, 0, fmt.Errorf("invalid transform multiplier '%s'", t.Multiplier)
+		}
+	}
+	if t.Offset != "" {
+		if offset, err = strconv.ParseFloat(t.Offset, 64); err != nil || !finite(offset) {
+			return 0, 0, fmt.Errorf("invalid transform offset '%s'", t.Offset)
+		}
+	}
+	return multiplier, offset, nil
+}
+
+func finite(value float64) bool {
+	return !math.IsNaN(value) && !math.IsInf(value, 0)
+}
+
+// divisor returns what a value in the unit is divided by to convert it to the unit of the transform
+func (t Transform) divisor(unit string) (float64, error) {
+	var divisor float64
+	var from []string
+	switch t.Unit {
+	case "":
+		return 1, nil
+	case KiB:
+		divisor, from = 1<<10, []string{unitBytes, unitBytesPerSecond}
+	case MiB:
+		divisor, from = 1<<20, []string{unitBytes, unitBytesPerSecond}
+	case GiB:
+		divisor, from = 1<<30, []string{unitBytes, unitBytesPerSecond}
+	case Seconds:
+		divisor, from = 1000, []string{unitMilliSeconds}
+	default:
+		return 0, fmt.Errorf("invalid transform unit '%s', must be %s, %s, %s or %s", t.Unit, KiB, MiB, GiB, Seconds)
+	}
+
+	if unit == "" {
+		return divisor, nil
+	}
+	for _, u := range from {
+		if u == unit {
+			return divisor, nil
+		}
+	}
+	return 0, fmt.Errorf("values in %s can not be converted to %s", unit, t.Unit)
+}
@@ -0,0 +1,54 @@
+package transform
+
+import "testing"
+
+func TestApplyTransform(t *testing.T) {
+	var tests = []struct {
+		transform Transform
+		value     float64
+		unit      string
+		want      float64
+	}{
+		{Transform{}, 12, "", 12},
+		{Transform{Multiplier: "0.5"}, 12, "", 6},
+		{Transform{Multiplier: "2", Offset: "-4"}, 12, "", 20},
+		{Transform{Unit: MiB}, 3 << 20, "Bytes", 3},
+		{Transform{Unit: KiB}, 2048, "BytesPerSecond", 2},
+		{Transform{Unit: GiB, Multiplier: "10"}, 1 << 29, "", 5},
+		{Transform{Unit: Seconds}, 250, "MilliSeconds", 0.25},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.transform.Apply(tt.value, tt.unit)
+		if err != nil {
+			t.Fatalf("%+v: error after processing got: %v, want nil", tt.transform, err)
+		}
+		if got != tt.want {
+			t.Errorf("%+v: Apply(%v, %q) = %v, want %v", tt.transform, tt.value, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestApplyTransformOfOtherUnitGetsError(t *testing.T) {
+	_, err := Transform{Unit: MiB}.Apply(250, "MilliSeconds")
+
+	if err == nil {
+		t.Errorf("error after processing got nil, want error")
+	}
+}
+
+func TestInvalidTransformsGetError(t *testing.T) {
+	var tests = []Transform{
+		{Multiplier: "half"},
+		{Offset: "1e"},
+		{Multiplier: "NaN"},
+		{Offset: "-Inf"},
+		{Unit: "MB"},
+	}
+
+	for _, transform := range tests {
+		if err := transform.Validate(); err == nil {
+			t.Errorf("Validate(%+v) got nil, want error", transform)
+		}
+	}
+}
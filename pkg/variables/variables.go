@@ -0,0 +1,166 @@
+// Package variables expands the template variables, such as {{ .NodeResourceGroup }}, that the
+// spec of an ExternalMetric can reference so specs targeting the resources of the cluster, or of
+// the environment named by a ConfigMap, can be applied unchanged to every cluster
+package variables
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Names of the variables
+const (
+	SubscriptionID       = "SubscriptionID"
+	Location             = "Location"
+	NodeResourceGroup    = "NodeResourceGroup"
+	ClusterResourceGroup = "ClusterResourceGroup"
+	ClusterName          = "ClusterName"
+	// Namespace and Name are those of the metric whose spec is expanded
+	Namespace = "Namespace"
+	Name      = "Name"
+)
+
+var configMapsResource = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+// Variables are the values of the variables by name.  Variables that are not known are left out
+// so referencing them fails rather than querying an empty resource group or name.
+type Variables map[string]string
+
+// Expand returns the value with the variables it references replaced
+func (v Variables) Expand(value string) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+
+	tmpl, err := template.New("value").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid template '%s': %v", value, err)
+	}
+
+	values := v
+	if values == nil {
+		values = Variables{}
+	}
+	expanded := bytes.Buffer{}
+	if err := tmpl.Execute(&expanded, map[string]string(values)); err != nil {
+		return "", fmt.Errorf("unable to expand '%s', the variable is not known: %v", value, err)
+	}
+	return expanded.String(), nil
+}
+
+// ForMetric returns the variables of the metric with the name in the namespace: the values, such
+// as those of the ConfigMap named by its spec, the variables of the cluster, and its Namespace and
+// Name.  Values can't replace the variables of the cluster or of the metric.
+func (v Variables) ForMetric(namespace string, name string, values map[string]string) Variables {
+	metricVariables := Variables{}
+	for key, value := range values {
+		metricVariables[key] = value
+	}
+	for key, value := range v {
+		metricVariables[key] = value
+	}
+	metricVariables[Namespace] = namespace
+	metricVariables[Name] = name
+	return metricVariables
+}
+
+// ConfigMapValues reads the data of the ConfigMap in the namespace that a spec takes variables
+// from.  A spec that names no ConfigMap has no values.
+func ConfigMapValues(client dynamic.Interface, namespace string, name string) (map[string]string, error) {
+	if name == "" {
+		return nil, nil
+	}
+	if client == nil {
+		return nil, fmt.Errorf("variables from config maps are not enabled")
+	}
+
+	configMap, err := client.Resource(configMapsResource).Namespace(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := unstructured.NestedStringMap(configMap.Object, "data")
+	if err != nil {
+		return nil, fmt.Errorf("config map %s is invalid: %v", name, err)
+	}
+	return data, nil
+}
+
+// ExpandSpec returns a copy of the spec with the variables referenced by any of its strings, such
+// as the resource group of the azure section, the resources it lists, their tags, the filter of the
+// metric or a query, replaced.  Strings that don't reference variables are left as they are.
+func (v Variables) ExpandSpec(spec api.ExternalMetricSpec) (api.ExternalMetricSpec, error) {
+	expanded := *spec.DeepCopy()
+	if err := v.expandValue(reflect.ValueOf(&expanded).Elem(), ""); err != nil {
+		return spec, err
+	}
+	return expanded, nil
+}
+
+// expandValue expands the strings of the value, and of the fields, elements and map values it holds.
+// The path names the value in errors, in the json names of the spec.
+func (v Variables) expandValue(value reflect.Value, path string) error {
+	switch value.Kind() {
+	case reflect.String:
+		expanded, err := v.Expand(value.String())
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		value.SetString(expanded)
+	case reflect.Ptr:
+		if !value.IsNil() {
+			return v.expandValue(value.Elem(), path)
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			if err := v.expandValue(value.Field(i), fieldPath(path, field)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			if err := v.expandValue(value.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			// map values can't be set in place, so each is expanded in a copy
+			element := reflect.New(value.Type().Elem()).Elem()
+			element.Set(value.MapIndex(key))
+			if err := v.expandValue(element, fmt.Sprintf("%s[%v]", path, key)); err != nil {
+				return err
+			}
+			value.SetMapIndex(key, element)
+		}
+	}
+	return nil
+}
+
+// fieldPath returns the path of the field of the struct at path, by its json name.  Inlined
+// fields, such as the spec of a source, keep the path of the struct.
+func fieldPath(path string, field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		if field.Anonymous {
+			return path
+		}
+		name = field.Name
+	}
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
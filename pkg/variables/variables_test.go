@@ -0,0 +1,155 @@
+package variables
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	api "github.com/Azure/azure-k8s-metrics-adapter/pkg/apis/metrics/v1alpha2"
+)
+
+func TestExpandSpecReplacesVariables(t *testing.T) {
+	variables := Variables{NodeResourceGroup: "MC_rg_aks_westus2", ClusterName: "aks"}
+	filter := "ClusterName eq '{{ .ClusterName }}'"
+	spec := api.ExternalMetricSpec{
+		AzureConfig:  api.AzureConfig{ResourceGroup: "{{ .NodeResourceGroup }}", ResourceName: "kubernetes"},
+		MetricConfig: api.ExternalMetricConfig{Filter: "ClusterName eq '{{.ClusterName}}'"},
+		Ratio:        &api.RatioConfig{Denominator: api.RatioDenominator{Filter: &filter}},
+		Sources: []api.WeightedSource{
+			{ExternalMetricSpec: api.ExternalMetricSpec{AzureConfig: api.AzureConfig{ResourceGroup: "{{ .NodeResourceGroup }}"}}},
+		},
+	}
+
+	expanded, err := variables.ExpandSpec(spec)
+
+	if err != nil {
+		t.Fatalf("ExpandSpec() error = %v, want nil", err)
+	}
+	if expanded.AzureConfig.ResourceGroup != "MC_rg_aks_westus2" || expanded.AzureConfig.ResourceName != "kubernetes" {
+		t.Errorf("azure = %+v, want the node resource group", expanded.AzureConfig)
+	}
+	if expanded.MetricConfig.Filter != "ClusterName eq 'aks'" || *expanded.Ratio.Denominator.Filter != "ClusterName eq 'aks'" {
+		t.Errorf("filters = %s and %s, want the cluster name", expanded.MetricConfig.Filter, *expanded.Ratio.Denominator.Filter)
+	}
+	if expanded.Sources[0].AzureConfig.ResourceGroup != "MC_rg_aks_westus2" {
+		t.Errorf("source resource group = %s, want the node resource group", expanded.Sources[0].AzureConfig.ResourceGroup)
+	}
+	if spec.AzureConfig.ResourceGroup != "{{ .NodeResourceGroup }}" || filter != "ClusterName eq '{{ .ClusterName }}'" {
+		t.Errorf("spec was modified")
+	}
+}
+
+func TestExpandSpecReplacesVariablesOfEveryString(t *testing.T) {
+	spec := api.ExternalMetricSpec{}
+	fillStrings(reflect.ValueOf(&spec).Elem(), "{{ .ClusterName }}", 0)
+
+	expanded, err := Variables{ClusterName: "aks"}.ExpandSpec(spec)
+
+	if err != nil {
+		t.Fatalf("ExpandSpec() error = %v, want nil", err)
+	}
+	strings := 0
+	walkStrings(reflect.ValueOf(expanded), "spec", func(path string, value string) {
+		strings++
+		if value != "aks" {
+			t.Errorf("%s = %q, want the variable expanded", path, value)
+		}
+	})
+	if strings < 100 {
+		t.Errorf("walked %d strings, want every string of the spec", strings)
+	}
+}
+
+func TestExpandSpecUnknownVariableNamesField(t *testing.T) {
+	spec := api.ExternalMetricSpec{
+		AzureConfig: api.AzureConfig{ResourceTags: map[string]string{"cluster": "{{ .ClusterName }}"}},
+	}
+
+	_, err := Variables{}.ExpandSpec(spec)
+
+	if err == nil || !strings.HasPrefix(err.Error(), "azure.resourceTags[cluster]:") {
+		t.Errorf("ExpandSpec() error = %v, want the error of azure.resourceTags[cluster]", err)
+	}
+}
+
+// fillStrings sets every string the value can hold to the template, allocating pointers and adding
+// an element to slices and maps, up to a depth so specs nested in sources end
+func fillStrings(value reflect.Value, template string, depth int) {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(template)
+	case reflect.Ptr, reflect.Slice, reflect.Map:
+		if depth < 4 {
+			fillReference(value, template, depth)
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if value.Type().Field(i).PkgPath == "" {
+				fillStrings(value.Field(i), template, depth)
+			}
+		}
+	}
+}
+
+func fillReference(value reflect.Value, template string, depth int) {
+	switch value.Kind() {
+	case reflect.Ptr:
+		value.Set(reflect.New(value.Type().Elem()))
+		fillStrings(value.Elem(), template, depth+1)
+	case reflect.Slice:
+		value.Set(reflect.MakeSlice(value.Type(), 1, 1))
+		fillStrings(value.Index(0), template, depth+1)
+	case reflect.Map:
+		value.Set(reflect.MakeMap(value.Type()))
+		key := reflect.New(value.Type().Key()).Elem()
+		element := reflect.New(value.Type().Elem()).Elem()
+		fillStrings(key, "key", depth+1)
+		fillStrings(element, template, depth+1)
+		value.SetMapIndex(key, element)
+	}
+}
+
+// walkStrings visits every string the value holds, except map keys
+func walkStrings(value reflect.Value, path string, visit func(path string, value string)) {
+	switch value.Kind() {
+	case reflect.String:
+		visit(path, value.String())
+	case reflect.Ptr:
+		if !value.IsNil() {
+			walkStrings(value.Elem(), path, visit)
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			if field := value.Type().Field(i); field.PkgPath == "" {
+				walkStrings(value.Field(i), path+"."+field.Name, visit)
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			walkStrings(value.Index(i), fmt.Sprintf("%s[%d]", path, i), visit)
+		}
+	case reflect.Map:
+		for _, key := range value.MapKeys() {
+			walkStrings(value.MapIndex(key), fmt.Sprintf("%s[%v]", path, key), visit)
+		}
+	}
+}
+
+func TestExpandUnknownVariableFails(t *testing.T) {
+	var tests = []struct {
+		name      string
+		variables Variables
+		value     string
+	}{
+		{"not detected", Variables{ClusterName: "aks"}, "{{ .NodeResourceGroup }}"},
+		{"no variables", nil, "{{ .ClusterName }}"},
+		{"invalid template", Variables{ClusterName: "aks"}, "{{ .ClusterName"},
+	}
+
+	for _, tt := range tests {
+		if _, err := tt.variables.Expand(tt.value); err == nil {
+			t.Errorf("%s: Expand() error = nil, want error", tt.name)
+		}
+	}
+}